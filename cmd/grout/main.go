@@ -1,37 +1,178 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/hashicorp/golang-lru/v2"
 
 	"grout/internal/config"
 	"grout/internal/handlers"
+	"grout/internal/kvstore"
 	"grout/internal/middleware"
 	"grout/internal/render"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "warm-avatars" {
+		warmAvatars(os.Args[2:])
+		return
+	}
+
 	cfg := config.LoadServerConfig()
+	svc := newService(&cfg)
+	defer cfg.Store.Close()
+
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimitRPM, cfg.RateLimitBurst)
+	rateLimiter.SetPrefixes(cfg.RateLimitIPv4Prefix, cfg.RateLimitIPv6Prefix)
+
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, rateLimiter)
+	if cfg.IPListsFile != "" {
+		go watchFile(cfg.IPListsFile, "IP lists", svc.ReloadIPLists)
+	}
+	if cfg.ColorMapFile != "" {
+		go watchFile(cfg.ColorMapFile, "color map", svc.ReloadColorMap)
+	}
+	if cfg.MeteringDir != "" || cfg.MeteringWebhook != "" {
+		go exportUsage(svc, cfg.MeteringInterval)
+	}
+	if cfg.TelemetryURL != "" {
+		log.Printf("telemetry is on: reporting anonymous request counts to %s every %s", cfg.TelemetryURL, cfg.TelemetryInterval)
+		go sendTelemetry(svc, cfg.TelemetryInterval)
+	}
+	if cfg.WarmupAvatars {
+		log.Printf("warmed the cache with %d avatars", svc.WarmAvatars())
+	}
+	if cfg.ChaosTesting {
+		log.Printf("chaos testing is on: image requests can inject latency and failures with x-chaos")
+	}
 
+	fmt.Printf("Grout running on %s (rate limit: %d req/min, burst: %d)\n", cfg.Addr, cfg.RateLimitRPM, cfg.RateLimitBurst)
+	log.Fatal(http.ListenAndServe(cfg.Addr, mux))
+}
+
+// newService loads the files the config names, opening its store, and wires
+// a service from them. It exits on any error.
+func newService(cfg *config.ServerConfig) *handlers.Service {
 	renderer, err := render.New()
 	if err != nil {
 		log.Fatalf("init renderer: %v", err)
 	}
+	if err := render.UseWebPBackend(cfg.WebPBackend); err != nil {
+		log.Fatalf("init renderer: %v", err)
+	}
+
+	cfg.Brand, err = config.LoadBrand(cfg.BrandFile)
+	if err != nil {
+		log.Fatalf("load brand: %v", err)
+	}
+	if cfg.Brand.Font != "" && !renderer.HasFont(cfg.Brand.Font) {
+		log.Fatalf("load brand: unknown font %q", cfg.Brand.Font)
+	}
+
+	cfg.Tenants, err = config.LoadTenants(cfg.TenantsFile)
+	if err != nil {
+		log.Fatalf("load tenants: %v", err)
+	}
+	for id, tenant := range cfg.Tenants {
+		if tenant.Font != "" && !renderer.HasFont(tenant.Font) {
+			log.Fatalf("load tenants: tenant %q: unknown font %q", id, tenant.Font)
+		}
+	}
+
+	cfg.APIKeys, err = config.LoadAPIKeys(cfg.APIKeysFile)
+	if err != nil {
+		log.Fatalf("load API keys: %v", err)
+	}
+
+	cfg.Templates, err = config.LoadTemplates(cfg.TemplatesFile)
+	if err != nil {
+		log.Fatalf("load templates: %v", err)
+	}
+
+	cfg.Deprecations, err = config.LoadDeprecations(cfg.DeprecationsFile)
+	if err != nil {
+		log.Fatalf("load deprecations: %v", err)
+	}
+
+	cfg.IPLists, err = config.LoadIPLists(cfg.IPListsFile)
+	if err != nil {
+		log.Fatalf("load IP lists: %v", err)
+	}
+
+	cfg.ColorMap, err = config.LoadColorMap(cfg.ColorMapFile)
+	if err != nil {
+		log.Fatalf("load color map: %v", err)
+	}
+
+	cfg.Store, err = kvstore.Open(cfg.StoreURL)
+	if err != nil {
+		log.Fatalf("open store: %v", err)
+	}
 
 	cache, err := lru.New[string, []byte](cfg.CacheSize)
 	if err != nil {
 		log.Fatalf("init cache: %v", err)
 	}
 
-	rateLimiter := middleware.NewRateLimiter(cfg.RateLimitRPM, cfg.RateLimitBurst)
+	svc := handlers.NewService(renderer, cache, *cfg)
+	if err := svc.CheckDeprecations(); err != nil {
+		log.Fatalf("load deprecations: %v", err)
+	}
+	return svc
+}
 
-	svc := handlers.NewService(renderer, cache, cfg)
-	mux := http.NewServeMux()
-	svc.RegisterRoutes(mux, rateLimiter)
+// exportUsage exports the usage records of each metering interval. Batches
+// that fail are logged, and retried with the next export.
+func exportUsage(svc *handlers.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	fmt.Printf("Grout running on %s (rate limit: %d req/min, burst: %d)\n", cfg.Addr, cfg.RateLimitRPM, cfg.RateLimitBurst)
-	log.Fatal(http.ListenAndServe(cfg.Addr, mux))
+	for range ticker.C {
+		if err := svc.ExportUsage(context.Background()); err != nil {
+			log.Printf("export usage: %v", err)
+		}
+	}
+}
+
+// sendTelemetry reports the telemetry counters every interval. Reports that
+// fail are logged, and their counts carried into the next one.
+func sendTelemetry(svc *handlers.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := svc.SendTelemetry(context.Background()); err != nil {
+			log.Printf("send telemetry: %v", err)
+		}
+	}
+}
+
+// watchFile calls reload whenever the file at path changes. A file that fails
+// to load is logged, and what was last loaded stays in force.
+func watchFile(path, what string, reload func() error) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+	ticker := time.NewTicker(config.FileReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(lastMod) {
+			continue
+		}
+		lastMod = info.ModTime()
+		if err := reload(); err != nil {
+			log.Printf("reload %s: %v", what, err)
+			continue
+		}
+		log.Printf("reloaded %s from %s", what, path)
+	}
 }
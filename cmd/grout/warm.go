@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"grout/internal/config"
+	"grout/internal/handlers"
+)
+
+// warmAvatars runs `grout warm-avatars`: it renders the avatars of the users in
+// a CSV, and writes them as a cache snapshot for the admin cache import API, or
+// as image files to serve or upload elsewhere. The server's own flags and env
+// vars apply, so avatars come out as the server would draw them.
+func warmAvatars(args []string) {
+	csvPath := flag.String("csv", "", "CSV file of users, with a name column and optionally a uid column")
+	sizes := flag.String("sizes", "", "Comma-separated avatar sizes, such as 64,128 (default 128)")
+	format := flag.String("format", "", "Avatar format: svg, png, jpg, gif, or webp (default svg)")
+	query := flag.String("query", "", "Other avatar parameters apps request, as a query string such as bg=random&rounded=true")
+	host := flag.String("host", "", "Host of the tenant whose theme and cache the avatars use")
+	snapshot := flag.String("snapshot", "", "Write the avatars to this cache snapshot file, for POST /api/v1/admin/cache/import")
+	outDir := flag.String("out", "", "Write the avatars to this directory as image files")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: grout warm-avatars -csv users.csv [-sizes 64,128] [-format webp] (-snapshot file | -out dir)\n")
+		flag.PrintDefaults()
+	}
+	// ExitOnError: a bad flag prints the usage and exits
+	_ = flag.CommandLine.Parse(args)
+
+	if *csvPath == "" || (*snapshot == "" && *outDir == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+	warmup, err := handlers.NewAvatarWarmup(*sizes, *format, *query, *host)
+	if err != nil {
+		log.Fatalf("warm avatars: %v", err)
+	}
+	file, err := os.Open(*csvPath)
+	if err != nil {
+		log.Fatalf("warm avatars: %v", err)
+	}
+	users, err := handlers.ReadAvatarUsers(file)
+	file.Close()
+	if err != nil {
+		log.Fatalf("warm avatars: %s: %v", *csvPath, err)
+	}
+
+	cfg := config.LoadServerConfig()
+	// The snapshot is taken from the cache, so it must hold every avatar
+	cfg.CacheSize = max(cfg.CacheSize, len(users)*len(warmup.Sizes))
+	svc := newService(&cfg)
+	defer cfg.Store.Close()
+
+	var each func(handlers.AvatarUser, int, []byte) error
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			log.Fatalf("warm avatars: %v", err)
+		}
+		each = func(user handlers.AvatarUser, size int, data []byte) error {
+			return os.WriteFile(filepath.Join(*outDir, avatarFileName(user, size, string(warmup.Format))), data, 0o644)
+		}
+	}
+	report, err := svc.WarmUserAvatars(context.Background(), users, warmup, each)
+	if err != nil {
+		log.Fatalf("warm avatars: %v", err)
+	}
+
+	if *snapshot != "" {
+		out, err := os.Create(*snapshot)
+		if err != nil {
+			log.Fatalf("warm avatars: %v", err)
+		}
+		if err := svc.WriteCacheSnapshot(out, 0); err != nil {
+			log.Fatalf("warm avatars: write snapshot: %v", err)
+		}
+		if err := out.Close(); err != nil {
+			log.Fatalf("warm avatars: write snapshot: %v", err)
+		}
+	}
+
+	log.Printf("rendered %d avatars of %d users, %d failed", report.Rendered, report.Users, report.Failed)
+	for _, f := range report.Failures {
+		log.Printf("row %d, size %d: %s", f.Row, f.Size, f.Code)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// avatarFileName names the exported avatar of a user in a size: the uid, or
+// else the name, escaped so it can't leave the directory, then the size.
+func avatarFileName(user handlers.AvatarUser, size int, ext string) string {
+	id := user.UID
+	if id == "" {
+		id = user.Name
+	}
+	return url.PathEscape(id) + "-" + strconv.Itoa(size) + "." + ext
+}
@@ -0,0 +1,334 @@
+// Package cache provides the rendered-image caches used by the HTTP handlers,
+// with the counters reported by the admin stats API.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// Stats is a snapshot of a cache's usage.
+type Stats struct {
+	Entries    int    `json:"entries"`
+	Bytes      int64  `json:"bytes"`
+	QuotaBytes int64  `json:"quota_bytes,omitempty"`
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
+	Evictions  uint64 `json:"evictions"`
+	// SavedBytes counts the bytes of entries sharing their value with another
+	// entry, which a deduplicating partition holds once
+	SavedBytes int64 `json:"saved_bytes,omitempty"`
+}
+
+// Entry is a cached value and the key it's stored under.
+type Entry struct {
+	Key   string
+	Value []byte
+}
+
+// recent returns up to limit of the most recently used entries of l, least
+// recently used first, without counting them as used. A limit of 0 or less
+// returns them all.
+func recent(l *lru.Cache[string, []byte], limit int) []Entry {
+	keys := l.Keys()
+	if limit > 0 && len(keys) > limit {
+		keys = keys[len(keys)-limit:]
+	}
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		// Keys evicted since the listing are skipped
+		if value, ok := l.Peek(key); ok {
+			entries = append(entries, Entry{Key: key, Value: value})
+		}
+	}
+	return entries
+}
+
+// Shared adapts an existing LRU cache, counting hits and misses. The byte total
+// is summed on demand since the cache has no eviction hook to track it.
+type Shared struct {
+	lru    *lru.Cache[string, []byte]
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewShared wraps l.
+func NewShared(l *lru.Cache[string, []byte]) *Shared {
+	return &Shared{lru: l}
+}
+
+// Get returns the cached value for key.
+func (s *Shared) Get(key string) ([]byte, bool) {
+	value, ok := s.lru.Get(key)
+	if ok {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+	return value, ok
+}
+
+// Add caches value under key, reporting whether an entry was evicted.
+func (s *Shared) Add(key string, value []byte) bool {
+	return s.lru.Add(key, value)
+}
+
+// Entries returns up to limit of the most recently used entries, least recently
+// used first.
+func (s *Shared) Entries(limit int) []Entry {
+	return recent(s.lru, limit)
+}
+
+// Stats returns the cache's usage.
+func (s *Shared) Stats() Stats {
+	var bytes int64
+	for _, value := range s.lru.Values() {
+		bytes += int64(len(value))
+	}
+	return Stats{Entries: s.lru.Len(), Bytes: bytes, Hits: s.hits.Load(), Misses: s.misses.Load()}
+}
+
+// Partition is an LRU cache bounded by entry count and, optionally, by the total
+// size of its values. Each tenant renders into its own partition so one tenant
+// can't evict another's images.
+type Partition struct {
+	mu       sync.Mutex
+	lru      *lru.Cache[string, []byte]
+	maxBytes int64 // 0 means no byte quota
+	// bytes counts the values held, each shared value once; entryBytes counts
+	// them per entry
+	bytes      int64
+	entryBytes int64
+	hits       uint64
+	misses     uint64
+	evictions  uint64
+	// blobs holds each distinct value by its SHA-256 when deduplicating, for
+	// the entries of the same bytes to share; digests maps keys to theirs
+	blobs   map[[sha256.Size]byte]*blob
+	digests map[string][sha256.Size]byte
+}
+
+// blob is a value shared by refs entries of a deduplicating partition.
+type blob struct {
+	value []byte
+	refs  int
+}
+
+// NewPartition creates a partition holding at most maxEntries values and, when
+// maxBytes is positive, at most maxBytes bytes. When dedup is set, values cached
+// under more than one key, such as images requested with parameters spelled
+// differently, are held once and count once against the quota.
+func NewPartition(maxEntries int, maxBytes int64, dedup bool) (*Partition, error) {
+	p := &Partition{maxBytes: maxBytes}
+	if dedup {
+		p.blobs = make(map[[sha256.Size]byte]*blob)
+		p.digests = make(map[string][sha256.Size]byte)
+	}
+	l, err := lru.NewWithEvict(maxEntries, func(key string, value []byte) {
+		// Called from Add and RemoveOldest, with p.mu held
+		p.release(key, value)
+		p.evictions++
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.lru = l
+	return p, nil
+}
+
+// hold counts value as cached under key, returning the copy to cache: the
+// identical value already held, when deduplicating, or value itself. digest
+// is the SHA-256 of value when deduplicating.
+func (p *Partition) hold(key string, value []byte, digest [sha256.Size]byte) []byte {
+	size := int64(len(value))
+	p.entryBytes += size
+	if p.blobs == nil {
+		p.bytes += size
+		return value
+	}
+	b, ok := p.blobs[digest]
+	if !ok {
+		b = &blob{value: value}
+		p.blobs[digest] = b
+		p.bytes += size
+	}
+	b.refs++
+	p.digests[key] = digest
+	return b.value
+}
+
+// release uncounts the value cached under key, as it's evicted or replaced.
+func (p *Partition) release(key string, value []byte) {
+	size := int64(len(value))
+	p.entryBytes -= size
+	if p.blobs == nil {
+		p.bytes -= size
+		return
+	}
+	digest := p.digests[key]
+	delete(p.digests, key)
+	if b := p.blobs[digest]; b != nil {
+		if b.refs--; b.refs == 0 {
+			delete(p.blobs, digest)
+			p.bytes -= size
+		}
+	}
+}
+
+// Get returns the cached value for key.
+func (p *Partition) Get(key string) ([]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	value, ok := p.lru.Get(key)
+	if ok {
+		p.hits++
+	} else {
+		p.misses++
+	}
+	return value, ok
+}
+
+// Add caches value under key, evicting the least recently used entries until the
+// partition is back under its quota. Values larger than the whole quota are not
+// cached. It reports whether anything was evicted.
+func (p *Partition) Add(key string, value []byte) bool {
+	if p.maxBytes > 0 && int64(len(value)) > p.maxBytes {
+		return false
+	}
+	// Hashed before taking the lock, so other requests aren't held up
+	var digest [sha256.Size]byte
+	if p.blobs != nil {
+		digest = sha256.Sum256(value)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Replacing a key doesn't go through the eviction hook
+	if old, ok := p.lru.Peek(key); ok {
+		p.release(key, old)
+	}
+	before := p.evictions
+	p.lru.Add(key, p.hold(key, value, digest))
+
+	for p.maxBytes > 0 && p.bytes > p.maxBytes {
+		if _, _, ok := p.lru.RemoveOldest(); !ok {
+			break
+		}
+	}
+	return p.evictions > before
+}
+
+// Entries returns up to limit of the most recently used entries, least recently
+// used first.
+func (p *Partition) Entries(limit int) []Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return recent(p.lru, limit)
+}
+
+// Stats returns the partition's usage.
+func (p *Partition) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return Stats{
+		Entries:    p.lru.Len(),
+		Bytes:      p.bytes,
+		QuotaBytes: p.maxBytes,
+		SavedBytes: p.entryBytes - p.bytes,
+		Hits:       p.hits,
+		Misses:     p.misses,
+		Evictions:  p.evictions,
+	}
+}
+
+// Cache is a rendered-image cache.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Add(key string, value []byte) bool
+	Entries(limit int) []Entry
+	Stats() Stats
+}
+
+// Hashed stores values in another cache under the SHA-256 digest of their key.
+// Keys can embed a long quote or text, and the digest keeps their size in the LRU
+// index fixed; distinct keys only share an entry if SHA-256 collides.
+type Hashed struct {
+	inner Cache
+	// keys maps recent digests back to their keys for debugging; nil when disabled
+	keys *lru.Cache[string, string]
+}
+
+// NewHashed wraps inner. When debugKeys is positive the original keys of the
+// debugKeys most recently added entries are kept for Keys.
+func NewHashed(inner Cache, debugKeys int) *Hashed {
+	h := &Hashed{inner: inner}
+	if debugKeys > 0 {
+		h.keys, _ = lru.New[string, string](debugKeys)
+	}
+	return h
+}
+
+// HashKey returns the digest a key is stored under.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return string(sum[:])
+}
+
+// Get returns the cached value for key.
+func (h *Hashed) Get(key string) ([]byte, bool) {
+	return h.inner.Get(HashKey(key))
+}
+
+// Add caches value under the digest of key, reporting whether an entry was evicted.
+func (h *Hashed) Add(key string, value []byte) bool {
+	digest := HashKey(key)
+	if h.keys != nil {
+		h.keys.Add(digest, key)
+	}
+	return h.inner.Add(digest, value)
+}
+
+// Entries returns up to limit of the most recently used entries, least recently
+// used first, keyed by digest.
+func (h *Hashed) Entries(limit int) []Entry {
+	return h.inner.Entries(limit)
+}
+
+// Restore caches an entry returned by Entries, possibly of another Hashed
+// cache, under its digest. Keys that aren't digests are not cached. It reports
+// whether the entry was cached.
+func (h *Hashed) Restore(e Entry) bool {
+	if len(e.Key) != sha256.Size {
+		return false
+	}
+	h.inner.Add(e.Key, e.Value)
+	return true
+}
+
+// Stats returns the wrapped cache's usage.
+func (h *Hashed) Stats() Stats {
+	return h.inner.Stats()
+}
+
+// Keys returns the recently added keys by hex digest, or nil when debug keys are
+// disabled. Entries may since have been evicted from the cache itself.
+func (h *Hashed) Keys() map[string]string {
+	if h.keys == nil {
+		return nil
+	}
+	keys := make(map[string]string, h.keys.Len())
+	for _, digest := range h.keys.Keys() {
+		if key, ok := h.keys.Peek(digest); ok {
+			keys[hex.EncodeToString([]byte(digest))] = key
+		}
+	}
+	return keys
+}
@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestPartitionByteQuota(t *testing.T) {
+	p, err := NewPartition(100, 10, false)
+	if err != nil {
+		t.Fatalf("new partition: %v", err)
+	}
+
+	p.Add("a", []byte("aaaa"))
+	p.Add("b", []byte("bbbb"))
+	if evicted := p.Add("c", []byte("cccc")); !evicted {
+		t.Fatal("expected adding past the quota to evict")
+	}
+
+	if _, ok := p.Get("a"); ok {
+		t.Error("expected the oldest entry to be evicted")
+	}
+	if value, ok := p.Get("c"); !ok || !bytes.Equal(value, []byte("cccc")) {
+		t.Error("expected the newest entry to be cached")
+	}
+
+	stats := p.Stats()
+	want := Stats{Entries: 2, Bytes: 8, QuotaBytes: 10, Hits: 1, Misses: 1, Evictions: 1}
+	if stats != want {
+		t.Fatalf("expected %+v got %+v", want, stats)
+	}
+}
+
+func TestPartitionReplaceAndOversize(t *testing.T) {
+	p, err := NewPartition(100, 10, false)
+	if err != nil {
+		t.Fatalf("new partition: %v", err)
+	}
+
+	p.Add("a", []byte("aaaa"))
+	p.Add("a", []byte("aa"))
+	if stats := p.Stats(); stats.Bytes != 2 || stats.Entries != 1 {
+		t.Fatalf("expected replacing a key to update its size, got %+v", stats)
+	}
+
+	p.Add("big", make([]byte, 11))
+	if _, ok := p.Get("big"); ok {
+		t.Error("expected values over the whole quota to be skipped")
+	}
+	if _, ok := p.Get("a"); !ok {
+		t.Error("expected a skipped value not to evict anything")
+	}
+}
+
+func TestPartitionDedup(t *testing.T) {
+	p, err := NewPartition(100, 10, true)
+	if err != nil {
+		t.Fatalf("new partition: %v", err)
+	}
+
+	// Identical values count once against the quota
+	for _, key := range []string{"a", "b", "c"} {
+		if evicted := p.Add(key, []byte("shared")); evicted {
+			t.Fatalf("expected adding %s to evict nothing", key)
+		}
+	}
+	a, _ := p.Get("a")
+	c, _ := p.Get("c")
+	if &a[0] != &c[0] {
+		t.Error("expected identical values to share their bytes")
+	}
+	if stats := p.Stats(); stats.Entries != 3 || stats.Bytes != 6 || stats.SavedBytes != 12 {
+		t.Fatalf("expected 3 entries holding 6 bytes, got %+v", stats)
+	}
+
+	// The shared value is held until its last entry goes
+	p.Add("a", []byte("own"))
+	p.Add("b", []byte("own"))
+	if stats := p.Stats(); stats.Bytes != 9 || stats.SavedBytes != 3 {
+		t.Fatalf("expected 9 bytes held after replacing, got %+v", stats)
+	}
+	if value, ok := p.Get("c"); !ok || string(value) != "shared" {
+		t.Errorf("expected c to keep the shared value, got %q", value)
+	}
+
+	// Evicting a only frees its value once b, which shares it, goes too
+	if evicted := p.Add("d", []byte("four")); !evicted {
+		t.Fatal("expected adding past the quota to evict")
+	}
+	if stats := p.Stats(); stats.Entries != 2 || stats.Bytes != 10 || stats.SavedBytes != 0 || stats.Evictions != 2 {
+		t.Fatalf("expected a and b to be evicted, got %+v", stats)
+	}
+	if len(p.blobs) != 2 || len(p.digests) != 2 {
+		t.Errorf("expected 2 values for 2 keys, got %d and %d", len(p.blobs), len(p.digests))
+	}
+}
+
+func TestPartitionEntryLimit(t *testing.T) {
+	p, err := NewPartition(2, 0, false)
+	if err != nil {
+		t.Fatalf("new partition: %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		p.Add(key, []byte(key))
+	}
+	if stats := p.Stats(); stats.Entries != 2 || stats.Bytes != 2 || stats.Evictions != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSharedStats(t *testing.T) {
+	l, _ := lru.New[string, []byte](10)
+	s := NewShared(l)
+
+	s.Add("a", []byte("abc"))
+	s.Get("a")
+	s.Get("missing")
+
+	want := Stats{Entries: 1, Bytes: 3, Hits: 1, Misses: 1}
+	if stats := s.Stats(); stats != want {
+		t.Fatalf("expected %+v got %+v", want, stats)
+	}
+}
+
+func TestHashedKeys(t *testing.T) {
+	l, _ := lru.New[string, []byte](10)
+	h := NewHashed(NewShared(l), 2)
+	long := strings.Repeat("quote ", 1000)
+	h.Add(long, []byte("abc"))
+	if value, ok := h.Get(long); !ok || !bytes.Equal(value, []byte("abc")) {
+		t.Fatal("expected a hashed key to round-trip")
+	}
+	if _, ok := l.Get(long); ok {
+		t.Error("expected the inner cache not to store the full key")
+	}
+	if stored := l.Keys()[0]; len(stored) != 32 {
+		t.Errorf("expected a 32-byte digest, got %d bytes", len(stored))
+	}
+
+	h.Add("b", []byte("b"))
+	h.Add("c", []byte("c"))
+	keys := h.Keys()
+	if len(keys) != 2 || keys[hex.EncodeToString([]byte(HashKey("c")))] != "c" {
+		t.Fatalf("expected the two most recent keys, got %v", keys)
+	}
+
+	if NewHashed(NewShared(l), 0).Keys() != nil {
+		t.Error("expected no debug keys when disabled")
+	}
+}
+
+func TestEntriesMostRecent(t *testing.T) {
+	p, err := NewPartition(10, 0, false)
+	if err != nil {
+		t.Fatalf("new partition: %v", err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		p.Add(key, []byte(key))
+	}
+	p.Get("a")
+
+	keys := func(entries []Entry) string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Key)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := keys(p.Entries(0)); got != "b,c,a" {
+		t.Errorf("expected every entry, least recently used first, got %s", got)
+	}
+	if got := keys(p.Entries(2)); got != "c,a" {
+		t.Errorf("expected the 2 most recently used entries, got %s", got)
+	}
+	if stats := p.Stats(); stats.Hits != 1 {
+		t.Errorf("expected listing entries not to count as hits, got %+v", stats)
+	}
+}
+
+func TestHashedRestore(t *testing.T) {
+	l, _ := lru.New[string, []byte](10)
+	src := NewHashed(NewShared(l), 0)
+	src.Add("/avatar/JD", []byte("png"))
+
+	p, _ := NewPartition(10, 0, false)
+	dst := NewHashed(p, 0)
+	for _, e := range src.Entries(0) {
+		if !dst.Restore(e) {
+			t.Fatalf("expected %x to be restored", e.Key)
+		}
+	}
+	if value, ok := dst.Get("/avatar/JD"); !ok || string(value) != "png" {
+		t.Error("expected a restored entry to be found by its key")
+	}
+	if dst.Restore(Entry{Key: "/avatar/JD", Value: []byte("png")}) {
+		t.Error("expected keys that aren't digests to be rejected")
+	}
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// snapshotMagic starts every snapshot; its last byte is the format version.
+const snapshotMagic = "GROUTCACHE\x01"
+
+// maxSnapshotField caps the length a snapshot may declare for a partition name,
+// key, or value, so a corrupt length can't allocate without bound.
+const maxSnapshotField = 64 << 20
+
+// SnapshotWriter writes cache entries to a snapshot, a stream of records of a
+// partition name, key, and value, each prefixed with its length as a uvarint.
+type SnapshotWriter struct {
+	w *bufio.Writer
+}
+
+// NewSnapshotWriter starts a snapshot on w.
+func NewSnapshotWriter(w io.Writer) (*SnapshotWriter, error) {
+	sw := &SnapshotWriter{w: bufio.NewWriter(w)}
+	if _, err := sw.w.WriteString(snapshotMagic); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// Write adds an entry of the named partition to the snapshot.
+func (sw *SnapshotWriter) Write(partition string, e Entry) error {
+	for _, field := range [][]byte{[]byte(partition), []byte(e.Key), e.Value} {
+		if err := sw.writeField(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sw *SnapshotWriter) writeField(field []byte) error {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(field)))
+	if _, err := sw.w.Write(size[:n]); err != nil {
+		return err
+	}
+	_, err := sw.w.Write(field)
+	return err
+}
+
+// Flush writes any buffered entries to the underlying writer.
+func (sw *SnapshotWriter) Flush() error {
+	return sw.w.Flush()
+}
+
+// ReadSnapshot calls fn with each entry of a snapshot written by
+// SnapshotWriter, in the order they were written. It stops at the first error,
+// from the snapshot or from fn.
+func ReadSnapshot(r io.Reader, fn func(partition string, e Entry) error) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != snapshotMagic {
+		return errors.New("cache: not a cache snapshot")
+	}
+	for {
+		partition, err := readField(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		key, err := readField(br)
+		if err != nil {
+			return truncated(err)
+		}
+		value, err := readField(br)
+		if err != nil {
+			return truncated(err)
+		}
+		if err := fn(string(partition), Entry{Key: string(key), Value: value}); err != nil {
+			return err
+		}
+	}
+}
+
+// readField reads a length-prefixed field, returning io.EOF only when the
+// snapshot ends before it.
+func readField(br *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSnapshotField {
+		return nil, fmt.Errorf("cache: snapshot field of %d bytes exceeds the limit", size)
+	}
+	field := make([]byte, size)
+	if _, err := io.ReadFull(br, field); err != nil {
+		return nil, truncated(err)
+	}
+	return field, nil
+}
+
+// truncated reports an unexpected end of a snapshot as an error of its own.
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New("cache: truncated snapshot")
+	}
+	return err
+}
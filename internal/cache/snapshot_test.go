@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	type record struct {
+		Partition string
+		Entry     Entry
+	}
+	want := []record{
+		{"default", Entry{Key: "a", Value: []byte("first")}},
+		{"acme", Entry{Key: "b", Value: bytes.Repeat([]byte{0xff}, 300)}},
+		{"default", Entry{Key: "c", Value: []byte{}}},
+	}
+
+	var buf bytes.Buffer
+	sw, err := NewSnapshotWriter(&buf)
+	if err != nil {
+		t.Fatalf("new snapshot writer: %v", err)
+	}
+	for _, rec := range want {
+		if err := sw.Write(rec.Partition, rec.Entry); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	var got []record
+	err = ReadSnapshot(bytes.NewReader(buf.Bytes()), func(partition string, e Entry) error {
+		got = append(got, record{partition, e})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Cut off before the length of the last value
+	err = ReadSnapshot(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), func(string, Entry) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("expected a truncated snapshot error, got %v", err)
+	}
+}
+
+func TestReadSnapshotErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", "not a cache snapshot"},
+		{"wrong magic", "PNG\x00\x00\x00\x00\x00\x00\x00\x00", "not a cache snapshot"},
+		{"truncated record", snapshotMagic + "\x07default\x01", "truncated"},
+		{"oversized field", snapshotMagic + "\xff\xff\xff\xff\x0f", "exceeds the limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ReadSnapshot(strings.NewReader(tt.data), func(string, Entry) error { return nil })
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIKey is a client of a hosted server, identified by the secret it sends in
+// the X-API-Key header, with its own monthly render quota.
+type APIKey struct {
+	Key string `yaml:"key"`
+	// RenderQuota caps the image requests made with the key each calendar month,
+	// in UTC; 0 means no cap. RenderQuotaSoft only warns once it's passed.
+	RenderQuota     int `yaml:"render_quota"`
+	RenderQuotaSoft int `yaml:"render_quota_soft"`
+}
+
+// apiKeysFile is the layout of the API keys YAML file.
+type apiKeysFile struct {
+	Keys map[string]APIKey `yaml:"keys"`
+}
+
+// LoadAPIKeys reads and validates the API keys from a YAML file, keyed by the
+// name usage is reported under. An empty path means no keys.
+func LoadAPIKeys(path string) (map[string]APIKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read API keys file: %w", err)
+	}
+	var file apiKeysFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse API keys file: %w", err)
+	}
+
+	seen := make(map[string]string)
+	for name, k := range file.Keys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("API key %q: no key", name)
+		}
+		if other, ok := seen[k.Key]; ok {
+			return nil, fmt.Errorf("API key %q: key is already used by %q", name, other)
+		}
+		seen[k.Key] = name
+		if k.RenderQuota < 0 || k.RenderQuotaSoft < 0 {
+			return nil, fmt.Errorf("API key %q: render quotas must not be negative", name)
+		}
+		if k.RenderQuota > 0 && k.RenderQuotaSoft > k.RenderQuota {
+			return nil, fmt.Errorf("API key %q: render_quota_soft %d is over render_quota %d", name, k.RenderQuotaSoft, k.RenderQuota)
+		}
+	}
+
+	return file.Keys, nil
+}
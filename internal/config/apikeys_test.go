@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadAPIKeys(t *testing.T) {
+	keys, err := LoadAPIKeys(writeTenantsFile(t, `
+keys:
+  acme-free:
+    key: s3cret
+    render_quota: 1000
+    render_quota_soft: 800
+`))
+	if err != nil {
+		t.Fatalf("load API keys: %v", err)
+	}
+	if k := keys["acme-free"]; k.Key != "s3cret" || k.RenderQuota != 1000 || k.RenderQuotaSoft != 800 {
+		t.Errorf("unexpected API key: %+v", k)
+	}
+
+	if keys, err := LoadAPIKeys(""); err != nil || keys != nil {
+		t.Errorf("expected no keys for an empty path, got %v, %v", keys, err)
+	}
+}
+
+func TestLoadAPIKeysInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		expect string
+	}{
+		{"no key", "keys:\n  a:\n    render_quota: 10\n", "no key"},
+		{"shared key", "keys:\n  a:\n    key: k\n  b:\n    key: k\n", "already used"},
+		{"negative quota", "keys:\n  a:\n    key: k\n    render_quota: -1\n", "render quotas"},
+		{"soft quota over hard", "keys:\n  a:\n    key: k\n    render_quota: 100\n    render_quota_soft: 200\n", "render_quota_soft"},
+		{"bad yaml", "keys: [", "parse API keys file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadAPIKeys(writeTenantsFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // Register the GIF decoder for brand logos
+	_ "image/jpeg" // Register the JPEG decoder for brand logos
+	_ "image/png"  // Register the PNG decoder for brand logos
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Brand is the brand kit applied to every generated image: the server-wide
+// defaults derive from it, and tenants override it field by field. Empty fields
+// leave the built-in defaults alone.
+type Brand struct {
+	// Name replaces BrandName
+	Name string `yaml:"name"`
+	// Logo is a PNG, JPEG, or GIF file drawn by "logo" layout nodes and on
+	// certificates
+	Logo string `yaml:"logo"`
+	// PrimaryColor (hex, no '#') replaces BrandColor and is the default avatar
+	// and placeholder background
+	PrimaryColor string `yaml:"primary_color"`
+	// SecondaryColor is the default accent: calendar headers, rating stars,
+	// dividers, and the certificate and ticket trim
+	SecondaryColor string `yaml:"secondary_color"`
+	// Font is the default font for /text/ and layout text
+	Font string `yaml:"font"`
+
+	// LogoImage is the decoded Logo, nil when there is none
+	LogoImage image.Image `yaml:"-"`
+}
+
+// brandFile is the layout of the brand YAML file.
+type brandFile struct {
+	Brand Brand `yaml:"brand"`
+}
+
+// LoadBrand reads and validates the brand kit from a YAML file, decoding its
+// logo. An empty path means no brand kit.
+func LoadBrand(path string) (Brand, error) {
+	if path == "" {
+		return Brand{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Brand{}, fmt.Errorf("read brand file: %w", err)
+	}
+	var file brandFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return Brand{}, fmt.Errorf("parse brand file: %w", err)
+	}
+
+	brand := file.Brand
+	for field, hex := range map[string]*string{
+		"primary_color":   &brand.PrimaryColor,
+		"secondary_color": &brand.SecondaryColor,
+	} {
+		if *hex != "" && !hexColorRegex.MatchString(*hex) {
+			return Brand{}, fmt.Errorf("brand: %s %q is not a 6-digit hex color", field, *hex)
+		}
+		*hex = strings.ToLower(*hex)
+	}
+	if brand.Logo != "" {
+		logo, err := os.Open(brand.Logo)
+		if err != nil {
+			return Brand{}, fmt.Errorf("brand: logo: %w", err)
+		}
+		defer logo.Close()
+		if brand.LogoImage, _, err = image.Decode(logo); err != nil {
+			return Brand{}, fmt.Errorf("brand: logo %s: %w", brand.Logo, err)
+		}
+	}
+	return brand, nil
+}
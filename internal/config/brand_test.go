@@ -0,0 +1,80 @@
+package config
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeBrandFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "brand.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write brand file: %v", err)
+	}
+	return path
+}
+
+func TestLoadBrand(t *testing.T) {
+	logoPath := filepath.Join(t.TempDir(), "logo.png")
+	logo, err := os.Create(logoPath)
+	if err != nil {
+		t.Fatalf("create logo: %v", err)
+	}
+	if err := png.Encode(logo, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatalf("encode logo: %v", err)
+	}
+	logo.Close()
+
+	path := writeBrandFile(t, `
+brand:
+  name: Acme
+  logo: `+logoPath+`
+  primary_color: FF5722
+  secondary_color: 00aa55
+  font: mono
+`)
+	brand, err := LoadBrand(path)
+	if err != nil {
+		t.Fatalf("load brand: %v", err)
+	}
+	if brand.Name != "Acme" || brand.PrimaryColor != "ff5722" || brand.SecondaryColor != "00aa55" || brand.Font != "mono" {
+		t.Errorf("unexpected brand: %+v", brand)
+	}
+	if brand.LogoImage == nil || brand.LogoImage.Bounds().Dx() != 40 {
+		t.Errorf("expected the decoded 40x20 logo, got %v", brand.LogoImage)
+	}
+
+	if brand, err := LoadBrand(""); err != nil || brand.Name != "" || brand.LogoImage != nil {
+		t.Errorf("expected no brand kit for an empty path, got %+v, %v", brand, err)
+	}
+}
+
+func TestLoadBrandErrors(t *testing.T) {
+	notImage := filepath.Join(t.TempDir(), "logo.txt")
+	if err := os.WriteFile(notImage, []byte("not an image"), 0o644); err != nil {
+		t.Fatalf("write logo: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		expect string
+	}{
+		{"bad color", "brand:\n  primary_color: orange\n", "not a 6-digit hex color"},
+		{"missing logo", "brand:\n  logo: /does/not/exist.png\n", "logo"},
+		{"logo not an image", "brand:\n  logo: " + notImage + "\n", "unknown format"},
+		{"invalid YAML", "brand: [", "parse brand file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadBrand(writeBrandFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected an error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
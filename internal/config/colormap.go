@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ColorMap fixes the colors of particular avatars, such as brand accounts,
+// over the colors derived from their hash. IDs maps user IDs, as sent in uid,
+// and Names maps names, to 6-digit hex colors.
+type ColorMap struct {
+	IDs   map[string]string
+	Names map[string]string
+}
+
+// colorMapFile is the layout of the color map YAML file.
+type colorMapFile struct {
+	IDs   map[string]string `yaml:"ids"`
+	Names map[string]string `yaml:"names"`
+}
+
+// LoadColorMap reads a color map from a YAML file. Colors are 6-digit hex,
+// with or without a leading '#'. Names have their runs of whitespace collapsed
+// like the names of requests. An empty path means an empty map.
+func LoadColorMap(path string) (ColorMap, error) {
+	if path == "" {
+		return ColorMap{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ColorMap{}, fmt.Errorf("read color map file: %w", err)
+	}
+	var file colorMapFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return ColorMap{}, fmt.Errorf("parse color map file: %w", err)
+	}
+
+	var colors ColorMap
+	if colors.IDs, err = parseColorEntries("ids", file.IDs, strings.TrimSpace); err != nil {
+		return ColorMap{}, err
+	}
+	if colors.Names, err = parseColorEntries("names", file.Names, func(name string) string {
+		return strings.Join(strings.Fields(name), " ")
+	}); err != nil {
+		return ColorMap{}, err
+	}
+	return colors, nil
+}
+
+// parseColorEntries checks the colors of one section of the file, and keys
+// them by their normalized keys.
+func parseColorEntries(section string, entries map[string]string, normalize func(string) string) (map[string]string, error) {
+	colors := make(map[string]string, len(entries))
+	for key, hex := range entries {
+		hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+		if !hexColorRegex.MatchString(hex) {
+			return nil, fmt.Errorf("color map: %s: %q: %q is not a 6-digit hex color", section, key, hex)
+		}
+		normalized := normalize(key)
+		if normalized == "" {
+			return nil, fmt.Errorf("color map: %s: empty key", section)
+		}
+		if _, ok := colors[normalized]; ok {
+			return nil, fmt.Errorf("color map: %s: %q is listed twice", section, normalized)
+		}
+		colors[normalized] = strings.ToLower(hex)
+	}
+	return colors, nil
+}
+
+// Color returns the color mapped to uid, or when uid is empty or unmapped, the
+// color mapped to name.
+func (m ColorMap) Color(uid, name string) (string, bool) {
+	if uid != "" {
+		if hex, ok := m.IDs[uid]; ok {
+			return hex, true
+		}
+	}
+	hex, ok := m.Names[name]
+	return hex, ok
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeColorMapFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "colors.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write color map file: %v", err)
+	}
+	return path
+}
+
+func TestLoadColorMap(t *testing.T) {
+	path := writeColorMapFile(t, `
+ids:
+  42: "#0A7CFF"
+  " svc-7 ": 222222
+names:
+  "Acme  Support ": FF5733
+`)
+
+	colors, err := LoadColorMap(path)
+	if err != nil {
+		t.Fatalf("load color map: %v", err)
+	}
+	want := ColorMap{
+		IDs:   map[string]string{"42": "0a7cff", "svc-7": "222222"},
+		Names: map[string]string{"Acme Support": "ff5733"},
+	}
+	if !reflect.DeepEqual(colors, want) {
+		t.Fatalf("expected %v, got %v", want, colors)
+	}
+
+	lookups := []struct {
+		uid, name string
+		want      string
+	}{
+		{"42", "Acme Support", "0a7cff"},
+		{"7", "Acme Support", "ff5733"},
+		{"", "Acme Support", "ff5733"},
+		{"", "acme support", ""},
+		{"", "", ""},
+	}
+	for _, l := range lookups {
+		if got, ok := colors.Color(l.uid, l.name); got != l.want || ok != (l.want != "") {
+			t.Errorf("Color(%q, %q): expected %q, got %q, %t", l.uid, l.name, l.want, got, ok)
+		}
+	}
+
+	if empty, err := LoadColorMap(""); err != nil || len(empty.IDs)+len(empty.Names) != 0 {
+		t.Fatalf("expected an empty map for an empty path, got %v, %v", empty, err)
+	}
+	if _, ok := (ColorMap{}).Color("42", "Acme Support"); ok {
+		t.Fatal("expected an empty map to map nothing")
+	}
+}
+
+func TestLoadColorMapErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		expect string
+	}{
+		{"named color", "names:\n  Jane: blue\n", `names: "Jane": "blue" is not a 6-digit hex color`},
+		{"short hex", "ids:\n  \"1\": \"#fff\"\n", `ids: "1": "fff" is not a 6-digit hex color`},
+		{"empty name", "names:\n  \" \": ff5733\n", "names: empty key"},
+		{"duplicate name", "names:\n  Jane Doe: ff5733\n  Jane  Doe: 000000\n", `names: "Jane Doe" is listed twice`},
+		{"invalid YAML", "names: [", "parse color map file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadColorMap(writeColorMapFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
@@ -1,21 +1,51 @@
 package config
 
 import (
+	"encoding/json"
 	"flag"
+	"net/netip"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
+
+	"grout/internal/kvstore"
 )
 
+// Version is the server's version, as /health and telemetry report it.
+const Version = "1.0.0"
+
 const (
 	DefaultSize               = 128
 	DefaultBgColor            = "cccccc"
 	DefaultFontColor          = "969696"
 	DefaultAvatarBg           = "f0e9e9"
 	DefaultAvatarFg           = "8b5d5d"
+	DarkAvatarBg              = "3d3232" // Avatar background for scheme=dark
+	DarkBgColor               = "2b2b2b" // Placeholder background for scheme=dark
+	DefaultCalendarHeader     = "e53935"
+	DefaultCalendarBg         = "ffffff"
+	DefaultRatingColor        = "f5a623"
+	DefaultRatingEmptyColor   = "dddddd"
+	DefaultRatingMax          = 5
+	DefaultRatingSize         = 24
+	MaxRatingStars            = 10 // Upper bound on the number of stars in a rating strip
+	DefaultTextSize           = 32
+	DefaultTextColor          = "000000"
+	MaxTextSize               = 256 // Maximum font size for text-only images
+	DefaultMaxTextLength      = 200 // Default maximum characters of a text parameter
+	DefaultMaxNameLength      = 100 // Default maximum characters of a name parameter
+	DefaultDividerColor       = "2c3e50"
+	DefaultPatternPaperColor  = "f8f9fa" // Background of tiled patterns when none is given
 	DefaultAddr               = ":8080"
 	DefaultDomain             = "localhost:8080"
 	DefaultStaticDir          = "./static"
+	DefaultBrandName          = "Grout"
+	DefaultBrandColor         = "667eea"
+	AppleTouchIconSize        = 180 // Size of the generated apple-touch-icon.png
 	CacheSize                 = 2000
+	CacheDebugKeys            = 100 // Recent cache keys listed by the admin stats API
 	MinWidthForQuoteJoke      = 300 // Minimum width required to render quotes/jokes
 	MinFontSize               = 16  // Minimum font size for readability
 	MaxFontSize               = 48  // Maximum font size to avoid huge text
@@ -23,11 +53,234 @@ const (
 	// MinTextLengthForWrapping is kept for backward compatibility; prefer MinTextLengthForSmallFont.
 	MinTextLengthForWrapping = MinTextLengthForSmallFont
 	MinCharsPerLine          = 10 // Minimum characters per line for SVG text estimation
+	// MaxDimension caps the width and height of sized images, such as placeholders
+	MaxDimension = 10000
+	// RenderTimeout bounds the time to generate one image, including remote fetches
+	RenderTimeout = 15 * time.Second
+	// MaxChaosLatency caps the latency an x-chaos parameter can inject
+	MaxChaosLatency = 30 * time.Second
+	// HealthCheckTTL is how long a health check's result is reused, so frequent
+	// polling doesn't render and hit the store on every request
+	HealthCheckTTL = 5 * time.Second
+	// DefaultRenderBudget caps the estimated work of rendering one image, in
+	// pixels drawn: width × height × animation frames × the effect's cost, plus
+	// a cost per generated shape
+	DefaultRenderBudget = 200_000_000
 	// Rate limiting defaults
 	DefaultRateLimitRPM   = 100 // Default requests per minute per IP
 	DefaultRateLimitBurst = 10  // Default burst size for rate limiter
+	// Default prefix lengths of the networks that share a rate limit; a /64 is
+	// what an IPv6 client usually holds
+	DefaultRateLimitIPv4Prefix = 32
+	DefaultRateLimitIPv6Prefix = 64
+	// RateLimitHitCost is the share of a request's rate limit cost a cache hit
+	// keeps; the rest is refunded. Revalidations answered 304 are free.
+	RateLimitHitCost = 0.25
+	// Concurrency limiting defaults
+	DefaultMaxConcurrentPerIP = 4               // Requests served at once per IP
+	DefaultMaxConcurrent      = 64              // Requests served at once in all
+	DefaultConcurrencyWait    = 5 * time.Second // How long a request waits in line for a slot
+	// Async render defaults
+	DefaultAsyncWorkers = 2         // Background renders run at once
+	AsyncQueueSize      = 100       // Renders waiting for a worker before async requests are refused
+	DefaultJobRetention = time.Hour // How long a render job's status is kept
+	// Permalink defaults
+	DefaultPermalinkTTL  = 30 * 24 * time.Hour // How long a permalink is kept after it's registered
+	DefaultMaxPermalinks = 100000              // Permalinks registered per PermalinkTTL
+	// Maintenance mode defaults
+	DefaultMaintenanceMessage    = "Down for maintenance"
+	DefaultMaintenanceRetryAfter = 10 * time.Minute // Retry-After of image requests during maintenance
+	MaxMaintenanceDimension      = 1200             // Maximum width or height of the maintenance placeholder
+	MaxMaintenanceRequestBytes   = 4 << 10          // Maximum size of a maintenance request body
+	// Usage metering defaults
+	DefaultMeteringInterval   = time.Hour        // How often usage records are exported
+	DefaultMeteringFormat     = "jsonl"          // Format of export files, jsonl or csv
+	MeteringTimeout           = 30 * time.Second // Timeout for posting a batch to the metering webhook
+	MaxPendingMeteringBatches = 168              // Undelivered batches kept for retry: a week of hourly exports
+	// Telemetry defaults
+	DefaultTelemetryInterval = 24 * time.Hour   // How often telemetry is reported
+	TelemetryTimeout         = 30 * time.Second // Timeout for posting a telemetry report
+	// DefaultLogSampleRate logs every successful request in the access log
+	DefaultLogSampleRate = 1
+	// FileReloadInterval is how often the IP lists and color map files are
+	// checked for changes
+	FileReloadInterval = 10 * time.Second
+	// Image proxy defaults
+	ProxyTimeout       = 10 * time.Second // Timeout for fetching remote images
+	MaxProxyBytes      = 10 << 20         // Maximum size of a fetched remote image (10 MiB)
+	MaxProxyPixels     = 25_000_000       // Maximum pixels of a fetched remote image, checked before decoding
+	MaxResizeDimension = 4000             // Maximum width or height of a resized image
+	// Encoder defaults, overridden per request by the quality, subsample, and effort parameters
+	DefaultImageQuality  = 90        // JPEG and WebP quality, from 1 to 100
+	DefaultJPEGSubsample = "420"     // JPEG chroma subsampling, 420 or 444
+	DefaultPNGEffort     = "default" // PNG compression effort: fast, default, or best
+	// Text rendering defaults, overridden per request by the hinting, aa, and
+	// supersample parameters
+	DefaultFontHinting   = "none" // Glyph hinting: none or full
+	DefaultTextAntialias = true   // Draw text anti-aliased
+	DefaultSupersample   = 1      // Factor raster images are drawn larger by, then scaled down
+	MaxSupersample       = 4
+	// MaxSupersampledDimension caps the width and height an image is drawn at
+	// when supersampled; larger images are supersampled less, or not at all
+	MaxSupersampledDimension = 2048
+	// Bounds on fitting an image to a byte budget (maxBytes)
+	MinBudgetQuality   = 10   // Lowest JPEG and WebP quality an image may drop to
+	MinBudgetDimension = 16   // Smallest width or height an image may shrink to
+	BudgetScaleStep    = 0.75 // Factor an image shrinks by each time it doesn't fit
+	// ui-avatars.com compatibility defaults and bounds
+	UIAvatarsDefaultSize     = 64
+	UIAvatarsMinSize         = 16
+	UIAvatarsMaxSize         = 512
+	UIAvatarsDefaultLength   = 2
+	UIAvatarsMaxLength       = 8   // Maximum initials of an avatar
+	UIAvatarsDefaultFontSize = 0.5 // Font size as a fraction of the image size
+	UIAvatarsMinFontSize     = 0.1
+	UIAvatarsMaxFontSize     = 1.0
+	// DiceBear compatibility defaults and bounds
+	DiceBearDefaultSize     = 256
+	DiceBearMaxSize         = 256 // DiceBear's own cap on raster sizes
+	DiceBearDefaultChars    = 2
+	DiceBearDefaultFontSize = 50 // Percent of the image size
+	// Lorem Picsum compatibility bounds
+	MaxPhotoDimension = 2000 // Maximum width or height of a noise photo
+	PicsumRandomIDs   = 1000 // Random photos redirect to one of this many IDs
+	// Gravatar compatibility defaults and bounds
+	GravatarDefaultSize = 80
+	GravatarMaxSize     = 2048
+	// DefaultBadgeCacheSeconds is the shortest client cache time of an endpoint badge
+	DefaultBadgeCacheSeconds = 300
+	EmailMaxDimension        = 1200 // Maximum width or height of email-safe images (email=true)
+	// Palette extraction defaults
+	DefaultPaletteCount  = 5
+	MaxPaletteCount      = 16
+	DefaultPaletteWidth  = 500
+	DefaultPaletteHeight = 100
+
+	// DefaultDiffThreshold is the perceptual difference, from 0 to 1, above which
+	// the diff API counts a pixel as changed
+	DefaultDiffThreshold = 0.1
+
+	// Bounds on layout documents posted to the render API
+	MaxLayoutDimension = 2000     // Maximum canvas width or height, and node size
+	MaxLayoutNodes     = 200      // Maximum nodes in a layout
+	MaxLayoutDepth     = 8        // Maximum nesting of rows and columns
+	MaxLayoutBytes     = 64 << 10 // Maximum size of a layout request body
+	MaxBarcodeLength   = 48       // Maximum characters of a layout barcode
+
+	// Live preview streams of the playground
+	MaxPreviewSessions    = 100                    // Preview streams open at once
+	MaxPreviewUpdateBytes = 4 << 10                // Maximum size of a preview update body
+	PreviewDebounce       = 150 * time.Millisecond // Quiet time after an update before the preview renders
+	PreviewKeepAlive      = 15 * time.Second       // Interval of comments keeping a quiet stream open through proxies
+	PreviewIdleTimeout    = 10 * time.Minute       // A stream closes after this long without an update
+
+	// MaxToolRequestBytes caps the size of a JSON-RPC request to the tool
+	// endpoint for assistants
+	MaxToolRequestBytes = 64 << 10
+
+	// UsageRetention is how long a tenant's monthly render count is kept, for
+	// the usage history of the admin API
+	UsageRetention = 400 * 24 * time.Hour
+
+	// MaxCacheImportBytes caps the size of a cache snapshot posted to the admin
+	// API, across every tenant's entries
+	MaxCacheImportBytes = 1 << 30
+
+	// Sizes of a certificate and a ticket when the path gives none
+	DefaultCertificateWidth  = 800
+	DefaultCertificateHeight = 600
+	DefaultTicketWidth       = 800
+	DefaultTicketHeight      = 300
+
+	// Avatar stack (facepile) defaults and bounds
+	DefaultFacepileWidth   = 240
+	DefaultFacepileHeight  = 64
+	DefaultFacepileMax     = 5        // Avatars shown before the +N bubble
+	MaxFacepileMax         = 20       // Upper bound on max
+	MaxFacepileNames       = 100      // Maximum names in one request
+	DefaultFacepileOverlap = 25       // Overlap of neighboring avatars, in percent of their size
+	MaxFacepileOverlap     = 50       // Upper bound on overlap
+	FacepileTextScale      = 0.35     // Initials' height as a fraction of the avatar, small enough to clear the next one
+	FacepileOverflowBg     = "e0e0e0" // Background of the +N bubble
+
+	// Team grid defaults and bounds. Cells are capped so a full grid stays
+	// within MaxLayoutNodes.
+	DefaultTeamCols       = 4
+	DefaultTeamAvatarSize = 96
+	MinTeamAvatarSize     = 24
+	MaxTeamAvatarSize     = 256
+	MaxTeamCols           = 8
+	MaxTeamRows           = 8
+	MaxTeamCells          = 40
+	DefaultTeamBg         = "ffffff"
+
+	// Placeholder table defaults and bounds
+	DefaultTableWidth  = 600
+	DefaultTableHeight = 300
+	DefaultTableRows   = 5
+	DefaultTableCols   = 4
+	MaxTableRows       = 50
+	MaxTableCols       = 12
+	DefaultTableHeader = "2c3e50"
+	DefaultTableStripe = "f2f4f7"
+
+	// Animation defaults and bounds
+	DefaultAnimationFPS    = 10
+	MaxAnimationFPS        = 50  // GIF delays are in hundredths of a second, and browsers slow shorter ones
+	MaxAnimationFrames     = 200 // Longer text is typed several characters a frame
+	AnimationPaletteFrames = 8   // Frames sampled for the palette of an animated GIF
+	// TypewriterHold is how long a typewriter animation shows the finished text
+	TypewriterHold = 2 * time.Second
+	// ShimmerDuration is how long a shimmer takes to sweep across an SVG
+	ShimmerDuration = 1500 * time.Millisecond
+
+	// Spinner defaults and bounds
+	DefaultSpinnerSize = 64
+	MinSpinnerSize     = 16
+	MaxSpinnerSize     = 512
+	// SpinnerPeriod is how long a spinner takes for one turn
+	SpinnerPeriod = time.Second
+
+	// Ops pipeline bounds
+	MaxOps     = 16 // Stages in one ops string
+	MaxOpsBlur = 20 // Strongest blur stage, in pixels
+
+	// WarmupPairLetters are the most common initials; the avatar warmup renders
+	// every pair of them along with each single letter
+	WarmupPairLetters = "ABCDEJKLMRST"
+	// MaxWarmupFailures caps the failed avatars a CSV warmup lists; the rest
+	// are only counted
+	MaxWarmupFailures = 100
+	// MaxWarmupCSVBytes caps the size of a users CSV posted to the admin API
+	MaxWarmupCSVBytes = 64 << 20
 )
 
+// hexColorRegex matches a 6-digit hex color without the leading '#'.
+var hexColorRegex = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+// JPEGSubsamples and PNGEfforts hold the valid values of the subsample and effort
+// encoder settings, FontHintings those of the hinting setting, MeteringFormats
+// those of the usage export format, and WebPBackends those of the WebP encoder
+// backend.
+var (
+	JPEGSubsamples  = map[string]bool{"420": true, "444": true}
+	PNGEfforts      = map[string]bool{"fast": true, "default": true, "best": true}
+	FontHintings    = map[string]bool{"none": true, "full": true}
+	MeteringFormats = map[string]bool{"jsonl": true, "csv": true}
+	WebPBackends    = map[string]bool{"cgo": true, "native": true, "off": true}
+)
+
+// FooterLink is a link shown in the footer of the HTML pages.
+type FooterLink struct {
+	Label string `yaml:"label"`
+	URL   string `yaml:"url"`
+}
+
+// DefaultFooterLinks returns the footer links used when none are configured.
+func DefaultFooterLinks() []FooterLink {
+	return []FooterLink{{Label: "View on GitHub", URL: "https://github.com/Nexlified/grout"}}
+}
+
 // ServerConfig represents runtime server settings.
 type ServerConfig struct {
 	Addr           string
@@ -36,6 +289,139 @@ type ServerConfig struct {
 	CacheSize      int
 	RateLimitRPM   int // Requests per minute per IP
 	RateLimitBurst int // Burst size for rate limiter
+	// RateLimitIPv4Prefix and RateLimitIPv6Prefix group clients into networks
+	// for rate limiting: every address in the same /n counts as one client.
+	RateLimitIPv4Prefix int
+	RateLimitIPv6Prefix int
+	// CacheDedup stores identical images cached under different keys once.
+	CacheDedup bool
+	// MaxConcurrentPerIP and MaxConcurrent cap the image requests served at once
+	// per IP and in all; 0 turns a limit off. Requests over a limit wait in line
+	// for up to ConcurrencyWait.
+	MaxConcurrentPerIP int
+	MaxConcurrent      int
+	ConcurrencyWait    time.Duration
+	// AsyncWorkers is how many async=true renders run in the background at once;
+	// 0 turns async rendering off.
+	AsyncWorkers int
+	// JobRetention is how long a finished render job's status can be looked up.
+	JobRetention time.Duration
+	// IPListsFile is a YAML file of client IPs exempt from the limits and IPs
+	// refused outright, reloaded when it changes; IPLists holds its parsed contents.
+	IPListsFile string
+	IPLists     IPLists
+	// TrustedProxies are the proxies whose X-Forwarded-For and X-Real-IP headers
+	// are believed when matching clients against IPLists. Other clients are
+	// matched by the address they connect from.
+	TrustedProxies []netip.Prefix
+	// ColorMapFile is a YAML file of names and user IDs whose avatars take fixed
+	// colors in place of derived ones, reloaded when it changes; ColorMap holds
+	// its parsed contents.
+	ColorMapFile string
+	ColorMap     ColorMap
+	// AccessLog logs every error response and one in LogSampleRate successful
+	// ones, with avatar names hashed.
+	AccessLog     bool
+	LogSampleRate int
+	// NoPersonalData keeps names out of everything the server stores or reports:
+	// no cache keys are kept for the admin API, and explain output hashes names
+	// and cache keys.
+	NoPersonalData bool
+	// ProxyAllowedHosts lists hosts the image proxy may fetch from; empty disables proxying.
+	ProxyAllowedHosts []string
+	// BrandName and BrandColor (hex, no '#') brand the HTML pages, web manifest, and touch icon.
+	BrandName   string
+	BrandColor  string
+	FooterLinks []FooterLink
+	// BrandFile is a YAML brand kit of logo, colors, and font that restyles every
+	// generated image; Brand holds its parsed contents.
+	BrandFile string
+	Brand     Brand
+	// TenantsFile is a YAML file of per-tenant overrides selected by Host header;
+	// Tenants holds its parsed contents, keyed by tenant ID.
+	TenantsFile string
+	Tenants     map[string]Tenant
+	// APIKeysFile is a YAML file of the API keys clients send in X-API-Key, each
+	// with its own monthly render quota; APIKeys holds its parsed contents, keyed
+	// by name.
+	APIKeysFile string
+	APIKeys     map[string]APIKey
+	// AdminToken is the bearer token for the admin API; empty disables it.
+	AdminToken string
+	// SigningKey is the HMAC key image URLs must be signed with; empty disables signing.
+	SigningKey string
+	// IdentitySalt is the HMAC key uid parameters are hashed with into color
+	// seeds, so each deployment gives a user its own colors.
+	IdentitySalt string
+	// ProvenanceKey is the secret the instance's Ed25519 provenance signing key
+	// is derived from; empty disables provenance manifests.
+	ProvenanceKey string
+	// Permalinks registers every image URL served under the hash of its
+	// parameters, and serves it at /i/{hash}.{ext}. Each is kept for PermalinkTTL,
+	// and at most MaxPermalinks are registered per PermalinkTTL.
+	Permalinks    bool
+	PermalinkTTL  time.Duration
+	MaxPermalinks int
+	// StoreURL names the key-value store of permalinks and other state that
+	// outlives requests (see kvstore.Open); empty keeps it in memory. Store is
+	// the opened store.
+	StoreURL string
+	Store    kvstore.Store
+	// CanonicalRedirects redirects image URLs to their canonical form with a 301.
+	CanonicalRedirects bool
+	// ChaosTesting lets image requests inject latency or failures with the
+	// x-chaos parameter, for testing how clients cope with a degraded service.
+	ChaosTesting bool
+	// Deterministic pins random choices and the current time to fixed values, so
+	// output is reproducible in snapshot tests.
+	Deterministic bool
+	// MaxTextLength and MaxNameLength cap the characters of text and name
+	// parameters. Longer values are truncated with an ellipsis, or rejected with a
+	// 400 when StrictTextLength is set.
+	MaxTextLength    int
+	MaxNameLength    int
+	StrictTextLength bool
+	// RenderBudget rejects images whose estimated work, in pixels drawn, is over
+	// it, before they're rendered; 0 turns the check off.
+	RenderBudget int64
+	// EmbedRequestID echoes a request's X-Request-ID header on generated images and
+	// records it in the metadata of PNGs it renders.
+	EmbedRequestID bool
+	// TemplatesFile is a YAML file of named layout templates rendered at
+	// /t/{template}; Templates holds them as JSON layout documents, keyed by name.
+	TemplatesFile string
+	Templates     map[string]json.RawMessage
+	// DeprecationsFile is a YAML file of deprecated routes, whose responses carry
+	// Deprecation, Sunset, and Link headers; Deprecations holds them by route path.
+	DeprecationsFile string
+	Deprecations     map[string]Deprecation
+	// WarmupAvatars renders the most common initials avatars into the cache at startup.
+	WarmupAvatars bool
+	// ImageQuality, JPEGSubsample, and PNGEffort tune the raster encoders for
+	// requests that don't set the quality, subsample, or effort parameters.
+	ImageQuality  int
+	JPEGSubsample string
+	PNGEffort     string
+	// FontHinting, TextAntialias, and Supersample tune how raster text is drawn
+	// for requests that don't set the hinting, aa, or supersample parameters.
+	FontHinting   string
+	TextAntialias bool
+	Supersample   int
+	// WebPBackend picks the WebP encoder: cgo, native, or off. Empty picks the
+	// best one the binary was built with.
+	WebPBackend string
+	// MeteringDir and MeteringWebhook receive a batch of usage records per tenant
+	// and format every MeteringInterval: as a file in MeteringFormat, and as JSON
+	// POSTed to the URL. Metering is off when both are empty.
+	MeteringDir      string
+	MeteringFormat   string
+	MeteringWebhook  string
+	MeteringInterval time.Duration
+	// TelemetryURL receives a JSON report of aggregate counters every
+	// TelemetryInterval: the version, and requests by route and image format.
+	// Telemetry is off when it's empty.
+	TelemetryURL      string
+	TelemetryInterval time.Duration
 }
 
 var (
@@ -43,19 +429,97 @@ var (
 	domainFlag         = flag.String("domain", "", "Public domain for example URLs (env DOMAIN)")
 	staticDirFlag      = flag.String("static-dir", "", "Directory for static files (env STATIC_DIR)")
 	cacheSizeFlag      = flag.Int("cache-size", 0, "LRU cache size (env CACHE_SIZE)")
+	cacheDedupFlag     = flag.Bool("cache-dedup", false, "Store identical cached images once (env CACHE_DEDUP)")
 	rateLimitRPMFlag   = flag.Int("rate-limit-rpm", 0, "Rate limit requests per minute per IP (env RATE_LIMIT_RPM)")
 	rateLimitBurstFlag = flag.Int("rate-limit-burst", 0, "Rate limit burst size (env RATE_LIMIT_BURST)")
+	rateLimitIPv4Flag  = flag.Int("rate-limit-ipv4-prefix", 0, "Prefix length of the IPv4 networks that share a rate limit, 1-32 (env RATE_LIMIT_IPV4_PREFIX)")
+	rateLimitIPv6Flag  = flag.Int("rate-limit-ipv6-prefix", 0, "Prefix length of the IPv6 networks that share a rate limit, 1-128 (env RATE_LIMIT_IPV6_PREFIX)")
+	maxConcPerIPFlag   = flag.Int("max-concurrent-per-ip", -1, "Image requests served at once per IP; 0 turns the limit off (env MAX_CONCURRENT_PER_IP)")
+	maxConcurrentFlag  = flag.Int("max-concurrent", -1, "Image requests served at once in all; 0 turns the limit off (env MAX_CONCURRENT)")
+	concWaitFlag       = flag.Duration("concurrency-wait", 0, "How long a request waits for a concurrency slot (env CONCURRENCY_WAIT)")
+	asyncWorkersFlag   = flag.Int("async-workers", -1, "Background renders for async=true requests run at once; 0 turns async off (env ASYNC_WORKERS)")
+	jobRetentionFlag   = flag.Duration("job-retention", 0, "How long render job statuses are kept (env JOB_RETENTION)")
+	accessLogFlag      = flag.Bool("access-log", false, "Log errors and a sample of successful requests, with avatar names hashed (env ACCESS_LOG)")
+	logSampleRateFlag  = flag.Int("log-sample-rate", 0, "Log one in N successful requests in the access log (env LOG_SAMPLE_RATE)")
+	noPersonalDataFlag = flag.Bool("no-personal-data", false, "Keep names out of cache debug keys and explain output (env NO_PERSONAL_DATA)")
+	ipListsFileFlag    = flag.String("ip-lists-file", "", "YAML file of IPs exempt from limits and IPs to refuse (env IP_LISTS_FILE)")
+	trustedProxiesFlag = flag.String("trusted-proxies", "", "Comma-separated proxy IPs and CIDR ranges whose forwarding headers name the client for the IP lists (env TRUSTED_PROXIES)")
+	colorMapFileFlag   = flag.String("color-map-file", "", "YAML file of fixed avatar colors by name and user ID (env COLOR_MAP_FILE)")
+	proxyHostsFlag     = flag.String("proxy-allowed-hosts", "", "Comma-separated hosts the image proxy may fetch from (env PROXY_ALLOWED_HOSTS)")
+	brandNameFlag      = flag.String("brand-name", "", "Site name shown in pages and the web manifest (env BRAND_NAME)")
+	brandColorFlag     = flag.String("brand-color", "", "Brand hex color for the theme and touch icon (env BRAND_COLOR)")
+	footerLinksFlag    = flag.String("footer-links", "", "Comma-separated Label=URL footer links (env FOOTER_LINKS)")
+	brandFileFlag      = flag.String("brand-file", "", "YAML brand kit of logo, colors, and font for generated images (env BRAND_FILE)")
+	tenantsFileFlag    = flag.String("tenants-file", "", "YAML file of per-hostname tenant overrides (env TENANTS_FILE)")
+	apiKeysFileFlag    = flag.String("api-keys-file", "", "YAML file of API keys and their monthly render quotas (env API_KEYS_FILE)")
+	adminTokenFlag     = flag.String("admin-token", "", "Bearer token for the admin API; empty disables it (env ADMIN_TOKEN)")
+	signingKeyFlag     = flag.String("signing-key", "", "HMAC key image URLs must be signed with; empty disables signing (env SIGNING_KEY)")
+	permalinksFlag     = flag.Bool("permalinks", false, "Serve image URLs at short /i/{hash} permalinks (env PERMALINKS)")
+	permalinkTTLFlag   = flag.Duration("permalink-ttl", 0, "How long a permalink is kept after it's registered (env PERMALINK_TTL)")
+	maxPermalinksFlag  = flag.Int("max-permalinks", 0, "Permalinks registered per permalink TTL (env MAX_PERMALINKS)")
+	storeURLFlag       = flag.String("store-url", "", "Key-value store: memory, bolt:///path/to.db, or redis://host:6379/0 (env STORE_URL)")
+	identitySaltFlag   = flag.String("identity-salt", "", "HMAC key uid parameters are hashed with into color seeds (env IDENTITY_SALT)")
+	provenanceKeyFlag  = flag.String("provenance-key", "", "Secret the Ed25519 key signing provenance manifests is derived from (env PROVENANCE_KEY)")
+	canonicalFlag      = flag.Bool("canonical-redirects", false, "Redirect non-canonical image URLs to their canonical form (env CANONICAL_REDIRECTS)")
+	chaosFlag          = flag.Bool("chaos-testing", false, "Let image requests inject latency and failures with x-chaos; never in production (env CHAOS_TESTING)")
+	deterministicFlag  = flag.Bool("deterministic", false, "Pin randomness and timestamps for reproducible output (env GROUT_DETERMINISTIC)")
+	maxTextLengthFlag  = flag.Int("max-text-length", 0, "Maximum characters of a text parameter (env MAX_TEXT_LENGTH)")
+	maxNameLengthFlag  = flag.Int("max-name-length", 0, "Maximum characters of a name parameter (env MAX_NAME_LENGTH)")
+	strictTextFlag     = flag.Bool("strict-text-length", false, "Reject over-long text and name parameters instead of truncating them (env STRICT_TEXT_LENGTH)")
+	renderBudgetFlag   = flag.Int64("render-budget", -1, "Maximum estimated pixels drawn for one image, 0 for no limit (env RENDER_BUDGET)")
+	embedRequestIDFlag = flag.Bool("embed-request-id", false, "Echo X-Request-ID on images and record it in rendered PNGs (env EMBED_REQUEST_ID)")
+	templatesFileFlag  = flag.String("templates-file", "", "YAML file of named layout templates served at /t/ (env TEMPLATES_FILE)")
+	deprecationsFlag   = flag.String("deprecations-file", "", "YAML file of deprecated routes and their sunset dates (env DEPRECATIONS_FILE)")
+	warmupAvatarsFlag  = flag.Bool("warmup-avatars", false, "Pre-render common initials avatars into the cache at startup (env WARMUP_AVATARS)")
+	imageQualityFlag   = flag.Int("image-quality", 0, "Default JPEG and WebP quality, 1 to 100 (env IMAGE_QUALITY)")
+	jpegSubsampleFlag  = flag.String("jpeg-subsample", "", "Default JPEG chroma subsampling, 420 or 444 (env JPEG_SUBSAMPLE)")
+	pngEffortFlag      = flag.String("png-effort", "", "Default PNG compression effort: fast, default, or best (env PNG_EFFORT)")
+	fontHintingFlag    = flag.String("font-hinting", "", "Default glyph hinting of raster text: none or full (env FONT_HINTING)")
+	textAntialiasFlag  = flag.String("text-antialias", "", "Default anti-aliasing of raster text: true or false (env TEXT_ANTIALIAS)")
+	supersampleFlag    = flag.Int("supersample", 0, "Default factor raster images are drawn larger by and scaled down, 1 to 4 (env SUPERSAMPLE)")
+	webpBackendFlag    = flag.String("webp-backend", "", "WebP encoder: cgo, native, or off; defaults to the best in the build (env WEBP_BACKEND)")
+	meterDirFlag       = flag.String("metering-dir", "", "Directory usage record files are exported to (env METERING_DIR)")
+	meterFormatFlag    = flag.String("metering-format", "", "Format of usage record files: jsonl or csv (env METERING_FORMAT)")
+	meterWebhookFlag   = flag.String("metering-webhook", "", "URL batches of usage records are POSTed to (env METERING_WEBHOOK)")
+	meterIntervalFlag  = flag.Duration("metering-interval", 0, "How often usage records are exported (env METERING_INTERVAL)")
+	telemetryURLFlag   = flag.String("telemetry-url", "", "URL anonymous usage counters are reported to; off when empty (env TELEMETRY_URL)")
+	telemetryIntFlag   = flag.Duration("telemetry-interval", 0, "How often telemetry is reported (env TELEMETRY_INTERVAL)")
 )
 
 // DefaultServerConfig returns sane defaults for local development.
 func DefaultServerConfig() ServerConfig {
 	return ServerConfig{
-		Addr:           DefaultAddr,
-		Domain:         DefaultDomain,
-		StaticDir:      DefaultStaticDir,
-		CacheSize:      CacheSize,
-		RateLimitRPM:   DefaultRateLimitRPM,
-		RateLimitBurst: DefaultRateLimitBurst,
+		Addr:                DefaultAddr,
+		Domain:              DefaultDomain,
+		StaticDir:           DefaultStaticDir,
+		CacheSize:           CacheSize,
+		RateLimitRPM:        DefaultRateLimitRPM,
+		RateLimitBurst:      DefaultRateLimitBurst,
+		RateLimitIPv4Prefix: DefaultRateLimitIPv4Prefix,
+		RateLimitIPv6Prefix: DefaultRateLimitIPv6Prefix,
+		MaxConcurrentPerIP:  DefaultMaxConcurrentPerIP,
+		MaxConcurrent:       DefaultMaxConcurrent,
+		ConcurrencyWait:     DefaultConcurrencyWait,
+		AsyncWorkers:        DefaultAsyncWorkers,
+		JobRetention:        DefaultJobRetention,
+		PermalinkTTL:        DefaultPermalinkTTL,
+		MaxPermalinks:       DefaultMaxPermalinks,
+		LogSampleRate:       DefaultLogSampleRate,
+		BrandName:           DefaultBrandName,
+		BrandColor:          DefaultBrandColor,
+		FooterLinks:         DefaultFooterLinks(),
+		MaxTextLength:       DefaultMaxTextLength,
+		MaxNameLength:       DefaultMaxNameLength,
+		RenderBudget:        DefaultRenderBudget,
+		ImageQuality:        DefaultImageQuality,
+		JPEGSubsample:       DefaultJPEGSubsample,
+		PNGEffort:           DefaultPNGEffort,
+		FontHinting:         DefaultFontHinting,
+		TextAntialias:       DefaultTextAntialias,
+		Supersample:         DefaultSupersample,
+		MeteringFormat:      DefaultMeteringFormat,
+		MeteringInterval:    DefaultMeteringInterval,
+		TelemetryInterval:   DefaultTelemetryInterval,
 	}
 }
 
@@ -77,6 +541,11 @@ func LoadServerConfig() ServerConfig {
 			cfg.CacheSize = n
 		}
 	}
+	if cacheDedupEnv := os.Getenv("CACHE_DEDUP"); cacheDedupEnv != "" {
+		if enabled, err := strconv.ParseBool(cacheDedupEnv); err == nil {
+			cfg.CacheDedup = enabled
+		}
+	}
 	if rateLimitRPMEnv := os.Getenv("RATE_LIMIT_RPM"); rateLimitRPMEnv != "" {
 		if n, err := strconv.Atoi(rateLimitRPMEnv); err == nil && n > 0 {
 			cfg.RateLimitRPM = n
@@ -87,6 +556,217 @@ func LoadServerConfig() ServerConfig {
 			cfg.RateLimitBurst = n
 		}
 	}
+	if ipv4PrefixEnv := os.Getenv("RATE_LIMIT_IPV4_PREFIX"); ipv4PrefixEnv != "" {
+		if n, err := strconv.Atoi(ipv4PrefixEnv); err == nil && n > 0 && n <= 32 {
+			cfg.RateLimitIPv4Prefix = n
+		}
+	}
+	if ipv6PrefixEnv := os.Getenv("RATE_LIMIT_IPV6_PREFIX"); ipv6PrefixEnv != "" {
+		if n, err := strconv.Atoi(ipv6PrefixEnv); err == nil && n > 0 && n <= 128 {
+			cfg.RateLimitIPv6Prefix = n
+		}
+	}
+	if perIPEnv := os.Getenv("MAX_CONCURRENT_PER_IP"); perIPEnv != "" {
+		if n, err := strconv.Atoi(perIPEnv); err == nil && n >= 0 {
+			cfg.MaxConcurrentPerIP = n
+		}
+	}
+	if maxConcurrentEnv := os.Getenv("MAX_CONCURRENT"); maxConcurrentEnv != "" {
+		if n, err := strconv.Atoi(maxConcurrentEnv); err == nil && n >= 0 {
+			cfg.MaxConcurrent = n
+		}
+	}
+	if waitEnv := os.Getenv("CONCURRENCY_WAIT"); waitEnv != "" {
+		if d, err := time.ParseDuration(waitEnv); err == nil && d > 0 {
+			cfg.ConcurrencyWait = d
+		}
+	}
+	if asyncWorkersEnv := os.Getenv("ASYNC_WORKERS"); asyncWorkersEnv != "" {
+		if n, err := strconv.Atoi(asyncWorkersEnv); err == nil && n >= 0 {
+			cfg.AsyncWorkers = n
+		}
+	}
+	if retentionEnv := os.Getenv("JOB_RETENTION"); retentionEnv != "" {
+		if d, err := time.ParseDuration(retentionEnv); err == nil && d > 0 {
+			cfg.JobRetention = d
+		}
+	}
+	if ipListsFile := os.Getenv("IP_LISTS_FILE"); ipListsFile != "" {
+		cfg.IPListsFile = ipListsFile
+	}
+	if trustedProxies := os.Getenv("TRUSTED_PROXIES"); trustedProxies != "" {
+		cfg.TrustedProxies = parseTrustedProxies(trustedProxies)
+	}
+	if colorMapFile := os.Getenv("COLOR_MAP_FILE"); colorMapFile != "" {
+		cfg.ColorMapFile = colorMapFile
+	}
+	if accessLogEnv := os.Getenv("ACCESS_LOG"); accessLogEnv != "" {
+		if enabled, err := strconv.ParseBool(accessLogEnv); err == nil {
+			cfg.AccessLog = enabled
+		}
+	}
+	if noPersonalDataEnv := os.Getenv("NO_PERSONAL_DATA"); noPersonalDataEnv != "" {
+		if enabled, err := strconv.ParseBool(noPersonalDataEnv); err == nil {
+			cfg.NoPersonalData = enabled
+		}
+	}
+	if sampleRateEnv := os.Getenv("LOG_SAMPLE_RATE"); sampleRateEnv != "" {
+		if n, err := strconv.Atoi(sampleRateEnv); err == nil && n > 0 {
+			cfg.LogSampleRate = n
+		}
+	}
+
+	if proxyHostsEnv := os.Getenv("PROXY_ALLOWED_HOSTS"); proxyHostsEnv != "" {
+		cfg.ProxyAllowedHosts = splitList(proxyHostsEnv)
+	}
+	if brandName := os.Getenv("BRAND_NAME"); brandName != "" {
+		cfg.BrandName = brandName
+	}
+	if brandColor := os.Getenv("BRAND_COLOR"); hexColorRegex.MatchString(brandColor) {
+		cfg.BrandColor = strings.ToLower(brandColor)
+	}
+	if footerLinksEnv := os.Getenv("FOOTER_LINKS"); footerLinksEnv != "" {
+		cfg.FooterLinks = parseFooterLinks(footerLinksEnv)
+	}
+	if brandFile := os.Getenv("BRAND_FILE"); brandFile != "" {
+		cfg.BrandFile = brandFile
+	}
+	if tenantsFile := os.Getenv("TENANTS_FILE"); tenantsFile != "" {
+		cfg.TenantsFile = tenantsFile
+	}
+	if apiKeysFile := os.Getenv("API_KEYS_FILE"); apiKeysFile != "" {
+		cfg.APIKeysFile = apiKeysFile
+	}
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		cfg.AdminToken = adminToken
+	}
+	if signingKey := os.Getenv("SIGNING_KEY"); signingKey != "" {
+		cfg.SigningKey = signingKey
+	}
+	if identitySalt := os.Getenv("IDENTITY_SALT"); identitySalt != "" {
+		cfg.IdentitySalt = identitySalt
+	}
+	if provenanceKey := os.Getenv("PROVENANCE_KEY"); provenanceKey != "" {
+		cfg.ProvenanceKey = provenanceKey
+	}
+	if permalinksEnv := os.Getenv("PERMALINKS"); permalinksEnv != "" {
+		if enabled, err := strconv.ParseBool(permalinksEnv); err == nil {
+			cfg.Permalinks = enabled
+		}
+	}
+	if permalinkTTLEnv := os.Getenv("PERMALINK_TTL"); permalinkTTLEnv != "" {
+		if d, err := time.ParseDuration(permalinkTTLEnv); err == nil && d > 0 {
+			cfg.PermalinkTTL = d
+		}
+	}
+	if maxPermalinksEnv := os.Getenv("MAX_PERMALINKS"); maxPermalinksEnv != "" {
+		if n, err := strconv.Atoi(maxPermalinksEnv); err == nil && n > 0 {
+			cfg.MaxPermalinks = n
+		}
+	}
+	if storeURL := os.Getenv("STORE_URL"); storeURL != "" {
+		cfg.StoreURL = storeURL
+	}
+	if canonicalEnv := os.Getenv("CANONICAL_REDIRECTS"); canonicalEnv != "" {
+		if enabled, err := strconv.ParseBool(canonicalEnv); err == nil {
+			cfg.CanonicalRedirects = enabled
+		}
+	}
+	if chaosEnv := os.Getenv("CHAOS_TESTING"); chaosEnv != "" {
+		if enabled, err := strconv.ParseBool(chaosEnv); err == nil {
+			cfg.ChaosTesting = enabled
+		}
+	}
+	if deterministicEnv := os.Getenv("GROUT_DETERMINISTIC"); deterministicEnv != "" {
+		if enabled, err := strconv.ParseBool(deterministicEnv); err == nil {
+			cfg.Deterministic = enabled
+		}
+	}
+	if maxTextEnv := os.Getenv("MAX_TEXT_LENGTH"); maxTextEnv != "" {
+		if n, err := strconv.Atoi(maxTextEnv); err == nil && n > 0 {
+			cfg.MaxTextLength = n
+		}
+	}
+	if maxNameEnv := os.Getenv("MAX_NAME_LENGTH"); maxNameEnv != "" {
+		if n, err := strconv.Atoi(maxNameEnv); err == nil && n > 0 {
+			cfg.MaxNameLength = n
+		}
+	}
+	if budgetEnv := os.Getenv("RENDER_BUDGET"); budgetEnv != "" {
+		if n, err := strconv.ParseInt(budgetEnv, 10, 64); err == nil && n >= 0 {
+			cfg.RenderBudget = n
+		}
+	}
+	if strictTextEnv := os.Getenv("STRICT_TEXT_LENGTH"); strictTextEnv != "" {
+		if enabled, err := strconv.ParseBool(strictTextEnv); err == nil {
+			cfg.StrictTextLength = enabled
+		}
+	}
+	if embedRequestIDEnv := os.Getenv("EMBED_REQUEST_ID"); embedRequestIDEnv != "" {
+		if enabled, err := strconv.ParseBool(embedRequestIDEnv); err == nil {
+			cfg.EmbedRequestID = enabled
+		}
+	}
+	if templatesFile := os.Getenv("TEMPLATES_FILE"); templatesFile != "" {
+		cfg.TemplatesFile = templatesFile
+	}
+	if deprecationsFile := os.Getenv("DEPRECATIONS_FILE"); deprecationsFile != "" {
+		cfg.DeprecationsFile = deprecationsFile
+	}
+	if warmupEnv := os.Getenv("WARMUP_AVATARS"); warmupEnv != "" {
+		if enabled, err := strconv.ParseBool(warmupEnv); err == nil {
+			cfg.WarmupAvatars = enabled
+		}
+	}
+	if qualityEnv := os.Getenv("IMAGE_QUALITY"); qualityEnv != "" {
+		if n, err := strconv.Atoi(qualityEnv); err == nil && n >= 1 && n <= 100 {
+			cfg.ImageQuality = n
+		}
+	}
+	if subsample := os.Getenv("JPEG_SUBSAMPLE"); JPEGSubsamples[subsample] {
+		cfg.JPEGSubsample = subsample
+	}
+	if effort := os.Getenv("PNG_EFFORT"); PNGEfforts[effort] {
+		cfg.PNGEffort = effort
+	}
+	if hinting := os.Getenv("FONT_HINTING"); FontHintings[hinting] {
+		cfg.FontHinting = hinting
+	}
+	if aaEnv := os.Getenv("TEXT_ANTIALIAS"); aaEnv != "" {
+		if enabled, err := strconv.ParseBool(aaEnv); err == nil {
+			cfg.TextAntialias = enabled
+		}
+	}
+	if supersampleEnv := os.Getenv("SUPERSAMPLE"); supersampleEnv != "" {
+		if n, err := strconv.Atoi(supersampleEnv); err == nil && n >= 1 && n <= MaxSupersample {
+			cfg.Supersample = n
+		}
+	}
+	if backend := os.Getenv("WEBP_BACKEND"); WebPBackends[backend] {
+		cfg.WebPBackend = backend
+	}
+	if meteringDir := os.Getenv("METERING_DIR"); meteringDir != "" {
+		cfg.MeteringDir = meteringDir
+	}
+	if format := os.Getenv("METERING_FORMAT"); MeteringFormats[format] {
+		cfg.MeteringFormat = format
+	}
+	if webhook := os.Getenv("METERING_WEBHOOK"); webhook != "" {
+		cfg.MeteringWebhook = webhook
+	}
+	if intervalEnv := os.Getenv("METERING_INTERVAL"); intervalEnv != "" {
+		if d, err := time.ParseDuration(intervalEnv); err == nil && d > 0 {
+			cfg.MeteringInterval = d
+		}
+	}
+	if telemetryURL := os.Getenv("TELEMETRY_URL"); telemetryURL != "" {
+		cfg.TelemetryURL = telemetryURL
+	}
+	if intervalEnv := os.Getenv("TELEMETRY_INTERVAL"); intervalEnv != "" {
+		if d, err := time.ParseDuration(intervalEnv); err == nil && d > 0 {
+			cfg.TelemetryInterval = d
+		}
+	}
 
 	if !flag.Parsed() {
 		flag.Parse()
@@ -104,12 +784,199 @@ func LoadServerConfig() ServerConfig {
 	if cacheSizeFlag != nil && *cacheSizeFlag > 0 {
 		cfg.CacheSize = *cacheSizeFlag
 	}
+	if cacheDedupFlag != nil && *cacheDedupFlag {
+		cfg.CacheDedup = true
+	}
 	if rateLimitRPMFlag != nil && *rateLimitRPMFlag > 0 {
 		cfg.RateLimitRPM = *rateLimitRPMFlag
 	}
 	if rateLimitBurstFlag != nil && *rateLimitBurstFlag > 0 {
 		cfg.RateLimitBurst = *rateLimitBurstFlag
 	}
+	if rateLimitIPv4Flag != nil && *rateLimitIPv4Flag > 0 && *rateLimitIPv4Flag <= 32 {
+		cfg.RateLimitIPv4Prefix = *rateLimitIPv4Flag
+	}
+	if rateLimitIPv6Flag != nil && *rateLimitIPv6Flag > 0 && *rateLimitIPv6Flag <= 128 {
+		cfg.RateLimitIPv6Prefix = *rateLimitIPv6Flag
+	}
+	if maxConcPerIPFlag != nil && *maxConcPerIPFlag >= 0 {
+		cfg.MaxConcurrentPerIP = *maxConcPerIPFlag
+	}
+	if maxConcurrentFlag != nil && *maxConcurrentFlag >= 0 {
+		cfg.MaxConcurrent = *maxConcurrentFlag
+	}
+	if concWaitFlag != nil && *concWaitFlag > 0 {
+		cfg.ConcurrencyWait = *concWaitFlag
+	}
+	if asyncWorkersFlag != nil && *asyncWorkersFlag >= 0 {
+		cfg.AsyncWorkers = *asyncWorkersFlag
+	}
+	if jobRetentionFlag != nil && *jobRetentionFlag > 0 {
+		cfg.JobRetention = *jobRetentionFlag
+	}
+	if ipListsFileFlag != nil && *ipListsFileFlag != "" {
+		cfg.IPListsFile = *ipListsFileFlag
+	}
+	if trustedProxiesFlag != nil && *trustedProxiesFlag != "" {
+		cfg.TrustedProxies = parseTrustedProxies(*trustedProxiesFlag)
+	}
+	if colorMapFileFlag != nil && *colorMapFileFlag != "" {
+		cfg.ColorMapFile = *colorMapFileFlag
+	}
+	if accessLogFlag != nil && *accessLogFlag {
+		cfg.AccessLog = true
+	}
+	if noPersonalDataFlag != nil && *noPersonalDataFlag {
+		cfg.NoPersonalData = true
+	}
+	if logSampleRateFlag != nil && *logSampleRateFlag > 0 {
+		cfg.LogSampleRate = *logSampleRateFlag
+	}
+	if proxyHostsFlag != nil && *proxyHostsFlag != "" {
+		cfg.ProxyAllowedHosts = splitList(*proxyHostsFlag)
+	}
+	if brandNameFlag != nil && *brandNameFlag != "" {
+		cfg.BrandName = *brandNameFlag
+	}
+	if brandColorFlag != nil && hexColorRegex.MatchString(*brandColorFlag) {
+		cfg.BrandColor = strings.ToLower(*brandColorFlag)
+	}
+	if footerLinksFlag != nil && *footerLinksFlag != "" {
+		cfg.FooterLinks = parseFooterLinks(*footerLinksFlag)
+	}
+	if brandFileFlag != nil && *brandFileFlag != "" {
+		cfg.BrandFile = *brandFileFlag
+	}
+	if tenantsFileFlag != nil && *tenantsFileFlag != "" {
+		cfg.TenantsFile = *tenantsFileFlag
+	}
+	if apiKeysFileFlag != nil && *apiKeysFileFlag != "" {
+		cfg.APIKeysFile = *apiKeysFileFlag
+	}
+	if adminTokenFlag != nil && *adminTokenFlag != "" {
+		cfg.AdminToken = *adminTokenFlag
+	}
+	if signingKeyFlag != nil && *signingKeyFlag != "" {
+		cfg.SigningKey = *signingKeyFlag
+	}
+	if identitySaltFlag != nil && *identitySaltFlag != "" {
+		cfg.IdentitySalt = *identitySaltFlag
+	}
+	if provenanceKeyFlag != nil && *provenanceKeyFlag != "" {
+		cfg.ProvenanceKey = *provenanceKeyFlag
+	}
+	if permalinksFlag != nil && *permalinksFlag {
+		cfg.Permalinks = true
+	}
+	if permalinkTTLFlag != nil && *permalinkTTLFlag > 0 {
+		cfg.PermalinkTTL = *permalinkTTLFlag
+	}
+	if maxPermalinksFlag != nil && *maxPermalinksFlag > 0 {
+		cfg.MaxPermalinks = *maxPermalinksFlag
+	}
+	if storeURLFlag != nil && *storeURLFlag != "" {
+		cfg.StoreURL = *storeURLFlag
+	}
+	if canonicalFlag != nil && *canonicalFlag {
+		cfg.CanonicalRedirects = true
+	}
+	if chaosFlag != nil && *chaosFlag {
+		cfg.ChaosTesting = true
+	}
+	if deterministicFlag != nil && *deterministicFlag {
+		cfg.Deterministic = true
+	}
+	if maxTextLengthFlag != nil && *maxTextLengthFlag > 0 {
+		cfg.MaxTextLength = *maxTextLengthFlag
+	}
+	if maxNameLengthFlag != nil && *maxNameLengthFlag > 0 {
+		cfg.MaxNameLength = *maxNameLengthFlag
+	}
+	if strictTextFlag != nil && *strictTextFlag {
+		cfg.StrictTextLength = true
+	}
+	if renderBudgetFlag != nil && *renderBudgetFlag >= 0 {
+		cfg.RenderBudget = *renderBudgetFlag
+	}
+	if embedRequestIDFlag != nil && *embedRequestIDFlag {
+		cfg.EmbedRequestID = true
+	}
+	if templatesFileFlag != nil && *templatesFileFlag != "" {
+		cfg.TemplatesFile = *templatesFileFlag
+	}
+	if deprecationsFlag != nil && *deprecationsFlag != "" {
+		cfg.DeprecationsFile = *deprecationsFlag
+	}
+	if warmupAvatarsFlag != nil && *warmupAvatarsFlag {
+		cfg.WarmupAvatars = true
+	}
+	if imageQualityFlag != nil && *imageQualityFlag >= 1 && *imageQualityFlag <= 100 {
+		cfg.ImageQuality = *imageQualityFlag
+	}
+	if jpegSubsampleFlag != nil && JPEGSubsamples[*jpegSubsampleFlag] {
+		cfg.JPEGSubsample = *jpegSubsampleFlag
+	}
+	if pngEffortFlag != nil && PNGEfforts[*pngEffortFlag] {
+		cfg.PNGEffort = *pngEffortFlag
+	}
+	if fontHintingFlag != nil && FontHintings[*fontHintingFlag] {
+		cfg.FontHinting = *fontHintingFlag
+	}
+	if textAntialiasFlag != nil && *textAntialiasFlag != "" {
+		if enabled, err := strconv.ParseBool(*textAntialiasFlag); err == nil {
+			cfg.TextAntialias = enabled
+		}
+	}
+	if supersampleFlag != nil && *supersampleFlag >= 1 && *supersampleFlag <= MaxSupersample {
+		cfg.Supersample = *supersampleFlag
+	}
+	if webpBackendFlag != nil && WebPBackends[*webpBackendFlag] {
+		cfg.WebPBackend = *webpBackendFlag
+	}
+	if meterDirFlag != nil && *meterDirFlag != "" {
+		cfg.MeteringDir = *meterDirFlag
+	}
+	if meterFormatFlag != nil && MeteringFormats[*meterFormatFlag] {
+		cfg.MeteringFormat = *meterFormatFlag
+	}
+	if meterWebhookFlag != nil && *meterWebhookFlag != "" {
+		cfg.MeteringWebhook = *meterWebhookFlag
+	}
+	if meterIntervalFlag != nil && *meterIntervalFlag > 0 {
+		cfg.MeteringInterval = *meterIntervalFlag
+	}
+	if telemetryURLFlag != nil && *telemetryURLFlag != "" {
+		cfg.TelemetryURL = *telemetryURLFlag
+	}
+	if telemetryIntFlag != nil && *telemetryIntFlag > 0 {
+		cfg.TelemetryInterval = *telemetryIntFlag
+	}
 
 	return cfg
 }
+
+// splitList splits a comma-separated value into trimmed, non-empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseFooterLinks parses a comma-separated list of Label=URL pairs, skipping
+// malformed entries.
+func parseFooterLinks(s string) []FooterLink {
+	var links []FooterLink
+	for _, item := range splitList(s) {
+		label, url, ok := strings.Cut(item, "=")
+		label, url = strings.TrimSpace(label), strings.TrimSpace(url)
+		if !ok || label == "" || url == "" {
+			continue
+		}
+		links = append(links, FooterLink{Label: label, URL: url})
+	}
+	return links
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Deprecation marks a route deprecated: responses announce when it was
+// deprecated, when it goes away, and where to read about the replacement.
+type Deprecation struct {
+	Since time.Time `yaml:"since"`
+	// Sunset is when the route will be removed; optional
+	Sunset time.Time `yaml:"sunset"`
+	// Link is a page about the deprecation, such as a migration guide; optional
+	Link string `yaml:"link"`
+}
+
+// deprecationsFile is the layout of the deprecations YAML file.
+type deprecationsFile struct {
+	Deprecations map[string]Deprecation `yaml:"deprecations"`
+}
+
+// LoadDeprecations reads the deprecated routes from a YAML file, keyed by the
+// path their route is registered under, such as /7.x/. An empty path means
+// none.
+func LoadDeprecations(path string) (map[string]Deprecation, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read deprecations file: %w", err)
+	}
+	var file deprecationsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse deprecations file: %w", err)
+	}
+
+	for route, d := range file.Deprecations {
+		if !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("deprecation %q: routes are paths starting with /", route)
+		}
+		if d.Since.IsZero() {
+			return nil, fmt.Errorf("deprecation %q: since is required", route)
+		}
+		if !d.Sunset.IsZero() && d.Sunset.Before(d.Since) {
+			return nil, fmt.Errorf("deprecation %q: sunset %s is before since %s", route, d.Sunset.Format(time.DateOnly), d.Since.Format(time.DateOnly))
+		}
+		if d.Link != "" {
+			u, err := url.Parse(d.Link)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("deprecation %q: link %q is not an absolute http or https URL", route, d.Link)
+			}
+		}
+	}
+	return file.Deprecations, nil
+}
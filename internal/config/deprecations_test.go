@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeDeprecationsFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "deprecations.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write deprecations file: %v", err)
+	}
+	return path
+}
+
+func TestLoadDeprecations(t *testing.T) {
+	path := writeDeprecationsFile(t, `
+deprecations:
+  /7.x/:
+    since: 2026-01-01
+    sunset: 2026-12-31
+    link: https://docs.example.com/migrate-dicebear
+  /api/:
+    since: 2026-03-01T12:00:00Z
+`)
+
+	deprecations, err := LoadDeprecations(path)
+	if err != nil {
+		t.Fatalf("load deprecations: %v", err)
+	}
+	dicebear := deprecations["/7.x/"]
+	if !dicebear.Since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !dicebear.Sunset.Equal(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected dates %v to %v", dicebear.Since, dicebear.Sunset)
+	}
+	if dicebear.Link != "https://docs.example.com/migrate-dicebear" {
+		t.Errorf("unexpected link %q", dicebear.Link)
+	}
+	if ui := deprecations["/api/"]; ui.Since.Hour() != 12 || !ui.Sunset.IsZero() || ui.Link != "" {
+		t.Errorf("unexpected deprecation %+v", ui)
+	}
+
+	if deprecations, err := LoadDeprecations(""); err != nil || deprecations != nil {
+		t.Errorf("expected no deprecations without a file, got %v, %v", deprecations, err)
+	}
+}
+
+func TestLoadDeprecationsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"relative route", "deprecations:\n  api/:\n    since: 2026-01-01\n", "starting with /"},
+		{"no since", "deprecations:\n  /api/:\n    sunset: 2026-01-01\n", "since is required"},
+		{"sunset first", "deprecations:\n  /api/:\n    since: 2026-06-01\n    sunset: 2026-01-01\n", "before since"},
+		{"bad link", "deprecations:\n  /api/:\n    since: 2026-01-01\n    link: docs/migrate\n", "not an absolute"},
+		{"bad date", "deprecations:\n  /api/:\n    since: soon\n", "parse deprecations file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadDeprecations(writeDeprecationsFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
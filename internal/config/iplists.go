@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IPLists are the client addresses exempt from request limits (Allow) and
+// refused outright (Deny).
+type IPLists struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// ipListsFile is the layout of the IP lists YAML file.
+type ipListsFile struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// LoadIPLists reads the IP allow and deny lists from a YAML file. Entries are IP
+// addresses or CIDR ranges. An empty path means empty lists.
+func LoadIPLists(path string) (IPLists, error) {
+	if path == "" {
+		return IPLists{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return IPLists{}, fmt.Errorf("read IP lists file: %w", err)
+	}
+	var file ipListsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return IPLists{}, fmt.Errorf("parse IP lists file: %w", err)
+	}
+
+	var lists IPLists
+	if lists.Allow, err = parsePrefixes("allow", file.Allow); err != nil {
+		return IPLists{}, err
+	}
+	if lists.Deny, err = parsePrefixes("deny", file.Deny); err != nil {
+		return IPLists{}, err
+	}
+	return lists, nil
+}
+
+// parseTrustedProxies parses a comma-separated list of proxy addresses and CIDR
+// ranges, skipping malformed entries.
+func parseTrustedProxies(s string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range splitList(s) {
+		if parsed, err := parsePrefixes("trusted proxies", []string{item}); err == nil {
+			prefixes = append(prefixes, parsed...)
+		}
+	}
+	return prefixes
+}
+
+// parsePrefixes parses the entries of one list, treating a bare address as a
+// range of one.
+func parsePrefixes(list string, entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%s list: %q is not a CIDR range", list, entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s list: %q is not an IP address or CIDR range", list, entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
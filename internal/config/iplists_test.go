@@ -0,0 +1,75 @@
+package config
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeIPListsFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "iplists.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write IP lists file: %v", err)
+	}
+	return path
+}
+
+func TestLoadIPLists(t *testing.T) {
+	path := writeIPListsFile(t, `
+allow:
+  - 10.0.0.0/8
+  - 192.0.2.7
+deny:
+  - 203.0.113.9/24
+  - "2001:db8::/32"
+`)
+
+	lists, err := LoadIPLists(path)
+	if err != nil {
+		t.Fatalf("load IP lists: %v", err)
+	}
+	want := IPLists{
+		Allow: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.7/32")},
+		// Ranges are stored masked, so a host address in one still names the range
+		Deny: []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24"), netip.MustParsePrefix("2001:db8::/32")},
+	}
+	if !reflect.DeepEqual(lists, want) {
+		t.Fatalf("expected %v, got %v", want, lists)
+	}
+
+	if empty, err := LoadIPLists(""); err != nil || len(empty.Allow)+len(empty.Deny) != 0 {
+		t.Fatalf("expected empty lists for an empty path, got %v, %v", empty, err)
+	}
+}
+
+func TestLoadIPListsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		expect string
+	}{
+		{"invalid address", "allow: [monitor.local]\n", "allow list: \"monitor.local\" is not an IP address"},
+		{"invalid range", "deny: [10.0.0.0/40]\n", "deny list: \"10.0.0.0/40\" is not a CIDR range"},
+		{"invalid YAML", "deny: [", "parse IP lists file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadIPLists(writeIPListsFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	got := parseTrustedProxies("10.0.0.1, 172.16.0.0/12,proxy.local,,2001:db8::/32")
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32"), netip.MustParsePrefix("172.16.0.0/12"), netip.MustParsePrefix("2001:db8::/32")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v with the malformed entry skipped, got %v", want, got)
+	}
+}
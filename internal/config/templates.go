@@ -0,0 +1,51 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateNameRegex matches a valid template name, as used in /t/{template} URLs.
+var TemplateNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// templatesFile is the layout of the templates YAML file.
+type templatesFile struct {
+	Templates map[string]any `yaml:"templates"`
+}
+
+// LoadTemplates reads the named layout templates from a YAML file, converting each
+// to a JSON layout document. An empty path means no templates.
+func LoadTemplates(path string) (map[string]json.RawMessage, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read templates file: %w", err)
+	}
+	var file templatesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse templates file: %w", err)
+	}
+
+	templates := make(map[string]json.RawMessage, len(file.Templates))
+	for name, doc := range file.Templates {
+		if !TemplateNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("template %q: names must be lowercase letters, digits, '-', or '_'", name)
+		}
+		if _, ok := doc.(map[string]any); !ok {
+			return nil, fmt.Errorf("template %q: not a layout document", name)
+		}
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("template %q: %w", name, err)
+		}
+		templates[name] = raw
+	}
+	return templates, nil
+}
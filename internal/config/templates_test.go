@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplatesFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "templates.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write templates file: %v", err)
+	}
+	return path
+}
+
+func TestLoadTemplates(t *testing.T) {
+	path := writeTemplatesFile(t, `
+templates:
+  ticket:
+    width: 400
+    height: 200
+    root:
+      type: text
+      text: "Admit {{name}}"
+`)
+
+	templates, err := LoadTemplates(path)
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	var doc struct {
+		Width int `json:"width"`
+		Root  struct {
+			Text string `json:"text"`
+		} `json:"root"`
+	}
+	if err := json.Unmarshal(templates["ticket"], &doc); err != nil {
+		t.Fatalf("expected a JSON document, got %s: %v", templates["ticket"], err)
+	}
+	if doc.Width != 400 || doc.Root.Text != "Admit {{name}}" {
+		t.Fatalf("unexpected template: %s", templates["ticket"])
+	}
+}
+
+func TestLoadTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		expect string
+	}{
+		{"invalid name", "templates:\n  My Ticket:\n    width: 1\n", "names must be"},
+		{"not a document", "templates:\n  ticket: [1, 2]\n", "not a layout document"},
+		{"invalid YAML", "templates: [", "parse templates file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTemplates(writeTemplatesFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+
+	if templates, err := LoadTemplates(""); err != nil || templates != nil {
+		t.Fatalf("expected no templates without a file, got %v, %v", templates, err)
+	}
+}
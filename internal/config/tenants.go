@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tenant overrides the branding and defaults for requests on its hosts. Empty
+// fields fall back to the server-wide settings.
+type Tenant struct {
+	// Hosts lists the Host header values served as this tenant; the first is its
+	// public domain in pages, robots.txt, and sitemap.xml.
+	Hosts       []string     `yaml:"hosts"`
+	BrandName   string       `yaml:"brand_name"`
+	BrandColor  string       `yaml:"brand_color"`
+	FooterLinks []FooterLink `yaml:"footer_links"`
+	// Default colors (hex, no '#') used when a request doesn't set one
+	AvatarBg      string `yaml:"avatar_bg"`
+	PlaceholderBg string `yaml:"placeholder_bg"`
+	TextColor     string `yaml:"text_color"`
+	// AccentColor is the default calendar header, rating star, divider, and
+	// certificate and ticket trim color
+	AccentColor string `yaml:"accent_color"`
+	// Font is the default font for /text/ and layout text
+	Font string `yaml:"font"`
+	// QuotesFile and JokesFile replace the embedded content with a tenant content pack
+	QuotesFile string `yaml:"quotes_file"`
+	JokesFile  string `yaml:"jokes_file"`
+	// CacheQuotaMB caps the bytes of the tenant's cache partition; 0 means no byte cap
+	CacheQuotaMB int `yaml:"cache_quota_mb"`
+	// RateLimitRPM and RateLimitBurst replace the server-wide rate limit for the tenant
+	RateLimitRPM   int `yaml:"rate_limit_rpm"`
+	RateLimitBurst int `yaml:"rate_limit_burst"`
+	// Priority replaces the priority of every route for the tenant's requests
+	// waiting for a concurrency slot: high, normal, or low
+	Priority string `yaml:"priority"`
+	// RenderQuota caps the image requests the tenant is served each calendar
+	// month, in UTC; 0 means no cap. RenderQuotaSoft only warns once it's passed.
+	RenderQuota     int `yaml:"render_quota"`
+	RenderQuotaSoft int `yaml:"render_quota_soft"`
+}
+
+// DefaultTenantID names the server-wide settings in the admin stats API, so it
+// can't be used as a tenant ID.
+const DefaultTenantID = "default"
+
+// tenantsFile is the layout of the tenants YAML file.
+type tenantsFile struct {
+	Tenants map[string]Tenant `yaml:"tenants"`
+}
+
+// LoadTenants reads and validates the per-tenant config map from a YAML file.
+// An empty path means single-tenant mode.
+func LoadTenants(path string) (map[string]Tenant, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tenants file: %w", err)
+	}
+	var file tenantsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse tenants file: %w", err)
+	}
+
+	seen := make(map[string]string)
+	for id, t := range file.Tenants {
+		if id == DefaultTenantID {
+			return nil, fmt.Errorf("tenant ID %q is reserved", id)
+		}
+		if len(t.Hosts) == 0 {
+			return nil, fmt.Errorf("tenant %q: no hosts", id)
+		}
+		for i, host := range t.Hosts {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if other, ok := seen[host]; ok {
+				return nil, fmt.Errorf("tenant %q: host %q is already used by tenant %q", id, host, other)
+			}
+			seen[host] = id
+			t.Hosts[i] = host
+		}
+		for field, hex := range map[string]string{
+			"brand_color":    t.BrandColor,
+			"avatar_bg":      t.AvatarBg,
+			"placeholder_bg": t.PlaceholderBg,
+			"text_color":     t.TextColor,
+			"accent_color":   t.AccentColor,
+		} {
+			if hex != "" && !hexColorRegex.MatchString(hex) {
+				return nil, fmt.Errorf("tenant %q: %s %q is not a 6-digit hex color", id, field, hex)
+			}
+		}
+		if t.CacheQuotaMB < 0 || t.RateLimitRPM < 0 || t.RateLimitBurst < 0 {
+			return nil, fmt.Errorf("tenant %q: cache quota and rate limits must not be negative", id)
+		}
+		if t.RenderQuota < 0 || t.RenderQuotaSoft < 0 {
+			return nil, fmt.Errorf("tenant %q: render quotas must not be negative", id)
+		}
+		if t.RenderQuota > 0 && t.RenderQuotaSoft > t.RenderQuota {
+			return nil, fmt.Errorf("tenant %q: render_quota_soft %d is over render_quota %d", id, t.RenderQuotaSoft, t.RenderQuota)
+		}
+		switch t.Priority {
+		case "", "high", "normal", "low":
+		default:
+			return nil, fmt.Errorf("tenant %q: priority %q is not high, normal, or low", id, t.Priority)
+		}
+		for _, packFile := range []string{t.QuotesFile, t.JokesFile} {
+			if packFile == "" {
+				continue
+			}
+			if _, err := os.Stat(packFile); err != nil {
+				return nil, fmt.Errorf("tenant %q: content pack: %w", id, err)
+			}
+		}
+	}
+
+	return file.Tenants, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTenantsFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("write tenants file: %v", err)
+	}
+	return path
+}
+
+func TestLoadTenants(t *testing.T) {
+	path := writeTenantsFile(t, `
+tenants:
+  acme:
+    hosts: [" IMG.Acme.com ", acme.localhost]
+    brand_name: Acme Images
+    brand_color: FF5722
+    footer_links:
+      - label: Status
+        url: https://status.acme.com
+    font: mono
+    priority: high
+    render_quota: 10000
+    render_quota_soft: 8000
+`)
+
+	tenants, err := LoadTenants(path)
+	if err != nil {
+		t.Fatalf("load tenants: %v", err)
+	}
+	acme, ok := tenants["acme"]
+	if !ok {
+		t.Fatal("expected tenant acme")
+	}
+	if acme.Hosts[0] != "img.acme.com" {
+		t.Errorf("expected normalized host, got %q", acme.Hosts[0])
+	}
+	if len(acme.FooterLinks) != 1 || acme.FooterLinks[0].URL != "https://status.acme.com" {
+		t.Errorf("unexpected footer links: %+v", acme.FooterLinks)
+	}
+	if acme.Font != "mono" || acme.BrandName != "Acme Images" || acme.Priority != "high" || acme.RenderQuota != 10000 || acme.RenderQuotaSoft != 8000 {
+		t.Errorf("unexpected tenant: %+v", acme)
+	}
+}
+
+func TestLoadTenantsEmptyPath(t *testing.T) {
+	tenants, err := LoadTenants("")
+	if err != nil || tenants != nil {
+		t.Fatalf("expected single-tenant mode, got %v, %v", tenants, err)
+	}
+}
+
+func TestLoadTenantsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		expect string
+	}{
+		{"no hosts", "tenants:\n  acme:\n    brand_name: Acme\n", "no hosts"},
+		{"shared host", "tenants:\n  a:\n    hosts: [img.example.com]\n  b:\n    hosts: [IMG.example.com]\n", "already used"},
+		{"bad color", "tenants:\n  acme:\n    hosts: [acme.localhost]\n    accent_color: orange\n", "accent_color"},
+		{"missing pack", "tenants:\n  acme:\n    hosts: [acme.localhost]\n    quotes_file: /nonexistent/quotes.yaml\n", "content pack"},
+		{"bad priority", "tenants:\n  acme:\n    hosts: [acme.localhost]\n    priority: urgent\n", "priority"},
+		{"negative quota", "tenants:\n  acme:\n    hosts: [acme.localhost]\n    render_quota: -1\n", "render quotas"},
+		{"soft quota over hard", "tenants:\n  acme:\n    hosts: [acme.localhost]\n    render_quota: 100\n    render_quota_soft: 200\n", "render_quota_soft"},
+		{"bad yaml", "tenants: [", "parse tenants file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTenants(writeTenantsFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
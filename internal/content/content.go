@@ -4,6 +4,8 @@ import (
 	_ "embed"
 	"fmt"
 	"math/rand/v2"
+	"os"
+	"sort"
 
 	"gopkg.in/yaml.v3"
 )
@@ -26,6 +28,8 @@ const (
 type Manager struct {
 	quotes map[string][]string
 	jokes  map[string][]string
+	// deterministic makes GetRandom return the first item instead of a random one
+	deterministic bool
 }
 
 // NewManager creates a new content manager with preloaded quotes and jokes
@@ -48,6 +52,39 @@ func NewManager() (*Manager, error) {
 	return m, nil
 }
 
+// NewManagerFromFiles creates a content manager from a content pack: YAML files
+// of quotes and jokes keyed by category. An empty path keeps the embedded set.
+func NewManagerFromFiles(quotesPath, jokesPath string) (*Manager, error) {
+	m, err := NewManager()
+	if err != nil {
+		return nil, err
+	}
+
+	if quotesPath != "" {
+		data, err := os.ReadFile(quotesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read quotes: %w", err)
+		}
+		m.quotes = make(map[string][]string)
+		if err := yaml.Unmarshal(data, &m.quotes); err != nil {
+			return nil, fmt.Errorf("failed to parse quotes: %w", err)
+		}
+	}
+
+	if jokesPath != "" {
+		data, err := os.ReadFile(jokesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jokes: %w", err)
+		}
+		m.jokes = make(map[string][]string)
+		if err := yaml.Unmarshal(data, &m.jokes); err != nil {
+			return nil, fmt.Errorf("failed to parse jokes: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
 // GetRandom returns a random quote or joke, optionally filtered by category
 func (m *Manager) GetRandom(contentType ContentType, category string) (string, error) {
 	var data map[string][]string
@@ -70,20 +107,40 @@ func (m *Manager) GetRandom(contentType ContentType, category string) (string, e
 		if !exists || len(items) == 0 {
 			return "", fmt.Errorf("%s category '%s' not found or empty", typeName, category)
 		}
-		return items[rand.IntN(len(items))], nil
+		return items[m.pick(len(items))], nil
 	}
 
-	// No category specified - collect all items from all categories
+	// No category specified - collect all items from all categories, in a stable
+	// order so deterministic picks don't depend on map iteration
+	categories := make([]string, 0, len(data))
+	for category := range data {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
 	var allItems []string
-	for _, items := range data {
-		allItems = append(allItems, items...)
+	for _, category := range categories {
+		allItems = append(allItems, data[category]...)
 	}
 
 	if len(allItems) == 0 {
 		return "", fmt.Errorf("no %ss available", typeName)
 	}
 
-	return allItems[rand.IntN(len(allItems))], nil
+	return allItems[m.pick(len(allItems))], nil
+}
+
+// SetDeterministic makes GetRandom always return the same item for the same
+// arguments, so output is reproducible in snapshot tests.
+func (m *Manager) SetDeterministic(deterministic bool) {
+	m.deterministic = deterministic
+}
+
+// pick returns a random index below n, or 0 in deterministic mode.
+func (m *Manager) pick(n int) int {
+	if m.deterministic {
+		return 0
+	}
+	return rand.IntN(n)
 }
 
 // GetCategories returns all available categories for a given content type
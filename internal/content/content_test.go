@@ -1,6 +1,8 @@
 package content
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -158,3 +160,48 @@ func TestInvalidContentType(t *testing.T) {
 		t.Errorf("Error message should mention invalid content type, got: %v", err)
 	}
 }
+
+func TestNewManagerFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	quotesPath := filepath.Join(dir, "quotes.yaml")
+	if err := os.WriteFile(quotesPath, []byte("brand:\n  - \"Ship it.\"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write quotes: %v", err)
+	}
+
+	manager, err := NewManagerFromFiles(quotesPath, "")
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	quote, err := manager.GetRandom(ContentTypeQuote, "")
+	if err != nil || quote != "Ship it." {
+		t.Errorf("Expected the pack's only quote, got %q (%v)", quote, err)
+	}
+	if len(manager.jokes) == 0 {
+		t.Error("Jokes should fall back to the embedded set")
+	}
+
+	if _, err := NewManagerFromFiles(filepath.Join(dir, "missing.yaml"), ""); err == nil {
+		t.Error("Expected an error for a missing content pack")
+	}
+}
+
+func TestGetRandomDeterministic(t *testing.T) {
+	manager, err := NewManager()
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	manager.SetDeterministic(true)
+
+	for _, category := range []string{"", "inspirational"} {
+		first, err := manager.GetRandom(ContentTypeQuote, category)
+		if err != nil {
+			t.Fatalf("Failed to get quote: %v", err)
+		}
+		for i := 0; i < 20; i++ {
+			if again, _ := manager.GetRandom(ContentTypeQuote, category); again != first {
+				t.Fatalf("Expected the same quote every time for category %q, got %q and %q", category, first, again)
+			}
+		}
+	}
+}
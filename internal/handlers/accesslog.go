@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// statusRecorder remembers the status and size of a response for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// accessLogger writes one line per request: every error response, and one in
+// sampleRate successful ones.
+type accessLogger struct {
+	sampleRate uint64
+	successes  atomic.Uint64
+	logf       func(format string, args ...any)
+}
+
+func newAccessLogger(sampleRate int) *accessLogger {
+	return &accessLogger{sampleRate: uint64(max(sampleRate, 1)), logf: log.Printf}
+}
+
+// sample reports whether the next successful request should be logged. The
+// first is, then every sampleRate-th after it.
+func (al *accessLogger) sample() bool {
+	return (al.successes.Add(1)-1)%al.sampleRate == 0
+}
+
+// accessLog wraps next to log its requests, when the access log is enabled.
+func (s *Service) accessLog(next http.Handler) http.Handler {
+	if s.accessLogger == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status < http.StatusBadRequest && !s.accessLogger.sample() {
+			return
+		}
+		line := fmt.Sprintf("%s %s %d %dB %s", r.Method, s.logURL(r.URL), status, rec.bytes, time.Since(start).Round(time.Microsecond))
+		if id := requestID(r); id != "" {
+			line += " [" + id + "]"
+		}
+		s.accessLogger.logf("%s", line)
+	})
+}
+
+// logScrubber replaces avatar names in logged URLs with a keyed hash, since
+// they're often real people's names. The key is random per process, so the
+// same name hashes the same within one run of the server, and the hashes can't
+// be reversed by hashing a list of likely names.
+type logScrubber struct {
+	key []byte
+}
+
+func newLogScrubber() logScrubber {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("log scrubber key: %v", err))
+	}
+	return logScrubber{key: key}
+}
+
+// hash returns a short pseudonym for v: '~' and 12 hex digits, which need no
+// escaping in paths or queries.
+func (sc logScrubber) hash(v string) string {
+	mac := hmac.New(sha256.New, sc.key)
+	mac.Write([]byte(v))
+	return "~" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+// logPath returns the path of u with the name in /avatar/{name} paths hashed.
+func (s *Service) logPath(u *url.URL) string {
+	rest, ok := strings.CutPrefix(u.Path, "/avatar/")
+	if !ok || rest == "" {
+		return u.EscapedPath()
+	}
+	// Keep the extension, which says which format was asked for
+	_, name, _ := extractFormat(rest)
+	return "/avatar/" + s.scrubber.hash(name) + rest[len(name):]
+}
+
+// logURL returns u as it should appear in logs: the path, and the query when
+// there is one, with the name, names, and uid parameters and the name in
+// /avatar/{name} paths hashed.
+func (s *Service) logURL(u *url.URL) string {
+	path := s.logPath(u)
+	if u.RawQuery == "" {
+		return path
+	}
+	query := u.Query()
+	for _, param := range []string{"name", "names", "uid"} {
+		if query.Has(param) {
+			query.Set(param, s.scrubber.hash(query.Get(param)))
+		}
+	}
+	return path + "?" + query.Encode()
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// setupAccessLogService returns a service logging one in sampleRate successful
+// requests, and the lines it has logged so far.
+func setupAccessLogService(t *testing.T, sampleRate int) (*Service, *http.ServeMux, *[]string) {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](10)
+	cfg := config.DefaultServerConfig()
+	cfg.AccessLog = true
+	cfg.LogSampleRate = sampleRate
+	svc := NewService(renderer, cache, cfg)
+	var lines []string
+	svc.accessLogger.logf = func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return svc, mux, &lines
+}
+
+func TestLogURL(t *testing.T) {
+	svc, _ := setupTestService(t)
+	hash := svc.scrubber.hash
+
+	tests := []struct {
+		name   string
+		target string
+		expect string
+	}{
+		{"avatar path", "/avatar/Jane%20Doe", "/avatar/" + hash("Jane Doe")},
+		{"avatar path with extension", "/avatar/Jane%20Doe.png?size=64", "/avatar/" + hash("Jane Doe") + ".png?size=64"},
+		{"dots in a name", "/avatar/J.R.R.%20Tolkien", "/avatar/" + hash("J.R.R. Tolkien")},
+		{"name parameter", "/avatar/?name=Jane+Doe&rounded=true", "/avatar/?name=" + hash("Jane Doe") + "&rounded=true"},
+		{"ui-avatars name", "/api/?name=Jane+Doe", "/api/?name=" + hash("Jane Doe")},
+		{"facepile names", "/avatars/?names=Jane,Joe", "/avatars/?names=" + hash("Jane,Joe")},
+		{"user ID", "/avatar/?uid=42", "/avatar/?uid=" + hash("42")},
+		{"other endpoints untouched", "/placeholder/300x200?text=Hello", "/placeholder/300x200?text=Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.target)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.target, err)
+			}
+			got := svc.logURL(u)
+			if got != tt.expect {
+				t.Fatalf("expected %q, got %q", tt.expect, got)
+			}
+			if strings.Contains(got, "Jane") || strings.Contains(got, "Tolkien") {
+				t.Fatalf("name leaked into %q", got)
+			}
+		})
+	}
+
+	if hash("Jane Doe") != hash("Jane Doe") || hash("Jane Doe") == hash("John Doe") {
+		t.Error("expected hashes to be stable and distinct")
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	_, mux, lines := setupAccessLogService(t, 3)
+
+	// Seven successes log the first, fourth, and seventh; errors always log
+	for range 7 {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/placeholder/100x100", nil))
+	}
+	for range 2 {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/placeholder/100x100?ops=bogus", nil))
+	}
+
+	var successes, errors int
+	for _, line := range *lines {
+		switch {
+		case strings.Contains(line, " 200 "):
+			successes++
+		case strings.Contains(line, " 400 "):
+			errors++
+		default:
+			t.Errorf("unexpected log line %q", line)
+		}
+	}
+	if successes != 3 || errors != 2 {
+		t.Fatalf("expected 3 sampled successes and 2 errors, got %d and %d: %q", successes, errors, *lines)
+	}
+}
+
+func TestAccessLogLine(t *testing.T) {
+	_, mux, lines := setupAccessLogService(t, 1)
+
+	req := httptest.NewRequest(http.MethodGet, "/avatar/Jane%20Doe.png?size=64", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if len(*lines) != 1 {
+		t.Fatalf("expected one log line, got %q", *lines)
+	}
+	line := (*lines)[0]
+	for _, want := range []string{"GET /avatar/~", ".png?size=64 200 ", fmt.Sprintf(" %dB ", rec.Body.Len()), "[req-42]"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in log line %q", want, line)
+		}
+	}
+	if strings.Contains(line, "Jane") {
+		t.Errorf("name leaked into log line %q", line)
+	}
+}
+
+func TestAccessLogDisabled(t *testing.T) {
+	svc, mux := setupTestService(t)
+	if svc.accessLogger != nil {
+		t.Fatal("expected the access log to be off by default")
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/100x100", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"grout/internal/cache"
+	"grout/internal/config"
+)
+
+// rejectionCounter is implemented by rate limiters that count refused requests.
+type rejectionCounter interface {
+	Rejected() uint64
+}
+
+// adminStatsResponse is the JSON body of the admin stats API.
+type adminStatsResponse struct {
+	Tenants     []tenantStats    `json:"tenants"`
+	Concurrency concurrencyStats `json:"concurrency"`
+	IPLists     ipListStats      `json:"ip_lists"`
+	Jobs        jobStats         `json:"jobs"`
+	// Errors counts error responses by code since the server started
+	Errors map[string]uint64 `json:"errors"`
+	// Deprecated reports the requests of deprecated routes, when there are any
+	Deprecated []deprecatedRouteStats `json:"deprecated,omitempty"`
+}
+
+// tenantStats reports the cache and rate limit usage of one tenant. The
+// server-wide settings are reported as the tenant "default".
+type tenantStats struct {
+	ID        string         `json:"id"`
+	Hosts     []string       `json:"hosts,omitempty"`
+	Cache     cache.Stats    `json:"cache"`
+	RateLimit rateLimitStats `json:"rate_limit"`
+	// Keys maps the digests of recently cached images to their cache keys; only
+	// sent with keys=true
+	Keys map[string]string `json:"keys,omitempty"`
+}
+
+type rateLimitStats struct {
+	RPM   int `json:"rpm"`
+	Burst int `json:"burst"`
+	// Rejected counts requests refused with 429. Tenants without their own limit
+	// share the server-wide limiter, so they report no count of their own.
+	Rejected *uint64 `json:"rejected,omitempty"`
+	Shared   bool    `json:"shared"`
+}
+
+// concurrencyStats reports the server's concurrency limits, shared by every
+// tenant. A limit of 0 is off.
+type concurrencyStats struct {
+	PerIP    int   `json:"per_ip"`
+	Global   int   `json:"global"`
+	InFlight int64 `json:"in_flight"`
+	// Rejected counts requests refused because no slot freed up in time
+	Rejected uint64 `json:"rejected"`
+}
+
+// ipListStats reports the size of the IP allow and deny lists, and how many
+// requests they've affected.
+type ipListStats struct {
+	Allow    int    `json:"allow"`
+	Deny     int    `json:"deny"`
+	Denied   uint64 `json:"denied"`
+	Exempted uint64 `json:"exempted"`
+}
+
+// jobStats reports the async render queue: its workers, the jobs waiting for
+// them, and the jobs rendered since the server started.
+type jobStats struct {
+	Workers   int    `json:"workers"`
+	Queued    int    `json:"queued"`
+	QueueSize int    `json:"queue_size"`
+	Running   int64  `json:"running"`
+	Done      uint64 `json:"done"`
+	Failed    uint64 `json:"failed"`
+	// RetentionSeconds is how long job statuses are kept
+	RetentionSeconds int64 `json:"retention_seconds"`
+}
+
+// authorizeAdmin checks the bearer token of an admin API request, writing an error
+// and returning false when the API is disabled or the token doesn't match.
+func (s *Service) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.cfg.AdminToken == "" {
+		s.failJSON(w, r, ErrFeatureDisabled.withMessage("the admin API is not enabled on this server"))
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.AdminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		s.failJSON(w, r, ErrUnauthorized.withMessage("missing or invalid admin token"))
+		return false
+	}
+	return true
+}
+
+func (s *Service) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	serverWide := rateLimitStats{RPM: s.cfg.RateLimitRPM, Burst: s.cfg.RateLimitBurst}
+	if s.serverLimiter != nil {
+		rejected := s.serverLimiter.Rejected()
+		serverWide.Rejected = &rejected
+	}
+
+	withKeys := r.URL.Query().Get("keys") == "true"
+	resp := adminStatsResponse{
+		Tenants: []tenantStats{{
+			ID:        config.DefaultTenantID,
+			Cache:     s.defaultTheme.cache.Stats(),
+			RateLimit: serverWide,
+		}},
+		Errors:     s.errorCounts.snapshot(),
+		Deprecated: s.deprecationStats(),
+	}
+	resp.Concurrency.PerIP, resp.Concurrency.Global = s.concurrency.Limits()
+	resp.Concurrency.InFlight = s.concurrency.InFlight()
+	resp.Concurrency.Rejected = s.concurrency.Rejected()
+	allow, deny := s.ipFilter.Lists()
+	resp.IPLists = ipListStats{Allow: len(allow), Deny: len(deny), Denied: s.ipFilter.Denied(), Exempted: s.ipFilter.Exempted()}
+	resp.Jobs = jobStats{
+		Workers:          s.cfg.AsyncWorkers,
+		Queued:           len(s.jobs.queue),
+		QueueSize:        cap(s.jobs.queue),
+		Running:          s.jobs.running.Load(),
+		Done:             s.jobs.done.Load(),
+		Failed:           s.jobs.failed.Load(),
+		RetentionSeconds: int64(s.jobs.retention.Seconds()),
+	}
+	if withKeys {
+		resp.Tenants[0].Keys = s.defaultTheme.cache.Keys()
+	}
+	for _, t := range s.tenants() {
+		stats := tenantStats{ID: t.tenantID, Hosts: t.hosts, Cache: t.cache.Stats()}
+		if t.rateLimiter != nil {
+			rpm, burst := t.rateLimiter.Limits()
+			rejected := t.rateLimiter.Rejected()
+			stats.RateLimit = rateLimitStats{RPM: rpm, Burst: burst, Rejected: &rejected}
+		} else {
+			stats.RateLimit = rateLimitStats{RPM: serverWide.RPM, Burst: serverWide.Burst, Shared: true}
+		}
+		if withKeys {
+			stats.Keys = t.cache.Keys()
+		}
+		resp.Tenants = append(resp.Tenants, stats)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/middleware"
+	"grout/internal/render"
+)
+
+const testAdminToken = "s3cret"
+
+// setupAdminTestService returns a service with the admin API enabled, a server-wide
+// rate limiter, and a tenant "acme" with its own cache quota and rate limit.
+func setupAdminTestService(t *testing.T) *http.ServeMux {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.AdminToken = testAdminToken
+	cfg.Tenants = map[string]config.Tenant{
+		"acme":  {Hosts: []string{"img.acme.test"}, CacheQuotaMB: 1, RateLimitRPM: 60, RateLimitBurst: 2},
+		"other": {Hosts: []string{"img.other.test"}},
+	}
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, middleware.NewRateLimiter(cfg.RateLimitRPM, cfg.RateLimitBurst))
+	return mux
+}
+
+func getAdminStats(t *testing.T, mux *http.ServeMux, token string) (*httptest.ResponseRecorder, adminStatsResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var resp adminStatsResponse
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode stats: %v", err)
+		}
+	}
+	return rec, resp
+}
+
+func TestAdminStatsAuth(t *testing.T) {
+	_, disabled := setupTestService(t)
+	if rec, _ := getAdminStats(t, disabled, "anything"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without an admin token configured, got %d", rec.Code)
+	}
+
+	mux := setupAdminTestService(t)
+	tests := []struct {
+		name   string
+		token  string
+		expect int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "guess", http.StatusUnauthorized},
+		{"valid token", testAdminToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec, _ := getAdminStats(t, mux, tt.token); rec.Code != tt.expect {
+				t.Fatalf("expected %d got %d", tt.expect, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAdminStatsPerTenant(t *testing.T) {
+	mux := setupAdminTestService(t)
+
+	// Three requests against acme's burst of 2: two render, one is refused
+	for i := 0; i < 3; i++ {
+		getWithHost(mux, "img.acme.test", "/placeholder/300x200")
+	}
+	getWithHost(mux, "localhost:8080", "/placeholder/300x200")
+
+	_, resp := getAdminStats(t, mux, testAdminToken)
+	byID := make(map[string]tenantStats)
+	for _, stats := range resp.Tenants {
+		byID[stats.ID] = stats
+	}
+	if len(byID) != 3 {
+		t.Fatalf("expected default, acme, and other, got %+v", resp.Tenants)
+	}
+
+	acme := byID["acme"]
+	if acme.Cache.Entries != 1 || acme.Cache.Hits != 1 || acme.Cache.Misses != 1 || acme.Cache.Bytes == 0 {
+		t.Errorf("unexpected acme cache stats: %+v", acme.Cache)
+	}
+	if acme.Cache.QuotaBytes != 1<<20 {
+		t.Errorf("expected a 1 MiB quota, got %d", acme.Cache.QuotaBytes)
+	}
+	if acme.RateLimit.Rejected == nil || *acme.RateLimit.Rejected != 1 || acme.RateLimit.Shared {
+		t.Errorf("unexpected acme rate limit stats: %+v", acme.RateLimit)
+	}
+
+	if def := byID[config.DefaultTenantID]; def.Cache.Entries != 1 || def.Cache.Misses != 1 {
+		t.Errorf("unexpected default cache stats: %+v", def.Cache)
+	}
+	if other := byID["other"]; other.Cache.Entries != 0 || !other.RateLimit.Shared {
+		t.Errorf("unexpected stats for the idle tenant: %+v", other)
+	}
+
+	want := concurrencyStats{PerIP: config.DefaultMaxConcurrentPerIP, Global: config.DefaultMaxConcurrent}
+	if resp.Concurrency != want {
+		t.Errorf("expected concurrency stats %+v, got %+v", want, resp.Concurrency)
+	}
+	if resp.IPLists != (ipListStats{}) {
+		t.Errorf("expected empty IP lists, got %+v", resp.IPLists)
+	}
+	wantJobs := jobStats{Workers: config.DefaultAsyncWorkers, QueueSize: config.AsyncQueueSize, RetentionSeconds: 3600}
+	if resp.Jobs != wantJobs {
+		t.Errorf("expected job stats %+v, got %+v", wantJobs, resp.Jobs)
+	}
+}
+
+func TestAdminStatsCacheKeys(t *testing.T) {
+	mux := setupAdminTestService(t)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/300x200?text=Hello", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats?keys=true", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var resp adminStatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+
+	keys := resp.Tenants[0].Keys
+	if len(keys) != 1 {
+		t.Fatalf("expected one recent key, got %v", keys)
+	}
+	for digest, key := range keys {
+		if len(digest) != 64 || !strings.Contains(key, "Hello") {
+			t.Fatalf("expected a hex digest of the placeholder key, got %s => %s", digest, key)
+		}
+	}
+
+	if _, plain := getAdminStats(t, mux, testAdminToken); plain.Tenants[0].Keys != nil {
+		t.Fatal("expected keys only with keys=true")
+	}
+}
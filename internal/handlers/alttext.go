@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"grout/internal/render"
+)
+
+// defaultAltTitle names images whose endpoint doesn't describe them.
+const defaultAltTitle = "Generated image"
+
+// altText describes an image for assistive technology: title is its accessible
+// name, and desc a longer description.
+type altText struct {
+	title, desc string
+}
+
+// altTextKey is the request context key of the endpoint's altText.
+type altTextKey struct{}
+
+// withAltText attaches the description of the image a request renders, for
+// serveImage to label SVG output with. Descriptions are made from the same
+// parameters as the cache key, so they never need a key of their own.
+func withAltText(r *http.Request, title, desc string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), altTextKey{}, altText{title: title, desc: desc}))
+}
+
+// altTextFor returns the description of the image a request renders: the one
+// attached by its endpoint, or a generic one. The alt parameter replaces the
+// title when it's set.
+func altTextFor(r *http.Request, alt string) altText {
+	text, ok := r.Context().Value(altTextKey{}).(altText)
+	if !ok {
+		text.title = defaultAltTitle
+	}
+	if alt != "" {
+		text.title = alt
+	}
+	return text
+}
+
+// labelSVG wraps an SVG generator so its output carries the alt text.
+func labelSVG(generator func(ctx context.Context) ([]byte, error), text altText) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		data, err := generator(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return render.AddSVGTitle(data, text.title, text.desc), nil
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSVGAltText(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name      string
+		target    string
+		wantTitle string
+		wantDesc  string
+	}{
+		{"avatar", "/avatar/John%20Doe.svg", "<title>Avatar with initials JD</title>", "<desc>A 128 x 128 avatar showing the initials JD</desc>"},
+		{"placeholder text", "/placeholder/300x200.svg?text=Hello", "<title>Hello</title>", "<desc>A 300 x 200 placeholder image</desc>"},
+		{"rating", "/rating/3.5.svg", "<title>Rated 3.5 out of 5 stars</title>", ""},
+		{"calendar", "/calendar/200x200.svg?date=2025-05-01", "<title>Thursday, May 1, 2025</title>", ""},
+		{"alt override", "/avatar/John%20Doe.svg?alt=Profile%20picture", "<title>Profile picture</title>", "<desc>A 128 x 128 avatar"},
+		{"escaped", "/placeholder/300x200.svg?alt=%3Cb%3E%20%26", "<title>&lt;b&gt; &amp;</title>", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			body := rec.Body.String()
+			if !strings.Contains(body, `role="img"`) || !strings.Contains(body, tt.wantTitle) {
+				t.Errorf("expected role=img and %s, got %s", tt.wantTitle, body)
+			}
+			if tt.wantDesc != "" && !strings.Contains(body, tt.wantDesc) {
+				t.Errorf("expected %s, got %s", tt.wantDesc, body)
+			}
+		})
+	}
+}
+
+func TestSVGAltTextCache(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	// alt is part of the cache key, so an override isn't served to other requests
+	for _, target := range []string{"/avatar/Jane%20Roe.svg?alt=Jane", "/avatar/Jane%20Roe.svg"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Header().Get("X-Cache") == "HIT" {
+			t.Errorf("expected a cache miss for %s", target)
+		}
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/Jane%20Roe.svg", nil))
+	if !strings.Contains(rec.Body.String(), "<title>Avatar with initials JR</title>") {
+		t.Errorf("expected the default title, got %s", rec.Body.String())
+	}
+}
+
+func TestSVGAltTextTooLong(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.MaxTextLength = 5
+
+	// alt is truncated like text, or rejected with strict lengths
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/JD.svg?alt=abcdefgh", nil))
+	if !strings.Contains(rec.Body.String(), "<title>abcd"+ellipsis+"</title>") {
+		t.Errorf("expected a truncated title, got %s", rec.Body.String())
+	}
+	svc.cfg.StrictTextLength = true
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/JD.svg?alt=abcdefgh", nil))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Error-Code") != "text_too_long" {
+		t.Errorf("expected 400 text_too_long, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// Animation styles of placeholders, for the animate parameter
+const (
+	// animateTypewriter types a placeholder's text out across GIF frames
+	animateTypewriter = "typewriter"
+	// animateShimmer sweeps a highlight across an SVG placeholder, like a loading skeleton
+	animateShimmer = "shimmer"
+)
+
+// animationParams reads the animate, fps, and loop parameters of a request. The
+// style is empty when the request isn't animated.
+func animationParams(r *http.Request, format render.ImageFormat) (string, render.AnimationOptions, error) {
+	query := r.URL.Query()
+	opts := render.AnimationOptions{FPS: config.DefaultAnimationFPS}
+	style := query.Get("animate")
+	switch style {
+	case "":
+		return "", opts, nil
+	case animateTypewriter:
+		if format != render.FormatGIF {
+			return "", opts, ErrUnsupportedFormat.withMessage("animate=%s needs GIF output. Use a .gif extension.", style)
+		}
+	case animateShimmer:
+		// A shimmer is animated by CSS, so it has no frames to time
+		if format != render.FormatSVG {
+			return "", opts, ErrUnsupportedFormat.withMessage("animate=%s needs SVG output. Use a .svg extension or none.", style)
+		}
+		return style, opts, nil
+	default:
+		return "", opts, ErrInvalidParameter.withMessage("Invalid animate. Use %s or %s.", animateTypewriter, animateShimmer)
+	}
+
+	if query.Has("fps") {
+		fps, err := strconv.Atoi(query.Get("fps"))
+		if err != nil || fps < 1 || fps > config.MaxAnimationFPS {
+			return "", opts, ErrInvalidParameter.withMessage("Invalid fps. Use a number from 1 to %d.", config.MaxAnimationFPS)
+		}
+		opts.FPS = fps
+	}
+	if query.Has("loop") {
+		loop, err := strconv.Atoi(query.Get("loop"))
+		if err != nil || loop < 0 {
+			return "", opts, ErrInvalidParameter.withMessage("Invalid loop. Use a number of plays, or 0 to loop forever.")
+		}
+		opts.Loop = loop
+	}
+	return style, opts, nil
+}
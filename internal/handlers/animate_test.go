@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPlaceholderTypewriter(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		frames int
+		loop   int
+	}{
+		{"typewriter", "/placeholder/300x100.gif?text=Hi%20there&animate=typewriter", http.StatusOK, 9, 0},
+		{"plays twice", "/placeholder/300x100.gif?text=Hi&animate=typewriter&fps=5&loop=2", http.StatusOK, 3, 1},
+		{"plays once", "/placeholder/300x100.gif?text=Hi&animate=typewriter&loop=1", http.StatusOK, 3, -1},
+		{"not a gif", "/placeholder/300x100.png?text=Hi&animate=typewriter", http.StatusBadRequest, 0, 0},
+		{"unknown animation", "/placeholder/300x100.gif?text=Hi&animate=bounce", http.StatusBadRequest, 0, 0},
+		{"fps too high", "/placeholder/300x100.gif?text=Hi&animate=typewriter&fps=60", http.StatusBadRequest, 0, 0},
+		{"negative loop", "/placeholder/300x100.gif?text=Hi&animate=typewriter&loop=-1", http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			g, err := gif.DecodeAll(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(g.Image) != tt.frames || g.LoopCount != tt.loop {
+				t.Fatalf("expected %d frames looping %d, got %d looping %d", tt.frames, tt.loop, len(g.Image), g.LoopCount)
+			}
+		})
+	}
+}
+
+func TestPlaceholderShimmer(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"svg by default", "/placeholder/300x100?animate=shimmer", http.StatusOK},
+		{"both schemes", "/placeholder/300x100.svg?animate=shimmer&scheme=auto", http.StatusOK},
+		{"not svg", "/placeholder/300x100.gif?animate=shimmer", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && !strings.Contains(rec.Body.String(), "@keyframes grout-shimmer-300") {
+				t.Fatalf("expected shimmer keyframes, got %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+// artPaletteSize is the number of colors derived from the seed when no background is given.
+const artPaletteSize = 4
+
+// parsePalette splits a comma-separated color list, dropping empty entries.
+func parsePalette(s string) []string {
+	var colors []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			colors = append(colors, c)
+		}
+	}
+	return colors
+}
+
+// serveArt renders a placeholder with a seeded generative background (style=art).
+func (s *Service) serveArt(w http.ResponseWriter, r *http.Request, width, height int, text string, format render.ImageFormat) {
+	variant := genart.Variant(r.URL.Query().Get("variant"))
+	if variant == "" {
+		variant = genart.DefaultVariant
+	}
+	if !variant.IsValid() {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid variant. Use triangles, voronoi, waves, or bubbles."))
+		return
+	}
+
+	seedParam := r.URL.Query().Get("seed")
+	if seedParam == "" {
+		seedParam = fmt.Sprintf("%dx%d", width, height)
+	}
+	seed := genart.ParseSeed(seedParam)
+
+	// Colors from the background parameter, or a palette derived from the seed
+	bgParam, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	palette := parsePalette(bgParam)
+	if len(palette) == 0 {
+		palette = genart.Palette(seed, artPaletteSize)
+	}
+
+	fgParam, err := queryColor(r, "color", accentColor, analogousColor)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgHex := foregroundColor(fgParam, palette[0])
+	// Every shape of the composition costs a path to fill, on top of its pixels
+	cost, _ := r.Context().Value(renderCostKey{}).(renderCost)
+	cost.width, cost.height, cost.shapes = width, height, genart.ShapeCount(variant, width, height)
+	r = withRenderCost(r, cost)
+
+	key := specKey("ART", artSpec{Width: width, Height: height, Variant: variant, Seed: seed, Palette: palette, Fg: fgHex, Text: text, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawArt(ctx, width, height, variant, seed, palette, text, fgHex, format)
+	})
+}
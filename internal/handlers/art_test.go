@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPlaceholderHandlerArtStyle(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"default variant", "/placeholder/800x400?style=art&seed=42", http.StatusOK, "image/svg+xml"},
+		{"voronoi PNG", "/placeholder/400x200.png?style=art&variant=voronoi&seed=7", http.StatusOK, "image/png"},
+		{"waves with palette", "/placeholder/400x200?style=art&variant=waves&bg=264653,2a9d8f,e9c46a", http.StatusOK, "image/svg+xml"},
+		{"bubbles with name seed", "/placeholder/400x200.webp?style=art&variant=bubbles&seed=jane", http.StatusOK, "image/webp"},
+		{"invalid variant", "/placeholder/400x200?style=art&variant=spirals", http.StatusBadRequest, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
+
+func TestPlaceholderHandlerArtIsSeeded(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	render := func(path string) []byte {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Body.Bytes()
+	}
+
+	first := render("/placeholder/300x150?style=art&seed=42")
+	second := render("/placeholder/300x150?style=art&seed=42")
+	other := render("/placeholder/300x150?style=art&seed=43")
+	if !bytes.Equal(first, second) {
+		t.Fatal("expected the same seed to render identically")
+	}
+	if bytes.Equal(first, other) {
+		t.Fatal("expected a different seed to render differently")
+	}
+}
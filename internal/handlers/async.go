@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"grout/internal/cache"
+	"grout/internal/config"
+	"grout/internal/kvstore"
+	"grout/internal/render"
+)
+
+// Render job statuses.
+const (
+	jobPending = "pending"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// jobKeyPrefix namespaces render jobs in the job store.
+const jobKeyPrefix = "job:"
+
+// renderJobStatus is a render job as reported by /api/v1/jobs/{id}. URL is where
+// the image is served once the job is done, with names hashed in
+// no-personal-data mode.
+type renderJobStatus struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+	URL       string `json:"url"`
+	Code      string `json:"code,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// RetryAfter is when to try a job refused by maintenance mode again, in seconds
+	RetryAfter int64 `json:"retry_after,omitempty"`
+	// CreatedAt is when the job was queued, StartedAt and FinishedAt when a
+	// worker picked it up and when rendering ended, and DurationMS the time
+	// rendering took
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMS int64      `json:"duration_ms,omitempty"`
+}
+
+// renderJob is an image waiting to be rendered into its theme's cache.
+type renderJob struct {
+	status   renderJobStatus
+	cache    *cache.Hashed
+	cacheKey string
+	render   func(ctx context.Context) ([]byte, error)
+	// charge is the render counted against a quota, given back if it fails
+	charge *quotaCharge
+}
+
+// jobQueue runs async=true renders in the background. Its workers start with
+// the first job; job statuses expire from the store after the retention period.
+type jobQueue struct {
+	store     kvstore.Store
+	queue     chan renderJob
+	retention time.Duration
+	start     sync.Once
+	// mu makes checking for a job and queueing it atomic, so identical requests
+	// share one job
+	mu sync.Mutex
+	// running, done, and failed count jobs being rendered, and jobs finished
+	// since the server started
+	running atomic.Int64
+	done    atomic.Uint64
+	failed  atomic.Uint64
+}
+
+func newJobQueue(retention time.Duration) *jobQueue {
+	return &jobQueue{store: kvstore.NewMemory(), queue: make(chan renderJob, config.AsyncQueueSize), retention: retention}
+}
+
+func (q *jobQueue) load(ctx context.Context, id string) (renderJobStatus, error) {
+	var status renderJobStatus
+	data, err := q.store.Get(ctx, jobKeyPrefix+id)
+	if err != nil {
+		return status, err
+	}
+	err = json.Unmarshal(data, &status)
+	return status, err
+}
+
+func (q *jobQueue) save(ctx context.Context, status renderJobStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return q.store.Set(ctx, jobKeyPrefix+status.ID, data, q.retention)
+}
+
+// asyncRequested reports whether a request asks for its image to be rendered in
+// the background.
+func asyncRequested(r *http.Request) bool {
+	async := r.URL.Query().Get("async")
+	return async == "true" || async == "1"
+}
+
+// assetURL returns the URL an async request's image is served at: the request's
+// own URL without async. That's the canonical URL when canonical redirects are
+// on, as the request was redirected there first.
+func assetURL(u *url.URL) string {
+	query := u.Query()
+	query.Del("async")
+	asset := u.EscapedPath()
+	if encoded := query.Encode(); encoded != "" {
+		asset += "?" + encoded
+	}
+	return asset
+}
+
+// jobURL returns the URL a job's status reports: its asset URL, with names
+// hashed as in the access log in no-personal-data mode, since statuses are
+// stored. The client that queued the job knows the URL as it sent it.
+func (s *Service) jobURL(u *url.URL) string {
+	asset := assetURL(u)
+	if !s.cfg.NoPersonalData {
+		return asset
+	}
+	scrubbed, err := url.Parse(asset)
+	if err != nil {
+		return ""
+	}
+	return s.logURL(scrubbed)
+}
+
+// serveAsync queues the image serveImage would render, and responds with 202
+// and the job's status, or 200 if the image is already cached. Jobs are named
+// after the cache key, so repeating a request while its job is queued or running
+// doesn't queue it again.
+func (s *Service) serveAsync(w http.ResponseWriter, r *http.Request, t *theme, cacheKey string, format render.ImageFormat, opts render.EncodeOptions, generator func(ctx context.Context) ([]byte, error), charge *quotaCharge) {
+	if s.cfg.AsyncWorkers == 0 {
+		s.refundRender(r.Context(), charge)
+		s.failJSON(w, r, ErrFeatureDisabled)
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.refundRender(r.Context(), charge)
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("async=true needs a GET request."))
+		return
+	}
+	digest := cache.HashKey(cacheKey)
+	id := hex.EncodeToString([]byte(digest[:16]))
+	now := time.Now().UTC()
+	status := renderJobStatus{ID: id, StatusURL: "/api/v1/jobs/" + id, URL: s.jobURL(r.URL), CreatedAt: now}
+	ctx := r.Context()
+	// Statuses change, so neither they nor the 202 may be cached
+	w.Header().Set("Cache-Control", "no-store")
+
+	if _, ok := t.cache.Get(cacheKey); ok {
+		status.Status = jobDone
+		status.StartedAt, status.FinishedAt = &now, &now
+		if err := s.jobs.save(ctx, status); err != nil {
+			s.refundRender(ctx, charge)
+			s.failJSON(w, r, err)
+			return
+		}
+		w.Header().Set("Location", status.StatusURL)
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+
+	s.jobs.mu.Lock()
+	defer s.jobs.mu.Unlock()
+	if existing, err := s.jobs.load(ctx, id); err == nil && (existing.Status == jobPending || existing.Status == jobRunning) {
+		w.Header().Set("Location", existing.StatusURL)
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusAccepted, existing)
+		return
+	}
+
+	// The status is saved before the job is queued, so a worker can't mark the
+	// job running before it's marked pending
+	status.Status = jobPending
+	if err := s.jobs.save(ctx, status); err != nil {
+		s.refundRender(ctx, charge)
+		s.failJSON(w, r, err)
+		return
+	}
+	reqID := requestID(r)
+	job := renderJob{
+		status:   status,
+		cache:    t.cache,
+		cacheKey: cacheKey,
+		render: func(ctx context.Context) ([]byte, error) {
+			return s.renderImage(ctx, generator, opts, format, reqID)
+		},
+		charge: charge,
+	}
+	select {
+	case s.jobs.queue <- job:
+	default:
+		s.refundRender(ctx, charge)
+		_ = s.jobs.store.Delete(ctx, jobKeyPrefix+status.ID)
+		w.Header().Set("Retry-After", "5")
+		s.failJSON(w, r, ErrQueueFull)
+		return
+	}
+	s.jobs.start.Do(func() {
+		for range s.cfg.AsyncWorkers {
+			go s.runRenderJobs()
+		}
+	})
+
+	w.Header().Set("Location", status.StatusURL)
+	w.Header().Set("Retry-After", "1")
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// runRenderJobs renders queued jobs into their caches, one at a time, recording
+// each job's outcome in its status.
+func (s *Service) runRenderJobs() {
+	for job := range s.jobs.queue {
+		status := job.status
+		if refusal, retryAfter, ok := s.maintenanceRefusal(); ok {
+			// Jobs queued before maintenance began don't start during it
+			finished := time.Now().UTC()
+			s.jobs.failed.Add(1)
+			status.Status, status.Code, status.Error, status.RetryAfter = jobFailed, refusal.code, refusal.message, retryAfter
+			status.FinishedAt = &finished
+			s.refundRender(context.Background(), job.charge)
+			if err := s.jobs.save(context.Background(), status); err != nil {
+				log.Printf("render job %s: save status: %v", status.ID, err)
+			}
+			continue
+		}
+		started := time.Now().UTC()
+		status.Status, status.StartedAt = jobRunning, &started
+		_ = s.jobs.save(context.Background(), status)
+		s.jobs.running.Add(1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.RenderTimeout)
+		data, err := job.render(ctx)
+		cancel()
+		finished := time.Now().UTC()
+		status.FinishedAt, status.DurationMS = &finished, finished.Sub(started).Milliseconds()
+		s.jobs.running.Add(-1)
+		if err != nil {
+			s.jobs.failed.Add(1)
+			s.refundRender(context.Background(), job.charge)
+			e := asRequestError(err)
+			s.errorCounts.add(e.code)
+			if e.status >= http.StatusInternalServerError {
+				log.Printf("render job %s: %v", status.ID, e)
+			}
+			status.Status, status.Code, status.Error = jobFailed, e.code, e.message
+		} else {
+			s.jobs.done.Add(1)
+			job.cache.Add(job.cacheKey, data)
+			status.Status = jobDone
+		}
+		if err := s.jobs.save(context.Background(), status); err != nil {
+			log.Printf("render job %s: save status: %v", status.ID, err)
+		}
+	}
+}
+
+// handleJob reports the status of a render job queued by an async=true request.
+func (s *Service) handleJob(w http.ResponseWriter, r *http.Request) {
+	status, err := s.jobs.load(r.Context(), r.PathValue("id"))
+	if errors.Is(err, kvstore.ErrNotFound) {
+		s.failJSON(w, r, ErrNotFound.withMessage("No such render job. It may have expired."))
+		return
+	}
+	if err != nil {
+		s.failJSON(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if status.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(status.RetryAfter, 10))
+	}
+	writeJSON(w, http.StatusOK, status)
+}
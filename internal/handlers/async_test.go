@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getJSON serves target and decodes its JSON body into v.
+func getJSON(t *testing.T, mux http.Handler, target string, v any) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s response %q: %v", target, rec.Body.String(), err)
+	}
+	return rec
+}
+
+// waitForJob polls a job's status URL until the job finishes.
+func waitForJob(t *testing.T, mux http.Handler, statusURL string) renderJobStatus {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var status renderJobStatus
+		if rec := getJSON(t, mux, statusURL, &status); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 from %s, got %d: %s", statusURL, rec.Code, rec.Body.String())
+		}
+		if status.Status == jobDone || status.Status == jobFailed {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s didn't finish, last status %q", status.ID, status.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAsyncRender(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	var queued renderJobStatus
+	rec := getJSON(t, mux, "/placeholder/320x200.png?async=true&bg=ff0000", &queued)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if queued.Status != jobPending && queued.Status != jobRunning {
+		t.Errorf("expected a pending job, got %q", queued.Status)
+	}
+	if want := "/api/v1/jobs/" + queued.ID; queued.StatusURL != want || rec.Header().Get("Location") != want {
+		t.Errorf("expected status URL %s, got %q and Location %q", want, queued.StatusURL, rec.Header().Get("Location"))
+	}
+	if want := "/placeholder/320x200.png?bg=ff0000"; queued.URL != want {
+		t.Errorf("expected the URL %s, got %s", want, queued.URL)
+	}
+
+	done := waitForJob(t, mux, queued.StatusURL)
+	if done.Status != jobDone {
+		t.Fatalf("expected the job to succeed, got %+v", done)
+	}
+	if done.CreatedAt.IsZero() || done.StartedAt == nil || done.FinishedAt == nil {
+		t.Fatalf("expected the job's times, got %+v", done)
+	}
+	if done.StartedAt.Before(done.CreatedAt) || done.FinishedAt.Before(*done.StartedAt) || !done.CreatedAt.Equal(queued.CreatedAt) {
+		t.Errorf("expected the job to be created, started, and finished in order, got %+v", done)
+	}
+
+	// The rendered image is cached at its URL
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, done.URL, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected a cache hit at %s, got %d with X-Cache %q", done.URL, rec.Code, rec.Header().Get("X-Cache"))
+	}
+
+	// Asking again for a rendered image reports it done straight away
+	var again renderJobStatus
+	if rec := getJSON(t, mux, "/placeholder/320x200.png?bg=ff0000&async=1", &again); rec.Code != http.StatusOK || again.Status != jobDone || again.ID != queued.ID {
+		t.Errorf("expected 200 and the done job %s, got %d and %+v", queued.ID, rec.Code, again)
+	}
+}
+
+func TestAsyncRenderFailure(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	var queued renderJobStatus
+	if rec := getJSON(t, mux, "/placeholder/320x200.svg?maxBytes=10&async=true", &queued); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	failed := waitForJob(t, mux, queued.StatusURL)
+	if failed.Status != jobFailed || failed.Code != "over_budget" || failed.Error == "" {
+		t.Errorf("expected an over_budget failure, got %+v", failed)
+	}
+}
+
+func TestAsyncRenderRetention(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.jobs.retention = 50 * time.Millisecond
+
+	var queued renderJobStatus
+	if rec := getJSON(t, mux, "/placeholder/100x100.png?async=true", &queued); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	waitForJob(t, mux, queued.StatusURL)
+	time.Sleep(100 * time.Millisecond)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, queued.StatusURL, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected the job to expire, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAsyncRenderAdminStats(t *testing.T) {
+	mux := setupAdminTestService(t)
+
+	for _, target := range []string{"/placeholder/100x100.png?async=true", "/placeholder/100x100.svg?maxBytes=10&async=true"} {
+		var queued renderJobStatus
+		if rec := getJSON(t, mux, target, &queued); rec.Code != http.StatusAccepted {
+			t.Fatalf("expected 202 for %s, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		waitForJob(t, mux, queued.StatusURL)
+	}
+	_, resp := getAdminStats(t, mux, testAdminToken)
+	if resp.Jobs.Done != 1 || resp.Jobs.Failed != 1 || resp.Jobs.Running != 0 || resp.Jobs.Queued != 0 {
+		t.Errorf("expected one done and one failed job, got %+v", resp.Jobs)
+	}
+}
+
+func TestAsyncRenderErrors(t *testing.T) {
+	svc, mux := setupTestService(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{"unknown job", http.MethodGet, "/api/v1/jobs/0123456789abcdef", http.StatusNotFound, "not_found"},
+		{"not a GET", http.MethodHead, "/placeholder/100x100.png?async=true", http.StatusBadRequest, "invalid_parameter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.wantStatus || rec.Header().Get("X-Error-Code") != tt.wantCode {
+				t.Errorf("expected %d %s, got %d %s", tt.wantStatus, tt.wantCode, rec.Code, rec.Header().Get("X-Error-Code"))
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		svc.cfg.AsyncWorkers = 0
+		defer func() { svc.cfg.AsyncWorkers = 2 }()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/100x100.png?async=true", nil))
+		if rec.Code != http.StatusNotFound || rec.Header().Get("X-Error-Code") != "feature_disabled" {
+			t.Errorf("expected 404 feature_disabled, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+		}
+	})
+}
+
+func TestAsyncRenderQueueFull(t *testing.T) {
+	svc, mux := setupTestService(t)
+	// Fill the queue without starting its workers
+	svc.jobs.start.Do(func() {})
+	for range cap(svc.jobs.queue) {
+		svc.jobs.queue <- renderJob{}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/100x100.png?async=true", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("X-Error-Code") != "queue_full" || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 queue_full with Retry-After, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
+
+func TestAsyncRenderMaintenance(t *testing.T) {
+	svc, mux := setupTestService(t)
+	// Queue a job without starting the workers, then start one in maintenance
+	svc.jobs.start.Do(func() {})
+	var queued renderJobStatus
+	if rec := getJSON(t, mux, "/placeholder/100x100.png?async=true", &queued); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	svc.maintenance.set(maintenanceState{Enabled: true, Message: "Back soon", RetryAfter: 900})
+	go svc.runRenderJobs()
+
+	failed := waitForJob(t, mux, queued.StatusURL)
+	if failed.Status != jobFailed || failed.Code != "maintenance" || failed.Error != "Back soon" || failed.RetryAfter != 900 || failed.StartedAt != nil {
+		t.Errorf("expected the job refused for maintenance without starting, got %+v", failed)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, queued.StatusURL, nil))
+	if rec.Header().Get("Retry-After") != "900" {
+		t.Errorf("expected the status to carry Retry-After 900, got %q", rec.Header().Get("Retry-After"))
+	}
+	if stats := svc.defaultTheme.cache.Stats(); stats.Entries != 0 {
+		t.Error("expected nothing rendered")
+	}
+}
+
+func TestAsyncRenderNoPersonalData(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.NoPersonalData = true
+
+	// Statuses are stored, so they keep names hashed as the access log does
+	var queued renderJobStatus
+	rec := getJSON(t, mux, "/avatar/Jane%20Doe.png?async=true&name=Jane", &queued)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(queued.URL, "Jane") || !strings.HasPrefix(queued.URL, "/avatar/~") {
+		t.Errorf("expected the name hashed in the job URL, got %s", queued.URL)
+	}
+	waitForJob(t, mux, queued.StatusURL)
+}
+
+func TestAssetURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"async removed", "/avatar/John%20Doe.png?async=true&bg=abcdef", "/avatar/John%20Doe.png?bg=abcdef"},
+		{"query sorted", "/placeholder/100x100.png?sig=abc&async=1&bg=ABCDEF", "/placeholder/100x100.png?bg=ABCDEF&sig=abc"},
+		{"no query left", "/placeholder/100x100.png?async=true", "/placeholder/100x100.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := assetURL(u); got != tt.want {
+				t.Errorf("assetURL(%s) = %s, want %s", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsyncRenderSigned(t *testing.T) {
+	mux := setupSigningTestService(t)
+
+	// async isn't covered by the signature, so it can be added to a signed URL
+	var queued renderJobStatus
+	target := signed(t, "/placeholder/100x100.png")
+	if rec := getJSON(t, mux, target+"&async=true", &queued); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if queued.URL != target {
+		t.Errorf("expected the signed URL %s, got %s", target, queued.URL)
+	}
+	if done := waitForJob(t, mux, queued.StatusURL); done.Status != jobDone {
+		t.Fatalf("expected the job to succeed, got %+v", done)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, queued.URL, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected a cache hit at %s, got %d with X-Cache %q", queued.URL, rec.Code, rec.Header().Get("X-Cache"))
+	}
+}
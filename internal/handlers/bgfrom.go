@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"grout/internal/config"
+)
+
+// backgroundFrom returns the dominant color of the image named by the bgFrom
+// parameter, for placeholders that match the site they sit in. It returns ""
+// without bgFrom, and writes an error page and returns false when the image
+// can't be fetched or used. Colors are extracted as /api/v1/palette does, and
+// share its cache.
+func (s *Service) backgroundFrom(w http.ResponseWriter, r *http.Request) (string, bool) {
+	rawURL := r.URL.Query().Get("bgFrom")
+	if rawURL == "" {
+		return "", true
+	}
+	if !s.validateProxyURL(w, r, rawURL) {
+		return "", false
+	}
+	swatches, err := s.extractPalette(r, rawURL, config.DefaultPaletteCount)
+	if err != nil {
+		s.fail(w, r, ErrUpstreamFailed.withCause(err))
+		return "", false
+	}
+	if len(swatches) == 0 {
+		s.fail(w, r, ErrInvalidImage.withMessage("The bgFrom image has no opaque pixels."))
+		return "", false
+	}
+	return swatches[0].Hex, true
+}
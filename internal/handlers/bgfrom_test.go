@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestBackgroundFrom(t *testing.T) {
+	srv := newImageServer(t)
+	mux := setupProxyTestService(t)
+	photo := url.QueryEscape(srv.URL + "/photo.png")
+
+	tests := []struct {
+		name   string
+		path   string
+		wantBg string
+	}{
+		{"placeholder", "/placeholder/300x200?bgFrom=" + photo, "1e88e5"},
+		{"placeholder in dark mode", "/placeholder/300x200?scheme=dark&bgFrom=" + photo, "1e88e5"},
+		{"placehold.co path", "/600x400?bgFrom=" + photo, "1e88e5"},
+		{"avatar", "/avatar/John%20Doe?bgFrom=" + photo, "1e88e5"},
+		{"bg wins", "/placeholder/300x200?bg=ff0000&bgFrom=" + photo, "ff0000"},
+		{"path color wins", "/600x400/00ff00?bgFrom=" + photo, "00ff00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path+"&explain=true", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var e explanation
+			if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if e.Background != tt.wantBg {
+				t.Errorf("expected background %s, got %s", tt.wantBg, e.Background)
+			}
+		})
+	}
+}
+
+func TestBackgroundFromErrors(t *testing.T) {
+	srv := newImageServer(t)
+	mux := setupProxyTestService(t)
+	_, disabled := setupTestService(t)
+
+	tests := []struct {
+		name     string
+		mux      *http.ServeMux
+		path     string
+		wantCode string
+	}{
+		{"proxy disabled", disabled, "/placeholder/300x200?bgFrom=" + url.QueryEscape(srv.URL+"/photo.png"), "feature_disabled"},
+		{"host not allowed", mux, "/avatar/Jo?bgFrom=" + url.QueryEscape("https://example.com/a.png"), "host_not_allowed"},
+		{"invalid url", mux, "/placeholder/300x200?bgFrom=ftp%3A%2F%2Fexample.com%2Fa.png", "invalid_url"},
+		{"upstream missing", mux, "/placeholder/300x200?bgFrom=" + url.QueryEscape(srv.URL+"/nope.png"), "upstream_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := rec.Header().Get("X-Error-Code"); got != tt.wantCode {
+				t.Errorf("expected error code %s, got %s (%d)", tt.wantCode, got, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// brandAssetMaxAge is the Cache-Control max-age for brand assets. Unlike generated
+// images, these can change when an operator edits the config or static directory.
+const brandAssetMaxAge = "public, max-age=86400"
+
+// logoFiles lists the logo overrides looked up in the static directory, in order.
+var logoFiles = []string{"logo.svg", "logo.png"}
+
+// webManifest is the JSON body of /site.webmanifest.
+type webManifest struct {
+	Name            string            `json:"name"`
+	ShortName       string            `json:"short_name"`
+	StartURL        string            `json:"start_url"`
+	Display         string            `json:"display"`
+	ThemeColor      string            `json:"theme_color"`
+	BackgroundColor string            `json:"background_color"`
+	Icons           []webManifestIcon `json:"icons"`
+}
+
+type webManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// renderPage fills the shared placeholders of an HTML page template with the
+// domain and branding of the request's tenant.
+func (s *Service) renderPage(r *http.Request, template string) string {
+	t := s.themeFor(r)
+	return strings.NewReplacer(
+		"{{DOMAIN}}", t.domain,
+		"{{BRAND_NAME}}", html.EscapeString(t.brandName),
+		"{{BRAND_COLOR}}", t.brandColor,
+		"{{BRAND_LOGO}}", s.brandLogoHTML(t.brandName),
+		"{{FOOTER_LINKS}}", footerLinksHTML(t.footerLinks),
+		"{{MAINTENANCE_BANNER}}", s.maintenanceBannerHTML(),
+	).Replace(template)
+}
+
+// brandLogoHTML returns an <img> for the logo in the static directory, or the
+// default emoji mark when there is none.
+func (s *Service) brandLogoHTML(brandName string) string {
+	for _, name := range logoFiles {
+		if _, ok := s.readStaticAsset(name); ok {
+			return fmt.Sprintf(`<img src="/%s" alt="%s logo" class="brand-logo">`, name, html.EscapeString(brandName))
+		}
+	}
+	return "🎨"
+}
+
+// footerLinksHTML renders footer links as anchors separated by bullets.
+func footerLinksHTML(links []config.FooterLink) string {
+	parts := make([]string, 0, len(links))
+	for _, link := range links {
+		parts = append(parts, fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener">%s</a>`, html.EscapeString(link.URL), html.EscapeString(link.Label)))
+	}
+	return strings.Join(parts, " • ")
+}
+
+// writeBrandAsset writes a static brand asset with a sniffed content type.
+func writeBrandAsset(w http.ResponseWriter, data []byte, contentType string) {
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", brandAssetMaxAge)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func (s *Service) handleFavicon(w http.ResponseWriter, r *http.Request) {
+	// Operator overrides in the static directory win over the embedded icon
+	for _, name := range []string{"favicon.ico", "favicon.png"} {
+		if data, ok := s.readStaticAsset(name); ok {
+			writeBrandAsset(w, data, "")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(faviconData)
+	if err != nil {
+		return
+	}
+}
+
+func (s *Service) handleLogo(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	data, ok := s.readStaticAsset(name)
+	if !ok {
+		s.handle404(w, r)
+		return
+	}
+	contentType := ""
+	if strings.HasSuffix(name, ".svg") {
+		contentType = "image/svg+xml"
+	}
+	writeBrandAsset(w, data, contentType)
+}
+
+func (s *Service) handleAppleTouchIcon(w http.ResponseWriter, r *http.Request) {
+	if data, ok := s.readStaticAsset("apple-touch-icon.png"); ok {
+		writeBrandAsset(w, data, "image/png")
+		return
+	}
+
+	// Generate the brand initials on the brand color
+	t := s.themeFor(r)
+	spec := avatarSpec{
+		Initials: render.GetInitials(t.brandName), Size: config.AppleTouchIconSize, Bold: true,
+		Bg: t.brandColor, Fg: render.GetContrastColor(t.brandColor), Format: render.FormatPNG,
+	}
+	key := t.cacheNamespace(specKey("BRAND:ICON", spec))
+	data, ok := t.cache.Get(key)
+	if !ok {
+		var err error
+		data, err = s.renderer.DrawImageWithFormat(r.Context(), spec.Size, spec.Size, spec.Bg, spec.Fg, spec.Initials, spec.Rounded, spec.Bold, spec.Format)
+		if err != nil {
+			s.fail(w, r, ErrRenderFailed.withCause(err))
+			return
+		}
+		t.cache.Add(key, data)
+	}
+	writeBrandAsset(w, data, "image/png")
+}
+
+func (s *Service) handleWebManifest(w http.ResponseWriter, r *http.Request) {
+	t := s.themeFor(r)
+	size := fmt.Sprintf("%dx%d", config.AppleTouchIconSize, config.AppleTouchIconSize)
+	manifest := webManifest{
+		Name:            t.brandName,
+		ShortName:       t.brandName,
+		StartURL:        "/",
+		Display:         "standalone",
+		ThemeColor:      "#" + t.brandColor,
+		BackgroundColor: "#ffffff",
+		Icons: []webManifestIcon{
+			{Src: "/apple-touch-icon.png", Sizes: size, Type: "image/png"},
+		},
+	}
+
+	w.Header().Set("Cache-Control", brandAssetMaxAge)
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(manifest)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// setupBrandTestService returns a service branded "Acme Images" whose static
+// directory is a fresh temp dir.
+func setupBrandTestService(t *testing.T) (string, *http.ServeMux) {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](10)
+	cfg := config.DefaultServerConfig()
+	cfg.StaticDir = t.TempDir()
+	cfg.BrandName = "Acme Images"
+	cfg.BrandColor = "ff5722"
+	cfg.FooterLinks = []config.FooterLink{{Label: "Status", URL: "https://status.example.com"}, {Label: "Terms & Privacy", URL: "https://example.com/terms"}}
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return cfg.StaticDir, mux
+}
+
+func TestHomePageBranding(t *testing.T) {
+	staticDir, mux := setupBrandTestService(t)
+
+	get := func() string {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	body := get()
+	for _, want := range []string{
+		`<meta name="theme-color" content="#ff5722">`,
+		`<link rel="manifest" href="/site.webmanifest">`,
+		`🎨 Acme Images`,
+		`<a href="https://status.example.com" target="_blank" rel="noopener">Status</a>`,
+		`Terms &amp; Privacy`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected home page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "{{BRAND_") || strings.Contains(body, "{{FOOTER_LINKS}}") {
+		t.Error("expected all brand placeholders to be replaced")
+	}
+
+	// A logo in the static directory replaces the default mark
+	if err := os.WriteFile(filepath.Join(staticDir, "logo.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0o644); err != nil {
+		t.Fatalf("write logo: %v", err)
+	}
+	if body := get(); !strings.Contains(body, `<img src="/logo.svg" alt="Acme Images logo" class="brand-logo">`) {
+		t.Error("expected home page to use the static logo")
+	}
+}
+
+func TestErrorPageBranding(t *testing.T) {
+	_, mux := setupBrandTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "| Acme Images</title>") || !strings.Contains(body, "https://status.example.com") {
+		t.Fatal("expected error page to use the configured brand")
+	}
+}
+
+func TestBrandAssetOverrides(t *testing.T) {
+	staticDir, mux := setupBrandTestService(t)
+	icon := []byte{0x00, 0x00, 0x01, 0x00, 0x01, 0x00}
+	logo := []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)
+	if err := os.WriteFile(filepath.Join(staticDir, "favicon.ico"), icon, 0o644); err != nil {
+		t.Fatalf("write favicon: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(staticDir, "logo.svg"), logo, 0o644); err != nil {
+		t.Fatalf("write logo: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		expectStatus int
+		expectType   string
+		expectBody   []byte
+	}{
+		{"favicon override", "/favicon.ico", http.StatusOK, "image/x-icon", icon},
+		{"svg logo", "/logo.svg", http.StatusOK, "image/svg+xml", logo},
+		{"missing png logo", "/logo.png", http.StatusNotFound, "text/html; charset=utf-8", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.expectType {
+				t.Fatalf("expected content-type %s got %s", tt.expectType, ct)
+			}
+			if tt.expectBody != nil && !bytes.Equal(rec.Body.Bytes(), tt.expectBody) {
+				t.Fatal("expected the static file contents")
+			}
+		})
+	}
+}
+
+func TestAppleTouchIcon(t *testing.T) {
+	_, mux := setupBrandTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/apple-touch-icon.png", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != config.AppleTouchIconSize || b.Dy() != config.AppleTouchIconSize {
+		t.Fatalf("expected %dpx icon, got %v", config.AppleTouchIconSize, b)
+	}
+	// The corner is plain brand color
+	if r, g, b, _ := img.At(2, 2).RGBA(); r>>8 != 0xff || g>>8 != 0x57 || b>>8 != 0x22 {
+		t.Fatalf("expected brand color background, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestWebManifest(t *testing.T) {
+	_, mux := setupBrandTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/site.webmanifest", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/manifest+json" {
+		t.Fatalf("expected manifest content type, got %s", ct)
+	}
+	var manifest webManifest
+	if err := json.NewDecoder(rec.Body).Decode(&manifest); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if manifest.Name != "Acme Images" || manifest.ThemeColor != "#ff5722" {
+		t.Fatalf("unexpected manifest: %+v", manifest)
+	}
+	if len(manifest.Icons) != 1 || manifest.Icons[0].Src != "/apple-touch-icon.png" || manifest.Icons[0].Sizes != "180x180" {
+		t.Fatalf("unexpected manifest icons: %+v", manifest.Icons)
+	}
+}
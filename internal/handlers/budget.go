@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"unicode/utf8"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// effectCosts is the work of each effect relative to drawing an image without
+// one: per-pixel effects redraw the whole image, overlays only add shapes.
+var effectCosts = map[render.Effect]int64{
+	render.EffectConfetti: 2,
+	render.EffectSparkle:  2,
+	render.EffectVignette: 3,
+	render.EffectHalftone: 4,
+	render.EffectDither:   4,
+}
+
+// shapeCost is the work of drawing one generated shape on top of the pixels it
+// covers, in pixels drawn: tracing and filling its path costs about as much as
+// a 64 x 64 area.
+const shapeCost = 64 * 64
+
+// renderCost is an upfront estimate of the work of rendering an image, taken
+// from its parameters alone.
+type renderCost struct {
+	width, height int
+	frames        int // 0 for a still image
+	effect        render.Effect
+	// supersample is the factor asked for raster images to be drawn larger by
+	supersample int
+	// shapes is the number of generated shapes drawn, 0 for images without any
+	shapes int
+}
+
+// total returns the estimate in pixels drawn, saturating at math.MaxInt64 so
+// sizes crafted to overflow it still count as over budget. Each shape adds
+// shapeCost.
+func (c renderCost) total() int64 {
+	factor, ok := effectCosts[c.effect]
+	if !ok {
+		factor = 1
+	}
+	sample := int64(render.SupersampleFactor(c.width, c.height, c.supersample))
+	cost := int64(1)
+	for _, n := range []int64{int64(c.width), int64(c.height), int64(max(c.frames, 1)), factor, sample * sample} {
+		if n <= 0 || cost > math.MaxInt64/n {
+			return math.MaxInt64
+		}
+		cost *= n
+	}
+	shapes := int64(c.shapes) * shapeCost
+	if shapes > math.MaxInt64-cost {
+		return math.MaxInt64
+	}
+	return cost + shapes
+}
+
+// String spells out how the estimate adds up, for errors.
+func (c renderCost) String() string {
+	s := fmt.Sprintf("%d x %d pixels", c.width, c.height)
+	if c.frames > 1 {
+		s += fmt.Sprintf(" x %d frames", c.frames)
+	}
+	if factor, ok := effectCosts[c.effect]; ok {
+		s += fmt.Sprintf(" x %d for effect=%s", factor, c.effect)
+	}
+	if sample := render.SupersampleFactor(c.width, c.height, c.supersample); sample > 1 {
+		s += fmt.Sprintf(" x %d for supersample=%d", sample*sample, sample)
+	}
+	if c.shapes > 0 {
+		s += fmt.Sprintf(" + %d shapes", c.shapes)
+	}
+	return s
+}
+
+// typewriterFrames estimates the frames of a typewriter animation of text: one
+// per character, up to config.MaxAnimationFrames with the finished text held.
+func typewriterFrames(text string) int {
+	return min(utf8.RuneCountInString(text), config.MaxAnimationFrames-1) + 1
+}
+
+type renderCostKey struct{}
+
+// withRenderCost attaches the estimated cost of the image a request renders, for
+// serveImage to check against the render budget before rendering it.
+func withRenderCost(r *http.Request, cost renderCost) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), renderCostKey{}, cost))
+}
+
+// checkRenderBudget rejects a request whose image is estimated to cost more
+// than the server's render budget. Requests without an estimate pass.
+func (s *Service) checkRenderBudget(r *http.Request) error {
+	cost, ok := r.Context().Value(renderCostKey{}).(renderCost)
+	if !ok || s.cfg.RenderBudget <= 0 || cost.total() <= s.cfg.RenderBudget {
+		return nil
+	}
+	return ErrTooComplex.withMessage("The image is too complex to render: %s is %d pixels drawn, over this server's budget of %d. Try a smaller size, a shorter animation, no effect, or no supersampling.", cost, cost.total(), s.cfg.RenderBudget)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+func TestRenderCost(t *testing.T) {
+	tests := []struct {
+		name string
+		cost renderCost
+		want int64
+	}{
+		{"still", renderCost{width: 100, height: 50}, 5000},
+		{"frames", renderCost{width: 100, height: 50, frames: 10}, 50000},
+		{"effect", renderCost{width: 100, height: 50, effect: render.EffectHalftone}, 20000},
+		{"frames and effect", renderCost{width: 10, height: 10, frames: 3, effect: render.EffectConfetti}, 600},
+		{"supersample", renderCost{width: 100, height: 50, supersample: 2}, 20000},
+		{"supersample lowered to fit", renderCost{width: 1000, height: 50, supersample: 4}, 200000},
+		{"shapes", renderCost{width: 10, height: 10, shapes: 2}, 100 + 2*shapeCost},
+		{"overflow", renderCost{width: math.MaxInt32 * 4, height: math.MaxInt32 * 4, frames: 200}, math.MaxInt64},
+		{"wrapped negative", renderCost{width: -5, height: 10}, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cost.total(); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	if got := typewriterFrames("Hello"); got != 6 {
+		t.Errorf("expected a frame per character plus the hold, got %d", got)
+	}
+	if got := typewriterFrames(strings.Repeat("a", 1000)); got != 200 {
+		t.Errorf("expected long text to be capped at 200 frames, got %d", got)
+	}
+}
+
+func TestRenderBudget(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"small placeholder", "/placeholder/400x300.png", http.StatusOK},
+		{"huge placeholder", "/placeholder/10000x10000.gif?animate=typewriter&text=abc", http.StatusUnprocessableEntity},
+		{"long typewriter", "/placeholder/2000x1000.gif?animate=typewriter&text=" + strings.Repeat("a", 150), http.StatusUnprocessableEntity},
+		{"typewriter", "/placeholder/100x100.gif?animate=typewriter&text=abc", http.StatusOK},
+		{"supersampled typewriter", "/placeholder/500x500.gif?animate=typewriter&supersample=4&text=" + strings.Repeat("a", 150), http.StatusUnprocessableEntity},
+		{"supersampled svg", "/placeholder/8000x8000.svg?supersample=4", http.StatusOK},
+		{"large halftone", "/placeholder/8000x8000.png?effect=halftone", http.StatusUnprocessableEntity},
+		{"huge avatar", "/avatar/JD.png?size=20000", http.StatusUnprocessableEntity},
+		{"wide rating", "/rating/4?size=100000", http.StatusUnprocessableEntity},
+		{"tall art", "/placeholder/1x10000.png?style=art", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status != http.StatusOK && rec.Header().Get("X-Error-Code") != "too_complex" {
+				t.Errorf("expected too_complex, got %q", rec.Header().Get("X-Error-Code"))
+			}
+		})
+	}
+
+	var details explanation
+	getJSON(t, mux, "/placeholder/10000x10000.gif?animate=typewriter&text=abc&explain=true", &details)
+	if details.RenderCost != 400_000_000 {
+		t.Errorf("expected explain to report the cost of an over-budget image, got %d", details.RenderCost)
+	}
+
+	// The same animation is only over budget supersampled
+	details = explanation{}
+	getJSON(t, mux, "/placeholder/500x500.gif?animate=typewriter&explain=true&text="+strings.Repeat("a", 150), &details)
+	if details.RenderCost != 500*500*151 || details.RenderCost > config.DefaultRenderBudget {
+		t.Errorf("expected the animation without supersampling under budget, got a cost of %d", details.RenderCost)
+	}
+
+	// Generated art pays for its shapes, whose number is capped
+	details = explanation{}
+	getJSON(t, mux, "/placeholder/1x10000.png?style=art&explain=true", &details)
+	if want := int64(10000 + 8*64*2*shapeCost); details.RenderCost != want {
+		t.Errorf("expected the art to cost %d with its shapes, got %d", want, details.RenderCost)
+	}
+}
+
+func TestRenderBudgetConfig(t *testing.T) {
+	svc, mux := setupTestService(t)
+	target := "/placeholder/300x200.gif?animate=typewriter&text=Hello%20there&effect=sparkle"
+
+	svc.cfg.RenderBudget = 1_000_000
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	want := "300 x 200 pixels x 12 frames x 2 for effect=sparkle is 1440000 pixels drawn, over this server's budget of 1000000"
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected the error to spell out the estimate %q, got %d: %s", want, rec.Code, rec.Body.String())
+	}
+
+	svc.cfg.RenderBudget = 0
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected a budget of 0 to turn the check off, got %d", rec.Code)
+	}
+}
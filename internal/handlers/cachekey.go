@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+// specKey returns the cache key of an image of kind rendered from spec: the
+// typed parameters it's drawn from, once parsed, normalized, and defaulted,
+// and with content such as a quote already picked. The spec is written as
+// JSON, so values are quoted and escaped and a name or text containing ":"
+// can't run into the next field, and the key depends on what's drawn rather
+// than on how a handler happens to format it. Two requests share an entry
+// exactly when their kind and spec are equal.
+func specKey(kind string, spec any) string {
+	// Specs hold only strings, numbers, booleans, and slices and structs of
+	// them, which always marshal
+	data, _ := json.Marshal(spec)
+	return kind + ":" + string(data)
+}
+
+// outputSpec is how a served image differs from the image its spec draws: the
+// encoder and text settings that aren't the server's defaults, and the alt
+// text labeling an SVG. It's empty for most requests, and left out of their
+// keys.
+type outputSpec struct {
+	Quality     int    `json:",omitempty"`
+	Subsample   string `json:",omitempty"`
+	Effort      string `json:",omitempty"`
+	MaxBytes    int    `json:",omitempty"`
+	Hinting     string `json:",omitempty"`
+	AA          string `json:",omitempty"`
+	Supersample int    `json:",omitempty"`
+	Alt         string `json:",omitempty"`
+}
+
+// key returns the suffix an image's cache key takes for o.
+func (o outputSpec) key() string {
+	if o == (outputSpec{}) {
+		return ""
+	}
+	return specKey("", o)
+}
+
+// The specs of each kind of image follow. A spec holds every value its
+// renderer is called with, since any of them can change the image, and
+// values derived from the request, such as a default alt text, only through
+// the fields they're derived from.
+
+// avatarSpec is an avatar of initials on a solid color, or of no initials for
+// Discord and Gravatar defaults. Names are reduced to their initials, and
+// random colors resolved, so names that share initials share an entry.
+type avatarSpec struct {
+	Initials       string `json:",omitempty"`
+	Size           int
+	Rounded, Bold  bool
+	Bg, Fg         string
+	DarkBg, DarkFg string      `json:",omitempty"`
+	Scheme         colorScheme `json:",omitempty"`
+	// FontScale is the font size as a fraction of the size, 0 for the default
+	FontScale  float64       `json:",omitempty"`
+	Effect     render.Effect `json:",omitempty"`
+	EffectSeed uint64        `json:",omitempty"`
+	Format     render.ImageFormat
+}
+
+// placeholderSpec is a placeholder of text, or a quote or joke, on a solid
+// color or gradient.
+type placeholderSpec struct {
+	Width, Height  int
+	Bg, Fg         string
+	DarkBg, DarkFg string
+	Scheme         colorScheme
+	Text           string
+	// Quote lays the text out as a quote or joke
+	Quote      bool                     `json:",omitempty"`
+	Animate    string                   `json:",omitempty"`
+	Animation  *render.AnimationOptions `json:",omitempty"`
+	Effect     render.Effect            `json:",omitempty"`
+	EffectSeed uint64                   `json:",omitempty"`
+	Format     render.ImageFormat
+}
+
+// patternSpec is a generated pattern, optionally labeled with text.
+type patternSpec struct {
+	Pattern       render.Pattern
+	Width, Height int
+	render.PatternOptions
+	Fg, Text      string `json:",omitempty"`
+	Rounded, Bold bool   `json:",omitempty"`
+	Format        render.ImageFormat
+}
+
+// artSpec is a generative art placeholder.
+type artSpec struct {
+	Width, Height int
+	Variant       genart.Variant
+	Seed          uint64
+	Palette       []string
+	Fg, Text      string
+	Format        render.ImageFormat
+}
+
+// badgeSpec is a shields.io endpoint badge, once its document and the query
+// parameters overriding it are resolved.
+type badgeSpec struct {
+	render.Badge
+	Format render.ImageFormat
+}
+
+// calendarSpec is a calendar tile; Date is in the YYYY-MM-DD layout.
+type calendarSpec struct {
+	Width, Height  int
+	Date, Locale   string
+	Header, Bg, Fg string
+	Format         render.ImageFormat
+}
+
+// dividerSpec is a section divider.
+type dividerSpec struct {
+	Width, Height int
+	Style         render.DividerStyle
+	Seed          uint64
+	Fill, Bg      string
+	Flip          bool
+	Format        render.ImageFormat
+}
+
+// opsSpec is an image drawn by an ops pipeline, as parsed.
+type opsSpec struct {
+	Width, Height int
+	Ops           []render.Op
+	Seed          uint64
+	Format        render.ImageFormat
+}
+
+// paletteStripSpec is a strip of an image's palette colors.
+type paletteStripSpec struct {
+	Colors        []string
+	Width, Height int
+	Format        render.ImageFormat
+}
+
+// paletteSpec is the palette extracted from a remote image; its entry holds
+// the swatches as JSON rather than an image.
+type paletteSpec struct {
+	URL   string
+	Count int
+}
+
+// picsumSpec is a generated stand-in for a Lorem Picsum photo.
+type picsumSpec struct {
+	Width, Height int
+	render.NoiseOptions
+	Format render.ImageFormat
+}
+
+// ratingSpec is a row of rating stars.
+type ratingSpec struct {
+	Value           float64
+	Max, Size       int
+	Fill, Empty, Bg string
+	Format          render.ImageFormat
+}
+
+// resizeSpec is a remote image resized; Width and Height are as requested, 0
+// to follow the aspect ratio.
+type resizeSpec struct {
+	URL           string
+	Width, Height int
+	Fit           render.FitMode
+	Bg            string `json:",omitempty"`
+	Email         bool   `json:",omitempty"`
+	Format        render.ImageFormat
+}
+
+// photoAvatarSpec is a remote photo cropped into an avatar, optionally with
+// initials over it.
+type photoAvatarSpec struct {
+	URL           string
+	Size          int
+	Rounded, Bold bool
+	Initials, Fg  string `json:",omitempty"`
+	Format        render.ImageFormat
+}
+
+// spinnerSpec is an animated loading spinner.
+type spinnerSpec struct {
+	Size   int
+	Style  render.SpinnerStyle
+	Fg, Bg string
+	Format render.ImageFormat
+}
+
+// tableSpec is a placeholder data table.
+type tableSpec struct {
+	Width, Height int
+	Rows, Cols    int
+	Seed          uint64
+	Style         render.TableStyle
+	Format        render.ImageFormat
+}
+
+// textSpec is a line of text on a transparent background.
+type textSpec struct {
+	Text, Font string
+	Size       int
+	Fg         string
+	Format     render.ImageFormat
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"grout/internal/render"
+)
+
+func TestSpecKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{
+			"colon in text",
+			specKey("TEXT", textSpec{Text: "a:b", Font: "c", Format: render.FormatPNG}),
+			specKey("TEXT", textSpec{Text: "a", Font: "b:c", Format: render.FormatPNG}),
+		},
+		{
+			"quote and text",
+			specKey("PH", placeholderSpec{Width: 800, Height: 400, Text: "Be yourself", Quote: true, Format: render.FormatPNG}),
+			specKey("PH", placeholderSpec{Width: 800, Height: 400, Text: "Be yourself", Format: render.FormatPNG}),
+		},
+		{
+			"alt and options",
+			specKey("PH", placeholderSpec{Format: render.FormatWebP}) + outputSpec{Alt: "x", Quality: 50}.key(),
+			specKey("PH", placeholderSpec{Format: render.FormatWebP}) + outputSpec{Alt: `x","Quality":50`}.key(),
+		},
+		{
+			"kind",
+			specKey("DICEBEAR:initials", avatarSpec{Initials: "JD", Size: 64, Format: render.FormatPNG}),
+			specKey("UIA", avatarSpec{Initials: "JD", Size: 64, Format: render.FormatPNG}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.a == tt.b {
+				t.Errorf("expected distinct keys, both are %s", tt.a)
+			}
+		})
+	}
+
+	if key := (outputSpec{}).key(); key != "" {
+		t.Errorf("expected default output to add nothing to a key, got %q", key)
+	}
+	a := specKey("PICSUM", picsumSpec{Width: 10, Height: 10, NoiseOptions: render.NoiseOptions{Seed: 1}, Format: render.FormatJPG})
+	b := specKey("PICSUM", picsumSpec{Width: 10, Height: 10, NoiseOptions: render.NoiseOptions{Seed: 1}, Format: render.FormatJPG})
+	if a != b {
+		t.Errorf("expected equal specs to share a key, got %s and %s", a, b)
+	}
+}
+
+func TestCacheKeySeparatesFields(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	// Joined with ":", both of these were keyed "...:x:svg:light:ALT:y:svg:light:ALT:z"
+	etags := make(map[string]string)
+	for _, target := range []string{
+		"/placeholder/120x60.svg?text=x%3Asvg%3Alight%3AALT%3Ay&alt=z",
+		"/placeholder/120x60.svg?text=x&alt=y%3Asvg%3Alight%3AALT%3Az",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, rec.Code)
+		}
+		if rec.Header().Get("X-Cache") == "HIT" {
+			t.Errorf("%s: expected a cache miss, got a hit", target)
+		}
+		etag := rec.Header().Get("ETag")
+		if other, ok := etags[etag]; ok {
+			t.Errorf("expected %s and %s to have distinct ETags, both have %s", other, target, etag)
+		}
+		etags[etag] = target
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"grout/internal/cache"
+	"grout/internal/config"
+)
+
+// cacheImportResponse is the JSON body of the cache import API.
+type cacheImportResponse struct {
+	Imported int `json:"imported"`
+	// Skipped counts entries of tenants this server doesn't have
+	Skipped int `json:"skipped"`
+}
+
+// snapshotCaches returns the image cache of every tenant by ID, the server-wide
+// one as the tenant "default". Tenants sharing the server-wide cache are left
+// out, as its entries are already under "default".
+func (s *Service) snapshotCaches() map[string]*cache.Hashed {
+	caches := map[string]*cache.Hashed{config.DefaultTenantID: s.defaultTheme.cache}
+	for _, t := range s.tenants() {
+		if t.cache != s.defaultTheme.cache {
+			caches[t.tenantID] = t.cache
+		}
+	}
+	return caches
+}
+
+// handleAdminCacheExport streams a snapshot of the cached images, so a newly
+// deployed server can import it and start warm. limit caps the entries taken
+// from each tenant's cache to its most recently used.
+func (s *Service) handleAdminCacheExport(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid limit: use a positive number of entries"))
+			return
+		}
+		limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="grout-cache.bin"`)
+	w.Header().Set("Cache-Control", "no-store")
+	if err := s.WriteCacheSnapshot(w, limit); err != nil {
+		// The client went away; the response is already under way
+		log.Printf("export cache: %v", err)
+	}
+}
+
+// WriteCacheSnapshot writes a snapshot of the cached images to w, in the
+// format the cache import API reads. limit caps the entries taken from each
+// tenant's cache to its most recently used; 0 takes them all.
+func (s *Service) WriteCacheSnapshot(w io.Writer, limit int) error {
+	sw, err := cache.NewSnapshotWriter(w)
+	if err != nil {
+		return err
+	}
+	for id, c := range s.snapshotCaches() {
+		for _, e := range c.Entries(limit) {
+			if err := sw.Write(id, e); err != nil {
+				return err
+			}
+		}
+	}
+	return sw.Flush()
+}
+
+// handleAdminCacheImport adds the entries of a snapshot from the export API to
+// the caches of their tenants. Entries imported before an invalid part of the
+// snapshot stay cached.
+func (s *Service) handleAdminCacheImport(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	caches := s.snapshotCaches()
+	var resp cacheImportResponse
+	err := cache.ReadSnapshot(http.MaxBytesReader(w, r.Body, config.MaxCacheImportBytes), func(tenant string, e cache.Entry) error {
+		if c, ok := caches[tenant]; ok && c.Restore(e) {
+			resp.Imported++
+		} else {
+			resp.Skipped++
+		}
+		return nil
+	})
+	if err != nil {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid snapshot after %d entries: %v", resp.Imported+resp.Skipped, err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp)
+}
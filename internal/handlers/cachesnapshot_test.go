@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminCacheExportImport(t *testing.T) {
+	old := setupAdminTestService(t)
+	for _, target := range []string{"http://localhost/placeholder/300x200?text=Hello", "http://img.acme.test/placeholder/300x200?text=Hello"} {
+		old.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	export := adminRequest(old, http.MethodGet, "/api/v1/admin/cache/export", "", testAdminToken)
+	if export.Code != http.StatusOK || export.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Fatalf("expected a snapshot, got %d %q", export.Code, export.Header().Get("Content-Type"))
+	}
+
+	fresh := setupAdminTestService(t)
+	rec := adminRequest(fresh, http.MethodPost, "/api/v1/admin/cache/import", export.Body.String(), testAdminToken)
+	var resp cacheImportResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected an import summary, got %d: %v", rec.Code, err)
+	}
+	if resp.Imported != 2 || resp.Skipped != 0 {
+		t.Fatalf("expected both entries to be imported, got %+v", resp)
+	}
+
+	for _, target := range []string{"http://localhost/placeholder/300x200?text=Hello", "http://img.acme.test/placeholder/300x200?text=Hello"} {
+		rec := httptest.NewRecorder()
+		fresh.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Header().Get("X-Cache") != "HIT" {
+			t.Errorf("expected %s to be served from the imported cache, got X-Cache %q", target, rec.Header().Get("X-Cache"))
+		}
+	}
+
+	limited := adminRequest(old, http.MethodGet, "/api/v1/admin/cache/export?limit=1", "", testAdminToken)
+	if limited.Code != http.StatusOK || limited.Body.Len() != export.Body.Len() {
+		t.Errorf("expected a limit of 1 to keep one entry per tenant, got %d bytes of %d", limited.Body.Len(), export.Body.Len())
+	}
+}
+
+func TestAdminCacheImportErrors(t *testing.T) {
+	mux := setupAdminTestService(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		want   string
+	}{
+		{"bad limit", http.MethodGet, "/api/v1/admin/cache/export?limit=0", "", http.StatusBadRequest, "invalid limit"},
+		{"not a snapshot", http.MethodPost, "/api/v1/admin/cache/import", "hello", http.StatusBadRequest, "not a cache snapshot"},
+		{"truncated", http.MethodPost, "/api/v1/admin/cache/import", "GROUTCACHE\x01\x07default\x05", http.StatusBadRequest, "truncated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := adminRequest(mux, tt.method, tt.target, tt.body, testAdminToken)
+			if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("expected %d with %q, got %d: %s", tt.status, tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	// Entries of unknown tenants, or under keys that aren't digests, are skipped
+	snapshot := "GROUTCACHE\x01" + "\x07unknown\x20" + strings.Repeat("k", 32) + "\x01v" + "\x07default\x01k\x01v"
+	rec := adminRequest(mux, http.MethodPost, "/api/v1/admin/cache/import", snapshot, testAdminToken)
+	if !strings.Contains(rec.Body.String(), `"imported":0,"skipped":2`) {
+		t.Errorf("expected both entries to be skipped, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	_, disabled := setupTestService(t)
+	if rec := adminRequest(disabled, http.MethodGet, "/api/v1/admin/cache/export", "", testAdminToken); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the export to be disabled without an admin token, got %d", rec.Code)
+	}
+}
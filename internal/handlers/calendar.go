@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/locale"
+)
+
+// calendarDateLayout is the accepted format for the date query parameter.
+const calendarDateLayout = "2006-01-02"
+
+func (s *Service) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	pathMetric := strings.TrimPrefix(r.URL.Path, "/calendar/")
+
+	// Extract format from path
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	width, height, err := parseDimensions(r, pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	width, height = emailDimensions(r, width, height)
+	format = emailFormat(r, format)
+	r = withRenderCost(r, renderCost{width: width, height: height})
+
+	tz, err := tzParam(r)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	// Default to today's date in tz when none is given
+	var date time.Time
+	if dateParam := r.URL.Query().Get("date"); dateParam == "" {
+		date, r = s.today(r, tz)
+	} else {
+		parsed, err := time.Parse(calendarDateLayout, dateParam)
+		if err != nil {
+			s.fail(w, r, ErrInvalidParameter.withMessage("Invalid date. Use the YYYY-MM-DD format, for example 2025-05-01."))
+			return
+		}
+		date = parsed
+	}
+	loc, err := localeParam(r)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if loc == nil {
+		loc = locale.English
+	}
+
+	headerHex, err := queryColor(r, "header")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if headerHex == "" {
+		headerHex = s.themeFor(r).calendarHeader
+	}
+	bgHex, err := backgroundParam(r, config.DefaultCalendarBg)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgParam, err := queryColor(r, "color", accentColor, analogousColor)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgHex := foregroundColor(fgParam, bgHex)
+
+	r = withAltText(r, loc.Weekday(date.Weekday())+", "+loc.LongDate(date), fmt.Sprintf("A %d x %d calendar tile", width, height))
+	key := specKey("CAL", calendarSpec{
+		Width: width, Height: height, Date: date.Format(calendarDateLayout), Locale: loc.Tag,
+		Header: headerHex, Bg: bgHex, Fg: fgHex, Format: format,
+	})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawCalendarTile(ctx, width, height, date, loc, headerHex, bgHex, fgHex, format)
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCalendarHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"Default date SVG", "/calendar/200x200", http.StatusOK, "image/svg+xml"},
+		{"Explicit date PNG", "/calendar/200x200.png?date=2025-05-01", http.StatusOK, "image/png"},
+		{"Custom header color", "/calendar/150x150.svg?date=2025-12-25&header=1e88e5", http.StatusOK, "image/svg+xml"},
+		{"Invalid date", "/calendar/200x200?date=05-01-2025", http.StatusBadRequest, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
+
+func TestCalendarHandlerRendersDate(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/calendar/200x200.svg?date=2025-05-01", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, ">MAY</text>") || !strings.Contains(body, ">1</text>") {
+		t.Fatalf("expected calendar tile for May 1, got: %s", body)
+	}
+}
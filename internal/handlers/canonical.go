@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// canonicalColorParams lists the query parameters that hold hex colors, whose
+// canonical form is lowercase.
+var canonicalColorParams = map[string]bool{
+	"bg":              true,
+	"background":      true,
+	"color":           true,
+	"labelColor":      true,
+	"backgroundColor": true,
+	"textColor":       true,
+}
+
+// hexListRegex matches a hex color or a comma-separated list of them.
+var hexListRegex = regexp.MustCompile(`^#?[0-9a-fA-F]{3,8}(,#?[0-9a-fA-F]{3,8})*$`)
+
+// canonicalExtensions maps file extensions to their canonical spelling.
+var canonicalExtensions = map[string]string{
+	".jpeg": ".jpg",
+	".jfif": ".jpg",
+	".jpe":  ".jpg",
+	".jpg":  ".jpg",
+	".png":  ".png",
+	".gif":  ".gif",
+	".svg":  ".svg",
+	".webp": ".webp",
+}
+
+// canonicalURL returns the canonical form of a request URL: no trailing slash
+// after a path with parameters, a lowercase .jpg rather than .jpeg, .jfif, or
+// .jpe extension, lowercase hex colors, and query parameters sorted by name.
+func canonicalURL(u *url.URL) string {
+	path := u.Path
+	// Keep the slash of bare prefixes like /avatar/
+	if trimmed := strings.TrimRight(path, "/"); strings.Count(trimmed, "/") >= 2 {
+		path = trimmed
+	}
+	if dot := strings.LastIndex(path, "."); dot > strings.LastIndex(path, "/") {
+		if ext, ok := canonicalExtensions[strings.ToLower(path[dot:])]; ok {
+			path = path[:dot] + ext
+		}
+	}
+
+	query := u.Query()
+	for key, values := range query {
+		if !canonicalColorParams[key] {
+			continue
+		}
+		for i, v := range values {
+			if hexListRegex.MatchString(v) {
+				values[i] = strings.ToLower(v)
+			}
+		}
+	}
+
+	canonical := (&url.URL{Path: path}).EscapedPath()
+	if encoded := query.Encode(); encoded != "" {
+		canonical += "?" + encoded
+	}
+	return canonical
+}
+
+// redirectToCanonical wraps next so GET and HEAD requests for a non-canonical URL
+// get a 301 to the canonical one, and caches and crawlers see one URL per image.
+// Signed URLs are left alone, as rewriting them would void the signature.
+func redirectToCanonical(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.URL.Query().Has("sig") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		current := r.URL.EscapedPath()
+		if r.URL.RawQuery != "" {
+			current += "?" + r.URL.RawQuery
+		}
+		if canonical := canonicalURL(r.URL); canonical != current {
+			http.Redirect(w, r, canonical, http.StatusMovedPermanently)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
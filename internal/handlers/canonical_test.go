@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalRedirects(t *testing.T) {
+	svc, _ := setupTestService(t)
+	svc.cfg.CanonicalRedirects = true
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	tests := []struct {
+		name     string
+		target   string
+		location string
+	}{
+		{"unsorted params", "/placeholder/300x200?text=Hi&bg=ff0000", "/placeholder/300x200?bg=ff0000&text=Hi"},
+		{"uppercase hex", "/avatar/Jane?bg=FF5733&color=FFF", "/avatar/Jane?bg=ff5733&color=fff"},
+		{"uppercase gradient", "/placeholder/300x200?bg=FF0000,0000FF", "/placeholder/300x200?bg=ff0000%2C0000ff"},
+		{"trailing slash", "/placeholder/300x200/", "/placeholder/300x200"},
+		{"jpeg extension", "/avatar/Jane.jpeg?size=64", "/avatar/Jane.jpg?size=64"},
+		{"uppercase extension", "/avatar/Jane.PNG", "/avatar/Jane.png"},
+		{"jfif extension", "/avatar/Jane.JFIF", "/avatar/Jane.jpg"},
+		{"canonical", "/placeholder/300x200?bg=ff0000&text=Hi", ""},
+		{"text is not a color", "/placeholder/300x200?text=ABC", ""},
+		{"bare prefix", "/avatar/", ""},
+		{"signed", "/placeholder/300x200?text=Hi&bg=FF0000&sig=abc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if tt.location == "" {
+				if rec.Code == http.StatusMovedPermanently {
+					t.Fatalf("expected no redirect, got one to %s", rec.Header().Get("Location"))
+				}
+				return
+			}
+			if rec.Code != http.StatusMovedPermanently {
+				t.Fatalf("expected 301 got %d", rec.Code)
+			}
+			if loc := rec.Header().Get("Location"); loc != tt.location {
+				t.Fatalf("expected redirect to %s got %s", tt.location, loc)
+			}
+		})
+	}
+}
+
+func TestCanonicalRedirectsOffByDefault(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/300x200?text=Hi&bg=FF0000", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+}
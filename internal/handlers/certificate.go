@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/locale"
+)
+
+// certificateTemplate is the built-in template of /certificate/. Its sizes are
+// variables, worked out from the canvas by certificateVars, so it scales to any
+// size. The embedded fonts have no script face, so the name is set in bold
+// italic, the closest of them.
+const certificateTemplate = `{
+	"width": "{{width}}", "height": "{{height}}", "background": "{{bg}}",
+	"border": {"style": "ornate", "color": "{{color|b8860b}}", "width": "{{border}}", "inset": "{{inset}}"},
+	"root": {"type": "column", "gap": "{{gap}}", "children": [
+		{"type": "text", "text": "{{title|Certificate of Completion}}", "font": "smallcaps", "size": "{{title_size}}", "color": "{{color|b8860b}}", "width": "{{text_width}}"},
+		{"type": "text", "text": "This is to certify that", "font": "italic", "size": "{{lead_size}}", "color": "555555"},
+		{"type": "text", "text": "{{name}}", "font": "bold-italic", "size": "{{name_size}}", "color": "1f2a44", "width": "{{text_width}}"},
+		{"type": "shape", "width": "{{rule_width}}", "height": "{{rule}}", "color": "{{color|b8860b}}"},
+		{"type": "text", "text": "has successfully completed", "font": "italic", "size": "{{lead_size}}", "color": "555555"},
+		{"type": "text", "text": "{{course}}", "bold": true, "size": "{{course_size}}", "color": "1f2a44", "width": "{{text_width}}"},
+		{"type": "spacer", "height": "{{gap}}"},
+		{"type": "row", "gap": "{{footer_gap}}", "align": "end", "children": [
+			{"type": "column", "gap": "{{rule}}", "children": [
+				{"type": "text", "text": "{{date}}", "size": "{{footer_size}}", "color": "1f2a44"},
+				{"type": "shape", "width": "{{footer_width}}", "height": "{{rule}}", "color": "555555"},
+				{"type": "text", "text": "Date", "font": "italic", "size": "{{footer_size}}", "color": "555555"}
+			]},
+			{"type": "column", "gap": "{{rule}}", "children": [
+				{"type": "spacer", "height": "{{footer_line}}"},
+				{"type": "shape", "width": "{{footer_width}}", "height": "{{rule}}", "color": "555555"},
+				{"type": "text", "text": "Signature", "font": "italic", "size": "{{footer_size}}", "color": "555555"}
+			]}
+		]}
+	]}
+}`
+
+// certificateLogo is the node put above a certificate's title when the brand
+// kit has a logo.
+const certificateLogo = `{"type": "logo", "height": "{{logo_size}}"},`
+
+// handleCertificate renders a certificate of completion at
+// /certificate/{WxH}[.ext], from the name, course, and date query parameters.
+func (s *Service) handleCertificate(w http.ResponseWriter, r *http.Request) {
+	pathMetric := strings.TrimPrefix(r.URL.Path, "/certificate/")
+
+	// Extract format from path
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	width, height := config.DefaultCertificateWidth, config.DefaultCertificateHeight
+	if pathMetric != "" {
+		if width, height, err = parseDimensions(r, pathMetric); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+	}
+	r = withRenderCost(r, renderCost{width: width, height: height})
+
+	name, err := s.limitLength(r, "name", r.URL.Query().Get("name"), s.cfg.MaxNameLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if name == "" {
+		name = "Recipient Name"
+	}
+	course, err := s.limitLength(r, "course", r.URL.Query().Get("course"), s.cfg.MaxTextLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if course == "" {
+		course = "the course"
+	}
+
+	loc, err := localeParam(r)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if loc == nil {
+		loc = locale.English
+	}
+	tz, err := tzParam(r)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	vars := certificateVars(width, height)
+	vars["name"] = name
+	vars["course"] = course
+	if vars["bg"], err = backgroundParam(r, "fffdf5"); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if r.URL.Query().Get("date") == "" {
+		var today time.Time
+		today, r = s.today(r, tz)
+		vars["date"] = loc.LongDate(today)
+	}
+	t := s.themeFor(r)
+	brandTrim(r, t, vars)
+	doc := certificateTemplate
+	if t.logo != nil {
+		doc = strings.Replace(doc, `"children": [`, `"children": [`+certificateLogo, 1)
+	}
+	r = withAltText(r, "Certificate for "+name, "Certificate of completion of "+course)
+	s.serveTemplate(w, r, json.RawMessage(doc), format, true, vars)
+}
+
+// brandTrim sets the trim color of a built-in template to the brand's secondary
+// color, unless the request picks a color or there is no brand color.
+func brandTrim(r *http.Request, t *theme, vars map[string]string) {
+	if t.secondaryColor != "" && !r.URL.Query().Has("color") {
+		vars["color"] = t.secondaryColor
+	}
+}
+
+// certificateVars returns the size variables of the certificate template for a
+// width x height canvas, scaled from their sizes on an 800x600 one.
+func certificateVars(width, height int) map[string]string {
+	scale := min(float64(width)/config.DefaultCertificateWidth, float64(height)/config.DefaultCertificateHeight)
+	px := templatePixels
+	return map[string]string{
+		"width":        strconv.Itoa(width),
+		"height":       strconv.Itoa(height),
+		"border":       px(4 * scale),
+		"inset":        px(16 * scale),
+		"gap":          px(12 * scale),
+		"rule":         px(2 * scale),
+		"title_size":   px(40 * scale),
+		"lead_size":    px(18 * scale),
+		"name_size":    px(56 * scale),
+		"course_size":  px(22 * scale),
+		"footer_size":  px(15 * scale),
+		"footer_line":  px(15 * scale * 1.5),
+		"footer_gap":   px(80 * scale),
+		"footer_width": px(180 * scale),
+		"logo_size":    px(48 * scale),
+		"rule_width":   px(float64(width) / 2),
+		"text_width":   px(float64(width) * 0.75),
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCertificate(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		status      int
+		contentType string
+		expect      []string
+	}{
+		{"defaults", "/certificate/", http.StatusOK, "image/svg+xml", []string{`width="800" height="600"`, ">Certificate of Completion<", ">Recipient Name<", ">the course<", `stroke="#b8860b"`}},
+		{"parameters", "/certificate/1200x900?name=Jane%20Doe&course=Go%20Basics&date=May%201,%202025&color=1f6f43", http.StatusOK, "image/svg+xml", []string{`width="1200" height="900"`, ">Jane Doe<", ">Go Basics<", ">May 1, 2025<", `stroke="#1f6f43"`, `font-style="italic"`}},
+		{"escapes values", "/certificate/?name=%3Cscript%3E", http.StatusOK, "image/svg+xml", []string{">&lt;script&gt;<"}},
+		{"png", "/certificate/800x600.png?name=Jane", http.StatusOK, "image/png", nil},
+		{"invalid color", "/certificate/?color=gold", http.StatusBadRequest, "text/html; charset=utf-8", []string{"not a hex color"}},
+		{"too large", "/certificate/3000x2000", http.StatusBadRequest, "text/html; charset=utf-8", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q, got: %s", expect, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCertificateVarsScale(t *testing.T) {
+	base := certificateVars(800, 600)
+	double := certificateVars(1600, 1200)
+	if base["name_size"] != "56" || double["name_size"] != "112" {
+		t.Fatalf("expected the name size to scale with the canvas, got %s and %s", base["name_size"], double["name_size"])
+	}
+	// A wide, short canvas scales by its height
+	if wide := certificateVars(1600, 300); wide["name_size"] != "28" {
+		t.Fatalf("expected a name size of 28 on a 1600x300 canvas, got %s", wide["name_size"])
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"grout/internal/config"
+)
+
+// chaosParam injects faults into image requests when chaos testing is on.
+const chaosParam = "x-chaos"
+
+// chaosFaults are the faults an x-chaos parameter asks for.
+type chaosFaults struct {
+	latency time.Duration
+	// status is the error status to fail with, 0 for none
+	status int
+}
+
+// parseChaos reads an x-chaos value: comma-separated faults, latency:DURATION
+// to delay the response and error:STATUS to fail it, such as
+// "latency:500ms,error:503".
+func parseChaos(value string) (chaosFaults, error) {
+	var faults chaosFaults
+	for _, fault := range strings.Split(value, ",") {
+		kind, arg, _ := strings.Cut(strings.TrimSpace(fault), ":")
+		switch kind {
+		case "latency":
+			d, err := time.ParseDuration(arg)
+			if err != nil || d < 0 || d > config.MaxChaosLatency {
+				return chaosFaults{}, fmt.Errorf("latency %q must be a duration from 0s to %s", arg, config.MaxChaosLatency)
+			}
+			faults.latency = d
+		case "error":
+			status, err := strconv.Atoi(arg)
+			if err != nil || status < 400 || status > 599 {
+				return chaosFaults{}, fmt.Errorf("error %q must be an HTTP status from 400 to 599", arg)
+			}
+			faults.status = status
+		default:
+			return chaosFaults{}, fmt.Errorf("unknown fault %q: use latency:DURATION or error:STATUS", fault)
+		}
+	}
+	return faults, nil
+}
+
+// stripQueryParam returns a raw query without the named parameter, leaving the
+// rest as written.
+func stripQueryParam(rawQuery, name string) string {
+	pairs := strings.Split(rawQuery, "&")
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil && unescaped == name {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
+
+// withChaos injects the faults of a request's x-chaos parameter, so teams can
+// test how their pages behave when images are slow or failing. The parameter
+// is removed before the route sees the request, so it doesn't change cache
+// keys, ETags, or signatures. Injected failures are counted like other errors
+// but never logged, since nothing went wrong.
+func (s *Service) withChaos(routePath string, next http.Handler) http.Handler {
+	api := strings.HasPrefix(routePath, "/api/v1/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if !query.Has(chaosParam) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		value := query.Get(chaosParam)
+		faults, err := parseChaos(value)
+		if err != nil {
+			e := ErrInvalidParameter.withMessage("invalid %s: %v", chaosParam, err)
+			if api {
+				s.failJSON(w, r, e)
+			} else {
+				s.fail(w, r, e)
+			}
+			return
+		}
+		w.Header().Set("X-Chaos", value)
+
+		if faults.latency > 0 {
+			timer := time.NewTimer(faults.latency)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		if faults.status != 0 {
+			e := ErrInjectedFailure
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("X-Error-Code", e.code)
+			s.errorCounts.add(e.code)
+			if api {
+				writeJSON(w, faults.status, map[string]string{"error": e.message, "code": e.code})
+			} else {
+				s.serveErrorPage(w, r, faults.status, e.message)
+			}
+			return
+		}
+
+		stripped := r.Clone(r.Context())
+		stripped.URL.RawQuery = stripQueryParam(r.URL.RawQuery, chaosParam)
+		next.ServeHTTP(w, stripped)
+	})
+}
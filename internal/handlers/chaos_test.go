@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupChaosTestService returns a mux with chaos testing on, and signing too
+// when signingKey is set.
+func setupChaosTestService(t *testing.T, signingKey string) *http.ServeMux {
+	t.Helper()
+	svc, _ := setupTestService(t)
+	svc.cfg.ChaosTesting = true
+	svc.cfg.SigningKey = signingKey
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return mux
+}
+
+func TestParseChaos(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    chaosFaults
+		wantErr string
+	}{
+		{"latency:500ms", chaosFaults{latency: 500 * time.Millisecond}, ""},
+		{"error:503", chaosFaults{status: 503}, ""},
+		{"latency:1s, error:404", chaosFaults{latency: time.Second, status: 404}, ""},
+		{"latency:1m", chaosFaults{}, "from 0s to 30s"},
+		{"latency:soon", chaosFaults{}, "must be a duration"},
+		{"error:200", chaosFaults{}, "from 400 to 599"},
+		{"drop", chaosFaults{}, "unknown fault"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseChaos(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("expected %+v, got %+v, %v", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestChaos(t *testing.T) {
+	mux := setupChaosTestService(t, "")
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	plain := get("/placeholder/100x50.png?text=Hi")
+
+	start := time.Now()
+	slow := get("/placeholder/100x50.png?text=Hi&x-chaos=latency:60ms")
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("expected at least 60ms of latency, took %v", elapsed)
+	}
+	// The parameter doesn't change the image or its cache entry
+	if slow.Code != http.StatusOK || slow.Header().Get("ETag") != plain.Header().Get("ETag") || slow.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected the cached image, got %d %v", slow.Code, slow.Header())
+	}
+	if slow.Header().Get("X-Chaos") != "latency:60ms" {
+		t.Errorf("expected X-Chaos to echo the faults, got %q", slow.Header().Get("X-Chaos"))
+	}
+
+	tests := []struct {
+		name, target, code, contentType string
+		status                          int
+	}{
+		{"image error", "/placeholder/100x50.png?x-chaos=error:503", "injected_failure", "text/html", http.StatusServiceUnavailable},
+		{"slow error", "/avatar/Jane.png?x-chaos=latency:1ms,error:500", "injected_failure", "text/html", http.StatusInternalServerError},
+		{"API error", "/api/v1/palette?x-chaos=error:429", "injected_failure", "application/json", http.StatusTooManyRequests},
+		{"invalid", "/placeholder/100x50?x-chaos=explode", "invalid_parameter", "text/html", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.target)
+			if rec.Code != tt.status || rec.Header().Get("X-Error-Code") != tt.code {
+				t.Fatalf("expected %d %s, got %d %q", tt.status, tt.code, rec.Code, rec.Header().Get("X-Error-Code"))
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+				t.Errorf("expected %s, got %q", tt.contentType, ct)
+			}
+		})
+	}
+
+	// Pages have no faults to inject
+	if rec := get("/?x-chaos=error:500"); rec.Code != http.StatusOK {
+		t.Errorf("expected the home page, got %d", rec.Code)
+	}
+}
+
+func TestChaosOff(t *testing.T) {
+	_, mux := setupTestService(t)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/100x50?x-chaos=error:500", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Chaos") != "" {
+		t.Fatalf("expected x-chaos ignored when chaos testing is off, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestChaosSigned(t *testing.T) {
+	mux := setupChaosTestService(t, testSigningKey)
+	// Faults are added to an already signed URL
+	target := signed(t, "/placeholder/100x50?text=Hi") + "&x-chaos=latency:1ms"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Chaos") == "" {
+		t.Fatalf("expected the signed image with its faults, got %d", rec.Code)
+	}
+}
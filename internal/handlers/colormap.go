@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"sync/atomic"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// colorMap holds the fixed colors of the color map file, swapped whole when
+// the file is reloaded.
+type colorMap struct {
+	current atomic.Pointer[config.ColorMap]
+}
+
+// newColorMap returns a colorMap holding m.
+func newColorMap(m config.ColorMap) *colorMap {
+	c := &colorMap{}
+	c.current.Store(&m)
+	return c
+}
+
+// hashColor returns the color of an avatar whose background is derived from
+// seed: the color mapped to uid or name, or else the color hashed from seed.
+func (s *Service) hashColor(uid, name, seed string) string {
+	if hex, ok := s.colorMap.current.Load().Color(uid, name); ok {
+		return hex
+	}
+	return render.GenerateColorHash(seed)
+}
+
+// ReloadColorMap rereads the color map file and swaps in its colors. On error
+// the current colors stay in place. Cache keys hold the colors drawn, so
+// avatars whose color changed render anew.
+func (s *Service) ReloadColorMap() error {
+	m, err := config.LoadColorMap(s.cfg.ColorMapFile)
+	if err != nil {
+		return err
+	}
+	s.colorMap.current.Store(&m)
+	return nil
+}
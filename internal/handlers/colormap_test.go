@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+func TestColorMap(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "colors.yaml")
+	writeColors := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write color map file: %v", err)
+		}
+	}
+	writeColors(`
+ids:
+  "42": "#0a7cff"
+names:
+  Acme Support: ff5733
+  acme-bot: 112233
+`)
+
+	cfg := config.DefaultServerConfig()
+	cfg.ColorMapFile = path
+	if cfg.ColorMap, err = config.LoadColorMap(path); err != nil {
+		t.Fatalf("load color map: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](10)
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	get := func(target string) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"mapped name", "/avatar/Acme%20Support.svg?bg=random", "#ff5733"},
+		{"name as normalized", "/avatar/Acme++Support.svg?bg=random", "#ff5733"},
+		{"mapped ID over name", "/avatar/Acme%20Support.svg?bg=random&uid=42", "#0a7cff"},
+		{"unmapped ID falls back to the name", "/avatar/Acme%20Support.svg?bg=random&uid=7", "#ff5733"},
+		{"unmapped name", "/avatar/Jane%20Doe.svg?bg=random", "#" + render.GenerateColorHash("Jane Doe")},
+		{"explicit background wins", "/avatar/Acme%20Support.svg?bg=00ff00", "#00ff00"},
+		{"ui-avatars", "/api/?name=Acme+Support&background=random&format=svg", "#ff5733"},
+		{"DiceBear seed as ID", "/7.x/initials/svg?seed=42", "#0a7cff"},
+		{"DiceBear seed as name", "/7.x/initials/svg?seed=acme-bot", "#112233"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if body := get(tt.target); !strings.Contains(body, tt.want) {
+				t.Errorf("expected %s in %s", tt.want, body)
+			}
+		})
+	}
+
+	// Reloading swaps in the new colors, and the avatar renders anew in them
+	writeColors("names:\n  Acme Support: \"654321\"\n")
+	if err := svc.ReloadColorMap(); err != nil {
+		t.Fatalf("reload color map: %v", err)
+	}
+	if body := get("/avatar/Acme%20Support.svg?bg=random"); !strings.Contains(body, "#654321") {
+		t.Errorf("expected the reloaded color, got %s", body)
+	}
+
+	// A broken file leaves the last good colors in place
+	writeColors("names:\n  Acme Support: blue\n")
+	if err := svc.ReloadColorMap(); err == nil {
+		t.Fatal("expected an error for a color that isn't hex")
+	}
+	if body := get("/avatar/Acme%20Support.svg?bg=random"); !strings.Contains(body, "#654321") {
+		t.Errorf("expected the last good color, got %s", body)
+	}
+}
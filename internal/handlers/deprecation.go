@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"grout/internal/config"
+)
+
+// deprecatedRoute is a route marked deprecated in the config, with its usage
+// since the server started.
+type deprecatedRoute struct {
+	route       string
+	deprecation config.Deprecation
+	requests    atomic.Uint64
+	// lastRequest is the Unix time of the last request, 0 for none
+	lastRequest atomic.Int64
+}
+
+func newDeprecatedRoutes(deprecations map[string]config.Deprecation) map[string]*deprecatedRoute {
+	routes := make(map[string]*deprecatedRoute, len(deprecations))
+	for route, d := range deprecations {
+		routes[route] = &deprecatedRoute{route: route, deprecation: d}
+	}
+	return routes
+}
+
+// setHeaders announces the deprecation on a response: Deprecation (RFC 9745)
+// with the date it took effect, Sunset (RFC 8594) with the date the route goes
+// away, and a Link to the page about it.
+func (d *deprecatedRoute) setHeaders(h http.Header) {
+	h.Set("Deprecation", "@"+strconv.FormatInt(d.deprecation.Since.Unix(), 10))
+	if !d.deprecation.Sunset.IsZero() {
+		h.Set("Sunset", d.deprecation.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.deprecation.Link != "" {
+		h.Add("Link", "<"+d.deprecation.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+}
+
+// deprecationWriter adds a deprecated route's headers as the response starts,
+// so handlers that set or clear their own Link headers don't drop them.
+type deprecationWriter struct {
+	http.ResponseWriter
+	route   *deprecatedRoute
+	started bool
+}
+
+func (dw *deprecationWriter) WriteHeader(code int) {
+	if !dw.started {
+		dw.started = true
+		dw.route.setHeaders(dw.Header())
+	}
+	dw.ResponseWriter.WriteHeader(code)
+}
+
+func (dw *deprecationWriter) Write(b []byte) (int, error) {
+	if !dw.started {
+		dw.WriteHeader(http.StatusOK)
+	}
+	return dw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (dw *deprecationWriter) Unwrap() http.ResponseWriter {
+	return dw.ResponseWriter
+}
+
+// deprecated counts the requests of a deprecated route and announces its
+// deprecation on their responses.
+func (s *Service) deprecated(d *deprecatedRoute, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d.requests.Add(1)
+		d.lastRequest.Store(s.now().Unix())
+		next.ServeHTTP(&deprecationWriter{ResponseWriter: w, route: d}, r)
+	})
+}
+
+// CheckDeprecations returns an error when the config deprecates a path that no
+// route is registered under, such as one with a typo.
+func (s *Service) CheckDeprecations() error {
+	var unknown []string
+	for path := range s.deprecations {
+		if !slices.ContainsFunc(s.routes(), func(rt route) bool { return rt.path == path }) {
+			unknown = append(unknown, path)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("no route is registered under %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// deprecatedRouteStats reports a deprecated route's usage since the server
+// started, so operators can tell when it's safe to remove.
+type deprecatedRouteStats struct {
+	Route       string     `json:"route"`
+	Since       time.Time  `json:"since"`
+	Sunset      *time.Time `json:"sunset,omitempty"`
+	Requests    uint64     `json:"requests"`
+	LastRequest *time.Time `json:"last_request,omitempty"`
+}
+
+// deprecationStats returns the usage of every deprecated route, by route.
+func (s *Service) deprecationStats() []deprecatedRouteStats {
+	stats := make([]deprecatedRouteStats, 0, len(s.deprecations))
+	for _, d := range s.deprecations {
+		st := deprecatedRouteStats{Route: d.route, Since: d.deprecation.Since, Requests: d.requests.Load()}
+		if !d.deprecation.Sunset.IsZero() {
+			sunset := d.deprecation.Sunset
+			st.Sunset = &sunset
+		}
+		if last := d.lastRequest.Load(); last != 0 {
+			lastRequest := time.Unix(last, 0).UTC()
+			st.LastRequest = &lastRequest
+		}
+		stats = append(stats, st)
+	}
+	slices.SortFunc(stats, func(a, b deprecatedRouteStats) int { return strings.Compare(a.Route, b.Route) })
+	return stats
+}
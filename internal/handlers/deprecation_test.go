@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+func setupDeprecationTestService(t *testing.T, deprecations map[string]config.Deprecation) (*Service, *http.ServeMux) {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.AdminToken = testAdminToken
+	cfg.Deterministic = true
+	cfg.Deprecations = deprecations
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return svc, mux
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	since := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	_, mux := setupDeprecationTestService(t, map[string]config.Deprecation{
+		"/7.x/": {Since: since, Sunset: time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC), Link: "https://docs.example.com/migrate"},
+		"/api/": {Since: since},
+	})
+
+	tests := []struct {
+		name, target              string
+		status                    int
+		deprecation, sunset, link string
+	}{
+		{"with sunset and link", "/7.x/initials/svg?seed=Jane", http.StatusOK, "@1767225600", "Thu, 31 Dec 2026 00:00:00 GMT", `<https://docs.example.com/migrate>; rel="deprecation"; type="text/html"`},
+		{"error response", "/7.x/unknown/svg", http.StatusBadRequest, "@1767225600", "Thu, 31 Dec 2026 00:00:00 GMT", `<https://docs.example.com/migrate>; rel="deprecation"; type="text/html"`},
+		{"since only", "/api/?name=Jane+Doe", http.StatusOK, "@1767225600", "", ""},
+		{"not deprecated", "/8.x/initials/svg?seed=Jane", http.StatusOK, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+			h := rec.Header()
+			if h.Get("Deprecation") != tt.deprecation || h.Get("Sunset") != tt.sunset || h.Get("Link") != tt.link {
+				t.Errorf("expected Deprecation %q, Sunset %q, Link %q, got %q, %q, %q", tt.deprecation, tt.sunset, tt.link, h.Get("Deprecation"), h.Get("Sunset"), h.Get("Link"))
+			}
+		})
+	}
+
+	rec := adminRequest(mux, http.MethodGet, "/api/v1/admin/stats", "", testAdminToken)
+	var stats adminStatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if len(stats.Deprecated) != 2 {
+		t.Fatalf("expected two deprecated routes, got %+v", stats.Deprecated)
+	}
+	dicebear, ui := stats.Deprecated[0], stats.Deprecated[1]
+	if ui.Route != "/api/" || ui.Requests != 1 || ui.Sunset != nil || ui.LastRequest == nil || !ui.LastRequest.Equal(deterministicNow) {
+		t.Errorf("unexpected stats %+v", ui)
+	}
+	if dicebear.Route != "/7.x/" || dicebear.Requests != 2 || dicebear.Sunset == nil {
+		t.Errorf("unexpected stats %+v", dicebear)
+	}
+}
+
+func TestDeprecatedRouteKeepsLinks(t *testing.T) {
+	svc, _ := setupDeprecationTestService(t, map[string]config.Deprecation{
+		"/placeholder/": {Since: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), Link: "https://docs.example.com/migrate"},
+	})
+	d := svc.deprecations["/placeholder/"]
+	// A handler that replaces the Link header still sends the deprecation's
+	handler := svc.deprecated(d, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `</i/abc>; rel="canonical"`)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/10x10", nil))
+	if links := strings.Join(rec.Header().Values("Link"), ", "); !strings.Contains(links, `rel="canonical"`) || !strings.Contains(links, `rel="deprecation"`) {
+		t.Fatalf("expected both links, got %q", links)
+	}
+}
+
+func TestCheckDeprecations(t *testing.T) {
+	since := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	svc, _ := setupDeprecationTestService(t, map[string]config.Deprecation{"/7.x/": {Since: since}})
+	if err := svc.CheckDeprecations(); err != nil {
+		t.Fatalf("expected a registered route to pass, got %v", err)
+	}
+	svc, _ = setupDeprecationTestService(t, map[string]config.Deprecation{"/7.x": {Since: since}, "/6.x/": {Since: since}})
+	if err := svc.CheckDeprecations(); err == nil || !strings.Contains(err.Error(), "/6.x/, /7.x") {
+		t.Fatalf("expected both unknown routes named, got %v", err)
+	}
+}
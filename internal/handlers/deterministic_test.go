@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// setupDeterministicTestService returns a mux served in deterministic mode.
+func setupDeterministicTestService(t *testing.T) *http.ServeMux {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](1)
+	cfg := config.DefaultServerConfig()
+	cfg.StaticDir = t.TempDir()
+	cfg.Deterministic = true
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return mux
+}
+
+func TestDeterministicMode(t *testing.T) {
+	mux := setupDeterministicTestService(t)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	for _, target := range []string{"/placeholder/600x300?quote=true", "/placeholder/600x300?joke=true", "/calendar/200x200", "/200/300"} {
+		t.Run(target, func(t *testing.T) {
+			first := get(target)
+			for i := 0; i < 10; i++ {
+				again := get(target)
+				if again.Body.String() != first.Body.String() || again.Header().Get("Location") != first.Header().Get("Location") {
+					t.Fatal("expected identical responses in deterministic mode")
+				}
+			}
+		})
+	}
+
+	if calendar := get("/calendar/200x200").Body.String(); !strings.Contains(calendar, ">JAN<") || !strings.Contains(calendar, ">Wednesday<") {
+		t.Errorf("expected the calendar to default to the pinned date, got: %s", calendar)
+	}
+	if sitemap := get("/sitemap.xml").Body.String(); strings.Contains(sitemap, "<lastmod>") {
+		t.Errorf("expected no timestamps in the sitemap, got: %s", sitemap)
+	}
+}
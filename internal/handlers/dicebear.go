@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/render/genart"
+	"grout/internal/utils"
+)
+
+// diceBearPatterns maps DiceBear styles to the pattern drawn in their place.
+// "initials" is handled separately.
+var diceBearPatterns = map[string]render.Pattern{
+	"glass":  render.PatternMesh,
+	"shapes": render.PatternLowPoly,
+}
+
+// diceBearStyles lists the supported DiceBear styles.
+func diceBearStyles() []string {
+	styles := []string{"initials"}
+	for style := range diceBearPatterns {
+		styles = append(styles, style)
+	}
+	sort.Strings(styles)
+	return styles
+}
+
+// handleDiceBear serves /{version}/{style}/{format}?seed= with a subset of the
+// DiceBear styles and options, so apps using DiceBear URLs can switch hostnames.
+// The 7.x, 8.x, and 9.x APIs share this URL shape.
+func (s *Service) handleDiceBear(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 {
+		s.handle404(w, r)
+		return
+	}
+	style, formatName := parts[1], parts[2]
+
+	format, ok := parseFormatParam(formatName)
+	if !ok {
+		s.fail(w, r, ErrUnsupportedFormat.withMessage("Invalid format. Use svg, png, jpg, or webp."))
+		return
+	}
+	format = emailFormat(r, format)
+	pattern, isPattern := diceBearPatterns[style]
+	if style != "initials" && !isPattern {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Unsupported style. Use one of: %s.", strings.Join(diceBearStyles(), ", ")))
+		return
+	}
+
+	query := r.URL.Query()
+	seed := query.Get("seed")
+	size := clampParam(r, "size", utils.ParseIntOrDefault(query.Get("size"), config.DiceBearDefaultSize), 1, config.DiceBearMaxSize)
+	// Grout only draws square or circular avatars, so any radius of 50% or more is a circle
+	rounded := utils.ParseIntOrDefault(query.Get("radius"), 0) >= 50
+
+	// DiceBear picks one of the listed background colors by seed
+	colors := parsePalette(query.Get("backgroundColor"))
+	for i, c := range colors {
+		hex, err := resolveColor("backgroundColor", c)
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		colors[i] = hex
+	}
+	seedNum := genart.ParseSeed(seed)
+
+	if isPattern {
+		if len(colors) == 0 {
+			colors = genart.Palette(seedNum, patternColorCount)
+		}
+		opts := render.PatternOptions{Seed: seedNum, Colors: colors}
+		key := specKey("DICEBEAR", patternSpec{Pattern: pattern, Width: size, Height: size, PatternOptions: opts, Rounded: rounded, Format: format})
+		s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+			return s.renderer.DrawPattern(ctx, size, size, pattern, opts, "", "", rounded, false, format)
+		})
+		return
+	}
+
+	// The seed is commonly a user ID or name, so it's looked up as either
+	bgHex := s.hashColor(seed, normalizeName(seed), seed)
+	if len(colors) > 0 {
+		bgHex = colors[seedNum%uint64(len(colors))]
+	}
+	fgHex, err := queryColor(r, "textColor")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if fgHex == "" {
+		fgHex = render.GetContrastColor(bgHex)
+	}
+	chars := utils.ParseIntOrDefault(query.Get("chars"), config.DiceBearDefaultChars)
+	chars = clampParam(r, "chars", chars, 0, config.UIAvatarsMaxLength)
+	fontPercent := min(utils.ParseIntOrDefault(query.Get("fontSize"), config.DiceBearDefaultFontSize), 100)
+	bold := false
+	if weight, err := strconv.Atoi(query.Get("fontWeight")); err == nil {
+		bold = weight >= 600
+	}
+
+	// DiceBear derives initials the same way as ui-avatars.com
+	initials := uiAvatarsInitials(seed, chars, true)
+	fontSize := float64(size*fontPercent) / 100
+	key := specKey("DICEBEAR:initials", avatarSpec{
+		Initials: initials, Size: size, Rounded: rounded, Bold: bold, Bg: bgHex, Fg: fgHex,
+		FontScale: float64(fontPercent) / 100, Format: format,
+	})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawImageWithFontSize(ctx, size, size, bgHex, fgHex, initials, rounded, bold, fontSize, format)
+	})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiceBearHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name         string
+		target       string
+		expectStatus int
+		expectType   string
+		expectSubstr []string
+	}{
+		{"initials svg", "/7.x/initials/svg?seed=Jane%20Doe", http.StatusOK, "image/svg+xml", []string{">JD<", `width="256"`, `font-size="128"`}},
+		{"chars is clamped", "/9.x/initials/svg?seed=John+Smith&chars=2000000000", http.StatusOK, "image/svg+xml", []string{">JSMITH<"}},
+		{"initials options", "/7.x/initials/svg?seed=Felix&chars=1&backgroundColor=b6e3f4&textColor=000000&radius=50&fontSize=40&fontWeight=700", http.StatusOK, "image/svg+xml", []string{">F<", `fill="#b6e3f4"`, `fill="#000000"`, "<circle", `font-weight="bold"`, `font-size="102"`}},
+		{"size is capped", "/7.x/initials/svg?seed=Felix&size=1000", http.StatusOK, "image/svg+xml", []string{`width="256"`}},
+		{"glass maps to mesh", "/7.x/glass/svg?seed=Felix&size=64", http.StatusOK, "image/svg+xml", []string{`width="64"`, "mesh_blur"}},
+		{"shapes maps to lowpoly", "/7.x/shapes/svg?seed=Felix&size=64", http.StatusOK, "image/svg+xml", []string{"<polygon"}},
+		{"png", "/9.x/initials/png?seed=Felix", http.StatusOK, "image/png", nil},
+		{"unsupported style", "/7.x/adventurer/svg?seed=Felix", http.StatusBadRequest, "text/html; charset=utf-8", []string{"initials"}},
+		{"unsupported format", "/7.x/initials/avif?seed=Felix", http.StatusBadRequest, "text/html; charset=utf-8", nil},
+		{"missing format", "/7.x/initials", http.StatusNotFound, "text/html; charset=utf-8", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.expectType {
+				t.Fatalf("expected content-type %s got %s", tt.expectType, ct)
+			}
+			for _, substr := range tt.expectSubstr {
+				if !strings.Contains(rec.Body.String(), substr) {
+					t.Errorf("expected body to contain %q", substr)
+				}
+			}
+		})
+	}
+}
+
+func TestDiceBearSeedIsStable(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+
+	first := get("/7.x/shapes/svg?seed=Felix")
+	if again := get("/8.x/shapes/svg?seed=Felix"); again != first {
+		t.Fatal("expected the same seed to render the same avatar")
+	}
+	if other := get("/7.x/shapes/svg?seed=Aneka"); other == first {
+		t.Fatal("expected different seeds to render different avatars")
+	}
+}
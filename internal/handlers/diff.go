@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// diffResponse is the JSON body of the diff API.
+type diffResponse struct {
+	// Score is the mean perceptual difference, from 0 (identical) to 1
+	Score     float64 `json:"score"`
+	Identical bool    `json:"identical"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	// ChangedPixels counts the pixels that differ by more than the threshold
+	ChangedPixels int `json:"changed_pixels"`
+	// Diff is a PNG data URL of the first image in faded gray with changes in red
+	Diff string `json:"diff"`
+}
+
+var errForeignSpec = errors.New("image spec is not on this instance")
+
+// specResponse buffers the response to an image spec rendered in-process.
+type specResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (sr *specResponse) Header() http.Header { return sr.header }
+
+func (sr *specResponse) Write(p []byte) (int, error) { return sr.body.Write(p) }
+
+func (sr *specResponse) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+}
+
+// specPath returns the path and query of an image spec: a path such as
+// /placeholder/300x200.png, or an absolute URL on one of this instance's hosts.
+func (s *Service) specPath(r *http.Request, spec string) (string, error) {
+	u, err := url.Parse(spec)
+	if err != nil || spec == "" {
+		return "", fmt.Errorf("invalid image spec %q", spec)
+	}
+	if u.IsAbs() || u.Host != "" {
+		host := strings.ToLower(u.Hostname())
+		_, tenant := s.tenantThemes[host]
+		if !strings.EqualFold(u.Host, r.Host) && !strings.EqualFold(u.Host, s.cfg.Domain) && !tenant {
+			return "", errForeignSpec
+		}
+	}
+	if !strings.HasPrefix(u.Path, "/") {
+		return "", fmt.Errorf("invalid image spec %q", spec)
+	}
+	return u.RequestURI(), nil
+}
+
+// renderSpec renders an image spec through the image routes, as the request's
+// tenant, and decodes it. Specs must produce a raster image.
+func (s *Service) renderSpec(r *http.Request, spec string) (image.Image, error) {
+	path, err := s.specPath(r, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// The spec's cost is the diff's own
+	ctx := context.WithValue(r.Context(), internalRenderKey{}, &internalRender{})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image spec %q", spec)
+	}
+	if req.URL.Path == "/api/v1/diff" {
+		return nil, fmt.Errorf("image spec %q is a diff, which can't be compared", spec)
+	}
+	req.Host = r.Host
+	res := &specResponse{header: http.Header{}}
+	s.imageRoutes().ServeHTTP(res, req)
+
+	if res.status != 0 && res.status != http.StatusOK {
+		return nil, fmt.Errorf("image spec %q returned status %d", spec, res.status)
+	}
+	img, _, err := image.Decode(&res.body)
+	if err != nil {
+		return nil, fmt.Errorf("image spec %q is not a raster image; request png, jpg, gif, or webp", spec)
+	}
+	return img, nil
+}
+
+// handleDiff compares two images rendered by this instance, for golden-image
+// tests: GET /api/v1/diff?a={spec}&b={spec}[&threshold=0.1].
+func (s *Service) handleDiff(w http.ResponseWriter, r *http.Request) {
+	threshold := config.DefaultDiffThreshold
+	if t := r.URL.Query().Get("threshold"); t != "" {
+		parsed, err := strconv.ParseFloat(t, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid threshold: use a number from 0 to 1"))
+			return
+		}
+		threshold = parsed
+	}
+
+	var images [2]image.Image
+	for i, param := range []string{"a", "b"} {
+		img, err := s.renderSpec(r, r.URL.Query().Get(param))
+		if errors.Is(err, errForeignSpec) {
+			s.failJSON(w, r, ErrInvalidParameter.withMessage("%s: only images on this instance can be compared", param))
+			return
+		}
+		if err != nil {
+			s.failJSON(w, r, ErrInvalidParameter.withMessage("%s: %v", param, err))
+			return
+		}
+		images[i] = img
+	}
+
+	result := render.DiffImages(images[0], images[1], threshold)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, result.Image); err != nil {
+		s.failJSON(w, r, ErrRenderFailed.withMessage("failed to encode the diff image").withCause(err))
+		return
+	}
+
+	bounds := result.Image.Bounds()
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, diffResponse{
+		Score:         result.Score,
+		Identical:     result.Score == 0,
+		Width:         bounds.Dx(),
+		Height:        bounds.Dy(),
+		ChangedPixels: result.Changed,
+		Diff:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDiffAPI(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name      string
+		a, b      string
+		identical bool
+		changed   bool
+	}{
+		{"identical specs", "/placeholder/100x50.png?bg=ff0000", "/placeholder/100x50.png?bg=ff0000", true, false},
+		{"different colors", "/placeholder/100x50.png?bg=ff0000&text=+", "/placeholder/100x50.png?bg=0000ff&text=+", false, true},
+		{"absolute URL on this instance", "http://example.com/avatar/Jane.png?size=40", "/avatar/Jane.png?size=40", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			target := "/api/v1/diff?a=" + url.QueryEscape(tt.a) + "&b=" + url.QueryEscape(tt.b)
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+			}
+			var res diffResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if res.Identical != tt.identical || (res.ChangedPixels > 0) != tt.changed {
+				t.Fatalf("unexpected result: %+v", res)
+			}
+			if !strings.HasPrefix(res.Diff, "data:image/png;base64,") {
+				t.Fatalf("expected a PNG data URL, got %.40s", res.Diff)
+			}
+		})
+	}
+}
+
+func TestDiffAPIErrors(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		query  string
+		expect string
+	}{
+		{"missing spec", "a=" + url.QueryEscape("/placeholder/10x10.png"), "b:"},
+		{"foreign host", "a=" + url.QueryEscape("https://other.example.org/placeholder/10x10.png") + "&b=" + url.QueryEscape("/placeholder/10x10.png"), "only images on this instance"},
+		{"svg spec", "a=" + url.QueryEscape("/placeholder/10x10") + "&b=" + url.QueryEscape("/placeholder/10x10.png"), "not a raster image"},
+		{"failing spec", "a=" + url.QueryEscape("/placeholder/10x10.png?scheme=sepia") + "&b=" + url.QueryEscape("/placeholder/10x10.png"), "returned status 400"},
+		{"diff spec", "a=" + url.QueryEscape("/api/v1/diff?a=/placeholder/10x10.png&b=/placeholder/10x10.png") + "&b=" + url.QueryEscape("/placeholder/10x10.png"), "can't be compared"},
+		{"bad threshold", "threshold=2&a=x&b=y", "invalid threshold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/diff?"+tt.query, nil))
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.expect) {
+				t.Fatalf("expected 400 mentioning %q, got %d: %s", tt.expect, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+func (s *Service) handleDivider(w http.ResponseWriter, r *http.Request) {
+	pathMetric := strings.TrimPrefix(r.URL.Path, "/divider/")
+
+	// Extract format from path
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	width, height, err := parseDimensions(r, pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	width, height = emailDimensions(r, width, height)
+	format = emailFormat(r, format)
+	r = withRenderCost(r, renderCost{width: width, height: height})
+
+	style := render.DividerStyle(r.URL.Query().Get("style"))
+	if style == "" {
+		style = render.DividerWave
+	}
+	if !style.IsValid() {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid style. Use wave, blob, or tilt."))
+		return
+	}
+
+	seedParam := r.URL.Query().Get("seed")
+	if seedParam == "" {
+		seedParam = fmt.Sprintf("%dx%d", width, height)
+	}
+	seed := genart.ParseSeed(seedParam)
+	flip := r.URL.Query().Get("flip") == "true"
+
+	fillHex, err := queryColor(r, "color")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if fillHex == "" {
+		fillHex = s.themeFor(r).dividerColor
+	}
+	// Transparent by default; JPEG has no alpha channel so fall back to white
+	bgHex, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if bgHex == "" && (format == render.FormatJPG || format == render.FormatJPEG) {
+		bgHex = "ffffff"
+	}
+
+	key := specKey("DIVIDER", dividerSpec{Width: width, Height: height, Style: style, Seed: seed, Fill: fillHex, Bg: bgHex, Flip: flip, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawDivider(ctx, width, height, style, seed, fillHex, bgHex, flip, format)
+	})
+}
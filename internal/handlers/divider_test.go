@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDividerHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"default wave", "/divider/1440x120", http.StatusOK, "image/svg+xml"},
+		{"blob with color", "/divider/1440x160.svg?style=blob&color=e76f51&seed=3", http.StatusOK, "image/svg+xml"},
+		{"flipped tilt PNG", "/divider/800x80.png?style=tilt&flip=true", http.StatusOK, "image/png"},
+		{"JPG gets background", "/divider/800x80.jpg", http.StatusOK, "image/jpeg"},
+		{"invalid style", "/divider/800x80?style=zigzag", http.StatusBadRequest, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
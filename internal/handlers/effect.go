@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+// effectParams reads the effect and seed parameters of a request for an image in
+// format, with the seed falling back to defaultSeed. The effect is empty when the
+// request has none.
+func effectParams(r *http.Request, format render.ImageFormat, defaultSeed string) (render.Effect, uint64, error) {
+	effect := render.Effect(r.URL.Query().Get("effect"))
+	if effect == "" {
+		return "", 0, nil
+	}
+	if !effect.IsValid() {
+		return "", 0, ErrInvalidParameter.withMessage("Invalid effect. Use confetti, sparkle, vignette, halftone, or dither.")
+	}
+	if effect.IsRasterOnly() && format == render.FormatSVG {
+		return "", 0, ErrUnsupportedFormat.withMessage("effect=%s needs raster output. Use a .png, .jpg, .gif, or .webp extension.", effect)
+	}
+	seedParam := r.URL.Query().Get("seed")
+	if seedParam == "" {
+		seedParam = defaultSeed
+	}
+	seed := genart.ParseSeed(seedParam)
+	return effect, seed, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEffectParameter(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		overlay bool
+	}{
+		{"confetti placeholder", "/placeholder/400x200?effect=confetti", http.StatusOK, true},
+		{"seeded sparkle avatar", "/avatar/?name=Jane+Doe&effect=sparkle&seed=party", http.StatusOK, true},
+		{"no effect", "/placeholder/400x200", http.StatusOK, false},
+		{"vignette scatters nothing", "/placeholder/400x200?effect=vignette", http.StatusOK, false},
+		{"halftone PNG", "/placeholder/400x200.png?effect=halftone", http.StatusOK, false},
+		{"dither needs raster", "/avatar/?name=Jane+Doe&effect=dither", http.StatusBadRequest, false},
+		{"invalid effect", "/avatar/?name=Jane+Doe&effect=snow", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if overlay := strings.Contains(rec.Body.String(), "<polygon"); overlay != tt.overlay {
+				t.Errorf("expected overlay %t, got %t", tt.overlay, overlay)
+			}
+		})
+	}
+
+	// The seed picks where the pieces land, so it's part of the cache key
+	etag := func(path string) string {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Header().Get("ETag")
+	}
+	if etag("/placeholder/400x200?effect=confetti&seed=1") == etag("/placeholder/400x200?effect=confetti&seed=2") {
+		t.Error("expected different seeds to have different ETags")
+	}
+}
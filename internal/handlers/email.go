@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// emailSafe reports whether the request asks for email-safe output (email=true).
+// Email clients render PNG and GIF reliably but not SVG or WebP, Outlook drops
+// gradients, and large images are clipped or slow to load.
+func emailSafe(r *http.Request) bool {
+	email := r.URL.Query().Get("email")
+	return email == "true" || email == "1"
+}
+
+// emailFormat returns PNG in place of formats email clients don't render reliably
+// when email-safe output is requested, and format otherwise.
+func emailFormat(r *http.Request, format render.ImageFormat) render.ImageFormat {
+	if !emailSafe(r) || format == render.FormatPNG || format == render.FormatGIF {
+		return format
+	}
+	return render.FormatPNG
+}
+
+// emailDimensions scales width×height down to fit config.EmailMaxDimension when
+// email-safe output is requested, keeping the aspect ratio.
+func emailDimensions(r *http.Request, width, height int) (int, int) {
+	if !emailSafe(r) {
+		return width, height
+	}
+	fitWidth, fitHeight := fitWithin(width, height, config.EmailMaxDimension)
+	if fitWidth != width || fitHeight != height {
+		warn(r, "email=true scales %dx%d down to %dx%d", width, height, fitWidth, fitHeight)
+	}
+	return fitWidth, fitHeight
+}
+
+// fitWithin scales width×height down so neither side exceeds limit, keeping the
+// aspect ratio. Sizes that already fit are returned unchanged.
+func fitWithin(width, height, limit int) (int, int) {
+	if width <= limit && height <= limit {
+		return width, height
+	}
+	if width >= height {
+		return limit, max(1, height*limit/width)
+	}
+	return max(1, width*limit/height), limit
+}
+
+// emailBackground flattens a gradient background to the solid color between its
+// stops when email-safe output is requested.
+func emailBackground(r *http.Request, bgHex string) string {
+	if colors := parsePalette(bgHex); emailSafe(r) && len(colors) > 1 {
+		return render.AverageColor(colors...)
+	}
+	return bgHex
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFitWithin(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		expectW       int
+		expectH       int
+	}{
+		{"fits", 600, 300, 600, 300},
+		{"wide", 2400, 600, 1200, 300},
+		{"tall", 600, 3600, 200, 1200},
+		{"square", 5000, 5000, 1200, 1200},
+		{"thin", 100000, 10, 1200, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := fitWithin(tt.width, tt.height, 1200)
+			if w != tt.expectW || h != tt.expectH {
+				t.Fatalf("expected %dx%d got %dx%d", tt.expectW, tt.expectH, w, h)
+			}
+		})
+	}
+}
+
+func TestEmailSafeOutput(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name          string
+		path          string
+		contentType   string
+		width, height int
+	}{
+		{"SVG becomes PNG", "/placeholder/600x200?email=true", "image/png", 600, 200},
+		{"WebP becomes PNG", "/avatar/Jane%20Doe.webp?email=true&size=64", "image/png", 64, 64},
+		{"GIF is kept", "/placeholder/300x100.gif?email=1", "image/gif", 300, 100},
+		{"dimensions are capped", "/placeholder/2400x600.png?email=true", "image/png", 1200, 300},
+		{"avatar size is capped", "/avatar/Jane%20Doe.png?email=true&size=4000", "image/png", 1200, 1200},
+		{"compatibility routes too", "/api/?name=Jane+Doe&format=svg&email=true", "image/png", 64, 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			img, _, err := image.Decode(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+				t.Fatalf("expected %dx%d got %dx%d", tt.width, tt.height, b.Dx(), b.Dy())
+			}
+		})
+	}
+}
+
+func TestEmailSafeFlattensGradients(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/400x100?bg=ff0000,0000ff&text=+&email=true", nil))
+
+	img, _, err := image.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	left, right := img.At(2, 50), img.At(397, 50)
+	if left != right {
+		t.Fatalf("expected a solid background, got %v on the left and %v on the right", left, right)
+	}
+	if r, _, b, _ := left.RGBA(); r>>8 != 0x7f || b>>8 != 0x7f {
+		t.Fatalf("expected the average of the stops, got %v", left)
+	}
+}
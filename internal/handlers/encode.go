@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// encodeParams reads the encoder parameters of a request (quality, subsample,
+// effort, and maxBytes), falling back to the server's defaults. The output spec
+// holds those that change how format is encoded from the defaults, to keep such
+// images apart in the cache.
+func (s *Service) encodeParams(r *http.Request, format render.ImageFormat) (render.EncodeOptions, outputSpec, error) {
+	query := r.URL.Query()
+	opts := render.EncodeOptions{Quality: s.cfg.ImageQuality, Subsample: s.cfg.JPEGSubsample, Effort: s.cfg.PNGEffort}
+	if query.Has("quality") {
+		quality, err := strconv.Atoi(query.Get("quality"))
+		if err != nil || quality < 1 || quality > 100 {
+			return opts, outputSpec{}, ErrInvalidParameter.withMessage("Invalid quality. Use a number from 1 to 100.")
+		}
+		opts.Quality = quality
+	}
+	if query.Has("subsample") {
+		if !config.JPEGSubsamples[query.Get("subsample")] {
+			return opts, outputSpec{}, ErrInvalidParameter.withMessage("Invalid subsample. Use 420 or 444.")
+		}
+		opts.Subsample = query.Get("subsample")
+	}
+	if query.Has("effort") {
+		if !config.PNGEfforts[query.Get("effort")] {
+			return opts, outputSpec{}, ErrInvalidParameter.withMessage("Invalid effort. Use fast, default, or best.")
+		}
+		opts.Effort = query.Get("effort")
+	}
+	if query.Has("maxBytes") {
+		maxBytes, err := strconv.Atoi(query.Get("maxBytes"))
+		if err != nil || maxBytes < 1 {
+			return opts, outputSpec{}, ErrInvalidParameter.withMessage("Invalid maxBytes. Use a positive number of bytes.")
+		}
+		opts.MaxBytes = maxBytes
+	}
+
+	var out outputSpec
+	switch format {
+	case render.FormatJPG, render.FormatJPEG:
+		if opts.Quality != s.cfg.ImageQuality {
+			out.Quality = opts.Quality
+		}
+		if opts.Subsample != s.cfg.JPEGSubsample {
+			out.Subsample = opts.Subsample
+		}
+	case render.FormatWebP:
+		if opts.Quality != s.cfg.ImageQuality {
+			out.Quality = opts.Quality
+		}
+	case render.FormatPNG:
+		if opts.Effort != s.cfg.PNGEffort {
+			out.Effort = opts.Effort
+		}
+	}
+	out.MaxBytes = opts.MaxBytes
+	return opts, out, nil
+}
+
+// textParams reads how a request's raster text is drawn (hinting, aa, and
+// supersample), falling back to the server's defaults, and adds those that
+// aren't the defaults to out. SVGs leave text to the viewer, so theirs are
+// checked but kept out of the cache key.
+func (s *Service) textParams(r *http.Request, format render.ImageFormat, out outputSpec) (render.TextOptions, outputSpec, error) {
+	query := r.URL.Query()
+	opts := render.TextOptions{Hinting: s.cfg.FontHinting, Aliased: !s.cfg.TextAntialias, Supersample: s.cfg.Supersample}
+	if query.Has("hinting") {
+		if !config.FontHintings[query.Get("hinting")] {
+			return opts, out, ErrInvalidParameter.withMessage("Invalid hinting. Use full or none.")
+		}
+		opts.Hinting = query.Get("hinting")
+	}
+	if query.Has("aa") {
+		aa, err := strconv.ParseBool(query.Get("aa"))
+		if err != nil {
+			return opts, out, ErrInvalidParameter.withMessage("Invalid aa. Use true or false.")
+		}
+		opts.Aliased = !aa
+	}
+	if query.Has("supersample") {
+		factor, err := strconv.Atoi(query.Get("supersample"))
+		if err != nil || factor < 1 || factor > config.MaxSupersample {
+			return opts, out, ErrInvalidParameter.withMessage("Invalid supersample. Use a number from 1 to %d.", config.MaxSupersample)
+		}
+		opts.Supersample = factor
+	}
+
+	if format == render.FormatSVG {
+		return opts, out, nil
+	}
+	if opts.Hinting != s.cfg.FontHinting {
+		out.Hinting = opts.Hinting
+	}
+	if opts.Aliased == s.cfg.TextAntialias {
+		out.AA = strconv.FormatBool(!opts.Aliased)
+	}
+	if opts.Supersample != s.cfg.Supersample {
+		out.Supersample = opts.Supersample
+	}
+	return opts, out, nil
+}
+
+// drawText wraps generator to draw its raster text with opts.
+func drawText(generator func(ctx context.Context) ([]byte, error), opts render.TextOptions) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		return generator(render.WithTextOptions(ctx, opts))
+	}
+}
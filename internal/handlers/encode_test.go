@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEncodeParams(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"quality", "/placeholder/200x100.jpg?quality=50", http.StatusOK},
+		{"subsample 444", "/placeholder/200x100.jpg?subsample=444", http.StatusOK},
+		{"effort", "/placeholder/200x100.png?effort=best", http.StatusOK},
+		{"ignored by the format", "/placeholder/200x100.svg?subsample=444&effort=fast", http.StatusOK},
+		{"quality not a number", "/placeholder/200x100.jpg?quality=high", http.StatusBadRequest},
+		{"quality out of range", "/placeholder/200x100.jpg?quality=101", http.StatusBadRequest},
+		{"invalid subsample", "/placeholder/200x100.jpg?subsample=422", http.StatusBadRequest},
+		{"invalid effort", "/placeholder/200x100.png?effort=max", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestEncodeParamsChangeOutput(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200 got %d", path, rec.Code)
+		}
+		return rec
+	}
+
+	base := get("/placeholder/300x150.jpg?text=Sharp")
+	for _, query := range []string{"&subsample=444", "&quality=40"} {
+		rec := get("/placeholder/300x150.jpg?text=Sharp" + query)
+		if bytes.Equal(rec.Body.Bytes(), base.Body.Bytes()) {
+			t.Errorf("%s: expected different output from the default encoding", query)
+		}
+		if rec.Header().Get("ETag") == base.Header().Get("ETag") {
+			t.Errorf("%s: expected its own ETag", query)
+		}
+	}
+
+	// Parameters that don't apply to the format share the default's cache entry
+	png := get("/placeholder/300x150.png?text=Sharp")
+	if rec := get("/placeholder/300x150.png?text=Sharp&subsample=444&quality=40"); rec.Header().Get("ETag") != png.Header().Get("ETag") {
+		t.Errorf("expected JPEG parameters not to change a PNG's ETag")
+	}
+}
+
+func TestEncodeParamsServerDefaults(t *testing.T) {
+	svc, mux := setupTestService(t)
+
+	get := func(path string) []byte {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Body.Bytes()
+	}
+
+	explicit := get("/placeholder/300x150.jpg?text=Sharp&subsample=444&quality=70")
+	svc.cfg.JPEGSubsample = "444"
+	svc.cfg.ImageQuality = 70
+	if !bytes.Equal(get("/placeholder/300x150.jpg?text=Sharp"), explicit) {
+		t.Fatalf("expected the server defaults to encode like the same parameters")
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		maxBytes int
+		status   int
+	}{
+		{"jpeg within budget", "/placeholder/800x400.jpg?text=Budget&maxBytes=8000", 8000, http.StatusOK},
+		{"webp within budget", "/placeholder/800x400.webp?text=Budget&maxBytes=3000", 3000, http.StatusOK},
+		{"png shrinks to fit", "/placeholder/800x400.png?bg=ff0000,0000ff&text=Budget&maxBytes=4000", 4000, http.StatusOK},
+		{"already fits", "/placeholder/100x100.png?maxBytes=100000", 100000, http.StatusOK},
+		{"raster can't fit", "/placeholder/800x400.png?maxBytes=10", 10, http.StatusRequestEntityTooLarge},
+		{"svg over budget", "/placeholder/800x400.svg?text=Budget&maxBytes=100", 100, http.StatusRequestEntityTooLarge},
+		{"invalid", "/placeholder/800x400.png?maxBytes=0", 0, http.StatusBadRequest},
+		{"not a number", "/placeholder/800x400.png?maxBytes=50kb", 0, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && rec.Body.Len() > tt.maxBytes {
+				t.Fatalf("expected at most %d bytes, got %d", tt.maxBytes, rec.Body.Len())
+			}
+			if tt.status == http.StatusRequestEntityTooLarge && rec.Header().Get("X-Error-Code") != "over_budget" {
+				t.Fatalf("expected over_budget, got %q", rec.Header().Get("X-Error-Code"))
+			}
+		})
+	}
+}
+
+func TestTextParams(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"full hinting", "/avatar/JD.png?size=32&hinting=full", http.StatusOK},
+		{"no anti-aliasing", "/avatar/JD.png?size=32&aa=false", http.StatusOK},
+		{"supersample", "/avatar/JD.png?size=32&supersample=2", http.StatusOK},
+		{"checked for SVG", "/avatar/JD.svg?hinting=full&aa=0&supersample=4", http.StatusOK},
+		{"invalid hinting", "/avatar/JD.png?hinting=slight", http.StatusBadRequest},
+		{"invalid aa", "/avatar/JD.png?aa=maybe", http.StatusBadRequest},
+		{"supersample not a number", "/avatar/JD.png?supersample=x", http.StatusBadRequest},
+		{"supersample out of range", "/avatar/JD.png?supersample=8", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTextParamsChangeOutput(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200 got %d", path, rec.Code)
+		}
+		return rec
+	}
+
+	base := get("/avatar/Jane%20Doe.png?size=32")
+	for _, query := range []string{"&hinting=full", "&aa=false", "&supersample=2"} {
+		rec := get("/avatar/Jane%20Doe.png?size=32" + query)
+		if bytes.Equal(rec.Body.Bytes(), base.Body.Bytes()) {
+			t.Errorf("%s: expected different output from the default text rendering", query)
+		}
+		if rec.Header().Get("ETag") == base.Header().Get("ETag") {
+			t.Errorf("%s: expected its own ETag", query)
+		}
+	}
+
+	// The defaults spelled out share the default's cache entry, as do SVGs,
+	// which leave text to the viewer
+	if rec := get("/avatar/Jane%20Doe.png?size=32&hinting=none&aa=true&supersample=1"); rec.Header().Get("ETag") != base.Header().Get("ETag") {
+		t.Errorf("expected the default text parameters not to change the ETag")
+	}
+	svg := get("/avatar/Jane%20Doe.svg?size=32")
+	if rec := get("/avatar/Jane%20Doe.svg?size=32&hinting=full&aa=false"); rec.Header().Get("ETag") != svg.Header().Get("ETag") {
+		t.Errorf("expected text parameters not to change an SVG's ETag")
+	}
+}
+
+func TestTextParamsServerDefaults(t *testing.T) {
+	svc, mux := setupTestService(t)
+
+	get := func(path string) []byte {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Body.Bytes()
+	}
+
+	explicit := get("/avatar/Jane%20Doe.png?size=32&hinting=full&aa=false&supersample=2")
+	svc.cfg.FontHinting = "full"
+	svc.cfg.TextAntialias = false
+	svc.cfg.Supersample = 2
+	if !bytes.Equal(get("/avatar/Jane%20Doe.png?size=32"), explicit) {
+		t.Fatalf("expected the server defaults to draw like the same parameters")
+	}
+	if bytes.Equal(get("/avatar/Jane%20Doe.png?size=32&aa=true"), explicit) {
+		t.Errorf("expected aa=true to override a server default of false")
+	}
+}
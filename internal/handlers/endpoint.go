@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/utils"
+)
+
+// shieldsEndpoint is the shields.io endpoint badge JSON schema.
+type shieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	LabelColor    string `json:"labelColor"`
+	IsError       bool   `json:"isError"`
+	Style         string `json:"style"`
+	CacheSeconds  int    `json:"cacheSeconds"`
+}
+
+// badgeColor resolves a badge color parameter, keeping def when it's empty or unknown.
+func badgeColor(value, def string) string {
+	if hex, ok := render.ResolveBadgeColor(value); ok {
+		return hex
+	}
+	return def
+}
+
+// endpointBadge turns an endpoint document into a badge. Like shields.io, a document
+// that doesn't follow the schema renders as an "invalid" badge.
+func endpointBadge(doc shieldsEndpoint) render.Badge {
+	if doc.SchemaVersion != 1 || doc.Message == "" {
+		return render.Badge{Label: "custom badge", Message: "invalid"}
+	}
+	badge := render.Badge{
+		Label:      doc.Label,
+		Message:    doc.Message,
+		Color:      badgeColor(doc.Color, ""),
+		LabelColor: badgeColor(doc.LabelColor, ""),
+		Style:      render.BadgeStyle(doc.Style),
+	}
+	if badge.Color == "" && doc.IsError {
+		badge.Color = badgeColor("critical", "")
+	}
+	return badge
+}
+
+// handleEndpointBadge serves /endpoint?url=, rendering the shields.io endpoint badge
+// JSON at url locally. The url must be on the proxy allowlist.
+func (s *Service) handleEndpointBadge(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	rawURL := query.Get("url")
+	if !s.validateProxyURL(w, r, rawURL) {
+		return
+	}
+	format, _, err := extractFormat(path.Base(r.URL.Path))
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	format = emailFormat(r, format)
+
+	badge := render.Badge{Label: "custom badge", Message: "inaccessible"}
+	cacheSeconds := config.DefaultBadgeCacheSeconds
+	var doc shieldsEndpoint
+	if err := s.fetcher.FetchJSON(r.Context(), rawURL, &doc); err == nil {
+		badge = endpointBadge(doc)
+		// Shorter cache times than the default are ignored, as on shields.io
+		cacheSeconds = max(cacheSeconds, doc.CacheSeconds)
+	}
+
+	// Query parameters override the document, as on shields.io
+	if query.Has("label") {
+		badge.Label = query.Get("label")
+	}
+	badge.Color = badgeColor(query.Get("color"), badge.Color)
+	badge.LabelColor = badgeColor(query.Get("labelColor"), badge.LabelColor)
+	if style := query.Get("style"); style != "" {
+		badge.Style = render.BadgeStyle(style)
+	}
+	cacheSeconds = max(cacheSeconds, utils.ParseIntOrDefault(query.Get("cacheSeconds"), 0))
+	if badge.Label, err = s.limitLength(r, "label", badge.Label, s.cfg.MaxTextLength); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if badge.Message, err = s.limitLength(r, "message", badge.Message, s.cfg.MaxTextLength); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	// The document can change, so the badge is cached for a while rather than
+	// forever, and under what it shows rather than its URL
+	r = staleAt(r, time.Now().Add(time.Duration(cacheSeconds)*time.Second))
+	key := specKey("EndpointBadge", badgeSpec{Badge: badge, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawBadge(ctx, badge, format)
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newEndpointServer serves shields.io endpoint documents.
+func newEndpointServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	docs := map[string]string{
+		"/build.json":   `{"schemaVersion":1,"label":"build","message":"passing","color":"brightgreen","cacheSeconds":3600}`,
+		"/error.json":   `{"schemaVersion":1,"label":"deploy","message":"failed","isError":true}`,
+		"/message.json": `{"schemaVersion":1,"label":"","message":"v1.2.3","color":"ff5722","style":"flat-square"}`,
+		"/invalid.json": `{"schemaVersion":2,"label":"x","message":"y"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := docs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(doc))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEndpointBadge(t *testing.T) {
+	srv := newEndpointServer(t)
+	mux := setupProxyTestService(t)
+
+	tests := []struct {
+		name         string
+		path         string
+		doc          string
+		query        string
+		contentType  string
+		cacheControl string
+		expectSubstr []string
+	}{
+		{"label and message", "/endpoint", "/build.json", "", "image/svg+xml", "public, max-age=3600", []string{">build<", ">passing<", `fill="#44cc11"`, `aria-label="build: passing"`}},
+		{"error defaults to red", "/endpoint", "/error.json", "", "image/svg+xml", "public, max-age=300", []string{">failed<", `fill="#e05d44"`}},
+		{"message only", "/endpoint.svg", "/message.json", "", "image/svg+xml", "public, max-age=300", []string{">v1.2.3<", `rx="0"`, `fill="#ff5722"`}},
+		{"query overrides", "/endpoint", "/build.json", "&label=ci&color=blue&style=for-the-badge", "image/svg+xml", "public, max-age=3600", []string{">CI<", ">PASSING<", `fill="#007ec6"`, `height="28"`}},
+		{"invalid schema", "/endpoint", "/invalid.json", "", "image/svg+xml", "public, max-age=300", []string{">custom badge<", ">invalid<"}},
+		{"unreachable document", "/endpoint", "/missing.json", "", "image/svg+xml", "public, max-age=300", []string{">inaccessible<"}},
+		{"png", "/endpoint.png", "/build.json", "", "image/png", "public, max-age=3600", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.path + "?url=" + url.QueryEscape(srv.URL+tt.doc) + tt.query
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			if cc := rec.Header().Get("Cache-Control"); cc != tt.cacheControl {
+				t.Fatalf("expected cache-control %q got %q", tt.cacheControl, cc)
+			}
+			for _, substr := range tt.expectSubstr {
+				if !strings.Contains(rec.Body.String(), substr) {
+					t.Errorf("expected body to contain %q, got: %s", substr, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointBadgeURLValidation(t *testing.T) {
+	mux := setupProxyTestService(t)
+
+	tests := []struct {
+		name         string
+		target       string
+		expectStatus int
+	}{
+		{"missing url", "/endpoint", http.StatusBadRequest},
+		{"host not allowed", "/endpoint?url=" + url.QueryEscape("https://example.com/badge.json"), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestEndpointBadgeLimits(t *testing.T) {
+	srv := newEndpointServer(t)
+	mux := setupProxyTestService(t)
+	doc := url.QueryEscape(srv.URL + "/build.json")
+
+	// Labels are cut like any other text
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/endpoint?url="+doc+"&label="+strings.Repeat("a", 300), nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), strings.Repeat("a", 300)) {
+		t.Errorf("expected the label to be cut, got %d", rec.Code)
+	}
+
+	// The badge is served like other images, within the byte budget
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/endpoint?url="+doc+"&maxBytes=10", nil))
+	if rec.Code != http.StatusRequestEntityTooLarge || rec.Header().Get("X-Error-Code") != "over_budget" {
+		t.Errorf("expected 413 over_budget, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"grout/internal/remote"
+	"grout/internal/render"
+)
+
+// requestError is an error a request fails with. Its code is stable, unlike the
+// message, so clients and dashboards can rely on it: every error response sends
+// the code in the X-Error-Code header, JSON error bodies include it, and the
+// admin stats count responses by code.
+type requestError struct {
+	code    string
+	status  int
+	message string
+	// cause is the underlying error; it's logged but never shown to clients
+	cause error
+}
+
+var (
+	// ErrInvalidParameter is returned when a query or path parameter has an invalid value.
+	ErrInvalidParameter = &requestError{code: "invalid_parameter", status: http.StatusBadRequest, message: "Invalid parameter."}
+	// ErrMissingParameter is returned when a required parameter is missing.
+	ErrMissingParameter = &requestError{code: "missing_parameter", status: http.StatusBadRequest, message: "Missing parameter."}
+	// ErrInvalidColor is returned when a color parameter is neither a hex color nor a CSS color name.
+	ErrInvalidColor = &requestError{code: "invalid_color", status: http.StatusBadRequest, message: "Invalid color. Use a hex color such as ff5733 or a CSS color name."}
+	// ErrDimensionTooLarge is returned when a requested width or height is over the maximum.
+	ErrDimensionTooLarge = &requestError{code: "dimension_too_large", status: http.StatusBadRequest, message: "The image is too large."}
+	// ErrTextTooLong is returned in strict mode when a text or name parameter is over its maximum length.
+	ErrTextTooLong = &requestError{code: "text_too_long", status: http.StatusBadRequest, message: "Text is too long."}
+	// ErrUnsupportedFormat is returned when an endpoint can't produce the requested format.
+	ErrUnsupportedFormat = &requestError{code: "unsupported_format", status: http.StatusBadRequest, message: "Unsupported format."}
+	// ErrInvalidURL is returned when a URL parameter is malformed or not http(s).
+	ErrInvalidURL = &requestError{code: "invalid_url", status: http.StatusBadRequest, message: "Invalid url parameter. Provide an absolute http or https image URL."}
+	// ErrInvalidImage is returned when a source image can't be used.
+	ErrInvalidImage = &requestError{code: "invalid_image", status: http.StatusUnprocessableEntity, message: "The image can't be used."}
+	// ErrOverBudget is returned when an image can't be made to fit its maxBytes.
+	ErrOverBudget = &requestError{code: "over_budget", status: http.StatusRequestEntityTooLarge, message: "The image can't be made to fit in maxBytes. Try a larger budget or a smaller size."}
+	// ErrNotFound is returned for paths that don't exist.
+	ErrNotFound = &requestError{code: "not_found", status: http.StatusNotFound, message: "The page you're looking for doesn't exist. It might have been moved or deleted."}
+	// ErrFeatureDisabled is returned by endpoints this server hasn't enabled.
+	ErrFeatureDisabled = &requestError{code: "feature_disabled", status: http.StatusNotFound, message: "This feature is not enabled on this server."}
+	// ErrUnauthorized is returned when a request lacks valid credentials.
+	ErrUnauthorized = &requestError{code: "unauthorized", status: http.StatusUnauthorized, message: "Missing or invalid credentials."}
+	// ErrInvalidSignature is returned when a signed URL's signature is missing or wrong.
+	ErrInvalidSignature = &requestError{code: "invalid_signature", status: http.StatusForbidden, message: "Missing or invalid signature."}
+	// ErrHostNotAllowed is returned when a remote image host isn't on the allowlist.
+	ErrHostNotAllowed = &requestError{code: "host_not_allowed", status: http.StatusForbidden, message: "The image host is not on this server's allowlist."}
+	// ErrLinkExpired is returned when a signed URL's exp time has passed.
+	ErrLinkExpired = &requestError{code: "link_expired", status: http.StatusGone, message: "This link has expired."}
+	// ErrUpstreamFailed is returned when a remote image or document can't be fetched.
+	ErrUpstreamFailed = &requestError{code: "upstream_failed", status: http.StatusBadGateway, message: "Failed to fetch or decode the remote image."}
+	// ErrTooComplex is returned when an image's estimated rendering work is over the render budget.
+	ErrTooComplex = &requestError{code: "too_complex", status: http.StatusUnprocessableEntity, message: "The image is too complex to render. Try a smaller size or simpler options."}
+	// ErrRateLimited is reported for live previews refused by the client's rate limit.
+	ErrRateLimited = &requestError{code: "rate_limited", status: http.StatusTooManyRequests, message: "Too many previews. Slow down and try again shortly."}
+	// ErrQuotaExceeded is returned when a tenant has used its monthly render quota.
+	ErrQuotaExceeded = &requestError{code: "quota_exceeded", status: http.StatusTooManyRequests, message: "This site has used its images for the month."}
+	// ErrRenderTimeout is returned when rendering takes longer than the render timeout.
+	ErrRenderTimeout = &requestError{code: "render_timeout", status: http.StatusServiceUnavailable, message: "Rendering the image took too long. Try a smaller size or simpler options."}
+	// ErrQueueFull is returned when there's no room in the queue for an async render.
+	ErrQueueFull = &requestError{code: "queue_full", status: http.StatusServiceUnavailable, message: "The render queue is full. Try again shortly."}
+	// ErrMaintenance is returned by image and API routes while the server is in maintenance mode.
+	ErrMaintenance = &requestError{code: "maintenance", status: http.StatusServiceUnavailable, message: "The server is down for maintenance. Try again later."}
+	// ErrInjectedFailure is returned, with the status asked for, by requests that inject a failure with x-chaos.
+	ErrInjectedFailure = &requestError{code: "injected_failure", status: http.StatusInternalServerError, message: "Failure injected by the x-chaos parameter."}
+	// ErrRenderFailed is returned when rendering fails for any other reason.
+	ErrRenderFailed = &requestError{code: "render_failed", status: http.StatusInternalServerError, message: "Failed to generate image. Please try again later or contact support if the problem persists."}
+)
+
+func (e *requestError) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.code, e.message, e.cause)
+	}
+	return e.code + ": " + e.message
+}
+
+func (e *requestError) Unwrap() error { return e.cause }
+
+// Is matches errors by code, so a copy with its own message or cause still
+// matches the error it was made from.
+func (e *requestError) Is(target error) bool {
+	t, ok := target.(*requestError)
+	return ok && t.code == e.code
+}
+
+// withMessage returns a copy of e with a message specific to the request.
+func (e *requestError) withMessage(format string, args ...any) *requestError {
+	copied := *e
+	copied.message = fmt.Sprintf(format, args...)
+	return &copied
+}
+
+// withCause returns a copy of e that wraps cause.
+func (e *requestError) withCause(cause error) *requestError {
+	copied := *e
+	copied.cause = cause
+	return &copied
+}
+
+// asRequestError maps err to the request error it's reported as, classifying
+// errors from the render and remote packages.
+func asRequestError(err error) *requestError {
+	var re *requestError
+	switch {
+	case errors.As(err, &re):
+		return re
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrRenderTimeout.withCause(err)
+	case errors.Is(err, render.ErrUnsupportedFormat):
+		return ErrUnsupportedFormat.withCause(err)
+	case errors.Is(err, render.ErrOverBudget):
+		return ErrOverBudget.withCause(err)
+	case errors.Is(err, remote.ErrHostNotAllowed):
+		return ErrHostNotAllowed.withCause(err)
+	case errors.Is(err, remote.ErrInvalidURL):
+		return ErrInvalidURL.withCause(err)
+	default:
+		return ErrRenderFailed.withCause(err)
+	}
+}
+
+// errorCounter counts error responses by code.
+type errorCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func newErrorCounter() *errorCounter {
+	return &errorCounter{counts: make(map[string]uint64)}
+}
+
+func (c *errorCounter) add(code string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[code]++
+}
+
+// snapshot returns a copy of the counts.
+func (c *errorCounter) snapshot() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]uint64, len(c.counts))
+	for code, n := range c.counts {
+		counts[code] = n
+	}
+	return counts
+}
+
+// reportError records an error response: it sets the X-Error-Code header, counts
+// the code, and logs server-side failures with their cause.
+func (s *Service) reportError(w http.ResponseWriter, r *http.Request, e *requestError) {
+	w.Header().Set("X-Error-Code", e.code)
+	s.errorCounts.add(e.code)
+	if e.status >= http.StatusInternalServerError {
+		if id := requestID(r); id != "" {
+			log.Printf("%s %s [%s]: %v", r.Method, s.logURL(r.URL), id, e)
+			return
+		}
+		log.Printf("%s %s: %v", r.Method, s.logURL(r.URL), e)
+	}
+}
+
+// fail responds to a page or image request with the HTML error page for err.
+func (s *Service) fail(w http.ResponseWriter, r *http.Request, err error) {
+	e := asRequestError(err)
+	s.reportError(w, r, e)
+	recordRenderFailure(r, e)
+	s.serveErrorPage(w, r, e.status, e.message)
+}
+
+// failJSON responds to an API request with a JSON error body for err.
+func (s *Service) failJSON(w http.ResponseWriter, r *http.Request, err error) {
+	e := asRequestError(err)
+	s.reportError(w, r, e)
+	writeJSON(w, e.status, map[string]string{"error": e.message, "code": e.code})
+}
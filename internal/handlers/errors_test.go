@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"grout/internal/remote"
+	"grout/internal/render"
+)
+
+func TestAsRequestError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect *requestError
+	}{
+		{"request error", ErrInvalidParameter.withMessage("Invalid thing."), ErrInvalidParameter},
+		{"wrapped request error", fmt.Errorf("context: %w", ErrLinkExpired), ErrLinkExpired},
+		{"render timeout", fmt.Errorf("render: %w", context.DeadlineExceeded), ErrRenderTimeout},
+		{"unsupported format", fmt.Errorf("%w: svg", render.ErrUnsupportedFormat), ErrUnsupportedFormat},
+		{"over budget", fmt.Errorf("%w: 100 bytes", render.ErrOverBudget), ErrOverBudget},
+		{"host not allowed", remote.ErrHostNotAllowed, ErrHostNotAllowed},
+		{"anything else", errors.New("boom"), ErrRenderFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := asRequestError(tt.err)
+			if !errors.Is(got, tt.expect) || got.status != tt.expect.status {
+				t.Fatalf("expected %s got %s", tt.expect.code, got.code)
+			}
+		})
+	}
+}
+
+func TestErrorResponsesCarryCodes(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		code   string
+		json   bool
+	}{
+		{"invalid parameter", "/avatar/Jane?scheme=sepia", http.StatusBadRequest, "invalid_parameter", false},
+		{"missing parameter", "/text/", http.StatusBadRequest, "missing_parameter", false},
+		{"unsupported format", "/7.x/initials/bmp?seed=Jane", http.StatusBadRequest, "unsupported_format", false},
+		{"invalid color", "/placeholder/100x100.svg?bg=zzzzzz", http.StatusBadRequest, "invalid_color", false},
+		{"markup in a color", "/placeholder/100x100.svg?bg=" + url.QueryEscape(`"><script>`), http.StatusBadRequest, "invalid_color", false},
+		{"invalid text color", "/avatar/Jane.svg?color=notacolor", http.StatusBadRequest, "invalid_color", false},
+		{"invalid gradient stop", "/placeholder/100x100.svg?bg=ff0000,nope", http.StatusBadRequest, "invalid_color", false},
+		{"dimension too large", "/placeholder/20000x20000.png", http.StatusBadRequest, "dimension_too_large", false},
+		{"overflowing dimension", "/placeholder/9000000000000000000x9000000000000000000.png", http.StatusBadRequest, "dimension_too_large", false},
+		{"query dimension too large", "/calendar/?w=20000&h=100", http.StatusBadRequest, "dimension_too_large", false},
+		{"not found", "/missing", http.StatusNotFound, "not_found", false},
+		{"disabled feature", "/api/v1/palette?url=https://example.com/a.png", http.StatusNotFound, "feature_disabled", true},
+		{"json invalid parameter", "/api/v1/diff?threshold=5", http.StatusBadRequest, "invalid_parameter", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if got := rec.Header().Get("X-Error-Code"); got != tt.code {
+				t.Fatalf("expected X-Error-Code %s got %s", tt.code, got)
+			}
+			if !tt.json {
+				return
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body["code"] != tt.code || body["error"] == "" {
+				t.Fatalf("expected code %s and a message, got %v", tt.code, body)
+			}
+		})
+	}
+}
+
+func TestAdminStatsCountErrors(t *testing.T) {
+	mux := setupAdminTestService(t)
+
+	for i := 0; i < 2; i++ {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	}
+
+	_, stats := getAdminStats(t, mux, testAdminToken)
+	if stats.Errors["not_found"] != 2 {
+		t.Fatalf("expected 2 not_found errors, got %v", stats.Errors)
+	}
+}
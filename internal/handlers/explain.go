@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+
+	"grout/internal/cache"
+	"grout/internal/render"
+)
+
+// explanation is the JSON body of an explain=true request: how Grout interpreted
+// the request, instead of the image. Endpoints fill in what applies to them.
+type explanation struct {
+	Path        string   `json:"path"`
+	Width       int      `json:"width,omitempty"`
+	Height      int      `json:"height,omitempty"`
+	Format      string   `json:"format"`
+	ContentType string   `json:"content_type"`
+	Scheme      string   `json:"scheme,omitempty"`
+	Background  string   `json:"background,omitempty"`
+	Foreground  string   `json:"foreground,omitempty"`
+	FontSize    float64  `json:"font_size,omitempty"`
+	Lines       []string `json:"lines,omitempty"`
+	// Content is where the text came from: "text", "dimensions", "initials",
+	// "quote", or "joke"
+	Content string `json:"content,omitempty"`
+	// Name is an avatar's name as decoded from the request and normalized, the
+	// name its initials come from
+	Name string `json:"name,omitempty"`
+	// RenderCost is the estimated work of rendering the image, in pixels drawn,
+	// as checked against the render budget
+	RenderCost int64  `json:"render_cost,omitempty"`
+	CacheKey   string `json:"cache_key"`
+	ETag       string `json:"etag"`
+}
+
+type explanationKey struct{}
+
+// explainRequested reports whether a request asks for an explanation.
+func explainRequested(r *http.Request) bool {
+	explain := r.URL.Query().Get("explain")
+	return explain == "true" || explain == "1"
+}
+
+// withExplanation attaches the endpoint-specific part of an explanation to the
+// request, for serveImage to complete. It's a no-op unless explain is set.
+func withExplanation(r *http.Request, details explanation) *http.Request {
+	if !explainRequested(r) {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), explanationKey{}, details))
+}
+
+// writeExplanation completes the explanation attached to r with the cache key and
+// ETag of the image, and writes it as JSON. The image isn't rendered. In
+// no-personal-data mode, names in the path are hashed, the cache key is replaced
+// by its digest, and an avatar's initials are left out.
+func (s *Service) writeExplanation(w http.ResponseWriter, r *http.Request, cacheKey, etag string, format render.ImageFormat) {
+	details, _ := r.Context().Value(explanationKey{}).(explanation)
+	details.Path = r.URL.Path
+	details.Format = string(format)
+	details.ContentType = getContentType(format)
+	details.CacheKey = cacheKey
+	details.ETag = etag
+	if cost, ok := r.Context().Value(renderCostKey{}).(renderCost); ok {
+		details.RenderCost = cost.total()
+	}
+	if s.cfg.NoPersonalData {
+		details.Path = s.logPath(r.URL)
+		details.CacheKey = "sha256:" + hex.EncodeToString([]byte(cache.HashKey(cacheKey)))
+		if details.Content == "initials" {
+			details.Lines = nil
+		}
+		details.Name = ""
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, details)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+func TestExplain(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name  string
+		path  string
+		check func(t *testing.T, e explanation)
+	}{
+		{"placeholder dimensions", "/placeholder/300x200.png?bg=ff0000&explain=true", func(t *testing.T, e explanation) {
+			if e.Width != 300 || e.Height != 200 || e.Format != "png" || e.ContentType != "image/png" {
+				t.Errorf("unexpected size or format: %+v", e)
+			}
+			if e.Background != "ff0000" || e.Foreground == "" || e.Content != "dimensions" {
+				t.Errorf("unexpected colors or content: %+v", e)
+			}
+			if e.FontSize != 30 || len(e.Lines) != 1 || e.Lines[0] != "300 x 200" {
+				t.Errorf("unexpected text layout: %+v", e)
+			}
+		}},
+		{"placeholder quote wraps", "/placeholder/600x300?quote=true&explain=1", func(t *testing.T, e explanation) {
+			if e.Content != "quote" || len(e.Lines) == 0 || e.FontSize == 0 {
+				t.Errorf("expected a wrapped quote, got %+v", e)
+			}
+		}},
+		{"avatar", "/avatar/Jane%20Doe?size=64&scheme=dark&explain=true", func(t *testing.T, e explanation) {
+			if e.Lines[0] != "JD" || e.Content != "initials" || e.Background != "3d3232" || e.Scheme != "dark" {
+				t.Errorf("unexpected avatar explanation: %+v", e)
+			}
+		}},
+		{"other endpoints", "/rating/4?explain=true", func(t *testing.T, e explanation) {
+			if e.Path != "/rating/4" || e.Format != "svg" {
+				t.Errorf("unexpected rating explanation: %+v", e)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Fatalf("expected JSON got %s", ct)
+			}
+			var e explanation
+			if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if e.CacheKey == "" || e.ETag == "" {
+				t.Errorf("expected a cache key and ETag, got %+v", e)
+			}
+			tt.check(t, e)
+		})
+	}
+}
+
+func TestExplainMatchesImageETag(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	explain := httptest.NewRecorder()
+	mux.ServeHTTP(explain, httptest.NewRequest(http.MethodGet, "/placeholder/300x200?text=Hi&explain=true", nil))
+	var e explanation
+	if err := json.Unmarshal(explain.Body.Bytes(), &e); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	image := httptest.NewRecorder()
+	mux.ServeHTTP(image, httptest.NewRequest(http.MethodGet, "/placeholder/300x200?text=Hi", nil))
+	if got := image.Header().Get("ETag"); got != e.ETag {
+		t.Fatalf("expected the image ETag %s to match the explanation %s", got, e.ETag)
+	}
+	if image.Header().Get("X-Cache") != "MISS" {
+		t.Fatal("expected explain not to render or cache the image")
+	}
+}
+
+func TestExplainNoPersonalData(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](10)
+	cfg := config.DefaultServerConfig()
+	cfg.NoPersonalData = true
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/Jane%20Doe.png?explain=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, leak := range []string{"Jane", "JD"} {
+		if strings.Contains(body, leak) {
+			t.Errorf("expected %q to be left out of %s", leak, body)
+		}
+	}
+
+	var e explanation
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasPrefix(e.Path, "/avatar/~") || !strings.HasSuffix(e.Path, ".png") {
+		t.Errorf("expected a hashed name in the path, got %q", e.Path)
+	}
+	if !strings.HasPrefix(e.CacheKey, "sha256:") || len(e.CacheKey) != len("sha256:")+64 {
+		t.Errorf("expected the cache key digest, got %q", e.CacheKey)
+	}
+	if e.Content != "initials" || e.Width != config.DefaultSize {
+		t.Errorf("expected the rest of the explanation, got %+v", e)
+	}
+}
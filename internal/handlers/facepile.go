@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/utils"
+)
+
+// handleFacepile renders a stack of overlapping avatars at /avatars/{WxH}[.ext]
+// from a comma-separated names parameter, as a layout row with a negative gap.
+// Names past max are counted in a +N bubble at the end.
+func (s *Service) handleFacepile(w http.ResponseWriter, r *http.Request) {
+	pathMetric := strings.TrimPrefix(r.URL.Path, "/avatars/")
+
+	// Extract format from path
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	format = emailFormat(r, format)
+
+	width, height := config.DefaultFacepileWidth, config.DefaultFacepileHeight
+	if pathMetric != "" {
+		if width, height, err = parseDimensions(r, pathMetric); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+	}
+
+	var names []string
+	for _, name := range strings.Split(r.URL.Query().Get("names"), ",") {
+		if name = normalizeName(name); name == "" {
+			continue
+		}
+		name, err := s.limitLength(r, "names", name, s.cfg.MaxNameLength)
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		s.fail(w, r, ErrMissingParameter.withMessage("Missing names. Use names=Alice,Bob,Carol."))
+		return
+	}
+	if len(names) > config.MaxFacepileNames {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Too many names. The maximum is %d.", config.MaxFacepileNames))
+		return
+	}
+	maxShown := max(1, min(utils.ParseIntOrDefault(r.URL.Query().Get("max"), config.DefaultFacepileMax), config.MaxFacepileMax))
+	overlap := max(0, min(utils.ParseIntOrDefault(r.URL.Query().Get("overlap"), config.DefaultFacepileOverlap), config.MaxFacepileOverlap))
+
+	// Transparent by default; JPEG has no alpha channel so fall back to white
+	bgHex, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if bgHex == "" && (format == render.FormatJPG || format == render.FormatJPEG) {
+		bgHex = "ffffff"
+	}
+	// Rings match the background, so each avatar looks cut out of the one under it
+	ringHex := bgHex
+	if ringHex == "" {
+		ringHex = "ffffff"
+	}
+
+	shown := names[:min(len(names), maxShown)]
+	hidden := len(names) - len(shown)
+	count := len(shown)
+	if hidden > 0 {
+		count++
+	}
+	// The largest avatars that fit: count avatars, each but the first shifted
+	// by its size less the overlap
+	step := 1 - float64(overlap)/100
+	size := math.Floor(min(float64(height), float64(width)/(1+float64(count-1)*step)))
+	if size < 1 {
+		s.fail(w, r, ErrInvalidParameter.withMessage("The image is too small for %d avatars.", count))
+		return
+	}
+	ring := math.Max(1, math.Round(size/20))
+	if 2*ring >= size {
+		ring = 0
+	}
+
+	textSize := math.Round(size * config.FacepileTextScale)
+	row := render.LayoutNode{Type: "row", Gap: -math.Round(size * float64(overlap) / 100)}
+	for _, name := range shown {
+		row.Children = append(row.Children, render.LayoutNode{Type: "avatar", Name: name, Size: size, TextSize: textSize, Ring: ring, RingColor: ringHex})
+	}
+	title := "Avatars of " + strings.Join(shown, ", ")
+	if hidden > 0 {
+		more := "+" + strconv.Itoa(hidden)
+		row.Children = append(row.Children, render.LayoutNode{Type: "avatar", Text: more, Size: size, TextSize: textSize, Ring: ring, RingColor: ringHex, Background: config.FacepileOverflowBg})
+		title += fmt.Sprintf(" and %d more", hidden)
+	}
+
+	r = withAltText(r, title, fmt.Sprintf("A stack of %d overlapping avatars", len(shown)))
+	s.serveLayout(w, r, render.Layout{Width: width, Height: height, Background: bgHex, Root: row}, format, s.fail)
+}
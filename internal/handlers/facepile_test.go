@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFacepile(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		status      int
+		contentType string
+		expect      []string
+		reject      []string
+	}{
+		{"defaults", "/avatars/?names=Alice,Bob,Carol", http.StatusOK, "image/svg+xml", []string{`width="240" height="64"`, ">A<", ">B<", ">C<", "<title>Avatars of Alice, Bob, Carol</title>"}, []string{">+"}},
+		{"overflow", "/avatars/300x60?names=Alice,Bob,Carol,Dave,Eve&max=3", http.StatusOK, "image/svg+xml", []string{">+2<", "<title>Avatars of Alice, Bob, Carol and 2 more</title>", `fill="#e0e0e0"`}, []string{">D<"}},
+		{"ring matches the background", "/avatars/?names=Alice,Bob&bg=102030", http.StatusOK, "image/svg+xml", []string{`fill="#102030"`}, nil},
+		{"empty names skipped", "/avatars/?names=,Alice,,%20", http.StatusOK, "image/svg+xml", []string{">A<"}, []string{">+"}},
+		{"png", "/avatars/200x50.png?names=Alice,Bob&overlap=50", http.StatusOK, "image/png", nil, nil},
+		{"no names", "/avatars/", http.StatusBadRequest, "text/html; charset=utf-8", []string{"Missing names"}, nil},
+		{"too many names", "/avatars/?names=" + strings.Repeat("a,", 101), http.StatusBadRequest, "text/html; charset=utf-8", []string{"Too many names"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q, got: %s", expect, rec.Body.String())
+				}
+			}
+			for _, reject := range tt.reject {
+				if strings.Contains(rec.Body.String(), reject) {
+					t.Errorf("expected body not to contain %q, got: %s", reject, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFacepileSize(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	// Three avatars overlapping by 50% fit in twice their size: 64px on 128x64
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatars/128x64?names=Alice,Bob,Carol&overlap=50", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, expect := range []string{`cx="32" cy="32" rx="32"`, `cx="64" cy="32" rx="32"`, `cx="96" cy="32" rx="32"`} {
+		if !strings.Contains(rec.Body.String(), expect) {
+			t.Errorf("expected %q, got: %s", expect, rec.Body.String())
+		}
+	}
+}
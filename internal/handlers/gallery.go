@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+// galleryOption is a choice a picker can offer, with a sample image of it.
+type galleryOption struct {
+	Name string `json:"name"`
+	// Sample is the path and query of an image showing the choice, signed
+	// when signing is on
+	Sample string `json:"sample"`
+}
+
+// galleryTheme is the theme of the host asked: the brand and the colors
+// images default to.
+type galleryTheme struct {
+	Name                  string `json:"name"`
+	BrandColor            string `json:"brand_color"`
+	AvatarBackground      string `json:"avatar_background"`
+	PlaceholderBackground string `json:"placeholder_background"`
+	TextColor             string `json:"text_color"`
+	AccentColor           string `json:"accent_color,omitempty"`
+	Font                  string `json:"font"`
+}
+
+// themeGallery is the JSON body of the themes API: the choices the image
+// endpoints offer, each with a sample.
+type themeGallery struct {
+	Theme        galleryTheme    `json:"theme"`
+	Schemes      []galleryOption `json:"schemes"`
+	Fonts        []galleryOption `json:"fonts"`
+	Patterns     []galleryOption `json:"patterns"`
+	Art          []galleryOption `json:"art"`
+	Effects      []galleryOption `json:"effects"`
+	Animations   []galleryOption `json:"animations"`
+	Dividers     []galleryOption `json:"dividers"`
+	Spinners     []galleryOption `json:"spinners"`
+	AvatarStyles []galleryOption `json:"avatar_styles"`
+	Templates    []galleryOption `json:"templates"`
+}
+
+// galleryURL returns the escaped path and query of a sample image, signed
+// when signing is on so it can be linked to as is.
+func (s *Service) galleryURL(path string, query url.Values) string {
+	if s.cfg.SigningKey != "" {
+		query.Set("sig", signURL(s.cfg.SigningKey, path, query))
+	}
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	if len(query) == 0 {
+		return escaped
+	}
+	return escaped + "?" + query.Encode()
+}
+
+// galleryOptions lists names with a sample each, made by sample.
+func galleryOptions[T ~string](names []T, sample func(name string) string) []galleryOption {
+	options := make([]galleryOption, len(names))
+	for i, name := range names {
+		options[i] = galleryOption{Name: string(name), Sample: sample(string(name))}
+	}
+	return options
+}
+
+// handleThemes lists the request host's theme and the styles, fonts, and
+// templates the image endpoints offer, with sample URLs, so apps can fill
+// their pickers from the server: GET /api/v1/themes. The lists come from the
+// same registries the endpoints check parameters against, so they can't drift.
+func (s *Service) handleThemes(w http.ResponseWriter, r *http.Request) {
+	t := s.themeFor(r)
+	sample := func(path string, params ...string) string {
+		query := url.Values{}
+		for i := 0; i+1 < len(params); i += 2 {
+			query.Set(params[i], params[i+1])
+		}
+		return s.galleryURL(path, query)
+	}
+
+	gallery := themeGallery{
+		Theme: galleryTheme{
+			Name:                  t.brandName,
+			BrandColor:            t.brandColor,
+			AvatarBackground:      t.avatarBg,
+			PlaceholderBackground: t.placeholderBg,
+			TextColor:             t.textColor,
+			AccentColor:           t.secondaryColor,
+			Font:                  t.font,
+		},
+		Schemes: galleryOptions([]colorScheme{schemeLight, schemeDark, schemeAuto}, func(name string) string {
+			return sample("/placeholder/300x200", "scheme", name)
+		}),
+		Fonts: galleryOptions(s.renderer.FontNames(), func(name string) string {
+			return sample("/text/Grout", "font", name)
+		}),
+		Patterns: galleryOptions(render.Patterns(), func(name string) string {
+			return sample("/placeholder/300x200", "pattern", name)
+		}),
+		Art: galleryOptions(genart.Variants(), func(name string) string {
+			return sample("/placeholder/300x200", "style", "art", "variant", name)
+		}),
+		Effects: galleryOptions(render.Effects(), func(name string) string {
+			return sample("/placeholder/300x200.png", "effect", name)
+		}),
+		Animations: []galleryOption{
+			{Name: animateTypewriter, Sample: sample("/placeholder/300x200.gif", "animate", animateTypewriter, "text", "Hello")},
+			{Name: animateShimmer, Sample: sample("/placeholder/300x200", "animate", animateShimmer)},
+		},
+		Dividers: galleryOptions(render.DividerStyles(), func(name string) string {
+			return sample("/divider/1200x80", "style", name)
+		}),
+		Spinners: galleryOptions(render.SpinnerStyles(), func(name string) string {
+			return sample("/spinner/64", "style", name)
+		}),
+		AvatarStyles: []galleryOption{
+			{Name: "initials", Sample: sample("/avatar/Jane Doe")},
+			{Name: "discord", Sample: sample("/avatar/Jane Doe", "style", "discord")},
+		},
+		Templates: galleryOptions(s.templates.names(), func(name string) string {
+			return sample("/t/" + name)
+		}),
+	}
+	// Templates can change at any time through the admin API
+	w.Header().Set("Cache-Control", "no-cache")
+	writeJSON(w, http.StatusOK, gallery)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"grout/internal/config"
+)
+
+// getGallery fetches the themes API as host.
+func getGallery(t *testing.T, mux *http.ServeMux, host string) themeGallery {
+	t.Helper()
+	rec := getWithHost(mux, host, "/api/v1/themes")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	var gallery themeGallery
+	if err := json.Unmarshal(rec.Body.Bytes(), &gallery); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return gallery
+}
+
+// checkGallerySamples checks every sample of a gallery renders as host.
+func checkGallerySamples(t *testing.T, mux *http.ServeMux, host string, gallery themeGallery) {
+	t.Helper()
+	sections := map[string][]galleryOption{
+		"schemes": gallery.Schemes, "fonts": gallery.Fonts, "patterns": gallery.Patterns, "art": gallery.Art,
+		"effects": gallery.Effects, "animations": gallery.Animations, "dividers": gallery.Dividers,
+		"spinners": gallery.Spinners, "avatar styles": gallery.AvatarStyles, "templates": gallery.Templates,
+	}
+	for section, options := range sections {
+		if len(options) == 0 && section != "templates" {
+			t.Errorf("%s: expected options", section)
+		}
+		for _, option := range options {
+			rec := getWithHost(mux, host, option.Sample)
+			if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "image/") {
+				t.Errorf("%s %s: expected the sample %s to render, got %d %s", section, option.Name, option.Sample, rec.Code, rec.Header().Get("Content-Type"))
+			}
+		}
+	}
+}
+
+func TestThemesAPI(t *testing.T) {
+	svc, mux := setupTenantTestService(t)
+	svc.templates.set("banner", json.RawMessage(`{"width": 300, "height": 100, "root": {"type": "text", "text": "{{title=Hello}}"}}`))
+
+	gallery := getGallery(t, mux, "example.com")
+	want := galleryTheme{
+		AvatarBackground: config.DefaultAvatarBg, PlaceholderBackground: config.DefaultBgColor,
+		TextColor: config.DefaultTextColor, Font: "regular",
+	}
+	want.Name, want.BrandColor = gallery.Theme.Name, gallery.Theme.BrandColor
+	if gallery.Theme != want {
+		t.Errorf("expected the server-wide theme %+v, got %+v", want, gallery.Theme)
+	}
+	if len(gallery.Templates) != 1 || gallery.Templates[0] != (galleryOption{Name: "banner", Sample: "/t/banner"}) {
+		t.Errorf("expected the banner template, got %+v", gallery.Templates)
+	}
+	if len(gallery.Fonts) != len(svc.renderer.FontNames()) {
+		t.Errorf("expected every font, got %+v", gallery.Fonts)
+	}
+	checkGallerySamples(t, mux, "example.com", gallery)
+
+	tenant := getGallery(t, mux, "img.acme.test")
+	want = galleryTheme{
+		Name: "Acme Images", BrandColor: "ff5722", AvatarBackground: "123456", PlaceholderBackground: "abcdef",
+		TextColor: config.DefaultTextColor, AccentColor: "00aa55", Font: "mono",
+	}
+	if tenant.Theme != want {
+		t.Errorf("expected the tenant's theme %+v, got %+v", want, tenant.Theme)
+	}
+}
+
+func TestThemesAPISigned(t *testing.T) {
+	mux := setupSigningTestService(t)
+
+	// Samples are signed, so they can be linked to as is
+	checkGallerySamples(t, mux, "example.com", getGallery(t, mux, "example.com"))
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/remote"
+	"grout/internal/render"
+	"grout/internal/render/genart"
+	"grout/internal/utils"
+)
+
+// gravatarHashRegex matches the MD5 or SHA-256 email hashes in Gravatar URLs.
+var gravatarHashRegex = regexp.MustCompile(`^(?:[0-9a-fA-F]{32}|[0-9a-fA-F]{64})$`)
+
+// gravatarPatterns maps Gravatar's generated default styles to the pattern drawn in
+// their place, seeded by the hash.
+var gravatarPatterns = map[string]render.Pattern{
+	"identicon": render.PatternLowPoly,
+	"monsterid": render.PatternMesh,
+	"wavatar":   render.PatternMesh,
+	"retro":     render.PatternDots,
+	"robohash":  render.PatternLowPoly,
+}
+
+// serveGravatar answers /avatar/{hash} with the Gravatar default image named by the
+// d parameter. Grout stores no uploaded avatars, so every hash gets its default and
+// f=y (force default) changes nothing.
+func (s *Service) serveGravatar(w http.ResponseWriter, r *http.Request, hash string, format render.ImageFormat) {
+	query := r.URL.Query()
+	sizeParam := query.Get("s")
+	if sizeParam == "" {
+		sizeParam = query.Get("size")
+	}
+	size := clampParam(r, "size", utils.ParseIntOrDefault(sizeParam, config.GravatarDefaultSize), 1, config.GravatarMaxSize)
+	size, _ = emailDimensions(r, size, size)
+	format = emailFormat(r, format)
+	def := query.Get("d")
+	if def == "" {
+		def = query.Get("default")
+	}
+
+	bgHex := s.themeFor(r).avatarBg
+	fgHex := avatarTextColor(bgHex)
+
+	switch {
+	case def == "404":
+		s.handle404(w, r)
+		return
+	case strings.HasPrefix(def, "http://") || strings.HasPrefix(def, "https://"):
+		// Like Gravatar, redirect to the caller's own default image, but only on
+		// the proxy allowlist, so the server can't be used as an open redirect
+		u, err := s.fetcher.Validate(def)
+		if errors.Is(err, remote.ErrHostNotAllowed) {
+			s.fail(w, r, ErrHostNotAllowed.withMessage("The default image's host is not on this server's allowlist."))
+			return
+		}
+		if err != nil {
+			s.fail(w, r, ErrInvalidURL.withMessage("Invalid default image URL."))
+			return
+		}
+		http.Redirect(w, r, u.String(), http.StatusFound)
+		return
+	case def == "blank":
+		s.serveImage(w, r, specKey("GRAVATAR:blank", avatarSpec{Size: size, Format: format}), format, func(ctx context.Context) ([]byte, error) {
+			return render.DrawBlank(ctx, size, size, format)
+		})
+		return
+	case def == "initials":
+		initials := query.Get("initials")
+		if initials == "" {
+			initials = render.GetInitials(query.Get("name"))
+		}
+		if initials != "" {
+			initials = strings.ToUpper(initials)
+			key := specKey("GRAVATAR:initials", avatarSpec{Initials: initials, Size: size, Bg: bgHex, Fg: fgHex, Format: format})
+			s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+				return s.renderer.DrawImageWithFormat(ctx, size, size, bgHex, fgHex, initials, false, false, format)
+			})
+			return
+		}
+	}
+
+	if pattern, ok := gravatarPatterns[def]; ok {
+		seed := genart.ParseSeed(hash)
+		opts := render.PatternOptions{Seed: seed, Colors: genart.Palette(seed, patternColorCount)}
+		key := specKey("GRAVATAR", patternSpec{Pattern: pattern, Width: size, Height: size, PatternOptions: opts, Format: format})
+		s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+			return s.renderer.DrawPattern(ctx, size, size, pattern, opts, "", "", false, false, format)
+		})
+		return
+	}
+
+	// mp (mystery person), Gravatar's own default, and anything unknown
+	key := specKey("GRAVATAR:mp", avatarSpec{Size: size, Bg: bgHex, Fg: fgHex, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return render.DrawSilhouette(ctx, size, bgHex, fgHex, format)
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gravatarHash is the MD5 hash of "test@example.com".
+const gravatarHash = "55502f40dc8b7c769880b10874abc9d0"
+
+func TestGravatarDefaults(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectStatus int
+		contentType  string
+		expectSubstr []string
+	}{
+		{"mystery person by default", "/avatar/" + gravatarHash + ".svg", http.StatusOK, "image/svg+xml", []string{"<ellipse", `fill="#f0e9e9"`, `fill="#8b5d5d"`}},
+		{"PNG without an extension", "/avatar/" + gravatarHash, http.StatusOK, "image/png", nil},
+		{"JPEG extension", "/avatar/" + gravatarHash + ".jpg?d=mp", http.StatusOK, "image/jpeg", nil},
+		{"uppercase SHA-256", "/avatar/" + strings.Repeat("AB", 32) + ".svg", http.StatusOK, "image/svg+xml", []string{"<ellipse"}},
+		{"size", "/avatar/" + gravatarHash + ".svg?s=200", http.StatusOK, "image/svg+xml", []string{`width="200"`}},
+		{"size is capped", "/avatar/" + gravatarHash + ".svg?size=9999", http.StatusOK, "image/svg+xml", []string{`width="2048"`}},
+		{"identicon", "/avatar/" + gravatarHash + ".svg?d=identicon", http.StatusOK, "image/svg+xml", []string{"<polygon"}},
+		{"blank", "/avatar/" + gravatarHash + ".svg?d=blank", http.StatusOK, "image/svg+xml", []string{"></svg>"}},
+		{"initials from name", "/avatar/" + gravatarHash + ".svg?d=initials&name=Jane+Doe", http.StatusOK, "image/svg+xml", []string{">JD<"}},
+		{"initials param", "/avatar/" + gravatarHash + ".svg?d=initials&initials=ab", http.StatusOK, "image/svg+xml", []string{">AB<"}},
+		{"force default", "/avatar/" + gravatarHash + ".svg?f=y", http.StatusOK, "image/svg+xml", []string{"<ellipse"}},
+		{"404", "/avatar/" + gravatarHash + "?d=404", http.StatusNotFound, "text/html; charset=utf-8", nil},
+		{"names still get initials", "/avatar/Jane%20Doe", http.StatusOK, "image/svg+xml", []string{">JD<"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, substr := range tt.expectSubstr {
+				if !strings.Contains(rec.Body.String(), substr) {
+					t.Errorf("expected body to contain %q", substr)
+				}
+			}
+		})
+	}
+}
+
+func TestGravatarDefaultSize(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/"+gravatarHash, nil))
+
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 80 || b.Dy() != 80 {
+		t.Fatalf("expected Gravatar's default of 80px, got %v", b)
+	}
+}
+
+func TestGravatarDefaultURLRedirect(t *testing.T) {
+	mux := setupProxyTestService(t)
+
+	tests := []struct {
+		name         string
+		def          string
+		expectStatus int
+	}{
+		{"allowlisted host", "https://127.0.0.1/default.png", http.StatusFound},
+		// Anything else would make the server an open redirect
+		{"host not allowed", "https://example.com/default.png", http.StatusForbidden},
+		{"URL without a host", "https://", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			target := "/avatar/" + gravatarHash + "?d=" + tt.def
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+			if tt.expectStatus == http.StatusFound && rec.Header().Get("Location") != tt.def {
+				t.Fatalf("expected redirect to %s, got %s", tt.def, rec.Header().Get("Location"))
+			}
+		})
+	}
+}
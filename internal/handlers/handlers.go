@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"crypto/md5"
 	_ "embed"
 	"encoding/json"
@@ -10,11 +11,17 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/golang-lru/v2"
 
 	"grout/internal/config"
 	"grout/internal/content"
+	"grout/internal/identity"
+	"grout/internal/kvstore"
+	"grout/internal/middleware"
+	"grout/internal/remote"
 	"grout/internal/render"
 	"grout/internal/utils"
 )
@@ -34,18 +41,61 @@ var error5xxTemplate string
 //go:embed web/favicon.png
 var faviconData []byte
 
-//go:embed web/robots.txt
-var fallbackRobotsTxt string
-
-//go:embed web/sitemap.xml
-var fallbackSitemapXml string
-
 // Service bundles dependencies required by HTTP handlers.
 type Service struct {
-	renderer       *render.Renderer
-	cache          *lru.Cache[string, []byte]
-	cfg            config.ServerConfig
-	contentManager *content.Manager
+	renderer     *render.Renderer
+	cfg          config.ServerConfig
+	fetcher      *remote.Fetcher
+	defaultTheme *theme
+	// tenantThemes maps each tenant host to its theme
+	tenantThemes map[string]*theme
+	// serverLimiter is the server-wide rate limiter, when one is registered
+	serverLimiter rejectionCounter
+	// concurrency caps the image requests served at once, per IP and in all
+	concurrency *middleware.ConcurrencyLimiter
+	// ipFilter refuses denied client IPs and exempts allowed ones from the limits
+	ipFilter *middleware.IPFilter
+	// accessLogger logs requests, when the access log is enabled
+	accessLogger *accessLogger
+	// scrubber hashes avatar names in logged URLs
+	scrubber logScrubber
+	// identity turns uid parameters into color seeds
+	identity *identity.Seeder
+	// colorMap fixes the colors of listed names and user IDs
+	colorMap *colorMap
+	// store holds state that outlives requests, such as permalinks
+	store kvstore.Store
+	// imageMux routes permalinks, previews, diffs, and validations to the image
+	// endpoints
+	imageMux struct {
+		once sync.Once
+		mux  *http.ServeMux
+	}
+	// builtAt is the lastmod date of the embedded pages in sitemap.xml
+	builtAt time.Time
+	// errorCounts counts error responses by code
+	errorCounts *errorCounter
+	// templates holds the layout templates rendered at /t/{template}
+	templates *templateStore
+	// jobs renders async=true requests in the background
+	jobs *jobQueue
+	// previews holds the playground's open live preview streams
+	previews *previewHub
+	// previewRender renders previews through the rate and concurrency limits
+	// of the image routes; RegisterRoutes sets it up
+	previewRender http.Handler
+	// provenance signs the manifests of served images, when enabled
+	provenance *provenanceSigner
+	// meter counts served images for usage exports, when enabled
+	meter *meter
+	// telemetry counts requests for anonymous usage reports, when enabled
+	telemetry *telemetry
+	// health caches the last health check
+	health healthCache
+	// maintenance refuses image and API requests while it's on
+	maintenance maintenanceMode
+	// deprecations maps the paths of deprecated routes to their usage
+	deprecations map[string]*deprecatedRoute
 }
 
 // NewService wires the handler dependencies.
@@ -54,8 +104,61 @@ func NewService(renderer *render.Renderer, cache *lru.Cache[string, []byte], cfg
 	if err != nil {
 		// Content manager is optional - quotes/jokes will be unavailable but service will still work
 		contentManager = nil
+	} else {
+		contentManager.SetDeterministic(cfg.Deterministic)
+	}
+	fetcher := remote.NewFetcher(cfg.ProxyAllowedHosts, config.ProxyTimeout, config.MaxProxyBytes, config.MaxProxyPixels)
+	defaultTheme := newDefaultTheme(cfg, contentManager, cache)
+	builtAt := buildTime()
+	var accessLogger *accessLogger
+	if cfg.AccessLog {
+		accessLogger = newAccessLogger(cfg.LogSampleRate)
+	}
+	if cfg.Deterministic {
+		// Leave timestamps out of metadata such as sitemap.xml
+		builtAt = time.Time{}
+	}
+	store := cfg.Store
+	if store == nil {
+		store = kvstore.NewMemory()
+	}
+	// Clients share concurrency slots by the networks they share a rate limit by
+	concurrency := middleware.NewConcurrencyLimiter(cfg.MaxConcurrentPerIP, cfg.MaxConcurrent, cfg.ConcurrencyWait)
+	concurrency.SetPrefixes(cfg.RateLimitIPv4Prefix, cfg.RateLimitIPv6Prefix)
+	return &Service{
+		renderer:     renderer,
+		cfg:          cfg,
+		fetcher:      fetcher,
+		defaultTheme: defaultTheme,
+		tenantThemes: newTenantThemes(defaultTheme, cfg),
+		builtAt:      builtAt,
+		errorCounts:  newErrorCounter(),
+		concurrency:  concurrency,
+		ipFilter:     middleware.NewIPFilter(cfg.IPLists.Allow, cfg.IPLists.Deny, cfg.TrustedProxies),
+		accessLogger: accessLogger,
+		scrubber:     newLogScrubber(),
+		identity:     identity.NewSeeder(cfg.IdentitySalt),
+		colorMap:     newColorMap(cfg.ColorMap),
+		store:        store,
+		templates:    newTemplateStore(cfg.Templates),
+		jobs:         newJobQueue(cfg.JobRetention),
+		previews:     newPreviewHub(),
+		provenance:   newProvenanceSigner(cfg.ProvenanceKey),
+		meter:        newMeter(cfg),
+		telemetry:    newTelemetry(cfg),
+		deprecations: newDeprecatedRoutes(cfg.Deprecations),
 	}
-	return &Service{renderer: renderer, cache: cache, cfg: cfg, contentManager: contentManager}
+}
+
+// deterministicNow is the current time in deterministic mode.
+var deterministicNow = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// now returns the current time in UTC, or a fixed time in deterministic mode.
+func (s *Service) now() time.Time {
+	if s.cfg.Deterministic {
+		return deterministicNow
+	}
+	return time.Now().UTC()
 }
 
 // RegisterRoutes attaches handlers to the provided mux.
@@ -68,46 +171,104 @@ func (s *Service) RegisterRoutes(mux *http.ServeMux, rateLimiter interface{}) {
 		Middleware(http.Handler) http.Handler
 	}); ok {
 		applyRateLimit = rl.Middleware
+		// Report its rejections in the admin stats when it counts them
+		if counter, ok := rl.(rejectionCounter); ok {
+			s.serverLimiter = counter
+		}
 	} else {
 		// No rate limiting - pass through
 		applyRateLimit = func(h http.Handler) http.Handler { return h }
 	}
 
-	mux.HandleFunc("/", s.handleHome)
-	mux.HandleFunc("/play", s.handlePlay)
-	// Apply rate limiting to image generation endpoints
-	mux.Handle("/avatar/", applyRateLimit(http.HandlerFunc(s.handleAvatar)))
-	mux.Handle("/placeholder/", applyRateLimit(http.HandlerFunc(s.handlePlaceholder)))
-	// No rate limiting for health, favicon, robots.txt, sitemap.xml
-	mux.HandleFunc("GET /health", s.HandleHealth)
-	mux.HandleFunc("GET /favicon.ico", s.handleFavicon)
-	mux.HandleFunc("GET /robots.txt", s.handleRobotsTxt)
-	mux.HandleFunc("GET /sitemap.xml", s.handleSitemapXml)
+	// Each preview counts against its client's limits like an image request,
+	// though its stream doesn't
+	s.previewRender = s.ipFilter.Middleware(s.rateLimit(s.concurrency.Middleware(s.imageRoutes()), applyRateLimit), s.imageRoutes())
+
+	for _, rt := range s.routes() {
+		var h http.Handler = rt.handler
+		// Image endpoints need a signature when a signing key is set
+		if rt.rateLimited && !rt.unsigned && s.cfg.SigningKey != "" {
+			h = s.requireSignature(h)
+		}
+		if rt.rateLimited && s.cfg.CanonicalRedirects {
+			h = redirectToCanonical(h)
+		}
+		limited := h
+		if rt.rateLimited {
+			// Requests over the rate limit are refused before they wait for a slot
+			limited = s.rateLimit(s.prioritize(rt.priority, s.concurrency.Middleware(h)), applyRateLimit)
+		}
+		// Denied IPs are refused before any limit counts them
+		handler := s.ipFilter.Middleware(limited, h)
+		if rt.rateLimited && s.cfg.ChaosTesting {
+			handler = s.withChaos(rt.path, handler)
+		}
+		if rt.rateLimited {
+			// Maintenance covers allowed IPs too, which skip the limits
+			handler = s.duringMaintenance(rt.path, handler)
+		}
+		if d, ok := s.deprecations[rt.path]; ok {
+			handler = s.deprecated(d, handler)
+		}
+		mux.Handle(rt.pattern(), s.accessLog(s.telemetry.count(rt.pattern(), handler)))
+	}
+}
+
+// ReloadIPLists rereads the IP lists file and swaps in its lists. On error the
+// current lists stay in place.
+func (s *Service) ReloadIPLists() error {
+	lists, err := config.LoadIPLists(s.cfg.IPListsFile)
+	if err != nil {
+		return err
+	}
+	s.ipFilter.SetLists(lists.Allow, lists.Deny)
+	return nil
 }
 
 var placeholderRegex = regexp.MustCompile(`^(\d+)x(\d+)$`)
 
-// formatExtensions maps file extensions to image formats
+// formatExtensions maps file extensions, in lowercase, to image formats
 var formatExtensions = map[string]render.ImageFormat{
 	".png":  render.FormatPNG,
 	".jpg":  render.FormatJPG,
 	".jpeg": render.FormatJPEG,
+	".jfif": render.FormatJPG,
+	".jpe":  render.FormatJPG,
 	".gif":  render.FormatGIF,
 	".webp": render.FormatWebP,
 	".svg":  render.FormatSVG,
 }
 
-// extractFormat extracts the image format from a filename, returning the format and the name without extension
-func extractFormat(filename string) (render.ImageFormat, string) {
-	// Check for known extensions
-	for ext, format := range formatExtensions {
-		if strings.HasSuffix(filename, ext) {
-			return format, strings.TrimSuffix(filename, ext)
-		}
+// unsupportedExtensions are the extensions of image formats Grout can't
+// produce. They're refused rather than read as part of a name, as any other
+// text after a dot is, such as in "j.doe" or a rating of "3.5".
+var unsupportedExtensions = map[string]bool{
+	".apng": true, ".avif": true, ".bmp": true, ".heic": true, ".heif": true, ".ico": true,
+	".jp2": true, ".jxl": true, ".psd": true, ".svgz": true, ".tga": true, ".tif": true, ".tiff": true,
+}
+
+// extractFormat extracts the image format from a filename, returning the format and the name without extension.
+// Extensions match in any case, and a filename without a known one is an SVG named in full. Extensions of
+// formats Grout can't produce are an ErrUnsupportedFormat listing the ones it can.
+func extractFormat(filename string) (render.ImageFormat, string, error) {
+	dot := strings.LastIndex(filename, ".")
+	if dot < 0 {
+		return render.FormatSVG, filename, nil
+	}
+	ext := strings.ToLower(filename[dot:])
+	if format, ok := formatExtensions[ext]; ok {
+		return format, filename[:dot], nil
 	}
+	if unsupportedExtensions[ext] {
+		return render.FormatSVG, filename, ErrUnsupportedFormat.withMessage("Unsupported format %s. Use .svg, .png, .jpg (or .jpeg, .jfif, .jpe), .gif, or .webp.", filename[dot:])
+	}
+	return render.FormatSVG, filename, nil
+}
 
-	// Default to SVG if no extension found
-	return render.FormatSVG, filename
+// parseFormatParam resolves a format name such as "png" or "jpg" from a query parameter.
+func parseFormatParam(name string) (render.ImageFormat, bool) {
+	format, ok := formatExtensions["."+strings.ToLower(name)]
+	return format, ok
 }
 
 // getContentType returns the MIME type for the given format
@@ -128,60 +289,395 @@ func getContentType(format render.ImageFormat) string {
 	}
 }
 
+// parseDimensions reads a WxH path segment, falling back to the w and h query
+// parameters. Sizes over config.MaxDimension get ErrDimensionTooLarge.
+func parseDimensions(r *http.Request, pathMetric string) (int, int, error) {
+	width, height := utils.ParseIntOrDefault(r.URL.Query().Get("w"), config.DefaultSize), utils.ParseIntOrDefault(r.URL.Query().Get("h"), config.DefaultSize)
+	if matches := placeholderRegex.FindStringSubmatch(pathMetric); len(matches) == 3 {
+		width, height = utils.ParseIntOrDefault(matches[1], config.DefaultSize), utils.ParseIntOrDefault(matches[2], config.DefaultSize)
+	}
+	if err := checkDimensions(width, height); err != nil {
+		return 0, 0, err
+	}
+	return width, height, nil
+}
+
+// checkDimensions rejects a width or height over config.MaxDimension.
+func checkDimensions(width, height int) error {
+	if width > config.MaxDimension || height > config.MaxDimension {
+		return ErrDimensionTooLarge.withMessage("The image is too large: %d x %d. Width and height can be at most %d pixels.", width, height, config.MaxDimension)
+	}
+	return nil
+}
+
+// backgroundParam returns the requested background color, or def when none is given.
+// Accepts both 'background' and 'bg' for consistency (background is primary).
+// The value is random or a comma-separated list of colors, such as a gradient's,
+// each resolved to hex; anything else gets ErrInvalidColor.
+func backgroundParam(r *http.Request, def string) (string, error) {
+	param := "background"
+	bgHex := r.URL.Query().Get(param)
+	if bgHex == "" {
+		param = "bg"
+		bgHex = r.URL.Query().Get(param)
+	}
+	if strings.EqualFold(bgHex, "random") {
+		return bgHex, nil
+	}
+	colors := parsePalette(bgHex)
+	if len(colors) == 0 {
+		return def, nil
+	}
+	for i, c := range colors {
+		hex, err := resolveColor(param, c)
+		if err != nil {
+			return "", err
+		}
+		colors[i] = hex
+	}
+	return strings.Join(colors, ","), nil
+}
+
+// queryColor returns the color of a query parameter as hex (no '#'), or ""
+// when it isn't given. Values in keywords, such as auto-accent, pass through as
+// they are; anything else that isn't a color gets ErrInvalidColor.
+func queryColor(r *http.Request, param string, keywords ...string) (string, error) {
+	return resolveColor(param, r.URL.Query().Get(param), keywords...)
+}
+
+// resolveColor resolves the value of a color parameter as queryColor does.
+// Parameters end up in SVG attributes, so only colors and keywords get there.
+func resolveColor(param, value string, keywords ...string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(value, keyword) {
+			return value, nil
+		}
+	}
+	hex, ok := render.ResolveColor(value)
+	if !ok {
+		return "", ErrInvalidColor.withMessage("Invalid %s color. Use a hex color such as ff5733 or a CSS color name.", param)
+	}
+	return hex, nil
+}
+
+// Text color values that derive an accent from the background.
+const (
+	accentColor    = "auto-accent"    // The complementary hue
+	analogousColor = "auto-analogous" // A neighboring hue
+)
+
+// foregroundColor resolves a requested text color for bgHex: an accent hue for
+// auto-accent and auto-analogous, black or white by contrast when none is given,
+// and fgHex itself otherwise.
+func foregroundColor(fgHex, bgHex string) string {
+	switch strings.ToLower(fgHex) {
+	case "":
+		return render.GetContrastColor(bgHex)
+	case accentColor:
+		return render.AccentColor(bgHex, render.ComplementaryRotation)
+	case analogousColor:
+		return render.AccentColor(bgHex, render.AnalogousRotation)
+	}
+	return fgHex
+}
+
+// avatarTextColor returns the text color for an avatar on bgHex: the fixed pairing
+// for the default avatar background, and a contrasting color otherwise.
+func avatarTextColor(bgHex string) string {
+	if bgHex == config.DefaultAvatarBg {
+		return config.DefaultAvatarFg
+	}
+	return render.GetContrastColor(bgHex)
+}
+
 func (s *Service) handleAvatar(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	format := render.FormatSVG // Default to SVG
 
 	if strings.HasPrefix(r.URL.Path, "/avatar/") {
-		parts := strings.Split(r.URL.Path, "/")
-		if len(parts) > 2 && parts[2] != "" {
-			format, name = extractFormat(parts[2])
+		segments, err := pathSegments(r, "/avatar/")
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		if len(segments) > 0 && segments[0] != "" {
+			format, name, err = extractFormat(segments[0])
+			if err != nil {
+				s.fail(w, r, err)
+				return
+			}
+		}
+		// Gravatar URLs put an email hash where the name goes
+		if gravatarHashRegex.MatchString(name) {
+			// Gravatar serves raster images unless an extension asks otherwise
+			if name == segments[0] {
+				format = render.FormatPNG
+			}
+			s.serveGravatar(w, r, strings.ToLower(name), format)
+			return
 		}
 	}
+	name = normalizeName(name)
 	if name == "" {
 		name = "John Doe"
 	}
+	name, err := s.limitLength(r, "name", name, s.cfg.MaxNameLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	seed, err := s.colorSeed(r, name)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
 
 	size := utils.ParseIntOrDefault(r.URL.Query().Get("size"), config.DefaultSize)
+	size, _ = emailDimensions(r, size, size)
+	format = emailFormat(r, format)
+	r = withRenderCost(r, renderCost{width: size, height: size})
 	rounded := r.URL.Query().Get("rounded") == "true"
 	bold := r.URL.Query().Get("bold") == "true"
+	bgParam, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgParam, err := queryColor(r, "color", accentColor, analogousColor)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
 
-	// Accept both 'background' and 'bg' for consistency (background is primary)
-	bgHex := r.URL.Query().Get("background")
-	if bgHex == "" {
-		bgHex = r.URL.Query().Get("bg")
+	// A photo URL switches to a cropped photo avatar, with the initials on top
+	// when overlay=initials
+	if photoURL := r.URL.Query().Get("url"); photoURL != "" {
+		var initials string
+		switch overlay := r.URL.Query().Get("overlay"); overlay {
+		case "":
+		case "initials":
+			initials = render.GetInitials(name)
+		default:
+			s.fail(w, r, ErrInvalidParameter.withMessage("Invalid overlay. Use initials."))
+			return
+		}
+		s.servePhotoAvatar(w, r, photoURL, clampParam(r, "size", size, 1, config.MaxResizeDimension), rounded, bold, initials, fgParam, format)
+		return
 	}
-	if bgHex == "" {
-		bgHex = config.DefaultAvatarBg
+	if pattern := r.URL.Query().Get("pattern"); pattern != "" {
+		s.servePattern(w, r, render.Pattern(pattern), size, size, render.GetInitials(name), seed, rounded, bold, format)
+		return
 	}
-	if strings.EqualFold(bgHex, "random") {
-		bgHex = render.GenerateColorHash(name)
+
+	switch style := r.URL.Query().Get("style"); style {
+	case "":
+	case "discord":
+		s.serveDiscordAvatar(w, r, seed, size, rounded, format)
+		return
+	default:
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid style. Use discord."))
+		return
+	}
+
+	scheme, ok := parseScheme(r, format)
+	if !ok {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid scheme. Use light, dark, or auto."))
+		return
+	}
+	fromBg, ok := s.backgroundFrom(w, r)
+	if !ok {
+		return
+	}
+	// Explicit colors and bgFrom apply to both schemes; only the defaults differ
+	avatarColors := func(defaultBg string) (string, string) {
+		if fromBg != "" {
+			defaultBg = fromBg
+		}
+		bgHex := bgParam
+		if bgHex == "" {
+			bgHex = defaultBg
+		}
+		bgHex = emailBackground(r, bgHex)
+		if strings.EqualFold(bgHex, "random") {
+			bgHex = s.hashColor(r.URL.Query().Get("uid"), name, seed)
+		}
+		return bgHex, foregroundColor(fgParam, bgHex)
 	}
+	bgHex, fgHex := avatarColors(s.themeFor(r).avatarBg)
+	darkBg, darkFg := avatarColors(config.DarkAvatarBg)
+	effect, effectSeed, err := effectParams(r, format, seed)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	r = withRenderCost(r, renderCost{width: size, height: size, effect: effect})
 
-	fgHex := r.URL.Query().Get("color")
+	initials := render.GetInitials(name)
+	shownBg, shownFg := bgHex, fgHex
+	if scheme == schemeDark {
+		shownBg, shownFg = darkBg, darkFg
+	}
+	r = withExplanation(r, explanation{
+		Width: size, Height: size, Scheme: string(scheme), Background: shownBg, Foreground: shownFg,
+		FontSize: render.LabelFontSize(size, size, initials), Lines: []string{initials}, Content: "initials",
+		Name: name,
+	})
+
+	r = withAltText(r, "Avatar with initials "+initials, fmt.Sprintf("A %d x %d avatar showing the initials %s", size, size, initials))
+
+	key := specKey("Avatar", avatarSpec{
+		Initials: initials, Size: size, Rounded: rounded, Bold: bold,
+		Bg: bgHex, Fg: fgHex, DarkBg: darkBg, DarkFg: darkFg, Scheme: scheme,
+		Effect: effect, EffectSeed: effectSeed, Format: format,
+	})
+	label := render.NewOptions(render.WithSize(size, size), render.WithText(initials), render.WithRounded(rounded), render.WithBold(bold), render.WithFormat(format))
+	s.serveSchemed(w, r, key, scheme, size, size, format, func(ctx context.Context, dark bool) ([]byte, error) {
+		if effect != "" {
+			ctx = render.WithEffect(ctx, effect, effectSeed)
+		}
+		if dark {
+			return s.renderer.DrawLabel(ctx, render.WithOptions(label), render.WithColors(darkBg, darkFg))
+		}
+		return s.renderer.DrawLabel(ctx, render.WithOptions(label), render.WithColors(bgHex, fgHex))
+	})
+}
+
+// placeholderPath is a placeholder size, with the colors and format given in the path.
+type placeholderPath struct {
+	width, height int
+	bg, fg        string // Empty when the path doesn't set them
+	format        render.ImageFormat
+}
+
+var placeholdSizeRegex = regexp.MustCompile(`^(\d+)(?:x(\d+))?$`)
+
+// parsePlaceholdPath parses the placehold.co path syntax,
+// {size}[/{background}[/{color}]][/{format}], where size is WxH or a single number
+// for a square, colors are hex or CSS color names, and the format can also be a
+// file extension on the last segment. It reports false for any other path.
+func parsePlaceholdPath(path string) (placeholderPath, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	last := len(segments) - 1
+	format, name, err := extractFormat(segments[last])
+	if err != nil {
+		return placeholderPath{}, false
+	}
+	segments[last] = name
+	if f, ok := parseFormatParam(name); ok && last > 0 {
+		format = f
+		segments = segments[:last]
+	}
+
+	matches := placeholdSizeRegex.FindStringSubmatch(segments[0])
+	if matches == nil || len(segments) > 3 {
+		return placeholderPath{}, false
+	}
+	p := placeholderPath{format: format}
+	p.width = utils.ParseIntOrDefault(matches[1], config.DefaultSize)
+	p.height = p.width
+	if matches[2] != "" {
+		p.height = utils.ParseIntOrDefault(matches[2], config.DefaultSize)
+	}
+
+	for i, field := range []*string{&p.bg, &p.fg} {
+		if i+1 >= len(segments) {
+			break
+		}
+		hex, ok := render.ResolveColor(segments[i+1])
+		if !ok {
+			return placeholderPath{}, false
+		}
+		*field = hex
+	}
+	return p, true
+}
+
+// serveDiscordAvatar renders a Discord-style default avatar (style=discord): a white
+// glyph on one of Discord's default colors, picked by the name's color seed unless a
+// background is given.
+func (s *Service) serveDiscordAvatar(w http.ResponseWriter, r *http.Request, seed string, size int, rounded bool, format render.ImageFormat) {
+	bgHex, err := backgroundParam(r, "random")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if strings.EqualFold(bgHex, "random") {
+		bgHex = render.DiscordColor(seed)
+	}
+	fgHex, err := queryColor(r, "color")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
 	if fgHex == "" {
-		fgHex = render.GetContrastColor(bgHex)
+		fgHex = "ffffff"
 	}
 
-	key := fmt.Sprintf("Avatar:%s:%d:%t:%t:%s:%s:%s", name, size, rounded, bold, bgHex, fgHex, format)
-	s.serveImage(w, r, key, format, func() ([]byte, error) {
-		return s.renderer.DrawImageWithFormat(size, size, bgHex, fgHex, render.GetInitials(name), rounded, bold, format)
+	r = withAltText(r, "Default avatar", fmt.Sprintf("A %d x %d Discord-style default avatar", size, size))
+	key := specKey("DISCORD", avatarSpec{Size: size, Rounded: rounded, Bg: bgHex, Fg: fgHex, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return render.DrawDiscordAvatar(ctx, size, bgHex, fgHex, rounded, format)
 	})
 }
 
 func (s *Service) handlePlaceholder(w http.ResponseWriter, r *http.Request) {
-	width, height := config.DefaultSize, config.DefaultSize
 	pathMetric := strings.TrimPrefix(r.URL.Path, "/placeholder/")
+	if p, ok := parsePlaceholdPath(pathMetric); ok {
+		s.servePlaceholder(w, r, p)
+		return
+	}
+	if strings.Contains(strings.Trim(pathMetric, "/"), "/") {
+		s.handle404(w, r)
+		return
+	}
 
 	// Extract format from path
-	format, pathMetric := extractFormat(pathMetric)
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
 
-	if matches := placeholderRegex.FindStringSubmatch(pathMetric); len(matches) == 3 {
-		width = utils.ParseIntOrDefault(matches[1], config.DefaultSize)
-		height = utils.ParseIntOrDefault(matches[2], config.DefaultSize)
-	} else {
-		width = utils.ParseIntOrDefault(r.URL.Query().Get("w"), config.DefaultSize)
-		height = utils.ParseIntOrDefault(r.URL.Query().Get("h"), config.DefaultSize)
+	width, height, err := parseDimensions(r, pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	s.servePlaceholder(w, r, placeholderPath{width: width, height: height, format: format})
+}
+
+// handlePlaceholdCo serves placehold.co URLs, such as /600x400/orange/white, at the
+// root so apps can switch to Grout by changing only the hostname.
+func (s *Service) handlePlaceholdCo(w http.ResponseWriter, r *http.Request) {
+	// Two numbers are a Lorem Picsum size rather than a size and a hex color
+	if isPicsumPath(r.URL.Path) {
+		s.redirectRandomPicsum(w, r)
+		return
+	}
+	p, ok := parsePlaceholdPath(r.URL.Path)
+	if !ok {
+		s.handle404(w, r)
+		return
+	}
+	s.servePlaceholder(w, r, p)
+}
+
+// servePlaceholder renders a placeholder of the given size. Query parameters win over
+// the colors given in the path.
+func (s *Service) servePlaceholder(w http.ResponseWriter, r *http.Request, p placeholderPath) {
+	if err := checkDimensions(p.width, p.height); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	width, height := emailDimensions(r, p.width, p.height)
+	format := emailFormat(r, p.format)
+	r = withRenderCost(r, renderCost{width: width, height: height})
+	if r.URL.Query().Has("ops") {
+		s.serveOps(w, r, width, height, format)
+		return
 	}
 
 	// Check for quote or joke parameter
@@ -189,93 +685,310 @@ func (s *Service) handlePlaceholder(w http.ResponseWriter, r *http.Request) {
 	jokeParam := r.URL.Query().Get("joke")
 	category := r.URL.Query().Get("category")
 
-	text := r.URL.Query().Get("text")
+	text, err := s.limitLength(r, "text", r.URL.Query().Get("text"), s.cfg.MaxTextLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	loc, err := localeParam(r)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
 	isQuoteOrJoke := false
+	contentManager := s.themeFor(r).content
+	source := "text"
 
 	// Priority: quote > joke > text > default
 	// Only render quote/joke if minimum width requirement is met
 	if (quoteParam == "true" || quoteParam == "1") && width >= config.MinWidthForQuoteJoke {
-		if s.contentManager != nil {
-			randomQuote, err := s.contentManager.GetRandom(content.ContentTypeQuote, category)
+		if contentManager != nil {
+			randomQuote, err := contentManager.GetRandom(content.ContentTypeQuote, category)
 			if err == nil {
 				text = randomQuote
 				isQuoteOrJoke = true
+				source = "quote"
 			} else {
 				// If error (e.g., invalid category), fall back to text or default
 				if text == "" {
-					text = fmt.Sprintf("%d x %d", width, height)
+					text = dimensionsLabel(width, height, loc)
 				}
 			}
 		}
 	} else if (jokeParam == "true" || jokeParam == "1") && width >= config.MinWidthForQuoteJoke {
-		if s.contentManager != nil {
-			randomJoke, err := s.contentManager.GetRandom(content.ContentTypeJoke, category)
+		if contentManager != nil {
+			randomJoke, err := contentManager.GetRandom(content.ContentTypeJoke, category)
 			if err == nil {
 				text = randomJoke
 				isQuoteOrJoke = true
+				source = "joke"
 			} else {
 				// If error (e.g., invalid category), fall back to text or default
 				if text == "" {
-					text = fmt.Sprintf("%d x %d", width, height)
+					text = dimensionsLabel(width, height, loc)
 				}
 			}
 		}
 	} else if text == "" {
-		text = fmt.Sprintf("%d x %d", width, height)
+		text = dimensionsLabel(width, height, loc)
+	}
+	if !isQuoteOrJoke && r.URL.Query().Get("text") == "" {
+		source = "dimensions"
 	}
 
-	// Accept both 'background' and 'bg' for consistency (background is primary)
-	bgHex := r.URL.Query().Get("background")
-	if bgHex == "" {
-		bgHex = r.URL.Query().Get("bg")
+	if r.URL.Query().Get("style") == "art" {
+		s.serveArt(w, r, width, height, text, format)
+		return
 	}
-	if bgHex == "" {
-		bgHex = config.DefaultBgColor
+	if pattern := r.URL.Query().Get("pattern"); pattern != "" {
+		s.servePattern(w, r, render.Pattern(pattern), width, height, text, text, false, false, format)
+		return
 	}
-	fgHex := r.URL.Query().Get("color")
-	if fgHex == "" {
-		fgHex = render.GetContrastColor(bgHex)
+
+	scheme, ok := parseScheme(r, format)
+	if !ok {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid scheme. Use light, dark, or auto."))
+		return
+	}
+	fromBg, ok := s.backgroundFrom(w, r)
+	if !ok {
+		return
+	}
+	bgParam, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgParam, err := queryColor(r, "color", accentColor, analogousColor)
+	if err != nil {
+		s.fail(w, r, err)
+		return
 	}
+	// Explicit colors, in the query or the path, apply to both schemes, and
+	// override a bgFrom color
+	placeholderColors := func(defaultBg string) (string, string) {
+		if fromBg != "" {
+			defaultBg = fromBg
+		}
+		if p.bg != "" {
+			defaultBg = p.bg
+		}
+		bgHex := bgParam
+		if bgHex == "" {
+			bgHex = defaultBg
+		}
+		bgHex = emailBackground(r, bgHex)
+		fgHex := fgParam
+		if fgHex == "" {
+			fgHex = p.fg
+		}
+		return bgHex, foregroundColor(fgHex, bgHex)
+	}
+	bgHex, fgHex := placeholderColors(s.themeFor(r).placeholderBg)
+	darkBg, darkFg := placeholderColors(config.DarkBgColor)
+	animate, animation, err := animationParams(r, format)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	effect, seed, err := effectParams(r, format, text)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	cost := renderCost{width: width, height: height, effect: effect}
+	if animate == animateTypewriter {
+		cost.frames = typewriterFrames(text)
+	}
+	r = withRenderCost(r, cost)
 
-	key := fmt.Sprintf("PH:%d:%d:%s:%s:%s:%s", width, height, bgHex, fgHex, text, format)
-	s.serveImage(w, r, key, format, func() ([]byte, error) {
-		return s.renderer.DrawPlaceholderImage(width, height, bgHex, fgHex, text, isQuoteOrJoke, format)
+	if explainRequested(r) {
+		shownBg, shownFg := bgHex, fgHex
+		if scheme == schemeDark {
+			shownBg, shownFg = darkBg, darkFg
+		}
+		fontSize, lines := s.renderer.PlaceholderLayout(width, height, text, isQuoteOrJoke, format)
+		r = withExplanation(r, explanation{
+			Width: width, Height: height, Scheme: string(scheme), Background: shownBg, Foreground: shownFg,
+			FontSize: fontSize, Lines: lines, Content: source,
+		})
+	}
+
+	r = withAltText(r, text, fmt.Sprintf("A %d x %d placeholder image", width, height))
+	spec := placeholderSpec{
+		Width: width, Height: height, Bg: bgHex, Fg: fgHex, DarkBg: darkBg, DarkFg: darkFg,
+		Scheme: scheme, Text: text, Quote: isQuoteOrJoke, Animate: animate,
+		Effect: effect, EffectSeed: seed, Format: format,
+	}
+	if animate != "" {
+		spec.Animation = &animation
+	}
+	key := specKey("PH", spec)
+	s.serveSchemed(w, r, key, scheme, width, height, format, func(ctx context.Context, dark bool) ([]byte, error) {
+		bg, fg := bgHex, fgHex
+		if dark {
+			bg, fg = darkBg, darkFg
+		}
+		if effect != "" {
+			ctx = render.WithEffect(ctx, effect, seed)
+		}
+		switch animate {
+		case animateTypewriter:
+			return s.renderer.DrawPlaceholderTypewriter(ctx, width, height, bg, fg, text, isQuoteOrJoke, animation, format)
+		case animateShimmer:
+			return s.renderer.DrawPlaceholderShimmer(ctx, width, height, bg, fg, text, isQuoteOrJoke, format)
+		}
+		return s.renderer.DrawPlaceholderImage(ctx, width, height, bg, fg, text, isQuoteOrJoke, format)
 	})
 }
 
-func (s *Service) serveImage(w http.ResponseWriter, r *http.Request, cacheKey string, format render.ImageFormat, generator func() ([]byte, error)) {
+func (s *Service) serveImage(w http.ResponseWriter, r *http.Request, cacheKey string, format render.ImageFormat, generator func(ctx context.Context) ([]byte, error)) {
+	opts, out, err := s.encodeParams(r, format)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	text, out, err := s.textParams(r, format, out)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	generator = drawText(generator, text)
+	// Supersampled images are drawn larger, and cost more to render
+	if cost, ok := r.Context().Value(renderCostKey{}).(renderCost); ok && format != render.FormatSVG {
+		cost.supersample = text.Supersample
+		r = withRenderCost(r, cost)
+	}
+	if format == render.FormatSVG {
+		alt, err := s.limitLength(r, "alt", r.URL.Query().Get("alt"), s.cfg.MaxTextLength)
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		out.Alt = alt
+		generator = labelSVG(generator, altTextFor(r, alt))
+	}
+	t := s.themeFor(r)
+	cacheKey = t.cacheNamespace(cacheKey + out.key())
 	etag := fmt.Sprintf("\"%x\"", md5.Sum([]byte(cacheKey)))
+	if explainRequested(r) {
+		s.writeExplanation(w, r, cacheKey, etag, format)
+		return
+	}
+	if err := s.checkRenderBudget(r); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	// Revalidations are answered without an image, so they're free
+	var charge *quotaCharge
+	if r.Header.Get("If-None-Match") != etag {
+		if charge, err = s.chargeRender(w, r, t); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+	}
+	if asyncRequested(r) {
+		s.serveAsync(w, r, t, cacheKey, format, opts, generator, charge)
+		return
+	}
 
 	w.Header().Set("Content-Type", getContentType(format))
 	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	// Signed expiring links, images of today's date, and endpoint badges are
+	// only cached until they expire
+	if expiry, ok := s.cacheExpiry(r); ok {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", max(0, int(time.Until(expiry).Round(time.Second).Seconds()))))
+	}
 	w.Header().Set("ETag", etag)
+	link, linkPath, linked := s.permalinkFor(r, format)
+	// Previews aren't cached, so their ETags name no image to vouch for
+	provenance := s.provenance != nil && !isPreview(r)
+	if provenance {
+		w.Header().Add("Link", "<"+provenancePath(etag)+`>; rel="provenance"`)
+	}
+	id := requestID(r)
+	if s.cfg.EmbedRequestID && id != "" {
+		w.Header().Set("X-Request-ID", id)
+	}
 
 	if r.Header.Get("If-None-Match") == etag {
+		refundRateLimit(r, 1)
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	if imgData, ok := s.cache.Get(cacheKey); ok {
+	if imgData, ok := t.cache.Get(cacheKey); ok {
+		refundRateLimit(r, 1-config.RateLimitHitCost)
+		if linked && s.registerPermalink(r, link, linkPath) {
+			addPermalinkHeader(w, linkPath)
+		}
+		if provenance {
+			s.recordProvenance(r, etag, format, imgData)
+		}
+		s.meterImage(r, t, format, imgData, true)
 		w.Header().Set("X-Cache", "HIT")
 		_, _ = w.Write(imgData)
 		return
 	}
 
-	imgData, err := generator()
+	// The generator stops early when the client disconnects or rendering times out
+	ctx, cancel := context.WithTimeout(r.Context(), config.RenderTimeout)
+	defer cancel()
+	imgData, err := s.renderImage(ctx, generator, opts, format, id)
 	if err != nil {
+		// No image was served, so none is counted against the quota
+		s.refundRender(r.Context(), charge)
+		if r.Context().Err() != nil {
+			// The client is gone, so there's no one to respond to
+			return
+		}
 		// Clear headers set earlier since we're serving HTML now
 		w.Header().Del("Content-Type")
 		w.Header().Del("Cache-Control")
 		w.Header().Del("ETag")
-		s.serveErrorPage(w, http.StatusInternalServerError, "Failed to generate image. Please try again later or contact support if the problem persists.")
+		w.Header().Del("Link")
+		s.fail(w, r, err)
 		return
 	}
-
-	s.cache.Add(cacheKey, imgData)
+	// Previews change with every edit, so they'd only churn the cache
+	if !isPreview(r) {
+		t.cache.Add(cacheKey, imgData)
+	}
+	// Only images that were served get a permalink, so failed and over-budget
+	// renders never take a slot
+	if linked && s.registerPermalink(r, link, linkPath) {
+		addPermalinkHeader(w, linkPath)
+	}
+	if provenance {
+		s.recordProvenance(r, etag, format, imgData)
+	}
+	s.meterImage(r, t, format, imgData, false)
 	w.Header().Set("X-Cache", "MISS")
 	_, _ = w.Write(imgData)
 }
 
+// renderImage runs generator with the encoder options opts, and checks the
+// result fits the byte budget. PNGs are tagged with the ID of the request that
+// rendered them when that's enabled.
+func (s *Service) renderImage(ctx context.Context, generator func(ctx context.Context) ([]byte, error), opts render.EncodeOptions, format render.ImageFormat, id string) ([]byte, error) {
+	imgData, err := generator(render.WithEncodeOptions(ctx, opts))
+	if err == nil && opts.MaxBytes > 0 && len(imgData) > opts.MaxBytes {
+		// Raster images are fitted to the budget as they're encoded; SVGs can't be
+		err = ErrOverBudget
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Cached copies keep the ID of the request that rendered them, so an asset can
+	// be traced back to it in the logs
+	if s.cfg.EmbedRequestID && id != "" && format == render.FormatPNG {
+		if tagged, err := render.AddPNGText(imgData, requestIDMetadataKey, id); err == nil && (opts.MaxBytes == 0 || len(tagged) <= opts.MaxBytes) {
+			imgData = tagged
+		}
+	}
+	return imgData, nil
+}
+
 // setSecurityHeaders applies security headers to HTML responses
 func setSecurityHeaders(w http.ResponseWriter) {
 	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data: https:; script-src 'self' 'unsafe-inline'")
@@ -284,13 +997,20 @@ func setSecurityHeaders(w http.ResponseWriter) {
 	w.Header().Set("X-XSS-Protection", "1; mode=block")
 }
 
+// writeJSON encodes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
 func (s *Service) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	health := s.checkHealth(r.Context())
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Health", health.Status)
 	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(map[string]string{
-		"status":  "healthy",
-		"version": "1.0.0",
-	})
+	err := json.NewEncoder(w).Encode(health)
 	if err != nil {
 		return
 	}
@@ -302,8 +1022,8 @@ func (s *Service) handleHome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Replace {{DOMAIN}} placeholder with actual configured domain
-	html := strings.ReplaceAll(homePageTemplate, "{{DOMAIN}}", s.cfg.Domain)
+	// Fill in the configured domain and branding
+	html := s.renderPage(r, homePageTemplate)
 
 	setSecurityHeaders(w)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -315,8 +1035,8 @@ func (s *Service) handleHome(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Service) handlePlay(w http.ResponseWriter, r *http.Request) {
-	// Replace {{DOMAIN}} placeholder with actual configured domain
-	html := strings.ReplaceAll(playPageTemplate, "{{DOMAIN}}", s.cfg.Domain)
+	// Fill in the configured domain and branding
+	html := s.renderPage(r, playPageTemplate)
 
 	setSecurityHeaders(w)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -327,18 +1047,8 @@ func (s *Service) handlePlay(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Service) handleFavicon(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "image/png")
-	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-	w.WriteHeader(http.StatusOK)
-	_, err := w.Write(faviconData)
-	if err != nil {
-		return
-	}
-}
-
 // serveErrorPage renders an error page with the given status code and message
-func (s *Service) serveErrorPage(w http.ResponseWriter, statusCode int, message string) {
+func (s *Service) serveErrorPage(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
 	var template string
 	var statusText string
 
@@ -359,6 +1069,7 @@ func (s *Service) serveErrorPage(w http.ResponseWriter, statusCode int, message
 	html := strings.ReplaceAll(template, "{{STATUS_CODE}}", fmt.Sprintf("%d", statusCode))
 	html = strings.ReplaceAll(html, "{{STATUS_TEXT}}", statusText)
 	html = strings.ReplaceAll(html, "{{ERROR_MESSAGE}}", message)
+	html = s.renderPage(r, html)
 
 	setSecurityHeaders(w)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -371,16 +1082,16 @@ func (s *Service) serveErrorPage(w http.ResponseWriter, statusCode int, message
 
 // handle404 handles all 404 Not Found errors with a custom error page
 func (s *Service) handle404(w http.ResponseWriter, r *http.Request) {
-	message := "The page you're looking for doesn't exist. It might have been moved or deleted."
-	s.serveErrorPage(w, http.StatusNotFound, message)
+	s.fail(w, r, ErrNotFound)
 }
 
 func (s *Service) handleRobotsTxt(w http.ResponseWriter, r *http.Request) {
-	// Try to read from static directory first
-	content := s.readStaticFile("robots.txt", fallbackRobotsTxt)
+	// A robots.txt in the static directory overrides the generated one
+	domain := s.themeFor(r).domain
+	content := s.readStaticFile("robots.txt", s.generateRobotsTxt(domain))
 
-	// Replace {{DOMAIN}} placeholder with actual configured domain
-	content = strings.ReplaceAll(content, "{{DOMAIN}}", s.cfg.Domain)
+	// Replace {{DOMAIN}} placeholder with the domain of the request's tenant
+	content = strings.ReplaceAll(content, "{{DOMAIN}}", domain)
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.Header().Set("Cache-Control", "public, max-age=86400")
@@ -392,16 +1103,22 @@ func (s *Service) handleRobotsTxt(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Service) handleSitemapXml(w http.ResponseWriter, r *http.Request) {
-	// Try to read from static directory first
-	content := s.readStaticFile("sitemap.xml", fallbackSitemapXml)
+	// A sitemap.xml in the static directory overrides the generated one
+	domain := s.themeFor(r).domain
+	generated, err := s.generateSitemapXml(domain)
+	if err != nil {
+		s.fail(w, r, ErrRenderFailed.withMessage("Failed to generate sitemap.").withCause(err))
+		return
+	}
+	content := s.readStaticFile("sitemap.xml", generated)
 
-	// Replace {{DOMAIN}} placeholder with actual configured domain
-	content = strings.ReplaceAll(content, "{{DOMAIN}}", s.cfg.Domain)
+	// Replace {{DOMAIN}} placeholder with the domain of the request's tenant
+	content = strings.ReplaceAll(content, "{{DOMAIN}}", domain)
 
 	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
 	w.Header().Set("Cache-Control", "public, max-age=86400")
 	w.WriteHeader(http.StatusOK)
-	_, err := w.Write([]byte(content))
+	_, err = w.Write([]byte(content))
 	if err != nil {
 		return
 	}
@@ -411,12 +1128,22 @@ func (s *Service) handleSitemapXml(w http.ResponseWriter, r *http.Request) {
 // If the file doesn't exist or can't be read, it returns the fallback content.
 // The function validates that the resolved path is within the static directory to prevent directory traversal attacks.
 func (s *Service) readStaticFile(filename string, fallback string) string {
+	data, ok := s.readStaticAsset(filename)
+	if !ok {
+		return fallback
+	}
+	return string(data)
+}
+
+// readStaticAsset reads a file from the static directory, reporting false if it
+// doesn't exist, can't be read, or resolves outside the static directory.
+func (s *Service) readStaticAsset(filename string) ([]byte, bool) {
 	// Clean the filename to prevent directory traversal
 	cleanFilename := filepath.Clean(filename)
 
 	// Prevent directory traversal by rejecting paths that start with ".." or are absolute
 	if strings.HasPrefix(cleanFilename, "..") || filepath.IsAbs(cleanFilename) {
-		return fallback
+		return nil, false
 	}
 
 	// Construct the full path
@@ -425,12 +1152,12 @@ func (s *Service) readStaticFile(filename string, fallback string) string {
 	// Resolve absolute paths and verify the file is within the static directory
 	absStaticDir, err := filepath.Abs(s.cfg.StaticDir)
 	if err != nil {
-		return fallback
+		return nil, false
 	}
 
 	absFilePath, err := filepath.Abs(filePath)
 	if err != nil {
-		return fallback
+		return nil, false
 	}
 
 	// Ensure the static directory ends with a path separator for proper prefix checking
@@ -440,14 +1167,14 @@ func (s *Service) readStaticFile(filename string, fallback string) string {
 
 	// Ensure the resolved path is within the static directory (must be a file, not the directory itself)
 	if !strings.HasPrefix(absFilePath, absStaticDir) {
-		return fallback
+		return nil, false
 	}
 
 	data, err := os.ReadFile(absFilePath)
 	if err != nil {
-		// File doesn't exist or can't be read, use fallback
-		return fallback
+		// File doesn't exist or can't be read
+		return nil, false
 	}
 
-	return string(data)
+	return data, true
 }
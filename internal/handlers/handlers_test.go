@@ -1,14 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/golang-lru/v2"
 
 	"grout/internal/config"
+	"grout/internal/middleware"
 	"grout/internal/render"
 )
 
@@ -61,6 +66,11 @@ func TestAvatarHandlerFormats(t *testing.T) {
 		{"WebP format", "/avatar/JohnDoe.webp", "image/webp"},
 		{"SVG format", "/avatar/JohnDoe.svg", "image/svg+xml"},
 		{"No extension defaults to SVG", "/avatar/JohnDoe", "image/svg+xml"},
+		{"Uppercase extension", "/avatar/JohnDoe.PNG", "image/png"},
+		{"Mixed-case extension", "/avatar/JohnDoe.JpEg", "image/jpeg"},
+		{"JFIF extension", "/avatar/JohnDoe.jfif", "image/jpeg"},
+		{"JPE extension", "/avatar/JohnDoe.jpe", "image/jpeg"},
+		{"Dotted name stays SVG", "/avatar/j.doe", "image/svg+xml"},
 	}
 
 	for _, tt := range tests {
@@ -83,6 +93,87 @@ func TestAvatarHandlerFormats(t *testing.T) {
 	}
 }
 
+func TestExtractFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		format   render.ImageFormat
+		name     string
+		wantErr  bool
+	}{
+		{"Jane.png", render.FormatPNG, "Jane", false},
+		{"Jane.PNG", render.FormatPNG, "Jane", false},
+		{"Jane.JpEg", render.FormatJPEG, "Jane", false},
+		{"Jane.jfif", render.FormatJPG, "Jane", false},
+		{"Jane.JPE", render.FormatJPG, "Jane", false},
+		{"Jane", render.FormatSVG, "Jane", false},
+		{"j.doe", render.FormatSVG, "j.doe", false},
+		{"3.5", render.FormatSVG, "3.5", false},
+		{"300x200.BMP", render.FormatSVG, "300x200.BMP", true},
+		{"Jane.avif", render.FormatSVG, "Jane.avif", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			format, name, err := extractFormat(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+			if format != tt.format || name != tt.name {
+				t.Fatalf("expected %s, %q, got %s, %q", tt.format, tt.name, format, name)
+			}
+		})
+	}
+}
+
+func TestUnsupportedExtension(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	for _, target := range []string{"/placeholder/300x200.bmp", "/avatar/Jane.TIFF"} {
+		t.Run(target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400 got %d", rec.Code)
+			}
+			if code := rec.Header().Get("X-Error-Code"); code != "unsupported_format" {
+				t.Fatalf("expected unsupported_format, got %q", code)
+			}
+		})
+	}
+}
+
+func TestAvatarHandlerDiscordStyle(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectStatus int
+		expectSubstr []string
+	}{
+		{"default colors", "/avatar/Jane%20Doe.svg?style=discord", http.StatusOK, []string{`fill="#` + render.DiscordColor("Jane Doe") + `"`, `<g fill="#ffffff">`, "<ellipse"}},
+		{"rounded", "/avatar/Jane%20Doe.svg?style=discord&rounded=true", http.StatusOK, []string{"<circle cx"}},
+		{"custom colors", "/avatar/Jane%20Doe.svg?style=discord&bg=123456&color=fedcba", http.StatusOK, []string{`fill="#123456"`, `<g fill="#fedcba">`}},
+		{"PNG", "/avatar/Jane%20Doe.png?style=discord", http.StatusOK, []string{"PNG"}},
+		{"unknown style", "/avatar/Jane%20Doe?style=slack", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+			for _, substr := range tt.expectSubstr {
+				if !strings.Contains(rec.Body.String(), substr) {
+					t.Errorf("expected body to contain %q", substr)
+				}
+			}
+		})
+	}
+}
+
 func TestPlaceholderHandlerFormats(t *testing.T) {
 	renderer, err := render.New()
 	if err != nil {
@@ -162,6 +253,77 @@ func TestPlaceholderHandlerGradient(t *testing.T) {
 	}
 }
 
+func TestParsePlaceholdPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		ok     bool
+		expect placeholderPath
+	}{
+		{"size only", "600x400", true, placeholderPath{width: 600, height: 400, format: render.FormatSVG}},
+		{"square", "/300", true, placeholderPath{width: 300, height: 300, format: render.FormatSVG}},
+		{"format segment", "/600x400/png", true, placeholderPath{width: 600, height: 400, format: render.FormatPNG}},
+		{"extension", "/600x400.webp", true, placeholderPath{width: 600, height: 400, format: render.FormatWebP}},
+		{"named colors", "/600x400/orange/white", true, placeholderPath{width: 600, height: 400, bg: "ffa500", fg: "ffffff", format: render.FormatSVG}},
+		{"hex colors and format", "/600x400/000/FFFFFF/jpg", true, placeholderPath{width: 600, height: 400, bg: "000", fg: "FFFFFF", format: render.FormatJPG}},
+		{"background only", "/600x400/DarkSlateGray.png", true, placeholderPath{width: 600, height: 400, bg: "2f4f4f", format: render.FormatPNG}},
+		{"unknown color", "/600x400/notacolor", false, placeholderPath{}},
+		{"too many segments", "/600x400/red/white/blue/png", false, placeholderPath{}},
+		{"not a size", "/about", false, placeholderPath{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePlaceholdPath(tt.path)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%t got %t", tt.ok, ok)
+			}
+			if got != tt.expect {
+				t.Fatalf("expected %+v got %+v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestPlaceholdCoRoutes(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectStatus int
+		contentType  string
+		expectSubstr []string
+	}{
+		{"root size", "/600x400", http.StatusOK, "image/svg+xml", []string{`width="600"`, "600 x 400"}},
+		{"root format", "/600x400/png", http.StatusOK, "image/png", nil},
+		{"root colors and text", "/600x400/orange/white?text=Hello", http.StatusOK, "image/svg+xml", []string{`fill="#ffa500"`, `fill="#ffffff"`, "Hello"}},
+		{"query wins over path", "/600x400/orange/white?bg=000000", http.StatusOK, "image/svg+xml", []string{`fill="#000000"`}},
+		{"under /placeholder/", "/placeholder/200x100/navy/yellow", http.StatusOK, "image/svg+xml", []string{`fill="#000080"`, `fill="#ffff00"`}},
+		{"unknown root path", "/about", http.StatusNotFound, "text/html; charset=utf-8", nil},
+		{"unknown color", "/600x400/notacolor", http.StatusNotFound, "text/html; charset=utf-8", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, substr := range tt.expectSubstr {
+				if !strings.Contains(rec.Body.String(), substr) {
+					t.Errorf("expected body to contain %q", substr)
+				}
+			}
+		})
+	}
+}
+
 func TestHomeHandler(t *testing.T) {
 	renderer, err := render.New()
 	if err != nil {
@@ -409,7 +571,7 @@ func TestServeErrorPage4xx(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			rec := httptest.NewRecorder()
 
-			svc.serveErrorPage(rec, tt.statusCode, tt.message)
+			svc.serveErrorPage(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.statusCode, tt.message)
 
 			if rec.Code != tt.statusCode {
 				t.Fatalf("expected %d got %d", tt.statusCode, rec.Code)
@@ -450,7 +612,7 @@ func TestServeErrorPage5xx(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			rec := httptest.NewRecorder()
 
-			svc.serveErrorPage(rec, tt.statusCode, tt.message)
+			svc.serveErrorPage(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.statusCode, tt.message)
 
 			if rec.Code != tt.statusCode {
 				t.Fatalf("expected %d got %d", tt.statusCode, rec.Code)
@@ -659,6 +821,42 @@ func TestPlaceholderHandlerBackgroundParamConsistency(t *testing.T) {
 	}
 }
 
+func TestForegroundColorAccent(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		wantFg string
+	}{
+		{"placeholder accent", "/placeholder/400x300?bg=1e88e5&color=auto-accent", render.AccentColor("1e88e5", render.ComplementaryRotation)},
+		{"placeholder analogous", "/placeholder/400x300?bg=1e88e5&color=auto-analogous", render.AccentColor("1e88e5", render.AnalogousRotation)},
+		{"placehold.co path", "/600x400/fff3c4?color=AUTO-ACCENT", render.AccentColor("fff3c4", render.ComplementaryRotation)},
+		{"avatar accent", "/avatar/Jane%20Doe?bg=5a0a0a&color=auto-accent", render.AccentColor("5a0a0a", render.ComplementaryRotation)},
+		{"unset contrasts", "/placeholder/400x300?bg=1e88e5", render.GetContrastColor("1e88e5")},
+		{"explicit color", "/placeholder/400x300?bg=1e88e5&color=ff0000", "ff0000"},
+		{"named color", "/placeholder/400x300?bg=1e88e5&color=Red", "ff0000"},
+		{"hash prefix dropped", "/placeholder/400x300?bg=1e88e5&color=%23ff0000", "ff0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path+"&explain=true", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+			}
+			var e explanation
+			if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if e.Foreground != tt.wantFg {
+				t.Errorf("expected foreground %s got %s", tt.wantFg, e.Foreground)
+			}
+		})
+	}
+}
+
 // rateLimiterWrapper is a test helper that wraps a middleware function
 type rateLimiterWrapper struct {
 	middleware func(http.Handler) http.Handler
@@ -802,7 +1000,7 @@ func TestSecurityHeadersOn500ErrorPage(t *testing.T) {
 	svc, _ := setupTestService(t)
 
 	rec := httptest.NewRecorder()
-	svc.serveErrorPage(rec, http.StatusInternalServerError, "Test error")
+	svc.serveErrorPage(rec, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusInternalServerError, "Test error")
 
 	if rec.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500 got %d", rec.Code)
@@ -850,3 +1048,125 @@ func TestSecurityHeadersNotPresentOnImageEndpoints(t *testing.T) {
 		})
 	}
 }
+
+func TestServeImageContext(t *testing.T) {
+	svc, _ := setupTestService(t)
+
+	t.Run("render timeout", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/placeholder/100x100", nil)
+		svc.serveImage(rec, req, "timeout", render.FormatPNG, func(ctx context.Context) ([]byte, error) {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("expected the render context to have a deadline")
+			}
+			return nil, context.DeadlineExceeded
+		})
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503 got %d", rec.Code)
+		}
+	})
+
+	t.Run("client gone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/placeholder/100x100.png", nil).WithContext(ctx)
+		svc.serveImage(rec, req, "gone", render.FormatPNG, func(ctx context.Context) ([]byte, error) {
+			return svc.renderer.DrawPlaceholderImage(ctx, 100, 100, "cccccc", "000000", "x", false, render.FormatPNG)
+		})
+		if rec.Body.Len() != 0 {
+			t.Fatalf("expected nothing written for a disconnected client, got %d bytes", rec.Body.Len())
+		}
+	})
+}
+
+func TestIPListsReload(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "iplists.yaml")
+	writeLists := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write IP lists file: %v", err)
+		}
+	}
+	// httptest requests come from 192.0.2.1
+	writeLists("allow: [192.0.2.0/24]\n")
+
+	cfg := config.DefaultServerConfig()
+	cfg.IPListsFile = path
+	if cfg.IPLists, err = config.LoadIPLists(path); err != nil {
+		t.Fatalf("load IP lists: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](10)
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, middleware.NewRateLimiter(60, 1))
+
+	get := func() int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/100x100", nil))
+		return rec.Code
+	}
+
+	// An allowed client isn't held to the burst of 1
+	for i := range 3 {
+		if code := get(); code != http.StatusOK {
+			t.Fatalf("request %d from an allowed IP: expected 200, got %d", i+1, code)
+		}
+	}
+
+	writeLists("deny: [192.0.2.1]\n")
+	if err := svc.ReloadIPLists(); err != nil {
+		t.Fatalf("reload IP lists: %v", err)
+	}
+	if code := get(); code != http.StatusForbidden {
+		t.Fatalf("expected 403 once the IP is denied, got %d", code)
+	}
+
+	// A broken file leaves the last good lists in place
+	writeLists("deny: [")
+	if err := svc.ReloadIPLists(); err == nil {
+		t.Fatal("expected an error reloading an invalid file")
+	}
+	if code := get(); code != http.StatusForbidden {
+		t.Fatalf("expected the deny list to survive a failed reload, got %d", code)
+	}
+}
+
+func TestRateLimitRefunds(t *testing.T) {
+	svc, _ := setupTestService(t)
+	mux := http.NewServeMux()
+	// 1 RPM refills too slowly to matter here
+	svc.RegisterRoutes(mux, middleware.NewRateLimiter(1, 2))
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/placeholder/100x100.png", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	if first.Code != http.StatusOK || first.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("expected a rendered image, got %d %s", first.Code, first.Header().Get("X-Cache"))
+	}
+	etag := first.Header().Get("ETag")
+	// Revalidations are free, however many there are
+	for i := range 5 {
+		if rec := get(etag); rec.Code != http.StatusNotModified {
+			t.Fatalf("revalidation %d: expected 304, got %d", i+1, rec.Code)
+		}
+	}
+	// A cache hit costs less than a render, but not nothing
+	if rec := get(""); rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected a cache hit, got %d %s", rec.Code, rec.Header().Get("X-Cache"))
+	}
+	if rec := get(""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the limit to be reached, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"sync"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/kvstore"
+	"grout/internal/render"
+)
+
+// Health states
+const (
+	healthHealthy  = "healthy"
+	healthDegraded = "degraded"
+)
+
+// healthImageSize is the width and height of /health.png.
+const healthImageSize = 16
+
+// healthProbeKey is read to check the store; it's never written.
+const healthProbeKey = "health:probe"
+
+// healthImages are the PNGs of /health.png by state: green when healthy, red
+// when degraded. They're drawn without the renderer, so they're served even
+// when it's what's broken.
+var healthImages = map[string][]byte{
+	healthHealthy:  solidPNG(color.RGBA{0x2e, 0xa4, 0x4f, 0xff}),
+	healthDegraded: solidPNG(color.RGBA{0xd7, 0x3a, 0x49, 0xff}),
+}
+
+func solidPNG(c color.Color) []byte {
+	img := image.NewPaletted(image.Rect(0, 0, healthImageSize, healthImageSize), color.Palette{c})
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// healthReport is the JSON body of /health.
+type healthReport struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+	// Checks maps each check to "ok" or why it failed
+	Checks map[string]string `json:"checks"`
+}
+
+type healthCache struct {
+	mu      sync.Mutex
+	checked time.Time
+	report  healthReport
+}
+
+// checkHealth reports whether the server can render images and reach its
+// store, reusing the last result for config.HealthCheckTTL.
+func (s *Service) checkHealth(ctx context.Context) healthReport {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	if !s.health.checked.IsZero() && time.Since(s.health.checked) < config.HealthCheckTTL {
+		return s.health.report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.RenderTimeout)
+	defer cancel()
+	report := healthReport{Status: healthHealthy, Version: config.Version, Checks: map[string]string{"render": "ok", "store": "ok"}}
+	if _, err := s.store.Get(ctx, healthProbeKey); err != nil && !errors.Is(err, kvstore.ErrNotFound) {
+		report.Checks["store"] = err.Error()
+	}
+	data, err := s.renderer.DrawImageWithFormat(ctx, selfTestSize, selfTestSize, config.DefaultAvatarBg, config.DefaultAvatarFg, "OK", false, false, render.FormatPNG)
+	if err == nil {
+		err = verifySelfTestImage(data, render.FormatPNG, false)
+	}
+	if err != nil {
+		report.Checks["render"] = err.Error()
+	}
+	for _, result := range report.Checks {
+		if result != "ok" {
+			report.Status = healthDegraded
+		}
+	}
+
+	s.health.checked, s.health.report = time.Now(), report
+	return report
+}
+
+// handleHealthImage serves the health state as a small green or red square,
+// for dashboards that can only embed images. It's 200 either way, so the image
+// shows; the X-Health header carries the state for monitors.
+func (s *Service) handleHealthImage(w http.ResponseWriter, r *http.Request) {
+	health := s.checkHealth(r.Context())
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Health", health.Status)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(healthImages[health.Status])
+}
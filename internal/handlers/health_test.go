@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/kvstore"
+	"grout/internal/render"
+)
+
+// downStore is a store whose reads fail, like a Redis server that's gone away.
+type downStore struct {
+	kvstore.Store
+	down bool
+}
+
+func (d *downStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if d.down {
+		return nil, errors.New("connection refused")
+	}
+	return d.Store.Get(ctx, key)
+}
+
+func TestHealthImage(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	store := &downStore{Store: kvstore.NewMemory()}
+	cfg := config.DefaultServerConfig()
+	cfg.Store = store
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	tests := []struct {
+		name      string
+		storeDown bool
+		status    string
+		// r, g, b is the image's color
+		r, g, b uint32
+	}{
+		{"healthy", false, "healthy", 0x2e, 0xa4, 0x4f},
+		{"store down", true, "degraded", 0xd7, 0x3a, 0x49},
+		{"store back", false, "healthy", 0x2e, 0xa4, 0x4f},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.down = tt.storeDown
+			// Check again rather than reuse the last result
+			svc.health.checked = svc.health.checked.AddDate(-1, 0, 0)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health.png", nil))
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+				t.Fatalf("expected a PNG, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			if got := rec.Header().Get("X-Health"); got != tt.status {
+				t.Errorf("expected X-Health %q, got %q", tt.status, got)
+			}
+			img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if img.Bounds().Dx() != healthImageSize {
+				t.Errorf("expected a %dpx image, got %v", healthImageSize, img.Bounds())
+			}
+			r, g, b, _ := img.At(0, 0).RGBA()
+			if r>>8 != tt.r || g>>8 != tt.g || b>>8 != tt.b {
+				t.Errorf("expected color %02x%02x%02x, got %02x%02x%02x", tt.r, tt.g, tt.b, r>>8, g>>8, b>>8)
+			}
+
+			// /health reports the same state
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+			var report healthReport
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if report.Status != tt.status || rec.Header().Get("X-Health") != tt.status || report.Checks["render"] != "ok" {
+				t.Errorf("expected /health to report %s, got %+v", tt.status, report)
+			}
+			if tt.storeDown && report.Checks["store"] != "connection refused" {
+				t.Errorf("expected the store's error, got %q", report.Checks["store"])
+			}
+		})
+	}
+}
+
+func TestHealthCached(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	store := &downStore{Store: kvstore.NewMemory()}
+	cfg := config.DefaultServerConfig()
+	cfg.Store = store
+	svc := NewService(renderer, cache, cfg)
+
+	if report := svc.checkHealth(context.Background()); report.Status != healthHealthy {
+		t.Fatalf("expected healthy, got %+v", report)
+	}
+	// Within the TTL the last result stands
+	store.down = true
+	if report := svc.checkHealth(context.Background()); report.Status != healthHealthy {
+		t.Fatalf("expected the cached result, got %+v", report)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// renderRequest is the JSON body of the render API: a layout document and the
+// format to render it in.
+type renderRequest struct {
+	render.Layout
+	Format string `json:"format,omitempty"`
+}
+
+// handleRender renders a posted layout document, so compositions such as tickets,
+// certificates, and cards don't each need an endpoint.
+func (s *Service) handleRender(w http.ResponseWriter, r *http.Request) {
+	var req renderRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, config.MaxLayoutBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid layout document: %v", err))
+		return
+	}
+
+	format := render.FormatSVG
+	if req.Format != "" {
+		f, ok := parseFormatParam(req.Format)
+		if !ok {
+			s.failJSON(w, r, ErrUnsupportedFormat.withMessage("invalid format: use svg, png, jpg, gif, or webp"))
+			return
+		}
+		format = f
+	}
+
+	s.serveLayout(w, r, req.Layout, format, s.failJSON)
+}
+
+// serveLayout lays out and serves a layout document, responding with fail when
+// the document is invalid.
+func (s *Service) serveLayout(w http.ResponseWriter, r *http.Request, layout render.Layout, format render.ImageFormat, fail func(http.ResponseWriter, *http.Request, error)) {
+	// The theme's font and logo fill in for the document's own
+	t := s.themeFor(r)
+	if layout.Font == "" {
+		layout.Font = t.font
+	}
+	layout.Logo = t.logo
+
+	// Layout errors all wrap render.ErrInvalidLayout and describe the problem
+	scene, err := s.renderer.LayoutScene(layout)
+	if err != nil {
+		fail(w, r, ErrInvalidParameter.withMessage("%v", err))
+		return
+	}
+
+	// Documents that decode the same share a cache entry, whatever their spacing
+	// or field order
+	canonical, _ := json.Marshal(layout)
+	key := fmt.Sprintf("RENDER:%x:%s", sha256.Sum256(canonical), format)
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.RenderScene(ctx, scene, format)
+	})
+}
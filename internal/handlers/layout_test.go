@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postLayout(mux *http.ServeMux, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/render", strings.NewReader(body)))
+	return rec
+}
+
+func TestRenderLayout(t *testing.T) {
+	_, mux := setupTestService(t)
+	card := `{"width":400,"height":200,"background":"ffffff","root":{"type":"column","gap":8,"children":[
+		{"type":"row","gap":12,"children":[{"type":"avatar","name":"Jane Doe","size":48},{"type":"text","text":"Jane Doe","bold":true}]},
+		{"type":"shape","width":300,"height":4,"color":"e53935"}]}}`
+
+	tests := []struct {
+		name        string
+		body        string
+		status      int
+		contentType string
+		expect      string
+	}{
+		{"SVG by default", card, http.StatusOK, "image/svg+xml", `>JD</text>`},
+		{"PNG", strings.Replace(card, `"width":400`, `"format":"png","width":400`, 1), http.StatusOK, "image/png", "\x89PNG"},
+		{"escapes text", `{"width":100,"height":50,"root":{"type":"text","text":"<b>&"}}`, http.StatusOK, "image/svg+xml", `&lt;b&gt;&amp;`},
+		{"invalid JSON", `{"width":`, http.StatusBadRequest, "application/json", `"code":"invalid_parameter"`},
+		{"unknown field", `{"width":100,"height":100,"root":{"type":"spacer"},"colour":"fff"}`, http.StatusBadRequest, "application/json", `unknown field`},
+		{"invalid layout", `{"width":100,"height":100,"root":{"type":"video"}}`, http.StatusBadRequest, "application/json", `unknown node type`},
+		{"unsupported format", `{"width":100,"height":100,"format":"bmp","root":{"type":"spacer"}}`, http.StatusBadRequest, "application/json", `"code":"unsupported_format"`},
+		{"too large", `{"width":100,"height":100,"root":{"type":"text","text":"` + strings.Repeat("a", 70<<10) + `"}}`, http.StatusBadRequest, "application/json", `too large`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postLayout(mux, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			if !strings.Contains(rec.Body.String(), tt.expect) {
+				t.Fatalf("expected body to contain %q, got: %s", tt.expect, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenderLayoutCacheKey(t *testing.T) {
+	_, mux := setupTestService(t)
+	a := postLayout(mux, `{"width":100,"height":100,"root":{"type":"shape","width":10,"height":10}}`)
+	// Same document with another field order and spacing
+	b := postLayout(mux, `{ "root": {"height": 10, "width": 10, "type": "shape"}, "height": 100, "width": 100 }`)
+	c := postLayout(mux, `{"width":100,"height":100,"root":{"type":"shape","width":20,"height":10}}`)
+
+	if a.Header().Get("ETag") != b.Header().Get("ETag") {
+		t.Error("expected equivalent documents to share an ETag")
+	}
+	if a.Header().Get("ETag") == c.Header().Get("ETag") {
+		t.Error("expected different documents to get different ETags")
+	}
+
+}
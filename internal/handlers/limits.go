@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"unicode/utf8"
+)
+
+// ellipsis marks where an over-long value was cut.
+const ellipsis = "…"
+
+// limitLength caps a user-supplied value at limit characters, keeping layouts and
+// cache keys bounded. Longer values are cut to fit with an ellipsis, or rejected
+// with ErrTextTooLong when strict text length is set.
+func (s *Service) limitLength(r *http.Request, param, value string, limit int) (string, error) {
+	if limit <= 0 || utf8.RuneCountInString(value) <= limit {
+		return value, nil
+	}
+	if s.cfg.StrictTextLength {
+		return "", ErrTextTooLong.withMessage("The %s parameter is too long. The maximum length is %d characters.", param, limit)
+	}
+	warn(r, "%s is cut to %d characters", param, limit)
+	return string([]rune(value)[:limit-1]) + ellipsis, nil
+}
+
+// clampParam bounds the value of a numeric parameter to lo..hi, warning when
+// it's moved.
+func clampParam[T int | float64](r *http.Request, param string, value, lo, hi T) T {
+	clamped := max(lo, min(value, hi))
+	if clamped != value {
+		warn(r, "%s %v is out of range, so %v is used; use %v to %v", param, value, clamped, lo, hi)
+	}
+	return clamped
+}
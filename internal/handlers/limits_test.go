@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitLength(t *testing.T) {
+	svc, _ := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		value  string
+		limit  int
+		strict bool
+		expect string
+		err    error
+	}{
+		{"within limit", "Jane Doe", 8, false, "Jane Doe", nil},
+		{"truncated", "Jane Doe", 5, false, "Jane…", nil},
+		{"counts characters not bytes", "Zoë Müller", 10, false, "Zoë Müller", nil},
+		{"no limit", strings.Repeat("a", 1000), 0, false, strings.Repeat("a", 1000), nil},
+		{"strict within limit", "Jane", 5, true, "Jane", nil},
+		{"strict rejects", "Jane Doe", 5, true, "", ErrTextTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.cfg.StrictTextLength = tt.strict
+			got, err := svc.limitLength(httptest.NewRequest(http.MethodGet, "/avatar/", nil), "name", tt.value, tt.limit)
+			if !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+				t.Fatalf("expected error %v got %v", tt.err, err)
+			}
+			if got != tt.expect {
+				t.Fatalf("expected %q got %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestTextLengthLimits(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.MaxTextLength = 5
+	svc.cfg.MaxNameLength = 5
+
+	tests := []struct {
+		name   string
+		path   string
+		expect string // Expected in the SVG when truncating
+	}{
+		{"text endpoint", "/text/HelloWorld", "Hell…"},
+		{"placeholder text", "/placeholder/400x200?text=HelloWorld", "Hell…"},
+		{"avatar name", "/avatar/?name=Zachary%20Quinn", ">Z<"},
+		{"ui-avatars name", "/api/?name=Zachary+Quinn&format=svg", ">ZA<"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.cfg.StrictTextLength = false
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), tt.expect) {
+				t.Fatalf("expected 200 containing %q, got %d: %s", tt.expect, rec.Code, rec.Body.String())
+			}
+
+			svc.cfg.StrictTextLength = true
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Error-Code") != "text_too_long" {
+				t.Fatalf("expected 400 text_too_long in strict mode, got %d %q", rec.Code, rec.Header().Get("X-Error-Code"))
+			}
+		})
+	}
+}
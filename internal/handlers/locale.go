@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"grout/internal/locale"
+)
+
+// localeParam returns the locale named by the locale query parameter, or nil
+// when the request doesn't set one.
+func localeParam(r *http.Request) (*locale.Locale, error) {
+	tag := r.URL.Query().Get("locale")
+	if tag == "" {
+		return nil, nil
+	}
+	loc, ok := locale.Lookup(tag)
+	if !ok {
+		return nil, ErrInvalidParameter.withMessage("Unknown locale. Use one of %s.", strings.Join(locale.Tags(), ", "))
+	}
+	return loc, nil
+}
+
+// dimensionsLabel is the default text of a placeholder. Without a locale the
+// numbers are left ungrouped.
+func dimensionsLabel(width, height int, loc *locale.Locale) string {
+	if loc == nil {
+		return fmt.Sprintf("%d x %d", width, height)
+	}
+	return loc.FormatInt(width) + " x " + loc.FormatInt(height)
+}
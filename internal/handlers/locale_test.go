@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocaleParam(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.Deterministic = true
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		expect string
+	}{
+		{"calendar month and weekday", "/calendar/200x200?date=2025-03-01&locale=de", http.StatusOK, ">Samstag<"},
+		{"calendar region tag", "/calendar/200x200?date=2025-03-01&locale=fr-CA", http.StatusOK, ">MARS<"},
+		{"calendar default", "/calendar/200x200?date=2025-03-01", http.StatusOK, ">Saturday<"},
+		{"placeholder dimensions", "/placeholder/1200x800?locale=de", http.StatusOK, ">1.200 x 800<"},
+		{"placeholder without locale", "/placeholder/1200x800", http.StatusOK, ">1200 x 800<"},
+		{"certificate date", "/certificate/?locale=es", http.StatusOK, ">1 de enero de 2025<"},
+		{"unknown locale", "/calendar/200x200?locale=xx", http.StatusBadRequest, "Unknown locale"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.expect) {
+				t.Fatalf("expected %d containing %q, got %d: %s", tt.status, tt.expect, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
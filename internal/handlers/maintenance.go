@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// maintenanceState is the server's maintenance mode, as the admin API reports it.
+type maintenanceState struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	// RetryAfter is the Retry-After of refused requests, in seconds
+	RetryAfter int64      `json:"retry_after,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+// maintenanceMode holds the maintenance state. Like templates registered
+// through the admin API, it lasts until the server restarts.
+type maintenanceMode struct {
+	mu    sync.RWMutex
+	state maintenanceState
+}
+
+func (m *maintenanceMode) get() maintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+func (m *maintenanceMode) set(state maintenanceState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = state
+}
+
+// duringMaintenance serves the maintenance response of a route while the server
+// is in maintenance mode: a JSON error for the API, and a placeholder carrying
+// the message for images, so embedded images degrade instead of breaking. Both
+// are 503 with Retry-After. Requests are refused this way rather than through
+// fail, so planned downtime isn't logged as a server error per request.
+func (s *Service) duringMaintenance(routePath string, next http.Handler) http.Handler {
+	api := strings.HasPrefix(routePath, "/api/v1/")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := s.maintenance.get()
+		if !state.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.FormatInt(state.RetryAfter, 10))
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Error-Code", ErrMaintenance.code)
+		s.errorCounts.add(ErrMaintenance.code)
+		if api {
+			writeJSON(w, ErrMaintenance.status, map[string]string{"error": state.Message, "code": ErrMaintenance.code})
+			return
+		}
+
+		format, data, err := s.maintenanceImage(r, state.Message)
+		if err != nil {
+			s.serveErrorPage(w, r, ErrMaintenance.status, state.Message)
+			return
+		}
+		w.Header().Set("Content-Type", getContentType(format))
+		w.WriteHeader(ErrMaintenance.status)
+		_, _ = w.Write(data)
+	})
+}
+
+// maintenanceRefusal returns the error renders outside the image routes, such
+// as live previews and queued async jobs, are refused with while the server is
+// in maintenance mode, and its Retry-After in seconds. ok is false when it isn't.
+func (s *Service) maintenanceRefusal() (refusal *requestError, retryAfter int64, ok bool) {
+	state := s.maintenance.get()
+	if !state.Enabled {
+		return nil, 0, false
+	}
+	s.errorCounts.add(ErrMaintenance.code)
+	return ErrMaintenance.withMessage("%s", state.Message), state.RetryAfter, true
+}
+
+// maintenanceImage renders the maintenance placeholder for an image request: in
+// the format of the path's extension, and the size of a WxH path segment when
+// there is one. Placeholders are cached, since every image request gets one.
+func (s *Service) maintenanceImage(r *http.Request, message string) (render.ImageFormat, []byte, error) {
+	// Unsupported extensions fall back to SVG, as there's no error to show
+	format, _, _ := extractFormat(path.Base(r.URL.Path))
+	width, height := config.DefaultSize, config.DefaultSize
+	for _, segment := range strings.Split(r.URL.Path, "/") {
+		_, segment, _ = extractFormat(segment)
+		if placeholderRegex.MatchString(segment) {
+			if w, h, err := parseDimensions(r, segment); err == nil {
+				width, height = w, h
+			}
+		}
+	}
+	width = min(max(width, 1), config.MaxMaintenanceDimension)
+	height = min(max(height, 1), config.MaxMaintenanceDimension)
+
+	cache := s.defaultTheme.cache
+	key := specKey("MAINTENANCE", placeholderSpec{Width: width, Height: height, Bg: config.DefaultAvatarBg, Fg: config.DefaultAvatarFg, Text: message, Format: format})
+	if data, ok := cache.Get(key); ok {
+		return format, data, nil
+	}
+	data, err := s.renderer.DrawImageWithFormat(r.Context(), width, height, config.DefaultAvatarBg, config.DefaultAvatarFg, message, false, false, format)
+	if err != nil {
+		return format, nil, err
+	}
+	cache.Add(key, data)
+	return format, data, nil
+}
+
+// maintenanceBannerHTML returns the banner pages show during maintenance, or
+// nothing.
+func (s *Service) maintenanceBannerHTML() string {
+	state := s.maintenance.get()
+	if !state.Enabled {
+		return ""
+	}
+	return `<div class="maintenance-banner" role="status" style="background:#fff3cd;color:#664d03;text-align:center;padding:10px 16px;font-weight:600;">🚧 ` +
+		html.EscapeString(state.Message) + `</div>`
+}
+
+// maintenanceRequest is the optional JSON body of the admin API request that
+// starts maintenance.
+type maintenanceRequest struct {
+	Message    string `json:"message"`
+	RetryAfter int64  `json:"retry_after"`
+}
+
+// handleAdminMaintenance reports the maintenance state.
+func (s *Service) handleAdminMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, s.maintenance.get())
+}
+
+// handleAdminStartMaintenance puts the server in maintenance mode, with the
+// message and Retry-After of the request body, or the defaults.
+func (s *Service) handleAdminStartMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	var req maintenanceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, config.MaxMaintenanceRequestBytes)).Decode(&req); err != nil && err != io.EOF {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid maintenance request: %v", err))
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	switch {
+	case utf8.RuneCountInString(req.Message) > config.DefaultMaxTextLength:
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("message is over %d characters", config.DefaultMaxTextLength))
+		return
+	case req.RetryAfter < 0:
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("retry_after must not be negative"))
+		return
+	}
+	if req.Message == "" {
+		req.Message = config.DefaultMaintenanceMessage
+	}
+	if req.RetryAfter == 0 {
+		req.RetryAfter = int64(config.DefaultMaintenanceRetryAfter.Seconds())
+	}
+
+	since := s.now()
+	state := maintenanceState{Enabled: true, Message: req.Message, RetryAfter: req.RetryAfter, Since: &since}
+	s.maintenance.set(state)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, state)
+}
+
+// handleAdminStopMaintenance ends maintenance mode.
+func (s *Service) handleAdminStopMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	s.maintenance.set(maintenanceState{})
+	w.WriteHeader(http.StatusNoContent)
+}
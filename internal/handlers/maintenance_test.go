@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	mux := setupAdminTestService(t)
+	get := func(target string) *httptest.ResponseRecorder {
+		return adminRequest(mux, http.MethodGet, target, "", "")
+	}
+
+	if rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/maintenance", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/maintenance", `{"retry_after": -1}`, testAdminToken); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a negative retry_after, got %d", rec.Code)
+	}
+	if rec := get("/placeholder/300x150.png"); rec.Code != http.StatusOK {
+		t.Fatalf("expected images served before maintenance, got %d", rec.Code)
+	}
+
+	rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/maintenance", `{"message": "Back at 10:00 UTC", "retry_after": 900}`, testAdminToken)
+	var state maintenanceState
+	if err := json.NewDecoder(rec.Body).Decode(&state); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the new state, got %d, %v", rec.Code, err)
+	}
+	if !state.Enabled || state.Message != "Back at 10:00 UTC" || state.RetryAfter != 900 || state.Since == nil {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	tests := []struct {
+		name, target, contentType string
+		// width and height are the PNG's size, when it's a PNG
+		width, height int
+	}{
+		{"sized PNG", "/placeholder/300x150.png?text=Hero", "image/png", 300, 150},
+		{"avatar", "/avatar/Jane+Doe.png", "image/png", 128, 128},
+		{"oversized", "/placeholder/5000x100.png", "image/png", 1200, 100},
+		{"SVG", "/placeholder/300x150", "image/svg+xml", 0, 0},
+		{"permalink", "/i/abc", "image/svg+xml", 0, 0},
+		{"API", "/api/v1/palette?url=https://example.com/a.png", "application/json", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.target)
+			if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "900" || rec.Header().Get("X-Error-Code") != "maintenance" {
+				t.Fatalf("expected 503 with Retry-After 900, got %d %v", rec.Code, rec.Header())
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+				t.Fatalf("expected %s, got %q", tt.contentType, ct)
+			}
+			switch {
+			case tt.width > 0:
+				img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+				if err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if img.Bounds().Dx() != tt.width || img.Bounds().Dy() != tt.height {
+					t.Errorf("expected %dx%d, got %v", tt.width, tt.height, img.Bounds())
+				}
+			case tt.contentType == "image/svg+xml":
+				if !strings.Contains(rec.Body.String(), "Back at") {
+					t.Errorf("expected the message in the placeholder, got %s", rec.Body.String())
+				}
+			default:
+				if !strings.Contains(rec.Body.String(), `"code":"maintenance"`) {
+					t.Errorf("expected a maintenance error, got %s", rec.Body.String())
+				}
+			}
+		})
+	}
+
+	// Pages stay up, with a banner
+	if rec := get("/"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "maintenance-banner") || !strings.Contains(rec.Body.String(), "Back at 10:00 UTC") {
+		t.Errorf("expected the home page with a banner, got %d", rec.Code)
+	}
+	if rec := get("/health"); rec.Code != http.StatusOK {
+		t.Errorf("expected /health to stay up, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodGet, "/api/v1/admin/maintenance", "", testAdminToken); !strings.Contains(rec.Body.String(), `"enabled":true`) {
+		t.Errorf("expected the admin API to report maintenance, got %s", rec.Body.String())
+	}
+
+	if rec := adminRequest(mux, http.MethodDelete, "/api/v1/admin/maintenance", "", testAdminToken); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := get("/placeholder/300x150.png"); rec.Code != http.StatusOK {
+		t.Fatalf("expected images served after maintenance, got %d", rec.Code)
+	}
+	if rec := get("/"); strings.Contains(rec.Body.String(), "maintenance-banner") || strings.Contains(rec.Body.String(), "{{MAINTENANCE_BANNER}}") {
+		t.Error("expected the banner gone after maintenance")
+	}
+}
+
+func TestMaintenanceDefaults(t *testing.T) {
+	mux := setupAdminTestService(t)
+	rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/maintenance", "", testAdminToken)
+	var state maintenanceState
+	_ = json.NewDecoder(rec.Body).Decode(&state)
+	if rec.Code != http.StatusOK || state.Message != "Down for maintenance" || state.RetryAfter != 600 {
+		t.Fatalf("expected the default message and Retry-After, got %d %+v", rec.Code, state)
+	}
+}
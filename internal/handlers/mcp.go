@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+)
+
+// mcpProtocolVersions are the Model Context Protocol revisions the tool
+// endpoint speaks, newest first.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	// ID is nil for notifications, which get no response
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// toolParam is an argument of a tool, described to clients by a JSON Schema.
+type toolParam struct {
+	name        string
+	kind        string // JSON Schema type: "string", "integer", "number", or "boolean"
+	description string
+	enum        []string
+	// minimum bounds integers and numbers, and maximum too when it's set
+	minimum, maximum float64
+	required         bool
+	// def is the default of an argument in the path, which can't be left out
+	def string
+	// inPath puts the argument in the image's path instead of its query
+	inPath bool
+}
+
+// tool generates one kind of image. Its arguments are checked against its
+// params, then sent to an image endpoint.
+type tool struct {
+	name        string
+	description string
+	params      []toolParam
+	// path is the image's path, with a {name} for each argument in it
+	path string
+}
+
+var (
+	imageFormats  = []string{"svg", "png", "jpg", "gif", "webp"}
+	effectNames   = []string{"confetti", "sparkle", "vignette", "halftone", "dither"}
+	patternNames  = []string{"lowpoly", "mesh", "isogrid", "dots"}
+	toolFontNames = []string{"regular", "bold", "italic", "bold-italic", "medium", "mono", "mono-bold", "smallcaps"}
+)
+
+func formatParam(formats ...string) toolParam {
+	return toolParam{name: "format", kind: "string", description: "Image format", enum: formats, def: formats[0], inPath: true}
+}
+
+func colorParam(name, description string) toolParam {
+	return toolParam{name: name, kind: "string", description: description + ", as a hex color such as ff5733"}
+}
+
+func sizeParams(width, height int) []toolParam {
+	return []toolParam{
+		{name: "width", kind: "integer", description: "Width in pixels", minimum: 1, def: strconv.Itoa(width), inPath: true},
+		{name: "height", kind: "integer", description: "Height in pixels", minimum: 1, def: strconv.Itoa(height), inPath: true},
+	}
+}
+
+// pathText escapes text for a path segment. A plus is escaped as well, as the
+// avatar endpoint reads it as a space.
+func pathText(text string) string {
+	return strings.ReplaceAll(url.PathEscape(text), "+", "%2B")
+}
+
+// expandPath fills in the {name} placeholders of a tool's path with escaped
+// arguments.
+func expandPath(path string, args map[string]string) string {
+	for name, value := range args {
+		path = strings.ReplaceAll(path, "{"+name+"}", pathText(value))
+	}
+	return path
+}
+
+// tools are the image generators the tool endpoint offers.
+var tools = []tool{
+	{
+		name:        "placeholder",
+		description: "Render a rectangular placeholder image with optional text, for mockups and layouts.",
+		params: append(sizeParams(128, 128),
+			formatParam(imageFormats...),
+			toolParam{name: "text", kind: "string", description: "Text shown on the image; defaults to the dimensions"},
+			colorParam("bg", "Background color; a comma-separated list draws a gradient"),
+			colorParam("color", "Text color; contrasts with the background by default"),
+			toolParam{name: "pattern", kind: "string", description: "Seeded background pattern", enum: patternNames},
+			toolParam{name: "effect", kind: "string", description: "Effect drawn over the image; halftone and dither need a raster format", enum: effectNames},
+			toolParam{name: "seed", kind: "string", description: "Seed of the pattern and effect"},
+		),
+		path: "/placeholder/{width}x{height}.{format}",
+	},
+	{
+		name:        "avatar",
+		description: "Render a square avatar of a person's initials.",
+		params: []toolParam{
+			{name: "name", kind: "string", description: "Name the initials are taken from", required: true, inPath: true},
+			formatParam(imageFormats...),
+			{name: "size", kind: "integer", description: "Width and height in pixels", minimum: 1},
+			colorParam("bg", "Background color, or random for a color picked by the name"),
+			colorParam("color", "Text color; contrasts with the background by default"),
+			{name: "rounded", kind: "boolean", description: "Draw a circle instead of a square"},
+			{name: "bold", kind: "boolean", description: "Use a bold font"},
+			{name: "effect", kind: "string", description: "Effect drawn over the avatar; halftone and dither need a raster format", enum: effectNames},
+		},
+		path: "/avatar/{name}.{format}",
+	},
+	{
+		name:        "calendar",
+		description: "Render a calendar tile showing a date's month, day, and weekday.",
+		params: append(sizeParams(128, 128),
+			formatParam(imageFormats...),
+			toolParam{name: "date", kind: "string", description: "Date in YYYY-MM-DD format; defaults to today"},
+			toolParam{name: "locale", kind: "string", description: "Language of the month and weekday names, such as de or ja"},
+			colorParam("header", "Color of the month band"),
+			colorParam("bg", "Background color"),
+			colorParam("color", "Text color; contrasts with the background by default"),
+		),
+		path: "/calendar/{width}x{height}.{format}",
+	},
+	{
+		name:        "rating",
+		description: "Render a strip of filled, half, and empty stars.",
+		params: []toolParam{
+			{name: "value", kind: "number", description: "Stars filled, rounded to the nearest half", required: true, inPath: true},
+			formatParam(imageFormats...),
+			{name: "max", kind: "integer", description: "Stars in the strip", minimum: 1, maximum: 10},
+			{name: "size", kind: "integer", description: "Size of a star in pixels", minimum: 1},
+			colorParam("color", "Color of filled stars"),
+			colorParam("empty", "Color of empty stars"),
+			colorParam("bg", "Background color; transparent by default"),
+		},
+		path: "/rating/{value}.{format}",
+	},
+	{
+		name:        "text",
+		description: "Render text on a transparent canvas sized to fit it, for headings where web fonts aren't available.",
+		params: []toolParam{
+			{name: "text", kind: "string", description: "Text to render", required: true, inPath: true},
+			formatParam(imageFormats...),
+			{name: "font", kind: "string", description: "Font", enum: toolFontNames},
+			{name: "size", kind: "integer", description: "Font size in pixels", minimum: 1, maximum: 256},
+			colorParam("color", "Text color"),
+		},
+		path: "/text/{text}.{format}",
+	},
+	{
+		name:        "divider",
+		description: "Render a section divider with a wavy, blobby, or slanted edge, filled below the edge.",
+		params: append(sizeParams(1440, 120),
+			formatParam(imageFormats...),
+			toolParam{name: "style", kind: "string", description: "Shape of the edge", enum: []string{"wave", "blob", "tilt"}},
+			toolParam{name: "seed", kind: "string", description: "Seed of the edge's shape"},
+			toolParam{name: "flip", kind: "boolean", description: "Mirror the divider to sit at the top of a section"},
+			colorParam("color", "Fill color"),
+			colorParam("bg", "Background color; transparent by default"),
+		),
+		path: "/divider/{width}x{height}.{format}",
+	},
+	{
+		name:        "table",
+		description: "Render a table of made-up data under a header band, for report and export mockups.",
+		params: append(sizeParams(600, 300),
+			formatParam(imageFormats...),
+			toolParam{name: "rows", kind: "integer", description: "Body rows", minimum: 1, maximum: 50},
+			toolParam{name: "cols", kind: "integer", description: "Columns", minimum: 1, maximum: 12},
+			toolParam{name: "seed", kind: "string", description: "Seed of the cell contents"},
+			colorParam("header", "Color of the header band"),
+			colorParam("stripe", "Color of every other row"),
+			colorParam("bg", "Background color"),
+			colorParam("color", "Text color of the body"),
+		),
+		path: "/table/{width}x{height}.{format}",
+	},
+	{
+		name:        "spinner",
+		description: "Render an animated loading spinner.",
+		params: []toolParam{
+			{name: "size", kind: "integer", description: "Width and height in pixels", minimum: 16, maximum: 512, def: "64", inPath: true},
+			formatParam("svg", "gif"),
+			{name: "style", kind: "string", description: "Style of the spinner", enum: []string{"ring", "dots", "bars"}},
+			colorParam("color", "Spinner color"),
+			colorParam("bg", "Background color"),
+		},
+		path: "/spinner/{size}.{format}",
+	},
+}
+
+// findTool returns the tool named name.
+func findTool(name string) (*tool, bool) {
+	for i := range tools {
+		if tools[i].name == name {
+			return &tools[i], true
+		}
+	}
+	return nil, false
+}
+
+// inputSchema returns the JSON Schema of the tool's arguments.
+func (t *tool) inputSchema() map[string]any {
+	properties := make(map[string]any, len(t.params))
+	required := []string{}
+	for _, p := range t.params {
+		property := map[string]any{"type": p.kind, "description": p.description}
+		if len(p.enum) > 0 {
+			property["enum"] = p.enum
+		}
+		if p.kind == "integer" || p.kind == "number" {
+			property["minimum"] = p.minimum
+			if p.maximum > 0 {
+				property["maximum"] = p.maximum
+			}
+		}
+		if p.kind == "string" && p.required {
+			property["minLength"] = 1
+		}
+		switch {
+		case p.def == "":
+		case p.kind == "string":
+			property["default"] = p.def
+		default:
+			property["default"] = json.RawMessage(p.def)
+		}
+		properties[p.name] = property
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// parse checks an argument against the param's schema, returning it as it's
+// written in an image URL.
+func (p toolParam) parse(raw json.RawMessage) (string, error) {
+	var value string
+	switch p.kind {
+	case "string":
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", errors.New("must be a string")
+		}
+		if p.required && value == "" {
+			return "", errors.New("must not be empty")
+		}
+	case "boolean":
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return "", errors.New("must be true or false")
+		}
+		value = strconv.FormatBool(b)
+	default:
+		var n float64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return "", errors.New("must be a number")
+		}
+		if p.kind == "integer" && n != math.Trunc(n) {
+			return "", errors.New("must be a whole number")
+		}
+		if n < p.minimum || (p.maximum > 0 && n > p.maximum) {
+			if p.maximum > 0 {
+				return "", fmt.Errorf("must be from %g to %g", p.minimum, p.maximum)
+			}
+			return "", fmt.Errorf("must be at least %g", p.minimum)
+		}
+		value = strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	if len(p.enum) > 0 && !slices.Contains(p.enum, value) {
+		return "", fmt.Errorf("must be one of %s", strings.Join(p.enum, ", "))
+	}
+	return value, nil
+}
+
+// imageURL checks arguments against the tool's schema and returns the path and
+// query of the image they describe.
+func (t *tool) imageURL(raw map[string]json.RawMessage) (*url.URL, error) {
+	for _, name := range slices.Sorted(maps.Keys(raw)) {
+		if !slices.ContainsFunc(t.params, func(p toolParam) bool { return p.name == name }) {
+			return nil, fmt.Errorf("unknown argument %q for tool %s", name, t.name)
+		}
+	}
+	args := make(map[string]string, len(t.params))
+	query := url.Values{}
+	for _, p := range t.params {
+		value := p.def
+		if arg, ok := raw[p.name]; ok && string(arg) != "null" {
+			parsed, err := p.parse(arg)
+			if err != nil {
+				return nil, fmt.Errorf("argument %q %v", p.name, err)
+			}
+			value = parsed
+		} else if p.required {
+			return nil, fmt.Errorf("missing argument %q for tool %s", p.name, t.name)
+		}
+		switch {
+		case p.inPath:
+			args[p.name] = value
+		case value != "":
+			query.Set(p.name, value)
+		}
+	}
+	target := expandPath(t.path, args)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return url.Parse(target)
+}
+
+type toolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type toolCallParams struct {
+	Name      string                     `json:"name"`
+	Arguments map[string]json.RawMessage `json:"arguments"`
+}
+
+type toolContent struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+type toolResult struct {
+	Content []toolContent `json:"content"`
+	// IsError marks an image the endpoint couldn't render, as opposed to a
+	// call that was invalid
+	IsError bool `json:"isError,omitempty"`
+}
+
+// handleMCP is a Model Context Protocol server over JSON-RPC, so AI assistants
+// and bots can discover the image generators and call them with arguments
+// checked against a schema: tools/list describes them, and tools/call renders
+// one and returns the image.
+func (s *Service) handleMCP(w http.ResponseWriter, r *http.Request) {
+	// Tool calls aren't signed, so on servers that require signed URLs they
+	// take the admin token
+	if s.cfg.SigningKey != "" {
+		if s.cfg.AdminToken == "" {
+			s.failJSON(w, r, ErrFeatureDisabled.withMessage("The tool endpoint needs an admin token on servers that require signed URLs."))
+			return
+		}
+		if !s.authorizeAdmin(w, r) {
+			return
+		}
+	}
+
+	var req rpcRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, config.MaxToolRequestBytes)).Decode(&req)
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF):
+		writeJSON(w, http.StatusOK, rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: rpcParseError, Message: "Parse error: the body isn't valid JSON."}})
+		return
+	case err != nil || req.JSONRPC != "2.0" || req.Method == "":
+		writeJSON(w, http.StatusOK, rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: rpcInvalidRequest, Message: "Invalid request: send one JSON-RPC 2.0 request object."}})
+		return
+	}
+
+	result, err := s.callMCP(r, req.Method, req.Params)
+	if req.ID == nil {
+		// Notifications, such as notifications/initialized, get no response
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// callMCP runs a JSON-RPC method, returning its result.
+func (s *Service) callMCP(r *http.Request, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		var init struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(params, &init)
+		// Answer with the client's version when it's one this server speaks,
+		// else the newest
+		version := mcpProtocolVersions[0]
+		if slices.Contains(mcpProtocolVersions, init.ProtocolVersion) {
+			version = init.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "grout", "version": config.Version},
+			"instructions":    "Each tool renders a placeholder image and returns it with its path on this server, signed when the server requires it, which can be linked to as is.",
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		infos := make([]toolInfo, len(tools))
+		for i := range tools {
+			infos[i] = toolInfo{Name: tools[i].name, Description: tools[i].description, InputSchema: tools[i].inputSchema()}
+		}
+		return map[string]any{"tools": infos}, nil
+	case "tools/call":
+		var call toolCallParams
+		if err := json.Unmarshal(params, &call); err != nil {
+			return nil, errors.New("invalid params: send the tool's name and an object of arguments")
+		}
+		t, ok := findTool(call.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown tool %q", call.Name)
+		}
+		u, err := t.imageURL(call.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return s.callTool(r, u), nil
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("Method not found: %s", method)}
+	}
+}
+
+// callTool renders the image at u, returning it with its path, signed when
+// signing is on, or why it couldn't be rendered.
+func (s *Service) callTool(r *http.Request, u *url.URL) toolResult {
+	// The tool endpoint's route holds the request's concurrency slot
+	contentType, image, failure := s.renderInternal(r, s.imageRoutes(), u, false)
+	if failure != nil {
+		message := failure.message
+		if failure.code != "" {
+			message = failure.code + ": " + message
+		}
+		return toolResult{IsError: true, Content: []toolContent{{Type: "text", Text: message}}}
+	}
+	link := *u
+	if s.cfg.SigningKey != "" {
+		query := link.Query()
+		query.Set("sig", signURL(s.cfg.SigningKey, link.Path, query))
+		link.RawQuery = query.Encode()
+	}
+	mimeType, _, _ := strings.Cut(contentType, ";")
+	return toolResult{Content: []toolContent{
+		{Type: "image", Data: base64.StdEncoding.EncodeToString(image), MimeType: strings.TrimSpace(mimeType)},
+		{Type: "text", Text: link.RequestURI()},
+	}}
+}
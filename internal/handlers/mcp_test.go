@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testRPCResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// callRPC posts a JSON-RPC request to the tool endpoint and decodes the
+// response.
+func callRPC(t *testing.T, mux *http.ServeMux, body string) testRPCResponse {
+	t.Helper()
+	rec := adminRequest(mux, http.MethodPost, "/api/v1/mcp", body, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp testRPCResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
+
+func TestMCPInitialize(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		requested string
+		expected  string
+	}{
+		{"2025-03-26", "2025-03-26"},
+		{"2024-11-05", "2024-11-05"},
+		{"2099-01-01", "2025-06-18"},
+		{"", "2025-06-18"},
+	}
+	for _, tt := range tests {
+		resp := callRPC(t, mux, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+tt.requested+`"}}`)
+		var result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			Capabilities    struct {
+				Tools *struct{} `json:"tools"`
+			} `json:"capabilities"`
+		}
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		if result.ProtocolVersion != tt.expected {
+			t.Errorf("requesting %q: expected version %q, got %q", tt.requested, tt.expected, result.ProtocolVersion)
+		}
+		if result.Capabilities.Tools == nil {
+			t.Errorf("expected the tools capability, got %s", resp.Result)
+		}
+	}
+}
+
+func TestMCPToolsList(t *testing.T) {
+	_, mux := setupTestService(t)
+	resp := callRPC(t, mux, `{"jsonrpc":"2.0","id":"list","method":"tools/list"}`)
+	if string(resp.ID) != `"list"` {
+		t.Errorf("expected the request's id, got %s", resp.ID)
+	}
+	var result struct {
+		Tools []struct {
+			Name        string `json:"name"`
+			InputSchema struct {
+				Type                 string                    `json:"type"`
+				Properties           map[string]map[string]any `json:"properties"`
+				Required             []string                  `json:"required"`
+				AdditionalProperties bool                      `json:"additionalProperties"`
+			} `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if len(result.Tools) != len(tools) {
+		t.Fatalf("expected %d tools, got %d", len(tools), len(result.Tools))
+	}
+	for _, tool := range result.Tools {
+		if tool.InputSchema.Type != "object" || tool.InputSchema.AdditionalProperties {
+			t.Errorf("%s: expected a closed object schema, got %+v", tool.Name, tool.InputSchema)
+		}
+		if _, ok := tool.InputSchema.Properties["format"]; !ok {
+			t.Errorf("%s: expected a format argument", tool.Name)
+		}
+	}
+	placeholder := result.Tools[0].InputSchema
+	if width := placeholder.Properties["width"]; width["type"] != "integer" || width["default"] != 128.0 || width["minimum"] != 1.0 {
+		t.Errorf("unexpected width schema %v", width)
+	}
+	if avatar := result.Tools[1].InputSchema; len(avatar.Required) != 1 || avatar.Required[0] != "name" {
+		t.Errorf("expected name to be required, got %v", avatar.Required)
+	}
+}
+
+func TestMCPToolCall(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name      string
+		call      string
+		imageURL  string
+		mimeType  string
+		signature []byte
+	}{
+		{
+			name:      "placeholder",
+			call:      `{"name":"placeholder","arguments":{"width":300,"height":200,"format":"png","text":"Hi there"}}`,
+			imageURL:  "/placeholder/300x200.png?text=Hi+there",
+			mimeType:  "image/png",
+			signature: []byte("\x89PNG"),
+		},
+		{
+			name:      "defaults",
+			call:      `{"name":"placeholder","arguments":{}}`,
+			imageURL:  "/placeholder/128x128.svg",
+			mimeType:  "image/svg+xml",
+			signature: []byte("<svg"),
+		},
+		{
+			name:      "name in the path",
+			call:      `{"name":"avatar","arguments":{"name":"A+B C/D","rounded":true,"size":64}}`,
+			imageURL:  "/avatar/A%2BB%20C%2FD.svg?rounded=true&size=64",
+			mimeType:  "image/svg+xml",
+			signature: []byte("<svg"),
+		},
+		{
+			name:      "number",
+			call:      `{"name":"rating","arguments":{"value":3.5,"format":"gif"}}`,
+			imageURL:  "/rating/3.5.gif",
+			mimeType:  "image/gif",
+			signature: []byte("GIF8"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callRPC(t, mux, `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":`+tt.call+`}`)
+			if resp.Error != nil {
+				t.Fatalf("unexpected error %+v", resp.Error)
+			}
+			var result toolResult
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				t.Fatalf("decode result: %v", err)
+			}
+			if result.IsError || len(result.Content) != 2 {
+				t.Fatalf("expected an image and its URL, got %s", resp.Result)
+			}
+			image, text := result.Content[0], result.Content[1]
+			if image.Type != "image" || image.MimeType != tt.mimeType {
+				t.Errorf("expected a %s image, got %s %s", tt.mimeType, image.Type, image.MimeType)
+			}
+			data, err := base64.StdEncoding.DecodeString(image.Data)
+			if err != nil || !bytes.Contains(data[:min(len(data), 64)], tt.signature) {
+				t.Errorf("expected image data starting with %q, got %.16q (%v)", tt.signature, data, err)
+			}
+			if text.Type != "text" || text.Text != tt.imageURL {
+				t.Errorf("expected URL %q, got %q", tt.imageURL, text.Text)
+			}
+		})
+	}
+}
+
+func TestMCPToolCallErrors(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name    string
+		params  string
+		message string
+	}{
+		{"unknown tool", `{"name":"hologram","arguments":{}}`, `unknown tool "hologram"`},
+		{"unknown argument", `{"name":"placeholder","arguments":{"colour":"fff"}}`, `unknown argument "colour"`},
+		{"missing argument", `{"name":"avatar","arguments":{"size":64}}`, `missing argument "name"`},
+		{"empty argument", `{"name":"text","arguments":{"text":""}}`, `"text" must not be empty`},
+		{"wrong type", `{"name":"placeholder","arguments":{"width":"300"}}`, `"width" must be a number`},
+		{"fraction", `{"name":"placeholder","arguments":{"width":300.5}}`, `"width" must be a whole number`},
+		{"below minimum", `{"name":"placeholder","arguments":{"width":0}}`, `"width" must be at least 1`},
+		{"above maximum", `{"name":"spinner","arguments":{"size":600}}`, `"size" must be from 16 to 512`},
+		{"not in enum", `{"name":"spinner","arguments":{"format":"png"}}`, `"format" must be one of svg, gif`},
+		{"boolean", `{"name":"avatar","arguments":{"name":"Jo","rounded":"yes"}}`, `"rounded" must be true or false`},
+		{"arguments not an object", `{"name":"placeholder","arguments":[]}`, "invalid params"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callRPC(t, mux, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+tt.params+`}`)
+			if resp.Error == nil || resp.Error.Code != rpcInvalidParams {
+				t.Fatalf("expected an invalid params error, got %+v %s", resp.Error, resp.Result)
+			}
+			if !strings.Contains(resp.Error.Message, tt.message) {
+				t.Errorf("expected message containing %q, got %q", tt.message, resp.Error.Message)
+			}
+		})
+	}
+}
+
+func TestMCPToolCallRenderError(t *testing.T) {
+	_, mux := setupTestService(t)
+	resp := callRPC(t, mux, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"placeholder","arguments":{"effect":"halftone"}}}`)
+	if resp.Error != nil {
+		t.Fatalf("expected a tool error result, got %+v", resp.Error)
+	}
+	var result toolResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if !result.IsError || len(result.Content) != 1 || !strings.HasPrefix(result.Content[0].Text, "unsupported_format: ") {
+		t.Errorf("expected an unsupported_format error, got %s", resp.Result)
+	}
+}
+
+func TestMCPProtocolErrors(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"invalid JSON", `{"jsonrpc":`, rpcParseError},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"ping"}]`, rpcInvalidRequest},
+		{"no version", `{"id":1,"method":"ping"}`, rpcInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, rpcMethodNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callRPC(t, mux, tt.body)
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("expected error code %d, got %+v", tt.code, resp.Error)
+			}
+		})
+	}
+
+	if resp := callRPC(t, mux, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.Error != nil || string(resp.Result) != "{}" {
+		t.Errorf("expected an empty ping result, got %s %+v", resp.Result, resp.Error)
+	}
+	rec := adminRequest(mux, http.MethodPost, "/api/v1/mcp", `{"jsonrpc":"2.0","method":"notifications/initialized"}`, "")
+	if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Errorf("expected 202 without a body for a notification, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMCPSigning(t *testing.T) {
+	svc, _ := setupTestService(t)
+	svc.cfg.SigningKey = testSigningKey
+	svc.cfg.AdminToken = testAdminToken
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"placeholder","arguments":{}}}`
+
+	if rec := adminRequest(mux, http.MethodPost, "/api/v1/mcp", call, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin token, got %d", rec.Code)
+	}
+	rec := adminRequest(mux, http.MethodPost, "/api/v1/mcp", call, testAdminToken)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"type":"image"`) {
+		t.Errorf("expected an image with the admin token, got %d %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Result toolResult `json:"result"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Result.Content) != 2 {
+		t.Fatalf("expected an image and its path, got %s", rec.Body.String())
+	}
+	path := resp.Result.Content[1].Text
+	if !strings.Contains(path, "sig=") {
+		t.Errorf("expected a signed path, got %q", path)
+	}
+	image := httptest.NewRecorder()
+	mux.ServeHTTP(image, httptest.NewRequest(http.MethodGet, path, nil))
+	if image.Code != http.StatusOK {
+		t.Errorf("expected the returned path %q to be served, got %d", path, image.Code)
+	}
+
+	if rec := adminRequest(setupSigningTestService(t), http.MethodPost, "/api/v1/mcp", call, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without an admin token configured, got %d", rec.Code)
+	}
+}
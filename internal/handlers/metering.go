@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// meterKey groups the usage a meter counts: a tenant's images in one format.
+type meterKey struct {
+	tenant string
+	format render.ImageFormat
+}
+
+type meterCounts struct {
+	renders   int64
+	cacheHits int64
+	bytes     int64
+}
+
+// meteringRecord is a tenant's usage in one format over a batch's period.
+type meteringRecord struct {
+	Tenant string `json:"tenant"`
+	Format string `json:"format"`
+	// Renders counts images rendered, CacheHits images served from the cache;
+	// Bytes is the size of both
+	Renders   int64 `json:"renders"`
+	CacheHits int64 `json:"cache_hits"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// meteringBatch is the usage of one period, exported and retried as a unit.
+// Its ID stays the same across retries, so a billing system can drop batches
+// it has already seen.
+type meteringBatch struct {
+	ID          string           `json:"batch_id"`
+	Instance    string           `json:"instance"`
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	Records     []meteringRecord `json:"records"`
+}
+
+// meteringCSVHeader is the header row of CSV exports; each row is a record
+// with its batch's ID and period.
+var meteringCSVHeader = []string{"batch_id", "instance", "period_start", "period_end", "tenant", "format", "renders", "cache_hits", "bytes"}
+
+// meter counts the images served per tenant and format, and exports them in
+// batches to a directory, a webhook, or both.
+type meter struct {
+	mu     sync.Mutex
+	since  time.Time
+	counts map[meterKey]*meterCounts
+	// batches numbers the batches closed, to tell apart periods that start in
+	// the same second
+	batches int
+
+	// exportMu serializes exports, so batches go out in order
+	exportMu sync.Mutex
+	// pending holds the batches not yet delivered, oldest first
+	pending  []meteringBatch
+	instance string
+	dir      string
+	format   string
+	webhook  string
+	client   *http.Client
+}
+
+// newMeter returns a meter exporting as the config says, or nil when metering
+// is off. Batches are named after the host, so instances sharing a directory
+// or webhook don't collide. Periods follow the wall clock even in
+// deterministic mode, since they're billed.
+func newMeter(cfg config.ServerConfig) *meter {
+	if cfg.MeteringDir == "" && cfg.MeteringWebhook == "" {
+		return nil
+	}
+	instance, err := os.Hostname()
+	if err != nil || instance == "" {
+		instance = "grout"
+	}
+	return &meter{
+		since:    time.Now(),
+		counts:   make(map[meterKey]*meterCounts),
+		instance: instance,
+		dir:      cfg.MeteringDir,
+		format:   cfg.MeteringFormat,
+		webhook:  cfg.MeteringWebhook,
+		client:   &http.Client{Timeout: config.MeteringTimeout},
+	}
+}
+
+// record counts an image served to a tenant, "" for the server-wide settings.
+// It does nothing on a nil meter.
+func (m *meter) record(tenant string, format render.ImageFormat, size int, cached bool) {
+	if m == nil {
+		return
+	}
+	if tenant == "" {
+		tenant = config.DefaultTenantID
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := meterKey{tenant: tenant, format: format}
+	counts, ok := m.counts[key]
+	if !ok {
+		counts = &meterCounts{}
+		m.counts[key] = counts
+	}
+	if cached {
+		counts.cacheHits++
+	} else {
+		counts.renders++
+	}
+	counts.bytes += int64(size)
+}
+
+// meterImage counts an image served for r, for usage exports and telemetry,
+// unless it's a preview, which renders for the playground rather than a page.
+func (s *Service) meterImage(r *http.Request, t *theme, format render.ImageFormat, data []byte, cached bool) {
+	if !isPreview(r) {
+		s.meter.record(t.tenantID, format, len(data), cached)
+		s.telemetry.recordFormat(format)
+	}
+}
+
+// close ends the current period at now, returning its usage as a batch, and
+// false when nothing was served in it.
+func (m *meter) close(now time.Time) (meteringBatch, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.batches++
+	batch := meteringBatch{
+		ID:          fmt.Sprintf("%s-%s-%d", m.instance, m.since.UTC().Format("20060102T150405Z"), m.batches),
+		Instance:    m.instance,
+		PeriodStart: m.since.UTC(),
+		PeriodEnd:   now.UTC(),
+	}
+	for key, counts := range m.counts {
+		batch.Records = append(batch.Records, meteringRecord{
+			Tenant:    key.tenant,
+			Format:    string(key.format),
+			Renders:   counts.renders,
+			CacheHits: counts.cacheHits,
+			Bytes:     counts.bytes,
+		})
+	}
+	slices.SortFunc(batch.Records, func(a, b meteringRecord) int {
+		return strings.Compare(a.Tenant+"\x00"+a.Format, b.Tenant+"\x00"+b.Format)
+	})
+	m.since = now
+	clear(m.counts)
+	return batch, len(batch.Records) > 0
+}
+
+// ExportUsage closes the current metering period and delivers its batch,
+// along with any earlier batches that failed to go out. A batch that fails
+// stays queued, with the ones after it, for the next export; only the newest
+// config.MaxPendingMeteringBatches are kept. It does nothing when metering is
+// off.
+func (s *Service) ExportUsage(ctx context.Context) error {
+	m := s.meter
+	if m == nil {
+		return nil
+	}
+	m.exportMu.Lock()
+	defer m.exportMu.Unlock()
+
+	if batch, ok := m.close(time.Now()); ok {
+		m.pending = append(m.pending, batch)
+	}
+	if dropped := len(m.pending) - config.MaxPendingMeteringBatches; dropped > 0 {
+		log.Printf("metering: dropping %d undelivered batches, from %s", dropped, m.pending[0].ID)
+		m.pending = m.pending[dropped:]
+	}
+	for len(m.pending) > 0 {
+		if err := m.deliver(ctx, m.pending[0]); err != nil {
+			return fmt.Errorf("export usage batch %s: %w", m.pending[0].ID, err)
+		}
+		m.pending = m.pending[1:]
+	}
+	return nil
+}
+
+// deliver writes a batch to the export directory and posts it to the webhook.
+// Both are safe to repeat: the file is replaced whole, and the webhook gets
+// the same batch ID.
+func (m *meter) deliver(ctx context.Context, batch meteringBatch) error {
+	if m.dir != "" {
+		if err := m.writeFile(batch); err != nil {
+			return err
+		}
+	}
+	if m.webhook != "" {
+		if err := m.post(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *meter) writeFile(batch meteringBatch) error {
+	var buf bytes.Buffer
+	if err := encodeBatch(&buf, batch, m.format); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so readers never see half a batch
+	path := filepath.Join(m.dir, "usage-"+batch.ID+"."+m.format)
+	tmp, err := os.CreateTemp(m.dir, ".usage-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// encodeBatch writes a batch's records as JSON lines or CSV rows, each with
+// the batch's ID and period.
+func encodeBatch(w io.Writer, batch meteringBatch, format string) error {
+	start, end := batch.PeriodStart.Format(time.RFC3339), batch.PeriodEnd.Format(time.RFC3339)
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(meteringCSVHeader); err != nil {
+			return err
+		}
+		for _, r := range batch.Records {
+			row := []string{batch.ID, batch.Instance, start, end, r.Tenant, r.Format,
+				strconv.FormatInt(r.Renders, 10), strconv.FormatInt(r.CacheHits, 10), strconv.FormatInt(r.Bytes, 10)}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	enc := json.NewEncoder(w)
+	for _, r := range batch.Records {
+		line := struct {
+			BatchID     string `json:"batch_id"`
+			Instance    string `json:"instance"`
+			PeriodStart string `json:"period_start"`
+			PeriodEnd   string `json:"period_end"`
+			meteringRecord
+		}{batch.ID, batch.Instance, start, end, r}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post sends a batch to the webhook as JSON, with its ID as the Idempotency-Key
+// header. Any 2xx response counts as delivered.
+func (m *meter) post(ctx context.Context, batch meteringBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", batch.ID)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
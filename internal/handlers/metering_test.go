@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+func setupMeteringTestService(t *testing.T, configure func(*config.ServerConfig)) (*Service, *http.ServeMux) {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.Tenants = map[string]config.Tenant{"acme": {Hosts: []string{"img.acme.test"}}}
+	configure(&cfg)
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return svc, mux
+}
+
+// serveImages requests each target on host, failing the test unless it's served.
+func serveImages(t *testing.T, mux *http.ServeMux, host string, targets ...string) {
+	t.Helper()
+	for _, target := range targets {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestMeteringExportFiles(t *testing.T) {
+	dir := t.TempDir()
+	svc, mux := setupMeteringTestService(t, func(cfg *config.ServerConfig) { cfg.MeteringDir = dir })
+
+	// The first PNG is rendered, the second served from the cache
+	serveImages(t, mux, "img.acme.test", "/placeholder/40x40.png?text=a", "/placeholder/40x40.png?text=a", "/placeholder/40x40.webp?text=a")
+	serveImages(t, mux, "example.com", "/placeholder/40x40.png?text=a")
+	if err := svc.ExportUsage(context.Background()); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "usage-*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("expected one export file, got %v", files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	type line struct {
+		BatchID string `json:"batch_id"`
+		meteringRecord
+	}
+	var lines []line
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			t.Fatalf("decode %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, l)
+	}
+
+	want := []struct {
+		tenant, format     string
+		renders, cacheHits int64
+	}{
+		{"acme", "png", 1, 1},
+		{"acme", "webp", 1, 0},
+		{"default", "png", 1, 0},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d records, got %+v", len(want), lines)
+	}
+	for i, w := range want {
+		l := lines[i]
+		if l.Tenant != w.tenant || l.Format != w.format || l.Renders != w.renders || l.CacheHits != w.cacheHits || l.Bytes <= 0 {
+			t.Errorf("record %d: expected %+v, got %+v", i, w, l)
+		}
+		if l.BatchID == "" || l.BatchID != lines[0].BatchID || !strings.HasSuffix(files[0], "usage-"+l.BatchID+".jsonl") {
+			t.Errorf("record %d: expected the file's batch ID, got %q", i, l.BatchID)
+		}
+	}
+
+	// A period without traffic exports nothing
+	if err := svc.ExportUsage(context.Background()); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "usage-*")); len(files) != 1 {
+		t.Fatalf("expected no new export file, got %v", files)
+	}
+}
+
+func TestMeteringExportCSV(t *testing.T) {
+	dir := t.TempDir()
+	svc, mux := setupMeteringTestService(t, func(cfg *config.ServerConfig) {
+		cfg.MeteringDir = dir
+		cfg.MeteringFormat = "csv"
+	})
+	serveImages(t, mux, "img.acme.test", "/placeholder/40x40.png?text=a")
+	if err := svc.ExportUsage(context.Background()); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "usage-*.csv"))
+	if len(files) != 1 {
+		t.Fatalf("expected one export file, got %v", files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 2 || strings.Join(rows[0], ",") != strings.Join(meteringCSVHeader, ",") {
+		t.Fatalf("expected a header and one row, got %v", rows)
+	}
+	if row := rows[1]; row[4] != "acme" || row[5] != "png" || row[6] != "1" || row[7] != "0" {
+		t.Fatalf("unexpected row %v", row)
+	}
+}
+
+func TestMeteringWebhookRetries(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	var batches []meteringBatch
+	fail := true
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch meteringBatch
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("decode batch: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer webhook.Close()
+	svc, mux := setupMeteringTestService(t, func(cfg *config.ServerConfig) { cfg.MeteringWebhook = webhook.URL })
+
+	serveImages(t, mux, "img.acme.test", "/placeholder/40x40.png?text=a")
+	if err := svc.ExportUsage(context.Background()); err == nil {
+		t.Fatal("expected the export to fail while the webhook does")
+	}
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	serveImages(t, mux, "img.acme.test", "/placeholder/40x40.png?text=b")
+	if err := svc.ExportUsage(context.Background()); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The failed batch is retried under the same ID, then the next one goes out
+	if len(keys) != 3 || keys[0] != keys[1] || keys[1] == keys[2] {
+		t.Fatalf("expected a retry under the same key, then a new one, got %v", keys)
+	}
+	if len(batches) != 2 || batches[0].ID != keys[0] || batches[1].ID != keys[2] {
+		t.Fatalf("expected both batches delivered in order, got %+v", batches)
+	}
+	for _, batch := range batches {
+		if len(batch.Records) != 1 || batch.Records[0].Tenant != "acme" || batch.Records[0].Renders != 1 {
+			t.Errorf("expected one render for acme, got %+v", batch.Records)
+		}
+		if batch.PeriodEnd.Before(batch.PeriodStart) {
+			t.Errorf("expected the period to end after it starts, got %v to %v", batch.PeriodStart, batch.PeriodEnd)
+		}
+	}
+	if !batches[1].PeriodStart.Equal(batches[0].PeriodEnd) {
+		t.Errorf("expected periods to follow on, got %v after %v", batches[1].PeriodStart, batches[0].PeriodEnd)
+	}
+}
+
+func TestMeteringOff(t *testing.T) {
+	svc, mux := setupTestService(t)
+	serveImages(t, mux, "example.com", "/placeholder/40x40.png")
+	if svc.meter != nil {
+		t.Fatal("expected no meter without an export target")
+	}
+	if err := svc.ExportUsage(context.Background()); err != nil {
+		t.Fatalf("expected exporting to do nothing, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// pathSegments returns the segments of a request's path after prefix,
+// percent-decoded one by one, so an encoded slash (%2F) stays inside its
+// segment. A '+' reads as a space, the way forms and ui-avatars.com URLs encode
+// names; a literal plus is %2B. Segments that don't decode to UTF-8 are rejected.
+func pathSegments(r *http.Request, prefix string) ([]string, error) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), prefix), "/")
+	if rest == "" {
+		return nil, nil
+	}
+	segments := strings.Split(rest, "/")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(strings.ReplaceAll(segment, "+", " "))
+		if err != nil || !utf8.ValidString(decoded) {
+			return nil, ErrInvalidParameter.withMessage("Invalid path. Percent-encode names as UTF-8, such as Jos%%C3%%A9 for José.")
+		}
+		segments[i] = decoded
+	}
+	return segments, nil
+}
+
+// normalizeName trims a name and collapses its runs of whitespace into single
+// spaces, so "José  Núñez " and "José Núñez" draw and cache alike.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// colorSeed returns what a request's per-user colors and patterns are derived
+// from: the seed of the uid parameter when it's set, so they survive renames,
+// or else the name.
+func (s *Service) colorSeed(r *http.Request, name string) (string, error) {
+	uid, err := s.limitLength(r, "uid", r.URL.Query().Get("uid"), s.cfg.MaxNameLength)
+	if err != nil || uid == "" {
+		return name, err
+	}
+	return s.identity.Seed(uid), nil
+}
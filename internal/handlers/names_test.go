@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"grout/internal/identity"
+	"grout/internal/render"
+)
+
+func TestPathSegments(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		want    []string
+		wantErr bool
+	}{
+		{"plain", "/avatar/Jane", []string{"Jane"}, false},
+		{"plus as space", "/avatar/Jos%C3%A9+%C3%91u%C3%B1ez", []string{"José Ñuñez"}, false},
+		{"raw UTF-8", "/avatar/José+Ñuñez.png", []string{"José Ñuñez.png"}, false},
+		{"encoded plus", "/avatar/C%2B%2B", []string{"C++"}, false},
+		{"encoded slash", "/avatar/AC%2FDC/x", []string{"AC/DC", "x"}, false},
+		{"encoded space", "/avatar/Jane%20Doe", []string{"Jane Doe"}, false},
+		{"nothing", "/avatar/", nil, false},
+		{"not UTF-8", "/avatar/Jos%E9", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pathSegments(httptest.NewRequest(http.MethodGet, tt.target, nil), "/avatar/")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pathSegments(%s) error = %v, wantErr %t", tt.target, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("pathSegments(%s) = %q, want %q", tt.target, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("pathSegments(%s) = %q, want %q", tt.target, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAvatarNameDecoding(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name     string
+		target   string
+		wantName string
+		wantLine string
+	}{
+		{"plus", "/avatar/José+Ñuñez?explain=true", "José Ñuñez", "JÑ"},
+		{"percent-encoded", "/avatar/Jos%C3%A9%20%C3%91u%C3%B1ez.png?explain=true", "José Ñuñez", "JÑ"},
+		{"whitespace collapsed", "/avatar/+%20%C3%A9mile++zola+?explain=true", "émile zola", "ÉZ"},
+		{"encoded slash", "/avatar/AC%2FDC?explain=true", "AC/DC", "A"},
+		{"query", "/avatar/?name=Ren%C3%A9e+Smith&explain=true", "Renée Smith", "RS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got explanation
+			if rec := getJSON(t, mux, tt.target, &got); rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+			if got.Name != tt.wantName || len(got.Lines) != 1 || got.Lines[0] != tt.wantLine {
+				t.Errorf("expected name %q and initials %q, got %q and %q", tt.wantName, tt.wantLine, got.Name, got.Lines)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/Jos%E9", nil))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Error-Code") != "invalid_parameter" {
+		t.Errorf("expected 400 invalid_parameter for a name that isn't UTF-8, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
+
+func TestAvatarUID(t *testing.T) {
+	svc, mux := setupTestService(t)
+	background := func(target string) string {
+		t.Helper()
+		var got explanation
+		if rec := getJSON(t, mux, target, &got); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 from %s, got %d", target, rec.Code)
+		}
+		return got.Background
+	}
+
+	seeded := render.GenerateColorHash(svc.identity.Seed("user-42"))
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"uid seeds the color", "/avatar/Jane%20Doe?uid=user-42&bg=random&explain=true", seeded},
+		{"renames keep it", "/avatar/Jane%20Smith?uid=user-42&bg=random&explain=true", seeded},
+		{"name without a uid", "/avatar/Jane%20Doe?bg=random&explain=true", render.GenerateColorHash("Jane Doe")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := background(tt.target); got != tt.want {
+				t.Errorf("expected background %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// Discord avatars pick their color by the seed too
+	body := func(target string) string {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+	if body("/avatar/Jane?uid=user-7&style=discord") != body("/avatar/Janet?uid=user-7&style=discord") {
+		t.Error("expected a renamed user to keep their Discord avatar")
+	}
+
+	// Another salt gives the same user another color
+	svc.identity = identity.NewSeeder("another deployment")
+	if got := background("/avatar/Jane%20Doe?uid=user-42&bg=random&explain=true"); got == seeded {
+		t.Errorf("expected a different color under another salt, got %s again", got)
+	}
+}
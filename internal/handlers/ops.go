@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+// serveOps renders a placeholder from the ops parameter, a pipeline of stages
+// run in order, in place of the usual text on a background.
+func (s *Service) serveOps(w http.ResponseWriter, r *http.Request, width, height int, format render.ImageFormat) {
+	// Ops errors all wrap render.ErrInvalidOps and describe the problem
+	ops, err := render.ParseOps(r.URL.Query().Get("ops"), width, height)
+	if err != nil {
+		s.fail(w, r, ErrInvalidParameter.withMessage("%v", err))
+		return
+	}
+	for i, op := range ops {
+		if op.Kind == render.OpText {
+			if ops[i].Text, err = s.limitLength(r, "text", op.Text, s.cfg.MaxTextLength); err != nil {
+				s.fail(w, r, err)
+				return
+			}
+		}
+		if op.Kind == render.OpEffect && op.Effect.IsRasterOnly() && format == render.FormatSVG {
+			s.fail(w, r, ErrUnsupportedFormat.withMessage("The %s stage needs raster output. Use a .png, .jpg, .gif, or .webp extension.", op.Effect))
+			return
+		}
+	}
+	seedParam := r.URL.Query().Get("seed")
+	if seedParam == "" {
+		seedParam = r.URL.Query().Get("ops")
+	}
+	seed := genart.ParseSeed(seedParam)
+
+	key := specKey("OPS", opsSpec{Width: width, Height: height, Ops: ops, Seed: seed, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawOps(ctx, width, height, ops, seed, format)
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpsParameter(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{"SVG pipeline", "/placeholder/300x150?ops=fill:navy,blur:2,color:gold,text:Hi", http.StatusOK, "image/svg+xml", `fill="#ffd700"`},
+		{"raster filters", "/placeholder/300x150.png?ops=fill:teal,confetti,text:Yay,halftone", http.StatusOK, "image/png", ""},
+		{"placehold.co path", "/300x150/png?ops=fill:red,vignette", http.StatusOK, "image/png", ""},
+		{"unknown stage", "/placeholder/300x150?ops=fill:red,wobble", http.StatusBadRequest, "text/html; charset=utf-8", "unknown stage"},
+		{"empty ops", "/placeholder/300x150?ops=", http.StatusBadRequest, "text/html; charset=utf-8", "invalid ops"},
+		{"raster stage in SVG", "/placeholder/300x150?ops=fill:red,dither", http.StatusBadRequest, "text/html; charset=utf-8", "needs raster output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("expected %q in body", tt.contains)
+			}
+		})
+	}
+}
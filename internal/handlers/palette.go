@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"grout/internal/config"
+	"grout/internal/palette"
+	"grout/internal/remote"
+	"grout/internal/utils"
+)
+
+// paletteResponse is the JSON body returned by the palette API.
+type paletteResponse struct {
+	URL    string           `json:"url"`
+	Colors []palette.Swatch `json:"colors"`
+}
+
+// extractPalette returns the dominant colors of the image at rawURL, caching the result.
+func (s *Service) extractPalette(r *http.Request, rawURL string, count int) ([]palette.Swatch, error) {
+	t := s.themeFor(r)
+	key := t.cacheNamespace(specKey("PALETTE", paletteSpec{URL: rawURL, Count: count}))
+	if data, ok := t.cache.Get(key); ok {
+		var swatches []palette.Swatch
+		if err := json.Unmarshal(data, &swatches); err == nil {
+			return swatches, nil
+		}
+	}
+
+	img, err := s.fetcher.FetchImage(r.Context(), rawURL)
+	if err != nil {
+		return nil, err
+	}
+	swatches := palette.Extract(img, count)
+	if data, err := json.Marshal(swatches); err == nil {
+		t.cache.Add(key, data)
+	}
+	return swatches, nil
+}
+
+func (s *Service) handlePalette(w http.ResponseWriter, r *http.Request) {
+	if !s.fetcher.Enabled() {
+		s.failJSON(w, r, ErrFeatureDisabled.withMessage("the image proxy is not enabled on this server"))
+		return
+	}
+
+	rawURL := r.URL.Query().Get("url")
+	if _, err := s.fetcher.Validate(rawURL); err != nil {
+		if errors.Is(err, remote.ErrHostNotAllowed) {
+			s.failJSON(w, r, ErrHostNotAllowed.withMessage("the image host is not on this server's allowlist"))
+			return
+		}
+		s.failJSON(w, r, ErrInvalidURL.withMessage("invalid url parameter: provide an absolute http or https image URL").withCause(err))
+		return
+	}
+
+	count := min(utils.ParseIntOrDefault(r.URL.Query().Get("count"), config.DefaultPaletteCount), config.MaxPaletteCount)
+
+	// Validate the strip format before doing any remote work
+	formatParam := r.URL.Query().Get("format")
+	format, ok := parseFormatParam(formatParam)
+	if formatParam != "" && !ok {
+		s.failJSON(w, r, ErrUnsupportedFormat.withMessage("invalid format: use svg, png, jpg, gif, or webp"))
+		return
+	}
+
+	swatches, err := s.extractPalette(r, rawURL, count)
+	if err != nil {
+		s.failJSON(w, r, ErrUpstreamFailed.withMessage("failed to fetch or decode the image").withCause(err))
+		return
+	}
+	if len(swatches) == 0 {
+		s.failJSON(w, r, ErrInvalidImage.withMessage("the image has no opaque pixels"))
+		return
+	}
+
+	if formatParam == "" {
+		writeJSON(w, http.StatusOK, paletteResponse{URL: rawURL, Colors: swatches})
+		return
+	}
+
+	// Render the palette as a strip image
+	colors := make([]string, len(swatches))
+	for i, sw := range swatches {
+		colors[i] = sw.Hex
+	}
+	width := min(utils.ParseIntOrDefault(r.URL.Query().Get("w"), config.DefaultPaletteWidth), config.MaxResizeDimension)
+	height := min(utils.ParseIntOrDefault(r.URL.Query().Get("h"), config.DefaultPaletteHeight), config.MaxResizeDimension)
+
+	key := specKey("PALETTESTRIP", paletteStripSpec{Colors: colors, Width: width, Height: height, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawPaletteStrip(ctx, colors, width, height, format)
+	})
+}
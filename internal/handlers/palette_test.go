@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPaletteHandlerJSON(t *testing.T) {
+	srv := newImageServer(t)
+	mux := setupProxyTestService(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/palette?url="+url.QueryEscape(srv.URL+"/photo.png"), nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected application/json got %s", ct)
+	}
+
+	var resp paletteResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Colors) == 0 || resp.Colors[0].Hex != "1e88e5" {
+		t.Fatalf("expected dominant color 1e88e5, got %+v", resp.Colors)
+	}
+}
+
+func TestPaletteHandler(t *testing.T) {
+	srv := newImageServer(t)
+	mux := setupProxyTestService(t)
+	photo := url.QueryEscape(srv.URL + "/photo.png")
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"strip SVG", "/api/v1/palette?url=" + photo + "&format=svg", http.StatusOK, "image/svg+xml"},
+		{"strip PNG", "/api/v1/palette?url=" + photo + "&format=png&w=200&h=40", http.StatusOK, "image/png"},
+		{"invalid format", "/api/v1/palette?url=" + photo + "&format=bmp", http.StatusBadRequest, "application/json"},
+		{"missing url", "/api/v1/palette", http.StatusBadRequest, "application/json"},
+		{"host not allowed", "/api/v1/palette?url=" + url.QueryEscape("https://example.com/a.png"), http.StatusForbidden, "application/json"},
+		{"upstream missing", "/api/v1/palette?url=" + url.QueryEscape(srv.URL+"/nope.png"), http.StatusBadGateway, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
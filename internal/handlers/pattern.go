@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/render/genart"
+	"grout/internal/utils"
+)
+
+// patternColorCount is the number of colors derived from the seed when no background is given.
+const patternColorCount = 4
+
+// servePattern renders an avatar or placeholder with a patterned background. The
+// seed parameter defaults to defaultSeed (the name or text) so output is cache-stable.
+func (s *Service) servePattern(w http.ResponseWriter, r *http.Request, pattern render.Pattern, width, height int, text, defaultSeed string, rounded, bold bool, format render.ImageFormat) {
+	if !pattern.IsValid() {
+		names := make([]string, 0, len(render.Patterns()))
+		for _, p := range render.Patterns() {
+			names = append(names, string(p))
+		}
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid pattern. Use one of: %s.", strings.Join(names, ", ")))
+		return
+	}
+
+	seedParam := r.URL.Query().Get("seed")
+	if seedParam == "" {
+		seedParam = defaultSeed
+	}
+	seed := genart.ParseSeed(seedParam)
+
+	// Colors from the background parameter, or derived from the seed. Tiled grids
+	// default to a plain paper color instead.
+	bgParam, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	colors := parsePalette(bgParam)
+	if len(colors) == 0 && pattern.IsTiled() {
+		colors = []string{config.DefaultPatternPaperColor}
+	}
+	if len(colors) == 0 || strings.EqualFold(colors[0], "random") {
+		colors = genart.Palette(seed, patternColorCount)
+	}
+	spacing := utils.ParseIntOrDefault(r.URL.Query().Get("spacing"), 0)
+	lineHex, err := queryColor(r, "lineColor")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgParam, err := queryColor(r, "color", accentColor, analogousColor)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	fgHex := foregroundColor(fgParam, strings.Join(colors[:min(2, len(colors))], ","))
+
+	opts := render.PatternOptions{Seed: seed, Colors: colors, Spacing: spacing, LineColor: lineHex}
+	key := specKey("PATTERN", patternSpec{
+		Pattern: pattern, Width: width, Height: height, PatternOptions: opts,
+		Fg: fgHex, Text: text, Rounded: rounded, Bold: bold, Format: format,
+	})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawPattern(ctx, width, height, pattern, opts, text, fgHex, rounded, bold, format)
+	})
+}
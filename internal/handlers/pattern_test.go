@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPatternParam(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"placeholder lowpoly", "/placeholder/800x400?pattern=lowpoly", http.StatusOK, "image/svg+xml"},
+		{"placeholder lowpoly PNG with colors", "/placeholder/400x200.png?pattern=lowpoly&bg=1e3c72,2a5298&seed=7", http.StatusOK, "image/png"},
+		{"avatar lowpoly rounded", "/avatar/Jane%20Doe.png?pattern=lowpoly&rounded=true", http.StatusOK, "image/png"},
+		{"avatar lowpoly random bg", "/avatar/Jane%20Doe?pattern=lowpoly&bg=random", http.StatusOK, "image/svg+xml"},
+		{"placeholder mesh", "/placeholder/800x400?pattern=mesh&seed=brand", http.StatusOK, "image/svg+xml"},
+		{"placeholder mesh WebP", "/placeholder/400x200.webp?pattern=mesh&bg=ff6b6b,feca57,48dbfb", http.StatusOK, "image/webp"},
+		{"placeholder isogrid", "/placeholder/400x200?pattern=isogrid&spacing=24&lineColor=3498db&bg=ffffff", http.StatusOK, "image/svg+xml"},
+		{"placeholder dots PNG", "/placeholder/400x200.png?pattern=dots&spacing=16", http.StatusOK, "image/png"},
+		{"avatar isogrid JPEG", "/avatar/Jane%20Doe.jpg?pattern=isogrid", http.StatusOK, "image/jpeg"},
+		{"unknown pattern", "/placeholder/400x200?pattern=plaid", http.StatusBadRequest, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
+
+func TestPatternSeededByName(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	render := func(path string) []byte {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Body.Bytes()
+	}
+
+	jane := render("/avatar/Jane%20Doe?pattern=lowpoly")
+	if !bytes.Equal(jane, render("/avatar/Jane%20Doe?pattern=lowpoly")) {
+		t.Fatal("expected the same name to render identically")
+	}
+	// Same initials, different name: the seed differs so the facets do too
+	if bytes.Equal(jane, render("/avatar/John%20Doe?pattern=lowpoly")) {
+		t.Fatal("expected a different name to render differently")
+	}
+}
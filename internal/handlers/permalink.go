@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"grout/internal/kvstore"
+	"grout/internal/render"
+)
+
+// permalinkKeyPrefix namespaces permalinks in the store, and
+// permalinkCountKey counts those registered in the current PermalinkTTL.
+const (
+	permalinkKeyPrefix = "permalink:"
+	permalinkCountKey  = "permalinks:registered"
+)
+
+// permalink is an image URL registered under its hash, served at
+// /i/{id}.{ext}.
+type permalink struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+}
+
+// permalinkFor returns the permalink of the image a request renders: its
+// canonical URL, less the parameters that don't change the image, and the path
+// it's served at. Images that expire, through a signed link's exp, by showing
+// today's date, or by drawing an endpoint badge's document, have none, as a
+// permalink would outlive them, and so do playground previews. In
+// no-personal-data mode no image has one, since the stored URL would keep an
+// avatar's name.
+func (s *Service) permalinkFor(r *http.Request, format render.ImageFormat) (permalink, string, bool) {
+	if !s.permalinksEnabled() || isPreview(r) || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return permalink{}, "", false
+	}
+	if _, expires := s.cacheExpiry(r); expires {
+		return permalink{}, "", false
+	}
+	canonical := canonicalImageURL(r)
+	sum := sha256.Sum256([]byte(canonical))
+	path := "/i/" + hex.EncodeToString(sum[:16]) + permalinkExtension(format)
+	return permalink{URL: canonical, Format: string(format)}, path, true
+}
+
+// canonicalImageURL returns the canonical URL of the image a request renders,
+// less the parameters that don't change the image.
+func canonicalImageURL(r *http.Request) string {
+	query := r.URL.Query()
+	for _, param := range []string{"async", "explain", "sig"} {
+		query.Del(param)
+	}
+	return canonicalURL(&url.URL{Path: r.URL.Path, RawQuery: query.Encode()})
+}
+
+// permalinkExtension returns the extension of a format in permalinks.
+func permalinkExtension(format render.ImageFormat) string {
+	return canonicalExtensions["."+string(format)]
+}
+
+// permalinksEnabled reports whether images get permalinks.
+func (s *Service) permalinksEnabled() bool {
+	return s.cfg.Permalinks && !s.cfg.NoPersonalData
+}
+
+// registerPermalink stores a permalink under the ID in its path for
+// PermalinkTTL, unless it's already there, and reports whether it's there now.
+// Once MaxPermalinks have been registered in a PermalinkTTL, new ones aren't
+// until the count expires. Failures are logged: the image is served either
+// way, just without a permalink.
+func (s *Service) registerPermalink(r *http.Request, link permalink, path string) bool {
+	key := permalinkKeyPrefix + strings.TrimSuffix(strings.TrimPrefix(path, "/i/"), permalinkExtension(render.ImageFormat(link.Format)))
+	if _, err := s.store.Get(r.Context(), key); err == nil {
+		return true
+	}
+	count, err := s.store.Incr(r.Context(), permalinkCountKey, 1, s.cfg.PermalinkTTL)
+	if err != nil {
+		log.Printf("register permalink %s: %v", path, err)
+		return false
+	}
+	if count > int64(s.cfg.MaxPermalinks) {
+		return false
+	}
+	data, err := json.Marshal(link)
+	if err == nil {
+		err = s.store.Set(r.Context(), key, data, s.cfg.PermalinkTTL)
+	}
+	if err != nil {
+		log.Printf("register permalink %s: %v", path, err)
+		return false
+	}
+	return true
+}
+
+// addPermalinkHeader links a response to the permalink at path.
+func addPermalinkHeader(w http.ResponseWriter, path string) {
+	w.Header().Add("Link", "<"+path+`>; rel="canonical"`)
+}
+
+// handlePermalink serves the image registered under /i/{id}.{ext}, by running
+// its URL through the image endpoints. The URL was checked, and signed when
+// signing is on, when it was registered, so it isn't checked again.
+func (s *Service) handlePermalink(w http.ResponseWriter, r *http.Request) {
+	if !s.permalinksEnabled() {
+		s.fail(w, r, ErrFeatureDisabled)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/i/")
+	id, ext, _ := strings.Cut(name, ".")
+	data, err := s.store.Get(r.Context(), permalinkKeyPrefix+id)
+	if errors.Is(err, kvstore.ErrNotFound) {
+		s.fail(w, r, ErrNotFound.withMessage("No such permalink. Request the image by its full URL first."))
+		return
+	}
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	var link permalink
+	if err := json.Unmarshal(data, &link); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if "."+ext != permalinkExtension(render.ImageFormat(link.Format)) {
+		s.fail(w, r, ErrNotFound.withMessage("No such permalink. Its extension is %s.", permalinkExtension(render.ImageFormat(link.Format))))
+		return
+	}
+
+	target, err := url.Parse(link.URL)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	resolved := r.Clone(r.Context())
+	resolved.URL = target
+	resolved.RequestURI = link.URL
+	s.imageRoutes().ServeHTTP(w, resolved)
+}
+
+// imageRoutes returns a mux of the image endpoints' handlers, without the
+// middleware of the server's mux, which a permalink, preview, diff, or
+// validation request has been through already. It's the one list of the
+// routes the server renders internally.
+func (s *Service) imageRoutes() *http.ServeMux {
+	s.imageMux.once.Do(func() {
+		s.imageMux.mux = http.NewServeMux()
+		for _, rt := range s.routes() {
+			if rt.rateLimited && !rt.unsigned {
+				s.imageMux.mux.Handle(rt.pattern(), rt.handler)
+			}
+		}
+	})
+	return s.imageMux.mux
+}
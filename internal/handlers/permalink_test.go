@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// permalinkPath returns the permalink in a response's Link header.
+func permalinkPath(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	link := rec.Header().Get("Link")
+	path, ok := strings.CutSuffix(strings.TrimPrefix(link, "<"), `>; rel="canonical"`)
+	if !ok || !strings.HasPrefix(path, "/i/") {
+		t.Fatalf("expected a permalink in the Link header, got %q", link)
+	}
+	return path
+}
+
+func TestPermalinks(t *testing.T) {
+	svc, _ := setupTestService(t)
+	svc.cfg.Permalinks = true
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	full := get("/placeholder/320x200.png?bg=ff0000&text=Hello")
+	if full.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", full.Code, full.Body.String())
+	}
+	path := permalinkPath(t, full)
+	if !strings.HasSuffix(path, ".png") || len(path) != len("/i/")+32+len(".png") {
+		t.Errorf("expected a 32-digit hash with the .png extension, got %s", path)
+	}
+
+	// The permalink serves the same image, with the same ETag
+	short := get(path)
+	if short.Code != http.StatusOK || short.Body.String() != full.Body.String() || short.Header().Get("ETag") != full.Header().Get("ETag") {
+		t.Fatalf("expected the permalink to serve the image, got %d with ETag %s", short.Code, short.Header().Get("ETag"))
+	}
+
+	// Equivalent URLs share a permalink
+	if same := permalinkPath(t, get("/placeholder/320x200.png?text=Hello&bg=FF0000&async=false")); same != path {
+		t.Errorf("expected reordered parameters, uppercase colors, and async to share %s, got %s", path, same)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"unknown hash", "/i/0123456789abcdef0123456789abcdef.png", http.StatusNotFound},
+		{"wrong extension", strings.TrimSuffix(path, ".png") + ".svg", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := get(tt.target); rec.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+
+	// Images that expire get no permalink
+	if link := get("/calendar/200x200").Header().Get("Link"); link != "" {
+		t.Errorf("expected no permalink for today's calendar, got %q", link)
+	}
+}
+
+func TestPermalinksSigned(t *testing.T) {
+	svc, _ := setupTestService(t)
+	svc.cfg.Permalinks = true
+	svc.cfg.SigningKey = testSigningKey
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	// The signed URL registers the permalink, which is served unsigned
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, signed(t, "/avatar/Jane.png"), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	short := httptest.NewRecorder()
+	mux.ServeHTTP(short, httptest.NewRequest(http.MethodGet, permalinkPath(t, rec), nil))
+	if short.Code != http.StatusOK || short.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("expected the permalink to serve the PNG, got %d %s", short.Code, short.Header().Get("Content-Type"))
+	}
+}
+
+func TestPermalinksDisabled(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/100x100", nil))
+	if link := rec.Header().Get("Link"); link != "" {
+		t.Errorf("expected no permalink, got %q", link)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/i/0123456789abcdef0123456789abcdef.png", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("X-Error-Code") != "feature_disabled" {
+		t.Errorf("expected 404 feature_disabled, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
+
+func TestPermalinksLimited(t *testing.T) {
+	svc, _ := setupTestService(t)
+	svc.cfg.Permalinks = true
+	svc.cfg.MaxPermalinks = 1
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	registered := func() []string {
+		keys, err := svc.store.Keys(context.Background(), permalinkKeyPrefix)
+		if err != nil {
+			t.Fatalf("list permalinks: %v", err)
+		}
+		return keys
+	}
+
+	// Failed renders don't register a permalink, or take a slot
+	if rec := get("/placeholder/320x200.svg?maxBytes=10"); rec.Code != http.StatusRequestEntityTooLarge || rec.Header().Get("Link") != "" {
+		t.Fatalf("expected 413 with no Link, got %d %q", rec.Code, rec.Header().Get("Link"))
+	}
+	if keys := registered(); len(keys) != 0 {
+		t.Fatalf("expected no permalinks after a failed render, got %v", keys)
+	}
+
+	// Once the limit is reached, new images are served without one
+	path := permalinkPath(t, get("/placeholder/320x200.png"))
+	if again := permalinkPath(t, get("/placeholder/320x200.png")); again != path {
+		t.Errorf("expected the registered image to keep %s, got %s", path, again)
+	}
+	if rec := get("/placeholder/200x200.png"); rec.Code != http.StatusOK || rec.Header().Get("Link") != "" {
+		t.Errorf("expected 200 with no Link over the limit, got %d %q", rec.Code, rec.Header().Get("Link"))
+	}
+	if keys := registered(); len(keys) != 1 {
+		t.Errorf("expected 1 permalink, got %v", keys)
+	}
+}
+
+func TestPermalinksExpire(t *testing.T) {
+	svc, _ := setupTestService(t)
+	svc.cfg.Permalinks = true
+	svc.cfg.PermalinkTTL = time.Millisecond
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/320x200.png", nil))
+	path := permalinkPath(t, rec)
+	time.Sleep(10 * time.Millisecond)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected an expired permalink to be 404, got %d", rec.Code)
+	}
+}
+
+func TestPermalinksNoPersonalData(t *testing.T) {
+	svc, _ := setupTestService(t)
+	svc.cfg.Permalinks = true
+	svc.cfg.NoPersonalData = true
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	// The stored URL would keep the name, so there are no permalinks
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/Jane%20Doe.png", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Link") != "" {
+		t.Errorf("expected 200 with no Link, got %d %q", rec.Code, rec.Header().Get("Link"))
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/i/0123456789abcdef0123456789abcdef.png", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("X-Error-Code") != "feature_disabled" {
+		t.Errorf("expected 404 feature_disabled, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+// parsePicsumSize parses the {width}[/{height}][.ext] tail of a Lorem Picsum path.
+// Photos default to JPEG; SVG isn't available.
+func parsePicsumSize(segments []string) (int, int, render.ImageFormat, bool) {
+	if len(segments) == 0 || len(segments) > 2 {
+		return 0, 0, "", false
+	}
+	last := len(segments) - 1
+	size := append([]string(nil), segments...)
+	format := render.FormatJPG
+	if ext := path.Ext(size[last]); ext != "" {
+		f, ok := formatExtensions[strings.ToLower(ext)]
+		if !ok || f == render.FormatSVG {
+			return 0, 0, "", false
+		}
+		format = f
+		size[last] = strings.TrimSuffix(size[last], ext)
+	}
+
+	width, err := strconv.Atoi(size[0])
+	if err != nil {
+		return 0, 0, "", false
+	}
+	height := width
+	if len(size) == 2 {
+		if height, err = strconv.Atoi(size[1]); err != nil {
+			return 0, 0, "", false
+		}
+	}
+	if width < 1 || height < 1 || width > config.MaxPhotoDimension || height > config.MaxPhotoDimension {
+		return 0, 0, "", false
+	}
+	return width, height, format, true
+}
+
+// isPicsumPath reports whether a root path is a Lorem Picsum /{width}/{height} URL.
+// A single number stays a placehold.co square.
+func isPicsumPath(urlPath string) bool {
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+	_, _, _, ok := parsePicsumSize(segments)
+	return ok && len(segments) == 2
+}
+
+// redirectRandomPicsum sends /{width}/{height} to a random /id/{n}/ photo of that
+// size, like Lorem Picsum does, so the photo itself stays cacheable.
+func (s *Service) redirectRandomPicsum(w http.ResponseWriter, r *http.Request) {
+	id := rand.IntN(config.PicsumRandomIDs)
+	if s.cfg.Deterministic {
+		// The same size always redirects to the same photo
+		id = int(genart.ParseSeed(r.URL.Path) % config.PicsumRandomIDs)
+	}
+	target := fmt.Sprintf("/id/%d/%s", id, strings.Trim(r.URL.Path, "/"))
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handlePicsum serves Lorem Picsum's /id/{n}/{width}/{height} and
+// /seed/{seed}/{width}/{height} URLs with seeded noise photos, honoring its
+// grayscale and blur parameters.
+func (s *Service) handlePicsum(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) < 3 {
+		s.handle404(w, r)
+		return
+	}
+	width, height, format, ok := parsePicsumSize(segments[2:])
+	if !ok {
+		s.handle404(w, r)
+		return
+	}
+	width, height = emailDimensions(r, width, height)
+	format = emailFormat(r, format)
+
+	var seed uint64
+	if segments[0] == "id" {
+		id, err := strconv.ParseUint(segments[1], 10, 64)
+		if err != nil {
+			s.handle404(w, r)
+			return
+		}
+		seed = id
+	} else {
+		seed = genart.ParseSeed(segments[1])
+	}
+
+	// Both parameters work without a value: ?grayscale&blur
+	query := r.URL.Query()
+	opts := render.NoiseOptions{Seed: seed, Grayscale: query.Has("grayscale")}
+	if query.Has("blur") {
+		opts.Blur = 1
+		if level, err := strconv.Atoi(query.Get("blur")); err == nil {
+			opts.Blur = max(1, min(level, render.MaxNoiseBlur))
+		}
+	}
+
+	key := specKey("PICSUM", picsumSpec{Width: width, Height: height, NoiseOptions: opts, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return render.DrawNoisePhoto(ctx, width, height, opts, format)
+	})
+}
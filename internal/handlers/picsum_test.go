@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestPicsumHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectStatus int
+		contentType  string
+	}{
+		{"id", "/id/237/200/300", http.StatusOK, "image/jpeg"},
+		{"seed", "/seed/picsum/200/300", http.StatusOK, "image/jpeg"},
+		{"square", "/id/10/150", http.StatusOK, "image/jpeg"},
+		{"webp extension", "/seed/picsum/200/300.webp", http.StatusOK, "image/webp"},
+		{"grayscale and blur", "/id/1/120/80?grayscale&blur=2", http.StatusOK, "image/jpeg"},
+		{"svg is unavailable", "/id/1/120/80.svg", http.StatusNotFound, "text/html; charset=utf-8"},
+		{"id must be a number", "/id/abc/200/300", http.StatusNotFound, "text/html; charset=utf-8"},
+		{"too large", "/id/1/5000/300", http.StatusNotFound, "text/html; charset=utf-8"},
+		{"missing size", "/seed/picsum", http.StatusNotFound, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectStatus {
+				t.Fatalf("expected %d got %d", tt.expectStatus, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
+
+func TestPicsumRandomRedirect(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/200/300.webp?grayscale", nil))
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302 got %d", rec.Code)
+	}
+	location := rec.Header().Get("Location")
+	if !regexp.MustCompile(`^/id/\d+/200/300\.webp\?grayscale$`).MatchString(location) {
+		t.Fatalf("unexpected redirect target %q", location)
+	}
+	if rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatal("expected the random redirect not to be cached")
+	}
+
+	photo := httptest.NewRecorder()
+	mux.ServeHTTP(photo, httptest.NewRequest(http.MethodGet, location, nil))
+	if photo.Code != http.StatusOK || photo.Header().Get("Content-Type") != "image/webp" {
+		t.Fatalf("expected the redirect target to serve a photo, got %d", photo.Code)
+	}
+}
@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/middleware"
+)
+
+// queuedPreview is an image URL sent to a preview stream, numbered in the
+// order updates were sent.
+type queuedPreview struct {
+	seq int
+	url string
+}
+
+// previewSession is an open preview stream. updates holds the latest image URL
+// sent for it that the stream hasn't picked up yet.
+type previewSession struct {
+	mu      sync.Mutex
+	sent    int
+	updates chan queuedPreview
+}
+
+func newPreviewSession() *previewSession {
+	return &previewSession{updates: make(chan queuedPreview, 1)}
+}
+
+// send queues target for the stream, replacing an update still waiting.
+func (p *previewSession) send(target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sent++
+	select {
+	case <-p.updates:
+	default:
+	}
+	p.updates <- queuedPreview{seq: p.sent, url: target}
+}
+
+// previewHub tracks the open preview streams by ID.
+type previewHub struct {
+	mu       sync.Mutex
+	sessions map[string]*previewSession
+}
+
+func newPreviewHub() *previewHub {
+	return &previewHub{sessions: make(map[string]*previewSession)}
+}
+
+// open starts a session under a random ID, unless config.MaxPreviewSessions
+// are open already.
+func (h *previewHub) open() (string, *previewSession, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.sessions) >= config.MaxPreviewSessions {
+		return "", nil, false
+	}
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+	id := hex.EncodeToString(raw)
+	session := newPreviewSession()
+	h.sessions[id] = session
+	return id, session, true
+}
+
+func (h *previewHub) get(id string) (*previewSession, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	session, ok := h.sessions[id]
+	return session, ok
+}
+
+func (h *previewHub) close(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, id)
+}
+
+// previewSessionEvent is the first event of a preview stream: where to send
+// the image URLs to preview.
+type previewSessionEvent struct {
+	ID        string `json:"id"`
+	UpdateURL string `json:"update_url"`
+}
+
+// previewEvent is a rendered preview, or why the image couldn't be rendered.
+// Seq numbers the update it shows among those sent to the stream, so a client
+// can tell which of its changes a preview shows.
+type previewEvent struct {
+	Seq         int    `json:"seq"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+	// Image is the image as a data URI
+	Image string `json:"image,omitempty"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	// RetryAfter is when to preview again after maintenance mode refused it,
+	// in seconds
+	RetryAfter int64 `json:"retry_after,omitempty"`
+}
+
+type internalRenderKey struct{}
+
+// internalRender is an image the server renders for itself through the image
+// endpoints, for a preview, a tool call, or a validation. err receives the error
+// an endpoint fails it with, and warnings how the endpoint read it differently
+// from how it was written.
+type internalRender struct {
+	err      *requestError
+	warnings []string
+	// preview leaves the image out of the cache and permalinks
+	preview bool
+}
+
+// isPreview reports whether r renders a preview, which isn't cached.
+func isPreview(r *http.Request) bool {
+	rendering, ok := r.Context().Value(internalRenderKey{}).(*internalRender)
+	return ok && rendering.preview
+}
+
+// recordRenderFailure hands e to the internal render r is, if it is one, so
+// its caller can report it.
+func recordRenderFailure(r *http.Request, e *requestError) {
+	if rendering, ok := r.Context().Value(internalRenderKey{}).(*internalRender); ok {
+		rendering.err = e
+	}
+}
+
+// refundRateLimit gives back part of r's rate limit cost, once its handler knows
+// the request was cheap to serve. Internal renders have nothing to give back,
+// as their cost is the request that made them.
+func refundRateLimit(r *http.Request, part float64) {
+	if _, ok := r.Context().Value(internalRenderKey{}).(*internalRender); !ok {
+		middleware.Refund(r, part)
+	}
+}
+
+// warn records how r was read differently from how it was written, such as a
+// size cut to its maximum, for the internal render r is, if it is one.
+func warn(r *http.Request, format string, args ...any) {
+	if rendering, ok := r.Context().Value(internalRenderKey{}).(*internalRender); ok {
+		rendering.warnings = append(rendering.warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+// renderRecorder captures the response of an image endpoint to an internal
+// render.
+type renderRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *renderRecorder) Header() http.Header { return rec.header }
+
+func (rec *renderRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+func (rec *renderRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+// renderInternal renders the image at target, a path and query, by serving it
+// to h as a GET request on behalf of r. It returns the image's content type and
+// data, or the error the image endpoint failed with.
+func (s *Service) renderInternal(r *http.Request, h http.Handler, target *url.URL, preview bool) (string, []byte, *requestError) {
+	rec, rendering := serveInternal(r, h, target, preview)
+	switch {
+	case rendering.err != nil:
+		return "", nil, rendering.err
+	case rec.status != http.StatusOK:
+		// Refused by a limit in front of the endpoints
+		return "", nil, &requestError{status: rec.status, message: http.StatusText(rec.status)}
+	}
+	return rec.header.Get("Content-Type"), rec.body.Bytes(), nil
+}
+
+// serveInternal serves target to h as a GET request on behalf of r, returning
+// the response and what the endpoint recorded of the render.
+func serveInternal(r *http.Request, h http.Handler, target *url.URL, preview bool) (*renderRecorder, *internalRender) {
+	rendering := &internalRender{preview: preview}
+	req := r.Clone(context.WithValue(r.Context(), internalRenderKey{}, rendering))
+	req.Method = http.MethodGet
+	req.URL = target
+	req.RequestURI = target.RequestURI()
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	rec := &renderRecorder{header: make(http.Header)}
+	h.ServeHTTP(rec, req)
+	return rec, rendering
+}
+
+// writeEvent writes a server-sent event with v as its JSON data.
+func writeEvent(w io.Writer, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+// handlePreview streams live previews for the playground as server-sent
+// events. The stream opens with a session event naming the URL to post image
+// URLs to; each is rendered once updates pause for config.PreviewDebounce, so
+// a burst of edits renders only its last image, and previews aren't cached.
+func (s *Service) handlePreview(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.SigningKey != "" {
+		// Previews would render URLs nobody signed
+		s.failJSON(w, r, ErrFeatureDisabled.withMessage("Live previews are off on servers that require signed URLs."))
+		return
+	}
+	id, session, ok := s.previews.open()
+	if !ok {
+		w.Header().Set("Retry-After", "5")
+		s.failJSON(w, r, ErrQueueFull.withMessage("Too many previews are open. Try again shortly."))
+		return
+	}
+	defer s.previews.close(id)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	// Keep proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	if err := writeEvent(w, "session", previewSessionEvent{ID: id, UpdateURL: "/api/v1/preview/" + id}); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(config.PreviewKeepAlive)
+	defer keepAlive.Stop()
+	idle := time.NewTimer(config.PreviewIdleTimeout)
+	defer idle.Stop()
+	var debounce <-chan time.Time
+	var pending queuedPreview
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-idle.C:
+			return
+		case pending = <-session.updates:
+			debounce = time.After(config.PreviewDebounce)
+			idle.Reset(config.PreviewIdleTimeout)
+			continue
+		case <-debounce:
+			debounce = nil
+			err = writeEvent(w, "preview", s.renderPreview(r, pending.seq, pending.url))
+		case <-keepAlive.C:
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+		}
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// renderPreview renders target through the image endpoints, subject to the
+// rate and concurrency limits, without caching it.
+func (s *Service) renderPreview(r *http.Request, seq int, target string) previewEvent {
+	event := previewEvent{Seq: seq, URL: target}
+	if refusal, retryAfter, ok := s.maintenanceRefusal(); ok {
+		event.Code, event.Error, event.RetryAfter = refusal.code, refusal.message, retryAfter
+		return event
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		// Checked when the update was posted
+		event.Code, event.Error = ErrInvalidURL.code, ErrInvalidURL.message
+		return event
+	}
+	// A preview is the image itself
+	query := u.Query()
+	query.Del("async")
+	query.Del("explain")
+	u.RawQuery = query.Encode()
+
+	contentType, image, failure := s.renderInternal(r, s.previewRender, u, true)
+	if failure != nil && failure.status == http.StatusTooManyRequests && failure.code == "" {
+		failure = ErrRateLimited
+	}
+	if failure != nil {
+		event.Code, event.Error = failure.code, failure.message
+		return event
+	}
+	event.ContentType = contentType
+	event.Image = "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(image)
+	return event
+}
+
+// previewUpdate is the body of a preview update: the path and query of the
+// image to preview.
+type previewUpdate struct {
+	URL string `json:"url"`
+}
+
+// handlePreviewUpdate queues an image URL for a preview stream.
+func (s *Service) handlePreviewUpdate(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.previews.get(r.PathValue("id"))
+	if !ok {
+		s.failJSON(w, r, ErrNotFound.withMessage("No such preview. Open one at /api/v1/preview first."))
+		return
+	}
+	var update previewUpdate
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, config.MaxPreviewUpdateBytes)).Decode(&update); err != nil {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("Invalid update. Send a JSON object with the url of the image to preview."))
+		return
+	}
+	u, err := url.Parse(update.URL)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		s.failJSON(w, r, ErrInvalidURL.withMessage("Invalid url. Send the path and query of an image on this server, such as /placeholder/400x300?text=Hi."))
+		return
+	}
+	session.send(update.URL)
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/middleware"
+	"grout/internal/render"
+)
+
+// readEvent reads the next server-sent event from a preview stream, skipping
+// comments.
+func readEvent(t *testing.T, stream *bufio.Reader, v any) string {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && name != "":
+			if err := json.Unmarshal([]byte(data), v); err != nil {
+				t.Fatalf("decode %s event: %v", name, err)
+			}
+			return name
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func postPreview(t *testing.T, server *httptest.Server, updateURL, body string) int {
+	t.Helper()
+	resp, err := http.Post(server.URL+updateURL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post update: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestPreviewStream(t *testing.T) {
+	svc, mux := setupTestService(t)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/preview")
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", resp.Header.Get("Content-Type"))
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	var session previewSessionEvent
+	if name := readEvent(t, stream, &session); name != "session" || session.UpdateURL != "/api/v1/preview/"+session.ID {
+		t.Fatalf("expected a session event, got %s %+v", name, session)
+	}
+
+	// A burst of edits renders only the last
+	for _, text := range []string{"H", "He", "Hello"} {
+		if code := postPreview(t, server, session.UpdateURL, `{"url": "/placeholder/200x100.png?text=`+text+`"}`); code != http.StatusAccepted {
+			t.Fatalf("expected the update to be accepted, got %d", code)
+		}
+	}
+	var preview previewEvent
+	if name := readEvent(t, stream, &preview); name != "preview" {
+		t.Fatalf("expected a preview event, got %s", name)
+	}
+	if preview.Seq != 3 || preview.URL != "/placeholder/200x100.png?text=Hello" {
+		t.Errorf("expected the last update to be rendered, got seq %d for %s", preview.Seq, preview.URL)
+	}
+	if preview.ContentType != "image/png" || !strings.HasPrefix(preview.Image, "data:image/png;base64,iVBOR") {
+		t.Errorf("expected a PNG data URI, got %q %.40s", preview.ContentType, preview.Image)
+	}
+	if stats := svc.defaultTheme.cache.Stats(); stats.Entries != 0 {
+		t.Errorf("expected previews not to be cached, got %d entries", stats.Entries)
+	}
+
+	postPreview(t, server, session.UpdateURL, `{"url": "/placeholder/200x100.png?effect=glitter"}`)
+	if readEvent(t, stream, &preview); preview.Code != "invalid_parameter" || !strings.Contains(preview.Error, "Invalid effect") {
+		t.Errorf("expected the endpoint's error in the preview, got %+v", preview)
+	}
+}
+
+func TestPreviewRateLimit(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](1)
+	svc := NewService(renderer, cache, config.DefaultServerConfig())
+	mux := http.NewServeMux()
+	// One request a minute, so only the first preview renders
+	svc.RegisterRoutes(mux, middleware.NewRateLimiter(1, 1))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/preview")
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+	var session previewSessionEvent
+	readEvent(t, stream, &session)
+
+	var preview previewEvent
+	postPreview(t, server, session.UpdateURL, `{"url": "/placeholder/200x100.png?text=One"}`)
+	if readEvent(t, stream, &preview); preview.Image == "" {
+		t.Fatalf("expected the first preview to render, got %+v", preview)
+	}
+	postPreview(t, server, session.UpdateURL, `{"url": "/placeholder/200x100.png?text=Two"}`)
+	preview = previewEvent{}
+	if readEvent(t, stream, &preview); preview.Code != "rate_limited" || preview.Image != "" {
+		t.Errorf("expected the second preview to be rate limited, got %+v", preview)
+	}
+}
+
+func TestPreviewMaintenance(t *testing.T) {
+	svc, mux := setupTestService(t)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/preview")
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+	var session previewSessionEvent
+	readEvent(t, stream, &session)
+
+	svc.maintenance.set(maintenanceState{Enabled: true, Message: "Back soon", RetryAfter: 900})
+	postPreview(t, server, session.UpdateURL, `{"url": "/placeholder/200x100.png"}`)
+	var preview previewEvent
+	if readEvent(t, stream, &preview); preview.Code != "maintenance" || preview.Error != "Back soon" || preview.RetryAfter != 900 || preview.Image != "" {
+		t.Errorf("expected the preview refused for maintenance, got %+v", preview)
+	}
+}
+
+func TestPreviewUpdateErrors(t *testing.T) {
+	svc, mux := setupTestService(t)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	id, _, _ := svc.previews.open()
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+	}{
+		{"unknown session", "/api/v1/preview/nope", `{"url": "/placeholder/100x100"}`, http.StatusNotFound},
+		{"not JSON", "/api/v1/preview/" + id, `/placeholder/100x100`, http.StatusBadRequest},
+		{"absolute URL", "/api/v1/preview/" + id, `{"url": "https://example.com/a.png"}`, http.StatusBadRequest},
+		{"other host", "/api/v1/preview/" + id, `{"url": "//example.com/a.png"}`, http.StatusBadRequest},
+		{"valid", "/api/v1/preview/" + id, `{"url": "/avatar/JD"}`, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := postPreview(t, server, tt.target, tt.body); code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, code)
+			}
+		})
+	}
+
+	signed := httptest.NewServer(setupSigningTestService(t))
+	defer signed.Close()
+	resp, err := http.Get(signed.URL + "/api/v1/preview")
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("X-Error-Code") != "feature_disabled" {
+		t.Errorf("expected previews to be off with signing, got %d %q", resp.StatusCode, resp.Header.Get("X-Error-Code"))
+	}
+}
+
+func TestPreviewSessionSend(t *testing.T) {
+	session := newPreviewSession()
+	session.send("/a")
+	session.send("/b")
+	if got := <-session.updates; got != (queuedPreview{seq: 2, url: "/b"}) {
+		t.Errorf("expected a newer update to replace a waiting one, got %+v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"grout/internal/kvstore"
+	"grout/internal/render"
+)
+
+// provenanceKeyPrefix namespaces provenance records in the store.
+const provenanceKeyPrefix = "provenance:"
+
+// provenanceSigner signs the provenance manifests of the images this instance
+// serves.
+type provenanceSigner struct {
+	key ed25519.PrivateKey
+	// keyID names the public key in manifests: the hex of the first 8 bytes of
+	// its SHA-256
+	keyID string
+}
+
+// newProvenanceSigner derives an Ed25519 key from secret, so the instance keeps
+// its key across restarts. It returns nil when secret is empty.
+func newProvenanceSigner(secret string) *provenanceSigner {
+	if secret == "" {
+		return nil
+	}
+	seed := sha256.Sum256([]byte(secret))
+	key := ed25519.NewKeyFromSeed(seed[:])
+	sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	return &provenanceSigner{key: key, keyID: hex.EncodeToString(sum[:8])}
+}
+
+func (p *provenanceSigner) publicKey() string {
+	return base64.StdEncoding.EncodeToString(p.key.Public().(ed25519.PublicKey))
+}
+
+// provenanceManifest says which instance served an image, and from what.
+type provenanceManifest struct {
+	Generator string `json:"generator"`
+	Format    string `json:"format"`
+	// ParamsHash is the hex SHA-256 of the canonical image URL, less the
+	// parameters that don't change the image
+	ParamsHash string `json:"params_hash"`
+	// ContentHash is the hex SHA-256 of the image as served
+	ContentHash string    `json:"content_hash"`
+	CreatedAt   time.Time `json:"created_at"`
+	KeyID       string    `json:"key_id"`
+}
+
+// provenanceRecord is a signed manifest as stored and served. The signature
+// covers the manifest's JSON as encoded in Payload.
+type provenanceRecord struct {
+	Manifest  provenanceManifest `json:"manifest"`
+	Payload   string             `json:"payload"`
+	Algorithm string             `json:"algorithm"`
+	Signature string             `json:"signature"`
+	PublicKey string             `json:"public_key"`
+}
+
+// provenanceKeyResponse is the JSON body of /provenance/key.
+type provenanceKeyResponse struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+// provenancePath returns the path of the provenance record of the image with
+// the given ETag.
+func provenancePath(etag string) string {
+	return "/provenance/" + strings.Trim(etag, `"`)
+}
+
+// recordProvenance signs and stores the manifest of an image served under etag,
+// unless the stored one already covers the same bytes and key. A new render
+// can differ from the last, through random content or an embedded request ID,
+// so the record follows the image last served. Failures are logged: the image
+// is served either way.
+func (s *Service) recordProvenance(r *http.Request, etag string, format render.ImageFormat, data []byte) {
+	key := provenanceKeyPrefix + strings.Trim(etag, `"`)
+	content := sha256.Sum256(data)
+	contentHash := hex.EncodeToString(content[:])
+	if stored, err := s.store.Get(r.Context(), key); err == nil {
+		var existing provenanceRecord
+		if json.Unmarshal(stored, &existing) == nil && existing.Manifest.ContentHash == contentHash && existing.Manifest.KeyID == s.provenance.keyID {
+			return
+		}
+	}
+	params := sha256.Sum256([]byte(canonicalImageURL(r)))
+	manifest := provenanceManifest{
+		Generator:   "grout",
+		Format:      string(format),
+		ParamsHash:  hex.EncodeToString(params[:]),
+		ContentHash: contentHash,
+		CreatedAt:   s.now(),
+		KeyID:       s.provenance.keyID,
+	}
+	payload, err := json.Marshal(manifest)
+	if err == nil {
+		var record []byte
+		record, err = json.Marshal(provenanceRecord{
+			Manifest:  manifest,
+			Payload:   base64.StdEncoding.EncodeToString(payload),
+			Algorithm: "ed25519",
+			Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(s.provenance.key, payload)),
+			PublicKey: s.provenance.publicKey(),
+		})
+		if err == nil {
+			err = s.store.Set(r.Context(), key, record, 0)
+		}
+	}
+	if err != nil {
+		log.Printf("record provenance %s: %v", etag, err)
+	}
+}
+
+// handleProvenance serves the signed manifest of the image with an ETag, so
+// systems downstream can check an asset came from this instance.
+func (s *Service) handleProvenance(w http.ResponseWriter, r *http.Request) {
+	if s.provenance == nil {
+		s.failJSON(w, r, ErrFeatureDisabled.withMessage("provenance manifests are not enabled on this server"))
+		return
+	}
+	data, err := s.store.Get(r.Context(), provenanceKeyPrefix+strings.Trim(r.PathValue("etag"), `"`))
+	if errors.Is(err, kvstore.ErrNotFound) {
+		s.failJSON(w, r, ErrNotFound.withMessage("no provenance for this ETag: the image hasn't been served since provenance was enabled"))
+		return
+	}
+	if err != nil {
+		s.failJSON(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(data)
+}
+
+// handleProvenanceKey serves the public key provenance manifests are signed
+// with.
+func (s *Service) handleProvenanceKey(w http.ResponseWriter, r *http.Request) {
+	if s.provenance == nil {
+		s.failJSON(w, r, ErrFeatureDisabled.withMessage("provenance manifests are not enabled on this server"))
+		return
+	}
+	writeJSON(w, http.StatusOK, provenanceKeyResponse{KeyID: s.provenance.keyID, Algorithm: "ed25519", PublicKey: s.provenance.publicKey()})
+}
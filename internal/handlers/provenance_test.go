@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setupProvenanceTestService(t *testing.T) *http.ServeMux {
+	t.Helper()
+	svc, _ := setupTestService(t)
+	svc.provenance = newProvenanceSigner("provenance secret")
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return mux
+}
+
+// provenanceLink returns the provenance path in an image response's Link
+// header.
+func provenanceLink(rec *httptest.ResponseRecorder) string {
+	for _, link := range rec.Header().Values("Link") {
+		if target, ok := strings.CutSuffix(link, `>; rel="provenance"`); ok {
+			return strings.TrimPrefix(target, "<")
+		}
+	}
+	return ""
+}
+
+func TestProvenance(t *testing.T) {
+	mux := setupProvenanceTestService(t)
+	var key provenanceKeyResponse
+	if rec := getJSON(t, mux, "/provenance/key", &key); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the key, got %d", rec.Code)
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(key.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		t.Fatalf("invalid public key %q: %v", key.PublicKey, err)
+	}
+
+	target := "/placeholder/300x200.png?text=Hi&async=false"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	path := provenanceLink(rec)
+	if expected := provenancePath(rec.Header().Get("ETag")); path != expected {
+		t.Fatalf("expected a provenance link to %s, got %q", expected, rec.Header().Values("Link"))
+	}
+
+	var record provenanceRecord
+	if rec := getJSON(t, mux, path, &record); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the manifest, got %d", rec.Code)
+	}
+	payload, _ := base64.StdEncoding.DecodeString(record.Payload)
+	signature, _ := base64.StdEncoding.DecodeString(record.Signature)
+	if !ed25519.Verify(publicKey, payload, signature) {
+		t.Fatal("the signature doesn't verify with the published key")
+	}
+	var signed provenanceManifest
+	if err := json.Unmarshal(payload, &signed); err != nil || signed != record.Manifest {
+		t.Fatalf("expected the payload to be the manifest, got %s (%v)", payload, err)
+	}
+	content := sha256.Sum256(rec.Body.Bytes())
+	params := sha256.Sum256([]byte("/placeholder/300x200.png?text=Hi"))
+	manifest := record.Manifest
+	if manifest.ContentHash != hex.EncodeToString(content[:]) {
+		t.Errorf("content hash %s doesn't match the image", manifest.ContentHash)
+	}
+	if manifest.ParamsHash != hex.EncodeToString(params[:]) {
+		t.Errorf("expected the params hash of the canonical URL, got %s", manifest.ParamsHash)
+	}
+	if manifest.Generator != "grout" || manifest.Format != "png" || manifest.KeyID != key.KeyID || manifest.CreatedAt.IsZero() {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	// A cached copy is the same image, so it keeps its record
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	var again provenanceRecord
+	getJSON(t, mux, path, &again)
+	if again != record {
+		t.Errorf("expected the record to be kept for a cached copy, got %+v", again)
+	}
+}
+
+func TestProvenanceErrors(t *testing.T) {
+	_, off := setupTestService(t)
+	rec := httptest.NewRecorder()
+	off.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/100x100", nil))
+	if link := provenanceLink(rec); link != "" {
+		t.Errorf("expected no provenance link when disabled, got %q", link)
+	}
+
+	tests := []struct {
+		name string
+		mux  *http.ServeMux
+		path string
+		code string
+	}{
+		{"disabled", off, "/provenance/0123", "feature_disabled"},
+		{"disabled key", off, "/provenance/key", "feature_disabled"},
+		{"unknown ETag", setupProvenanceTestService(t), "/provenance/0123", "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]string
+			rec := getJSON(t, tt.mux, tt.path, &body)
+			if rec.Code != http.StatusNotFound || body["code"] != tt.code {
+				t.Errorf("expected 404 %s, got %d %v", tt.code, rec.Code, body)
+			}
+		})
+	}
+}
+
+func TestProvenanceSignerDerivation(t *testing.T) {
+	a, b := newProvenanceSigner("one"), newProvenanceSigner("one")
+	if a.publicKey() != b.publicKey() || a.keyID != b.keyID {
+		t.Error("expected the same secret to give the same key")
+	}
+	if c := newProvenanceSigner("two"); c.publicKey() == a.publicKey() || c.keyID == a.keyID {
+		t.Error("expected another secret to give another key")
+	}
+	if newProvenanceSigner("") != nil {
+		t.Error("expected no signer without a secret")
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/utils"
+)
+
+func (s *Service) handleRating(w http.ResponseWriter, r *http.Request) {
+	pathValue := strings.TrimPrefix(r.URL.Path, "/rating/")
+
+	// Extract format from path
+	format, pathValue, err := extractFormat(pathValue)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	format = emailFormat(r, format)
+
+	value, err := strconv.ParseFloat(pathValue, 64)
+	if err != nil {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid rating. Use a number such as /rating/3.5."))
+		return
+	}
+
+	maxStars := utils.ParseIntOrDefault(r.URL.Query().Get("max"), config.DefaultRatingMax)
+	if maxStars > config.MaxRatingStars {
+		maxStars = config.MaxRatingStars
+	}
+	size := utils.ParseIntOrDefault(r.URL.Query().Get("size"), config.DefaultRatingSize)
+	r = withRenderCost(r, renderCost{width: size * maxStars, height: size})
+	value = render.RoundRating(value, maxStars)
+
+	fillHex, err := queryColor(r, "color")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if fillHex == "" {
+		fillHex = s.themeFor(r).ratingColor
+	}
+	emptyHex, err := queryColor(r, "empty")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if emptyHex == "" {
+		emptyHex = config.DefaultRatingEmptyColor
+	}
+	// Transparent by default; JPEG has no alpha channel so fall back to white
+	bgHex, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if bgHex == "" && (format == render.FormatJPG || format == render.FormatJPEG) {
+		bgHex = "ffffff"
+	}
+
+	r = withAltText(r, fmt.Sprintf("Rated %s out of %d stars", strconv.FormatFloat(value, 'f', -1, 64), maxStars), "")
+	key := specKey("RATING", ratingSpec{Value: value, Max: maxStars, Size: size, Fill: fillHex, Empty: emptyHex, Bg: bgHex, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawRating(ctx, value, maxStars, size, fillHex, emptyHex, bgHex, format)
+	})
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRatingHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"SVG default", "/rating/3.5", http.StatusOK, "image/svg+xml"},
+		{"PNG with options", "/rating/4.png?max=5&size=32&color=ff0000", http.StatusOK, "image/png"},
+		{"JPG gets white background", "/rating/2.jpg", http.StatusOK, "image/jpeg"},
+		{"Max is capped", "/rating/50?max=500", http.StatusOK, "image/svg+xml"},
+		{"Invalid value", "/rating/abc", http.StatusBadRequest, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
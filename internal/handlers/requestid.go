@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// requestIDRegex limits request IDs to characters that are safe in headers, logs,
+// and PNG text chunks.
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestIDMetadataKey is the PNG text keyword a rendering request's ID is stored under.
+const requestIDMetadataKey = "Request-ID"
+
+// requestID returns the request's X-Request-ID header, or "" when it's missing or
+// has unsafe characters.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get("X-Request-ID"); requestIDRegex.MatchString(id) {
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func getWithRequestID(mux *http.ServeMux, target, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("X-Request-ID", id)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequestIDMetadata(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.EmbedRequestID = true
+
+	first := getWithRequestID(mux, "/avatar/Jane%20Doe.png", "req-1")
+	if first.Header().Get("X-Request-ID") != "req-1" {
+		t.Fatalf("expected the request ID echoed, got %q", first.Header().Get("X-Request-ID"))
+	}
+	if !bytes.Contains(first.Body.Bytes(), []byte("Request-ID\x00req-1")) {
+		t.Fatal("expected the request ID in the PNG metadata")
+	}
+
+	// A cache hit keeps the ID of the request that rendered the image
+	second := getWithRequestID(mux, "/avatar/Jane%20Doe.png", "req-2")
+	if second.Header().Get("X-Cache") != "HIT" || second.Header().Get("X-Request-ID") != "req-2" {
+		t.Fatalf("expected a cache hit echoing req-2, got %s %q", second.Header().Get("X-Cache"), second.Header().Get("X-Request-ID"))
+	}
+	if !bytes.Contains(second.Body.Bytes(), []byte("Request-ID\x00req-1")) {
+		t.Fatal("expected the cached PNG to keep the originating request ID")
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		id     string
+		header string
+	}{
+		{"SVG is not tagged", "/avatar/Jane%20Doe.svg", "req-3", "req-3"},
+		{"unsafe ID is ignored", "/avatar/John%20Roe.png", "req 4\x01", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getWithRequestID(mux, tt.target, tt.id)
+			if rec.Header().Get("X-Request-ID") != tt.header {
+				t.Fatalf("expected header %q got %q", tt.header, rec.Header().Get("X-Request-ID"))
+			}
+			if bytes.Contains(rec.Body.Bytes(), []byte("Request-ID\x00")) {
+				t.Fatal("expected no request ID metadata")
+			}
+		})
+	}
+}
+
+func TestRequestIDMetadataDisabled(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := getWithRequestID(mux, "/avatar/Jane%20Doe.png", "req-1")
+	if rec.Header().Get("X-Request-ID") != "" || bytes.Contains(rec.Body.Bytes(), []byte("Request-ID")) {
+		t.Fatal("expected request IDs to be ignored unless enabled")
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"grout/internal/config"
+	"grout/internal/remote"
+	"grout/internal/render"
+	"grout/internal/utils"
+)
+
+// validateProxyURL checks that the image proxy is enabled and rawURL may be fetched,
+// writing an error page and returning false otherwise.
+func (s *Service) validateProxyURL(w http.ResponseWriter, r *http.Request, rawURL string) bool {
+	if !s.fetcher.Enabled() {
+		s.fail(w, r, ErrFeatureDisabled.withMessage("The image proxy is not enabled on this server."))
+		return false
+	}
+	if _, err := s.fetcher.Validate(rawURL); err != nil {
+		if errors.Is(err, remote.ErrHostNotAllowed) {
+			s.fail(w, r, ErrHostNotAllowed)
+			return false
+		}
+		s.fail(w, r, ErrInvalidURL.withCause(err))
+		return false
+	}
+	return true
+}
+
+// fetchError maps a failed fetch of a remote image in an image's generator to
+// the error /api/v1/palette answers the same failure with: the allowlist and
+// URL errors of validateProxyURL for redirects and bad URLs, and a 502
+// upstream_failed for anything the remote host did, including not answering
+// within the fetcher's timeout. Only the render's own ctx ending makes it a
+// render timeout.
+func fetchError(ctx context.Context, err error) error {
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case errors.Is(err, remote.ErrHostNotAllowed), errors.Is(err, remote.ErrInvalidURL):
+		return err
+	default:
+		return ErrUpstreamFailed.withCause(err)
+	}
+}
+
+func (s *Service) handleResize(w http.ResponseWriter, r *http.Request) {
+	rawURL := r.URL.Query().Get("url")
+	if !s.validateProxyURL(w, r, rawURL) {
+		return
+	}
+
+	// Raster output only; PNG unless another format is requested
+	format := render.FormatPNG
+	if formatParam := r.URL.Query().Get("format"); formatParam != "" {
+		var ok bool
+		format, ok = parseFormatParam(formatParam)
+		if !ok || format == render.FormatSVG {
+			s.fail(w, r, ErrUnsupportedFormat.withMessage("Invalid format. Use png, jpg, gif, or webp."))
+			return
+		}
+	}
+
+	fit := render.FitMode(r.URL.Query().Get("fit"))
+	if fit == "" {
+		fit = render.FitCover
+	}
+	if fit != render.FitCover && fit != render.FitContain && fit != render.FitSmart {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid fit. Use cover, contain, or smart."))
+		return
+	}
+
+	// Zero means "derive from the other side" so the aspect ratio is preserved
+	width := min(utils.ParseIntOrDefault(r.URL.Query().Get("w"), 0), config.MaxResizeDimension)
+	height := min(utils.ParseIntOrDefault(r.URL.Query().Get("h"), 0), config.MaxResizeDimension)
+
+	// Transparent padding by default; JPEG has no alpha channel so fall back to white
+	bgHex, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if bgHex == "" && (format == render.FormatJPG || format == render.FormatJPEG) {
+		bgHex = "ffffff"
+	}
+
+	format = emailFormat(r, format)
+	email := emailSafe(r)
+
+	key := specKey("RESIZE", resizeSpec{URL: rawURL, Width: width, Height: height, Fit: fit, Bg: bgHex, Email: email, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		img, err := s.fetcher.FetchImage(ctx, rawURL)
+		if err != nil {
+			return nil, fetchError(ctx, err)
+		}
+		bounds := img.Bounds()
+		tw, th := render.ResizeDimensions(bounds.Dx(), bounds.Dy(), width, height)
+		// A side derived from the aspect ratio can run past the maximum too
+		tw, th = fitWithin(tw, th, config.MaxResizeDimension)
+		if email {
+			tw, th = fitWithin(tw, th, config.EmailMaxDimension)
+		}
+		return render.ResizeImage(ctx, img, tw, th, fit, bgHex, format)
+	})
+}
+
+// servePhotoAvatar fetches a remote photo and serves it as an avatar cropped around its subject.
+// Non-empty initials are drawn over the photo in fgHex, or in a color contrasting with the
+// photo when fgHex is empty.
+func (s *Service) servePhotoAvatar(w http.ResponseWriter, r *http.Request, rawURL string, size int, rounded, bold bool, initials, fgHex string, format render.ImageFormat) {
+	if !s.validateProxyURL(w, r, rawURL) {
+		return
+	}
+
+	key := specKey("AvatarPhoto", photoAvatarSpec{URL: rawURL, Size: size, Rounded: rounded, Bold: bold, Initials: initials, Fg: fgHex, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		img, err := s.fetcher.FetchImage(ctx, rawURL)
+		if err != nil {
+			return nil, fetchError(ctx, err)
+		}
+		if initials != "" {
+			return s.renderer.DrawPhotoAvatarWithInitials(ctx, img, size, rounded, bold, initials, fgHex, format)
+		}
+		return render.DrawPhotoAvatar(ctx, img, size, rounded, format)
+	})
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/remote"
+	"grout/internal/render"
+)
+
+// newImageServer serves a solid 200x100 PNG at /photo.png.
+func newImageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			img.Set(x, y, color.RGBA{R: 30, G: 136, B: 229, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/photo.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(buf.Bytes())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// setupProxyTestService creates a test service that may fetch from the local test server.
+func setupProxyTestService(t *testing.T) *http.ServeMux {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](10)
+	cfg := config.DefaultServerConfig()
+	cfg.ProxyAllowedHosts = []string{"127.0.0.1"}
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return mux
+}
+
+func TestResizeHandler(t *testing.T) {
+	srv := newImageServer(t)
+	mux := setupProxyTestService(t)
+	photo := url.QueryEscape(srv.URL + "/photo.png")
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		w, h        int
+	}{
+		{"cover PNG", "/resize?url=" + photo + "&w=50&h=50", http.StatusOK, "image/png", 50, 50},
+		{"width only keeps aspect", "/resize?url=" + photo + "&w=100", http.StatusOK, "image/png", 100, 50},
+		{"derived side past the maximum keeps aspect", "/resize?url=" + photo + "&h=3000", http.StatusOK, "image/png", config.MaxResizeDimension, config.MaxResizeDimension / 2},
+		{"contain JPG", "/resize?url=" + photo + "&w=80&h=80&fit=contain&format=jpg", http.StatusOK, "image/jpeg", 80, 80},
+		{"smart crop", "/resize?url=" + photo + "&w=60&h=60&fit=smart", http.StatusOK, "image/png", 60, 60},
+		{"invalid fit", "/resize?url=" + photo + "&fit=stretch", http.StatusBadRequest, "text/html; charset=utf-8", 0, 0},
+		{"svg not allowed", "/resize?url=" + photo + "&format=svg", http.StatusBadRequest, "text/html; charset=utf-8", 0, 0},
+		{"missing url", "/resize", http.StatusBadRequest, "text/html; charset=utf-8", 0, 0},
+		{"host not allowed", "/resize?url=" + url.QueryEscape("https://example.com/a.png"), http.StatusForbidden, "text/html; charset=utf-8", 0, 0},
+		{"upstream missing", "/resize?url=" + url.QueryEscape(srv.URL+"/nope.png"), http.StatusBadGateway, "text/html; charset=utf-8", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			if tt.status == http.StatusBadGateway && rec.Header().Get("X-Error-Code") != "upstream_failed" {
+				t.Fatalf("expected upstream_failed, as from the palette API, got %q", rec.Header().Get("X-Error-Code"))
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			cfg, _, err := image.DecodeConfig(rec.Body)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if cfg.Width != tt.w || cfg.Height != tt.h {
+				t.Fatalf("expected %dx%d got %dx%d", tt.w, tt.h, cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
+
+func TestFetchError(t *testing.T) {
+	live := context.Background()
+	timedOut, cancel := context.WithTimeout(live, 0)
+	defer cancel()
+	// What http.Client returns when its own timeout passes
+	clientTimeout := fmt.Errorf("fetch: %w", context.DeadlineExceeded)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want error
+	}{
+		{"fetcher timeout", live, clientTimeout, ErrUpstreamFailed},
+		{"render timeout", timedOut, clientTimeout, context.DeadlineExceeded},
+		{"host not allowed", live, remote.ErrHostNotAllowed, remote.ErrHostNotAllowed},
+		{"upstream failure", live, errors.New("404 Not Found"), ErrUpstreamFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fetchError(tt.ctx, tt.err); !errors.Is(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResizeHandlerDisabledByDefault(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/resize?url=https://example.com/a.png", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rec.Code)
+	}
+}
+
+func TestAvatarHandlerPhotoMode(t *testing.T) {
+	srv := newImageServer(t)
+	mux := setupProxyTestService(t)
+	photo := url.QueryEscape(srv.URL + "/photo.png")
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"SVG photo", "/avatar/?url=" + photo + "&size=64", http.StatusOK, "image/svg+xml"},
+		{"rounded PNG photo", "/avatar/Jane.png?url=" + photo + "&size=64&rounded=true", http.StatusOK, "image/png"},
+		{"host not allowed", "/avatar/?url=" + url.QueryEscape("https://example.com/me.jpg"), http.StatusForbidden, "text/html; charset=utf-8"},
+		{"PNG initials overlay", "/avatar/Jane%20Doe.png?url=" + photo + "&size=64&overlay=initials", http.StatusOK, "image/png"},
+		{"invalid overlay", "/avatar/Jane%20Doe?url=" + photo + "&overlay=badge", http.StatusBadRequest, "text/html; charset=utf-8"},
+		{"upstream missing", "/avatar/Jane.png?url=" + url.QueryEscape(srv.URL+"/nope.png"), http.StatusBadGateway, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
+
+func TestPhotoAvatarInitialsOverlay(t *testing.T) {
+	srv := newImageServer(t)
+	mux := setupProxyTestService(t)
+	photo := url.QueryEscape(srv.URL + "/photo.png")
+
+	tests := []struct {
+		name   string
+		path   string
+		expect string
+	}{
+		// The test photo is a mid blue, dark enough for white text
+		{"contrasting color", "/avatar/Jane%20Doe?url=" + photo + "&size=64&overlay=initials", `fill="#ffffff" text-anchor="middle" dominant-baseline="middle">JD</text>`},
+		{"explicit color", "/avatar/Jane%20Doe?url=" + photo + "&size=64&overlay=initials&color=ff0000", `fill="#ff0000" text-anchor="middle" dominant-baseline="middle">JD</text>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.expect) {
+				t.Fatalf("expected the initials overlay %q, got: %.300s", tt.expect, rec.Body.String())
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/Jane%20Doe?url="+photo+"&size=64", nil))
+	if strings.Contains(rec.Body.String(), "<text") {
+		t.Fatal("expected no overlay without overlay=initials")
+	}
+}
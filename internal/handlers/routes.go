@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"grout/internal/middleware"
+)
+
+// crawlPolicy controls how a route is listed in robots.txt.
+type crawlPolicy int
+
+const (
+	crawlUnlisted crawlPolicy = iota
+	crawlAllow
+	crawlDisallow
+)
+
+// route is an entry in the route registry. RegisterRoutes mounts every route, and
+// robots.txt and sitemap.xml are generated from the same list so they can't drift.
+type route struct {
+	method      string // Empty matches any method
+	path        string
+	handler     http.HandlerFunc
+	rateLimited bool
+	// unsigned serves a rate-limited route without a signature when signing
+	// is on
+	unsigned bool
+	// priority orders the route's requests waiting for a concurrency slot:
+	// cheap, user-facing images go before photos and batch work
+	priority middleware.Priority
+	crawl    crawlPolicy
+	// page lists the route in sitemap.xml when set
+	page *sitemapPage
+}
+
+// sitemapPage holds the sitemap metadata of an HTML page.
+type sitemapPage struct {
+	changeFreq string
+	priority   float64
+}
+
+// pattern returns the ServeMux pattern of the route.
+func (rt route) pattern() string {
+	if rt.method == "" {
+		return rt.path
+	}
+	return rt.method + " " + rt.path
+}
+
+// routes returns the route registry.
+func (s *Service) routes() []route {
+	return []route{
+		{path: "/", handler: s.handleHome, crawl: crawlAllow, page: &sitemapPage{changeFreq: "monthly", priority: 1.0}},
+		{path: "/play", handler: s.handlePlay, crawl: crawlAllow, page: &sitemapPage{changeFreq: "monthly", priority: 0.8}},
+		// Image generation endpoints are rate limited
+		{path: "/avatar/", handler: s.handleAvatar, rateLimited: true, priority: middleware.PriorityHigh, crawl: crawlAllow},
+		{path: "/avatars/", handler: s.handleFacepile, rateLimited: true, priority: middleware.PriorityHigh},
+		{path: "/placeholder/", handler: s.handlePlaceholder, rateLimited: true, crawl: crawlAllow},
+		{path: "/calendar/", handler: s.handleCalendar, rateLimited: true},
+		{path: "/rating/", handler: s.handleRating, rateLimited: true},
+		{path: "/text/", handler: s.handleText, rateLimited: true},
+		{path: "/divider/", handler: s.handleDivider, rateLimited: true},
+		{path: "/table/", handler: s.handleTable, rateLimited: true},
+		{path: "/spinner/", handler: s.handleSpinner, rateLimited: true},
+		// Compatibility with placehold.co and Lorem Picsum /{width}/{height} URLs; other
+		// root paths get a 404
+		{path: "/{size}", handler: s.handlePlaceholdCo, rateLimited: true},
+		{path: "/{size}/", handler: s.handlePlaceholdCo, rateLimited: true},
+		// Compatibility with Lorem Picsum URLs
+		{path: "/id/", handler: s.handlePicsum, rateLimited: true, priority: middleware.PriorityLow},
+		{path: "/seed/", handler: s.handlePicsum, rateLimited: true, priority: middleware.PriorityLow},
+		// Compatibility with ui-avatars.com URLs
+		{path: "/api/", handler: s.handleUIAvatars, rateLimited: true, priority: middleware.PriorityHigh},
+		// Compatibility with DiceBear URLs
+		{path: "/7.x/", handler: s.handleDiceBear, rateLimited: true, priority: middleware.PriorityHigh},
+		{path: "/8.x/", handler: s.handleDiceBear, rateLimited: true, priority: middleware.PriorityHigh},
+		{path: "/9.x/", handler: s.handleDiceBear, rateLimited: true, priority: middleware.PriorityHigh},
+		// Compatibility with shields.io endpoint badges
+		{method: http.MethodGet, path: "/endpoint", handler: s.handleEndpointBadge, rateLimited: true, crawl: crawlDisallow},
+		{method: http.MethodGet, path: "/endpoint.svg", handler: s.handleEndpointBadge, rateLimited: true},
+		{method: http.MethodGet, path: "/endpoint.png", handler: s.handleEndpointBadge, rateLimited: true},
+		{path: "/resize", handler: s.handleResize, rateLimited: true, priority: middleware.PriorityLow, crawl: crawlDisallow},
+		{method: http.MethodGet, path: "/api/v1/palette", handler: s.handlePalette, rateLimited: true, priority: middleware.PriorityLow, crawl: crawlDisallow},
+		{method: http.MethodGet, path: "/api/v1/diff", handler: s.handleDiff, rateLimited: true, priority: middleware.PriorityLow, crawl: crawlDisallow},
+		{method: http.MethodPost, path: "/api/v1/render", handler: s.handleRender, rateLimited: true},
+		// Validation checks the signature of the URL it's given rather than its own
+		{method: http.MethodGet, path: "/api/v1/validate", handler: s.handleValidate, rateLimited: true, unsigned: true, crawl: crawlDisallow},
+		// Tool calls check their own credentials when signing is on
+		{method: http.MethodPost, path: "/api/v1/mcp", handler: s.handleMCP, rateLimited: true, unsigned: true},
+		{path: "/t/", handler: s.handleTemplate, rateLimited: true},
+		{path: "/certificate/", handler: s.handleCertificate, rateLimited: true},
+		{path: "/ticket/", handler: s.handleTicket, rateLimited: true},
+		{path: "/team/", handler: s.handleTeam, rateLimited: true},
+		// Permalinks were signed, when signing is on, as they were registered
+		{method: http.MethodGet, path: "/i/", handler: s.handlePermalink, rateLimited: true, unsigned: true},
+		// No rate limiting for health, job status, brand assets, robots.txt, sitemap.xml
+		{method: http.MethodGet, path: "/health", handler: s.HandleHealth},
+		{method: http.MethodGet, path: "/health.png", handler: s.handleHealthImage},
+		{method: http.MethodGet, path: "/version", handler: s.handleVersion},
+		{method: http.MethodGet, path: "/api/v1/themes", handler: s.handleThemes},
+		{method: http.MethodGet, path: "/api/v1/jobs/{id}", handler: s.handleJob},
+		{method: http.MethodGet, path: "/provenance/key", handler: s.handleProvenanceKey},
+		{method: http.MethodGet, path: "/provenance/{etag}", handler: s.handleProvenance},
+		// Preview streams stay open, so they can't hold a concurrency slot; each
+		// preview takes one as it renders
+		{method: http.MethodGet, path: "/api/v1/preview", handler: s.handlePreview},
+		{method: http.MethodPost, path: "/api/v1/preview/{id}", handler: s.handlePreviewUpdate},
+		{method: http.MethodGet, path: "/api/v1/admin/stats", handler: s.handleAdminStats},
+		{method: http.MethodGet, path: "/api/v1/admin/usage/{tenant}", handler: s.handleAdminUsage},
+		{method: http.MethodGet, path: "/api/v1/admin/usage/keys/{key}", handler: s.handleAdminKeyUsage},
+		{method: http.MethodGet, path: "/api/v1/admin/selftest", handler: s.handleAdminSelfTest},
+		{method: http.MethodGet, path: "/api/v1/admin/maintenance", handler: s.handleAdminMaintenance},
+		{method: http.MethodPut, path: "/api/v1/admin/maintenance", handler: s.handleAdminStartMaintenance},
+		{method: http.MethodDelete, path: "/api/v1/admin/maintenance", handler: s.handleAdminStopMaintenance},
+		{method: http.MethodGet, path: "/api/v1/admin/templates", handler: s.handleAdminTemplates},
+		{method: http.MethodPut, path: "/api/v1/admin/templates/{name}", handler: s.handleAdminPutTemplate},
+		{method: http.MethodDelete, path: "/api/v1/admin/templates/{name}", handler: s.handleAdminDeleteTemplate},
+		{method: http.MethodGet, path: "/api/v1/admin/cache/export", handler: s.handleAdminCacheExport},
+		{method: http.MethodPost, path: "/api/v1/admin/cache/import", handler: s.handleAdminCacheImport},
+		{method: http.MethodPost, path: "/api/v1/admin/avatars/warm", handler: s.handleAdminWarmAvatars},
+		{method: http.MethodGet, path: "/favicon.ico", handler: s.handleFavicon},
+		{method: http.MethodGet, path: "/logo.svg", handler: s.handleLogo},
+		{method: http.MethodGet, path: "/logo.png", handler: s.handleLogo},
+		{method: http.MethodGet, path: "/apple-touch-icon.png", handler: s.handleAppleTouchIcon},
+		{method: http.MethodGet, path: "/site.webmanifest", handler: s.handleWebManifest},
+		{method: http.MethodGet, path: "/sdk/grout.js", handler: s.handleSDK},
+		{method: http.MethodGet, path: "/sdk/grout.min.js", handler: s.handleSDK},
+		{method: http.MethodGet, path: "/robots.txt", handler: s.handleRobotsTxt},
+		{method: http.MethodGet, path: "/sitemap.xml", handler: s.handleSitemapXml},
+	}
+}
+
+// buildTime approximates when the embedded pages last changed: the VCS commit
+// time recorded in the binary, else the executable's modification time, else now.
+func buildTime() time.Time {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.time" {
+				if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+					return t.UTC()
+				}
+			}
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		if stat, err := os.Stat(exe); err == nil {
+			return stat.ModTime().UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
+// generateRobotsTxt lists the crawl policy of every registered route and points
+// crawlers at the sitemap on domain.
+func (s *Service) generateRobotsTxt(domain string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, policy := range []crawlPolicy{crawlAllow, crawlDisallow} {
+		for _, rt := range s.routes() {
+			if rt.crawl != policy {
+				continue
+			}
+			directive := "Allow"
+			if policy == crawlDisallow {
+				directive = "Disallow"
+			}
+			fmt.Fprintf(&b, "%s: %s\n", directive, rt.path)
+		}
+	}
+	b.WriteString("\n# Crawl delay\nCrawl-delay: 1\n")
+	fmt.Fprintf(&b, "\n# Sitemap\nSitemap: https://%s/sitemap.xml\n", domain)
+	return b.String()
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+}
+
+// generateSitemapXml lists every registered page under domain.
+func (s *Service) generateSitemapXml(domain string) (string, error) {
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	var lastMod string
+	if !s.builtAt.IsZero() {
+		lastMod = s.builtAt.Format("2006-01-02")
+	}
+	for _, rt := range s.routes() {
+		if rt.page == nil {
+			continue
+		}
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:        fmt.Sprintf("https://%s%s", domain, rt.path),
+			LastMod:    lastMod,
+			ChangeFreq: rt.page.changeFreq,
+			Priority:   fmt.Sprintf("%.1f", rt.page.priority),
+		})
+	}
+
+	data, err := xml.MarshalIndent(set, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRobotsTxtFromRegistry(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.StaticDir = t.TempDir()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+
+	body := rec.Body.String()
+	for _, expected := range []string{
+		"Allow: /play\n",
+		"Allow: /avatar/\n",
+		"Disallow: /resize\n",
+		"Disallow: /api/v1/palette\n",
+		"Sitemap: https://" + svc.cfg.Domain + "/sitemap.xml",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected robots.txt to contain %q, got:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, "/health") {
+		t.Error("expected unlisted routes to be left out of robots.txt")
+	}
+}
+
+func TestSitemapXmlFromRegistry(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.StaticDir = t.TempDir()
+	svc.builtAt = time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	var set sitemapURLSet
+	if err := xml.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("failed to parse sitemap: %v", err)
+	}
+
+	// Every registered page is listed, and every listed page is served
+	var pages int
+	for _, rt := range svc.routes() {
+		if rt.page != nil {
+			pages++
+		}
+	}
+	if len(set.URLs) != pages {
+		t.Fatalf("expected %d URLs got %d", pages, len(set.URLs))
+	}
+	for _, u := range set.URLs {
+		if u.LastMod != "2026-03-14" {
+			t.Errorf("expected lastmod 2026-03-14 for %s, got %s", u.Loc, u.LastMod)
+		}
+		path := strings.TrimPrefix(u.Loc, "https://"+svc.cfg.Domain)
+		page := httptest.NewRecorder()
+		mux.ServeHTTP(page, httptest.NewRequest(http.MethodGet, path, nil))
+		if page.Code != http.StatusOK {
+			t.Errorf("expected %s to be served, got %d", path, page.Code)
+		}
+	}
+}
+
+func TestSitemapXmlStaticOverride(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.StaticDir = t.TempDir()
+	custom := `<?xml version="1.0"?><urlset><url><loc>https://{{DOMAIN}}/custom</loc></url></urlset>`
+	if err := os.WriteFile(filepath.Join(svc.cfg.StaticDir, "sitemap.xml"), []byte(custom), 0o644); err != nil {
+		t.Fatalf("write sitemap: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+
+	if body := rec.Body.String(); !strings.Contains(body, "https://"+svc.cfg.Domain+"/custom") {
+		t.Fatalf("expected the static sitemap, got: %s", body)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"grout/internal/render"
+)
+
+// colorScheme selects the light or dark default colors of an image, or both.
+type colorScheme string
+
+const (
+	schemeLight colorScheme = "light"
+	schemeDark  colorScheme = "dark"
+	// schemeAuto embeds both renderings in one SVG, switched by prefers-color-scheme
+	schemeAuto colorScheme = "auto"
+)
+
+// parseScheme reads the scheme parameter, reporting false when it's invalid. auto
+// relies on SVG media queries, so raster formats fall back to light.
+func parseScheme(r *http.Request, format render.ImageFormat) (colorScheme, bool) {
+	switch scheme := colorScheme(r.URL.Query().Get("scheme")); scheme {
+	case "", schemeLight:
+		return schemeLight, true
+	case schemeDark:
+		return schemeDark, true
+	case schemeAuto:
+		if format != render.FormatSVG {
+			return schemeLight, true
+		}
+		return schemeAuto, true
+	default:
+		return "", false
+	}
+}
+
+// serveSchemed serves the rendering of scheme from draw, which renders the dark
+// variant when dark is true. cacheKey must be the key of a spec holding the
+// scheme and the colors of both variants.
+func (s *Service) serveSchemed(w http.ResponseWriter, r *http.Request, cacheKey string, scheme colorScheme, width, height int, format render.ImageFormat, draw func(ctx context.Context, dark bool) ([]byte, error)) {
+	s.serveImage(w, r, cacheKey, format, func(ctx context.Context) ([]byte, error) {
+		if scheme != schemeAuto {
+			return draw(ctx, scheme == schemeDark)
+		}
+		light, err := draw(ctx, false)
+		if err != nil {
+			return nil, err
+		}
+		dark, err := draw(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		return render.CombineSchemes(light, dark, width, height), nil
+	})
+}
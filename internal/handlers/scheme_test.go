@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSchemeDarkRaster(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		expect [3]uint32
+	}{
+		{"avatar", "/avatar/Jane%20Doe.png?size=64&scheme=dark", [3]uint32{0x3d, 0x32, 0x32}},
+		{"placeholder", "/placeholder/200x100.png?text=+&scheme=dark", [3]uint32{0x2b, 0x2b, 0x2b}},
+		{"explicit background wins", "/placeholder/200x100.png?text=+&bg=ff0000&scheme=dark", [3]uint32{0xff, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d", rec.Code)
+			}
+			img, _, err := image.Decode(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			r, g, b, _ := img.At(2, 2).RGBA()
+			if got := [3]uint32{r >> 8, g >> 8, b >> 8}; got != tt.expect {
+				t.Fatalf("expected background %x got %x", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestSchemeAutoSVG(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/avatar/Jane%20Doe?scheme=auto", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, expect := range []string{"prefers-color-scheme: dark", `class="scheme-light"`, `class="scheme-dark"`, "#3d3232", "#f0e9e9"} {
+		if !strings.Contains(body, expect) {
+			t.Errorf("expected auto SVG to contain %q, got: %s", expect, body)
+		}
+	}
+
+	// The light and dark renderings are cached apart
+	light := httptest.NewRecorder()
+	mux.ServeHTTP(light, httptest.NewRequest(http.MethodGet, "/avatar/Jane%20Doe", nil))
+	if light.Header().Get("ETag") == rec.Header().Get("ETag") {
+		t.Fatal("expected auto and light to get distinct ETags")
+	}
+}
+
+func TestSchemeAutoRasterFallsBackToLight(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	auto := httptest.NewRecorder()
+	mux.ServeHTTP(auto, httptest.NewRequest(http.MethodGet, "/placeholder/200x100.png?scheme=auto", nil))
+	light := httptest.NewRecorder()
+	mux.ServeHTTP(light, httptest.NewRequest(http.MethodGet, "/placeholder/200x100.png", nil))
+
+	if auto.Code != http.StatusOK || !bytes.Equal(auto.Body.Bytes(), light.Body.Bytes()) {
+		t.Fatalf("expected the light rendering for a raster auto request, got %d", auto.Code)
+	}
+}
+
+func TestSchemeInvalid(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	for _, path := range []string{"/avatar/Jane?scheme=sepia", "/placeholder/200x100?scheme=sepia"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 got %d", path, rec.Code)
+		}
+	}
+}
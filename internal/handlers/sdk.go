@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+//go:generate go test -run TestSDKUpToDate -update-sdk .
+
+// The URL-builder SDK is generated from the tools' parameters and checked in,
+// so frontends get a static file; TestSDKUpToDate fails when it drifts.
+
+//go:embed web/sdk/grout.js
+var sdkJS []byte
+
+//go:embed web/sdk/grout.min.js
+var sdkMinJS []byte
+
+// sdkParam is a tool parameter as the SDK checks it.
+type sdkParam struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Enum     []string `json:"enum,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	InPath   bool     `json:"inPath,omitempty"`
+}
+
+type sdkTool struct {
+	Path   string     `json:"path"`
+	Params []sdkParam `json:"params"`
+}
+
+// sdkHeader opens the SDK, up to the tools' definitions.
+const sdkHeader = `// Code generated by go generate in internal/handlers; DO NOT EDIT.
+
+// grout.js builds image URLs for a Grout server, checking options against the
+// parameters the server's tool endpoint describes. Load it with a script tag
+// for a global Grout, or require it as a CommonJS module.
+//
+// A browser can't sign URLs without giving away the signing key, so on servers
+// that require signed URLs build them on a backend instead.
+(function (root, factory) {
+  if (typeof module === "object" && module.exports) {
+    module.exports = factory();
+  } else {
+    root.Grout = factory();
+  }
+})(typeof self !== "undefined" ? self : this, function () {
+  "use strict";
+
+  // The server this script was loaded from, the default base URL
+  var scriptOrigin = "";
+  if (typeof document !== "undefined" && document.currentScript && document.currentScript.src) {
+    scriptOrigin = new URL(document.currentScript.src).origin;
+  }
+
+  // Each tool's path, with a {name} for each option in it, and its parameters
+  var tools = `
+
+// sdkBody follows the tools' definitions, up to their methods.
+const sdkBody = `;
+
+  function fail(tool, message) {
+    throw new TypeError("grout: " + tool + ": " + message);
+  }
+
+  // check returns an option as it's written in a URL, or throws when it
+  // doesn't fit its parameter
+  function check(tool, p, value) {
+    switch (p.type) {
+      case "string":
+        if (typeof value !== "string") {
+          fail(tool, p.name + " must be a string");
+        }
+        if (p.required && value === "") {
+          fail(tool, p.name + " must not be empty");
+        }
+        break;
+      case "boolean":
+        if (typeof value !== "boolean") {
+          fail(tool, p.name + " must be true or false");
+        }
+        value = String(value);
+        break;
+      default:
+        if (typeof value !== "number" || !isFinite(value)) {
+          fail(tool, p.name + " must be a number");
+        }
+        if (p.type === "integer" && value % 1 !== 0) {
+          fail(tool, p.name + " must be a whole number");
+        }
+        if (value < p.min || (p.max !== undefined && value > p.max)) {
+          fail(tool, p.name + (p.max !== undefined ? " must be from " + p.min + " to " + p.max : " must be at least " + p.min));
+        }
+        value = String(value);
+    }
+    if (p.enum && p.enum.indexOf(value) < 0) {
+      fail(tool, p.name + " must be one of " + p.enum.join(", "));
+    }
+    return value;
+  }
+
+  function build(base, name, options) {
+    var tool = tools[name];
+    options = options || {};
+    Object.keys(options).forEach(function (key) {
+      if (!tool.params.some(function (p) { return p.name === key; })) {
+        fail(name, "unknown option " + key);
+      }
+    });
+    var path = tool.path;
+    var query = [];
+    tool.params.forEach(function (p) {
+      var value = p.default || "";
+      var option = options[p.name];
+      if (option !== undefined && option !== null) {
+        value = check(name, p, option);
+      } else if (p.required) {
+        fail(name, "missing option " + p.name);
+      }
+      if (p.inPath) {
+        path = path.replace("{" + p.name + "}", encodeURIComponent(value));
+      } else if (value !== "") {
+        query.push([p.name, value]);
+      }
+    });
+    // Sorted like the server's own URLs, so the same options share a cache entry
+    query.sort(function (a, b) { return a[0] < b[0] ? -1 : 1; });
+    var search = new URLSearchParams(query).toString();
+    return base + path + (search ? "?" + search : "");
+  }
+
+  /**
+   * Grout builds image URLs for the server at baseURL, such as
+   * https://img.example.com; it defaults to the server this script came from.
+   * @constructor
+   * @param {string} [baseURL]
+   */
+  function Grout(baseURL) {
+    if (!(this instanceof Grout)) {
+      return new Grout(baseURL);
+    }
+    this.baseURL = (baseURL === undefined ? scriptOrigin : baseURL).replace(/\/+$/, "");
+  }
+`
+
+// sdkFooter closes the SDK after the tools' methods.
+const sdkFooter = `
+  return Grout;
+});
+`
+
+// generateSDK returns the source of the URL-builder SDK: the tools'
+// definitions, a method per tool, and JSDoc types of their options. Minified,
+// it drops comments and indentation.
+func generateSDK(minify bool) []byte {
+	var b strings.Builder
+	b.WriteString(sdkHeader)
+	b.WriteString("{\n")
+	for i, t := range tools {
+		def := sdkTool{Path: t.path}
+		for _, p := range t.params {
+			param := sdkParam{Name: p.name, Type: p.kind, Enum: p.enum, Required: p.required, Default: p.def, InPath: p.inPath}
+			if p.kind == "integer" || p.kind == "number" {
+				param.Min = &p.minimum
+				if p.maximum > 0 {
+					param.Max = &p.maximum
+				}
+			}
+			def.Params = append(def.Params, param)
+		}
+		data, _ := json.Marshal(def)
+		fmt.Fprintf(&b, "    %q: %s", t.name, data)
+		if i < len(tools)-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("  }")
+	b.WriteString(sdkBody)
+	for _, t := range tools {
+		b.WriteString(sdkMethod(t))
+	}
+	b.WriteString(sdkFooter)
+
+	if !minify {
+		return []byte(b.String())
+	}
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/**") || strings.HasPrefix(line, "*") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// sdkMethod returns the SDK method of a tool, with a JSDoc type of its options.
+func sdkMethod(t tool) string {
+	typeName := strings.ToUpper(t.name[:1]) + t.name[1:] + "Options"
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n  /**\n   * @typedef {Object} %s\n", typeName)
+	required := false
+	for _, p := range t.params {
+		kind := p.kind
+		switch {
+		case len(p.enum) > 0:
+			kind = `"` + strings.Join(p.enum, `"|"`) + `"`
+		case kind == "integer":
+			kind = "number"
+		}
+		name := "[" + p.name + "]"
+		if p.required {
+			name, required = p.name, true
+		}
+		description := p.description
+		if p.def != "" {
+			description += " (default " + p.def + ")"
+		}
+		fmt.Fprintf(&b, "   * @property {%s} %s %s\n", kind, name, description)
+	}
+	b.WriteString("   */\n\n")
+	options := "[options]"
+	if required {
+		options = "options"
+	}
+	fmt.Fprintf(&b, "  /**\n   * %s\n   * @param {%s} %s\n   * @returns {string} The image's URL\n   */\n", t.description, typeName, options)
+	fmt.Fprintf(&b, "  Grout.prototype.%s = function (options) {\n    return build(this.baseURL, %q, options);\n  };\n", t.name, t.name)
+	return b.String()
+}
+
+// handleSDK serves the URL-builder SDK, minified at /sdk/grout.min.js.
+func (s *Service) handleSDK(w http.ResponseWriter, r *http.Request) {
+	data := sdkJS
+	if strings.HasSuffix(r.URL.Path, ".min.js") {
+		data = sdkMinJS
+	}
+	writeBrandAsset(w, data, "text/javascript; charset=utf-8")
+}
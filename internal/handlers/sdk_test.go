@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateSDK = flag.Bool("update-sdk", false, "regenerate web/sdk from the tools' parameters")
+
+func TestSDKUpToDate(t *testing.T) {
+	files := map[string][]byte{
+		"web/sdk/grout.js":     generateSDK(false),
+		"web/sdk/grout.min.js": generateSDK(true),
+	}
+	for path, want := range files {
+		if *updateSDK {
+			if err := os.WriteFile(path, want, 0o644); err != nil {
+				t.Fatalf("write %s: %v", path, err)
+			}
+			continue
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date with the tools' parameters; run go generate ./internal/handlers", path)
+		}
+	}
+}
+
+func TestSDKRoutes(t *testing.T) {
+	_, mux := setupTestService(t)
+	for path, want := range map[string][]byte{"/sdk/grout.js": sdkJS, "/sdk/grout.min.js": sdkMinJS} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/javascript") {
+			t.Errorf("%s: expected JavaScript, got %q", path, ct)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), want) {
+			t.Errorf("%s: expected the embedded SDK", path)
+		}
+	}
+	if len(sdkMinJS) >= len(sdkJS) || bytes.Contains(sdkMinJS, []byte("@typedef")) {
+		t.Error("expected the minified SDK to drop comments")
+	}
+}
+
+// TestSDKURLs runs the SDK under Node and checks it builds the URLs the tool
+// endpoint would, and refuses the arguments it would.
+func TestSDKURLs(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	cases := []struct {
+		tool string
+		args string
+		ok   bool
+	}{
+		{"placeholder", `{}`, true},
+		{"placeholder", `{"width": 300, "height": 150, "format": "png", "text": "Hello, world", "bg": "ff5733,3357ff"}`, true},
+		{"avatar", `{"name": "José Ñuñez+1/2", "rounded": true, "size": 96}`, true},
+		{"rating", `{"value": 3.5, "max": 5}`, true},
+		{"text", `{"text": "Q3 report", "font": "mono", "format": "webp"}`, true},
+		{"spinner", `{"style": "dots", "format": "gif"}`, true},
+		{"avatar", `{}`, false},
+		{"avatar", `{"name": ""}`, false},
+		{"placeholder", `{"width": 0}`, false},
+		{"placeholder", `{"width": 1.5}`, false},
+		{"placeholder", `{"effect": "blur"}`, false},
+		{"placeholder", `{"colour": "red"}`, false},
+		{"rating", `{"value": 4, "max": 11}`, false},
+		{"spinner", `{"format": "png"}`, false},
+		{"avatar", `{"name": "Jo", "bold": "yes"}`, false},
+	}
+
+	var script strings.Builder
+	script.WriteString("const Grout = require(process.argv[1]);\nconst g = new Grout(\"\");\nconst out = [];\n")
+	for _, c := range cases {
+		script.WriteString("try { out.push(g." + c.tool + "(" + c.args + ")); } catch (e) { out.push(e instanceof TypeError ? null : String(e)); }\n")
+	}
+	script.WriteString("console.log(JSON.stringify(out));\n")
+	for _, path := range []string{"web/sdk/grout.js", "web/sdk/grout.min.js"} {
+		abs, _ := filepath.Abs(path)
+		output, err := exec.Command(node, "-e", script.String(), abs).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: node: %v\n%s", path, err, output)
+		}
+		var urls []*string
+		if err := json.Unmarshal(output, &urls); err != nil {
+			t.Fatalf("%s: decode %q: %v", path, output, err)
+		}
+		for i, c := range cases {
+			var args map[string]json.RawMessage
+			_ = json.Unmarshal([]byte(c.args), &args)
+			tl, _ := findTool(c.tool)
+			want, err := tl.imageURL(args)
+			if (err == nil) != c.ok {
+				t.Fatalf("%s %s: expected ok=%v from the tool, got %v", c.tool, c.args, c.ok, err)
+			}
+			switch {
+			case !c.ok && urls[i] != nil:
+				t.Errorf("%s: %s %s: expected a TypeError, got %q", path, c.tool, c.args, *urls[i])
+			case c.ok && urls[i] == nil:
+				t.Errorf("%s: %s %s: expected a URL, got a TypeError", path, c.tool, c.args)
+			case c.ok:
+				got, _ := http.NewRequest(http.MethodGet, *urls[i], nil)
+				if got.URL.Path != want.Path || got.URL.Query().Encode() != want.Query().Encode() {
+					t.Errorf("%s: %s %s: expected %s, got %s", path, c.tool, c.args, want, *urls[i])
+				}
+			}
+		}
+	}
+}
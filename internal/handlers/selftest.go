@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder
+	_ "image/jpeg" // register JPEG decoder
+	_ "image/png"  // register PNG decoder
+	"io"
+	"net/http"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/render/genart"
+)
+
+// selfTestSize is the width and height of the images rendered by the self-test.
+const selfTestSize = 32
+
+// selfTestFormats are the output formats the self-test checks; jpeg is the same
+// encoder as jpg.
+var selfTestFormats = []render.ImageFormat{render.FormatSVG, render.FormatPNG, render.FormatJPG, render.FormatGIF, render.FormatWebP}
+
+// selfTestResult is the JSON body of the admin self-test API.
+type selfTestResult struct {
+	OK         bool        `json:"ok"`
+	DurationMS int64       `json:"duration_ms"`
+	Checks     []selfCheck `json:"checks"`
+}
+
+// selfCheck reports one image rendered by the self-test.
+type selfCheck struct {
+	Name       string `json:"name"`
+	OK         bool   `json:"ok"`
+	DurationMS int64  `json:"duration_ms"`
+	Bytes      int    `json:"bytes,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// selfTestCase renders one image for the self-test, in the format it's checked as.
+type selfTestCase struct {
+	name   string
+	format render.ImageFormat
+	render func(ctx context.Context) ([]byte, error)
+	// inked requires a drawn pixel, so a font that renders nothing fails
+	inked bool
+}
+
+// selfTestCases lists one image per output format, font, pattern, and animated
+// format: enough to show every encoder and font works in this build.
+func (s *Service) selfTestCases() []selfTestCase {
+	var cases []selfTestCase
+	for _, format := range selfTestFormats {
+		if !render.CanEncode(format) {
+			continue
+		}
+		cases = append(cases, selfTestCase{name: "format/" + string(format), format: format, render: func(ctx context.Context) ([]byte, error) {
+			return s.renderer.DrawImageWithFormat(ctx, selfTestSize, selfTestSize, config.DefaultAvatarBg, config.DefaultAvatarFg, "GT", false, false, format)
+		}})
+	}
+	for _, name := range s.renderer.FontNames() {
+		cases = append(cases, selfTestCase{name: "font/" + name, format: render.FormatPNG, inked: true, render: func(ctx context.Context) ([]byte, error) {
+			return s.renderer.DrawText(ctx, "Ag", name, 16, "000000", render.FormatPNG)
+		}})
+	}
+	for _, pattern := range render.Patterns() {
+		cases = append(cases, selfTestCase{name: "pattern/" + string(pattern), format: render.FormatPNG, render: func(ctx context.Context) ([]byte, error) {
+			return s.renderer.DrawPattern(ctx, selfTestSize, selfTestSize, pattern, render.PatternOptions{Seed: 1, Colors: genart.Palette(1, patternColorCount)}, "", "", false, false, render.FormatPNG)
+		}})
+	}
+	cases = append(cases, selfTestCase{name: "animation/gif", format: render.FormatGIF, render: func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawSpinner(ctx, selfTestSize, render.SpinnerRing, "000000", "ffffff", render.FormatGIF)
+	}})
+	return cases
+}
+
+// runSelfCheck renders c and checks the output is a well-formed image.
+func runSelfCheck(ctx context.Context, c selfTestCase) selfCheck {
+	start := time.Now()
+	check := selfCheck{Name: c.name}
+	data, err := c.render(ctx)
+	if err == nil {
+		check.Bytes = len(data)
+		err = verifySelfTestImage(data, c.format, c.inked)
+	}
+	check.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// verifySelfTestImage checks that data is an image in format: well-formed XML for
+// SVG, and for other formats one their decoder accepts, with a drawn pixel when
+// inked is set.
+func verifySelfTestImage(data []byte, format render.ImageFormat, inked bool) error {
+	if len(data) == 0 {
+		return errors.New("empty output")
+	}
+	if format == render.FormatSVG {
+		dec := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := dec.Token(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("malformed SVG: %w", err)
+			}
+		}
+	}
+
+	img, decoded, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	if want := string(format); decoded != want && !(decoded == "jpeg" && format == render.FormatJPG) {
+		return fmt.Errorf("expected %s output, got %s", want, decoded)
+	}
+	if !inked {
+		return nil
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0 {
+				return nil
+			}
+		}
+	}
+	return errors.New("nothing was drawn")
+}
+
+// handleAdminSelfTest renders a small image per format, font, and pattern, and
+// reports which worked. It's for checking a deployment after its base image or
+// image libraries change, so it bypasses the cache, and answers 503 when any
+// check fails so it can back a readiness probe.
+func (s *Service) handleAdminSelfTest(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), config.RenderTimeout)
+	defer cancel()
+	start := time.Now()
+	result := selfTestResult{OK: true}
+	for _, c := range s.selfTestCases() {
+		check := runSelfCheck(ctx, c)
+		result.OK = result.OK && check.OK
+		result.Checks = append(result.Checks, check)
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+
+	status := http.StatusOK
+	if !result.OK {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, result)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grout/internal/render"
+)
+
+func TestAdminSelfTest(t *testing.T) {
+	_, disabled := setupTestService(t)
+	rec := httptest.NewRecorder()
+	disabled.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/selftest", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without an admin token configured, got %d", rec.Code)
+	}
+
+	mux := setupAdminTestService(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/selftest", nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result selfTestResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !result.OK {
+		t.Fatalf("expected every check to pass, got %+v", result.Checks)
+	}
+	names := make(map[string]bool)
+	for _, check := range result.Checks {
+		names[check.Name] = true
+		if !check.OK || check.Bytes == 0 {
+			t.Errorf("unexpected check result: %+v", check)
+		}
+	}
+	for _, want := range []string{"format/svg", "format/png", "format/jpg", "format/gif", "format/webp", "font/regular", "font/mono", "pattern/lowpoly", "animation/gif"} {
+		if !names[want] {
+			t.Errorf("expected a %s check, got %v", want, names)
+		}
+	}
+}
+
+func TestRunSelfCheckFailures(t *testing.T) {
+	// A blank, fully transparent PNG
+	blank := func(context.Context) ([]byte, error) {
+		var buf bytes.Buffer
+		err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+		return buf.Bytes(), err
+	}
+	tests := []struct {
+		name   string
+		c      selfTestCase
+		expect string
+	}{
+		{"render error", selfTestCase{format: render.FormatPNG, render: func(context.Context) ([]byte, error) {
+			return nil, render.ErrUnsupportedFormat
+		}}, "unsupported"},
+		{"empty output", selfTestCase{format: render.FormatPNG, render: func(context.Context) ([]byte, error) { return nil, nil }}, "empty output"},
+		{"malformed SVG", selfTestCase{format: render.FormatSVG, render: func(context.Context) ([]byte, error) {
+			return []byte(`<svg><rect></svg>`), nil
+		}}, "malformed SVG"},
+		{"wrong format", selfTestCase{format: render.FormatWebP, render: blank}, "expected webp output, got png"},
+		{"nothing drawn", selfTestCase{format: render.FormatPNG, inked: true, render: blank}, "nothing was drawn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := runSelfCheck(context.Background(), tt.c)
+			if check.OK || !strings.Contains(check.Error, tt.expect) {
+				t.Fatalf("expected a failure containing %q, got %+v", tt.expect, check)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// signURL returns the signature of a request path and its query parameters: the
+// hex HMAC-SHA256 of "{path}?{query}", with the query sorted by key. The sig and
+// async parameters are left out, since neither changes the image.
+func signURL(key, path string, query url.Values) string {
+	unsigned := url.Values{}
+	for k, v := range query {
+		if k != "sig" && k != "async" {
+			unsigned[k] = v
+		}
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(path + "?" + unsigned.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// linkExpiry returns the time set by a request's exp parameter, in Unix seconds.
+func linkExpiry(r *http.Request) (time.Time, bool) {
+	exp, err := strconv.ParseInt(r.URL.Query().Get("exp"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(exp, 0), true
+}
+
+// requireSignature wraps next so requests must carry a valid sig parameter, and
+// are refused once the time in their exp parameter has passed.
+func (s *Service) requireSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		expected := signURL(s.cfg.SigningKey, r.URL.Path, query)
+		if !hmac.Equal([]byte(query.Get("sig")), []byte(expected)) {
+			s.fail(w, r, ErrInvalidSignature)
+			return
+		}
+		if query.Has("exp") {
+			expiry, ok := linkExpiry(r)
+			if !ok {
+				s.fail(w, r, ErrInvalidParameter.withMessage("Invalid exp. Use a Unix timestamp in seconds."))
+				return
+			}
+			if !time.Now().Before(expiry) {
+				s.fail(w, r, ErrLinkExpired)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSigningKey = "test-signing-key"
+
+// setupSigningTestService returns a mux that requires URLs signed with testSigningKey.
+func setupSigningTestService(t *testing.T) *http.ServeMux {
+	t.Helper()
+	svc, _ := setupTestService(t)
+	svc.cfg.SigningKey = testSigningKey
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return mux
+}
+
+// signed appends a valid sig parameter to target.
+func signed(t *testing.T, target string) string {
+	t.Helper()
+	u, err := url.Parse(target)
+	if err != nil {
+		t.Fatalf("parse %s: %v", target, err)
+	}
+	query := u.Query()
+	query.Set("sig", signURL(testSigningKey, u.Path, query))
+	return u.Path + "?" + query.Encode()
+}
+
+func TestSignedURLs(t *testing.T) {
+	mux := setupSigningTestService(t)
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name   string
+		target string
+		expect int
+	}{
+		{"unsigned", "/placeholder/300x200", http.StatusForbidden},
+		{"signed", signed(t, "/placeholder/300x200?bg=ff0000"), http.StatusOK},
+		{"tampered", strings.Replace(signed(t, "/placeholder/300x200?bg=ff0000"), "ff0000", "00ff00", 1), http.StatusForbidden},
+		{"bad signature", "/placeholder/300x200?sig=deadbeef", http.StatusForbidden},
+		{"not expired", signed(t, fmt.Sprintf("/avatar/Jane?exp=%d", future)), http.StatusOK},
+		{"expired", signed(t, fmt.Sprintf("/avatar/Jane?exp=%d", past)), http.StatusGone},
+		{"invalid exp", signed(t, "/avatar/Jane?exp=tomorrow"), http.StatusBadRequest},
+		{"unsigned health", "/health", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.expect {
+				t.Fatalf("expected %d got %d", tt.expect, rec.Code)
+			}
+		})
+	}
+}
+
+func TestSignedURLsExpiringCacheControl(t *testing.T) {
+	mux := setupSigningTestService(t)
+
+	rec := httptest.NewRecorder()
+	target := signed(t, fmt.Sprintf("/placeholder/300x200?exp=%d", time.Now().Add(time.Hour).Unix()))
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	cc := rec.Header().Get("Cache-Control")
+	if strings.Contains(cc, "immutable") || !strings.HasPrefix(cc, "public, max-age=3") {
+		t.Fatalf("expected caching until the link expires, got %q", cc)
+	}
+}
+
+func TestUnsignedURLsWithoutSigningKey(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/300x200?exp=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected signing to be off without a key, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/utils"
+)
+
+func (s *Service) handleSpinner(w http.ResponseWriter, r *http.Request) {
+	pathValue := strings.TrimPrefix(r.URL.Path, "/spinner/")
+
+	// Extract format from path
+	format, pathValue, err := extractFormat(pathValue)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if format != render.FormatSVG && format != render.FormatGIF {
+		s.fail(w, r, ErrUnsupportedFormat.withMessage("Spinners are animated. Use a .svg extension, .gif, or none."))
+		return
+	}
+
+	size := utils.ParseIntOrDefault(r.URL.Query().Get("size"), config.DefaultSpinnerSize)
+	if pathValue != "" {
+		var err error
+		size, err = strconv.Atoi(pathValue)
+		if err != nil || size < 1 {
+			s.fail(w, r, ErrInvalidParameter.withMessage("Invalid size. Use a number of pixels such as /spinner/64."))
+			return
+		}
+	}
+	size = clampParam(r, "size", size, config.MinSpinnerSize, config.MaxSpinnerSize)
+
+	style := render.SpinnerStyle(r.URL.Query().Get("style"))
+	if style == "" {
+		style = render.SpinnerRing
+	}
+	if !style.IsValid() {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid style. Use ring, dots, or bars."))
+		return
+	}
+
+	fgHex, err := queryColor(r, "color")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if fgHex == "" {
+		fgHex = s.themeFor(r).brandColor
+	}
+	// Transparent by default; GIF frames can't fade into transparency so fall back to white
+	bgHex, err := backgroundParam(r, "")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if bgHex == "" && format == render.FormatGIF {
+		bgHex = "ffffff"
+	}
+
+	r = withAltText(r, "Loading", "")
+	key := specKey("SPINNER", spinnerSpec{Size: size, Style: style, Fg: fgHex, Bg: bgHex, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawSpinner(ctx, size, style, fgHex, bgHex, format)
+	})
+}
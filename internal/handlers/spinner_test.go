@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSpinnerHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+		contains    string
+	}{
+		{"default ring", "/spinner/", http.StatusOK, "image/svg+xml", `width="64"`},
+		{"sized dots with color", "/spinner/32.svg?style=dots&color=e76f51", http.StatusOK, "image/svg+xml", `fill="#e76f51"`},
+		{"size parameter", "/spinner/?size=100&style=bars", http.StatusOK, "image/svg+xml", `width="100"`},
+		{"size clamped", "/spinner/5000", http.StatusOK, "image/svg+xml", `width="512"`},
+		{"GIF fallback", "/spinner/48.gif?style=bars", http.StatusOK, "image/gif", "GIF89a"},
+		{"invalid style", "/spinner/64?style=pulse", http.StatusBadRequest, "text/html; charset=utf-8", "ring, dots, or bars"},
+		{"invalid size", "/spinner/big", http.StatusBadRequest, "text/html; charset=utf-8", "Invalid size"},
+		{"static format", "/spinner/64.png", http.StatusBadRequest, "text/html; charset=utf-8", "Spinners are animated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("expected %q in body", tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/render/genart"
+	"grout/internal/utils"
+)
+
+func (s *Service) handleTable(w http.ResponseWriter, r *http.Request) {
+	pathMetric := strings.TrimPrefix(r.URL.Path, "/table/")
+
+	// Extract format from path
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	width, height := config.DefaultTableWidth, config.DefaultTableHeight
+	if pathMetric != "" {
+		if width, height, err = parseDimensions(r, pathMetric); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+	}
+	width, height = emailDimensions(r, width, height)
+	format = emailFormat(r, format)
+	r = withRenderCost(r, renderCost{width: width, height: height})
+
+	rows := min(utils.ParseIntOrDefault(r.URL.Query().Get("rows"), config.DefaultTableRows), config.MaxTableRows)
+	cols := min(utils.ParseIntOrDefault(r.URL.Query().Get("cols"), config.DefaultTableCols), config.MaxTableCols)
+
+	seedParam := r.URL.Query().Get("seed")
+	if seedParam == "" {
+		seedParam = fmt.Sprintf("%dx%d", rows, cols)
+	}
+	seed := genart.ParseSeed(seedParam)
+
+	var style render.TableStyle
+	if style.Background, err = backgroundParam(r, "ffffff"); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	for _, c := range []struct {
+		param string
+		field *string
+	}{{"header", &style.Header}, {"stripe", &style.Stripe}, {"color", &style.Text}} {
+		if *c.field, err = queryColor(r, c.param); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+	}
+	if style.Header == "" {
+		style.Header = config.DefaultTableHeader
+	}
+	if style.Stripe == "" {
+		style.Stripe = config.DefaultTableStripe
+	}
+	if style.Text == "" {
+		style.Text = render.GetContrastColor(style.Background)
+	}
+
+	key := specKey("TABLE", tableSpec{Width: width, Height: height, Rows: rows, Cols: cols, Seed: seed, Style: style, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawTable(ctx, width, height, rows, cols, seed, style, format)
+	})
+}
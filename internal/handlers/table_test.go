@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		expect      []string
+		cells       int
+	}{
+		{"defaults", "/table/", "image/svg+xml", []string{`width="600" height="300"`, `fill="#2c3e50"`, ">Amount<"}, 6 * 4},
+		{"rows and columns", "/table/800x400?rows=10&cols=6&header=e53935&stripe=fafafa", "image/svg+xml", []string{`width="800" height="400"`, `fill="#e53935"`, `fill="#fafafa"`}, 11 * 6},
+		{"clamped", "/table/800x400?rows=500&cols=50", "image/svg+xml", nil, 51 * 12},
+		{"png", "/table/600x300.png", "image/png", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q", expect)
+				}
+			}
+			if tt.cells > 0 {
+				if cells := strings.Count(rec.Body.String(), "<text "); cells != tt.cells {
+					t.Errorf("expected %d cells got %d", tt.cells, cells)
+				}
+			}
+		})
+	}
+}
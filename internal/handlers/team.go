@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/utils"
+)
+
+// handleTeam renders a grid of avatars captioned with names, and job titles
+// when given, at /team/{cols}x{rows}[.ext], for team pages. The grid is a layout
+// column of rows, each cell a column of an avatar and its captions.
+func (s *Service) handleTeam(w http.ResponseWriter, r *http.Request) {
+	pathMetric := strings.TrimPrefix(r.URL.Path, "/team/")
+
+	// Extract format from path
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	format = emailFormat(r, format)
+
+	entries, err := s.teamList(r, "names")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	var names []string
+	for _, name := range entries {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	titles, err := s.teamList(r, "titles")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	// Without a grid size, the names fill rows of the default width
+	cols, rows := min(max(len(names), 1), config.DefaultTeamCols), 0
+	if pathMetric != "" {
+		matches := placeholderRegex.FindStringSubmatch(pathMetric)
+		if matches == nil {
+			s.fail(w, r, ErrInvalidParameter.withMessage("Invalid grid. Use columns x rows, such as /team/4x2."))
+			return
+		}
+		cols, _ = strconv.Atoi(matches[1])
+		rows, _ = strconv.Atoi(matches[2])
+	} else {
+		rows = max((len(names)+cols-1)/cols, 1)
+	}
+	if cols < 1 || rows < 1 || cols > config.MaxTeamCols || rows > config.MaxTeamRows || cols*rows > config.MaxTeamCells {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Invalid grid. Use up to %d columns and %d rows, and at most %d cells.", config.MaxTeamCols, config.MaxTeamRows, config.MaxTeamCells))
+		return
+	}
+	// Names fill the grid in reading order; without names it's filled with
+	// numbered members, as a mockup
+	if len(names) == 0 {
+		for i := range cols * rows {
+			names = append(names, "Member "+strconv.Itoa(i+1))
+		}
+	}
+	names = names[:min(len(names), cols*rows)]
+
+	size := float64(clampParam(r, "size", utils.ParseIntOrDefault(r.URL.Query().Get("size"), config.DefaultTeamAvatarSize), config.MinTeamAvatarSize, config.MaxTeamAvatarSize))
+	bgHex, err := backgroundParam(r, config.DefaultTeamBg)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgParam, err := queryColor(r, "color", accentColor, analogousColor)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	fgHex := foregroundColor(fgParam, bgHex)
+
+	// Cells are half again as wide as their avatars, with room for two lines of
+	// name and one of title
+	nameSize := math.Max(12, math.Round(size*0.16))
+	titleSize := math.Round(nameSize * 0.85)
+	cellWidth := math.Round(size * 1.5)
+	gap := math.Round(size * 0.1)
+	cellHeight := size + gap + 2*nameSize*1.5
+	if len(titles) > 0 {
+		cellHeight += titleSize * 1.5
+	}
+	pad := math.Round(size * 0.25)
+	width := int(float64(cols)*cellWidth + float64(cols+1)*pad)
+	height := int(float64(rows)*cellHeight + float64(rows+1)*pad)
+	if width > config.MaxLayoutDimension || height > config.MaxLayoutDimension {
+		s.fail(w, r, ErrInvalidParameter.withMessage("The grid is too large. Use fewer columns or rows, or a smaller size."))
+		return
+	}
+
+	grid := render.LayoutNode{Type: "column", Gap: pad, Align: "start"}
+	for start := 0; start < len(names); start += cols {
+		row := render.LayoutNode{Type: "row", Gap: pad, Align: "start"}
+		for i := start; i < min(start+cols, len(names)); i++ {
+			cell := render.LayoutNode{Type: "column", Gap: gap, Children: []render.LayoutNode{
+				{Type: "avatar", Name: names[i], Size: size},
+				{Type: "text", Text: names[i], Size: nameSize, Width: cellWidth, Bold: true, Color: fgHex},
+			}}
+			if i < len(titles) && titles[i] != "" {
+				cell.Children = append(cell.Children, render.LayoutNode{Type: "text", Text: titles[i], Size: titleSize, Width: cellWidth, Color: fgHex})
+			}
+			row.Children = append(row.Children, cell)
+		}
+		grid.Children = append(grid.Children, row)
+	}
+
+	r = withAltText(r, "Team: "+strings.Join(names, ", "), fmt.Sprintf("A grid of %d avatars with names", len(names)))
+	s.serveLayout(w, r, render.Layout{Width: width, Height: height, Background: bgHex, Root: grid}, format, s.fail)
+}
+
+// teamList returns the comma-separated entries of a team grid parameter,
+// normalized like names, up to the most cells a grid has. Empty entries are
+// kept, so a title can be skipped with ",,".
+func (s *Service) teamList(r *http.Request, param string) ([]string, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	entries := strings.Split(value, ",")
+	entries = entries[:min(len(entries), config.MaxTeamCells)]
+	for i, entry := range entries {
+		entry, err := s.limitLength(r, param, normalizeName(entry), s.cfg.MaxNameLength)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTeam(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		status      int
+		contentType string
+		expect      []string
+		reject      []string
+	}{
+		{"names fill rows of four", "/team/?names=Alice,Bob,Carol,Dave,Eve", http.StatusOK, "image/svg+xml", []string{`width="696" height="374"`, ">Alice<", ">Eve<", ">A<"}, nil},
+		{"grid from the path", "/team/2x1?names=Alice,Bob,Carol", http.StatusOK, "image/svg+xml", []string{`width="360" height="199"`, ">Bob<"}, []string{">Carol<"}},
+		{"titles", "/team/2x1?names=Alice,Bob&titles=,CTO", http.StatusOK, "image/svg+xml", []string{">CTO<", `font-size="13"`}, nil},
+		{"mockup members", "/team/2x2.png", http.StatusOK, "image/png", nil, nil},
+		{"captions contrast", "/team/1x1?names=Alice&bg=000000", http.StatusOK, "image/svg+xml", []string{`fill="#ffffff" text-anchor="middle" dominant-baseline="middle">Alice<`}, nil},
+		{"bad grid", "/team/4?names=Alice", http.StatusBadRequest, "text/html; charset=utf-8", []string{"Invalid grid"}, nil},
+		{"too many cells", "/team/8x8", http.StatusBadRequest, "text/html; charset=utf-8", []string{"at most 40 cells"}, nil},
+		{"too large", "/team/8x5?size=256", http.StatusBadRequest, "text/html; charset=utf-8", []string{"too large"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q, got: %s", expect, rec.Body.String())
+				}
+			}
+			for _, reject := range tt.reject {
+				if strings.Contains(rec.Body.String(), reject) {
+					t.Errorf("expected body not to contain %q, got: %s", reject, rec.Body.String())
+				}
+			}
+		})
+	}
+}
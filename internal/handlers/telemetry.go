@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// telemetryReport is the JSON body of a telemetry report. It holds aggregate
+// counters only: no hosts, parameters, IPs, or tenants.
+type telemetryReport struct {
+	Version     string    `json:"version"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// Requests counts requests by route pattern, such as "/avatar/"
+	Requests map[string]uint64 `json:"requests"`
+	// Formats counts the images served by format
+	Formats map[string]uint64 `json:"formats"`
+}
+
+// telemetry counts requests per route and images per format, and reports
+// them to the telemetry URL. It's off unless a URL is set.
+type telemetry struct {
+	url    string
+	client *http.Client
+
+	// routes holds a counter per route pattern, made when routes are registered
+	routes map[string]*atomic.Uint64
+
+	mu      sync.Mutex
+	since   time.Time
+	formats map[render.ImageFormat]uint64
+
+	// reportMu serializes reports, so counts taken back after a failure don't
+	// cross a report in flight
+	reportMu sync.Mutex
+}
+
+// newTelemetry returns the telemetry the config asks for, or nil when it's off.
+func newTelemetry(cfg config.ServerConfig) *telemetry {
+	if cfg.TelemetryURL == "" {
+		return nil
+	}
+	return &telemetry{
+		url:     cfg.TelemetryURL,
+		client:  &http.Client{Timeout: config.TelemetryTimeout},
+		routes:  make(map[string]*atomic.Uint64),
+		since:   time.Now(),
+		formats: make(map[render.ImageFormat]uint64),
+	}
+}
+
+// count counts the requests of a route under its pattern. It returns next
+// unchanged on a nil telemetry.
+func (t *telemetry) count(pattern string, next http.Handler) http.Handler {
+	if t == nil {
+		return next
+	}
+	counter, ok := t.routes[pattern]
+	if !ok {
+		counter = new(atomic.Uint64)
+		t.routes[pattern] = counter
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter.Add(1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recordFormat counts an image served in format. It does nothing on a nil
+// telemetry.
+func (t *telemetry) recordFormat(format render.ImageFormat) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.formats[format]++
+}
+
+// take returns the counts since the last report ended at now, and resets them.
+func (t *telemetry) take(now time.Time) telemetryReport {
+	report := telemetryReport{
+		Version:   config.Version,
+		PeriodEnd: now.UTC(),
+		Requests:  make(map[string]uint64),
+		Formats:   make(map[string]uint64),
+	}
+	for pattern, counter := range t.routes {
+		if n := counter.Swap(0); n > 0 {
+			report.Requests[pattern] = n
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	report.PeriodStart = t.since.UTC()
+	for format, n := range t.formats {
+		report.Formats[string(format)] = n
+	}
+	clear(t.formats)
+	t.since = now
+	return report
+}
+
+// putBack returns the counts of a report that failed to go out, so the next
+// report carries them.
+func (t *telemetry) putBack(report telemetryReport) {
+	for pattern, n := range report.Requests {
+		t.routes[pattern].Add(n)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.since = report.PeriodStart
+	for format, n := range report.Formats {
+		t.formats[render.ImageFormat(format)] += n
+	}
+}
+
+// SendTelemetry reports the counts since the last report to the telemetry URL.
+// When it fails, the counts are kept for the next report. It does nothing when
+// telemetry is off, or when nothing was requested.
+func (s *Service) SendTelemetry(ctx context.Context) error {
+	t := s.telemetry
+	if t == nil {
+		return nil
+	}
+	t.reportMu.Lock()
+	defer t.reportMu.Unlock()
+
+	report := t.take(time.Now())
+	if len(report.Requests) == 0 && len(report.Formats) == 0 {
+		return nil
+	}
+	if err := t.post(ctx, report); err != nil {
+		t.putBack(report)
+		return err
+	}
+	return nil
+}
+
+// post sends a report as JSON. Any 2xx response counts as delivered.
+func (t *telemetry) post(ctx context.Context, report telemetryReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "grout/"+config.Version)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("telemetry endpoint responded %s", resp.Status)
+	}
+	return nil
+}
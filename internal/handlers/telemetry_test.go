@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"grout/internal/config"
+)
+
+func TestTelemetry(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	fail := true
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer endpoint.Close()
+	svc, mux := setupMeteringTestService(t, func(cfg *config.ServerConfig) { cfg.TelemetryURL = endpoint.URL })
+
+	serveImages(t, mux, "img.acme.test", "/placeholder/40x40.png?text=secret", "/placeholder/40x40.webp?text=secret")
+	if err := svc.SendTelemetry(context.Background()); err == nil {
+		t.Fatal("expected the report to fail while the endpoint does")
+	}
+
+	// The failed report's counts go out with the next one
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	serveImages(t, mux, "example.com", "/avatar/Jane%20Doe.png", "/placeholder/40x40.png?text=secret")
+	if err := svc.SendTelemetry(context.Background()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	// Nothing was requested since, so there's nothing to send
+	if err := svc.SendTelemetry(context.Background()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(bodies))
+	}
+	var report telemetryReport
+	if err := json.Unmarshal([]byte(bodies[0]), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.Version != config.Version {
+		t.Errorf("expected version %s, got %q", config.Version, report.Version)
+	}
+	wantRequests := map[string]uint64{"/placeholder/": 3, "/avatar/": 1}
+	if len(report.Requests) != len(wantRequests) {
+		t.Errorf("expected requests %v, got %v", wantRequests, report.Requests)
+	}
+	for pattern, n := range wantRequests {
+		if report.Requests[pattern] != n {
+			t.Errorf("expected %d requests of %s, got %d", n, pattern, report.Requests[pattern])
+		}
+	}
+	wantFormats := map[string]uint64{"png": 3, "webp": 1}
+	for format, n := range wantFormats {
+		if report.Formats[format] != n {
+			t.Errorf("expected %d %s images, got %d", n, format, report.Formats[format])
+		}
+	}
+	if !report.PeriodStart.Before(report.PeriodEnd) {
+		t.Errorf("expected the period to start before it ends, got %s to %s", report.PeriodStart, report.PeriodEnd)
+	}
+	// Only aggregate counts are reported
+	for _, private := range []string{"secret", "Jane", "acme", "example.com", "127.0.0.1", "192.0.2"} {
+		if strings.Contains(bodies[0], private) {
+			t.Errorf("expected the report to leave out %q: %s", private, bodies[0])
+		}
+	}
+}
+
+func TestTelemetryOff(t *testing.T) {
+	svc, mux := setupMeteringTestService(t, func(*config.ServerConfig) {})
+	if svc.telemetry != nil {
+		t.Fatal("expected telemetry to be off without a URL")
+	}
+	serveImages(t, mux, "example.com", "/placeholder/40x40.png")
+	if err := svc.SendTelemetry(context.Background()); err != nil {
+		t.Fatalf("expected no report, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// templateStore holds the named layout templates rendered at /t/{template}: those
+// from the templates file, and those registered through the admin API.
+type templateStore struct {
+	mu        sync.RWMutex
+	templates map[string]json.RawMessage
+}
+
+func newTemplateStore(initial map[string]json.RawMessage) *templateStore {
+	templates := make(map[string]json.RawMessage, len(initial))
+	for name, doc := range initial {
+		templates[name] = doc
+	}
+	return &templateStore{templates: templates}
+}
+
+func (ts *templateStore) get(name string) (json.RawMessage, bool) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	doc, ok := ts.templates[name]
+	return doc, ok
+}
+
+func (ts *templateStore) set(name string, doc json.RawMessage) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.templates[name] = doc
+}
+
+// remove deletes a template, reporting whether it existed.
+func (ts *templateStore) remove(name string) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	_, ok := ts.templates[name]
+	delete(ts.templates, name)
+	return ok
+}
+
+func (ts *templateStore) names() []string {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	names := make([]string, 0, len(ts.templates))
+	for name := range ts.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateVarRegex matches a template variable, {{name}} or {{name|default}}.
+var templateVarRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*(?:\|([^}]*))?\}\}`)
+
+// templateNumberFields are the layout fields that hold numbers. A variable that
+// makes up the whole value of one becomes a number, so sizes can be variables too.
+var templateNumberFields = map[string]bool{"width": true, "height": true, "size": true, "gap": true, "inset": true, "dash": true}
+
+// templatePixels formats a size for a template variable, in whole pixels of at
+// least 1.
+func templatePixels(v float64) string {
+	return strconv.Itoa(max(int(math.Round(v)), 1))
+}
+
+// templateLookup returns a variable's value given its default, and false when it
+// has neither.
+type templateLookup func(name, def string, hasDefault bool) (string, bool)
+
+// fillTemplate substitutes the variables in every string of a decoded template.
+func fillTemplate(v any, lookup templateLookup) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if str, ok := value.(string); ok && templateNumberFields[key] {
+				if m := templateVarRegex.FindStringSubmatch(str); m != nil && m[0] == str {
+					if n, err := strconv.ParseFloat(resolveTemplateVar(m, lookup), 64); err == nil {
+						v[key] = n
+						continue
+					}
+				}
+			}
+			v[key] = fillTemplate(value, lookup)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = fillTemplate(value, lookup)
+		}
+	case string:
+		return templateVarRegex.ReplaceAllStringFunc(v, func(match string) string {
+			return resolveTemplateVar(templateVarRegex.FindStringSubmatch(match), lookup)
+		})
+	}
+	return v
+}
+
+// resolveTemplateVar returns the value of a matched template variable.
+func resolveTemplateVar(match []string, lookup templateLookup) string {
+	value, _ := lookup(match[1], match[2], strings.Contains(match[0], "|"))
+	return value
+}
+
+// handleTemplate renders a named template at /t/{template}[.ext], filling its
+// variables from the query parameters.
+func (s *Service) handleTemplate(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/t/")
+	format, name, err := extractFormat(path)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	raw, ok := s.templates.get(name)
+	if !ok {
+		s.fail(w, r, ErrNotFound.withMessage("Unknown template."))
+		return
+	}
+	s.serveTemplate(w, r, raw, format, name != path, nil)
+}
+
+// brandTemplateVars returns the variables every template can use for the
+// request's branding. Query parameters of the same name win over them.
+func (t *theme) brandTemplateVars() map[string]string {
+	vars := map[string]string{
+		"brand_name":  t.brandName,
+		"brand_color": t.brandColor,
+		"brand_font":  t.font,
+	}
+	if t.secondaryColor != "" {
+		vars["brand_secondary"] = t.secondaryColor
+	}
+	return vars
+}
+
+// serveTemplate fills a template's variables and serves the layout it makes.
+// Variables in vars win over the query parameters, which win over the brand
+// variables. With formatFromPath set, the path's extension wins over the
+// template's format.
+func (s *Service) serveTemplate(w http.ResponseWriter, r *http.Request, raw json.RawMessage, format render.ImageFormat, formatFromPath bool, vars map[string]string) {
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		s.fail(w, r, ErrRenderFailed.withCause(err))
+		return
+	}
+	query := r.URL.Query()
+	brand := s.themeFor(r).brandTemplateVars()
+	missing := make(map[string]bool)
+	var lookupErr error
+	doc = fillTemplate(doc, func(variable, def string, hasDefault bool) (string, bool) {
+		if value, ok := vars[variable]; ok {
+			return value, true
+		}
+		if !query.Has(variable) {
+			if value, ok := brand[variable]; ok {
+				return value, true
+			}
+			if !hasDefault {
+				missing[variable] = true
+			}
+			return def, hasDefault
+		}
+		value, err := s.limitLength(r, variable, query.Get(variable), s.cfg.MaxTextLength)
+		if err != nil && lookupErr == nil {
+			lookupErr = err
+		}
+		return value, true
+	})
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		s.fail(w, r, ErrMissingParameter.withMessage("Missing template variables: %s.", strings.Join(names, ", ")))
+		return
+	}
+	if lookupErr != nil {
+		s.fail(w, r, lookupErr)
+		return
+	}
+
+	filled, _ := json.Marshal(doc)
+	var req renderRequest
+	decoder := json.NewDecoder(bytes.NewReader(filled))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		s.fail(w, r, ErrInvalidParameter.withMessage("The template doesn't make a valid layout with these variables: %v", err))
+		return
+	}
+	if !formatFromPath && req.Format != "" {
+		f, ok := parseFormatParam(req.Format)
+		if !ok {
+			s.fail(w, r, ErrUnsupportedFormat.withMessage("The template's format is not supported."))
+			return
+		}
+		format = f
+	}
+	s.serveLayout(w, r, req.Layout, format, s.fail)
+}
+
+// adminTemplatesResponse is the JSON body of the admin templates list.
+type adminTemplatesResponse struct {
+	Templates []string `json:"templates"`
+}
+
+// handleAdminTemplates lists the names of the registered templates.
+func (s *Service) handleAdminTemplates(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, adminTemplatesResponse{Templates: s.templates.names()})
+}
+
+// handleAdminPutTemplate registers or replaces a template from a JSON layout
+// document. Templates registered this way last until the server restarts.
+func (s *Service) handleAdminPutTemplate(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	name := r.PathValue("name")
+	if !config.TemplateNameRegex.MatchString(name) {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid template name: use lowercase letters, digits, '-', or '_'"))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, config.MaxLayoutBytes))
+	if err != nil {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid template: %v", err))
+		return
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid template: not a JSON layout document"))
+		return
+	}
+
+	s.templates.set(name, json.RawMessage(body))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminDeleteTemplate removes a template.
+func (s *Service) handleAdminDeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	if !s.templates.remove(r.PathValue("name")) {
+		s.failJSON(w, r, ErrNotFound.withMessage("unknown template"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+const testTicketTemplate = `{"width":400,"height":200,"background":"{{bg|ffffff}}","root":{"type":"column","gap":8,"children":[
+	{"type":"text","text":"Admit {{name}}","size":"{{size|24}}"},
+	{"type":"text","text":"Seat {{seat|TBA}}"}]}}`
+
+// setupTemplateTestService returns a service with the admin API enabled and a
+// "ticket" template.
+func setupTemplateTestService(t *testing.T) *http.ServeMux {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.AdminToken = testAdminToken
+	cfg.Templates = map[string]json.RawMessage{"ticket": json.RawMessage(testTicketTemplate)}
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return mux
+}
+
+func TestTemplateRender(t *testing.T) {
+	mux := setupTemplateTestService(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		status      int
+		contentType string
+		expect      []string
+	}{
+		{"variables and defaults", "/t/ticket?name=Jane", http.StatusOK, "image/svg+xml", []string{">Admit Jane<", ">Seat TBA<", `font-size="24"`, `fill="#ffffff"`}},
+		{"numeric variable", "/t/ticket?name=Jane&size=40&seat=2024", http.StatusOK, "image/svg+xml", []string{`font-size="40"`, ">Seat 2024<"}},
+		{"escapes values", "/t/ticket?name=%3Cb%3E", http.StatusOK, "image/svg+xml", []string{">Admit &lt;b&gt;<"}},
+		{"extension", "/t/ticket.png?name=Jane", http.StatusOK, "image/png", nil},
+		{"missing variable", "/t/ticket", http.StatusBadRequest, "text/html; charset=utf-8", []string{"Missing template variables: name."}},
+		{"invalid value", "/t/ticket?name=Jane&bg=red", http.StatusBadRequest, "text/html; charset=utf-8", []string{"not a hex color"}},
+		{"unknown template", "/t/missing", http.StatusNotFound, "text/html; charset=utf-8", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q, got: %s", expect, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func adminRequest(mux *http.ServeMux, method, target, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminTemplates(t *testing.T) {
+	mux := setupTemplateTestService(t)
+	badge := `{"width":100,"height":40,"root":{"type":"text","text":"{{label}}"}}`
+
+	if rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/templates/badge", badge, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/templates/Bad%20Name", badge, testAdminToken); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid name, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/templates/badge", "[1]", testAdminToken); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a non-document, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodPut, "/api/v1/admin/templates/badge", badge, testAdminToken); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := adminRequest(mux, http.MethodGet, "/t/badge?label=beta", "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), ">beta<") {
+		t.Fatalf("expected the registered template to render, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var list adminTemplatesResponse
+	rec = adminRequest(mux, http.MethodGet, "/api/v1/admin/templates", "", testAdminToken)
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || strings.Join(list.Templates, ",") != "badge,ticket" {
+		t.Fatalf("expected badge and ticket, got %s", rec.Body.String())
+	}
+
+	if rec := adminRequest(mux, http.MethodDelete, "/api/v1/admin/templates/badge", "", testAdminToken); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodDelete, "/api/v1/admin/templates/badge", "", testAdminToken); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 deleting twice, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodGet, "/t/badge?label=beta", "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected a deleted template to 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/utils"
+)
+
+func (s *Service) handleText(w http.ResponseWriter, r *http.Request) {
+	pathText := strings.TrimPrefix(r.URL.Path, "/text/")
+
+	// Extract format from path
+	format, text, err := extractFormat(pathText)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	format = emailFormat(r, format)
+	if text == "" {
+		text = r.URL.Query().Get("text")
+	}
+	if strings.TrimSpace(text) == "" {
+		s.fail(w, r, ErrMissingParameter.withMessage("Missing text. Use /text/{string} or the text query parameter."))
+		return
+	}
+	text, err = s.limitLength(r, "text", text, s.cfg.MaxTextLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	fontName := r.URL.Query().Get("font")
+	if fontName == "" {
+		fontName = s.themeFor(r).font
+	}
+	if !s.renderer.HasFont(fontName) {
+		s.fail(w, r, ErrInvalidParameter.withMessage("Unknown font. Available fonts: %s.", strings.Join(s.renderer.FontNames(), ", ")))
+		return
+	}
+
+	size := clampParam(r, "size", utils.ParseIntOrDefault(r.URL.Query().Get("size"), config.DefaultTextSize), 1, config.MaxTextSize)
+	fgHex, err := queryColor(r, "color")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if fgHex == "" {
+		fgHex = s.themeFor(r).textColor
+	}
+
+	r = withAltText(r, text, "")
+	key := specKey("TEXT", textSpec{Text: text, Font: fontName, Size: size, Fg: fgHex, Format: format})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawText(ctx, text, fontName, float64(size), fgHex, format)
+	})
+}
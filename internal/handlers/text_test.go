@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTextHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		contentType string
+	}{
+		{"SVG default", "/text/Hello", http.StatusOK, "image/svg+xml"},
+		{"PNG with font", "/text/Hello.png?font=bold&size=48&color=ff0000", http.StatusOK, "image/png"},
+		{"Query text", "/text/?text=Hello", http.StatusOK, "image/svg+xml"},
+		{"Missing text", "/text/", http.StatusBadRequest, "text/html; charset=utf-8"},
+		{"Unknown font", "/text/Hello?font=comic-sans", http.StatusBadRequest, "text/html; charset=utf-8"},
+		{"Text too long is truncated", "/text/" + strings.Repeat("a", 201), http.StatusOK, "image/svg+xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d", tt.status, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"image"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/cache"
+	"grout/internal/config"
+	"grout/internal/content"
+	"grout/internal/middleware"
+	"grout/internal/render"
+)
+
+// theme holds the branding and defaults that apply to a request: the server-wide
+// settings, or a tenant's overrides when the Host header matches one of its hosts.
+type theme struct {
+	tenantID    string // Empty for the server-wide theme
+	hosts       []string
+	domain      string
+	brandName   string
+	brandColor  string
+	footerLinks []config.FooterLink
+	// Default colors when a request doesn't set one
+	avatarBg       string
+	placeholderBg  string
+	textColor      string
+	calendarHeader string
+	ratingColor    string
+	dividerColor   string
+	font           string
+	// secondaryColor is the brand kit's secondary color, or the tenant's accent
+	// color; empty leaves the certificate and ticket trim at their defaults
+	secondaryColor string
+	// logo is the brand kit's logo, nil when it has none
+	logo    image.Image
+	content *content.Manager // nil when quotes and jokes are unavailable
+	// cache holds the theme's rendered images; every tenant gets its own partition
+	cache *cache.Hashed
+	// rateLimiter replaces the server-wide rate limit when the tenant sets one
+	rateLimiter *middleware.RateLimiter
+	// priority replaces the priority of every route when hasPriority is set
+	priority    middleware.Priority
+	hasPriority bool
+	// renderQuota and renderQuotaSoft are the tenant's monthly limits on image
+	// requests; 0 is none
+	renderQuota     int
+	renderQuotaSoft int
+}
+
+// newDefaultTheme builds the server-wide theme from the config and its brand kit,
+// rendering into the shared image cache.
+func newDefaultTheme(cfg config.ServerConfig, contentManager *content.Manager, shared *lru.Cache[string, []byte]) *theme {
+	t := &theme{
+		domain:         cfg.Domain,
+		brandName:      cfg.BrandName,
+		brandColor:     cfg.BrandColor,
+		footerLinks:    cfg.FooterLinks,
+		avatarBg:       config.DefaultAvatarBg,
+		placeholderBg:  config.DefaultBgColor,
+		textColor:      config.DefaultTextColor,
+		calendarHeader: config.DefaultCalendarHeader,
+		ratingColor:    config.DefaultRatingColor,
+		dividerColor:   config.DefaultDividerColor,
+		font:           render.DefaultFont,
+		content:        contentManager,
+		cache:          cache.NewHashed(defaultCache(cfg, shared), debugKeys(cfg)),
+	}
+
+	brand := cfg.Brand
+	if brand.Name != "" {
+		t.brandName = brand.Name
+	}
+	if brand.PrimaryColor != "" {
+		t.brandColor, t.avatarBg, t.placeholderBg = brand.PrimaryColor, brand.PrimaryColor, brand.PrimaryColor
+	}
+	if brand.SecondaryColor != "" {
+		t.calendarHeader, t.ratingColor, t.dividerColor = brand.SecondaryColor, brand.SecondaryColor, brand.SecondaryColor
+		t.secondaryColor = brand.SecondaryColor
+	}
+	if brand.Font != "" {
+		t.font = brand.Font
+	}
+	t.logo = brand.LogoImage
+	return t
+}
+
+// withTenant returns a copy of t with a tenant's overrides applied. The tenant
+// gets its own cache partition of cfg.CacheSize entries, capped at its byte quota.
+func (t *theme) withTenant(id string, tenant config.Tenant, cfg config.ServerConfig) *theme {
+	override := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+
+	themed := *t
+	themed.tenantID = id
+	themed.hosts = tenant.Hosts
+	themed.domain = tenant.Hosts[0]
+	override(&themed.brandName, tenant.BrandName)
+	override(&themed.brandColor, strings.ToLower(tenant.BrandColor))
+	if len(tenant.FooterLinks) > 0 {
+		themed.footerLinks = tenant.FooterLinks
+	}
+	override(&themed.avatarBg, tenant.AvatarBg)
+	override(&themed.placeholderBg, tenant.PlaceholderBg)
+	override(&themed.textColor, tenant.TextColor)
+	override(&themed.calendarHeader, tenant.AccentColor)
+	override(&themed.ratingColor, tenant.AccentColor)
+	override(&themed.dividerColor, tenant.AccentColor)
+	override(&themed.secondaryColor, tenant.AccentColor)
+	override(&themed.font, tenant.Font)
+
+	if tenant.QuotesFile != "" || tenant.JokesFile != "" {
+		// Pack files are checked when the tenants file is loaded; keep the shared
+		// content if one has since become unreadable
+		if pack, err := content.NewManagerFromFiles(tenant.QuotesFile, tenant.JokesFile); err == nil {
+			pack.SetDeterministic(cfg.Deterministic)
+			themed.content = pack
+		}
+	}
+
+	// Without a partition the tenant shares the server-wide cache; keys are
+	// namespaced either way
+	if partition, err := cache.NewPartition(cfg.CacheSize, int64(tenant.CacheQuotaMB)<<20, cfg.CacheDedup); err == nil {
+		themed.cache = cache.NewHashed(partition, debugKeys(cfg))
+	}
+	themed.priority, themed.hasPriority = middleware.ParsePriority(tenant.Priority)
+	themed.renderQuota, themed.renderQuotaSoft = tenant.RenderQuota, tenant.RenderQuotaSoft
+	if tenant.RateLimitRPM > 0 {
+		burst := tenant.RateLimitBurst
+		if burst == 0 {
+			burst = cfg.RateLimitBurst
+		}
+		themed.rateLimiter = middleware.NewRateLimiter(tenant.RateLimitRPM, burst)
+		themed.rateLimiter.SetPrefixes(cfg.RateLimitIPv4Prefix, cfg.RateLimitIPv6Prefix)
+	}
+	return &themed
+}
+
+// defaultCache returns the server-wide image cache: the shared LRU, or a
+// deduplicating partition of the same size when deduplication is on, as the
+// shared LRU can't report the evictions it counts on.
+func defaultCache(cfg config.ServerConfig, shared *lru.Cache[string, []byte]) cache.Cache {
+	if cfg.CacheDedup {
+		if partition, err := cache.NewPartition(cfg.CacheSize, 0, true); err == nil {
+			return partition
+		}
+	}
+	return cache.NewShared(shared)
+}
+
+// debugKeys is the number of recent cache keys kept for the admin stats API, which
+// only needs them when it's enabled. Keys can hold initials and text, so none
+// are kept in no-personal-data mode.
+func debugKeys(cfg config.ServerConfig) int {
+	if cfg.AdminToken == "" || cfg.NoPersonalData {
+		return 0
+	}
+	return config.CacheDebugKeys
+}
+
+// newTenantThemes indexes a theme per tenant host.
+func newTenantThemes(base *theme, cfg config.ServerConfig) map[string]*theme {
+	themes := make(map[string]*theme)
+	for id, tenant := range cfg.Tenants {
+		themed := base.withTenant(id, tenant, cfg)
+		for _, host := range tenant.Hosts {
+			themes[host] = themed
+		}
+	}
+	return themes
+}
+
+// themeFor returns the theme of the tenant serving the request's host, or the
+// server-wide theme.
+func (s *Service) themeFor(r *http.Request) *theme {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if t, ok := s.tenantThemes[strings.ToLower(host)]; ok {
+		return t
+	}
+	return s.defaultTheme
+}
+
+// cacheNamespace prefixes a cache key with the request's tenant so tenants never
+// share cached output.
+func (t *theme) cacheNamespace(key string) string {
+	if t.tenantID == "" {
+		return key
+	}
+	return "TENANT:" + t.tenantID + ":" + key
+}
+
+// tenants returns each tenant's theme once, ordered by tenant ID.
+func (s *Service) tenants() []*theme {
+	seen := make(map[string]bool)
+	var themes []*theme
+	for _, t := range s.tenantThemes {
+		if !seen[t.tenantID] {
+			seen[t.tenantID] = true
+			themes = append(themes, t)
+		}
+	}
+	sort.Slice(themes, func(i, j int) bool { return themes[i].tenantID < themes[j].tenantID })
+	return themes
+}
+
+// prioritize gives requests to next their tenant's priority, or the route's
+// when the tenant sets none, for the concurrency limiter.
+func (s *Service) prioritize(priority middleware.Priority, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := priority
+		if t := s.themeFor(r); t.hasPriority {
+			p = t.priority
+		}
+		next.ServeHTTP(w, middleware.WithPriority(r, p))
+	})
+}
+
+// rateLimit wraps next in the rate limiter of the request's tenant when it has
+// its own, and in the server-wide one otherwise.
+func (s *Service) rateLimit(next http.Handler, applyRateLimit func(http.Handler) http.Handler) http.Handler {
+	serverWide := applyRateLimit(next)
+	perTenant := make(map[string]http.Handler)
+	for _, t := range s.tenants() {
+		if t.rateLimiter != nil {
+			perTenant[t.tenantID] = t.rateLimiter.Middleware(next)
+		}
+	}
+	if len(perTenant) == 0 {
+		return serverWide
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := perTenant[s.themeFor(r).tenantID]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		serverWide.ServeHTTP(w, r)
+	})
+}
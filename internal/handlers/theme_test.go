@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/middleware"
+	"grout/internal/render"
+)
+
+// setupTenantTestService returns a service with one tenant, "acme", served on
+// img.acme.test and acme.localhost.
+func setupTenantTestService(t *testing.T) (*Service, *http.ServeMux) {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	quotesPath := filepath.Join(t.TempDir(), "quotes.yaml")
+	if err := os.WriteFile(quotesPath, []byte("acme:\n  - \"Acme quote of the day\"\n"), 0o644); err != nil {
+		t.Fatalf("write quotes: %v", err)
+	}
+
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.Domain = "grout.example.com"
+	cfg.StaticDir = t.TempDir()
+	cfg.Tenants = map[string]config.Tenant{
+		"acme": {
+			Hosts:         []string{"img.acme.test", "acme.localhost"},
+			BrandName:     "Acme Images",
+			BrandColor:    "ff5722",
+			FooterLinks:   []config.FooterLink{{Label: "Acme Status", URL: "https://status.acme.test"}},
+			AvatarBg:      "123456",
+			PlaceholderBg: "abcdef",
+			AccentColor:   "00aa55",
+			Font:          "mono",
+			QuotesFile:    quotesPath,
+			Priority:      "low",
+		},
+	}
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+	return svc, mux
+}
+
+func getWithHost(mux *http.ServeMux, host, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Host = host
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestThemeForHost(t *testing.T) {
+	svc, _ := setupTenantTestService(t)
+
+	tests := []struct {
+		name     string
+		host     string
+		expectID string
+	}{
+		{"tenant host", "img.acme.test", "acme"},
+		{"tenant host with port", "acme.localhost:8080", "acme"},
+		{"tenant host mixed case", "IMG.Acme.Test", "acme"},
+		{"unknown host", "other.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			if got := svc.themeFor(req).tenantID; got != tt.expectID {
+				t.Fatalf("expected tenant %q got %q", tt.expectID, got)
+			}
+		})
+	}
+}
+
+func TestTenantDefaults(t *testing.T) {
+	_, mux := setupTenantTestService(t)
+
+	tests := []struct {
+		name   string
+		target string
+		expect string
+	}{
+		{"avatar background", "/avatar/Jane%20Doe", `fill="#123456"`},
+		{"placeholder background", "/placeholder/300x200", `fill="#abcdef"`},
+		{"calendar header", "/calendar/200x200?date=2025-05-01", `fill="#00aa55"`},
+		{"rating stars", "/rating/4", `#00aa55`},
+		{"divider fill", "/divider/600x80", `#00aa55`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant := getWithHost(mux, "img.acme.test", tt.target)
+			if tenant.Code != http.StatusOK || !strings.Contains(tenant.Body.String(), tt.expect) {
+				t.Fatalf("expected tenant output to contain %q, got %d: %s", tt.expect, tenant.Code, tenant.Body.String())
+			}
+			other := getWithHost(mux, "grout.example.com", tt.target)
+			if strings.Contains(other.Body.String(), tt.expect) {
+				t.Fatalf("expected default output not to contain %q", tt.expect)
+			}
+		})
+	}
+}
+
+func TestTenantCacheKeys(t *testing.T) {
+	_, mux := setupTenantTestService(t)
+	target := "/placeholder/300x200?bg=ff0000"
+
+	first := getWithHost(mux, "grout.example.com", target)
+	tenant := getWithHost(mux, "img.acme.test", target)
+
+	// Identical parameters still render separately per tenant
+	if tenant.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("expected a tenant cache miss, got %s", tenant.Header().Get("X-Cache"))
+	}
+	if first.Header().Get("ETag") == tenant.Header().Get("ETag") {
+		t.Fatal("expected tenants to get distinct ETags")
+	}
+	if again := getWithHost(mux, "acme.localhost", target); again.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("expected hosts of one tenant to share the cache, got %s", again.Header().Get("X-Cache"))
+	}
+}
+
+func TestTenantContentPackAndPages(t *testing.T) {
+	_, mux := setupTenantTestService(t)
+
+	quote := getWithHost(mux, "img.acme.test", "/placeholder/600x300?quote=true")
+	if !strings.Contains(quote.Body.String(), "Acme quote of the day") {
+		t.Fatalf("expected a quote from the tenant content pack, got: %s", quote.Body.String())
+	}
+
+	home := getWithHost(mux, "img.acme.test", "/").Body.String()
+	for _, expect := range []string{"Acme Images", "https://status.acme.test", "https://img.acme.test/", `content="#ff5722"`} {
+		if !strings.Contains(home, expect) {
+			t.Errorf("expected tenant home page to contain %q", expect)
+		}
+	}
+
+	sitemap := getWithHost(mux, "acme.localhost", "/sitemap.xml").Body.String()
+	if !strings.Contains(sitemap, "<loc>https://img.acme.test/play</loc>") {
+		t.Errorf("expected sitemap on the tenant's primary host, got: %s", sitemap)
+	}
+
+	notFound := getWithHost(mux, "img.acme.test", "/missing").Body.String()
+	if !strings.Contains(notFound, "| Acme Images</title>") {
+		t.Error("expected tenant branding on error pages")
+	}
+}
+
+func TestBrandKit(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.Brand = config.Brand{
+		Name:           "Acme",
+		PrimaryColor:   "ff5722",
+		SecondaryColor: "00aa55",
+		Font:           "mono",
+		LogoImage:      image.NewRGBA(image.Rect(0, 0, 80, 40)),
+	}
+	cfg.Templates = map[string]json.RawMessage{"card": json.RawMessage(`{"width":400,"height":200,"root":{"type":"column","children":[
+		{"type":"logo"},
+		{"type":"text","text":"{{brand_name}}","color":"{{brand_secondary|000000}}"}]}}`)}
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		expect []string
+	}{
+		{"avatar background", "/avatar/Jane%20Doe", []string{`fill="#ff5722"`}},
+		{"placeholder background", "/placeholder/300x200", []string{`fill="#ff5722"`}},
+		{"calendar header", "/calendar/200x200?date=2025-05-01", []string{`fill="#00aa55"`}},
+		{"text font", "/text/hello", []string{`font-family="monospace"`}},
+		{"certificate trim and logo", "/certificate/", []string{`stroke="#00aa55"`, `<image `}},
+		{"certificate color param wins", "/certificate/?color=1f6f43", []string{`stroke="#1f6f43"`}},
+		{"ticket trim", "/ticket/", []string{`stroke="#00aa55"`}},
+		{"template variables", "/t/card", []string{">Acme<", `fill="#00aa55"`, `font-family="monospace"`, `<image `}},
+		{"query wins over brand variables", "/t/card?brand_name=Other", []string{">Other<"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q, got: %s", expect, rec.Body.String())
+				}
+			}
+		})
+	}
+
+	// Without a logo, logo nodes are an error
+	_, plain := setupTestService(t)
+	rec := httptest.NewRecorder()
+	plain.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/render", strings.NewReader(`{"width":100,"height":100,"root":{"type":"logo"}}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "brand logo") {
+		t.Errorf("expected 400 for a logo without a brand logo, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDebugKeys(t *testing.T) {
+	tests := []struct {
+		name           string
+		adminToken     string
+		noPersonalData bool
+		expect         int
+	}{
+		{"admin API disabled", "", false, 0},
+		{"admin API enabled", "s3cret", false, config.CacheDebugKeys},
+		{"no personal data", "s3cret", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultServerConfig()
+			cfg.AdminToken = tt.adminToken
+			cfg.NoPersonalData = tt.noPersonalData
+			if got := debugKeys(cfg); got != tt.expect {
+				t.Fatalf("expected %d debug keys, got %d", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestCacheDedup(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	shared, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.CacheDedup = true
+	cfg.Tenants = map[string]config.Tenant{"acme": {Hosts: []string{"img.acme.test"}}}
+	svc := NewService(renderer, shared, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	// Spelling out the default background renders the same image under another key
+	for _, host := range []string{"localhost", "img.acme.test"} {
+		for _, target := range []string{"/placeholder/100x100.png?text=Hi", "/placeholder/100x100.png?text=Hi&bg=cccccc"} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Host = host
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}
+
+	if shared.Len() != 0 {
+		t.Errorf("expected the shared LRU to be replaced, got %d entries", shared.Len())
+	}
+	for _, th := range []*theme{svc.defaultTheme, svc.tenantThemes["img.acme.test"]} {
+		stats := th.cache.Stats()
+		if stats.Entries != 2 || stats.Bytes == 0 || stats.SavedBytes != stats.Bytes {
+			t.Errorf("%s: expected 2 entries sharing one image, got %+v", th.tenantID, stats)
+		}
+	}
+}
+
+func TestRequestPriority(t *testing.T) {
+	svc, _ := setupTenantTestService(t)
+
+	classes := make(map[string]middleware.Priority)
+	for _, rt := range svc.routes() {
+		classes[rt.path] = rt.priority
+	}
+	for path, want := range map[string]middleware.Priority{
+		"/avatar/":      middleware.PriorityHigh,
+		"/api/":         middleware.PriorityHigh,
+		"/placeholder/": middleware.PriorityNormal,
+		"/id/":          middleware.PriorityLow,
+		"/resize":       middleware.PriorityLow,
+	} {
+		if classes[path] != want {
+			t.Errorf("expected %s to be %v priority, got %v", path, want, classes[path])
+		}
+	}
+
+	var got middleware.Priority
+	handler := svc.prioritize(middleware.PriorityHigh, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = middleware.PriorityOf(r)
+	}))
+	for host, want := range map[string]middleware.Priority{
+		"localhost":     middleware.PriorityHigh,
+		"img.acme.test": middleware.PriorityLow,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/avatar/JD", nil)
+		req.Host = host
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if got != want {
+			t.Errorf("expected requests to %s to be %v priority, got %v", host, want, got)
+		}
+	}
+}
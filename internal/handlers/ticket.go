@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+)
+
+// ticketTemplate is the built-in template of /ticket/: the event and details
+// on the left, a perforation line, and a stub with the ticket's barcode. Like the
+// certificate, its sizes are variables scaled to the canvas by ticketVars.
+const ticketTemplate = `{
+	"width": "{{width}}", "height": "{{height}}", "background": "{{bg}}",
+	"border": {"color": "{{color|e53935}}", "width": "{{border}}", "inset": "{{inset}}"},
+	"root": {"type": "row", "gap": "{{gap}}", "children": [
+		{"type": "column", "gap": "{{small_gap}}", "children": [
+			{"type": "text", "text": "ADMIT ONE", "bold": true, "size": "{{label_size}}", "color": "{{color|e53935}}"},
+			{"type": "text", "text": "{{event}}", "bold": true, "size": "{{event_size}}", "color": "222222", "width": "{{main_width}}"},
+			{"type": "row", "gap": "{{gap}}", "children": [
+				{"type": "column", "children": [
+					{"type": "text", "text": "SEAT", "size": "{{label_size}}", "color": "777777"},
+					{"type": "text", "text": "{{seat}}", "bold": true, "size": "{{value_size}}", "color": "222222"}
+				]},
+				{"type": "column", "children": [
+					{"type": "text", "text": "TICKET", "size": "{{label_size}}", "color": "777777"},
+					{"type": "text", "text": "{{code}}", "font": "mono", "size": "{{value_size}}", "color": "222222"}
+				]}
+			]}
+		]},
+		{"type": "shape", "width": "{{rule}}", "height": "{{perforation}}", "dash": "{{dash}}", "color": "999999"},
+		{"type": "column", "gap": "{{small_gap}}", "children": [
+			{"type": "text", "text": "SEAT {{seat}}", "bold": true, "size": "{{label_size}}", "color": "222222"},
+			{"type": "barcode", "text": "{{code}}", "width": "{{stub_width}}", "height": "{{barcode_height}}", "color": "222222"},
+			{"type": "text", "text": "{{code}}", "font": "mono", "size": "{{label_size}}", "color": "222222"}
+		]}
+	]}
+}`
+
+// handleTicket renders a ticket at /ticket/{WxH}[.ext] from the event, seat,
+// and code query parameters.
+func (s *Service) handleTicket(w http.ResponseWriter, r *http.Request) {
+	pathMetric := strings.TrimPrefix(r.URL.Path, "/ticket/")
+
+	// Extract format from path
+	format, pathMetric, err := extractFormat(pathMetric)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	width, height := config.DefaultTicketWidth, config.DefaultTicketHeight
+	if pathMetric != "" {
+		if width, height, err = parseDimensions(r, pathMetric); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+	}
+	r = withRenderCost(r, renderCost{width: width, height: height})
+
+	event, err := s.limitLength(r, "event", r.URL.Query().Get("event"), s.cfg.MaxTextLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if event == "" {
+		event = "Event Name"
+	}
+	seat, err := s.limitLength(r, "seat", r.URL.Query().Get("seat"), s.cfg.MaxNameLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if seat == "" {
+		seat = "GA"
+	}
+	// The code goes into the barcode as it is; the layout rejects codes too long
+	// or outside printable ASCII. Without one, it's derived from the event and seat.
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		code = fmt.Sprintf("%08X", crc32.ChecksumIEEE([]byte(event+"\x00"+seat)))
+	}
+
+	vars := ticketVars(width, height)
+	vars["event"] = event
+	vars["seat"] = seat
+	vars["code"] = code
+	if vars["bg"], err = backgroundParam(r, "ffffff"); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	brandTrim(r, s.themeFor(r), vars)
+	r = withAltText(r, "Ticket for "+event, fmt.Sprintf("Seat %s, ticket code %s", seat, code))
+	s.serveTemplate(w, r, json.RawMessage(ticketTemplate), format, true, vars)
+}
+
+// ticketVars returns the size variables of the ticket template for a width x
+// height canvas, scaled from their sizes on an 800x300 one.
+func ticketVars(width, height int) map[string]string {
+	scale := min(float64(width)/config.DefaultTicketWidth, float64(height)/config.DefaultTicketHeight)
+	px := templatePixels
+	return map[string]string{
+		"width":          strconv.Itoa(width),
+		"height":         strconv.Itoa(height),
+		"border":         px(3 * scale),
+		"inset":          px(12 * scale),
+		"gap":            px(32 * scale),
+		"small_gap":      px(10 * scale),
+		"rule":           px(2 * scale),
+		"dash":           px(8 * scale),
+		"label_size":     px(14 * scale),
+		"event_size":     px(36 * scale),
+		"value_size":     px(22 * scale),
+		"main_width":     px(400 * scale),
+		"perforation":    px(230 * scale),
+		"stub_width":     px(200 * scale),
+		"barcode_height": px(90 * scale),
+	}
+}
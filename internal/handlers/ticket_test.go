@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTicket(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		status      int
+		contentType string
+		expect      []string
+	}{
+		{"defaults", "/ticket/", http.StatusOK, "image/svg+xml", []string{`width="800" height="300"`, ">Event Name<", ">SEAT GA<", `stroke="#e53935"`}},
+		{"parameters", "/ticket/600x240?event=Summer%20Fest&seat=B-12&code=GRT-0042", http.StatusOK, "image/svg+xml", []string{`width="600" height="240"`, ">Summer Fest<", ">B-12<", ">GRT-0042<", `font-family="monospace"`}},
+		{"png", "/ticket/800x300.png", http.StatusOK, "image/png", nil},
+		{"invalid code", "/ticket/?code=caf%C3%A9", http.StatusBadRequest, "text/html; charset=utf-8", []string{"printable ASCII"}},
+		{"long code", "/ticket/?code=" + strings.Repeat("1", 49), http.StatusBadRequest, "text/html; charset=utf-8", []string{"at most 48"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Fatalf("expected content-type %s got %s", tt.contentType, ct)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q, got: %s", expect, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestTicketDefaultCode(t *testing.T) {
+	_, mux := setupTestService(t)
+	body := func(target string) string {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Body.String()
+	}
+	if body("/ticket/?event=A&seat=1") != body("/ticket/?event=A&seat=1") {
+		t.Fatal("expected the same ticket for the same event and seat")
+	}
+	if body("/ticket/?event=A&seat=1") == body("/ticket/?event=A&seat=2") {
+		t.Fatal("expected different codes for different seats")
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	_ "time/tzdata" // Embedded zone data, as the Alpine image has none
+)
+
+// staleAtKey is the request context key of the time an image goes stale: the
+// end of the day it shows, or when the document it's drawn from may change.
+type staleAtKey struct{}
+
+// tzParam returns the time zone named by the tz query parameter, or UTC when
+// the request doesn't set one.
+func tzParam(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return time.UTC, nil
+	}
+	// "Local" is the server's own zone, which tz exists to get away from
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, ErrInvalidParameter.withMessage("Unknown time zone. Use an IANA name such as Europe/Berlin.")
+	}
+	return loc, nil
+}
+
+// today returns the current time in loc, and the request marked to be cached
+// only until the day ends there.
+func (s *Service) today(r *http.Request, loc *time.Location) (time.Time, *http.Request) {
+	now := s.now().In(loc)
+	dayEnd := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	return now, staleAt(r, dayEnd)
+}
+
+// staleAt returns the request marked to be cached only until t.
+func staleAt(r *http.Request, t time.Time) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), staleAtKey{}, t))
+}
+
+// cacheExpiry returns when a response for r stops being valid: when its signed
+// link expires, or when what it shows goes stale, whichever is sooner.
+func (s *Service) cacheExpiry(r *http.Request) (time.Time, bool) {
+	expiry, ok := linkExpiry(r)
+	ok = ok && s.cfg.SigningKey != ""
+	if stale, marked := r.Context().Value(staleAtKey{}).(time.Time); marked && (!ok || stale.Before(expiry)) {
+		return stale, true
+	}
+	return expiry, ok
+}
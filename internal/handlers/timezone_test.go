@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTZParam(t *testing.T) {
+	tests := []struct {
+		tz      string
+		expect  string
+		wantErr bool
+	}{
+		{"", "UTC", false},
+		{"Europe/Berlin", "Europe/Berlin", false},
+		{"Local", "", true},
+		{"Mars/Olympus_Mons", "", true},
+		{"../../etc/passwd", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/calendar/?tz="+tt.tz, nil)
+			loc, err := tzParam(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && loc.String() != tt.expect {
+				t.Errorf("expected %s got %s", tt.expect, loc)
+			}
+		})
+	}
+}
+
+func TestCalendarTimeZone(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.Deterministic = true
+
+	// The pinned time is midnight UTC on 2025-01-01, still the 31st in New York
+	tests := []struct {
+		name   string
+		target string
+		expect []string
+	}{
+		{"UTC", "/calendar/200x200", []string{">JAN<", ">1<", ">Wednesday<"}},
+		{"behind UTC", "/calendar/200x200?tz=America/New_York", []string{">DEC<", ">31<", ">Tuesday<"}},
+		{"ahead of UTC", "/calendar/200x200?tz=Asia/Tokyo", []string{">JAN<", ">1<"}},
+		{"certificate", "/certificate/?tz=America/New_York", []string{">December 31, 2024<"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(rec.Body.String(), expect) {
+					t.Errorf("expected body to contain %q, got: %s", expect, rec.Body.String())
+				}
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar/200x200?tz=Nowhere/City", nil))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Error-Code") != "invalid_parameter" {
+		t.Errorf("expected 400 invalid_parameter for an unknown zone, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
+
+func TestCalendarTodayCacheControl(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	// Today's tile is cached until the day ends in its time zone
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar/200x200?tz=Asia/Tokyo", nil))
+	maxAge, ok := strings.CutPrefix(rec.Header().Get("Cache-Control"), "public, max-age=")
+	if seconds, err := strconv.Atoi(maxAge); !ok || err != nil || seconds < 0 || seconds > 86400 {
+		t.Errorf("expected a max-age of at most a day, got %q", rec.Header().Get("Cache-Control"))
+	}
+
+	// A fixed date never changes
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar/200x200?date=2025-05-01&tz=Asia/Tokyo", nil))
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Errorf("expected an immutable response for a fixed date, got %q", cc)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"grout/internal/config"
+	"grout/internal/render"
+	"grout/internal/utils"
+)
+
+// uiAvatarsSegments lists the parameters of the ui-avatars.com path syntax,
+// /api/{name}/{size}/{background}/..., in order.
+var uiAvatarsSegments = []string{"name", "size", "background", "color", "length", "font-size", "rounded", "uppercase", "bold", "format"}
+
+// uiAvatarsParams merges the path segments of a ui-avatars.com URL into its query
+// parameters. Query parameters win over path segments.
+func uiAvatarsParams(r *http.Request) url.Values {
+	params := url.Values{}
+	// Segments that don't decode are left out, like unknown ones
+	segments, _ := pathSegments(r, "/api/")
+	for i, segment := range segments {
+		if i >= len(uiAvatarsSegments) || segment == "" {
+			continue
+		}
+		params.Set(uiAvatarsSegments[i], segment)
+	}
+	for key, values := range r.URL.Query() {
+		params[key] = values
+	}
+	return params
+}
+
+// uiAvatarsBool parses a ui-avatars boolean parameter.
+func uiAvatarsBool(value string, def bool) bool {
+	switch strings.ToLower(value) {
+	case "true", "1", "yes":
+		return true
+	case "false", "0", "no":
+		return false
+	}
+	return def
+}
+
+// uiAvatarsInitials derives up to length initials the way ui-avatars.com does: the
+// first letters of a single word, or one letter per word with the last position
+// taken from the last word (so "John Ronald Tolkien" gives "JT").
+func uiAvatarsInitials(name string, length int, uppercase bool) string {
+	name = strings.TrimSpace(name)
+	if uppercase {
+		name = strings.ToUpper(name)
+	}
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		runes := []rune(name)
+		return string(runes[:min(length, len(runes))])
+	}
+
+	// Past the longest word's letters, no position adds another
+	longest := 0
+	for _, word := range words {
+		longest = max(longest, utf8.RuneCountInString(word))
+	}
+
+	var initials []rune
+	start, assigned := 0, 0
+	for i := 0; i < length && start < longest; i++ {
+		index := i
+		if (index == length-1 && index > 0) || index > len(words)-1 {
+			index = len(words) - 1
+		}
+		// Once every word has given a letter, continue with their next letters
+		if assigned >= len(words) {
+			start++
+		}
+		if runes := []rune(words[index]); start < len(runes) {
+			initials = append(initials, runes[start])
+		}
+		assigned++
+	}
+	return string(initials)
+}
+
+// handleUIAvatars serves /api/ with the parameters of ui-avatars.com, so apps can
+// switch to Grout by changing only the hostname.
+func (s *Service) handleUIAvatars(w http.ResponseWriter, r *http.Request) {
+	params := uiAvatarsParams(r)
+
+	name := normalizeName(params.Get("name"))
+	if name == "" {
+		name = "John Doe"
+	}
+	name, err := s.limitLength(r, "name", name, s.cfg.MaxNameLength)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+
+	size := utils.ParseIntOrDefault(params.Get("size"), config.UIAvatarsDefaultSize)
+	size = clampParam(r, "size", size, config.UIAvatarsMinSize, config.UIAvatarsMaxSize)
+	length := utils.ParseIntOrDefault(params.Get("length"), config.UIAvatarsDefaultLength)
+	length = clampParam(r, "length", length, 1, config.UIAvatarsMaxLength)
+
+	fontScale := config.UIAvatarsDefaultFontSize
+	if f, err := strconv.ParseFloat(params.Get("font-size"), 64); err == nil {
+		fontScale = clampParam(r, "font-size", f, config.UIAvatarsMinFontSize, config.UIAvatarsMaxFontSize)
+	}
+
+	rounded := uiAvatarsBool(params.Get("rounded"), false)
+	bold := uiAvatarsBool(params.Get("bold"), false)
+	uppercase := uiAvatarsBool(params.Get("uppercase"), true)
+
+	// PNG unless SVG is requested, like ui-avatars.com
+	format := render.FormatPNG
+	if strings.EqualFold(params.Get("format"), "svg") {
+		format = render.FormatSVG
+	}
+	format = emailFormat(r, format)
+
+	defaultBg := s.themeFor(r).avatarBg
+	bgHex, err := resolveColor("background", params.Get("background"), "random")
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if bgHex == "" {
+		bgHex = defaultBg
+	}
+	if strings.EqualFold(bgHex, "random") {
+		bgHex = s.hashColor("", name, name)
+	}
+	fgHex, err := resolveColor("color", params.Get("color"))
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if fgHex == "" {
+		fgHex = avatarTextColor(bgHex)
+	}
+
+	initials := uiAvatarsInitials(name, length, uppercase)
+	fontSize := float64(size) * fontScale
+	key := specKey("UIA", avatarSpec{
+		Initials: initials, Size: size, Rounded: rounded, Bold: bold, Bg: bgHex, Fg: fgHex,
+		FontScale: fontScale, Format: format,
+	})
+	s.serveImage(w, r, key, format, func(ctx context.Context) ([]byte, error) {
+		return s.renderer.DrawImageWithFontSize(ctx, size, size, bgHex, fgHex, initials, rounded, bold, fontSize, format)
+	})
+}
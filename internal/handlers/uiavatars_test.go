@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUIAvatarsInitials(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		length    int
+		uppercase bool
+		expect    string
+	}{
+		{"two words", "John Doe", 2, true, "JD"},
+		{"three words use the last", "John Ronald Tolkien", 2, true, "JT"},
+		{"single word", "elon", 2, true, "EL"},
+		{"single letter", "john doe", 1, true, "J"},
+		{"longer than words", "John Doe", 3, true, "JDO"},
+		{"lowercase kept", "john doe", 2, false, "jd"},
+		{"short single word", "Al", 4, true, "AL"},
+		{"every letter used", "Al Bo", 1_000_000_000, true, "ABO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uiAvatarsInitials(tt.input, tt.length, tt.uppercase); got != tt.expect {
+				t.Fatalf("expected %q got %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestUIAvatarsHandler(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name         string
+		target       string
+		expectType   string
+		expectSubstr []string
+	}{
+		{"defaults to PNG", "/api/?name=John+Doe", "image/png", nil},
+		{"svg with ui-avatars defaults", "/api/?name=John+Doe&format=svg", "image/svg+xml", []string{`fill="#f0e9e9"`, `fill="#8b5d5d"`, ">JD<", `font-size="32"`}},
+		{"length and case", "/api/?name=john+doe&length=1&uppercase=false&format=svg", "image/svg+xml", []string{">j<"}},
+		{"font-size and bold", "/api/?name=Jane&size=100&font-size=0.33&bold=true&format=svg", "image/svg+xml", []string{`font-size="33"`, `font-weight="bold"`, ">JA<"}},
+		{"rounded with colors", "/api/?name=A+B&rounded=true&background=0D8ABC&color=fff&format=svg", "image/svg+xml", []string{"<circle", `fill="#0D8ABC"`, `fill="#fff"`}},
+		{"size is clamped", "/api/?name=A+B&size=9999&format=svg", "image/svg+xml", []string{`width="512"`}},
+		{"length is clamped", "/api/?name=Alexander+Smith&length=2000000000&format=svg", "image/svg+xml", []string{">ASMITH<"}},
+		{"path segments", "/api/Jane+Smith/128/000000/ffffff/2/0.5/true/true/false/svg", "image/svg+xml", []string{`width="128"`, "<circle", ">JS<"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200 got %d", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.expectType {
+				t.Fatalf("expected content-type %s got %s", tt.expectType, ct)
+			}
+			for _, substr := range tt.expectSubstr {
+				if !strings.Contains(rec.Body.String(), substr) {
+					t.Errorf("expected body to contain %q, got: %s", substr, rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestUIAvatarsDefaultSize(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/?name=John+Doe", nil))
+
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Fatalf("expected the ui-avatars default of 64px, got %v", b)
+	}
+}
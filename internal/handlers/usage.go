@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"grout/internal/config"
+	"grout/internal/kvstore"
+)
+
+// usageKeyPrefix namespaces the monthly render counters of tenants in the
+// store, and keyUsageKeyPrefix those of API keys.
+const (
+	usageKeyPrefix    = "usage:"
+	keyUsageKeyPrefix = "usage-key:"
+)
+
+// apiKeyHeader is the request header carrying the API key a render is counted
+// against.
+const apiKeyHeader = "X-API-Key"
+
+// usageMonth names the calendar month of t in UTC, the period quotas cover.
+func usageMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+func usageKey(tenantID, month string) string {
+	return usageKeyPrefix + tenantID + ":" + month
+}
+
+func keyUsageKey(name, month string) string {
+	return keyUsageKeyPrefix + name + ":" + month
+}
+
+// renderQuota is the monthly render quota a request is counted against: its
+// API key's, or its tenant's when it sends none.
+type renderQuota struct {
+	// owner names the key or tenant in logs, and holder in the error of a
+	// request over the limit
+	owner, holder string
+	// prefix is the store key of the quota's counters, less the month
+	prefix      string
+	limit, soft int
+}
+
+// quotaFor returns the quota a request is counted against, and false when it's
+// counted against none: it sends no API key and is outside any tenant. A
+// request with a key the server doesn't know is refused.
+func (s *Service) quotaFor(r *http.Request, t *theme) (renderQuota, bool, error) {
+	if sent := r.Header.Get(apiKeyHeader); sent != "" {
+		for name, key := range s.cfg.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(key.Key)) == 1 {
+				return renderQuota{owner: "API key " + name, holder: "This API key", prefix: keyUsageKey(name, ""), limit: key.RenderQuota, soft: key.RenderQuotaSoft}, true, nil
+			}
+		}
+		return renderQuota{}, false, ErrUnauthorized.withMessage("Unknown API key.")
+	}
+	if t.tenantID == "" {
+		return renderQuota{}, false, nil
+	}
+	return renderQuota{owner: "tenant " + t.tenantID, holder: "This site", prefix: usageKey(t.tenantID, ""), limit: t.renderQuota, soft: t.renderQuotaSoft}, true, nil
+}
+
+// quotaCharge is a render chargeRender counted, for refundRender to give back
+// when the image isn't served.
+type quotaCharge struct {
+	owner, key string
+}
+
+// quotaReset returns when the quota of t's month resets: the start of the next
+// month, in UTC.
+func quotaReset(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// chargeRender counts an image request against its API key's or tenant's
+// render quota for the month, and reports the quota in headers. Once the hard
+// limit is reached requests are refused, and aren't counted. Requests without
+// a quota aren't counted, and get a nil charge. A store that fails doesn't
+// take images down with it: the request is logged and served uncounted.
+func (s *Service) chargeRender(w http.ResponseWriter, r *http.Request, t *theme) (*quotaCharge, error) {
+	quota, ok, err := s.quotaFor(r, t)
+	if !ok {
+		return nil, err
+	}
+	now := s.now()
+	charge := &quotaCharge{owner: quota.owner, key: quota.prefix + usageMonth(now)}
+	used, err := s.store.Incr(r.Context(), charge.key, 1, config.UsageRetention)
+	if err != nil {
+		log.Printf("count render for %s: %v", quota.owner, err)
+		return nil, nil
+	}
+	reset := quotaReset(now)
+	if quota.limit > 0 {
+		w.Header().Set("X-Quota-Limit", strconv.Itoa(quota.limit))
+		w.Header().Set("X-Quota-Remaining", strconv.FormatInt(max(int64(quota.limit)-used, 0), 10))
+		w.Header().Set("X-Quota-Reset", strconv.FormatInt(reset.Unix(), 10))
+	}
+	if quota.limit > 0 && used > int64(quota.limit) {
+		// Give back the request being refused, so the count is what was served
+		s.refundRender(r.Context(), charge)
+		w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())))
+		return nil, ErrQuotaExceeded.withMessage("%s has used its %d images for the month. The quota resets on %s.", quota.holder, quota.limit, reset.Format("January 2"))
+	}
+	if quota.soft > 0 && used > int64(quota.soft) {
+		w.Header().Set("X-Quota-Warning", "soft limit of "+strconv.Itoa(quota.soft)+" images this month passed")
+	}
+	return charge, nil
+}
+
+// refundRender gives back a render chargeRender counted whose image wasn't
+// served: it was refused, failed, or timed out. The store is shared, so another
+// request may have counted since: it takes back one rather than setting the
+// count. A nil charge is a no-op.
+func (s *Service) refundRender(ctx context.Context, charge *quotaCharge) {
+	if charge == nil {
+		return
+	}
+	if _, err := s.store.Incr(context.WithoutCancel(ctx), charge.key, -1, config.UsageRetention); err != nil {
+		log.Printf("uncount render for %s: %v", charge.owner, err)
+	}
+}
+
+// quotaUsage is the JSON body of the admin usage API: the image requests a
+// tenant or API key was served this month, against its quota.
+type quotaUsage struct {
+	Tenant string `json:"tenant,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+	Month  string `json:"month"`
+	Used   int64  `json:"used"`
+	// SoftLimit and Limit are the quotas, 0 for none; Remaining is sent when
+	// there's a hard limit
+	SoftLimit int       `json:"soft_limit"`
+	Limit     int       `json:"limit"`
+	Remaining *int64    `json:"remaining,omitempty"`
+	ResetsAt  time.Time `json:"resets_at"`
+	// History counts the requests of the earlier months still kept, by month
+	History map[string]int64 `json:"history,omitempty"`
+}
+
+// handleAdminUsage reports a tenant's render usage this month and in the months
+// before it, up to config.UsageRetention.
+func (s *Service) handleAdminUsage(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	id := r.PathValue("tenant")
+	var t *theme
+	for _, candidate := range s.tenants() {
+		if candidate.tenantID == id {
+			t = candidate
+		}
+	}
+	if t == nil {
+		s.failJSON(w, r, ErrNotFound.withMessage("no tenant %q on this server", id))
+		return
+	}
+	s.writeUsage(w, r, quotaUsage{Tenant: id, SoftLimit: t.renderQuotaSoft, Limit: t.renderQuota}, usageKey(id, ""))
+}
+
+// handleAdminKeyUsage reports an API key's render usage this month and in the
+// months before it, up to config.UsageRetention.
+func (s *Service) handleAdminKeyUsage(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	name := r.PathValue("key")
+	key, ok := s.cfg.APIKeys[name]
+	if !ok {
+		s.failJSON(w, r, ErrNotFound.withMessage("no API key %q on this server", name))
+		return
+	}
+	s.writeUsage(w, r, quotaUsage{APIKey: name, SoftLimit: key.RenderQuotaSoft, Limit: key.RenderQuota}, keyUsageKey(name, ""))
+}
+
+// writeUsage fills in usage from the counters under prefix, and writes it.
+func (s *Service) writeUsage(w http.ResponseWriter, r *http.Request, usage quotaUsage, prefix string) {
+	now := s.now()
+	usage.Month, usage.ResetsAt = usageMonth(now), quotaReset(now)
+	keys, err := s.store.Keys(r.Context(), prefix)
+	if err != nil {
+		s.failJSON(w, r, err)
+		return
+	}
+	for _, key := range keys {
+		value, err := s.store.Get(r.Context(), key)
+		if errors.Is(err, kvstore.ErrNotFound) {
+			// Expired since it was listed
+			continue
+		}
+		if err != nil {
+			s.failJSON(w, r, err)
+			return
+		}
+		used, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		month := strings.TrimPrefix(key, prefix)
+		if month == usage.Month {
+			usage.Used = used
+			continue
+		}
+		if usage.History == nil {
+			usage.History = make(map[string]int64)
+		}
+		usage.History[month] = used
+	}
+	if usage.Limit > 0 {
+		remaining := max(int64(usage.Limit)-usage.Used, 0)
+		usage.Remaining = &remaining
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, usage)
+}
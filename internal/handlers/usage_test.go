@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/kvstore"
+	"grout/internal/middleware"
+	"grout/internal/render"
+)
+
+func setupUsageTestService(t *testing.T, store kvstore.Store) *http.ServeMux {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.AdminToken = testAdminToken
+	// Usage is counted by the month of the fixed deterministic time
+	cfg.Deterministic = true
+	cfg.Store = store
+	cfg.Tenants = map[string]config.Tenant{
+		"acme":  {Hosts: []string{"img.acme.test"}, RenderQuota: 3, RenderQuotaSoft: 2},
+		"other": {Hosts: []string{"img.other.test"}},
+	}
+	cfg.APIKeys = map[string]config.APIKey{
+		"free": {Key: "free-key", RenderQuota: 2},
+	}
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, middleware.NewRateLimiter(cfg.RateLimitRPM, cfg.RateLimitBurst))
+	return mux
+}
+
+func TestRenderQuota(t *testing.T) {
+	mux := setupUsageTestService(t, kvstore.NewMemory())
+	get := func(host, target, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("img.acme.test", "/placeholder/40x40?text=1", "")
+	if first.Code != http.StatusOK || first.Header().Get("X-Quota-Limit") != "3" || first.Header().Get("X-Quota-Remaining") != "2" {
+		t.Fatalf("expected the first image with 2 remaining of 3, got %d %v", first.Code, first.Header())
+	}
+	if reset := first.Header().Get("X-Quota-Reset"); reset != "1738368000" {
+		t.Errorf("expected the quota to reset on February 1, got %q", reset)
+	}
+	// Revalidations and requests outside the tenant are free
+	if rec := get("img.acme.test", "/placeholder/40x40?text=1", first.Header().Get("ETag")); rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for a revalidation, got %d", rec.Code)
+	}
+	if rec := get("example.com", "/placeholder/40x40?text=1", ""); rec.Header().Get("X-Quota-Limit") != "" {
+		t.Fatalf("expected no quota outside a tenant, got %v", rec.Header())
+	}
+	if rec := get("img.other.test", "/placeholder/40x40?text=1", ""); rec.Code != http.StatusOK || rec.Header().Get("X-Quota-Limit") != "" {
+		t.Fatalf("expected no quota headers for a tenant without a quota, got %d %v", rec.Code, rec.Header())
+	}
+
+	tests := []struct {
+		target    string
+		status    int
+		remaining string
+		warned    bool
+	}{
+		{"/placeholder/40x40?text=2", http.StatusOK, "1", false},
+		{"/placeholder/40x40?text=3", http.StatusOK, "0", true},
+		{"/placeholder/40x40?text=4", http.StatusTooManyRequests, "0", true},
+		// Cached images count too
+		{"/placeholder/40x40?text=1", http.StatusTooManyRequests, "0", true},
+	}
+	for _, tt := range tests {
+		rec := get("img.acme.test", tt.target, "")
+		if rec.Code != tt.status || rec.Header().Get("X-Quota-Remaining") != tt.remaining {
+			t.Fatalf("%s: expected %d with %s remaining, got %d %v", tt.target, tt.status, tt.remaining, rec.Code, rec.Header())
+		}
+		if warned := rec.Header().Get("X-Quota-Warning") != ""; warned != tt.warned && tt.status == http.StatusOK {
+			t.Errorf("%s: expected warning %v, got %q", tt.target, tt.warned, rec.Header().Get("X-Quota-Warning"))
+		}
+		if tt.status == http.StatusTooManyRequests {
+			if code := rec.Header().Get("X-Error-Code"); code != "quota_exceeded" {
+				t.Errorf("%s: expected quota_exceeded, got %q", tt.target, code)
+			}
+			if rec.Header().Get("Retry-After") == "" {
+				t.Errorf("%s: expected Retry-After until the quota resets", tt.target)
+			}
+		}
+	}
+}
+
+func TestAdminUsage(t *testing.T) {
+	store := kvstore.NewMemory()
+	if err := store.Set(context.Background(), "usage:acme:2024-12", []byte("7"), 0); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	mux := setupUsageTestService(t, store)
+	for _, text := range []string{"a", "b", "c", "d"} {
+		req := httptest.NewRequest(http.MethodGet, "/placeholder/40x40?text="+text, nil)
+		req.Host = "img.acme.test"
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if rec := adminRequest(mux, http.MethodGet, "/api/v1/admin/usage/acme", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := adminRequest(mux, http.MethodGet, "/api/v1/admin/usage/nobody", "", testAdminToken); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown tenant, got %d", rec.Code)
+	}
+
+	rec := adminRequest(mux, http.MethodGet, "/api/v1/admin/usage/acme", "", testAdminToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var usage quotaUsage
+	if err := json.NewDecoder(rec.Body).Decode(&usage); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	remaining := int64(0)
+	want := quotaUsage{
+		Tenant:    "acme",
+		Month:     "2025-01",
+		Used:      3, // The refused fourth request isn't counted
+		SoftLimit: 2,
+		Limit:     3,
+		Remaining: &remaining,
+		ResetsAt:  time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+		History:   map[string]int64{"2024-12": 7},
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Fatalf("expected %+v, got %+v", want, usage)
+	}
+
+	rec = adminRequest(mux, http.MethodGet, "/api/v1/admin/usage/other", "", testAdminToken)
+	var other quotaUsage
+	if err := json.NewDecoder(rec.Body).Decode(&other); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if other.Used != 0 || other.Limit != 0 || other.Remaining != nil {
+		t.Fatalf("expected an unused tenant without a quota, got %+v", other)
+	}
+}
+
+func TestAPIKeyQuota(t *testing.T) {
+	mux := setupUsageTestService(t, kvstore.NewMemory())
+	get := func(host, key, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = host
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A key is counted on its own, on any host, and instead of the tenant
+	tests := []struct {
+		host, key string
+		status    int
+		remaining string
+	}{
+		{"example.com", "free-key", http.StatusOK, "1"},
+		{"img.acme.test", "free-key", http.StatusOK, "0"},
+		{"example.com", "free-key", http.StatusTooManyRequests, "0"},
+		{"img.acme.test", "", http.StatusOK, "2"},
+		{"example.com", "wrong-key", http.StatusUnauthorized, ""},
+	}
+	for i, tt := range tests {
+		rec := get(tt.host, tt.key, "/placeholder/40x40?text="+strconv.Itoa(i))
+		if rec.Code != tt.status || rec.Header().Get("X-Quota-Remaining") != tt.remaining {
+			t.Fatalf("%s with key %q: expected %d with %q remaining, got %d %v", tt.host, tt.key, tt.status, tt.remaining, rec.Code, rec.Header())
+		}
+	}
+
+	rec := adminRequest(mux, http.MethodGet, "/api/v1/admin/usage/keys/free", "", testAdminToken)
+	var usage quotaUsage
+	if err := json.NewDecoder(rec.Body).Decode(&usage); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusOK || usage.APIKey != "free" || usage.Used != 2 || usage.Limit != 2 {
+		t.Fatalf("expected the key to have used 2 of 2, got %d %+v", rec.Code, usage)
+	}
+	if rec := adminRequest(mux, http.MethodGet, "/api/v1/admin/usage/keys/nobody", "", testAdminToken); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown key, got %d", rec.Code)
+	}
+}
+
+func TestRenderQuotaRefund(t *testing.T) {
+	mux := setupUsageTestService(t, kvstore.NewMemory())
+
+	// An image that fails to render isn't counted
+	req := httptest.NewRequest(http.MethodGet, "/placeholder/40x40.svg?maxBytes=10", nil)
+	req.Header.Set("X-API-Key", "free-key")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rec.Code)
+	}
+
+	rec = adminRequest(mux, http.MethodGet, "/api/v1/admin/usage/keys/free", "", testAdminToken)
+	var usage quotaUsage
+	if err := json.NewDecoder(rec.Body).Decode(&usage); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if usage.Used != 0 {
+		t.Fatalf("expected the failed render to be given back, got %d used", usage.Used)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// validation is the JSON body of the validate API: whether an image URL would
+// render, its canonical form, and what was read differently from how it was
+// written.
+type validation struct {
+	Valid bool `json:"valid"`
+	// URL is the path and query of the image in canonical form, or as given
+	// when it's signed
+	URL         string `json:"url,omitempty"`
+	Format      string `json:"format,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	// Warnings are changes made to the request to serve it, such as a size
+	// cut to its maximum
+	Warnings []string          `json:"warnings"`
+	Errors   []validationError `json:"errors"`
+}
+
+// validationError is why an image URL wouldn't render.
+type validationError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// handleValidate checks an image URL before it's saved, for CMSes and other
+// integrations taking URLs from their users: GET /api/v1/validate?url={url},
+// where url is a path such as /placeholder/300x200.png or an absolute URL on
+// one of this instance's hosts. The URL is read by its endpoint as a request
+// for an explanation, so nothing is rendered, and signatures are checked when
+// signing is on. A URL that wouldn't render is still a 200, with valid false.
+func (s *Service) handleValidate(w http.ResponseWriter, r *http.Request) {
+	spec := r.URL.Query().Get("url")
+	if spec == "" {
+		s.failJSON(w, r, ErrMissingParameter.withMessage("Missing url. Give the image URL to validate, such as /placeholder/300x200.png."))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	result := validation{Warnings: []string{}, Errors: []validationError{}}
+	invalid := func(e *requestError) {
+		result.Errors = append(result.Errors, validationError{Code: e.code, Message: e.message})
+		writeJSON(w, http.StatusOK, result)
+	}
+
+	path, err := s.specPath(r, spec)
+	switch {
+	case errors.Is(err, errForeignSpec):
+		invalid(ErrInvalidURL.withMessage("The URL is not on this instance."))
+		return
+	case err != nil:
+		invalid(ErrInvalidURL.withMessage("Invalid url. Give a path such as /placeholder/300x200.png, or an absolute URL on this instance."))
+		return
+	}
+	target, _ := url.Parse(path)
+	if strings.HasPrefix(target.Path, "/api/v1/") {
+		invalid(ErrNotFound.withMessage("The URL is not an image URL."))
+		return
+	}
+	query := target.Query()
+	query.Del("async")
+	query.Del("explain")
+	if query.Has("sig") {
+		// Rewriting a signed URL would void its signature
+		result.URL = target.RequestURI()
+	} else {
+		result.URL = canonicalURL(&url.URL{Path: target.Path, RawQuery: query.Encode()})
+	}
+
+	// The signature covers the URL as given, so it's checked before explain
+	// is added
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		explained := r.URL.Query()
+		explained.Set("explain", "true")
+		r.URL.RawQuery = explained.Encode()
+		s.imageRoutes().ServeHTTP(w, r)
+	})
+	if s.cfg.SigningKey != "" {
+		h = s.requireSignature(h)
+	}
+	req := r
+	if u, _ := url.Parse(spec); u.Host != "" {
+		// Read the URL as the tenant whose host it names
+		req = r.Clone(r.Context())
+		req.Host = u.Host
+	}
+	target.RawQuery = query.Encode()
+	// The validate route holds the request's concurrency slot
+	rec, rendering := serveInternal(req, h, target, true)
+	result.Warnings = append(result.Warnings, rendering.warnings...)
+
+	switch {
+	case rendering.err != nil:
+		invalid(rendering.err)
+		return
+	case rec.status >= http.StatusMultipleChoices && rec.status < http.StatusBadRequest:
+		result.Valid = true
+		result.Warnings = append(result.Warnings, "The URL redirects to "+rec.header.Get("Location"))
+		writeJSON(w, http.StatusOK, result)
+		return
+	case rec.status == http.StatusNotFound:
+		invalid(ErrNotFound.withMessage("No image endpoint serves this URL."))
+		return
+	case rec.status != http.StatusOK:
+		invalid(ErrRenderFailed.withMessage("The URL can't be rendered: %s.", http.StatusText(rec.status)))
+		return
+	}
+
+	contentType := rec.header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" {
+		// The endpoint rendered the image, as it has no explanation to give
+		result.Valid = true
+		result.ContentType = contentType
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+	var details explanation
+	if err := json.Unmarshal(rec.body.Bytes(), &details); err != nil {
+		invalid(ErrRenderFailed.withMessage("The URL can't be read.").withCause(err))
+		return
+	}
+	if budget := s.cfg.RenderBudget; budget > 0 && details.RenderCost > budget {
+		invalid(ErrTooComplex.withMessage("The image is too complex to render: %d pixels drawn, over this server's budget of %d. Try a smaller size, a shorter animation, no effect, or no supersampling.", details.RenderCost, budget))
+		return
+	}
+	result.Valid = true
+	result.Format = details.Format
+	result.ContentType = details.ContentType
+	result.Width, result.Height = details.Width, details.Height
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// validate asks mux to validate target, and decodes the result.
+func validate(t *testing.T, mux *http.ServeMux, target string) validation {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/validate?url="+url.QueryEscape(target), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result validation
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return result
+}
+
+func TestValidateAPI(t *testing.T) {
+	_, mux := setupTestService(t)
+
+	tests := []struct {
+		name    string
+		target  string
+		url     string
+		format  string
+		width   int
+		warning string
+	}{
+		{"placeholder", "/placeholder/300x200.png?text=Hi", "/placeholder/300x200.png?text=Hi", "png", 300, ""},
+		{"normalized", "/placeholder/300x200.JPEG/?text=Hi&bg=FF0000", "/placeholder/300x200.jpg?bg=ff0000&text=Hi", "jpeg", 300, ""},
+		{"absolute URL on this instance", "http://example.com/avatar/Jane.svg?size=64", "/avatar/Jane.svg?size=64", "svg", 64, ""},
+		{"explain and async dropped", "/avatar/Jane?explain=true&async=true", "/avatar/Jane", "svg", 128, ""},
+		{"text cut", "/placeholder/300x200?text=" + strings.Repeat("a", 250), "", "svg", 300, "text is cut to 200 characters"},
+		{"size clamped", "/api/?name=Jane+Doe&size=1000&format=png", "", "png", 0, "size 1000 is out of range, so 512 is used"},
+		{"email scaled", "/placeholder/3000x2000.png?email=true", "", "png", 1200, "email=true scales 3000x2000 down to 1200x800"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validate(t, mux, tt.target)
+			if !result.Valid || len(result.Errors) != 0 {
+				t.Fatalf("expected a valid URL, got %+v", result)
+			}
+			if tt.url != "" && result.URL != tt.url {
+				t.Errorf("expected the URL %s, got %s", tt.url, result.URL)
+			}
+			if result.Format != tt.format || result.Width != tt.width {
+				t.Errorf("expected %s at width %d, got %s at %d", tt.format, tt.width, result.Format, result.Width)
+			}
+			if tt.warning == "" && len(result.Warnings) != 0 {
+				t.Errorf("expected no warnings, got %v", result.Warnings)
+			}
+			if tt.warning != "" && (len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], tt.warning)) {
+				t.Errorf("expected the warning %q, got %v", tt.warning, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestValidateAPIErrors(t *testing.T) {
+	svc, mux := setupTestService(t)
+	svc.cfg.RenderBudget = 1_000_000
+
+	tests := []struct {
+		name   string
+		target string
+		code   string
+	}{
+		{"bad scheme", "/placeholder/300x200?scheme=sepia", "invalid_parameter"},
+		{"unsupported extension", "/placeholder/300x200.bmp", "unsupported_format"},
+		{"no such endpoint", "/nothing/here/at/all", "not_found"},
+		{"API route", "/api/v1/diff?a=x&b=y", "not_found"},
+		{"foreign host", "https://other.example.org/placeholder/10x10.png", "invalid_url"},
+		{"over budget", "/placeholder/2000x2000.gif?animate=typewriter&text=Hello%20there", "too_complex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validate(t, mux, tt.target)
+			if result.Valid || len(result.Errors) != 1 || result.Errors[0].Code != tt.code {
+				t.Fatalf("expected the error %s, got %+v", tt.code, result)
+			}
+			if result.Errors[0].Message == "" {
+				t.Error("expected an error message")
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/validate", nil))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Error-Code") != "missing_parameter" {
+		t.Fatalf("expected a 400 missing_parameter without url, got %d %s", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
+
+func TestValidateAPISigned(t *testing.T) {
+	mux := setupSigningTestService(t)
+
+	if result := validate(t, mux, "/placeholder/300x200.png"); result.Valid || result.Errors[0].Code != "invalid_signature" {
+		t.Fatalf("expected an unsigned URL to fail, got %+v", result)
+	}
+	target := signed(t, "/placeholder/300x200.png?text=Hi&bg=FF0000")
+	result := validate(t, mux, target)
+	if !result.Valid {
+		t.Fatalf("expected a signed URL to pass, got %+v", result)
+	}
+	if result.URL != target {
+		t.Errorf("expected a signed URL to be left as is, got %s", result.URL)
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// versionReport is the JSON body of /version: the server's version and what
+// its build can render.
+type versionReport struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	render.Capabilities
+}
+
+// handleVersion reports the server's version and the capabilities of its build,
+// such as whether it was built with cgo and which WebP encoder it uses.
+func (s *Service) handleVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache")
+	writeJSON(w, http.StatusOK, versionReport{
+		Version:      config.Version,
+		GoVersion:    runtime.Version(),
+		Capabilities: render.BuildCapabilities(),
+	})
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+func TestVersion(t *testing.T) {
+	_, mux := setupTestService(t)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var report versionReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Version != config.Version || report.GoVersion == "" || report.Platform == "" {
+		t.Errorf("expected the version, Go version, and platform, got %+v", report)
+	}
+	if !slices.Contains(report.Formats, render.FormatPNG) {
+		t.Errorf("expected png among the formats, got %v", report.Formats)
+	}
+	if report.WebP.Backend == render.WebPOff || !slices.Contains(report.Formats, render.FormatWebP) {
+		t.Errorf("expected a WebP backend by default, got %+v", report.WebP)
+	}
+}
+
+func TestVersionWebPOff(t *testing.T) {
+	if err := render.UseWebPBackend(render.WebPOff); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = render.UseWebPBackend("") })
+	_, mux := setupTestService(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	var report versionReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.WebP.Backend != render.WebPOff || slices.Contains(report.Formats, render.FormatWebP) {
+		t.Errorf("expected WebP to be off, got %+v with formats %v", report.WebP, report.Formats)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/placeholder/40x40.webp", nil))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("X-Error-Code") != "unsupported_format" {
+		t.Errorf("expected 400 unsupported_format for WebP, got %d %q", rec.Code, rec.Header().Get("X-Error-Code"))
+	}
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+// warmupNames returns a name for each avatar the warmup renders: every single
+// letter, and every pair of the most common initials.
+func warmupNames() []string {
+	var names []string
+	for c := 'A'; c <= 'Z'; c++ {
+		names = append(names, string(c))
+	}
+	for _, first := range config.WarmupPairLetters {
+		for _, last := range config.WarmupPairLetters {
+			names = append(names, string(first)+" "+string(last))
+		}
+	}
+	return names
+}
+
+// WarmAvatars renders the most common initials avatars, in the default size and
+// format, into the server-wide cache so the first requests for them are hits. It
+// returns the number of avatars rendered.
+func (s *Service) WarmAvatars() int {
+	var rendered int
+	for _, name := range warmupNames() {
+		req, err := http.NewRequest(http.MethodGet, "/avatar/?name="+url.QueryEscape(name), nil)
+		if err != nil {
+			continue
+		}
+		res := &specResponse{header: http.Header{}}
+		s.handleAvatar(res, req)
+		if res.status == 0 || res.status == http.StatusOK {
+			rendered++
+		}
+	}
+	return rendered
+}
+
+// AvatarUser is a user whose avatars are pre-rendered: their name, and the ID
+// their colors are seeded from when set, as sent in uid.
+type AvatarUser struct {
+	Name string
+	UID  string
+}
+
+// ReadAvatarUsers reads users from CSV. The header row names the columns: name
+// is required, uid (or id) is optional, and any others are ignored. Rows
+// without a name are skipped.
+func ReadAvatarUsers(r io.Reader) ([]AvatarUser, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty CSV: expected a header row with a name column")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read CSV: %w", err)
+	}
+	nameCol, uidCol := -1, -1
+	for i, column := range header {
+		// Spreadsheets often start a UTF-8 CSV with a byte order mark
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))) {
+		case "name":
+			nameCol = i
+		case "uid", "id":
+			uidCol = i
+		}
+	}
+	if nameCol < 0 {
+		return nil, errors.New("CSV header has no name column")
+	}
+
+	var users []AvatarUser
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return users, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read CSV: %w", err)
+		}
+		var user AvatarUser
+		if nameCol < len(record) {
+			user.Name = normalizeName(record[nameCol])
+		}
+		if uidCol >= 0 && uidCol < len(record) {
+			user.UID = strings.TrimSpace(record[uidCol])
+		}
+		if user.Name != "" {
+			users = append(users, user)
+		}
+	}
+}
+
+// AvatarWarmup describes the avatars pre-rendered for each user.
+type AvatarWarmup struct {
+	Sizes  []int
+	Format render.ImageFormat
+	// Query holds the other parameters the app requests avatars with, such as
+	// bg=random, since they're part of the cache key
+	Query url.Values
+	// Host picks the tenant whose theme and cache the avatars use
+	Host string
+}
+
+// NewAvatarWarmup parses the options of a warmup: a comma-separated list of
+// sizes such as "64,128", a format such as "webp", and the query string of
+// the other parameters. Empty sizes and format mean the avatar defaults.
+func NewAvatarWarmup(sizes, format, query, host string) (AvatarWarmup, error) {
+	warmup := AvatarWarmup{Sizes: []int{config.DefaultSize}, Format: render.FormatSVG, Host: host}
+	if sizes != "" {
+		warmup.Sizes = nil
+		for _, field := range strings.Split(sizes, ",") {
+			size, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || size < 1 {
+				return AvatarWarmup{}, fmt.Errorf("invalid size %q: use positive numbers of pixels, such as 64,128", field)
+			}
+			warmup.Sizes = append(warmup.Sizes, size)
+		}
+	}
+	if format != "" {
+		f, ok := formatExtensions["."+strings.ToLower(format)]
+		if !ok {
+			return AvatarWarmup{}, fmt.Errorf("invalid format %q: use svg, png, jpg, gif, or webp", format)
+		}
+		warmup.Format = f
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return AvatarWarmup{}, fmt.Errorf("invalid query %q: %w", query, err)
+	}
+	for _, param := range []string{"name", "size", "uid", "url", "async", "explain"} {
+		if values.Has(param) {
+			return AvatarWarmup{}, fmt.Errorf("invalid query: %s can't be set for every avatar", param)
+		}
+	}
+	warmup.Query = values
+	return warmup, nil
+}
+
+// AvatarWarmupReport counts the avatars a warmup rendered, and lists the first
+// config.MaxWarmupFailures that failed.
+type AvatarWarmupReport struct {
+	Users    int                   `json:"users"`
+	Rendered int                   `json:"rendered"`
+	Failed   int                   `json:"failed"`
+	Failures []AvatarWarmupFailure `json:"failures,omitempty"`
+}
+
+// AvatarWarmupFailure is an avatar a warmup couldn't render. Row counts users
+// from 1, in the order they were read.
+type AvatarWarmupFailure struct {
+	Row  int    `json:"row"`
+	Size int    `json:"size"`
+	Code string `json:"code"`
+}
+
+// WarmUserAvatars renders the avatar of each user in each size of warmup into
+// the cache, as /avatar/{name} would, so the first requests for them are hits.
+// each, when set, is called with every avatar rendered, such as to export it;
+// an error from it stops the warmup. It also stops when ctx is done.
+func (s *Service) WarmUserAvatars(ctx context.Context, users []AvatarUser, warmup AvatarWarmup, each func(user AvatarUser, size int, data []byte) error) (AvatarWarmupReport, error) {
+	report := AvatarWarmupReport{Users: len(users)}
+	ext := "." + string(warmup.Format)
+	for row, user := range users {
+		for _, size := range warmup.Sizes {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			query := url.Values{}
+			for param, values := range warmup.Query {
+				query[param] = values
+			}
+			query.Set("size", strconv.Itoa(size))
+			if user.UID != "" {
+				query.Set("uid", user.UID)
+			}
+			// A '+' in a path reads as a space, so literal ones are escaped
+			path := "/avatar/" + strings.ReplaceAll(url.PathEscape(user.Name), "+", "%2B") + ext
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, path+"?"+query.Encode(), nil)
+			if err != nil {
+				return report, err
+			}
+			req.Host = warmup.Host
+			res := &specResponse{header: http.Header{}}
+			s.handleAvatar(res, req)
+			if res.status != 0 && res.status != http.StatusOK {
+				report.Failed++
+				if len(report.Failures) < config.MaxWarmupFailures {
+					report.Failures = append(report.Failures, AvatarWarmupFailure{Row: row + 1, Size: size, Code: res.header.Get("X-Error-Code")})
+				}
+				continue
+			}
+			report.Rendered++
+			if each != nil {
+				if err := each(user, size, res.body.Bytes()); err != nil {
+					return report, err
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// handleAdminWarmAvatars renders the avatars of the users in a posted CSV into
+// the cache of the request's host, in the sizes, format, and other parameters
+// the query sets, and reports how many were rendered.
+func (s *Service) handleAdminWarmAvatars(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	warmup, err := NewAvatarWarmup(query.Get("sizes"), query.Get("format"), query.Get("query"), r.Host)
+	if err != nil {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("%v", err))
+		return
+	}
+	users, err := ReadAvatarUsers(http.MaxBytesReader(w, r.Body, config.MaxWarmupCSVBytes))
+	if err != nil {
+		s.failJSON(w, r, ErrInvalidParameter.withMessage("invalid users CSV: %v", err))
+		return
+	}
+	report, err := s.WarmUserAvatars(r.Context(), users, warmup, nil)
+	if err != nil {
+		// The client went away; the avatars rendered so far stay cached
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/render"
+)
+
+func TestWarmAvatars(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](500)
+	svc := NewService(renderer, cache, config.DefaultServerConfig())
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	if got, want := svc.WarmAvatars(), 26+len(config.WarmupPairLetters)*len(config.WarmupPairLetters); got != want {
+		t.Fatalf("expected %d avatars warmed, got %d", want, got)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		cache  string
+	}{
+		{"single letter", "/avatar/Zed", "HIT"},
+		{"common pair", "/avatar/John%20Doe", "HIT"},
+		{"common pair from query", "/avatar/?name=mary+smith", "HIT"},
+		{"uncommon pair", "/avatar/Quinn%20Xu", "MISS"},
+		{"other size", "/avatar/John%20Doe?size=64", "MISS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != tt.cache {
+				t.Fatalf("expected 200 %s, got %d %s", tt.cache, rec.Code, rec.Header().Get("X-Cache"))
+			}
+		})
+	}
+}
+
+func TestReadAvatarUsers(t *testing.T) {
+	users, err := ReadAvatarUsers(strings.NewReader("\ufeffEmail, Name ,ID\nj@example.com,Jane  Doe,42\nx@example.com,,7\ns@example.com,\"Smith, John\"\nshort\n"))
+	if err != nil {
+		t.Fatalf("read users: %v", err)
+	}
+	want := []AvatarUser{{Name: "Jane Doe", UID: "42"}, {Name: "Smith, John"}}
+	if !reflect.DeepEqual(users, want) {
+		t.Fatalf("expected %+v, got %+v", want, users)
+	}
+
+	for _, body := range []string{"", "email,uid\nj@example.com,42\n", "name\n\"unterminated\n"} {
+		if _, err := ReadAvatarUsers(strings.NewReader(body)); err == nil {
+			t.Errorf("expected an error for %q", body)
+		}
+	}
+}
+
+func TestNewAvatarWarmup(t *testing.T) {
+	warmup, err := NewAvatarWarmup("64, 128", "WEBP", "bg=random&rounded=true", "img.acme.test")
+	if err != nil {
+		t.Fatalf("parse warmup: %v", err)
+	}
+	if !reflect.DeepEqual(warmup.Sizes, []int{64, 128}) || warmup.Format != render.FormatWebP || warmup.Query.Get("bg") != "random" || warmup.Host != "img.acme.test" {
+		t.Fatalf("unexpected warmup %+v", warmup)
+	}
+	if defaults, err := NewAvatarWarmup("", "", "", ""); err != nil || !reflect.DeepEqual(defaults.Sizes, []int{config.DefaultSize}) || defaults.Format != render.FormatSVG {
+		t.Fatalf("expected the avatar defaults, got %+v, %v", defaults, err)
+	}
+
+	tests := []struct {
+		name                 string
+		sizes, format, query string
+		expect               string
+	}{
+		{"zero size", "0", "", "", `invalid size "0"`},
+		{"not a size", "64,big", "", "", `invalid size "big"`},
+		{"unknown format", "", "bmp", "", `invalid format "bmp"`},
+		{"per-user parameter", "", "", "size=32", "size can't be set"},
+		{"invalid query", "", "", "bg=%zz", "invalid query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAvatarWarmup(tt.sizes, tt.format, tt.query, "")
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
+
+func TestWarmUserAvatars(t *testing.T) {
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](50)
+	cfg := config.DefaultServerConfig()
+	cfg.RenderBudget = 100 * 100
+	svc := NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, nil)
+
+	users := []AvatarUser{{Name: "Jane Doe", UID: "42"}, {Name: "A+B/C"}}
+	warmup, err := NewAvatarWarmup("64,512", "png", "bg=random", "")
+	if err != nil {
+		t.Fatalf("parse warmup: %v", err)
+	}
+	exported := make(map[string]int)
+	report, err := svc.WarmUserAvatars(context.Background(), users, warmup, func(user AvatarUser, size int, data []byte) error {
+		exported[fmt.Sprintf("%s@%d", user.Name, size)] = len(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("warm: %v", err)
+	}
+	// 512 is over the render budget, so only the 64s render
+	want := AvatarWarmupReport{Users: 2, Rendered: 2, Failed: 2, Failures: []AvatarWarmupFailure{
+		{Row: 1, Size: 512, Code: "too_complex"}, {Row: 2, Size: 512, Code: "too_complex"},
+	}}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("expected %+v, got %+v", want, report)
+	}
+	if len(exported) != 2 || exported["Jane Doe@64"] == 0 || exported["A+B/C@64"] == 0 {
+		t.Fatalf("expected each rendered avatar to be exported, got %v", exported)
+	}
+
+	// Requests with the same parameters are cache hits
+	for _, target := range []string{"/avatar/Jane%20Doe.png?size=64&bg=random&uid=42", "/avatar/A%2BB%2FC.png?bg=random&size=64"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "HIT" {
+			t.Errorf("%s: expected 200 HIT, got %d %s", target, rec.Code, rec.Header().Get("X-Cache"))
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.WarmUserAvatars(ctx, users, warmup, nil); err == nil {
+		t.Fatal("expected a canceled warmup to stop")
+	}
+}
+
+func TestAdminWarmAvatars(t *testing.T) {
+	mux := setupAdminTestService(t)
+	csv := "name,uid\nJane Doe,42\nJohn Roe,\n"
+
+	rec := adminRequest(mux, http.MethodPost, "http://img.acme.test/api/v1/admin/avatars/warm?sizes=32,48&format=png&query=rounded%3Dtrue", csv, testAdminToken)
+	var report AvatarWarmupReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected a report, got %d: %v", rec.Code, err)
+	}
+	if report.Users != 2 || report.Rendered != 4 || report.Failed != 0 {
+		t.Fatalf("expected 4 avatars rendered, got %+v", report)
+	}
+	// Warmed into the tenant's cache only
+	for host, cacheStatus := range map[string]string{"img.acme.test": "HIT", "localhost": "MISS"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://"+host+"/avatar/John%20Roe.png?size=48&rounded=true", nil))
+		if rec.Header().Get("X-Cache") != cacheStatus {
+			t.Errorf("%s: expected %s, got %q", host, cacheStatus, rec.Header().Get("X-Cache"))
+		}
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		token  string
+		status int
+	}{
+		{"no token", "/api/v1/admin/avatars/warm", csv, "", http.StatusUnauthorized},
+		{"bad sizes", "/api/v1/admin/avatars/warm?sizes=-1", csv, testAdminToken, http.StatusBadRequest},
+		{"no name column", "/api/v1/admin/avatars/warm", "email\nj@example.com\n", testAdminToken, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := adminRequest(mux, http.MethodPost, tt.target, tt.body, tt.token); rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+(function (root, factory) {
+if (typeof module === "object" && module.exports) {
+module.exports = factory();
+} else {
+root.Grout = factory();
+}
+})(typeof self !== "undefined" ? self : this, function () {
+"use strict";
+var scriptOrigin = "";
+if (typeof document !== "undefined" && document.currentScript && document.currentScript.src) {
+scriptOrigin = new URL(document.currentScript.src).origin;
+}
+var tools = {
+"placeholder": {"path":"/placeholder/{width}x{height}.{format}","params":[{"name":"width","type":"integer","min":1,"default":"128","inPath":true},{"name":"height","type":"integer","min":1,"default":"128","inPath":true},{"name":"format","type":"string","enum":["svg","png","jpg","gif","webp"],"default":"svg","inPath":true},{"name":"text","type":"string"},{"name":"bg","type":"string"},{"name":"color","type":"string"},{"name":"pattern","type":"string","enum":["lowpoly","mesh","isogrid","dots"]},{"name":"effect","type":"string","enum":["confetti","sparkle","vignette","halftone","dither"]},{"name":"seed","type":"string"}]},
+"avatar": {"path":"/avatar/{name}.{format}","params":[{"name":"name","type":"string","required":true,"inPath":true},{"name":"format","type":"string","enum":["svg","png","jpg","gif","webp"],"default":"svg","inPath":true},{"name":"size","type":"integer","min":1},{"name":"bg","type":"string"},{"name":"color","type":"string"},{"name":"rounded","type":"boolean"},{"name":"bold","type":"boolean"},{"name":"effect","type":"string","enum":["confetti","sparkle","vignette","halftone","dither"]}]},
+"calendar": {"path":"/calendar/{width}x{height}.{format}","params":[{"name":"width","type":"integer","min":1,"default":"128","inPath":true},{"name":"height","type":"integer","min":1,"default":"128","inPath":true},{"name":"format","type":"string","enum":["svg","png","jpg","gif","webp"],"default":"svg","inPath":true},{"name":"date","type":"string"},{"name":"locale","type":"string"},{"name":"header","type":"string"},{"name":"bg","type":"string"},{"name":"color","type":"string"}]},
+"rating": {"path":"/rating/{value}.{format}","params":[{"name":"value","type":"number","min":0,"required":true,"inPath":true},{"name":"format","type":"string","enum":["svg","png","jpg","gif","webp"],"default":"svg","inPath":true},{"name":"max","type":"integer","min":1,"max":10},{"name":"size","type":"integer","min":1},{"name":"color","type":"string"},{"name":"empty","type":"string"},{"name":"bg","type":"string"}]},
+"text": {"path":"/text/{text}.{format}","params":[{"name":"text","type":"string","required":true,"inPath":true},{"name":"format","type":"string","enum":["svg","png","jpg","gif","webp"],"default":"svg","inPath":true},{"name":"font","type":"string","enum":["regular","bold","italic","bold-italic","medium","mono","mono-bold","smallcaps"]},{"name":"size","type":"integer","min":1,"max":256},{"name":"color","type":"string"}]},
+"divider": {"path":"/divider/{width}x{height}.{format}","params":[{"name":"width","type":"integer","min":1,"default":"1440","inPath":true},{"name":"height","type":"integer","min":1,"default":"120","inPath":true},{"name":"format","type":"string","enum":["svg","png","jpg","gif","webp"],"default":"svg","inPath":true},{"name":"style","type":"string","enum":["wave","blob","tilt"]},{"name":"seed","type":"string"},{"name":"flip","type":"boolean"},{"name":"color","type":"string"},{"name":"bg","type":"string"}]},
+"table": {"path":"/table/{width}x{height}.{format}","params":[{"name":"width","type":"integer","min":1,"default":"600","inPath":true},{"name":"height","type":"integer","min":1,"default":"300","inPath":true},{"name":"format","type":"string","enum":["svg","png","jpg","gif","webp"],"default":"svg","inPath":true},{"name":"rows","type":"integer","min":1,"max":50},{"name":"cols","type":"integer","min":1,"max":12},{"name":"seed","type":"string"},{"name":"header","type":"string"},{"name":"stripe","type":"string"},{"name":"bg","type":"string"},{"name":"color","type":"string"}]},
+"spinner": {"path":"/spinner/{size}.{format}","params":[{"name":"size","type":"integer","min":16,"max":512,"default":"64","inPath":true},{"name":"format","type":"string","enum":["svg","gif"],"default":"svg","inPath":true},{"name":"style","type":"string","enum":["ring","dots","bars"]},{"name":"color","type":"string"},{"name":"bg","type":"string"}]}
+};
+function fail(tool, message) {
+throw new TypeError("grout: " + tool + ": " + message);
+}
+function check(tool, p, value) {
+switch (p.type) {
+case "string":
+if (typeof value !== "string") {
+fail(tool, p.name + " must be a string");
+}
+if (p.required && value === "") {
+fail(tool, p.name + " must not be empty");
+}
+break;
+case "boolean":
+if (typeof value !== "boolean") {
+fail(tool, p.name + " must be true or false");
+}
+value = String(value);
+break;
+default:
+if (typeof value !== "number" || !isFinite(value)) {
+fail(tool, p.name + " must be a number");
+}
+if (p.type === "integer" && value % 1 !== 0) {
+fail(tool, p.name + " must be a whole number");
+}
+if (value < p.min || (p.max !== undefined && value > p.max)) {
+fail(tool, p.name + (p.max !== undefined ? " must be from " + p.min + " to " + p.max : " must be at least " + p.min));
+}
+value = String(value);
+}
+if (p.enum && p.enum.indexOf(value) < 0) {
+fail(tool, p.name + " must be one of " + p.enum.join(", "));
+}
+return value;
+}
+function build(base, name, options) {
+var tool = tools[name];
+options = options || {};
+Object.keys(options).forEach(function (key) {
+if (!tool.params.some(function (p) { return p.name === key; })) {
+fail(name, "unknown option " + key);
+}
+});
+var path = tool.path;
+var query = [];
+tool.params.forEach(function (p) {
+var value = p.default || "";
+var option = options[p.name];
+if (option !== undefined && option !== null) {
+value = check(name, p, option);
+} else if (p.required) {
+fail(name, "missing option " + p.name);
+}
+if (p.inPath) {
+path = path.replace("{" + p.name + "}", encodeURIComponent(value));
+} else if (value !== "") {
+query.push([p.name, value]);
+}
+});
+query.sort(function (a, b) { return a[0] < b[0] ? -1 : 1; });
+var search = new URLSearchParams(query).toString();
+return base + path + (search ? "?" + search : "");
+}
+function Grout(baseURL) {
+if (!(this instanceof Grout)) {
+return new Grout(baseURL);
+}
+this.baseURL = (baseURL === undefined ? scriptOrigin : baseURL).replace(/\/+$/, "");
+}
+Grout.prototype.placeholder = function (options) {
+return build(this.baseURL, "placeholder", options);
+};
+Grout.prototype.avatar = function (options) {
+return build(this.baseURL, "avatar", options);
+};
+Grout.prototype.calendar = function (options) {
+return build(this.baseURL, "calendar", options);
+};
+Grout.prototype.rating = function (options) {
+return build(this.baseURL, "rating", options);
+};
+Grout.prototype.text = function (options) {
+return build(this.baseURL, "text", options);
+};
+Grout.prototype.divider = function (options) {
+return build(this.baseURL, "divider", options);
+};
+Grout.prototype.table = function (options) {
+return build(this.baseURL, "table", options);
+};
+Grout.prototype.spinner = function (options) {
+return build(this.baseURL, "spinner", options);
+};
+return Grout;
+});
@@ -0,0 +1,30 @@
+// Package identity derives the seeds of per-user colors and patterns from
+// stable user IDs, so a user keeps their colors when their name changes.
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Seeder hashes user IDs under a server-side salt. Deployments with different
+// salts give the same user different colors, so a user's colors can't be
+// predicted from their ID elsewhere.
+type Seeder struct {
+	salt []byte
+}
+
+// NewSeeder returns a Seeder for salt. An empty salt still gives stable
+// seeds, but ones any deployment without a salt shares.
+func NewSeeder(salt string) *Seeder {
+	return &Seeder{salt: []byte(salt)}
+}
+
+// Seed returns the seed of a user ID: the hex HMAC-SHA256 of the ID under the
+// salt. The same ID and salt always give the same seed.
+func (s *Seeder) Seed(uid string) string {
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(uid))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,31 @@
+package identity
+
+import "testing"
+
+func TestSeed(t *testing.T) {
+	a, b := NewSeeder("salt-a"), NewSeeder("salt-b")
+
+	tests := []struct {
+		name  string
+		left  string
+		right string
+		same  bool
+	}{
+		{"same ID and salt", a.Seed("user-42"), a.Seed("user-42"), true},
+		{"different IDs", a.Seed("user-42"), a.Seed("user-43"), false},
+		{"different salts", a.Seed("user-42"), b.Seed("user-42"), false},
+		{"no salt is stable", NewSeeder("").Seed("user-42"), NewSeeder("").Seed("user-42"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.left == tt.right) != tt.same {
+				t.Errorf("expected same=%t, got %s and %s", tt.same, tt.left, tt.right)
+			}
+		})
+	}
+
+	// HMAC-SHA256("salt-a", "user-42"), so seeds don't change between releases
+	if got, want := a.Seed("user-42"), "5cdc09fdf753f5f6f7b49aa99a8eda745d456e04ae0a520376acf04862e9b747"; got != want {
+		t.Errorf("expected the seed %s, got %s", want, got)
+	}
+}
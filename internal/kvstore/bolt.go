@@ -0,0 +1,149 @@
+package kvstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBucket is the bucket every key is stored in.
+var boltBucket = []byte("grout")
+
+// boltOpenTimeout bounds the wait for another process's lock on the file.
+const boltOpenTimeout = 5 * time.Second
+
+// Bolt is a Store in a BoltDB file, for single instances that keep state across
+// restarts. Each value is stored after an 8-byte expiry in Unix nanoseconds, 0
+// for none; expired entries are dropped when they're next read or listed.
+type Bolt struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+// OpenBolt opens the BoltDB file at path, creating it if it's missing.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("kvstore: open bolt file: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("kvstore: create bolt bucket: %w", err)
+	}
+	return &Bolt{db: db, now: time.Now}, nil
+}
+
+// unpack splits a stored record into its value, and whether it has expired.
+func (b *Bolt) unpack(record []byte) ([]byte, bool) {
+	if len(record) < 8 {
+		return nil, true
+	}
+	expires := int64(binary.BigEndian.Uint64(record[:8]))
+	return record[8:], expires != 0 && b.now().UnixNano() >= expires
+}
+
+func (b *Bolt) Get(_ context.Context, key string) ([]byte, error) {
+	var value []byte
+	var expired bool
+	err := b.db.View(func(tx *bolt.Tx) error {
+		record := tx.Bucket(boltBucket).Get([]byte(key))
+		if record == nil {
+			return ErrNotFound
+		}
+		var v []byte
+		v, expired = b.unpack(record)
+		// Values are only valid inside the transaction
+		value = bytes.Clone(v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		if err := b.Delete(context.Background(), key); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+func (b *Bolt) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	record := make([]byte, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(record[:8], uint64(b.now().Add(ttl).UnixNano()))
+	}
+	copy(record[8:], value)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), record)
+	})
+}
+
+func (b *Bolt) Delete(_ context.Context, key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+}
+
+func (b *Bolt) Incr(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	var n int64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		record := bucket.Get([]byte(key))
+		value, expired := b.unpack(record)
+		if record == nil || expired {
+			record = make([]byte, 8, 9)
+			if ttl > 0 {
+				binary.BigEndian.PutUint64(record, uint64(b.now().Add(ttl).UnixNano()))
+			}
+			value = []byte("0")
+		}
+		var err error
+		if n, err = parseCounter(value); err != nil {
+			return err
+		}
+		n += delta
+		// Keep the expiry; the record is only valid inside the transaction
+		updated := strconv.AppendInt(bytes.Clone(record[:8]), n, 10)
+		return bucket.Put([]byte(key), updated)
+	})
+	return n, err
+}
+
+func (b *Bolt) Keys(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		var expired [][]byte
+		c := bucket.Cursor()
+		// Keys come out of a cursor in byte order, already sorted
+		for k, record := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, record = c.Next() {
+			if _, ok := b.unpack(record); ok {
+				expired = append(expired, bytes.Clone(k))
+				continue
+			}
+			keys = append(keys, string(k))
+		}
+		// Deleting while iterating would skip keys, so expired ones go after
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,77 @@
+// Package kvstore is the key-value storage behind Grout's stateful features, so
+// they all persist the same way: in memory, in a BoltDB file, or in Redis.
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get when a key has no value, or its value expired.
+var ErrNotFound = errors.New("kvstore: key not found")
+
+// Store is a key-value store. Stores are safe for concurrent use.
+type Store interface {
+	// Get returns the value of key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key. A positive ttl expires it after that long; 0
+	// keeps it until it's deleted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key. Deleting a missing key isn't an error.
+	Delete(ctx context.Context, key string) error
+	// Incr adds delta to the integer stored under key, as decimal text, and
+	// returns the sum. A missing key counts as 0; a positive ttl expires it that
+	// long after Incr creates it, and later increments keep that expiry.
+	Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	// Keys returns the keys starting with prefix, sorted.
+	Keys(ctx context.Context, prefix string) ([]string, error)
+	// Close releases the store's resources.
+	Close() error
+}
+
+// parseCounter reads the value of a key Incr counts in.
+func parseCounter(value []byte) (int64, error) {
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("kvstore: value is not an integer: %w", err)
+	}
+	return n, nil
+}
+
+// Open opens the store a URL names:
+//
+//	memory                      in memory, lost on restart
+//	bolt:///var/lib/grout.db    a BoltDB file, created if missing
+//	redis://host:6379/0         a Redis server; rediss:// uses TLS
+//
+// An empty URL opens a memory store.
+func Open(rawURL string) (Store, error) {
+	if rawURL == "" || rawURL == "memory" {
+		return NewMemory(), nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("kvstore: invalid store URL: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "memory":
+		return NewMemory(), nil
+	case "bolt":
+		path := u.Path
+		if u.Opaque != "" {
+			path = u.Opaque
+		}
+		if path == "" {
+			return nil, errors.New("kvstore: bolt URLs need a file path, such as bolt:///var/lib/grout.db")
+		}
+		return OpenBolt(path)
+	case "redis", "rediss":
+		return OpenRedis(rawURL)
+	}
+	return nil, fmt.Errorf("kvstore: unknown store %q; use memory, bolt://, or redis://", u.Scheme)
+}
@@ -0,0 +1,224 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time for stores that read their own clock.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// testStore runs the behavior every Store shares. advance moves the store's
+// clock forward.
+func testStore(t *testing.T, store Store, advance func(time.Duration)) {
+	ctx := context.Background()
+	t.Cleanup(func() { store.Close() })
+
+	if _, err := store.Get(ctx, "test:missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing key, got %v", err)
+	}
+
+	for key, value := range map[string]string{"test:b": "2", "test:a": "1", "test:c*": "3", "other:a": "x"} {
+		if err := store.Set(ctx, key, []byte(value), 0); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	if got, err := store.Get(ctx, "test:a"); err != nil || string(got) != "1" {
+		t.Fatalf("expected test:a to be 1, got %q, %v", got, err)
+	}
+	if err := store.Set(ctx, "test:a", []byte("one"), 0); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if got, _ := store.Get(ctx, "test:a"); string(got) != "one" {
+		t.Fatalf("expected the overwritten value, got %q", got)
+	}
+
+	keys, err := store.Keys(ctx, "test:")
+	if err != nil {
+		t.Fatalf("keys: %v", err)
+	}
+	if want := []string{"test:a", "test:b", "test:c*"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("expected keys %v, got %v", want, keys)
+	}
+	// Glob characters in a prefix match literally
+	if keys, _ := store.Keys(ctx, "test:c*"); !reflect.DeepEqual(keys, []string{"test:c*"}) {
+		t.Fatalf("expected only test:c*, got %v", keys)
+	}
+
+	if err := store.Delete(ctx, "test:b"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := store.Delete(ctx, "test:b"); err != nil {
+		t.Fatalf("expected deleting a missing key to succeed, got %v", err)
+	}
+	if _, err := store.Get(ctx, "test:b"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected test:b to be gone, got %v", err)
+	}
+
+	if n, err := store.Incr(ctx, "test:count", 2, 0); err != nil || n != 2 {
+		t.Fatalf("expected a new counter to count from 0 to 2, got %d, %v", n, err)
+	}
+	if n, err := store.Incr(ctx, "test:count", -1, 0); err != nil || n != 1 {
+		t.Fatalf("expected the counter to go down to 1, got %d, %v", n, err)
+	}
+	if got, _ := store.Get(ctx, "test:count"); string(got) != "1" {
+		t.Fatalf("expected the counter to read as 1, got %q", got)
+	}
+	if _, err := store.Incr(ctx, "test:a", 1, 0); err == nil {
+		t.Fatal("expected incrementing a value that isn't an integer to fail")
+	}
+
+	if err := store.Set(ctx, "test:ttl", []byte("soon gone"), 100*time.Millisecond); err != nil {
+		t.Fatalf("set with ttl: %v", err)
+	}
+	if _, err := store.Get(ctx, "test:ttl"); err != nil {
+		t.Fatalf("expected test:ttl before it expires, got %v", err)
+	}
+	advance(150 * time.Millisecond)
+	if _, err := store.Get(ctx, "test:ttl"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected test:ttl to expire, got %v", err)
+	}
+	if keys, _ := store.Keys(ctx, "test:ttl"); len(keys) != 0 {
+		t.Fatalf("expected expired keys not to be listed, got %v", keys)
+	}
+
+	// A counter's expiry is set when it's created, not pushed back by increments
+	if _, err := store.Incr(ctx, "test:counter-ttl", 1, 100*time.Millisecond); err != nil {
+		t.Fatalf("incr with ttl: %v", err)
+	}
+	advance(60 * time.Millisecond)
+	if n, err := store.Incr(ctx, "test:counter-ttl", 1, 100*time.Millisecond); err != nil || n != 2 {
+		t.Fatalf("expected the counter to reach 2 before it expires, got %d, %v", n, err)
+	}
+	advance(60 * time.Millisecond)
+	if _, err := store.Get(ctx, "test:counter-ttl"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the counter to expire 100ms after it was created, got %v", err)
+	}
+	if n, err := store.Incr(ctx, "test:counter-ttl", 1, 0); err != nil || n != 1 {
+		t.Fatalf("expected an expired counter to start over, got %d, %v", n, err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	store := NewMemory()
+	store.now = clock.Now
+	testStore(t, store, clock.Advance)
+}
+
+func TestBolt(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	store, err := OpenBolt(filepath.Join(t.TempDir(), "grout.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store.now = clock.Now
+	testStore(t, store, clock.Advance)
+}
+
+func TestBoltPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "grout.db")
+	store, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := store.Set(ctx, "kept", []byte("value"), 0); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	store.Close()
+
+	reopened, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.Get(ctx, "kept"); err != nil || string(got) != "value" {
+		t.Fatalf("expected the value to survive a reopen, got %q, %v", got, err)
+	}
+}
+
+// TestRedis runs against the server at GROUT_TEST_REDIS_URL, and is skipped
+// without one. It uses keys under test: and other:.
+func TestRedis(t *testing.T) {
+	url := os.Getenv("GROUT_TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("set GROUT_TEST_REDIS_URL to test against Redis")
+	}
+	store, err := OpenRedis(url)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	ctx := context.Background()
+	for _, prefix := range []string{"test:", "other:"} {
+		keys, _ := store.Keys(ctx, prefix)
+		for _, key := range keys {
+			store.Delete(ctx, key)
+		}
+	}
+	testStore(t, store, time.Sleep)
+}
+
+func TestOpen(t *testing.T) {
+	boltPath := filepath.Join(t.TempDir(), "open.db")
+	tests := []struct {
+		name    string
+		url     string
+		want    any
+		wantErr bool
+	}{
+		{"default", "", &Memory{}, false},
+		{"memory", "memory", &Memory{}, false},
+		{"bolt", "bolt://" + boltPath, &Bolt{}, false},
+		{"bolt without a path", "bolt://", nil, true},
+		{"invalid redis URL", "redis://localhost:port/0", nil, true},
+		{"unknown scheme", "postgres://localhost/grout", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := Open(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					store.Close()
+					t.Fatalf("expected an error opening %q", tt.url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open %q: %v", tt.url, err)
+			}
+			defer store.Close()
+			if reflect.TypeOf(store) != reflect.TypeOf(tt.want) {
+				t.Fatalf("expected a %T, got %T", tt.want, store)
+			}
+		})
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	store := NewMemory()
+	defer store.Close()
+	store.now = clock.Now
+
+	_ = store.Set(ctx, "expiring", []byte("value"), time.Minute)
+	_ = store.Set(ctx, "kept", []byte("value"), 0)
+	clock.Advance(2 * time.Minute)
+	store.sweep()
+	// Entries that were never read again are gone all the same
+	if _, ok := store.entries["expiring"]; ok {
+		t.Error("expected the sweep to drop the expired entry")
+	}
+	if _, ok := store.entries["kept"]; !ok {
+		t.Error("expected the sweep to keep the entry that doesn't expire")
+	}
+}
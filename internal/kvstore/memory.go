@@ -0,0 +1,151 @@
+package kvstore
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a value and when it expires; a zero time never expires.
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// memorySweepInterval is how often a memory store drops expired entries that
+// haven't been read since.
+const memorySweepInterval = time.Minute
+
+// Memory is a Store held in memory, for single instances and tests. Expired
+// entries are dropped when they're next read or listed, and by a sweep every
+// memorySweepInterval until the store is closed.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+	done    chan struct{}
+	closed  sync.Once
+}
+
+// NewMemory returns an empty memory store.
+func NewMemory() *Memory {
+	m := &Memory{entries: make(map[string]memoryEntry), now: time.Now, done: make(chan struct{})}
+	go m.sweepExpired()
+	return m
+}
+
+// sweepExpired periodically drops expired entries, until the store is closed.
+func (m *Memory) sweepExpired() {
+	ticker := time.NewTicker(memorySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sweep()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// sweep drops every expired entry.
+func (m *Memory) sweep() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for key, entry := range m.entries {
+		if entry.expired(now) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if entry.expired(m.now()) {
+		delete(m.entries, key)
+		return nil, ErrNotFound
+	}
+	return slices.Clone(entry.value), nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := memoryEntry{value: slices.Clone(value)}
+	if ttl > 0 {
+		entry.expires = m.now().Add(ttl)
+	}
+	m.entries[key] = entry
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *Memory) Incr(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	entry, ok := m.entries[key]
+	if !ok || entry.expired(now) {
+		entry = memoryEntry{value: []byte("0")}
+		if ttl > 0 {
+			entry.expires = now.Add(ttl)
+		}
+	}
+	n, err := parseCounter(entry.value)
+	if err != nil {
+		return 0, err
+	}
+	n += delta
+	entry.value = strconv.AppendInt(nil, n, 10)
+	m.entries[key] = entry
+	return n, nil
+}
+
+func (m *Memory) Keys(_ context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var keys []string
+	for key, entry := range m.entries {
+		if entry.expired(now) {
+			delete(m.entries, key)
+			continue
+		}
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (m *Memory) Close() error {
+	m.closed.Do(func() { close(m.done) })
+	return nil
+}
@@ -0,0 +1,102 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisScanCount is how many keys Keys asks Redis for per SCAN round trip.
+const redisScanCount = 500
+
+// Redis is a Store on a Redis server, for state shared by several instances.
+// Redis expires keys itself.
+type Redis struct {
+	client *redis.Client
+}
+
+// OpenRedis connects to the Redis server at a redis:// or rediss:// URL and
+// checks that it answers.
+func OpenRedis(rawURL string) (*Redis, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("kvstore: invalid redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("kvstore: connect to redis: %w", err)
+	}
+	return &Redis{client: client}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	// go-redis reads a ttl of 0 as no expiry, as Store does
+	return r.client.Set(ctx, key, value, max(ttl, 0)).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+func (r *Redis) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	// SETNX creates the key with its expiry in the same transaction, so INCRBY
+	// never leaves a counter that doesn't expire
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, max(ttl, 0))
+		incr = pipe.IncrBy(ctx, key, delta)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (r *Redis) Keys(ctx context.Context, prefix string) ([]string, error) {
+	// SCAN rather than KEYS, which blocks the server while it runs
+	var keys []string
+	iter := r.client.Scan(ctx, 0, escapeRedisPattern(prefix)+"*", redisScanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	// SCAN can return a key more than once
+	slices.Sort(keys)
+	return slices.Compact(keys), nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// escapeRedisPattern escapes the glob characters of a SCAN MATCH pattern, so a
+// prefix matches literally.
+func escapeRedisPattern(s string) string {
+	var escaped []byte
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, s[i])
+	}
+	return string(escaped)
+}
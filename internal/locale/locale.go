@@ -0,0 +1,119 @@
+// Package locale formats the dates and numbers drawn into generated images in
+// the conventions of a language, from an embedded subset of the Unicode CLDR.
+package locale
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed data/locales.yaml
+var localesData []byte
+
+// Locale holds the names and number symbols of one language.
+type Locale struct {
+	// Tag is the locale's language code, such as "de"
+	Tag          string   `yaml:"-"`
+	Months       []string `yaml:"months"`
+	MonthsFormat []string `yaml:"months_format"`
+	MonthsShort  []string `yaml:"months_short"`
+	Weekdays     []string `yaml:"weekdays"`
+	Decimal      string   `yaml:"decimal"`
+	Group        string   `yaml:"group"`
+	// MinGroupingDigits is the fewest digits before the last group for groups
+	// to be inserted at all; 0 counts as 1
+	MinGroupingDigits int    `yaml:"min_grouping_digits"`
+	LongDatePattern   string `yaml:"long_date"`
+}
+
+// locales holds the embedded locales by tag.
+var locales = mustLoad()
+
+// English is the locale used when a request doesn't ask for one.
+var English = locales["en"]
+
+func mustLoad() map[string]*Locale {
+	loaded := make(map[string]*Locale)
+	if err := yaml.Unmarshal(localesData, &loaded); err != nil {
+		panic(fmt.Sprintf("parse embedded locales: %v", err))
+	}
+	for tag, l := range loaded {
+		l.Tag = tag
+		if len(l.MonthsFormat) == 0 {
+			l.MonthsFormat = l.Months
+		}
+		if len(l.Months) != 12 || len(l.MonthsFormat) != 12 || len(l.MonthsShort) != 12 || len(l.Weekdays) != 7 {
+			panic(fmt.Sprintf("embedded locale %q: needs 12 months and 7 weekdays", tag))
+		}
+	}
+	return loaded
+}
+
+// Lookup returns the locale of a language tag such as "de", "de-AT", or
+// "pt_BR". Only the language is matched, case-insensitively.
+func Lookup(tag string) (*Locale, bool) {
+	language, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	l, ok := locales[strings.ToLower(language)]
+	return l, ok
+}
+
+// Tags returns the tags of the embedded locales, sorted.
+func Tags() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// MonthShort returns the abbreviated stand-alone name of a month.
+func (l *Locale) MonthShort(m time.Month) string {
+	return l.MonthsShort[m-1]
+}
+
+// Weekday returns the name of a day of the week.
+func (l *Locale) Weekday(d time.Weekday) string {
+	return l.Weekdays[d]
+}
+
+// LongDate formats a date with the month spelled out, such as "May 1, 2025" or
+// "1. Mai 2025".
+func (l *Locale) LongDate(t time.Time) string {
+	return strings.NewReplacer(
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", l.MonthsFormat[t.Month()-1],
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(l.LongDatePattern)
+}
+
+// FormatInt formats an integer with the locale's digit group separator.
+func (l *Locale) FormatInt(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	// Groups of three, when there are enough digits before the last one
+	if len(digits)-3 < max(l.MinGroupingDigits, 1) {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	lead := len(digits) % 3
+	if lead == 0 {
+		lead = 3
+	}
+	b.WriteString(digits[:lead])
+	for i := lead; i < len(digits); i += 3 {
+		b.WriteString(l.Group)
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		tag    string
+		expect string
+		ok     bool
+	}{
+		{"de", "de", true},
+		{"de-AT", "de", true},
+		{"pt_BR", "pt", true},
+		{"FR", "fr", true},
+		{"xx", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			l, ok := Lookup(tt.tag)
+			if ok != tt.ok || (ok && l.Tag != tt.expect) {
+				t.Fatalf("Lookup(%q) = %v, %v; want %q, %v", tt.tag, l, ok, tt.expect, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEmbeddedLocales(t *testing.T) {
+	// mustLoad checks the name counts; every locale also needs number symbols and
+	// a date pattern
+	for _, tag := range Tags() {
+		l, _ := Lookup(tag)
+		if l.Decimal == "" || l.Group == "" || l.LongDatePattern == "" {
+			t.Errorf("locale %s is incomplete: %+v", tag, l)
+		}
+	}
+}
+
+func TestLongDate(t *testing.T) {
+	date := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		tag    string
+		expect string
+	}{
+		{"en", "May 1, 2025"},
+		{"de", "1. Mai 2025"},
+		{"es", "1 de mayo de 2025"},
+		{"pl", "1 maja 2025"},
+		{"ru", "1 мая 2025 г."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			l, _ := Lookup(tt.tag)
+			if got := l.LongDate(date); got != tt.expect {
+				t.Errorf("expected %q got %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	fr, _ := Lookup("fr")
+	if got := fr.MonthShort(time.February); got != "févr." {
+		t.Errorf("expected févr. got %q", got)
+	}
+	if got := fr.Weekday(time.Sunday); got != "dimanche" {
+		t.Errorf("expected dimanche got %q", got)
+	}
+}
+
+func TestFormatInt(t *testing.T) {
+	tests := []struct {
+		tag    string
+		n      int
+		expect string
+	}{
+		{"en", 999, "999"},
+		{"en", 1200, "1,200"},
+		{"en", 1234567, "1,234,567"},
+		{"en", -1234567, "-1,234,567"},
+		{"de", 1200, "1.200"},
+		{"fr", 1200, "1 200"},
+		// Spanish and Polish only group numbers of five digits or more
+		{"es", 1200, "1200"},
+		{"es", 12000, "12.000"},
+		{"pl", 1200, "1200"},
+		{"pl", 12000, "12 000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expect, func(t *testing.T) {
+			l, _ := Lookup(tt.tag)
+			if got := l.FormatInt(tt.n); got != tt.expect {
+				t.Errorf("%s FormatInt(%d) = %q, want %q", tt.tag, tt.n, got, tt.expect)
+			}
+		})
+	}
+}
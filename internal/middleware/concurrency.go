@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ipSlots is the semaphore of one client IP, shared by its requests in flight
+// and in line. It's dropped once the last of them is done.
+type ipSlots struct {
+	sem  chan struct{}
+	refs int
+}
+
+// ConcurrencyLimiter caps the requests served at once, per client IP and across
+// the server. Unlike a rate limit it counts requests in flight, so a client with
+// a few slow renders can't hold every worker while staying under its rate.
+// Requests over a limit wait in line until a slot frees up or the wait runs out;
+// global slots go to the waiting requests of the highest Priority first.
+type ConcurrencyLimiter struct {
+	perIP  int
+	global *prioritySemaphore // nil when there's no global limit
+	wait   time.Duration
+
+	mu  sync.Mutex
+	ips map[string]*ipSlots
+
+	inFlight atomic.Int64
+	rejected atomic.Uint64 // Requests refused after waiting too long
+	clientPrefixes
+}
+
+// NewConcurrencyLimiter creates a limiter allowing perIP requests at once from
+// each client and global requests at once in all, waiting up to wait for a
+// slot. A limit of 0 turns it off. Clients are grouped by network as the rate
+// limiter groups them, IPv4 by address and IPv6 by /64 until SetPrefixes says
+// otherwise.
+func NewConcurrencyLimiter(perIP, global int, wait time.Duration) *ConcurrencyLimiter {
+	cl := &ConcurrencyLimiter{perIP: perIP, wait: wait, ips: make(map[string]*ipSlots)}
+	if global > 0 {
+		cl.global = newPrioritySemaphore(global)
+	}
+	cl.SetPrefixes(32, 64)
+	return cl
+}
+
+// claimIP returns the semaphore of ip, counting the caller as one of its users.
+func (cl *ConcurrencyLimiter) claimIP(ip string) *ipSlots {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	slots, ok := cl.ips[ip]
+	if !ok {
+		slots = &ipSlots{sem: make(chan struct{}, cl.perIP)}
+		cl.ips[ip] = slots
+	}
+	slots.refs++
+	return slots
+}
+
+// releaseIP drops the caller's claim on ip's semaphore, forgetting the IP when
+// nobody else holds one.
+func (cl *ConcurrencyLimiter) releaseIP(ip string, slots *ipSlots) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	slots.refs--
+	if slots.refs == 0 {
+		delete(cl.ips, ip)
+	}
+}
+
+// acquire takes one slot of sem, giving up when deadline fires or the request
+// is canceled.
+func acquire(r *http.Request, sem chan struct{}, deadline <-chan time.Time) bool {
+	select {
+	case sem <- struct{}{}:
+		return true
+	default:
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-deadline:
+	case <-r.Context().Done():
+	}
+	return false
+}
+
+// Middleware creates an HTTP middleware that applies the concurrency limits. A
+// request that can't get a slot in time gets a 429 Too Many Requests when its
+// own IP is at the limit, and a 503 Service Unavailable when the server is.
+func (cl *ConcurrencyLimiter) Middleware(next http.Handler) http.Handler {
+	if cl.perIP <= 0 && cl.global == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timer := time.NewTimer(cl.wait)
+		defer timer.Stop()
+
+		// The client's own slot comes first, so requests waiting on a busy client
+		// don't hold global slots other clients could use
+		if cl.perIP > 0 {
+			// A client can't get more slots by spreading requests over its network
+			ip := cl.clientKey(getIP(r))
+			slots := cl.claimIP(ip)
+			defer cl.releaseIP(ip, slots)
+			if !acquire(r, slots.sem, timer.C) {
+				cl.reject(w, r, http.StatusTooManyRequests)
+				return
+			}
+			defer func() { <-slots.sem }()
+		}
+		if cl.global != nil {
+			if !cl.global.acquire(r, PriorityOf(r), timer.C) {
+				cl.reject(w, r, http.StatusServiceUnavailable)
+				return
+			}
+			defer cl.global.release()
+		}
+
+		cl.inFlight.Add(1)
+		defer cl.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reject refuses a request that couldn't get a slot. Clients that went away
+// while waiting get nothing, since nobody's left to read it.
+func (cl *ConcurrencyLimiter) reject(w http.ResponseWriter, r *http.Request, code int) {
+	if r.Context().Err() != nil {
+		return
+	}
+	cl.rejected.Add(1)
+	w.Header().Set("Retry-After", strconv.Itoa(max(int(cl.wait.Seconds()), 1)))
+	http.Error(w, http.StatusText(code), code)
+}
+
+// Limits returns the configured per-IP and global limits, 0 when off.
+func (cl *ConcurrencyLimiter) Limits() (perIP, global int) {
+	if cl.global != nil {
+		global = cl.global.size
+	}
+	return max(cl.perIP, 0), global
+}
+
+// InFlight returns how many requests are being served right now.
+func (cl *ConcurrencyLimiter) InFlight() int64 {
+	return cl.inFlight.Load()
+}
+
+// Rejected returns how many requests were refused because no slot freed up in time.
+func (cl *ConcurrencyLimiter) Rejected() uint64 {
+	return cl.rejected.Load()
+}
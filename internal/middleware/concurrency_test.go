@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingHandler holds every request until release is closed, signaling
+// started as each one begins.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func requestFrom(ip string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = ip + ":1234"
+	return req
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		perIP    int
+		global   int
+		held     []string // IPs of requests in flight
+		ip       string   // IP of the request that must wait
+		wantCode int
+	}{
+		{"per-IP limit", 1, 10, []string{"10.0.0.1"}, "10.0.0.1", http.StatusTooManyRequests},
+		{"other IPs unaffected", 1, 10, []string{"10.0.0.1"}, "10.0.0.2", http.StatusOK},
+		{"same /64 shares slots", 1, 10, []string{"[2001:db8::1]"}, "[2001:db8::2]", http.StatusTooManyRequests},
+		{"other /64 unaffected", 1, 10, []string{"[2001:db8::1]"}, "[2001:db8:0:1::1]", http.StatusOK},
+		{"global limit", 5, 2, []string{"10.0.0.1", "10.0.0.2"}, "10.0.0.3", http.StatusServiceUnavailable},
+		{"disabled", 0, 0, []string{"10.0.0.1", "10.0.0.1"}, "10.0.0.1", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := NewConcurrencyLimiter(tt.perIP, tt.global, 50*time.Millisecond)
+			started := make(chan struct{}, len(tt.held)+1)
+			release := make(chan struct{})
+			handler := cl.Middleware(blockingHandler(started, release))
+
+			var wg sync.WaitGroup
+			for _, ip := range tt.held {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					handler.ServeHTTP(httptest.NewRecorder(), requestFrom(ip))
+				}()
+				<-started
+			}
+
+			rec := httptest.NewRecorder()
+			done := make(chan struct{})
+			go func() {
+				handler.ServeHTTP(rec, requestFrom(tt.ip))
+				close(done)
+			}()
+			if tt.wantCode == http.StatusOK {
+				<-started
+				close(release)
+				<-done
+			} else {
+				// Rejected once the wait runs out, while the others still hold their slots
+				<-done
+				close(release)
+			}
+			wg.Wait()
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantCode != http.StatusOK {
+				if rec.Header().Get("Retry-After") == "" {
+					t.Error("expected a Retry-After header")
+				}
+				if cl.Rejected() != 1 {
+					t.Errorf("expected 1 rejection, got %d", cl.Rejected())
+				}
+			}
+			if cl.InFlight() != 0 {
+				t.Errorf("expected no requests in flight, got %d", cl.InFlight())
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterQueues(t *testing.T) {
+	cl := NewConcurrencyLimiter(1, 1, time.Second)
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	handler := cl.Middleware(blockingHandler(started, release))
+
+	first := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), requestFrom("10.0.0.1"))
+		close(first)
+	}()
+	<-started
+
+	// The second request waits in line and is served once the first finishes
+	rec := httptest.NewRecorder()
+	second := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(rec, requestFrom("10.0.0.1"))
+		close(second)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-first
+	<-second
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected queued request to get 200, got %d", rec.Code)
+	}
+	if cl.Rejected() != 0 {
+		t.Errorf("expected no rejections, got %d", cl.Rejected())
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if len(cl.ips) != 0 {
+		t.Errorf("expected idle IPs to be forgotten, %d remain", len(cl.ips))
+	}
+}
+
+func TestConcurrencyLimiterCanceled(t *testing.T) {
+	cl := NewConcurrencyLimiter(1, 0, time.Minute)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handler := cl.Middleware(blockingHandler(started, release))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), requestFrom("10.0.0.1"))
+	<-started
+	defer close(release)
+
+	// A client that gives up while waiting frees its place without a rejection
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	handler.ServeHTTP(httptest.NewRecorder(), requestFrom("10.0.0.1").WithContext(ctx))
+
+	if cl.Rejected() != 0 {
+		t.Errorf("expected canceled requests not to count as rejections, got %d", cl.Rejected())
+	}
+}
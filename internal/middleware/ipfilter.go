@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+// ipRules is one version of an IPFilter's lists. It's replaced whole on reload,
+// so a request never sees half of an update.
+type ipRules struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// IPFilter refuses requests from denied client IPs and lets allowed ones skip
+// request limits, such as internal monitors. Its lists can be swapped while the
+// server runs.
+type IPFilter struct {
+	rules atomic.Pointer[ipRules]
+	// trusted are the proxies whose forwarding headers name the client
+	trusted  []netip.Prefix
+	denied   atomic.Uint64 // Requests refused with 403
+	exempted atomic.Uint64 // Requests that skipped the limits
+}
+
+// NewIPFilter creates a filter with the given allow and deny lists. Clients are
+// matched by the address they connect from, or by the address in forwarding
+// headers set by one of the trusted proxies.
+func NewIPFilter(allow, deny, trusted []netip.Prefix) *IPFilter {
+	f := &IPFilter{trusted: trusted}
+	f.SetLists(allow, deny)
+	return f
+}
+
+// SetLists replaces the allow and deny lists.
+func (f *IPFilter) SetLists(allow, deny []netip.Prefix) {
+	f.rules.Store(&ipRules{allow: allow, deny: deny})
+}
+
+// Lists returns the current allow and deny lists.
+func (f *IPFilter) Lists() (allow, deny []netip.Prefix) {
+	rules := f.rules.Load()
+	return rules.allow, rules.deny
+}
+
+// containsAddr reports whether any of prefixes contains addr.
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr returns the address of the client that sent r. Anyone can set
+// forwarding headers, so they're only read from a trusted proxy: then the
+// client is the last address in X-Forwarded-For that isn't a trusted proxy
+// itself, or X-Real-IP. Otherwise the client is the peer. It reports false for
+// an address it can't parse.
+func clientAddr(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	peer = peer.Unmap()
+	if !containsAddr(trusted, peer) {
+		return peer, true
+	}
+
+	// Proxies append the address they're connected to, so the hops a client
+	// wrote come first and are skipped
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return netip.Addr{}, false
+			}
+			if addr = addr.Unmap(); !containsAddr(trusted, addr) {
+				return addr, true
+			}
+		}
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		addr, err := netip.ParseAddr(realIP)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		return addr.Unmap(), true
+	}
+	return peer, true
+}
+
+// Middleware creates an HTTP middleware that refuses denied IPs with 403
+// Forbidden, sends allowed IPs to exempt, and everyone else to next. A client
+// on both lists is denied. Wrap the limited handler in next and the bare one in
+// exempt, so allowed clients skip the limits.
+func (f *IPFilter) Middleware(next, exempt http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := f.rules.Load()
+		if len(rules.allow) == 0 && len(rules.deny) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		addr, ok := clientAddr(r, f.trusted)
+		if !ok {
+			// Not an address we can match, so no list applies
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch {
+		case containsAddr(rules.deny, addr):
+			f.denied.Add(1)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		case containsAddr(rules.allow, addr):
+			f.exempted.Add(1)
+			exempt.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Denied returns how many requests have been refused with 403 Forbidden.
+func (f *IPFilter) Denied() uint64 {
+	return f.denied.Load()
+}
+
+// Exempted returns how many requests from allowed IPs skipped the limits.
+func (f *IPFilter) Exempted() uint64 {
+	return f.exempted.Load()
+}
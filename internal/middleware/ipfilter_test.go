@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	allow := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+	deny := []netip.Prefix{netip.MustParsePrefix("203.0.113.0/24"), netip.MustParsePrefix("10.6.6.6/32")}
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32"), netip.MustParsePrefix("10.0.0.2/32")}
+	f := NewIPFilter(allow, deny, trusted)
+
+	var served string
+	handler := f.Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served = "limited" }),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served = "exempt" }),
+	)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		wantCode   int
+		wantServed string
+	}{
+		{"unlisted", "192.0.2.1:1234", "", http.StatusOK, "limited"},
+		{"allowed", "10.1.2.3:1234", "", http.StatusOK, "exempt"},
+		{"allowed IPv6", "[2001:db8::1]:1234", "", http.StatusOK, "exempt"},
+		{"allowed as IPv4-mapped IPv6", "[::ffff:10.1.2.3]:1234", "", http.StatusOK, "exempt"},
+		{"denied", "203.0.113.50:1234", "", http.StatusForbidden, ""},
+		{"denied beats allowed", "10.6.6.6:1234", "", http.StatusForbidden, ""},
+		{"denied behind a proxy", "10.0.0.1:1234", "203.0.113.7", http.StatusForbidden, ""},
+		{"denied behind two proxies", "10.0.0.1:1234", "203.0.113.7, 10.0.0.2", http.StatusForbidden, ""},
+		{"denied with a forged first hop", "10.0.0.1:1234", "192.0.2.9, 203.0.113.7", http.StatusForbidden, ""},
+		{"forged allowed address", "192.0.2.1:1234", "10.1.2.3", http.StatusOK, "limited"},
+		{"forged address to escape denial", "203.0.113.50:1234", "192.0.2.9", http.StatusForbidden, ""},
+		{"unparsable", "unix-socket", "", http.StatusOK, "limited"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			served = ""
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if served != tt.wantServed {
+				t.Fatalf("expected the %q handler, got %q", tt.wantServed, served)
+			}
+		})
+	}
+
+	if f.Denied() != 6 || f.Exempted() != 3 {
+		t.Errorf("expected 6 denied and 3 exempted, got %d and %d", f.Denied(), f.Exempted())
+	}
+}
+
+func TestIPFilterSetLists(t *testing.T) {
+	f := NewIPFilter(nil, nil, nil)
+	handler := f.Middleware(http.NotFoundHandler(), http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "198.51.100.4:1234"
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code == http.StatusForbidden {
+		t.Fatal("expected no denial with empty lists")
+	}
+
+	f.SetLists(nil, []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 after the deny list was set, got %d", rec.Code)
+	}
+}
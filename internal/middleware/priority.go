@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Priority is a request's class when it waits for a concurrency slot: freed
+// slots go to the waiting requests of the highest class first, and within a
+// class in the order they arrived.
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// priorityNames spells each priority, lowest first.
+var priorityNames = [...]string{"low", "normal", "high"}
+
+// ParsePriority returns the priority named s: "low", "normal", or "high".
+func ParsePriority(s string) (Priority, bool) {
+	for i, name := range priorityNames {
+		if s == name {
+			return Priority(i) + PriorityLow, true
+		}
+	}
+	return PriorityNormal, false
+}
+
+func (p Priority) String() string {
+	return priorityNames[p-PriorityLow]
+}
+
+type priorityKey struct{}
+
+// WithPriority returns r with priority p for the concurrency limiter. Requests
+// without one are PriorityNormal.
+func WithPriority(r *http.Request, p Priority) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), priorityKey{}, p))
+}
+
+// PriorityOf returns the priority of r set by WithPriority.
+func PriorityOf(r *http.Request) Priority {
+	if p, ok := r.Context().Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// prioritySemaphore is a counting semaphore whose waiters are served by
+// priority. A released slot is handed straight to the next waiter, so a new
+// request can't take it from one already in line.
+type prioritySemaphore struct {
+	mu   sync.Mutex
+	size int
+	used int
+	// waiting holds the channels of the requests in line, by priority, lowest
+	// first; a channel is closed when its request is handed a slot
+	waiting [len(priorityNames)][]chan struct{}
+}
+
+func newPrioritySemaphore(size int) *prioritySemaphore {
+	return &prioritySemaphore{size: size}
+}
+
+// acquire takes a slot for a request of priority p, giving up when deadline
+// fires or the request is canceled.
+func (ps *prioritySemaphore) acquire(r *http.Request, p Priority, deadline <-chan time.Time) bool {
+	ps.mu.Lock()
+	if ps.used < ps.size {
+		ps.used++
+		ps.mu.Unlock()
+		return true
+	}
+	ready := make(chan struct{})
+	class := p - PriorityLow
+	ps.waiting[class] = append(ps.waiting[class], ready)
+	ps.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	case <-deadline:
+	case <-r.Context().Done():
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for i, ch := range ps.waiting[class] {
+		if ch == ready {
+			ps.waiting[class] = append(ps.waiting[class][:i], ps.waiting[class][i+1:]...)
+			return false
+		}
+	}
+	// The request was handed a slot as it gave up; pass the slot on
+	ps.releaseLocked()
+	return false
+}
+
+// release frees a slot, handing it to the highest priority request in line.
+func (ps *prioritySemaphore) release() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.releaseLocked()
+}
+
+func (ps *prioritySemaphore) releaseLocked() {
+	for class := len(ps.waiting) - 1; class >= 0; class-- {
+		if line := ps.waiting[class]; len(line) > 0 {
+			ps.waiting[class] = line[1:]
+			close(line[0])
+			return
+		}
+	}
+	ps.used--
+}
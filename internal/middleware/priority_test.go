@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Priority
+		ok   bool
+	}{
+		{"low", PriorityLow, true},
+		{"normal", PriorityNormal, true},
+		{"high", PriorityHigh, true},
+		{"urgent", PriorityNormal, false},
+		{"", PriorityNormal, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParsePriority(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParsePriority(%q) = %v, %v; expected %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+		if ok && got.String() != tt.in {
+			t.Errorf("expected %v to be spelled %q", got, tt.in)
+		}
+	}
+}
+
+// waitingFor blocks until n requests of priority p are in line for cl's slots.
+func waitingFor(t *testing.T, cl *ConcurrencyLimiter, p Priority, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		cl.global.mu.Lock()
+		queued := len(cl.global.waiting[p-PriorityLow])
+		cl.global.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d %v requests in line", n, p)
+}
+
+func TestConcurrencyLimiterPriority(t *testing.T) {
+	cl := NewConcurrencyLimiter(0, 1, time.Second)
+	var mu sync.Mutex
+	var order []Priority
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	handler := cl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, PriorityOf(r))
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+	}))
+	serve := func(wg *sync.WaitGroup, p Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), WithPriority(requestFrom("10.0.0.1"), p))
+		}()
+	}
+
+	var wg sync.WaitGroup
+	serve(&wg, PriorityNormal)
+	<-started
+	// Low priority requests line up first, yet the high priority one goes next
+	serve(&wg, PriorityLow)
+	waitingFor(t, cl, PriorityLow, 1)
+	serve(&wg, PriorityLow)
+	waitingFor(t, cl, PriorityLow, 2)
+	serve(&wg, PriorityHigh)
+	waitingFor(t, cl, PriorityHigh, 1)
+	close(release)
+	wg.Wait()
+
+	want := []Priority{PriorityNormal, PriorityHigh, PriorityLow, PriorityLow}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected requests to run in the order %v, got %v", want, order)
+		}
+	}
+	if cl.global.used != 0 {
+		t.Errorf("expected every slot to be released, %d still in use", cl.global.used)
+	}
+}
+
+func TestPrioritySemaphoreGiveUp(t *testing.T) {
+	ps := newPrioritySemaphore(1)
+	req := requestFrom("10.0.0.1")
+	if !ps.acquire(req, PriorityNormal, nil) {
+		t.Fatal("expected a free slot to be taken")
+	}
+	deadline := make(chan time.Time)
+	close(deadline)
+	if ps.acquire(req, PriorityHigh, deadline) {
+		t.Fatal("expected a request to give up when its wait runs out")
+	}
+	if len(ps.waiting[PriorityHigh-PriorityLow]) != 0 {
+		t.Error("expected a request that gave up to leave the line")
+	}
+	ps.release()
+	if ps.used != 0 {
+		t.Errorf("expected the slot to be free, %d in use", ps.used)
+	}
+}
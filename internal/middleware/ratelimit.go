@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"context"
+	"math"
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -14,6 +18,9 @@ import (
 type limiterEntry struct {
 	limiter    *rate.Limiter
 	lastAccess time.Time
+	// credit is the cost refunded by requests that turned out cheap, spent
+	// before the limiter's tokens. Guarded by the RateLimiter's mutex.
+	credit float64
 }
 
 // RateLimiter manages per-IP rate limiters
@@ -23,6 +30,8 @@ type RateLimiter struct {
 	rpm      int           // Requests per minute
 	burst    int           // Burst size
 	cleanup  time.Duration // Cleanup interval for stale entries
+	rejected atomic.Uint64 // Requests refused with 429
+	clientPrefixes
 }
 
 // NewRateLimiter creates a new rate limiter with the given requests per minute and burst size
@@ -33,6 +42,9 @@ func NewRateLimiter(rpm, burst int) *RateLimiter {
 		burst:    burst,
 		cleanup:  time.Minute * 10, // Clean up stale entries every 10 minutes
 	}
+	// Limit IPv4 clients by address, and IPv6 ones by /64: a holder usually
+	// gets a whole /64, and could pick a new address per request
+	rl.SetPrefixes(32, 64)
 
 	// Start cleanup goroutine
 	go rl.cleanupStaleEntries()
@@ -40,8 +52,8 @@ func NewRateLimiter(rpm, burst int) *RateLimiter {
 	return rl
 }
 
-// getLimiter returns the rate limiter for the given IP
-func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
+// getLimiter returns the rate limiter entry for the given IP
+func (rl *RateLimiter) getLimiter(ip string) *limiterEntry {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -59,7 +71,91 @@ func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
 		entry.lastAccess = time.Now()
 	}
 
-	return entry.limiter
+	return entry
+}
+
+// allow takes the cost of a request from the entry's credit, or else from its
+// limiter, reporting false when there's neither.
+func (rl *RateLimiter) allow(entry *limiterEntry) bool {
+	rl.mu.Lock()
+	if entry.credit >= 1 {
+		entry.credit--
+		rl.mu.Unlock()
+		return true
+	}
+	rl.mu.Unlock()
+	return entry.limiter.Allow()
+}
+
+// refund credits part of a request's cost back to the entry, up to the whole
+// tokens its limiter's bucket lacks of the burst size. Both are spent a whole
+// request at a time, so together they never let a client make more than a
+// burst of requests at once.
+func (rl *RateLimiter) refund(entry *limiterEntry, part float64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	room := max(float64(rl.burst)-math.Floor(entry.limiter.Tokens()), 0)
+	entry.credit = min(entry.credit+part, room)
+}
+
+type refundKey struct{}
+
+// refunder gives back part of one request's cost, once.
+type refunder struct {
+	refunded atomic.Bool
+	refund   func(part float64)
+}
+
+// Refund gives back the part of its rate limit cost, from 0 to 1, that r
+// turned out not to need, once its handler knows what it served: all of it
+// for a revalidation answered 304 Not Modified, say. The credit is spent by
+// the client's next requests before its tokens. Only the first refund of a
+// request counts, and requests no limiter charged have nothing to refund.
+func Refund(r *http.Request, part float64) {
+	if rf, ok := r.Context().Value(refundKey{}).(*refunder); ok && part > 0 && !rf.refunded.Swap(true) {
+		rf.refund(min(part, 1))
+	}
+}
+
+// clientPrefixes groups client IPs into the networks that count as one client,
+// for the rate and concurrency limiters.
+type clientPrefixes struct {
+	// ipv4 and ipv6 are the prefix lengths clients are grouped by
+	ipv4 atomic.Int32
+	ipv6 atomic.Int32
+}
+
+// SetPrefixes sets the prefix lengths clients share a limit by: every IPv4
+// address in the same /ipv4 network, and every IPv6 address in the same /ipv6
+// network, count as one client. Lengths out of range are clamped.
+func (cp *clientPrefixes) SetPrefixes(ipv4, ipv6 int) {
+	cp.ipv4.Store(int32(min(max(ipv4, 0), 32)))
+	cp.ipv6.Store(int32(min(max(ipv6, 0), 128)))
+}
+
+// Prefixes returns the prefix lengths clients share a limit by.
+func (cp *clientPrefixes) Prefixes() (ipv4, ipv6 int) {
+	return int(cp.ipv4.Load()), int(cp.ipv6.Load())
+}
+
+// clientKey returns the key of the limit an IP counts against: its network
+// at the configured prefix length, or the IP as given when it doesn't parse.
+// IPv4-mapped IPv6 addresses count as IPv4.
+func (cp *clientPrefixes) clientKey(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	bits := cp.ipv6.Load()
+	if addr.Is4() {
+		bits = cp.ipv4.Load()
+	}
+	prefix, err := addr.WithZone("").Prefix(int(bits))
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
 }
 
 // cleanupStaleEntries periodically removes rate limiters that haven't been used recently
@@ -110,14 +206,25 @@ func getIP(r *http.Request) string {
 // Middleware creates an HTTP middleware that applies rate limiting
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := getIP(r)
-		limiter := rl.getLimiter(ip)
+		entry := rl.getLimiter(rl.clientKey(getIP(r)))
 
-		if !limiter.Allow() {
+		if !rl.allow(entry) {
+			rl.rejected.Add(1)
 			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		rf := &refunder{refund: func(part float64) { rl.refund(entry, part) }}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), refundKey{}, rf)))
 	})
 }
+
+// Limits returns the configured requests per minute and burst size.
+func (rl *RateLimiter) Limits() (rpm, burst int) {
+	return rl.rpm, rl.burst
+}
+
+// Rejected returns how many requests have been refused with 429 Too Many Requests.
+func (rl *RateLimiter) Rejected() uint64 {
+	return rl.rejected.Load()
+}
@@ -71,6 +71,26 @@ func TestRateLimiterExceeded(t *testing.T) {
 	}
 }
 
+func TestRateLimiterCountsRejections(t *testing.T) {
+	rl := NewRateLimiter(60, 1)
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := rl.Rejected(); got != 2 {
+		t.Fatalf("expected 2 rejected requests, got %d", got)
+	}
+	if rpm, burst := rl.Limits(); rpm != 60 || burst != 1 {
+		t.Fatalf("expected limits 60/1, got %d/%d", rpm, burst)
+	}
+}
+
 func TestRateLimiterDifferentIPs(t *testing.T) {
 	// Create a rate limiter with 60 RPM (1 per second) and burst of 1
 	rl := NewRateLimiter(60, 1)
@@ -324,3 +344,112 @@ func TestGetIP(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimiterClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		ipv4, ipv6 int
+		ip         string
+		want       string
+	}{
+		{"IPv4 by address", 32, 64, "192.168.1.1", "192.168.1.1/32"},
+		{"IPv6 by /64", 32, 64, "2001:db8:1:2:aaaa::1", "2001:db8:1:2::/64"},
+		{"IPv6 with zone", 32, 64, "fe80::1%eth0", "fe80::/64"},
+		{"IPv4-mapped IPv6", 32, 64, "::ffff:192.168.1.1", "192.168.1.1/32"},
+		{"IPv4 by /24", 24, 64, "192.168.1.77", "192.168.1.0/24"},
+		{"IPv6 by /48", 32, 48, "2001:db8:1:2::1", "2001:db8:1::/48"},
+		{"IPv6 by address", 32, 128, "2001:db8::1", "2001:db8::1/128"},
+		{"out of range clamped", 40, 200, "2001:db8::1", "2001:db8::1/128"},
+		{"unparsable kept", 32, 64, "not-an-ip", "not-an-ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewRateLimiter(60, 1)
+			rl.SetPrefixes(tt.ipv4, tt.ipv6)
+			if got := rl.clientKey(tt.ip); got != tt.want {
+				t.Errorf("clientKey(%q) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterIPv6Prefix(t *testing.T) {
+	rl := NewRateLimiter(60, 1)
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve("[2001:db8:1:2::1]:1234"); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	// Another address in the same /64 shares the limit
+	if code := serve("[2001:db8:1:2::ffff]:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429 for the same /64, got %d", code)
+	}
+	// Another /64 has its own
+	if code := serve("[2001:db8:1:3::1]:1234"); code != http.StatusOK {
+		t.Fatalf("expected status 200 for another /64, got %d", code)
+	}
+}
+
+func TestRateLimiterRefund(t *testing.T) {
+	// 1 RPM refills too slowly to matter here, so only refunds make room
+	rl := NewRateLimiter(1, 2)
+
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/free":
+			Refund(r, 1)
+			// Only the first refund of a request counts
+			Refund(r, 1)
+		case "/half":
+			Refund(r, 0.5)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	get := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		path   string
+		expect int
+	}{
+		// Free requests spend their refunded credit and get it back
+		{"/free", http.StatusOK},
+		{"/free", http.StatusOK},
+		{"/free", http.StatusOK},
+		// Two halves make up one request
+		{"/half", http.StatusOK},
+		{"/half", http.StatusOK},
+		{"/full", http.StatusOK},
+		{"/full", http.StatusTooManyRequests},
+	}
+	for i, tt := range tests {
+		if code := get(tt.path); code != tt.expect {
+			t.Fatalf("request %d to %s: expected %d, got %d", i+1, tt.path, tt.expect, code)
+		}
+	}
+
+	// Refunds outside a rate-limited request are a no-op
+	Refund(httptest.NewRequest(http.MethodGet, "/free", nil), 1)
+
+	// A full bucket has no room for credit, so refunds can't raise a client
+	// over its burst
+	entry := rl.getLimiter("192.168.1.2")
+	rl.refund(entry, 1)
+	if entry.credit != 0 {
+		t.Fatalf("expected no credit on a full bucket, got %v", entry.credit)
+	}
+}
@@ -0,0 +1,119 @@
+package palette
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+// maxSamples bounds how many pixels are considered, so large images stay cheap.
+const maxSamples = 20000
+
+// Swatch is one extracted color and the share of sampled pixels it represents.
+type Swatch struct {
+	Hex    string  `json:"hex"`
+	Weight float64 `json:"weight"`
+}
+
+type pixel [3]uint8
+
+// box is a set of pixels handled as one unit by the median-cut algorithm.
+type box []pixel
+
+// channelRange returns the channel with the widest spread and that spread.
+func (b box) channelRange() (int, int) {
+	bestChannel, bestRange := 0, -1
+	for c := 0; c < 3; c++ {
+		lo, hi := uint8(255), uint8(0)
+		for _, p := range b {
+			lo = min(lo, p[c])
+			hi = max(hi, p[c])
+		}
+		if r := int(hi) - int(lo); r > bestRange {
+			bestChannel, bestRange = c, r
+		}
+	}
+	return bestChannel, bestRange
+}
+
+// average returns the mean color of the box.
+func (b box) average() pixel {
+	var sum [3]int
+	for _, p := range b {
+		for c := 0; c < 3; c++ {
+			sum[c] += int(p[c])
+		}
+	}
+	n := len(b)
+	return pixel{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n)}
+}
+
+// samplePixels collects opaque pixels from img, striding over large images.
+func samplePixels(img image.Image) []pixel {
+	bounds := img.Bounds()
+	total := bounds.Dx() * bounds.Dy()
+	step := 1
+	for total/(step*step) > maxSamples {
+		step++
+	}
+
+	pixels := make([]pixel, 0, min(total, maxSamples))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, a := img.At(x, y).RGBA()
+			// Skip mostly transparent pixels; they carry no visible color
+			if a < 0x8000 {
+				continue
+			}
+			pixels = append(pixels, pixel{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)})
+		}
+	}
+	return pixels
+}
+
+// Extract returns up to count dominant colors of img using median cut, ordered by weight.
+func Extract(img image.Image, count int) []Swatch {
+	pixels := samplePixels(img)
+	if len(pixels) == 0 || count <= 0 {
+		return nil
+	}
+
+	boxes := []box{pixels}
+	for len(boxes) < count {
+		// Split the box with the widest color range
+		idx, channel, widest := -1, 0, 0
+		for i, b := range boxes {
+			if len(b) < 2 {
+				continue
+			}
+			if c, r := b.channelRange(); r > widest {
+				idx, channel, widest = i, c, r
+			}
+		}
+		if idx < 0 {
+			break
+		}
+
+		b := boxes[idx]
+		sort.Slice(b, func(i, j int) bool { return b[i][channel] < b[j][channel] })
+		// Cut at the median, keeping pixels with the median value on one side
+		median := b[len(b)/2][channel]
+		cut := sort.Search(len(b), func(i int) bool { return b[i][channel] > median })
+		if cut == len(b) {
+			cut = sort.Search(len(b), func(i int) bool { return b[i][channel] >= median })
+		}
+		boxes[idx] = b[:cut]
+		boxes = append(boxes, b[cut:])
+	}
+
+	swatches := make([]Swatch, 0, len(boxes))
+	for _, b := range boxes {
+		avg := b.average()
+		swatches = append(swatches, Swatch{
+			Hex:    fmt.Sprintf("%02x%02x%02x", avg[0], avg[1], avg[2]),
+			Weight: float64(len(b)) / float64(len(pixels)),
+		})
+	}
+	sort.SliceStable(swatches, func(i, j int) bool { return swatches[i].Weight > swatches[j].Weight })
+	return swatches
+}
@@ -0,0 +1,67 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// splitImage returns an image whose left leftPct percent is left and the rest is right.
+func splitImage(w, h, leftPct int, left, right color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x*100 < w*leftPct {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+	return img
+}
+
+func TestExtractTwoColors(t *testing.T) {
+	img := splitImage(100, 10, 75, color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255})
+
+	swatches := Extract(img, 2)
+	if len(swatches) != 2 {
+		t.Fatalf("expected 2 swatches, got %d", len(swatches))
+	}
+	if swatches[0].Hex != "ff0000" || swatches[1].Hex != "0000ff" {
+		t.Fatalf("expected red then blue, got %s and %s", swatches[0].Hex, swatches[1].Hex)
+	}
+	if math.Abs(swatches[0].Weight-0.75) > 0.01 {
+		t.Fatalf("expected red weight 0.75, got %v", swatches[0].Weight)
+	}
+}
+
+func TestExtractSolidImageStopsEarly(t *testing.T) {
+	img := splitImage(50, 50, 100, color.RGBA{G: 128, A: 255}, color.RGBA{G: 128, A: 255})
+
+	swatches := Extract(img, 5)
+	if len(swatches) == 0 || swatches[0].Hex != "008000" {
+		t.Fatalf("expected dominant 008000, got %+v", swatches)
+	}
+}
+
+func TestExtractSkipsTransparentPixels(t *testing.T) {
+	img := splitImage(100, 10, 50, color.RGBA{R: 255, A: 255}, color.RGBA{})
+
+	swatches := Extract(img, 3)
+	if len(swatches) != 1 || swatches[0].Hex != "ff0000" || swatches[0].Weight != 1 {
+		t.Fatalf("expected only opaque red, got %+v", swatches)
+	}
+}
+
+func TestExtractLargeImageIsSampled(t *testing.T) {
+	img := splitImage(1000, 1000, 50, color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255})
+
+	if n := len(samplePixels(img)); n > maxSamples {
+		t.Fatalf("expected at most %d samples, got %d", maxSamples, n)
+	}
+	if swatches := Extract(img, 2); len(swatches) != 2 {
+		t.Fatalf("expected 2 swatches, got %d", len(swatches))
+	}
+}
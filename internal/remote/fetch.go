@@ -0,0 +1,166 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder
+	_ "image/jpeg" // register JPEG decoder
+	_ "image/png"  // register PNG decoder
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "golang.org/x/image/webp" // register WebP decoder
+)
+
+var (
+	// ErrInvalidURL is returned when the URL is malformed or not http(s).
+	ErrInvalidURL = errors.New("invalid image url")
+	// ErrHostNotAllowed is returned when the URL host is not on the allowlist.
+	ErrHostNotAllowed = errors.New("image host not allowed")
+	// ErrTooLarge is returned when the remote body exceeds the configured limit.
+	ErrTooLarge = errors.New("remote body too large")
+	// ErrTooManyPixels is returned when a remote image declares more pixels than
+	// the configured limit, before it's decoded.
+	ErrTooManyPixels = errors.New("remote image has too many pixels")
+)
+
+// Fetcher downloads and decodes images and JSON documents from an allowlist of hosts.
+type Fetcher struct {
+	allowedHosts []string
+	client       *http.Client
+	maxBytes     int64
+	maxPixels    int64
+}
+
+// NewFetcher creates a fetcher restricted to allowedHosts. Entries match a host
+// exactly, or any subdomain when prefixed with "*." (e.g. "*.example.com").
+// Bodies are limited to maxBytes, and images to maxPixels, checked from the
+// size they declare before they're decoded.
+func NewFetcher(allowedHosts []string, timeout time.Duration, maxBytes, maxPixels int64) *Fetcher {
+	hosts := make([]string, 0, len(allowedHosts))
+	for _, h := range allowedHosts {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+
+	f := &Fetcher{allowedHosts: hosts, maxBytes: maxBytes, maxPixels: maxPixels}
+	f.client = &http.Client{
+		Timeout: timeout,
+		// Re-check the allowlist on every redirect hop
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if !f.hostAllowed(req.URL.Hostname()) {
+				return ErrHostNotAllowed
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+// Enabled reports whether any hosts are allowlisted.
+func (f *Fetcher) Enabled() bool {
+	return len(f.allowedHosts) > 0
+}
+
+// hostAllowed checks host against the allowlist.
+func (f *Fetcher) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range f.allowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate parses rawURL and verifies that it is an http(s) URL on an allowlisted host.
+func (f *Fetcher) Validate(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, ErrInvalidURL
+	}
+	if !f.hostAllowed(u.Hostname()) {
+		return nil, ErrHostNotAllowed
+	}
+	return u, nil
+}
+
+// fetch downloads rawURL from an allowlisted host, up to the configured size limit.
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := f.Validate(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch: unexpected status %d", resp.StatusCode)
+	}
+	// Read one byte past the limit so oversized bodies can be detected
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if int64(len(body)) > f.maxBytes {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
+
+// FetchImage downloads rawURL and decodes it as PNG, JPEG, GIF, or WebP. The
+// size an image declares is checked before it's decoded, since a small body
+// can declare enough pixels to exhaust memory.
+func (f *Fetcher) FetchImage(ctx context.Context, rawURL string) (image.Image, error) {
+	body, err := f.fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > f.maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return img, nil
+}
+
+// FetchJSON downloads rawURL and decodes it as JSON into v.
+func (f *Fetcher) FetchJSON(ctx context.Context, rawURL string, v any) error {
+	body, err := f.fetch(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decode json: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHostAllowed(t *testing.T) {
+	f := NewFetcher([]string{"images.example.com", "*.cdn.example.org", " "}, time.Second, 1024, 1<<20)
+
+	tests := []struct {
+		host string
+		exp  bool
+	}{
+		{"images.example.com", true},
+		{"IMAGES.example.com", true},
+		{"evil.example.com", false},
+		{"a.cdn.example.org", true},
+		{"a.b.cdn.example.org", true},
+		{"cdn.example.org", false},
+		{"notcdn.example.org", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := f.hostAllowed(tt.host); got != tt.exp {
+				t.Fatalf("expected %v got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f := NewFetcher([]string{"example.com"}, time.Second, 1024, 1<<20)
+
+	tests := []struct {
+		name string
+		url  string
+		err  error
+	}{
+		{"valid https", "https://example.com/a.png", nil},
+		{"valid http with port", "http://example.com:8080/a.png", nil},
+		{"bad scheme", "file:///etc/passwd", ErrInvalidURL},
+		{"relative", "/a.png", ErrInvalidURL},
+		{"empty", "", ErrInvalidURL},
+		{"other host", "https://other.com/a.png", ErrHostNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.Validate(tt.url)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestFetchImage(t *testing.T) {
+	data := pngBytes(t, 20, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok.png":
+			_, _ = w.Write(data)
+		case "/redirect":
+			http.Redirect(w, r, "http://localhost.invalid/ok.png", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFetcher([]string{"127.0.0.1"}, 5*time.Second, 1<<20, 1<<20)
+
+	img, err := f.FetchImage(context.Background(), srv.URL+"/ok.png")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 10 {
+		t.Fatalf("expected 20x10 image, got %dx%d", b.Dx(), b.Dy())
+	}
+
+	if _, err := f.FetchImage(context.Background(), srv.URL+"/missing.png"); err == nil {
+		t.Fatal("expected error for missing image")
+	}
+	if _, err := f.FetchImage(context.Background(), srv.URL+"/redirect"); !errors.Is(err, ErrHostNotAllowed) {
+		t.Fatalf("expected redirect off the allowlist to fail with ErrHostNotAllowed, got %v", err)
+	}
+
+	small := NewFetcher([]string{"127.0.0.1"}, 5*time.Second, 10, 1<<20)
+	if _, err := small.FetchImage(context.Background(), srv.URL+"/ok.png"); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+
+	// The 20x10 image is refused from its header, before it's decoded
+	few := NewFetcher([]string{"127.0.0.1"}, 5*time.Second, 1<<20, 100)
+	if _, err := few.FetchImage(context.Background(), srv.URL+"/ok.png"); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("expected ErrTooManyPixels, got %v", err)
+	}
+}
+
+func TestFetchJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok.json":
+			_, _ = w.Write([]byte(`{"label":"build","message":"passing"}`))
+		case "/broken.json":
+			_, _ = w.Write([]byte(`{"label":`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFetcher([]string{"127.0.0.1"}, 5*time.Second, 1<<20, 1<<20)
+
+	var doc struct {
+		Label   string `json:"label"`
+		Message string `json:"message"`
+	}
+	if err := f.FetchJSON(context.Background(), srv.URL+"/ok.json", &doc); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if doc.Label != "build" || doc.Message != "passing" {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+
+	if err := f.FetchJSON(context.Background(), srv.URL+"/broken.json", &doc); err == nil {
+		t.Fatal("expected error for malformed JSON")
+	}
+	if err := f.FetchJSON(context.Background(), "https://other.example.com/ok.json", &doc); !errors.Is(err, ErrHostNotAllowed) {
+		t.Fatalf("expected ErrHostNotAllowed, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"grout/internal/config"
+)
+
+// Frame is one frame of an animation: a scene, shown for Delay.
+type Frame struct {
+	Scene Scene
+	Delay time.Duration
+}
+
+// Animation is a sequence of frames of the same size, played Loop times, or
+// forever when Loop is 0.
+type Animation struct {
+	Frames []Frame
+	Loop   int
+}
+
+// RenderAnimation draws an animation as an animated GIF, the only raster format
+// browsers animate everywhere. Every frame shares one palette of the colors the
+// frames use most, so flat areas don't flicker between frames, and each frame
+// after the first only stores the region that changed. ctx's effect is drawn over
+// every frame, in the same place.
+func (r *Renderer) RenderAnimation(ctx context.Context, anim Animation, format ImageFormat) ([]byte, error) {
+	if format != FormatGIF {
+		return nil, fmt.Errorf("%w: %s can't be animated", ErrUnsupportedFormat, format)
+	}
+	if len(anim.Frames) == 0 {
+		return nil, fmt.Errorf("render animation: no frames")
+	}
+	anim.Frames = slices.Clone(anim.Frames)
+	for i := range anim.Frames {
+		anim.Frames[i].Scene = applyEffect(ctx, anim.Frames[i].Scene)
+	}
+
+	palette, err := r.animationPalette(ctx, anim.Frames)
+	if err != nil {
+		return nil, err
+	}
+
+	out := gif.GIF{LoopCount: gifLoopCount(anim.Loop)}
+	var prev *image.Paletted
+	for _, f := range anim.Frames {
+		img, err := r.rasterizeScene(ctx, f.Scene)
+		if err != nil {
+			return nil, err
+		}
+		bounds := img.Bounds()
+		frame := image.NewPaletted(bounds, palette)
+		draw.Draw(frame, bounds, img, bounds.Min, draw.Src)
+		delay := gifDelay(f.Delay)
+
+		stored := frame
+		if prev != nil {
+			changed := changedBounds(prev, frame)
+			if changed.Empty() {
+				// Nothing changed, so the previous frame is shown for longer instead
+				out.Delay[len(out.Delay)-1] += delay
+				continue
+			}
+			stored = frame.SubImage(changed).(*image.Paletted)
+		}
+		out.Image = append(out.Image, stored)
+		out.Delay = append(out.Delay, delay)
+		out.Disposal = append(out.Disposal, gif.DisposalNone)
+		prev = frame
+	}
+	size := out.Image[0].Bounds().Size()
+	out.Config = image.Config{ColorModel: palette, Width: size.X, Height: size.Y}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &out); err != nil {
+		return nil, fmt.Errorf("encode gif: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gifLoopCount converts a number of plays, 0 for forever, to a GIF loop count,
+// which counts repeats after the first play and uses -1 for none.
+func gifLoopCount(plays int) int {
+	if plays == 1 {
+		return -1
+	}
+	return max(plays-1, 0)
+}
+
+// gifDelay converts a frame delay to hundredths of a second, at least 1.
+func gifDelay(d time.Duration) int {
+	return max(int(math.Round(float64(d)/float64(10*time.Millisecond))), 1)
+}
+
+// animationPalette returns up to 256 colors that a sample of the frames use most.
+// The sample is at most config.AnimationPaletteFrames frames spread evenly from
+// the first to the last, and large frames are sampled sparsely too.
+func (r *Renderer) animationPalette(ctx context.Context, frames []Frame) (color.Palette, error) {
+	counts := make(map[color.RGBA]int)
+	samples := min(len(frames), config.AnimationPaletteFrames)
+	for i := range samples {
+		index := 0
+		if samples > 1 {
+			index = i * (len(frames) - 1) / (samples - 1)
+		}
+		img, err := r.rasterizeScene(ctx, frames[index].Scene)
+		if err != nil {
+			return nil, err
+		}
+		bounds := img.Bounds()
+		step := max(int(math.Sqrt(float64(bounds.Dx()*bounds.Dy())/250000)), 1)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+			for x := bounds.Min.X; x < bounds.Max.X; x += step {
+				counts[color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)]++
+			}
+		}
+	}
+
+	colors := make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		colors = append(colors, c)
+	}
+	sort.Slice(colors, func(i, j int) bool {
+		if counts[colors[i]] != counts[colors[j]] {
+			return counts[colors[i]] > counts[colors[j]]
+		}
+		// Ties are broken by value so the palette doesn't depend on map order
+		return rgbaValue(colors[i]) < rgbaValue(colors[j])
+	})
+	palette := make(color.Palette, 0, min(len(colors), 256))
+	for _, c := range colors[:min(len(colors), 256)] {
+		palette = append(palette, c)
+	}
+	return palette, nil
+}
+
+// rgbaValue packs a color into one number, for ordering.
+func rgbaValue(c color.RGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
+
+// changedBounds returns the smallest rectangle holding every pixel that differs
+// between two frames of the same size and palette.
+func changedBounds(a, b *image.Paletted) image.Rectangle {
+	bounds := a.Bounds()
+	minX, minY, maxX, maxY := bounds.Max.X, bounds.Max.Y, bounds.Min.X, bounds.Min.Y
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		rowA := a.Pix[(y-bounds.Min.Y)*a.Stride:][:bounds.Dx()]
+		rowB := b.Pix[(y-bounds.Min.Y)*b.Stride:][:bounds.Dx()]
+		for i := range rowA {
+			if rowA[i] != rowB[i] {
+				x := bounds.Min.X + i
+				minX, maxX = min(minX, x), max(maxX, x+1)
+				minY, maxY = min(minY, y), max(maxY, y+1)
+			}
+		}
+	}
+	if maxX <= minX {
+		return image.Rectangle{}
+	}
+	return image.Rect(minX, minY, maxX, maxY)
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+func TestRenderAnimation(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	square := func(x float64) Scene {
+		return Scene{Width: 40, Height: 20, Background: Background{Color: "ffffff"}, Shapes: []Shape{{X: x, Y: 5, Width: 10, Height: 10, Color: "e53935"}}}
+	}
+	anim := Animation{Frames: []Frame{
+		{Scene: square(0), Delay: 100 * time.Millisecond},
+		{Scene: square(20), Delay: 100 * time.Millisecond},
+		{Scene: square(20), Delay: 300 * time.Millisecond}, // Unchanged, so merged into the frame before
+	}, Loop: 3}
+
+	data, err := r.RenderAnimation(context.Background(), anim, FormatGIF)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(g.Image) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(g.Image))
+	}
+	if g.Delay[0] != 10 || g.Delay[1] != 40 {
+		t.Errorf("expected delays [10 40], got %v", g.Delay)
+	}
+	if g.LoopCount != 2 {
+		t.Errorf("expected loop count 2 for 3 plays, got %d", g.LoopCount)
+	}
+	// The second frame only stores the squares' old and new places
+	if got, want := g.Image[1].Bounds(), image.Rect(0, 5, 30, 15); got != want {
+		t.Errorf("expected the second frame to cover %v, got %v", want, got)
+	}
+
+	if _, err := r.RenderAnimation(context.Background(), anim, FormatPNG); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for PNG, got %v", err)
+	}
+}
+
+func TestGIFLoopCount(t *testing.T) {
+	tests := []struct{ plays, expect int }{{0, 0}, {1, -1}, {2, 1}, {5, 4}}
+	for _, tt := range tests {
+		if got := gifLoopCount(tt.plays); got != tt.expect {
+			t.Errorf("gifLoopCount(%d) = %d, expected %d", tt.plays, got, tt.expect)
+		}
+	}
+}
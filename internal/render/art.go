@@ -0,0 +1,59 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/fogleman/gg"
+
+	"grout/internal/render/genart"
+)
+
+// writeSVGShapes appends shapes to an SVG document as polygons and circles.
+func writeSVGShapes(buf *bytes.Buffer, shapes []genart.Shape) {
+	for _, s := range shapes {
+		opacity := ""
+		if s.Opacity < 1 {
+			opacity = fmt.Sprintf(` fill-opacity="%.2f"`, s.Opacity)
+		}
+		if s.Circle {
+			buf.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="#%s"%s />`, s.CX, s.CY, s.R, s.Fill, opacity))
+		} else {
+			coords := make([]string, len(s.Points))
+			for i, p := range s.Points {
+				coords[i] = fmt.Sprintf("%.1f,%.1f", p[0], p[1])
+			}
+			buf.WriteString(fmt.Sprintf(`<polygon points="%s" fill="#%s"%s />`, strings.Join(coords, " "), s.Fill, opacity))
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// fillShapes draws shapes onto a raster context.
+func fillShapes(dc *gg.Context, shapes []genart.Shape) {
+	for _, s := range shapes {
+		c := ParseHexColor(s.Fill).(color.RGBA)
+		dc.SetColor(color.NRGBA{R: c.R, G: c.G, B: c.B, A: uint8(s.Opacity * 255)})
+		if s.Circle {
+			dc.DrawCircle(s.CX, s.CY, s.R)
+		} else {
+			for _, p := range s.Points {
+				dc.LineTo(p[0], p[1])
+			}
+			dc.ClosePath()
+		}
+		dc.Fill()
+	}
+}
+
+// DrawArt renders a seeded generative composition with optional centered text on top.
+func (r *Renderer) DrawArt(ctx context.Context, w, h int, variant genart.Variant, seed uint64, palette []string, text, fgHex string, format ImageFormat) ([]byte, error) {
+	shapes, err := genart.Generate(ctx, variant, w, h, seed, palette)
+	if err != nil {
+		return nil, err
+	}
+	return r.drawBackgroundWithLabel(ctx, w, h, shapesBackground(shapes), text, fgHex, false, true, format)
+}
@@ -0,0 +1,36 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"grout/internal/render/genart"
+)
+
+func TestDrawArt(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	palette := []string{"264653", "2a9d8f", "e9c46a"}
+
+	for _, variant := range genart.Variants() {
+		for _, format := range []ImageFormat{FormatSVG, FormatPNG} {
+			t.Run(string(variant)+"/"+string(format), func(t *testing.T) {
+				data, err := r.DrawArt(context.Background(), 400, 200, variant, 42, palette, "Hero", "ffffff", format)
+				if err != nil {
+					t.Fatalf("failed to draw art: %v", err)
+				}
+				again, _ := r.DrawArt(context.Background(), 400, 200, variant, 42, palette, "Hero", "ffffff", format)
+				if !bytes.Equal(data, again) {
+					t.Fatal("expected deterministic output")
+				}
+				if format == FormatSVG && !strings.Contains(string(data), ">Hero</text>") {
+					t.Fatalf("expected overlay text, got: %.300s", data)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"runtime"
+	"slices"
+	"sync/atomic"
+)
+
+// webpBackend is a WebP encoder. The native one is pure Go and in every build,
+// so a CGO_ENABLED=0 build still serves WebP; builds with cgo also carry the
+// cgo one, which wraps libwebp.
+type webpBackend struct {
+	name string
+	// lossy backends honor the quality; the others encode losslessly
+	lossy  bool
+	encode func(w io.Writer, img image.Image, quality int) error
+}
+
+// WebP backend names, in the order the default prefers them. WebPOff turns
+// WebP output off in any build.
+const (
+	WebPCgo    = "cgo"
+	WebPNative = "native"
+	WebPOff    = "off"
+)
+
+// webpBackends holds the WebP backends this build carries, by name; build-tagged
+// files register them.
+var webpBackends = map[string]*webpBackend{}
+
+// activeWebP is the WebP backend in use, nil when WebP is off.
+var activeWebP atomic.Pointer[webpBackend]
+
+func registerWebPBackend(b *webpBackend) {
+	webpBackends[b.name] = b
+	// Until a backend is picked, use the best one
+	_ = UseWebPBackend("")
+}
+
+// UseWebPBackend picks the WebP backend images are encoded with: a backend's
+// name, WebPOff, or "" for the best this build carries. It fails when the build
+// doesn't carry the backend.
+func UseWebPBackend(name string) error {
+	switch name {
+	case WebPOff:
+		activeWebP.Store(nil)
+		return nil
+	case "":
+		for _, preferred := range []string{WebPCgo, WebPNative} {
+			if b, ok := webpBackends[preferred]; ok {
+				activeWebP.Store(b)
+				return nil
+			}
+		}
+		activeWebP.Store(nil)
+		return nil
+	}
+	b, ok := webpBackends[name]
+	if !ok {
+		return fmt.Errorf("webp backend %q is not in this build; it has %v", name, webpBackendNames())
+	}
+	activeWebP.Store(b)
+	return nil
+}
+
+func webpBackendNames() []string {
+	names := []string{WebPOff}
+	for name := range webpBackends {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// encodeWebP encodes img with the WebP backend in use.
+func encodeWebP(w io.Writer, img image.Image, quality int) error {
+	b := activeWebP.Load()
+	if b == nil {
+		return fmt.Errorf("%w: webp output is off on this server", ErrUnsupportedFormat)
+	}
+	return b.encode(w, img, quality)
+}
+
+// CanEncode reports whether images can be encoded in format with this build
+// and backend.
+func CanEncode(format ImageFormat) bool {
+	return format != FormatWebP || activeWebP.Load() != nil
+}
+
+// Capabilities describes what this build of the renderer can do.
+type Capabilities struct {
+	// CGO reports whether the binary was built with cgo
+	CGO bool `json:"cgo"`
+	// Platform is the GOOS/GOARCH the binary was built for
+	Platform string `json:"platform"`
+	// Formats lists the output formats images can be encoded in
+	Formats []ImageFormat    `json:"formats"`
+	WebP    WebPCapabilities `json:"webp"`
+}
+
+// WebPCapabilities describes the WebP backend in use, and the ones the build
+// carries.
+type WebPCapabilities struct {
+	Backend string `json:"backend"`
+	// Lossy reports whether the quality parameter applies to WebP
+	Lossy     bool     `json:"lossy"`
+	Available []string `json:"available"`
+}
+
+// BuildCapabilities reports what this build can do, with the WebP backend in use.
+func BuildCapabilities() Capabilities {
+	caps := Capabilities{
+		CGO:      cgoEnabled,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		WebP:     WebPCapabilities{Backend: WebPOff, Available: webpBackendNames()},
+	}
+	for _, format := range []ImageFormat{FormatSVG, FormatPNG, FormatJPG, FormatJPEG, FormatGIF, FormatWebP} {
+		if CanEncode(format) {
+			caps.Formats = append(caps.Formats, format)
+		}
+	}
+	if b := activeWebP.Load(); b != nil {
+		caps.WebP.Backend, caps.WebP.Lossy = b.name, b.lossy
+	}
+	return caps
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+
+	xwebp "golang.org/x/image/webp"
+)
+
+func TestUseWebPBackend(t *testing.T) {
+	t.Cleanup(func() { _ = UseWebPBackend("") })
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 30), uint8(y * 30), 200, 255})
+		}
+	}
+
+	for name, b := range webpBackends {
+		t.Run(name, func(t *testing.T) {
+			if err := UseWebPBackend(name); err != nil {
+				t.Fatal(err)
+			}
+			data, err := encodeWith(img, FormatWebP, EncodeOptions{Quality: 80})
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			decoded, err := xwebp.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if decoded.Bounds() != img.Bounds() {
+				t.Fatalf("expected %v, got %v", img.Bounds(), decoded.Bounds())
+			}
+			// Lossless backends give back the exact pixels
+			if !b.lossy {
+				r, g, bl, _ := decoded.At(5, 3).RGBA()
+				if got := (color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), 255}); got != img.RGBAAt(5, 3) {
+					t.Errorf("expected %v, got %v", img.RGBAAt(5, 3), got)
+				}
+			}
+			if caps := BuildCapabilities(); caps.WebP.Backend != name || caps.WebP.Lossy != b.lossy {
+				t.Errorf("expected capabilities of %s, got %+v", name, caps.WebP)
+			}
+		})
+	}
+
+	if err := UseWebPBackend(WebPOff); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := encodeImage(context.Background(), img, FormatWebP); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat with WebP off, got %v", err)
+	}
+	if CanEncode(FormatWebP) || !CanEncode(FormatPNG) || slices.Contains(BuildCapabilities().Formats, FormatWebP) {
+		t.Error("expected WebP, and only WebP, to be unavailable")
+	}
+
+	if err := UseWebPBackend("libvips"); err == nil {
+		t.Error("expected an unknown backend to fail")
+	}
+}
+
+func TestDefaultWebPBackend(t *testing.T) {
+	t.Cleanup(func() { _ = UseWebPBackend("") })
+	if err := UseWebPBackend(""); err != nil {
+		t.Fatal(err)
+	}
+	want := WebPOff
+	switch {
+	case webpBackends[WebPCgo] != nil:
+		want = WebPCgo
+	case webpBackends[WebPNative] != nil:
+		want = WebPNative
+	}
+	caps := BuildCapabilities()
+	if caps.WebP.Backend != want {
+		t.Errorf("expected the %s backend, got %s", want, caps.WebP.Backend)
+	}
+	if caps.CGO != cgoEnabled {
+		t.Errorf("expected cgo %v, got %v", cgoEnabled, caps.CGO)
+	}
+}
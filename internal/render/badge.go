@@ -0,0 +1,181 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+)
+
+// BadgeStyle selects the look of a badge, named as on shields.io.
+type BadgeStyle string
+
+const (
+	BadgeFlat        BadgeStyle = "flat"
+	BadgeFlatSquare  BadgeStyle = "flat-square"
+	BadgeForTheBadge BadgeStyle = "for-the-badge"
+)
+
+// Badge default colors, as on shields.io.
+const (
+	DefaultBadgeLabelColor = "555555"
+	DefaultBadgeColor      = "9f9f9f"
+)
+
+// Badge is a two-part label and message badge. An empty label draws the message alone.
+type Badge struct {
+	Label      string
+	Message    string
+	Color      string // Message background, hex without '#'
+	LabelColor string // Label background, hex without '#'
+	Style      BadgeStyle
+}
+
+// badgeColors maps the shields.io color names and aliases to hex.
+var badgeColors = map[string]string{
+	"brightgreen":   "44cc11",
+	"green":         "97ca00",
+	"yellow":        "dfb317",
+	"yellowgreen":   "a4a61d",
+	"orange":        "fe7d37",
+	"red":           "e05d44",
+	"blue":          "007ec6",
+	"grey":          "555555",
+	"gray":          "555555",
+	"lightgrey":     "9f9f9f",
+	"lightgray":     "9f9f9f",
+	"success":       "44cc11",
+	"important":     "fe7d37",
+	"critical":      "e05d44",
+	"informational": "007ec6",
+	"inactive":      "9f9f9f",
+}
+
+// ResolveBadgeColor returns the hex value of a shields.io color name, a CSS color
+// name, or a hex color, and false for anything else.
+func ResolveBadgeColor(s string) (string, bool) {
+	if hex, ok := badgeColors[strings.ToLower(s)]; ok {
+		return hex, true
+	}
+	return ResolveColor(s)
+}
+
+// badgeMetrics holds the size and type of a badge style.
+type badgeMetrics struct {
+	height   int
+	fontSize float64
+	padding  float64 // Horizontal padding on each side of a text
+	radius   float64
+	bold     bool
+	gloss    bool // Draws the subtle top-to-bottom shading of flat badges
+}
+
+func (s BadgeStyle) metrics() badgeMetrics {
+	switch s {
+	case BadgeFlatSquare:
+		return badgeMetrics{height: 20, fontSize: 11, padding: 6}
+	case BadgeForTheBadge:
+		return badgeMetrics{height: 28, fontSize: 10, padding: 9, bold: true}
+	default:
+		return badgeMetrics{height: 20, fontSize: 11, padding: 6, radius: 3, gloss: true}
+	}
+}
+
+// DrawBadge renders a shields.io-style badge.
+func (r *Renderer) DrawBadge(ctx context.Context, b Badge, format ImageFormat) ([]byte, error) {
+	m := b.Style.metrics()
+	if b.Style == BadgeForTheBadge {
+		b.Label, b.Message = strings.ToUpper(b.Label), strings.ToUpper(b.Message)
+	}
+	if b.Color == "" {
+		b.Color = DefaultBadgeColor
+	}
+	if b.LabelColor == "" {
+		b.LabelColor = DefaultBadgeLabelColor
+	}
+
+	font := r.regular
+	if m.bold {
+		font = r.bold
+	}
+	face := truetype.NewFace(font, &truetype.Options{Size: m.fontSize})
+	defer face.Close()
+	measure := gg.NewContext(1, 1)
+	measure.SetFontFace(face)
+	partWidth := func(text string) float64 {
+		if text == "" {
+			return 0
+		}
+		tw, _ := measure.MeasureString(text)
+		return math.Ceil(tw + 2*m.padding)
+	}
+	labelW, messageW := partWidth(b.Label), partWidth(b.Message)
+	w, h := int(labelW+messageW), m.height
+	labelFg, messageFg := GetContrastColor(b.LabelColor), GetContrastColor(b.Color)
+
+	if format == FormatSVG {
+		title := b.Message
+		if b.Label != "" {
+			title = b.Label + ": " + b.Message
+		}
+		fontWeight := "normal"
+		if m.bold {
+			fontWeight = "bold"
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s">`, w, h, w, h, escapeXML(title)))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<title>%s</title>`, escapeXML(title)))
+		buf.WriteString("\n")
+		if m.gloss {
+			buf.WriteString(`<linearGradient id="badge_gloss" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1" /><stop offset="1" stop-opacity=".1" /></linearGradient>`)
+			buf.WriteString("\n")
+		}
+		buf.WriteString(fmt.Sprintf(`<clipPath id="badge_clip"><rect width="%d" height="%d" rx="%.0f" /></clipPath>`, w, h, m.radius))
+		buf.WriteString("\n")
+		buf.WriteString(`<g clip-path="url(#badge_clip)">`)
+		if labelW > 0 {
+			buf.WriteString(fmt.Sprintf(`<rect width="%.0f" height="%d" fill="#%s" />`, labelW, h, b.LabelColor))
+		}
+		buf.WriteString(fmt.Sprintf(`<rect x="%.0f" width="%.0f" height="%d" fill="#%s" />`, labelW, messageW, h, b.Color))
+		if m.gloss {
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="url(#badge_gloss)" />`, w, h))
+		}
+		buf.WriteString("</g>\n")
+		buf.WriteString(fmt.Sprintf(`<g text-anchor="middle" dominant-baseline="central" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="%.0f" font-weight="%s">`, m.fontSize, fontWeight))
+		buf.WriteString("\n")
+		if labelW > 0 {
+			buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" fill="#%s">%s</text>`, labelW/2, float64(h)/2, labelFg, escapeXML(b.Label)))
+			buf.WriteString("\n")
+		}
+		buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" fill="#%s">%s</text>`, labelW+messageW/2, float64(h)/2, messageFg, escapeXML(b.Message)))
+		buf.WriteString("\n")
+		buf.WriteString("</g>\n</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(w, h)
+	dc.DrawRoundedRectangle(0, 0, float64(w), float64(h), m.radius)
+	dc.Clip()
+	dc.SetColor(ParseHexColor(b.LabelColor))
+	dc.DrawRectangle(0, 0, labelW, float64(h))
+	dc.Fill()
+	dc.SetColor(ParseHexColor(b.Color))
+	dc.DrawRectangle(labelW, 0, messageW, float64(h))
+	dc.Fill()
+	dc.ResetClip()
+
+	dc.SetFontFace(textOptions(ctx).face(font, m.fontSize))
+	if labelW > 0 {
+		dc.SetColor(ParseHexColor(labelFg))
+		dc.DrawStringAnchored(b.Label, labelW/2, float64(h)/2, 0.5, 0.5)
+	}
+	dc.SetColor(ParseHexColor(messageFg))
+	dc.DrawStringAnchored(b.Message, labelW+messageW/2, float64(h)/2, 0.5, 0.5)
+	return encodeImage(ctx, dc.Image(), format)
+}
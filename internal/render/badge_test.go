@@ -0,0 +1,68 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestResolveBadgeColor(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+		ok     bool
+	}{
+		{"brightgreen", "44cc11", true},
+		{"Critical", "e05d44", true},
+		{"orange", "fe7d37", true}, // The shields.io orange wins over the CSS one
+		{"navy", "000080", true},
+		{"abc", "abc", true},
+		{"nope", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := ResolveBadgeColor(tt.input)
+			if ok != tt.ok || got != tt.expect {
+				t.Fatalf("expected (%q, %t) got (%q, %t)", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestDrawBadge(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	full, err := r.DrawBadge(context.Background(), Badge{Label: "build", Message: "passing"}, FormatSVG)
+	if err != nil {
+		t.Fatalf("DrawBadge: %v", err)
+	}
+	for _, expect := range []string{`fill="#555555"`, `fill="#9f9f9f"`, "badge_gloss", `rx="3"`} {
+		if !strings.Contains(string(full), expect) {
+			t.Errorf("expected default badge to contain %q", expect)
+		}
+	}
+
+	// A badge without a label is narrower and has a single text
+	messageOnly, _ := r.DrawBadge(context.Background(), Badge{Message: "passing"}, FormatSVG)
+	if strings.Count(string(messageOnly), "<text") != 1 {
+		t.Fatal("expected a single text without a label")
+	}
+
+	data, err := r.DrawBadge(context.Background(), Badge{Label: "build", Message: "passing"}, FormatPNG)
+	if err != nil {
+		t.Fatalf("DrawBadge png: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dy() != 20 || b.Dx() < 60 {
+		t.Fatalf("unexpected badge size %v", b)
+	}
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidBarcode is returned for text a barcode can't encode.
+var ErrInvalidBarcode = errors.New("invalid barcode text")
+
+// code128Patterns are the bar and space widths, in modules, of each Code 128
+// symbol value, starting with a bar. Values 103 to 105 are the start codes.
+var code128Patterns = [106]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = "2331112" // The stop pattern ends with a final bar
+	// code128QuietZone is the blank space, in modules, needed each side of a barcode
+	code128QuietZone = 10
+)
+
+// code128 encodes text as a Code 128 barcode in code set B, which covers
+// printable ASCII. It returns the widths, in modules, of alternating bars and
+// spaces, starting with a bar and without the quiet zones.
+func code128(text string) ([]int, error) {
+	if text == "" {
+		return nil, fmt.Errorf("%w: no text", ErrInvalidBarcode)
+	}
+
+	values := []int{code128StartB}
+	checksum := code128StartB
+	for i, c := range text {
+		if c < ' ' || c > '~' {
+			return nil, fmt.Errorf("%w: %q is not printable ASCII", ErrInvalidBarcode, c)
+		}
+		value := int(c - ' ')
+		values = append(values, value)
+		checksum += (i + 1) * value
+	}
+	values = append(values, checksum%103)
+
+	var widths []int
+	for _, value := range values {
+		for _, w := range code128Patterns[value] {
+			widths = append(widths, int(w-'0'))
+		}
+	}
+	for _, w := range code128Stop {
+		widths = append(widths, int(w-'0'))
+	}
+	return widths, nil
+}
+
+// code128Modules returns the width of a barcode in modules, quiet zones included.
+func code128Modules(widths []int) int {
+	modules := 2 * code128QuietZone
+	for _, w := range widths {
+		modules += w
+	}
+	return modules
+}
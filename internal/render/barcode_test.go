@@ -0,0 +1,68 @@
+package render
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestCode128Patterns(t *testing.T) {
+	seen := make(map[string]bool)
+	for value, pattern := range code128Patterns {
+		if seen[pattern] {
+			t.Errorf("value %d: duplicate pattern %s", value, pattern)
+		}
+		seen[pattern] = true
+
+		// Every symbol is 11 modules, and its bars add up to an even width
+		total, bars := 0, 0
+		for i, c := range pattern {
+			w := int(c - '0')
+			total += w
+			if i%2 == 0 {
+				bars += w
+			}
+		}
+		if total != 11 || bars%2 != 0 {
+			t.Errorf("value %d: pattern %s has %d modules and %d bar modules", value, pattern, total, bars)
+		}
+	}
+}
+
+func TestCode128(t *testing.T) {
+	widths, err := code128("Wikipedia")
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	// Start, 9 characters, check, and the 7-element stop
+	if len(widths) != 11*6+7 {
+		t.Fatalf("expected %d widths got %d", 11*6+7, len(widths))
+	}
+	if modules := code128Modules(widths); modules != 11*11+13+2*code128QuietZone {
+		t.Errorf("expected %d modules got %d", 11*11+13+2*code128QuietZone, modules)
+	}
+
+	symbol := func(i int) string {
+		s := ""
+		for _, w := range widths[i*6 : i*6+6] {
+			s += strconv.Itoa(w)
+		}
+		return s
+	}
+	if symbol(0) != code128Patterns[code128StartB] {
+		t.Errorf("expected start B, got %s", symbol(0))
+	}
+	if symbol(1) != code128Patterns['W'-' '] {
+		t.Errorf("expected W, got %s", symbol(1))
+	}
+	// The check symbol of "Wikipedia" is 88
+	if symbol(10) != code128Patterns[88] {
+		t.Errorf("expected check symbol 88, got %s", symbol(10))
+	}
+
+	for _, text := range []string{"", "café", "tab\there"} {
+		if _, err := code128(text); !errors.Is(err, ErrInvalidBarcode) {
+			t.Errorf("%q: expected ErrInvalidBarcode, got %v", text, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+
+	"grout/internal/locale"
+)
+
+// calendarLayout holds the geometry of a calendar tile: a header band on top
+// and a body region below it holding the day number and weekday.
+type calendarLayout struct {
+	headerHeight    float64
+	monthFontSize   float64
+	dayFontSize     float64
+	weekdayFontSize float64
+	dayY            float64
+	weekdayY        float64
+}
+
+// newCalendarLayout splits the tile into a header band (28% of the height) and a body region.
+func newCalendarLayout(w, h int) calendarLayout {
+	width, height := float64(w), float64(h)
+	headerHeight := height * 0.28
+	bodyHeight := height - headerHeight
+
+	minDim := width
+	if bodyHeight < minDim {
+		minDim = bodyHeight
+	}
+
+	return calendarLayout{
+		headerHeight:    headerHeight,
+		monthFontSize:   headerHeight * 0.5,
+		dayFontSize:     minDim * 0.55,
+		weekdayFontSize: bodyHeight * 0.12,
+		dayY:            headerHeight + bodyHeight*0.45,
+		weekdayY:        headerHeight + bodyHeight*0.85,
+	}
+}
+
+// DrawCalendarTile renders a calendar tile with the month in a header band and
+// a large day number with its weekday in the body, named in loc's language.
+func (r *Renderer) DrawCalendarTile(ctx context.Context, w, h int, date time.Time, loc *locale.Locale, headerHex, bgHex, fgHex string, format ImageFormat) ([]byte, error) {
+	layout := newCalendarLayout(w, h)
+	month := strings.ToUpper(loc.MonthShort(date.Month()))
+	day := strconv.Itoa(date.Day())
+	weekday := loc.Weekday(date.Weekday())
+	headerFgHex := GetContrastColor(headerHex)
+
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, w, h, bgHex))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%.0f" fill="#%s" />`, w, layout.headerHeight, headerHex))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<text x="%d" y="%.0f" font-family="sans-serif" font-size="%.0f" font-weight="bold" fill="#%s" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+			w/2, layout.headerHeight/2, layout.monthFontSize, headerFgHex, escapeXML(month)))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<text x="%d" y="%.0f" font-family="sans-serif" font-size="%.0f" font-weight="bold" fill="#%s" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+			w/2, layout.dayY, layout.dayFontSize, fgHex, day))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<text x="%d" y="%.0f" font-family="sans-serif" font-size="%.0f" font-weight="normal" fill="#%s" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+			w/2, layout.weekdayY, layout.weekdayFontSize, fgHex, escapeXML(weekday)))
+		buf.WriteString("\n")
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	text := textOptions(ctx)
+	dc := gg.NewContext(w, h)
+	dc.SetColor(ParseHexColor(bgHex))
+	dc.DrawRectangle(0, 0, float64(w), float64(h))
+	dc.Fill()
+
+	dc.SetColor(ParseHexColor(headerHex))
+	dc.DrawRectangle(0, 0, float64(w), layout.headerHeight)
+	dc.Fill()
+
+	dc.SetFontFace(text.face(r.bold, layout.monthFontSize))
+	dc.SetColor(ParseHexColor(headerFgHex))
+	dc.DrawStringAnchored(month, float64(w)/2, layout.headerHeight/2, 0.5, 0.5)
+
+	fg := ParseHexColor(fgHex)
+	dc.SetFontFace(text.face(r.bold, layout.dayFontSize))
+	dc.SetColor(fg)
+	dc.DrawStringAnchored(day, float64(w)/2, layout.dayY, 0.5, 0.5)
+
+	dc.SetFontFace(text.face(r.regular, layout.weekdayFontSize))
+	dc.DrawStringAnchored(weekday, float64(w)/2, layout.weekdayY, 0.5, 0.5)
+
+	return encodeImage(ctx, dc.Image(), format)
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"grout/internal/locale"
+)
+
+func TestDrawCalendarTile(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	date := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format ImageFormat
+	}{
+		{"SVG tile", FormatSVG},
+		{"PNG tile", FormatPNG},
+		{"JPG tile", FormatJPG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := r.DrawCalendarTile(context.Background(), 200, 200, date, locale.English, "e53935", "ffffff", "000000", tt.format)
+			if err != nil {
+				t.Fatalf("failed to draw calendar tile: %v", err)
+			}
+			if len(data) == 0 {
+				t.Fatal("expected image data, got empty")
+			}
+		})
+	}
+}
+
+func TestDrawCalendarTileSVGContent(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	date := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+	data, err := r.DrawCalendarTile(context.Background(), 200, 200, date, locale.English, "e53935", "ffffff", "000000", FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw calendar tile: %v", err)
+	}
+
+	svgStr := string(data)
+	for _, want := range []string{">MAY</text>", ">1</text>", ">Thursday</text>", `fill="#e53935"`} {
+		if !strings.Contains(svgStr, want) {
+			t.Errorf("expected SVG to contain %q, got: %s", want, svgStr)
+		}
+	}
+}
+
+func TestDrawCalendarTileLocale(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	de, _ := locale.Lookup("de")
+	date := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	data, err := r.DrawCalendarTile(context.Background(), 200, 200, date, de, "e53935", "ffffff", "000000", FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw calendar tile: %v", err)
+	}
+	for _, want := range []string{">MÄR</text>", ">Samstag</text>"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected SVG to contain %q, got: %s", want, data)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"regexp"
+	"strings"
+)
+
+var hexColorRegex = regexp.MustCompile(`^(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// namedColors maps the CSS named colors to their hex values.
+var namedColors = map[string]string{
+	"aliceblue": "f0f8ff", "antiquewhite": "faebd7", "aqua": "00ffff", "aquamarine": "7fffd4",
+	"azure": "f0ffff", "beige": "f5f5dc", "bisque": "ffe4c4", "black": "000000",
+	"blanchedalmond": "ffebcd", "blue": "0000ff", "blueviolet": "8a2be2", "brown": "a52a2a",
+	"burlywood": "deb887", "cadetblue": "5f9ea0", "chartreuse": "7fff00", "chocolate": "d2691e",
+	"coral": "ff7f50", "cornflowerblue": "6495ed", "cornsilk": "fff8dc", "crimson": "dc143c",
+	"cyan": "00ffff", "darkblue": "00008b", "darkcyan": "008b8b", "darkgoldenrod": "b8860b",
+	"darkgray": "a9a9a9", "darkgreen": "006400", "darkgrey": "a9a9a9", "darkkhaki": "bdb76b",
+	"darkmagenta": "8b008b", "darkolivegreen": "556b2f", "darkorange": "ff8c00", "darkorchid": "9932cc",
+	"darkred": "8b0000", "darksalmon": "e9967a", "darkseagreen": "8fbc8f", "darkslateblue": "483d8b",
+	"darkslategray": "2f4f4f", "darkslategrey": "2f4f4f", "darkturquoise": "00ced1", "darkviolet": "9400d3",
+	"deeppink": "ff1493", "deepskyblue": "00bfff", "dimgray": "696969", "dimgrey": "696969",
+	"dodgerblue": "1e90ff", "firebrick": "b22222", "floralwhite": "fffaf0", "forestgreen": "228b22",
+	"fuchsia": "ff00ff", "gainsboro": "dcdcdc", "ghostwhite": "f8f8ff", "gold": "ffd700",
+	"goldenrod": "daa520", "gray": "808080", "green": "008000", "greenyellow": "adff2f",
+	"grey": "808080", "honeydew": "f0fff0", "hotpink": "ff69b4", "indianred": "cd5c5c",
+	"indigo": "4b0082", "ivory": "fffff0", "khaki": "f0e68c", "lavender": "e6e6fa",
+	"lavenderblush": "fff0f5", "lawngreen": "7cfc00", "lemonchiffon": "fffacd", "lightblue": "add8e6",
+	"lightcoral": "f08080", "lightcyan": "e0ffff", "lightgoldenrodyellow": "fafad2", "lightgray": "d3d3d3",
+	"lightgreen": "90ee90", "lightgrey": "d3d3d3", "lightpink": "ffb6c1", "lightsalmon": "ffa07a",
+	"lightseagreen": "20b2aa", "lightskyblue": "87cefa", "lightslategray": "778899", "lightslategrey": "778899",
+	"lightsteelblue": "b0c4de", "lightyellow": "ffffe0", "lime": "00ff00", "limegreen": "32cd32",
+	"linen": "faf0e6", "magenta": "ff00ff", "maroon": "800000", "mediumaquamarine": "66cdaa",
+	"mediumblue": "0000cd", "mediumorchid": "ba55d3", "mediumpurple": "9370db", "mediumseagreen": "3cb371",
+	"mediumslateblue": "7b68ee", "mediumspringgreen": "00fa9a", "mediumturquoise": "48d1cc", "mediumvioletred": "c71585",
+	"midnightblue": "191970", "mintcream": "f5fffa", "mistyrose": "ffe4e1", "moccasin": "ffe4b5",
+	"navajowhite": "ffdead", "navy": "000080", "oldlace": "fdf5e6", "olive": "808000",
+	"olivedrab": "6b8e23", "orange": "ffa500", "orangered": "ff4500", "orchid": "da70d6",
+	"palegoldenrod": "eee8aa", "palegreen": "98fb98", "paleturquoise": "afeeee", "palevioletred": "db7093",
+	"papayawhip": "ffefd5", "peachpuff": "ffdab9", "peru": "cd853f", "pink": "ffc0cb",
+	"plum": "dda0dd", "powderblue": "b0e0e6", "purple": "800080", "rebeccapurple": "663399",
+	"red": "ff0000", "rosybrown": "bc8f8f", "royalblue": "4169e1", "saddlebrown": "8b4513",
+	"salmon": "fa8072", "sandybrown": "f4a460", "seagreen": "2e8b57", "seashell": "fff5ee",
+	"sienna": "a0522d", "silver": "c0c0c0", "skyblue": "87ceeb", "slateblue": "6a5acd",
+	"slategray": "708090", "slategrey": "708090", "snow": "fffafa", "springgreen": "00ff7f",
+	"steelblue": "4682b4", "tan": "d2b48c", "teal": "008080", "thistle": "d8bfd8",
+	"tomato": "ff6347", "turquoise": "40e0d0", "violet": "ee82ee", "wheat": "f5deb3",
+	"white": "ffffff", "whitesmoke": "f5f5f5", "yellow": "ffff00", "yellowgreen": "9acd32",
+}
+
+// AverageColor returns the hex value (no '#') of the average of hex colors.
+func AverageColor(hexes ...string) string {
+	var sum [3]int
+	for _, hex := range hexes {
+		c := ParseHexColor(hex).(color.RGBA)
+		sum[0], sum[1], sum[2] = sum[0]+int(c.R), sum[1]+int(c.G), sum[2]+int(c.B)
+	}
+	n := max(1, len(hexes))
+	return fmt.Sprintf("%02x%02x%02x", sum[0]/n, sum[1]/n, sum[2]/n)
+}
+
+// ResolveColor returns the hex value (no '#') of a 3- or 6-digit hex color or a CSS
+// color name, and false for anything else.
+func ResolveColor(s string) (string, bool) {
+	s = strings.TrimPrefix(s, "#")
+	if hexColorRegex.MatchString(s) {
+		return s, true
+	}
+	hex, ok := namedColors[strings.ToLower(s)]
+	return hex, ok
+}
+
+// Hue rotations for AccentColor.
+const (
+	ComplementaryRotation = 180 // The hue opposite the background's
+	AnalogousRotation     = 30  // A neighbor of the background's hue
+)
+
+// Accent colors stay between these saturations, so they're neither gray nor garish.
+const (
+	minAccentSaturation = 0.45
+	maxAccentSaturation = 0.85
+	// grayscaleSaturation is the saturation below which a color's hue is too
+	// weak to rotate, so its accent is a lighter or darker gray instead
+	grayscaleSaturation = 0.08
+	// minAccentContrast is the WCAG contrast ratio an accent keeps against its
+	// background, the minimum for large text
+	minAccentContrast = 3
+)
+
+// RGBToHSL converts a color to its hue in degrees, from 0 to 360, and its
+// saturation and lightness, from 0 to 1.
+func RGBToHSL(c color.RGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := max(r, g, b), min(r, g, b)
+	l = (hi + lo) / 2
+	if hi == lo {
+		return 0, 0, l
+	}
+	d := hi - lo
+	if l > 0.5 {
+		s = d / (2 - hi - lo)
+	} else {
+		s = d / (hi + lo)
+	}
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// HSLToRGB converts a hue in degrees and a saturation and lightness from 0 to 1
+// to an opaque color. Hues outside 0 to 360 wrap around.
+func HSLToRGB(h, s, l float64) color.RGBA {
+	h = math.Mod(math.Mod(h, 360)+360, 360) / 60
+	chroma := (1 - math.Abs(2*l-1)) * s
+	x := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g, b = chroma, x, 0
+	case 1:
+		r, g, b = x, chroma, 0
+	case 2:
+		r, g, b = 0, chroma, x
+	case 3:
+		r, g, b = 0, x, chroma
+	case 4:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	m := l - chroma/2
+	channel := func(v float64) uint8 { return uint8(math.Round(min(max(v+m, 0), 1) * 255)) }
+	return color.RGBA{R: channel(r), G: channel(g), B: channel(b), A: 255}
+}
+
+// AccentColor returns the hex value (no '#') of an accent color for text on
+// bgHex: the background's hue rotated by rotation degrees, saturated enough to
+// read as a color, and light or dark enough to contrast with the background.
+// Gray backgrounds get a darker or lighter gray. Gradients use the average of
+// their colors.
+func AccentColor(bgHex string, rotation float64) string {
+	colors := strings.Split(bgHex, ",")
+	for i := range colors {
+		colors[i] = strings.TrimSpace(colors[i])
+	}
+	bg := ParseHexColor(AverageColor(colors...)).(color.RGBA)
+	h, s, l := RGBToHSL(bg)
+	if s < grayscaleSaturation {
+		s = 0
+	} else {
+		h += rotation
+		s = min(max(s, minAccentSaturation), maxAccentSaturation)
+	}
+
+	// Move away from the background's lightness until the accent stands out
+	step := 0.05
+	if l > 0.5 {
+		step = -step
+	}
+	accent := HSLToRGB(h, s, l)
+	for contrastRatio(accent, bg) < minAccentContrast {
+		next := min(max(l+step, 0), 1)
+		if next == l {
+			break
+		}
+		l = next
+		accent = HSLToRGB(h, s, l)
+	}
+	return fmt.Sprintf("%02x%02x%02x", accent.R, accent.G, accent.B)
+}
+
+// contrastRatio returns the WCAG contrast ratio of two colors, from 1 to 21.
+func contrastRatio(a, b color.RGBA) float64 {
+	la, lb := linearLuminance(a), linearLuminance(b)
+	return (max(la, lb) + 0.05) / (min(la, lb) + 0.05)
+}
+
+// linearLuminance returns the WCAG relative luminance of a color, from its
+// linearized sRGB components.
+func linearLuminance(c color.RGBA) float64 {
+	linear := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.04045 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
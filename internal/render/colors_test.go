@@ -0,0 +1,120 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestResolveColor(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect string
+		ok     bool
+	}{
+		{"ff5722", "ff5722", true},
+		{"#FFF", "FFF", true},
+		{"orange", "ffa500", true},
+		{"RebeccaPurple", "663399", true},
+		{"transparent", "", false},
+		{"12345", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := ResolveColor(tt.input)
+			if ok != tt.ok || got != tt.expect {
+				t.Fatalf("expected (%q, %t) got (%q, %t)", tt.expect, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestAverageColor(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  []string
+		expect string
+	}{
+		{"two colors", []string{"ff0000", "0000ff"}, "7f007f"},
+		{"short hex", []string{"fff", "000000"}, "7f7f7f"},
+		{"one color", []string{"123456"}, "123456"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AverageColor(tt.input...); got != tt.expect {
+				t.Fatalf("expected %s got %s", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestHSLRoundTrip(t *testing.T) {
+	tests := []struct {
+		hex     string
+		h, s, l float64
+	}{
+		{"ff0000", 0, 1, 0.5},
+		{"00ff00", 120, 1, 0.5},
+		{"0000ff", 240, 1, 0.5},
+		{"ffffff", 0, 0, 1},
+		{"808080", 0, 0, 0.502},
+		{"1e88e5", 208, 0.79, 0.51},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			c := ParseHexColor(tt.hex).(color.RGBA)
+			h, s, l := RGBToHSL(c)
+			if math.Abs(h-tt.h) > 1 || math.Abs(s-tt.s) > 0.01 || math.Abs(l-tt.l) > 0.01 {
+				t.Fatalf("expected hsl(%.0f, %.2f, %.2f) got hsl(%.0f, %.2f, %.2f)", tt.h, tt.s, tt.l, h, s, l)
+			}
+			if back := HSLToRGB(h, s, l); back != c {
+				t.Fatalf("expected %v back got %v", c, back)
+			}
+		})
+	}
+}
+
+func TestAccentColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		bg       string
+		rotation float64
+		wantHue  float64 // -1 for a gray accent
+	}{
+		{"complementary of blue", "1e88e5", ComplementaryRotation, 28},
+		{"analogous of blue", "1e88e5", AnalogousRotation, 238},
+		{"complementary of pale yellow", "fff3c4", ComplementaryRotation, 228},
+		{"complementary of dark red", "5a0a0a", ComplementaryRotation, 180},
+		{"gradient", "ff0000, ff8800", ComplementaryRotation, 196},
+		{"light gray", "cccccc", ComplementaryRotation, -1},
+		{"black", "000000", ComplementaryRotation, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AccentColor(tt.bg, tt.rotation)
+			accent := ParseHexColor(got).(color.RGBA)
+			bg := ParseHexColor(AverageColor(strings.Split(strings.ReplaceAll(tt.bg, " ", ""), ",")...)).(color.RGBA)
+			if ratio := contrastRatio(accent, bg); ratio < minAccentContrast {
+				t.Errorf("expected contrast of at least %d against %s, got %.2f for %s", minAccentContrast, tt.bg, ratio, got)
+			}
+			h, s, _ := RGBToHSL(accent)
+			if tt.wantHue < 0 {
+				if s > 0.01 {
+					t.Errorf("expected a gray accent for %s, got %s", tt.bg, got)
+				}
+				return
+			}
+			if diff := math.Abs(math.Mod(h-tt.wantHue+540, 360) - 180); diff > 4 {
+				t.Errorf("expected hue near %.0f, got %.0f (%s)", tt.wantHue, h, got)
+			}
+			if s < minAccentSaturation-0.02 {
+				t.Errorf("expected a saturated accent, got saturation %.2f (%s)", s, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package render
+
+import (
+	"image"
+	"image/color"
+)
+
+// maxYIQDelta is the largest possible yiqDelta of two colors.
+const maxYIQDelta = 35215.0
+
+// DiffResult is the perceptual difference between two images.
+type DiffResult struct {
+	// Score is the mean perceptual difference of all pixels, from 0 (identical)
+	// to 1 (every pixel as far apart as two colors get)
+	Score float64
+	// Changed counts the pixels whose difference exceeds the threshold
+	Changed int
+	// Image shows the first image faded to gray, with changed pixels in red
+	Image *image.RGBA
+}
+
+// DiffImages compares a and b pixel by pixel in YIQ color space, which weighs
+// brightness over hue the way the eye does. Pixels differ when their difference,
+// from 0 to 1, exceeds threshold. Images of different sizes are compared over
+// the larger bounds, where a pixel only one image covers counts as fully different.
+func DiffImages(a, b image.Image, threshold float64) DiffResult {
+	ab, bb := a.Bounds(), b.Bounds()
+	w, h := max(ab.Dx(), bb.Dx()), max(ab.Dy(), bb.Dy())
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	var total float64
+	changed := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pa, inA := pixelAt(a, x, y)
+			pb, inB := pixelAt(b, x, y)
+			delta := 1.0
+			if inA && inB {
+				delta = yiqDelta(pa, pb) / maxYIQDelta
+			}
+			total += delta
+			if delta > threshold {
+				changed++
+				out.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+				continue
+			}
+			// Fade the unchanged pixel toward white so changes stand out
+			gray := uint8(255 - (255-yiq(pa)[0])*0.1)
+			out.SetRGBA(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+
+	score := 0.0
+	if w > 0 && h > 0 {
+		score = total / float64(w*h)
+	}
+	return DiffResult{Score: score, Changed: changed, Image: out}
+}
+
+// pixelAt returns the pixel at x, y relative to the image's origin, blended onto
+// white, and whether the image covers that point.
+func pixelAt(img image.Image, x, y int) ([3]float64, bool) {
+	bounds := img.Bounds()
+	if x >= bounds.Dx() || y >= bounds.Dy() {
+		return [3]float64{255, 255, 255}, false
+	}
+	r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+	// RGBA is alpha-premultiplied, so adding the uncovered share of white blends it
+	white := float64(0xffff - a)
+	return [3]float64{
+		(float64(r) + white) / 257,
+		(float64(g) + white) / 257,
+		(float64(b) + white) / 257,
+	}, true
+}
+
+// yiq converts an 8-bit RGB color to YIQ.
+func yiq(c [3]float64) [3]float64 {
+	return [3]float64{
+		c[0]*0.29889531 + c[1]*0.58662247 + c[2]*0.11448223,
+		c[0]*0.59597799 - c[1]*0.27417610 - c[2]*0.32180189,
+		c[0]*0.21147017 - c[1]*0.52261711 + c[2]*0.31114694,
+	}
+}
+
+// yiqDelta returns the weighted squared YIQ distance of two colors.
+func yiqDelta(a, b [3]float64) float64 {
+	ya, yb := yiq(a), yiq(b)
+	dy, di, dq := ya[0]-yb[0], ya[1]-yb[1], ya[2]-yb[2]
+	return 0.5053*dy*dy + 0.299*di*di + 0.1957*dq*dq
+}
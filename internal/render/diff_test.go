@@ -0,0 +1,64 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func solidImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDiffImages(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	black := color.RGBA{0, 0, 0, 255}
+
+	tests := []struct {
+		name     string
+		a, b     image.Image
+		minScore float64
+		maxScore float64
+		changed  int
+	}{
+		{"identical", solidImage(10, 10, white), solidImage(10, 10, white), 0, 0, 0},
+		{"black and white", solidImage(10, 10, white), solidImage(10, 10, black), 0.9, 1, 100},
+		{"slight shade", solidImage(10, 10, white), solidImage(10, 10, color.RGBA{250, 250, 250, 255}), 0, 0.01, 0},
+		{"different sizes", solidImage(10, 10, white), solidImage(10, 5, white), 0.5, 0.5, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := DiffImages(tt.a, tt.b, 0.1)
+			if result.Score < tt.minScore || result.Score > tt.maxScore {
+				t.Errorf("expected score in [%v, %v] got %v", tt.minScore, tt.maxScore, result.Score)
+			}
+			if result.Changed != tt.changed {
+				t.Errorf("expected %d changed pixels got %d", tt.changed, result.Changed)
+			}
+			if b := result.Image.Bounds(); b.Dx() != 10 || b.Dy() != 10 {
+				t.Errorf("expected a 10x10 diff image got %v", b)
+			}
+		})
+	}
+}
+
+func TestDiffImagesMarksChanges(t *testing.T) {
+	a := solidImage(4, 4, color.RGBA{255, 255, 255, 255})
+	b := solidImage(4, 4, color.RGBA{255, 255, 255, 255})
+	b.Set(1, 2, color.RGBA{0, 0, 0, 255})
+
+	result := DiffImages(a, b, 0.1)
+	if got := result.Image.RGBAAt(1, 2); got != (color.RGBA{R: 255, A: 255}) {
+		t.Fatalf("expected the changed pixel in red, got %v", got)
+	}
+	if got := result.Image.RGBAAt(0, 0); got.R != got.G || got.R < 200 {
+		t.Fatalf("expected unchanged pixels in faded gray, got %v", got)
+	}
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+
+	"github.com/fogleman/gg"
+)
+
+// discordColors are the backgrounds of Discord's default avatars.
+var discordColors = []string{"5865f2", "757e8a", "3ba55c", "faa61a", "ed4245", "eb459e"}
+
+// DiscordColor picks a default-avatar background for seed, the same one every time.
+func DiscordColor(seed string) string {
+	hash := md5.Sum([]byte(seed))
+	return discordColors[int(hash[0])%len(discordColors)]
+}
+
+// Glyph proportions, as fractions of the avatar size. The glyph is a rounded face
+// with controller-like grips below and two eyes cut out in the background color.
+const (
+	discordFaceX, discordFaceY = 0.22, 0.34
+	discordFaceW, discordFaceH = 0.56, 0.34
+	discordFaceR               = 0.14
+	discordGripX, discordGripY = 0.31, 0.66 // Left grip; the right one is mirrored
+	discordGripR               = 0.09
+	discordEyeX, discordEyeY   = 0.40, 0.50 // Left eye; the right one is mirrored
+	discordEyeRX, discordEyeRY = 0.055, 0.065
+)
+
+// DrawDiscordAvatar renders a Discord-style default avatar: a fgHex glyph on a flat
+// bgHex background, optionally clipped to a circle.
+func DrawDiscordAvatar(ctx context.Context, size int, bgHex, fgHex string, rounded bool, format ImageFormat) ([]byte, error) {
+	s := float64(size)
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, size, size))
+		buf.WriteString("\n")
+		if rounded {
+			buf.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="#%s" />`, s/2, s/2, s/2, bgHex))
+		} else {
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, size, size, bgHex))
+		}
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<g fill="#%s">`, fgHex))
+		buf.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="%.1f" />`, s*discordFaceX, s*discordFaceY, s*discordFaceW, s*discordFaceH, s*discordFaceR))
+		for _, x := range []float64{discordGripX, 1 - discordGripX} {
+			buf.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%.1f" />`, s*x, s*discordGripY, s*discordGripR))
+		}
+		buf.WriteString("</g>\n")
+		buf.WriteString(fmt.Sprintf(`<g fill="#%s">`, bgHex))
+		for _, x := range []float64{discordEyeX, 1 - discordEyeX} {
+			buf.WriteString(fmt.Sprintf(`<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" />`, s*x, s*discordEyeY, s*discordEyeRX, s*discordEyeRY))
+		}
+		buf.WriteString("</g>\n")
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(size, size)
+	dc.SetColor(ParseHexColor(bgHex))
+	if rounded {
+		dc.DrawCircle(s/2, s/2, s/2)
+	} else {
+		dc.DrawRectangle(0, 0, s, s)
+	}
+	dc.Fill()
+
+	dc.SetColor(ParseHexColor(fgHex))
+	dc.DrawRoundedRectangle(s*discordFaceX, s*discordFaceY, s*discordFaceW, s*discordFaceH, s*discordFaceR)
+	dc.Fill()
+	for _, x := range []float64{discordGripX, 1 - discordGripX} {
+		dc.DrawCircle(s*x, s*discordGripY, s*discordGripR)
+		dc.Fill()
+	}
+	dc.SetColor(ParseHexColor(bgHex))
+	for _, x := range []float64{discordEyeX, 1 - discordEyeX} {
+		dc.DrawEllipse(s*x, s*discordEyeY, s*discordEyeRX, s*discordEyeRY)
+		dc.Fill()
+	}
+	return encodeImage(ctx, dc.Image(), format)
+}
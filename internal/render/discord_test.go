@@ -0,0 +1,52 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestDiscordColorIsStable(t *testing.T) {
+	if DiscordColor("Jane Doe") != DiscordColor("Jane Doe") {
+		t.Fatal("expected the same name to get the same color")
+	}
+	seen := make(map[string]bool)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		seen[DiscordColor(name)] = true
+	}
+	if len(seen) < 2 {
+		t.Fatal("expected names to spread across the default colors")
+	}
+}
+
+func TestDrawDiscordAvatar(t *testing.T) {
+	data, err := DrawDiscordAvatar(context.Background(), 100, "5865f2", "ffffff", true, FormatPNG)
+	if err != nil {
+		t.Fatalf("DrawDiscordAvatar: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		x, y   int
+		expect color.RGBA
+	}{
+		{"face", 50, 60, color.RGBA{255, 255, 255, 255}},
+		{"eye", 40, 50, color.RGBA{0x58, 0x65, 0xf2, 255}},
+		{"background", 50, 15, color.RGBA{0x58, 0x65, 0xf2, 255}},
+		{"outside the circle", 1, 1, color.RGBA{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)); got != tt.expect {
+				t.Fatalf("expected %v got %v", tt.expect, got)
+			}
+		})
+	}
+}
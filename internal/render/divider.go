@@ -0,0 +1,164 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/color"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// DividerStyle selects the edge shape of a section divider.
+type DividerStyle string
+
+const (
+	DividerWave DividerStyle = "wave"
+	DividerBlob DividerStyle = "blob"
+	DividerTilt DividerStyle = "tilt"
+)
+
+// DividerStyles lists the supported divider styles.
+func DividerStyles() []DividerStyle {
+	return []DividerStyle{DividerWave, DividerBlob, DividerTilt}
+}
+
+// IsValid reports whether d is a supported divider style.
+func (d DividerStyle) IsValid() bool {
+	for _, known := range DividerStyles() {
+		if d == known {
+			return true
+		}
+	}
+	return false
+}
+
+// dividerLayer is a closed path made of a start point and cubic Bézier segments
+// along the top edge, closed along the bottom of the canvas.
+type dividerLayer struct {
+	start    [2]float64
+	segments [][3][2]float64 // control point 1, control point 2, end point
+	opacity  float64
+}
+
+// smoothEdge returns cubic segments through points using Catmull-Rom tangents.
+func smoothEdge(points [][2]float64) [][3][2]float64 {
+	segments := make([][3][2]float64, 0, len(points)-1)
+	for i := 0; i+1 < len(points); i++ {
+		p0 := points[max(0, i-1)]
+		p1, p2 := points[i], points[i+1]
+		p3 := points[min(len(points)-1, i+2)]
+		c1 := [2]float64{p1[0] + (p2[0]-p0[0])/6, p1[1] + (p2[1]-p0[1])/6}
+		c2 := [2]float64{p2[0] - (p3[0]-p1[0])/6, p2[1] - (p3[1]-p1[1])/6}
+		segments = append(segments, [3][2]float64{c1, c2, p2})
+	}
+	return segments
+}
+
+// waveEdge returns evenly spaced seeded points across the width between minY and maxY.
+func waveEdge(rng *rand.Rand, w, minY, maxY float64, count int) [][2]float64 {
+	points := make([][2]float64, count+1)
+	for i := range points {
+		points[i] = [2]float64{w * float64(i) / float64(count), minY + rng.Float64()*(maxY-minY)}
+	}
+	return points
+}
+
+// dividerLayers builds the layers for style on a w×h canvas.
+func dividerLayers(style DividerStyle, w, h float64, seed uint64) []dividerLayer {
+	rng := seededRand(seed)
+
+	switch style {
+	case DividerTilt:
+		// A straight edge; the seed picks which side is higher
+		high, low := h*0.1, h*0.9
+		if rng.IntN(2) == 0 {
+			high, low = low, high
+		}
+		start := [2]float64{0, high}
+		end := [2]float64{w, low}
+		return []dividerLayer{{start: start, segments: [][3][2]float64{{start, end, end}}, opacity: 1}}
+	case DividerBlob:
+		// Three stacked organic edges fading in toward the front
+		layers := make([]dividerLayer, 0, 3)
+		for i, opacity := range []float64{0.3, 0.6, 1} {
+			top := h * (0.05 + 0.2*float64(i))
+			points := waveEdge(rng, w, top, top+h*0.45, 3+rng.IntN(3))
+			layers = append(layers, dividerLayer{start: points[0], segments: smoothEdge(points), opacity: opacity})
+		}
+		return layers
+	default:
+		points := waveEdge(rng, w, h*0.2, h*0.8, 4+rng.IntN(3))
+		return []dividerLayer{{start: points[0], segments: smoothEdge(points), opacity: 1}}
+	}
+}
+
+// svgPath returns the SVG path data for a layer, closed along the bottom edge.
+func (l dividerLayer) svgPath(w, h float64) string {
+	var d strings.Builder
+	d.WriteString(fmt.Sprintf("M0,%.1f L%.1f,%.1f", h, l.start[0], l.start[1]))
+	for _, s := range l.segments {
+		d.WriteString(fmt.Sprintf(" C%.1f,%.1f %.1f,%.1f %.1f,%.1f", s[0][0], s[0][1], s[1][0], s[1][1], s[2][0], s[2][1]))
+	}
+	d.WriteString(fmt.Sprintf(" L%.1f,%.1f Z", w, h))
+	return d.String()
+}
+
+// DrawDivider renders a section divider: the area below a wave, blob, or tilted edge
+// is filled with fillHex. Flip mirrors it vertically to sit at the top of a section.
+// An empty bgHex leaves the rest transparent.
+func (r *Renderer) DrawDivider(ctx context.Context, w, h int, style DividerStyle, seed uint64, fillHex, bgHex string, flip bool, format ImageFormat) ([]byte, error) {
+	layers := dividerLayers(style, float64(w), float64(h), seed)
+
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" preserveAspectRatio="none">`, w, h, w, h))
+		buf.WriteString("\n")
+		if bgHex != "" {
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, w, h, bgHex))
+			buf.WriteString("\n")
+		}
+		if flip {
+			buf.WriteString(fmt.Sprintf(`<g transform="translate(0,%d) scale(1,-1)">`, h))
+			buf.WriteString("\n")
+		}
+		for _, l := range layers {
+			opacity := ""
+			if l.opacity < 1 {
+				opacity = fmt.Sprintf(` fill-opacity="%.2f"`, l.opacity)
+			}
+			buf.WriteString(fmt.Sprintf(`<path d="%s" fill="#%s"%s />`, l.svgPath(float64(w), float64(h)), fillHex, opacity))
+			buf.WriteString("\n")
+		}
+		if flip {
+			buf.WriteString("</g>\n")
+		}
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(w, h)
+	if bgHex != "" {
+		dc.SetColor(ParseHexColor(bgHex))
+		dc.Clear()
+	}
+	if flip {
+		dc.Translate(0, float64(h))
+		dc.Scale(1, -1)
+	}
+	fill := ParseHexColor(fillHex).(color.RGBA)
+	for _, l := range layers {
+		dc.SetColor(color.NRGBA{R: fill.R, G: fill.G, B: fill.B, A: uint8(l.opacity * 255)})
+		dc.MoveTo(0, float64(h))
+		dc.LineTo(l.start[0], l.start[1])
+		for _, s := range l.segments {
+			dc.CubicTo(s[0][0], s[0][1], s[1][0], s[1][1], s[2][0], s[2][1])
+		}
+		dc.LineTo(float64(w), float64(h))
+		dc.ClosePath()
+		dc.Fill()
+	}
+	return encodeImage(ctx, dc.Image(), format)
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestDrawDividerSVG(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	tests := []struct {
+		style  DividerStyle
+		paths  int
+		curved bool
+	}{
+		{DividerWave, 1, true},
+		{DividerBlob, 3, true},
+		{DividerTilt, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.style), func(t *testing.T) {
+			data, err := r.DrawDivider(context.Background(), 1440, 120, tt.style, 42, "2c3e50", "", false, FormatSVG)
+			if err != nil {
+				t.Fatalf("failed to draw divider: %v", err)
+			}
+			svgStr := string(data)
+			if got := strings.Count(svgStr, "<path"); got != tt.paths {
+				t.Fatalf("expected %d paths, got %d", tt.paths, got)
+			}
+			if !strings.Contains(svgStr, `fill="#2c3e50"`) {
+				t.Fatalf("expected fill color, got: %s", svgStr)
+			}
+			again, _ := r.DrawDivider(context.Background(), 1440, 120, tt.style, 42, "2c3e50", "", false, FormatSVG)
+			if !bytes.Equal(data, again) {
+				t.Fatal("expected the same seed to produce the same divider")
+			}
+		})
+	}
+}
+
+func TestDrawDividerFlip(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	data, err := r.DrawDivider(context.Background(), 200, 50, DividerTilt, 1, "000000", "", true, FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw divider: %v", err)
+	}
+	if !strings.Contains(string(data), `transform="translate(0,50) scale(1,-1)"`) {
+		t.Fatalf("expected flipped group, got: %s", data)
+	}
+
+	// Raster: the fill sits at the bottom normally and at the top when flipped
+	for _, flip := range []bool{false, true} {
+		data, err := r.DrawDivider(context.Background(), 200, 50, DividerWave, 1, "000000", "", flip, FormatPNG)
+		if err != nil {
+			t.Fatalf("failed to draw divider: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		filledY, emptyY := 49, 0
+		if flip {
+			filledY, emptyY = 0, 49
+		}
+		if _, _, _, a := img.At(100, filledY).RGBA(); a == 0 {
+			t.Errorf("flip=%t: expected filled pixel at y=%d", flip, filledY)
+		}
+		if _, _, _, a := img.At(100, emptyY).RGBA(); a != 0 {
+			t.Errorf("flip=%t: expected transparent pixel at y=%d", flip, emptyY)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package render
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// Effect is a finish over an image: shapes scattered over it, for celebratory
+// avatars and fun placeholders, or a filter that post-processes it.
+type Effect string
+
+const (
+	// EffectConfetti scatters colored paper strips and dots
+	EffectConfetti Effect = "confetti"
+	// EffectSparkle scatters four-pointed stars
+	EffectSparkle Effect = "sparkle"
+	// EffectVignette darkens the image toward its edges, like a lens
+	EffectVignette Effect = "vignette"
+	// EffectHalftone redraws the image as newspaper-style ink dots
+	EffectHalftone Effect = "halftone"
+	// EffectDither reduces the image to black and white pixels
+	EffectDither Effect = "dither"
+)
+
+// Effects lists the supported effects.
+func Effects() []Effect {
+	return []Effect{EffectConfetti, EffectSparkle, EffectVignette, EffectHalftone, EffectDither}
+}
+
+// IsValid reports whether e is a supported effect.
+func (e Effect) IsValid() bool {
+	return slices.Contains(Effects(), e)
+}
+
+// confettiColors are the colors confetti is cut from.
+var confettiColors = []string{"e63946", "f4a261", "ffb703", "2a9d8f", "457b9d", "8338ec", "ff006e"}
+
+// Sparkles are gold on light backgrounds, and white and pale gold on dark ones.
+var (
+	sparkleColorsLight = []string{"f4b400", "e09f3e", "ffc107"}
+	sparkleColorsDark  = []string{"ffffff", "fff3b0", "ffd700"}
+)
+
+const (
+	// Pieces per pixel of area, and bounds on the count for small and large images
+	confettiDensity = 1.0 / 1200
+	minConfetti     = 12
+	maxConfetti     = 150
+	sparkleDensity  = 1.0 / 5000
+	minSparkles     = 4
+	maxSparkles     = 40
+	// effectPlacementTries is how many spots a piece tries before it's left out
+	effectPlacementTries = 10
+)
+
+type effectKey struct{}
+
+type effectOptions struct {
+	effect Effect
+	seed   uint64
+}
+
+// WithEffect returns a context whose scenes are drawn with effect. The same seed
+// always scatters the same pieces; filters don't use it.
+func WithEffect(ctx context.Context, effect Effect, seed uint64) context.Context {
+	return context.WithValue(ctx, effectKey{}, effectOptions{effect: effect, seed: seed})
+}
+
+// IsFilter reports whether e post-processes the whole image rather than
+// scattering shapes over it.
+func (e Effect) IsFilter() bool {
+	return e == EffectVignette || e == EffectHalftone || e == EffectDither
+}
+
+// IsRasterOnly reports whether e has no vector form and can't be drawn in SVG.
+func (e Effect) IsRasterOnly() bool {
+	return e == EffectHalftone || e == EffectDither
+}
+
+// applyEffect returns scene with ctx's effect, if any, added: scattered pieces
+// as overlay shapes, and a filter for each backend to apply its own way.
+func applyEffect(ctx context.Context, scene Scene) Scene {
+	opts, ok := ctx.Value(effectKey{}).(effectOptions)
+	switch {
+	case !ok || opts.effect == "":
+	case opts.effect.IsFilter():
+		scene.Filter = opts.effect
+	default:
+		scene.Overlay = append(slices.Clone(scene.Overlay), effectShapes(opts.effect, opts.seed, scene)...)
+	}
+	return scene
+}
+
+// effectShapes scatters the pieces of effect over scene. Pieces stay inside a
+// circular background, so rounded avatars keep their outline.
+func effectShapes(effect Effect, seed uint64, scene Scene) []Shape {
+	rng := seededRand(seed)
+	w, h := float64(scene.Width), float64(scene.Height)
+	minDim := min(w, h)
+
+	// place picks a center for a piece of radius size, or reports false
+	place := func(size float64) (float64, float64, bool) {
+		for range effectPlacementTries {
+			x, y := size+rng.Float64()*(w-2*size), size+rng.Float64()*(h-2*size)
+			if bg := scene.Background; bg.Circle && math.Hypot(x-w/2, y-h/2)+size > bg.Radius {
+				continue
+			}
+			return x, y, true
+		}
+		return 0, 0, false
+	}
+
+	var shapes []Shape
+	switch effect {
+	case EffectConfetti:
+		count := max(minConfetti, min(int(w*h*confettiDensity), maxConfetti))
+		for range count {
+			size := max(minDim*(0.04+0.03*rng.Float64()), 3)
+			color := confettiColors[rng.IntN(len(confettiColors))]
+			x, y, ok := place(size / 2)
+			if !ok {
+				continue
+			}
+			if rng.IntN(3) == 0 {
+				d := size * 0.6
+				shapes = append(shapes, Shape{X: x - d/2, Y: y - d/2, Width: d, Height: d, Circle: true, Color: color})
+				continue
+			}
+			// Strips are twice as long as they are wide, tumbling at any angle
+			shapes = append(shapes, Shape{Points: rotatedRect(x, y, size, size/2, rng.Float64()*math.Pi), Color: color})
+		}
+	case EffectSparkle:
+		colors := sparkleColorsLight
+		if scene.Background.Color != "" && GetContrastColor(scene.Background.Color) == "ffffff" {
+			colors = sparkleColorsDark
+		}
+		count := max(minSparkles, min(int(w*h*sparkleDensity), maxSparkles))
+		for range count {
+			radius := max(minDim*(0.03+0.05*rng.Float64()), 3)
+			x, y, ok := place(radius)
+			if !ok {
+				continue
+			}
+			shapes = append(shapes, Shape{Points: sparklePoints(rng, x, y, radius), Color: colors[rng.IntN(len(colors))]})
+		}
+	}
+	return shapes
+}
+
+// rotatedRect returns the corners of a length x width rectangle centered on
+// (x, y), turned by angle radians.
+func rotatedRect(x, y, length, width, angle float64) []Point {
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	corners := [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	points := make([]Point, len(corners))
+	for i, c := range corners {
+		dx, dy := c[0]*length/2, c[1]*width/2
+		points[i] = Point{X: x + dx*cos - dy*sin, Y: y + dx*sin + dy*cos}
+	}
+	return points
+}
+
+// sparklePoints returns a four-pointed star of radius centered on (x, y), with a
+// slight random tilt.
+func sparklePoints(rng *rand.Rand, x, y, radius float64) []Point {
+	tilt := (rng.Float64() - 0.5) * math.Pi / 6
+	points := make([]Point, 8)
+	for i := range points {
+		r := radius
+		if i%2 == 1 {
+			// The waist between points
+			r = radius * 0.25
+		}
+		angle := tilt + float64(i)*math.Pi/4 - math.Pi/2
+		points[i] = Point{X: x + r*math.Cos(angle), Y: y + r*math.Sin(angle)}
+	}
+	return points
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEffectShapes(t *testing.T) {
+	square := Scene{Width: 200, Height: 200, Background: Background{Color: "ffffff"}}
+	circle := Scene{Width: 200, Height: 200, Background: Background{Color: "1e1e2e", Circle: true, Radius: 100}}
+
+	tests := []struct {
+		name     string
+		effect   Effect
+		scene    Scene
+		min, max int
+		colors   []string
+	}{
+		{"confetti", EffectConfetti, square, minConfetti, maxConfetti, confettiColors},
+		{"sparkle on light", EffectSparkle, square, minSparkles, maxSparkles, sparkleColorsLight},
+		{"sparkle on dark circle", EffectSparkle, circle, 1, maxSparkles, sparkleColorsDark},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := effectShapes(tt.effect, 7, tt.scene)
+			if len(shapes) < tt.min || len(shapes) > tt.max {
+				t.Fatalf("expected %d to %d shapes, got %d", tt.min, tt.max, len(shapes))
+			}
+			if again := effectShapes(tt.effect, 7, tt.scene); !reflect.DeepEqual(shapes, again) {
+				t.Error("expected the same seed to scatter the same shapes")
+			}
+			if other := effectShapes(tt.effect, 8, tt.scene); reflect.DeepEqual(shapes, other) {
+				t.Error("expected another seed to scatter other shapes")
+			}
+			for _, shape := range shapes {
+				if !strings.Contains(strings.Join(tt.colors, " "), shape.Color) {
+					t.Errorf("unexpected color %s", shape.Color)
+				}
+				for _, p := range shape.Points {
+					if bg := tt.scene.Background; bg.Circle && math.Hypot(p.X-100, p.Y-100) > bg.Radius+0.001 {
+						t.Errorf("point %+v outside the circular background", p)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderSceneEffect(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	scene := labelScene(300, 150, "cccccc", "333333", "Yay", false, true, 40, false, nil)
+
+	plain, err := r.RenderScene(context.Background(), scene, FormatSVG)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if strings.Contains(string(plain), "<polygon") {
+		t.Error("expected no overlay without an effect")
+	}
+
+	svg, err := r.RenderScene(WithEffect(context.Background(), EffectSparkle, 1), scene, FormatSVG)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	// The overlay is drawn over the text
+	if text, overlay := strings.Index(string(svg), "Yay"), strings.Index(string(svg), "<polygon"); overlay < text {
+		t.Errorf("expected sparkles after the text in:\n%s", svg)
+	}
+	if _, err := r.RenderScene(WithEffect(context.Background(), EffectConfetti, 1), scene, FormatPNG); err != nil {
+		t.Fatalf("render png: %v", err)
+	}
+}
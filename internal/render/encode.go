@@ -0,0 +1,156 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/draw"
+
+	"grout/internal/config"
+	"grout/internal/render/jpegenc"
+)
+
+// EncodeOptions tune the raster encoders. Zero fields take the defaults in config.
+type EncodeOptions struct {
+	Quality   int    // JPEG and WebP quality, from 1 to 100
+	Subsample string // JPEG chroma subsampling: "420", or "444" to keep colored text sharp
+	Effort    string // PNG compression effort: "fast", "default", or "best"
+	// MaxBytes is the most bytes an encoded image may take; 0 means no limit.
+	// Images over it give up quality, then size, until they fit.
+	MaxBytes int
+}
+
+// ErrOverBudget is returned when an image can't be encoded within its MaxBytes.
+var ErrOverBudget = errors.New("image doesn't fit the byte budget")
+
+type encodeOptionsKey struct{}
+
+// WithEncodeOptions returns a context whose images are encoded with opts.
+func WithEncodeOptions(ctx context.Context, opts EncodeOptions) context.Context {
+	return context.WithValue(ctx, encodeOptionsKey{}, opts)
+}
+
+// encodeOptions returns the encode options of ctx, with defaults filled in.
+func encodeOptions(ctx context.Context) EncodeOptions {
+	opts, _ := ctx.Value(encodeOptionsKey{}).(EncodeOptions)
+	if opts.Quality == 0 {
+		opts.Quality = config.DefaultImageQuality
+	}
+	if opts.Subsample == "" {
+		opts.Subsample = config.DefaultJPEGSubsample
+	}
+	if opts.Effort == "" {
+		opts.Effort = config.DefaultPNGEffort
+	}
+	return opts
+}
+
+// pngCompression maps a PNG effort to the compression level that gives it.
+var pngCompression = map[string]png.CompressionLevel{
+	"fast":    png.BestSpeed,
+	"default": png.DefaultCompression,
+	"best":    png.BestCompression,
+}
+
+// encodeImage encodes a rasterized image in the specified format (PNG, JPEG, GIF,
+// WebP), with the encode options of ctx.
+func encodeImage(ctx context.Context, img image.Image, format ImageFormat) ([]byte, error) {
+	opts := encodeOptions(ctx)
+	data, err := encodeWith(img, format, opts)
+	if err != nil || opts.MaxBytes == 0 || len(data) <= opts.MaxBytes {
+		return data, err
+	}
+	return encodeWithinBudget(ctx, img, format, opts)
+}
+
+// encodeWithinBudget encodes img in at most opts.MaxBytes. At each size, JPEG and
+// WebP take the highest quality that fits, down to config.MinBudgetQuality, and
+// PNG is compressed as hard as it can be. When nothing fits, the image shrinks by
+// config.BudgetScaleStep and tries again, until its shorter side would drop
+// below config.MinBudgetDimension.
+func encodeWithinBudget(ctx context.Context, img image.Image, format ImageFormat, opts EncodeOptions) ([]byte, error) {
+	lossy := format == FormatJPG || format == FormatJPEG || format == FormatWebP
+	opts.Effort = "best"
+	bounds := img.Bounds()
+	for scale := 1.0; ; scale *= config.BudgetScaleStep {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		w, h := int(math.Round(float64(bounds.Dx())*scale)), int(math.Round(float64(bounds.Dy())*scale))
+		scaled := img
+		if scale < 1 {
+			if min(w, h) < config.MinBudgetDimension {
+				return nil, fmt.Errorf("%w: %d bytes", ErrOverBudget, opts.MaxBytes)
+			}
+			dst := image.NewRGBA(image.Rect(0, 0, w, h))
+			draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+			scaled = dst
+		}
+
+		lowest := opts
+		if lossy {
+			lowest.Quality = min(config.MinBudgetQuality, opts.Quality)
+		}
+		data, err := encodeWith(scaled, format, lowest)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > opts.MaxBytes {
+			continue
+		}
+		// The lowest quality fits, so search for the highest that does
+		for lo, hi := lowest.Quality, opts.Quality; lossy && lo < hi; {
+			try := opts
+			try.Quality = (lo + hi + 1) / 2
+			encoded, err := encodeWith(scaled, format, try)
+			if err != nil {
+				return nil, err
+			}
+			if len(encoded) <= opts.MaxBytes {
+				lo, data = try.Quality, encoded
+			} else {
+				hi = try.Quality - 1
+			}
+		}
+		return data, nil
+	}
+}
+
+// encodeWith encodes img in format with opts, ignoring opts.MaxBytes.
+func encodeWith(img image.Image, format ImageFormat, opts EncodeOptions) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatPNG:
+		encoder := png.Encoder{CompressionLevel: pngCompression[opts.Effort]}
+		if err := encoder.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+	case FormatJPG, FormatJPEG:
+		subsampling := jpegenc.Subsample420
+		if opts.Subsample == "444" {
+			subsampling = jpegenc.Subsample444
+		}
+		if err := jpegenc.Encode(&buf, img, &jpegenc.Options{Quality: opts.Quality, Subsampling: subsampling}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+	case FormatGIF:
+		if err := gif.Encode(&buf, img, nil); err != nil {
+			return nil, fmt.Errorf("encode gif: %w", err)
+		}
+	case FormatWebP:
+		if err := encodeWebP(&buf, img, opts.Quality); err != nil {
+			return nil, fmt.Errorf("encode webp: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s is not a raster format", ErrUnsupportedFormat, format)
+	}
+
+	return buf.Bytes(), nil
+}
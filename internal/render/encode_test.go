@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// stripes returns an image of 1px red columns on white, which chroma
+// subsampling smears into pink.
+func stripes(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{255, 255, 255, 255}
+			if x%2 == 0 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeImageOptions(t *testing.T) {
+	img := stripes(64, 64)
+	encode := func(opts EncodeOptions, format ImageFormat) []byte {
+		data, err := encodeImage(WithEncodeOptions(context.Background(), opts), img, format)
+		if err != nil {
+			t.Fatalf("encode %s: %v", format, err)
+		}
+		return data
+	}
+
+	defaults := encode(EncodeOptions{}, FormatJPEG)
+	if !bytes.Equal(defaults, encode(EncodeOptions{Quality: 90, Subsample: "420"}, FormatJPEG)) {
+		t.Errorf("expected empty options to take the defaults")
+	}
+	if low := encode(EncodeOptions{Quality: 20}, FormatJPEG); len(low) >= len(defaults) {
+		t.Errorf("expected quality 20 to be smaller than the default, got %d >= %d bytes", len(low), len(defaults))
+	}
+
+	// Without subsampling, a red column stays red instead of blending with its neighbours
+	for _, tt := range []struct {
+		subsample string
+		red       bool
+	}{{"420", false}, {"444", true}} {
+		decoded, err := jpeg.Decode(bytes.NewReader(encode(EncodeOptions{Subsample: tt.subsample}, FormatJPEG)))
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		r, g, _, _ := decoded.At(32, 32).RGBA()
+		if red := r>>8 > 200 && g>>8 < 80; red != tt.red {
+			t.Errorf("subsample %s: expected red %t, got rgb %d,%d", tt.subsample, tt.red, r>>8, g>>8)
+		}
+	}
+
+	fast, best := encode(EncodeOptions{Effort: "fast"}, FormatPNG), encode(EncodeOptions{Effort: "best"}, FormatPNG)
+	if len(best) > len(fast) {
+		t.Errorf("expected best effort to be no larger than fast, got %d > %d bytes", len(best), len(fast))
+	}
+}
+
+func TestEncodeWithinBudget(t *testing.T) {
+	img := stripes(400, 400)
+	encode := func(opts EncodeOptions, format ImageFormat) ([]byte, error) {
+		return encodeImage(WithEncodeOptions(context.Background(), opts), img, format)
+	}
+
+	full, err := encode(EncodeOptions{}, FormatJPEG)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	for _, format := range []ImageFormat{FormatJPEG, FormatWebP, FormatPNG, FormatGIF} {
+		t.Run(string(format), func(t *testing.T) {
+			data, err := encode(EncodeOptions{MaxBytes: 2000}, format)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if len(data) > 2000 {
+				t.Fatalf("expected at most 2000 bytes, got %d", len(data))
+			}
+		})
+	}
+
+	// Lowering the quality is enough here, so the size is kept
+	data, err := encode(EncodeOptions{MaxBytes: len(full) * 3 / 4}, FormatJPEG)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	decoded, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.Bounds().Dx() != 400 {
+		t.Errorf("expected the size to be kept, got width %d", decoded.Bounds().Dx())
+	}
+
+	if _, err := encode(EncodeOptions{MaxBytes: 10}, FormatPNG); !errors.Is(err, ErrOverBudget) {
+		t.Errorf("expected ErrOverBudget, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+)
+
+const (
+	// vignetteStrength is how much a vignette darkens the farthest pixels
+	vignetteStrength = 0.35
+	// halftoneCells is how many halftone dots fit across the shorter side of an
+	// image, and halftoneMinCell the smallest dot spacing, in pixels
+	halftoneCells   = 60
+	halftoneMinCell = 3
+	// halftoneAngle is the screen angle of halftone dots, as in newspaper print
+	halftoneAngle = math.Pi / 4
+)
+
+// applyFilter post-processes a drawn scene in place with the scene's filter, if
+// any. Every filter leaves transparent pixels transparent, so a rounded avatar
+// keeps its outline.
+func applyFilter(img *image.RGBA, scene Scene) {
+	switch scene.Filter {
+	case EffectVignette:
+		applyVignette(img, vignetteRadius(scene))
+	case EffectHalftone:
+		applyHalftone(img)
+	case EffectDither:
+		applyDither(img)
+	}
+}
+
+// vignetteShade returns how much a vignette keeps of a pixel's brightness at
+// dist from the center, where the farthest pixel is maxDist away: all of it at
+// the center, falling off with the square of the distance.
+func vignetteShade(dist, maxDist float64) float64 {
+	return 1 - vignetteStrength*math.Pow(dist/maxDist, 2)
+}
+
+// vignetteRadius returns how far the edge of a scene's background is from its
+// center: the radius of a circular background, else the distance to a corner.
+func vignetteRadius(scene Scene) float64 {
+	if scene.Background.Circle {
+		return scene.Background.Radius
+	}
+	return math.Hypot(float64(scene.Width)/2, float64(scene.Height)/2)
+}
+
+// applyVignette darkens img toward its edges in place, as a filter after the
+// scene is drawn. Transparent pixels stay transparent.
+func applyVignette(img *image.RGBA, maxDist float64) {
+	bounds := img.Bounds()
+	cx, cy := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			// Pixels are sampled at their centers
+			shade := max(vignetteShade(math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy), maxDist), 0)
+			i := y*img.Stride + x*4
+			img.Pix[i] = uint8(math.Round(float64(img.Pix[i]) * shade))
+			img.Pix[i+1] = uint8(math.Round(float64(img.Pix[i+1]) * shade))
+			img.Pix[i+2] = uint8(math.Round(float64(img.Pix[i+2]) * shade))
+		}
+	}
+}
+
+// vignetteStops is the number of gradient stops an SVG vignette approximates the
+// falloff with.
+const vignetteStops = 5
+
+// writeSVGVignette writes a scene's vignette as a radial gradient from clear to
+// black, over a shape matching the background, so it darkens what's below it
+// like the raster filter does.
+func writeSVGVignette(buf *bytes.Buffer, scene Scene) {
+	w, h := float64(scene.Width), float64(scene.Height)
+	radius := vignetteRadius(scene)
+	gradientID := fmt.Sprintf("vignette_%s", svgNumber(radius))
+	buf.WriteString(fmt.Sprintf(`<defs><radialGradient id="%s" gradientUnits="userSpaceOnUse" cx="%s" cy="%s" r="%s">`,
+		gradientID, svgNumber(w/2), svgNumber(h/2), svgNumber(radius)))
+	for i := range vignetteStops {
+		t := float64(i) / (vignetteStops - 1)
+		opacity := 1 - vignetteShade(t, 1)
+		buf.WriteString(fmt.Sprintf(`<stop offset="%s" stop-color="#000000" stop-opacity="%s" />`,
+			strconv.FormatFloat(t, 'f', -1, 64), strconv.FormatFloat(math.Round(opacity*1000)/1000, 'f', -1, 64)))
+	}
+	buf.WriteString(`</radialGradient></defs>`)
+	buf.WriteString("\n")
+	if scene.Background.Circle {
+		buf.WriteString(fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" fill="url(#%s)" />`, svgNumber(w/2), svgNumber(h/2), svgNumber(radius), gradientID))
+	} else {
+		buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="url(#%s)" />`, scene.Width, scene.Height, gradientID))
+	}
+	buf.WriteString("\n")
+}
+
+// pixelLuminance returns the relative luminance (0–1) and alpha (0–255) of the
+// pixel at offset i of img, undoing the premultiplied alpha.
+func pixelLuminance(img *image.RGBA, i int) (float64, uint8) {
+	a := img.Pix[i+3]
+	if a == 0 {
+		return 1, 0
+	}
+	alpha := float64(a)
+	return relativeLuminance(float64(img.Pix[i])/alpha, float64(img.Pix[i+1])/alpha, float64(img.Pix[i+2])/alpha), a
+}
+
+// setGray sets the pixel at offset i of img to gray (0–255) at alpha a.
+func setGray(img *image.RGBA, i int, gray float64, a uint8) {
+	v := uint8(math.Round(gray * float64(a) / 255))
+	img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = v, v, v, a
+}
+
+// applyHalftone redraws img in place as black ink dots on white paper, on a
+// screen turned by halftoneAngle. Each dot's area matches how dark the image is
+// around it, so the dots merge in the darkest areas.
+func applyHalftone(img *image.RGBA) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	cell := max(float64(min(w, h))/halftoneCells, halftoneMinCell)
+	cos, sin := math.Cos(halftoneAngle), math.Sin(halftoneAngle)
+
+	// Darkness is sampled once per dot, on a 3x3 grid over its cell
+	lum := make([]float64, w*h)
+	for y := range h {
+		for x := range w {
+			lum[y*w+x], _ = pixelLuminance(img, y*img.Stride+x*4)
+		}
+	}
+	darkness := make(map[[2]int]float64)
+	dotDarkness := func(cu, cv int, cx, cy float64) float64 {
+		if d, ok := darkness[[2]int{cu, cv}]; ok {
+			return d
+		}
+		var sum float64
+		for _, dy := range []float64{-1, 0, 1} {
+			for _, dx := range []float64{-1, 0, 1} {
+				x := min(max(int(cx+dx*cell/3), 0), w-1)
+				y := min(max(int(cy+dy*cell/3), 0), h-1)
+				sum += 1 - lum[y*w+x]
+			}
+		}
+		d := sum / 9
+		darkness[[2]int{cu, cv}] = d
+		return d
+	}
+
+	for y := range h {
+		for x := range w {
+			i := y*img.Stride + x*4
+			a := img.Pix[i+3]
+			if a == 0 {
+				continue
+			}
+			// Find the nearest dot center in screen space, and map it back
+			px, py := float64(x)+0.5, float64(y)+0.5
+			u, v := (px*cos+py*sin)/cell, (-px*sin+py*cos)/cell
+			cu, cv := math.Floor(u), math.Floor(v)
+			cx := ((cu+0.5)*cos - (cv+0.5)*sin) * cell
+			cy := ((cu+0.5)*sin + (cv+0.5)*cos) * cell
+
+			// A dot covering the whole cell reaches its corners
+			radius := cell / math.Sqrt2 * math.Sqrt(dotDarkness(int(cu), int(cv), cx, cy))
+			coverage := min(max(radius-math.Hypot(px-cx, py-cy)+0.5, 0), 1)
+			setGray(img, i, 255*(1-coverage), a)
+		}
+	}
+}
+
+// applyDither reduces img in place to black and white pixels with Floyd–Steinberg
+// error diffusion, so areas of gray become patterns of dots, like a 1-bit screen.
+func applyDither(img *image.RGBA) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	// The error carried into this row and the next
+	current, next := make([]float64, w+2), make([]float64, w+2)
+	for y := range h {
+		for x := range w {
+			i := y*img.Stride + x*4
+			lum, a := pixelLuminance(img, i)
+			if a == 0 {
+				continue
+			}
+			want := lum + current[x+1]
+			out := 0.0
+			if want >= 0.5 {
+				out = 1
+			}
+			err := want - out
+			current[x+2] += err * 7 / 16
+			next[x] += err * 3 / 16
+			next[x+1] += err * 5 / 16
+			next[x+2] += err * 1 / 16
+			setGray(img, i, 255*out, a)
+		}
+		current, next = next, current
+		clear(next)
+	}
+}
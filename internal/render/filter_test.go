@@ -0,0 +1,122 @@
+package render
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestVignette(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	ctx := WithEffect(context.Background(), EffectVignette, 0)
+	scene := Scene{Width: 200, Height: 100, Background: Background{Color: "ffffff"}}
+
+	img, err := r.rasterizeScene(context.Background(), applyEffect(ctx, scene))
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	center, _, _, _ := img.At(100, 50).RGBA()
+	corner, _, _, _ := img.At(0, 0).RGBA()
+	if center>>8 != 0xff {
+		t.Errorf("expected the center untouched, got %d", center>>8)
+	}
+	// The corners are darkened by the full strength, like noise photos
+	if want := 255 * (1 - vignetteStrength); math.Abs(float64(corner>>8)-want) > 3 {
+		t.Errorf("expected a corner near %.0f, got %d", want, corner>>8)
+	}
+
+	tests := []struct {
+		name   string
+		bg     Background
+		expect string
+	}{
+		{"rectangle", Background{Color: "ffffff"}, `<rect width="200" height="100" fill="url(#vignette_111.8)" />`},
+		{"circle", Background{Color: "ffffff", Circle: true, Radius: 50}, `<circle cx="100" cy="50" r="50" fill="url(#vignette_50)" />`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg, err := r.RenderScene(ctx, Scene{Width: 200, Height: 100, Background: tt.bg}, FormatSVG)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if !strings.Contains(string(svg), tt.expect) || !strings.Contains(string(svg), `stop-opacity="0.35"`) {
+				t.Errorf("expected %q in:\n%s", tt.expect, svg)
+			}
+		})
+	}
+}
+
+func TestRasterOnlyFilters(t *testing.T) {
+	// A light-to-dark gradient inside a transparent circle
+	gradient := Scene{Width: 120, Height: 120, Background: Background{Color: "ffffff", GradientTo: "000000", Circle: true, Radius: 60}}
+
+	for _, effect := range []Effect{EffectHalftone, EffectDither} {
+		t.Run(string(effect), func(t *testing.T) {
+			r, err := New()
+			if err != nil {
+				t.Fatalf("renderer init: %v", err)
+			}
+			ctx := WithEffect(context.Background(), effect, 0)
+			raster, err := r.rasterizeScene(context.Background(), applyEffect(ctx, gradient))
+			if err != nil {
+				t.Fatalf("rasterize: %v", err)
+			}
+			img := raster.(*image.RGBA)
+
+			if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+				t.Errorf("expected the corner outside the circle to stay transparent, got alpha %d", a)
+			}
+			// Output is gray, and brighter on the light side than the dark side
+			var left, right float64
+			for y := 50; y < 70; y++ {
+				for x := 10; x < 110; x++ {
+					c := img.RGBAAt(x, y)
+					if c.R != c.G || c.G != c.B {
+						t.Fatalf("expected gray at (%d, %d), got %v", x, y, c)
+					}
+					if effect == EffectDither && c.R != 0 && c.R != 255 {
+						t.Fatalf("expected black or white at (%d, %d), got %v", x, y, c)
+					}
+					if x < 40 {
+						left += float64(c.R)
+					} else if x >= 80 {
+						right += float64(c.R)
+					}
+				}
+			}
+			if left <= right {
+				t.Errorf("expected the light side brighter, got %.0f and %.0f", left, right)
+			}
+
+			if _, err := r.RenderScene(ctx, gradient, FormatSVG); err == nil {
+				t.Error("expected an error for SVG")
+			}
+		})
+	}
+}
+
+func TestDitherKeepsBrightness(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 128, 128, 128, 255
+	}
+	applyDither(img)
+	white := 0
+	for y := range 64 {
+		for x := range 64 {
+			if img.RGBAAt(x, y) == (color.RGBA{255, 255, 255, 255}) {
+				white++
+			}
+		}
+	}
+	// Mid gray dithers to about half white pixels
+	if share := float64(white) / (64 * 64); math.Abs(share-0.5) > 0.05 {
+		t.Errorf("expected about half the pixels white, got %.2f", share)
+	}
+}
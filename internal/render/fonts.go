@@ -0,0 +1,95 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomedium"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/gofont/gosmallcaps"
+)
+
+// DefaultFont is the font name used when none is requested.
+const DefaultFont = "regular"
+
+// fontSpec pairs a parsed TrueType font with the SVG attributes that best describe it.
+type fontSpec struct {
+	ttf     *truetype.Font
+	family  string
+	weight  string
+	style   string
+	variant string
+}
+
+// embeddedFonts lists the Go font family variants bundled into the binary, keyed by public name.
+var embeddedFonts = []struct {
+	name    string
+	data    []byte
+	family  string
+	weight  string
+	style   string
+	variant string
+}{
+	{"regular", goregular.TTF, "sans-serif", "normal", "normal", "normal"},
+	{"bold", gobold.TTF, "sans-serif", "bold", "normal", "normal"},
+	{"italic", goitalic.TTF, "sans-serif", "normal", "italic", "normal"},
+	{"bold-italic", gobolditalic.TTF, "sans-serif", "bold", "italic", "normal"},
+	{"medium", gomedium.TTF, "sans-serif", "500", "normal", "normal"},
+	{"mono", gomono.TTF, "monospace", "normal", "normal", "normal"},
+	{"mono-bold", gomonobold.TTF, "monospace", "bold", "normal", "normal"},
+	{"smallcaps", gosmallcaps.TTF, "sans-serif", "normal", "normal", "small-caps"},
+}
+
+// embeddedFont returns the SVG attributes of the named embedded font, without
+// parsing it.
+func embeddedFont(name string) (fontSpec, bool) {
+	for _, f := range embeddedFonts {
+		if f.name == name {
+			return fontSpec{family: f.family, weight: f.weight, style: f.style, variant: f.variant}, true
+		}
+	}
+	return fontSpec{}, false
+}
+
+// loadFonts parses every embedded font.
+func loadFonts() (map[string]fontSpec, error) {
+	fonts := make(map[string]fontSpec, len(embeddedFonts))
+	for _, f := range embeddedFonts {
+		ttf, err := truetype.Parse(f.data)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s font: %w", f.name, err)
+		}
+		fonts[f.name] = fontSpec{ttf: ttf, family: f.family, weight: f.weight, style: f.style, variant: f.variant}
+	}
+	return fonts, nil
+}
+
+// HasFont reports whether name is a known embedded font.
+func (r *Renderer) HasFont(name string) bool {
+	_, ok := r.fonts[name]
+	return ok
+}
+
+// FontNames returns the names of all embedded fonts in sorted order.
+func (r *Renderer) FontNames() []string {
+	names := make([]string, 0, len(r.fonts))
+	for name := range r.fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// font returns the named font, falling back to the default font.
+func (r *Renderer) font(name string) fontSpec {
+	if f, ok := r.fonts[name]; ok {
+		return f
+	}
+	return r.fonts[DefaultFont]
+}
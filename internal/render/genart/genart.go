@@ -0,0 +1,312 @@
+// Package genart generates deterministic, seeded compositions of simple shapes
+// for use as decorative placeholder backgrounds.
+package genart
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"strconv"
+)
+
+// Variant selects a generative algorithm.
+type Variant string
+
+const (
+	VariantTriangles Variant = "triangles"
+	VariantVoronoi   Variant = "voronoi"
+	VariantWaves     Variant = "waves"
+	VariantBubbles   Variant = "bubbles"
+)
+
+// DefaultVariant is used when no variant is requested.
+const DefaultVariant = VariantTriangles
+
+// triangleCols is the number of columns of the triangles mesh, and
+// maxTriangleRows caps its rows, so a tall, thin canvas doesn't split into an
+// unbounded number of shapes.
+const (
+	triangleCols    = 8
+	maxTriangleRows = 64
+)
+
+// voronoiSites, waveBands, and bubbleCount are the number of cells, bands, and
+// circles of the other variants.
+const (
+	voronoiSites = 24
+	waveBands    = 5
+	bubbleCount  = 18
+)
+
+// Shape is a filled polygon, or a circle when Circle is true.
+type Shape struct {
+	Points  [][2]float64
+	Circle  bool
+	CX, CY  float64
+	R       float64
+	Fill    string
+	Opacity float64
+}
+
+// Variants lists the supported algorithms.
+func Variants() []Variant {
+	return []Variant{VariantTriangles, VariantVoronoi, VariantWaves, VariantBubbles}
+}
+
+// IsValid reports whether v is a supported variant.
+func (v Variant) IsValid() bool {
+	for _, known := range Variants() {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSeed turns a seed parameter into a number. Integers are used as-is and any
+// other string is hashed, so names work as seeds too.
+func ParseSeed(s string) uint64 {
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return n
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// newRand returns a deterministic random source for seed.
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// Palette derives n harmonious colors from seed by walking the hue wheel from a
+// seeded base hue.
+func Palette(seed uint64, n int) []string {
+	rng := newRand(seed)
+	base := rng.Float64() * 360
+	colors := make([]string, n)
+	for i := range colors {
+		hue := math.Mod(base+float64(i)*(120/float64(max(1, n-1))), 360)
+		lightness := 0.35 + 0.3*float64(i)/float64(max(1, n-1))
+		colors[i] = hslToHex(hue, 0.65, lightness)
+	}
+	return colors
+}
+
+// Generate produces the shapes of a w×h composition for variant, seeded by seed and
+// colored from palette (at least one color). It stops with ctx's error once ctx
+// is done.
+func Generate(ctx context.Context, variant Variant, w, h int, seed uint64, palette []string) ([]Shape, error) {
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("genart: empty palette")
+	}
+	rng := newRand(seed)
+	switch variant {
+	case VariantTriangles:
+		return triangles(ctx, rng, float64(w), float64(h), palette)
+	case VariantVoronoi:
+		return voronoi(ctx, rng, float64(w), float64(h), palette)
+	case VariantWaves:
+		return waves(rng, float64(w), float64(h), palette), nil
+	case VariantBubbles:
+		return bubbles(rng, float64(w), float64(h), palette), nil
+	default:
+		return nil, fmt.Errorf("genart: unknown variant %q", variant)
+	}
+}
+
+// ShapeCount returns the most shapes Generate produces for a w×h composition
+// of variant, or 0 for an unknown variant.
+func ShapeCount(variant Variant, w, h int) int {
+	switch variant {
+	case VariantTriangles:
+		cols, rows := triangleGrid(float64(w), float64(h))
+		return cols * rows * 2
+	case VariantVoronoi:
+		return voronoiSites
+	case VariantWaves:
+		return waveBands + 1
+	case VariantBubbles:
+		return bubbleCount + 1
+	}
+	return 0
+}
+
+// triangleGrid returns the columns and rows of the triangles mesh of a w×h
+// canvas, whose cells are roughly square.
+func triangleGrid(w, h float64) (cols, rows int) {
+	return triangleCols, min(maxTriangleRows, max(2, int(math.Round(triangleCols*h/w))))
+}
+
+// background returns a full-canvas rectangle in the first palette color.
+func background(w, h float64, palette []string) Shape {
+	return Shape{Points: [][2]float64{{0, 0}, {w, 0}, {w, h}, {0, h}}, Fill: palette[0], Opacity: 1}
+}
+
+// pick returns a random palette color.
+func pick(rng *rand.Rand, palette []string) string {
+	return palette[rng.IntN(len(palette))]
+}
+
+// triangles splits a jittered grid into triangles with random palette fills.
+func triangles(ctx context.Context, rng *rand.Rand, w, h float64, palette []string) ([]Shape, error) {
+	cols, rows := triangleGrid(w, h)
+	cw, ch := w/float64(cols), h/float64(rows)
+
+	grid := make([][][2]float64, rows+1)
+	for y := 0; y <= rows; y++ {
+		grid[y] = make([][2]float64, cols+1)
+		for x := 0; x <= cols; x++ {
+			px, py := float64(x)*cw, float64(y)*ch
+			// Jitter interior points only, so the mesh still covers the edges
+			if x > 0 && x < cols {
+				px += (rng.Float64() - 0.5) * cw * 0.6
+			}
+			if y > 0 && y < rows {
+				py += (rng.Float64() - 0.5) * ch * 0.6
+			}
+			grid[y][x] = [2]float64{px, py}
+		}
+	}
+
+	shapes := make([]Shape, 0, rows*cols*2)
+	for y := 0; y < rows; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := 0; x < cols; x++ {
+			a, b, c, d := grid[y][x], grid[y][x+1], grid[y+1][x+1], grid[y+1][x]
+			shapes = append(shapes,
+				Shape{Points: [][2]float64{a, b, c}, Fill: pick(rng, palette), Opacity: 1},
+				Shape{Points: [][2]float64{a, c, d}, Fill: pick(rng, palette), Opacity: 1},
+			)
+		}
+	}
+	return shapes, nil
+}
+
+// voronoi partitions the canvas into cells around random sites by clipping the
+// canvas rectangle against the perpendicular bisector of every other site.
+func voronoi(ctx context.Context, rng *rand.Rand, w, h float64, palette []string) ([]Shape, error) {
+	sites := make([][2]float64, voronoiSites)
+	for i := range sites {
+		sites[i] = [2]float64{rng.Float64() * w, rng.Float64() * h}
+	}
+
+	shapes := make([]Shape, 0, len(sites))
+	for i, site := range sites {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cell := [][2]float64{{0, 0}, {w, 0}, {w, h}, {0, h}}
+		for j, other := range sites {
+			if i == j {
+				continue
+			}
+			cell = clipHalfPlane(cell, site, other)
+			if len(cell) == 0 {
+				break
+			}
+		}
+		if len(cell) >= 3 {
+			shapes = append(shapes, Shape{Points: cell, Fill: pick(rng, palette), Opacity: 1})
+		}
+	}
+	return shapes, nil
+}
+
+// clipHalfPlane keeps the part of poly that is closer to a than to b
+// (Sutherland–Hodgman against the bisector of a and b).
+func clipHalfPlane(poly [][2]float64, a, b [2]float64) [][2]float64 {
+	// Points p with dot(p - mid, b - a) <= 0 are closer to a
+	nx, ny := b[0]-a[0], b[1]-a[1]
+	mx, my := (a[0]+b[0])/2, (a[1]+b[1])/2
+	side := func(p [2]float64) float64 { return (p[0]-mx)*nx + (p[1]-my)*ny }
+
+	out := make([][2]float64, 0, len(poly)+1)
+	for i, cur := range poly {
+		prev := poly[(i+len(poly)-1)%len(poly)]
+		sc, sp := side(cur), side(prev)
+		if sc <= 0 {
+			if sp > 0 {
+				out = append(out, intersect(prev, cur, sp, sc))
+			}
+			out = append(out, cur)
+		} else if sp <= 0 {
+			out = append(out, intersect(prev, cur, sp, sc))
+		}
+	}
+	return out
+}
+
+// intersect returns the point on segment p→q where the side function crosses zero.
+func intersect(p, q [2]float64, sp, sq float64) [2]float64 {
+	t := sp / (sp - sq)
+	return [2]float64{p[0] + t*(q[0]-p[0]), p[1] + t*(q[1]-p[1])}
+}
+
+// waves stacks horizontal bands whose top edges follow seeded sine curves.
+func waves(rng *rand.Rand, w, h float64, palette []string) []Shape {
+	shapes := []Shape{background(w, h, palette)}
+	bands := waveBands
+	const steps = 48
+	for i := 1; i <= bands; i++ {
+		baseY := h * float64(i) / float64(bands+1)
+		amp := h * (0.03 + rng.Float64()*0.06)
+		freq := 1 + rng.Float64()*2
+		phase := rng.Float64() * 2 * math.Pi
+
+		points := make([][2]float64, 0, steps+3)
+		for s := 0; s <= steps; s++ {
+			x := w * float64(s) / steps
+			y := baseY + amp*math.Sin(phase+freq*2*math.Pi*x/w)
+			points = append(points, [2]float64{x, y})
+		}
+		points = append(points, [2]float64{w, h}, [2]float64{0, h})
+		shapes = append(shapes, Shape{Points: points, Fill: palette[i%len(palette)], Opacity: 0.85})
+	}
+	return shapes
+}
+
+// bubbles scatters translucent circles of varying size over a solid background.
+func bubbles(rng *rand.Rand, w, h float64, palette []string) []Shape {
+	shapes := []Shape{background(w, h, palette)}
+	minDim := math.Min(w, h)
+	for i := 0; i < bubbleCount; i++ {
+		shapes = append(shapes, Shape{
+			Circle:  true,
+			CX:      rng.Float64() * w,
+			CY:      rng.Float64() * h,
+			R:       minDim * (0.05 + rng.Float64()*0.3),
+			Fill:    pick(rng, palette),
+			Opacity: 0.35 + rng.Float64()*0.4,
+		})
+	}
+	return shapes
+}
+
+// hslToHex converts hue (degrees), saturation and lightness (0–1) to a hex color.
+func hslToHex(hue, s, l float64) string {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g, b = c, x, 0
+	case hue < 120:
+		r, g, b = x, c, 0
+	case hue < 180:
+		r, g, b = 0, c, x
+	case hue < 240:
+		r, g, b = 0, x, c
+	case hue < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return fmt.Sprintf("%02x%02x%02x", uint8(math.Round((r+m)*255)), uint8(math.Round((g+m)*255)), uint8(math.Round((b+m)*255)))
+}
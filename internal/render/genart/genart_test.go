@@ -0,0 +1,122 @@
+package genart
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	palette := []string{"264653", "2a9d8f", "e9c46a", "f4a261"}
+
+	for _, variant := range Variants() {
+		t.Run(string(variant), func(t *testing.T) {
+			first, err := Generate(context.Background(), variant, 400, 200, 42, palette)
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			second, _ := Generate(context.Background(), variant, 400, 200, 42, palette)
+			if !reflect.DeepEqual(first, second) {
+				t.Fatal("expected the same seed to produce the same shapes")
+			}
+			other, _ := Generate(context.Background(), variant, 400, 200, 43, palette)
+			if reflect.DeepEqual(first, other) {
+				t.Fatal("expected a different seed to produce different shapes")
+			}
+			if len(first) == 0 {
+				t.Fatal("expected shapes")
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := Generate(context.Background(), "spirals", 100, 100, 1, []string{"000000"}); err == nil {
+		t.Fatal("expected error for unknown variant")
+	}
+	if _, err := Generate(context.Background(), VariantTriangles, 100, 100, 1, nil); err == nil {
+		t.Fatal("expected error for empty palette")
+	}
+}
+
+func TestGenerateBounded(t *testing.T) {
+	palette := []string{"000000", "ffffff"}
+	for _, variant := range Variants() {
+		for _, size := range [][2]int{{400, 200}, {1, 20000}, {20000, 1}} {
+			shapes, err := Generate(context.Background(), variant, size[0], size[1], 1, palette)
+			if err != nil {
+				t.Fatalf("%s %v: generate: %v", variant, size, err)
+			}
+			if limit := ShapeCount(variant, size[0], size[1]); len(shapes) > limit {
+				t.Errorf("%s %v: expected at most %d shapes, got %d", variant, size, limit, len(shapes))
+			}
+		}
+	}
+	if n := ShapeCount(VariantTriangles, 1, 20000); n != triangleCols*maxTriangleRows*2 {
+		t.Errorf("expected a tall canvas to use the most rows, got %d shapes", n)
+	}
+}
+
+func TestGenerateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, variant := range []Variant{VariantTriangles, VariantVoronoi} {
+		if _, err := Generate(ctx, variant, 400, 200, 1, []string{"000000"}); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", variant, err)
+		}
+	}
+}
+
+// polygonArea returns the absolute area of a simple polygon (shoelace formula).
+func polygonArea(points [][2]float64) float64 {
+	var sum float64
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		sum += p[0]*q[1] - q[0]*p[1]
+	}
+	return math.Abs(sum) / 2
+}
+
+func TestVoronoiCellsTileCanvas(t *testing.T) {
+	shapes, err := Generate(context.Background(), VariantVoronoi, 300, 200, 7, []string{"000000", "ffffff"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	var total float64
+	for _, s := range shapes {
+		total += polygonArea(s.Points)
+	}
+	if math.Abs(total-300*200) > 1 {
+		t.Fatalf("expected cells to cover 60000px², got %.1f", total)
+	}
+}
+
+func TestParseSeed(t *testing.T) {
+	if got := ParseSeed("42"); got != 42 {
+		t.Fatalf("expected numeric seed 42, got %d", got)
+	}
+	if ParseSeed("Jane Doe") != ParseSeed("Jane Doe") {
+		t.Fatal("expected string seeds to hash deterministically")
+	}
+	if ParseSeed("Jane Doe") == ParseSeed("John Doe") {
+		t.Fatal("expected different strings to hash differently")
+	}
+}
+
+func TestPalette(t *testing.T) {
+	colors := Palette(42, 4)
+	if len(colors) != 4 {
+		t.Fatalf("expected 4 colors, got %d", len(colors))
+	}
+	for _, c := range colors {
+		if len(c) != 6 {
+			t.Fatalf("expected 6-digit hex, got %q", c)
+		}
+	}
+	if !reflect.DeepEqual(colors, Palette(42, 4)) {
+		t.Fatal("expected palette to be deterministic")
+	}
+}
@@ -0,0 +1,157 @@
+package genart
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// delaunay triangulates points with the Bowyer–Watson algorithm and returns
+// triangles as index triples into points.
+func delaunay(points [][2]float64) [][3]int {
+	type tri struct {
+		v      [3]int
+		cx, cy float64
+		r2     float64
+	}
+
+	// Work on a copy with a super triangle appended that encloses every point
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		minX, minY = math.Min(minX, p[0]), math.Min(minY, p[1])
+		maxX, maxY = math.Max(maxX, p[0]), math.Max(maxY, p[1])
+	}
+	span := math.Max(maxX-minX, maxY-minY) * 20
+	midX, midY := (minX+maxX)/2, (minY+maxY)/2
+	n := len(points)
+	pts := append(append([][2]float64{}, points...),
+		[2]float64{midX - span, midY - span},
+		[2]float64{midX + span, midY - span},
+		[2]float64{midX, midY + span},
+	)
+
+	circum := func(a, b, c int) tri {
+		ax, ay := pts[a][0], pts[a][1]
+		bx, by := pts[b][0], pts[b][1]
+		cx, cy := pts[c][0], pts[c][1]
+		d := 2 * (ax*(by-cy) + bx*(cy-ay) + cx*(ay-by))
+		if d == 0 {
+			// Degenerate (collinear) triangle: give it a circumcircle that contains nothing
+			return tri{v: [3]int{a, b, c}, r2: -1}
+		}
+		ux := ((ax*ax+ay*ay)*(by-cy) + (bx*bx+by*by)*(cy-ay) + (cx*cx+cy*cy)*(ay-by)) / d
+		uy := ((ax*ax+ay*ay)*(cx-bx) + (bx*bx+by*by)*(ax-cx) + (cx*cx+cy*cy)*(bx-ax)) / d
+		return tri{v: [3]int{a, b, c}, cx: ux, cy: uy, r2: (ax-ux)*(ax-ux) + (ay-uy)*(ay-uy)}
+	}
+
+	tris := []tri{circum(n, n+1, n+2)}
+	for i := 0; i < n; i++ {
+		p := pts[i]
+		var keep []tri
+		// Edges in discovery order, so the output doesn't depend on map iteration
+		var edges [][2]int
+		edgeCount := map[[2]int]int{}
+		for _, t := range tris {
+			dx, dy := p[0]-t.cx, p[1]-t.cy
+			if dx*dx+dy*dy < t.r2 {
+				// Bad triangle: remember its edges to find the cavity boundary
+				for k := 0; k < 3; k++ {
+					a, b := t.v[k], t.v[(k+1)%3]
+					if a > b {
+						a, b = b, a
+					}
+					e := [2]int{a, b}
+					edgeCount[e]++
+					if edgeCount[e] == 1 {
+						edges = append(edges, e)
+					}
+				}
+				continue
+			}
+			keep = append(keep, t)
+		}
+		for _, e := range edges {
+			if edgeCount[e] == 1 {
+				keep = append(keep, circum(e[0], e[1], i))
+			}
+		}
+		tris = keep
+	}
+
+	result := make([][3]int, 0, len(tris))
+	for _, t := range tris {
+		if t.v[0] < n && t.v[1] < n && t.v[2] < n {
+			result = append(result, t.v)
+		}
+	}
+	return result
+}
+
+// LowPoly returns a Delaunay-triangulated w×h composition whose facets are shaded
+// along the diagonal from color1 to color2, with seeded lightness variation.
+func LowPoly(w, h int, seed uint64, color1, color2 string) []Shape {
+	rng := newRand(seed)
+	fw, fh := float64(w), float64(h)
+
+	// Jittered grid points, plus corners and edge points so the mesh covers the canvas
+	cell := math.Max(24, math.Min(fw, fh)/5)
+	cols, rows := max(1, int(math.Round(fw/cell))), max(1, int(math.Round(fh/cell)))
+	cw, ch := fw/float64(cols), fh/float64(rows)
+
+	var points [][2]float64
+	for y := 0; y <= rows; y++ {
+		for x := 0; x <= cols; x++ {
+			px, py := float64(x)*cw, float64(y)*ch
+			if x > 0 && x < cols {
+				px += (rng.Float64() - 0.5) * cw * 0.8
+			}
+			if y > 0 && y < rows {
+				py += (rng.Float64() - 0.5) * ch * 0.8
+			}
+			points = append(points, [2]float64{px, py})
+		}
+	}
+
+	triangles := delaunay(points)
+	shapes := make([]Shape, 0, len(triangles))
+	for _, t := range triangles {
+		a, b, c := points[t[0]], points[t[1]], points[t[2]]
+		cx, cy := (a[0]+b[0]+c[0])/3, (a[1]+b[1]+c[1])/3
+		mix := (cx/fw + cy/fh) / 2
+		shade := (rng.Float64() - 0.5) * 0.16
+		shapes = append(shapes, Shape{
+			Points:  [][2]float64{a, b, c},
+			Fill:    mixHex(color1, color2, mix, shade),
+			Opacity: 1,
+		})
+	}
+	return shapes
+}
+
+// parseHex converts a 6-digit (or 3-digit) hex color to RGB, defaulting to gray.
+func parseHex(s string) [3]float64 {
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if len(s) != 6 || err != nil {
+		return [3]float64{200, 200, 200}
+	}
+	return [3]float64{float64(v >> 16 & 0xff), float64(v >> 8 & 0xff), float64(v & 0xff)}
+}
+
+// mixHex blends a toward b by t (0–1), then lightens (shade > 0) or darkens (shade < 0).
+func mixHex(a, b string, t, shade float64) string {
+	ca, cb := parseHex(a), parseHex(b)
+	var out [3]uint8
+	for i := 0; i < 3; i++ {
+		v := ca[i] + (cb[i]-ca[i])*t
+		if shade > 0 {
+			v += (255 - v) * shade
+		} else {
+			v += v * shade
+		}
+		out[i] = uint8(math.Round(math.Max(0, math.Min(255, v))))
+	}
+	return fmt.Sprintf("%02x%02x%02x", out[0], out[1], out[2])
+}
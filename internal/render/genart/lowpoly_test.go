@@ -0,0 +1,84 @@
+package genart
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDelaunaySquare(t *testing.T) {
+	points := [][2]float64{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {5, 5}}
+
+	triangles := delaunay(points)
+	if len(triangles) != 4 {
+		t.Fatalf("expected 4 triangles around the center point, got %d", len(triangles))
+	}
+	for _, tri := range triangles {
+		hasCenter := tri[0] == 4 || tri[1] == 4 || tri[2] == 4
+		if !hasCenter {
+			t.Fatalf("expected every triangle to use the center point, got %v", tri)
+		}
+	}
+}
+
+func TestLowPolyCoversCanvas(t *testing.T) {
+	tests := []struct {
+		name string
+		w, h int
+	}{
+		{"landscape", 400, 200},
+		{"portrait", 120, 300},
+		{"small square", 64, 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shapes := LowPoly(tt.w, tt.h, 42, "1e3c72", "2a5298")
+			var total float64
+			for _, s := range shapes {
+				if len(s.Points) != 3 {
+					t.Fatalf("expected triangles, got %d points", len(s.Points))
+				}
+				total += polygonArea(s.Points)
+			}
+			if want := float64(tt.w * tt.h); math.Abs(total-want) > 1 {
+				t.Fatalf("expected facets to cover %.0fpx², got %.1f", want, total)
+			}
+		})
+	}
+}
+
+func TestLowPolySeeded(t *testing.T) {
+	first := LowPoly(300, 200, 1, "000000", "ffffff")
+	if !reflect.DeepEqual(first, LowPoly(300, 200, 1, "000000", "ffffff")) {
+		t.Fatal("expected the same seed to produce the same facets")
+	}
+	if reflect.DeepEqual(first, LowPoly(300, 200, 2, "000000", "ffffff")) {
+		t.Fatal("expected a different seed to produce different facets")
+	}
+}
+
+func TestMixHex(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		t     float64
+		shade float64
+		exp   string
+	}{
+		{"start", "000000", "ffffff", 0, 0, "000000"},
+		{"end", "000000", "ffffff", 1, 0, "ffffff"},
+		{"middle", "000000", "ffffff", 0.5, 0, "808080"},
+		{"lighten", "000000", "000000", 0, 0.5, "808080"},
+		{"darken", "ffffff", "ffffff", 0, -0.5, "808080"},
+		{"shorthand", "f00", "f00", 0, 0, "ff0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mixHex(tt.a, tt.b, tt.t, tt.shade); got != tt.exp {
+				t.Fatalf("expected %s got %s", tt.exp, got)
+			}
+		})
+	}
+}
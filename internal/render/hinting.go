@@ -0,0 +1,93 @@
+package render
+
+import (
+	"context"
+	"image"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"grout/internal/config"
+)
+
+// TextOptions tune how raster images draw their text. The zero value draws it
+// unhinted and anti-aliased, at the image's size. SVG output leaves text to
+// the viewer and ignores them.
+type TextOptions struct {
+	// Hinting is "none" or "full", which snaps glyph outlines to the pixel grid
+	// for sharper stems in small text. Empty means none.
+	Hinting string
+	// Aliased draws text in whole pixels, without anti-aliasing
+	Aliased bool
+	// Supersample draws scenes this many times larger and scales them down,
+	// smoothing small text and edges; 0 or 1 draws them at size
+	Supersample int
+}
+
+type textOptionsKey struct{}
+
+// WithTextOptions returns a context whose images draw their text with opts.
+func WithTextOptions(ctx context.Context, opts TextOptions) context.Context {
+	return context.WithValue(ctx, textOptionsKey{}, opts)
+}
+
+// textOptions returns the text options of ctx.
+func textOptions(ctx context.Context) TextOptions {
+	opts, _ := ctx.Value(textOptionsKey{}).(TextOptions)
+	return opts
+}
+
+// face returns a face of f at size, hinted and anti-aliased as opts say.
+func (opts TextOptions) face(f *truetype.Font, size float64) font.Face {
+	hinting := font.HintingNone
+	if opts.Hinting == "full" {
+		hinting = font.HintingFull
+	}
+	face := truetype.NewFace(f, &truetype.Options{Size: size, Hinting: hinting})
+	if opts.Aliased {
+		return aliasedFace{face}
+	}
+	return face
+}
+
+// SupersampleFactor returns the factor a w×h image is supersampled by when
+// factor is asked for: at most factor, and low enough to keep both sides
+// within config.MaxSupersampledDimension. 1 means it's drawn at size.
+func SupersampleFactor(w, h, factor int) int {
+	for factor > 1 && max(w, h)*factor > config.MaxSupersampledDimension {
+		factor--
+	}
+	return max(factor, 1)
+}
+
+// downsample scales a supersampled image down to w×h.
+func downsample(img image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// aliasedFace draws the glyphs of a face in whole pixels: a pixel is covered
+// when the glyph covers at least half of it, and left clear otherwise.
+type aliasedFace struct {
+	font.Face
+}
+
+func (f aliasedFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	dr, mask, maskp, advance, ok := f.Face.Glyph(dot, r)
+	if !ok {
+		return dr, mask, maskp, advance, ok
+	}
+	// The face reuses its mask for the next glyph, so the hard mask is a copy
+	hard := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	for y := 0; y < dr.Dy(); y++ {
+		for x := 0; x < dr.Dx(); x++ {
+			if _, _, _, a := mask.At(maskp.X+x, maskp.Y+y).RGBA(); a >= 0x8000 {
+				hard.Pix[y*hard.Stride+x] = 0xff
+			}
+		}
+	}
+	return dr, hard, image.Point{}, advance, true
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"grout/internal/config"
+)
+
+func TestSupersampleFactor(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h, factor int
+		expected     int
+	}{
+		{"none asked", 64, 64, 0, 1},
+		{"one is none", 64, 64, 1, 1},
+		{"small image", 64, 64, 2, 2},
+		{"lowered to fit", config.MaxSupersampledDimension / 3, 10, 4, 3},
+		{"too large for any", config.MaxSupersampledDimension, 10, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SupersampleFactor(tt.w, tt.h, tt.factor); got != tt.expected {
+				t.Errorf("expected factor %d got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSceneScaled(t *testing.T) {
+	scene := Scene{
+		Width: 40, Height: 20,
+		Background: Background{Color: "ffffff", Circle: true, Radius: 10},
+		Shapes:     []Shape{{X: 1, Y: 2, Width: 3, Height: 4, Stroke: 1, Points: []Point{{X: 5, Y: 6}}}},
+		Text:       []TextRun{{Text: "A", X: 20, Y: 10, Size: 8}},
+		Layers:     []Scene{{Overlay: []Shape{{X: 7, Width: 1, Height: 1}}}},
+	}
+	scaled := scene.scaled(2)
+	if scaled.Width != 80 || scaled.Height != 40 || scaled.Background.Radius != 20 {
+		t.Errorf("expected an 80x40 scene with radius 20, got %dx%d with radius %v", scaled.Width, scaled.Height, scaled.Background.Radius)
+	}
+	if s := scaled.Shapes[0]; s.X != 2 || s.Height != 8 || s.Stroke != 2 || s.Points[0] != (Point{X: 10, Y: 12}) {
+		t.Errorf("expected the shape doubled, got %+v", s)
+	}
+	if run := scaled.Text[0]; run.X != 40 || run.Y != 20 || run.Size != 16 {
+		t.Errorf("expected the text run doubled, got %+v", run)
+	}
+	if x := scaled.Layers[0].Overlay[0].X; x != 14 {
+		t.Errorf("expected layers scaled too, got overlay x %v", x)
+	}
+	if scene.Shapes[0].Points[0] != (Point{X: 5, Y: 6}) || scene.Text[0].Size != 8 {
+		t.Error("expected the original scene unchanged")
+	}
+}
+
+func TestTextOptions(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	// White text on a transparent canvas, so coverage shows in the alpha channel
+	scene := labelScene(48, 48, "", "ffffff", "Ag", false, false, 18, false, nil)
+	rasterize := func(opts TextOptions) *image.RGBA {
+		t.Helper()
+		img, err := r.rasterizeScene(WithTextOptions(context.Background(), opts), scene)
+		if err != nil {
+			t.Fatalf("rasterize: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != 48 || b.Dy() != 48 {
+			t.Fatalf("expected a 48x48 image, got %v", b)
+		}
+		return img.(*image.RGBA)
+	}
+	partial := func(img *image.RGBA) int {
+		n := 0
+		for i := 3; i < len(img.Pix); i += 4 {
+			if a := img.Pix[i]; a != 0 && a != 0xff {
+				n++
+			}
+		}
+		return n
+	}
+
+	plain := rasterize(TextOptions{})
+	if partial(plain) == 0 {
+		t.Fatal("expected anti-aliased text to have partly covered pixels")
+	}
+	if n := partial(rasterize(TextOptions{Aliased: true})); n != 0 {
+		t.Errorf("expected aliased text to cover whole pixels, got %d partly covered", n)
+	}
+	if string(rasterize(TextOptions{Hinting: "full"}).Pix) == string(plain.Pix) {
+		t.Error("expected full hinting to change the glyphs")
+	}
+	if string(rasterize(TextOptions{Supersample: 2}).Pix) == string(plain.Pix) {
+		t.Error("expected supersampling to change the image")
+	}
+}
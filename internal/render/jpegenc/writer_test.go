@@ -0,0 +1,60 @@
+package jpegenc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// stripes returns an image of 1px red and blue columns, which 4:2:0 blurs to purple.
+func stripes() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 37, 21))
+	for y := 0; y < 21; y++ {
+		for x := 0; x < 37; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x%2 == 1 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncode420MatchesStandardLibrary(t *testing.T) {
+	var ours, std bytes.Buffer
+	if err := Encode(&ours, stripes(), &Options{Quality: 90}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if err := jpeg.Encode(&std, stripes(), &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if !bytes.Equal(ours.Bytes(), std.Bytes()) {
+		t.Fatal("expected 4:2:0 output identical to image/jpeg")
+	}
+}
+
+func TestEncode444(t *testing.T) {
+	// redError is how far the first column is from pure red once decoded
+	redError := func(subsampling Subsampling) int {
+		var buf bytes.Buffer
+		if err := Encode(&buf, stripes(), &Options{Quality: 95, Subsampling: subsampling}); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		img, err := jpeg.Decode(&buf)
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != 37 || b.Dy() != 21 {
+			t.Fatalf("expected a 37x21 image got %v", b)
+		}
+		r, _, b, _ := img.At(0, 10).RGBA()
+		return int(0xffff-r)>>8 + int(b>>8)
+	}
+
+	if sharp, blurred := redError(Subsample444), redError(Subsample420); sharp >= blurred || sharp > 64 {
+		t.Fatalf("expected 4:4:4 to keep the red column red: error %d with 4:4:4, %d with 4:2:0", sharp, blurred)
+	}
+}
@@ -0,0 +1,464 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+
+	"grout/internal/config"
+)
+
+// ErrInvalidLayout is returned when a layout document can't be laid out.
+var ErrInvalidLayout = errors.New("invalid layout")
+
+// Layout is a composition of text, shapes, and avatars stacked in rows and
+// columns, centered on a canvas.
+type Layout struct {
+	Width      int           `json:"width"`
+	Height     int           `json:"height"`
+	Background string        `json:"background,omitempty"` // Hex, no '#'; empty is transparent
+	Border     *LayoutBorder `json:"border,omitempty"`
+	// Font is the embedded font of text nodes that set neither font nor bold
+	Font string     `json:"font,omitempty"`
+	Root LayoutNode `json:"root"`
+	// Logo is the image "logo" nodes draw: the server's brand logo, which
+	// documents can place but not supply
+	Logo image.Image `json:"-"`
+}
+
+// LayoutBorder is a frame drawn Inset pixels inside the edge of the canvas, with
+// lines Width pixels thick. Style is "solid" (the default), "double", or
+// "ornate", a double frame with a circle on each corner.
+type LayoutBorder struct {
+	Color string  `json:"color,omitempty"`
+	Width float64 `json:"width,omitempty"`
+	Inset float64 `json:"inset,omitempty"`
+	Style string  `json:"style,omitempty"`
+}
+
+// LayoutNode is one element of a layout. Type selects which fields apply:
+//   - "row" and "column" stack Children with Gap pixels between them, aligned
+//     by Align ("start", "center", or "end") across the stacking direction; a
+//     negative Gap overlaps them, each child drawn over the one before it
+//   - "text" draws Text at Size pixels, wrapping at Width when it's set, in the
+//     embedded font named by Font, or the regular or bold font
+//   - "avatar" draws the initials of Name, or Text when it's set, on a circle,
+//     or a square when Shape is "square", Size pixels across, with the text
+//     TextSize pixels high (half the avatar by default). Ring sets it in
+//     an outline that many pixels wide, in RingColor (white by default), which
+//     cuts it out of the avatars it overlaps.
+//   - "shape" draws a Width x Height "rect" (the default) or "circle"; a rect
+//     with Dash set is a dashed line of Dash-pixel dashes along its longer side
+//   - "barcode" draws Text as a Code 128 barcode, Width x Height pixels
+//   - "logo" draws the layout's Logo fitted inside Width x Height pixels; a
+//     missing side follows the logo's aspect ratio, and the height is 48 when
+//     neither is set
+//   - "spacer" takes up Width x Height pixels
+type LayoutNode struct {
+	Type       string       `json:"type"`
+	Text       string       `json:"text,omitempty"`
+	Name       string       `json:"name,omitempty"`
+	Shape      string       `json:"shape,omitempty"`
+	Width      float64      `json:"width,omitempty"`
+	Height     float64      `json:"height,omitempty"`
+	Size       float64      `json:"size,omitempty"`
+	Bold       bool         `json:"bold,omitempty"`
+	Font       string       `json:"font,omitempty"`
+	Color      string       `json:"color,omitempty"`
+	Background string       `json:"background,omitempty"`
+	Gap        float64      `json:"gap,omitempty"`
+	Dash       float64      `json:"dash,omitempty"`
+	Align      string       `json:"align,omitempty"`
+	TextSize   float64      `json:"text_size,omitempty"`
+	Ring       float64      `json:"ring,omitempty"`
+	RingColor  string       `json:"ring_color,omitempty"`
+	Children   []LayoutNode `json:"children,omitempty"`
+}
+
+const (
+	defaultLayoutTextSize   = 16
+	defaultLayoutAvatarSize = 64
+	defaultLayoutColor      = "000000"
+	defaultLayoutRingColor  = "ffffff"
+	defaultLayoutBorder     = 2
+	defaultLayoutBarcode    = 60  // Height of a barcode
+	defaultLayoutLogo       = 48  // Height of a logo
+	layoutBarcodeModule     = 2   // Width of a barcode module when the barcode has no width
+	layoutLineHeight        = 1.5 // Line height as a multiple of the font size
+)
+
+// layoutColorRegex matches a 3- or 6-digit hex color without the leading '#'.
+var layoutColorRegex = regexp.MustCompile(`^([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// measuredNode is a layout node with its size worked out.
+type measuredNode struct {
+	node     *LayoutNode
+	w, h     float64
+	lines    []string // Text lines of a text node
+	bars     []int    // Bar and space widths of a barcode node, in modules
+	children []measuredNode
+}
+
+// layouter measures and places the nodes of one layout.
+type layouter struct {
+	r     *Renderer
+	doc   *Layout
+	scene Scene
+	nodes int
+}
+
+// LayoutScene lays out a layout document, returning an error wrapping
+// ErrInvalidLayout when the document is invalid or over the size limits.
+func (r *Renderer) LayoutScene(doc Layout) (Scene, error) {
+	if doc.Width < 1 || doc.Height < 1 || doc.Width > config.MaxLayoutDimension || doc.Height > config.MaxLayoutDimension {
+		return Scene{}, fmt.Errorf("%w: width and height must be between 1 and %d", ErrInvalidLayout, config.MaxLayoutDimension)
+	}
+	if doc.Background != "" && !layoutColorRegex.MatchString(doc.Background) {
+		return Scene{}, fmt.Errorf("%w: background %q is not a hex color", ErrInvalidLayout, doc.Background)
+	}
+	if doc.Font != "" && !r.HasFont(doc.Font) {
+		return Scene{}, fmt.Errorf("%w: unknown font %q", ErrInvalidLayout, doc.Font)
+	}
+
+	l := &layouter{r: r, doc: &doc, scene: Scene{Width: doc.Width, Height: doc.Height, Background: Background{Color: doc.Background}}}
+	if doc.Border != nil {
+		if err := l.border(doc.Border); err != nil {
+			return Scene{}, err
+		}
+	}
+	root, err := l.measure(&doc.Root, 1)
+	if err != nil {
+		return Scene{}, err
+	}
+	// The root is centered on the canvas
+	l.place(root, (float64(doc.Width)-root.w)/2, (float64(doc.Height)-root.h)/2)
+	return l.scene, nil
+}
+
+// measure works out the size of a node and its children.
+func (l *layouter) measure(n *LayoutNode, depth int) (measuredNode, error) {
+	if l.nodes++; l.nodes > config.MaxLayoutNodes {
+		return measuredNode{}, fmt.Errorf("%w: more than %d nodes", ErrInvalidLayout, config.MaxLayoutNodes)
+	}
+	if depth > config.MaxLayoutDepth {
+		return measuredNode{}, fmt.Errorf("%w: nested deeper than %d levels", ErrInvalidLayout, config.MaxLayoutDepth)
+	}
+	for _, v := range []float64{n.Width, n.Height, n.Size, n.Dash, n.Ring, n.TextSize} {
+		if v < 0 || v > config.MaxLayoutDimension {
+			return measuredNode{}, fmt.Errorf("%w: %s sizes must be between 0 and %d", ErrInvalidLayout, n.Type, config.MaxLayoutDimension)
+		}
+	}
+	if n.Gap < -config.MaxLayoutDimension || n.Gap > config.MaxLayoutDimension {
+		return measuredNode{}, fmt.Errorf("%w: gaps must be between -%d and %d", ErrInvalidLayout, config.MaxLayoutDimension, config.MaxLayoutDimension)
+	}
+	for _, c := range []string{n.Color, n.Background, n.RingColor} {
+		if c != "" && !layoutColorRegex.MatchString(c) {
+			return measuredNode{}, fmt.Errorf("%w: %q is not a hex color", ErrInvalidLayout, c)
+		}
+	}
+	if n.Type != "row" && n.Type != "column" && len(n.Children) > 0 {
+		return measuredNode{}, fmt.Errorf("%w: only rows and columns have children", ErrInvalidLayout)
+	}
+	switch n.Align {
+	case "", "start", "center", "end":
+	default:
+		return measuredNode{}, fmt.Errorf("%w: align must be start, center, or end", ErrInvalidLayout)
+	}
+
+	m := measuredNode{node: n}
+	switch n.Type {
+	case "row", "column":
+		for i := range n.Children {
+			child, err := l.measure(&n.Children[i], depth+1)
+			if err != nil {
+				return measuredNode{}, err
+			}
+			m.children = append(m.children, child)
+			if n.Type == "row" {
+				m.w += child.w
+				m.h = max(m.h, child.h)
+			} else {
+				m.w = max(m.w, child.w)
+				m.h += child.h
+			}
+		}
+		// Overlapping children never make a stack smaller than nothing
+		if gaps := float64(max(len(n.Children)-1, 0)) * n.Gap; n.Type == "row" {
+			m.w = max(m.w+gaps, 0)
+		} else {
+			m.h = max(m.h+gaps, 0)
+		}
+	case "text":
+		if n.Text == "" {
+			return measuredNode{}, fmt.Errorf("%w: text nodes need text", ErrInvalidLayout)
+		}
+		if utf8.RuneCountInString(n.Text) > config.DefaultMaxTextLength {
+			return measuredNode{}, fmt.Errorf("%w: text is longer than %d characters", ErrInvalidLayout, config.DefaultMaxTextLength)
+		}
+		if n.Font != "" && !l.r.HasFont(n.Font) {
+			return measuredNode{}, fmt.Errorf("%w: unknown font %q", ErrInvalidLayout, n.Font)
+		}
+		size := layoutTextSize(n)
+		face := truetype.NewFace(l.r.runFont(l.textFont(n), n.Bold), &truetype.Options{Size: size})
+		width := func(line string) float64 { return float64(font.MeasureString(face, line)) / 64 }
+		m.lines = []string{n.Text}
+		if n.Width > 0 {
+			m.lines = wrapLines(n.Text, func(line string) bool { return width(line) <= n.Width })
+			m.w = n.Width
+		} else {
+			m.w = width(n.Text)
+		}
+		m.h = float64(len(m.lines)) * size * layoutLineHeight
+	case "avatar":
+		if n.Shape != "" && n.Shape != "circle" && n.Shape != "square" {
+			return measuredNode{}, fmt.Errorf("%w: avatar shape must be circle or square", ErrInvalidLayout)
+		}
+		if utf8.RuneCountInString(n.Text) > config.DefaultMaxTextLength {
+			return measuredNode{}, fmt.Errorf("%w: text is longer than %d characters", ErrInvalidLayout, config.DefaultMaxTextLength)
+		}
+		m.w = layoutAvatarSize(n)
+		m.h = m.w
+		if 2*n.Ring >= m.w {
+			return measuredNode{}, fmt.Errorf("%w: an avatar's ring must be narrower than half its size", ErrInvalidLayout)
+		}
+	case "shape":
+		if n.Shape != "" && n.Shape != "rect" && n.Shape != "circle" {
+			return measuredNode{}, fmt.Errorf("%w: shape must be rect or circle", ErrInvalidLayout)
+		}
+		if n.Dash != 0 && (n.Dash < 1 || n.Shape == "circle") {
+			return measuredNode{}, fmt.Errorf("%w: dash must be at least 1 and only applies to rects", ErrInvalidLayout)
+		}
+		m.w, m.h = n.Width, n.Height
+	case "barcode":
+		if utf8.RuneCountInString(n.Text) > config.MaxBarcodeLength {
+			return measuredNode{}, fmt.Errorf("%w: barcodes hold at most %d characters", ErrInvalidLayout, config.MaxBarcodeLength)
+		}
+		bars, err := code128(n.Text)
+		if err != nil {
+			return measuredNode{}, fmt.Errorf("%w: %v", ErrInvalidLayout, err)
+		}
+		m.bars = bars
+		m.w = n.Width
+		if m.w == 0 {
+			m.w = float64(code128Modules(bars) * layoutBarcodeModule)
+		}
+		m.h = n.Height
+		if m.h == 0 {
+			m.h = defaultLayoutBarcode
+		}
+	case "logo":
+		if l.doc.Logo == nil {
+			return measuredNode{}, fmt.Errorf("%w: logo nodes need a brand logo, and none is configured", ErrInvalidLayout)
+		}
+		bounds := l.doc.Logo.Bounds()
+		aspect := float64(bounds.Dx()) / float64(bounds.Dy())
+		m.w, m.h = n.Width, n.Height
+		switch {
+		case m.w == 0 && m.h == 0:
+			m.h = defaultLayoutLogo
+			m.w = m.h * aspect
+		case m.w == 0:
+			m.w = m.h * aspect
+		case m.h == 0:
+			m.h = m.w / aspect
+		}
+	case "spacer":
+		m.w, m.h = n.Width, n.Height
+	default:
+		return measuredNode{}, fmt.Errorf("%w: unknown node type %q", ErrInvalidLayout, n.Type)
+	}
+	return m, nil
+}
+
+// place adds a measured node to the scene with its top-left corner at (x, y).
+func (l *layouter) place(m measuredNode, x, y float64) {
+	n := m.node
+	switch n.Type {
+	case "row", "column":
+		offset := 0.0
+		for _, child := range m.children {
+			var cx, cy float64
+			if n.Type == "row" {
+				cx, cy = x+offset, y+alignOffset(n.Align, m.h, child.h)
+				offset += child.w + n.Gap
+			} else {
+				cx, cy = x+alignOffset(n.Align, m.w, child.w), y+offset
+				offset += child.h + n.Gap
+			}
+			if n.Gap >= 0 {
+				l.place(child, cx, cy)
+				continue
+			}
+			// Overlapping children each get a layer, so one's text can't show
+			// through the next
+			parent := l.scene
+			l.scene = Scene{}
+			l.place(child, cx, cy)
+			parent.Layers = append(parent.Layers, l.scene)
+			l.scene = parent
+		}
+	case "text":
+		size := layoutTextSize(n)
+		for i, line := range m.lines {
+			l.scene.Text = append(l.scene.Text, TextRun{
+				Text:  line,
+				X:     x + m.w/2,
+				Y:     y + (float64(i)+0.5)*size*layoutLineHeight,
+				Size:  size,
+				Bold:  n.Bold,
+				Color: layoutColor(n.Color, defaultLayoutColor),
+				Font:  l.textFont(n),
+			})
+		}
+	case "avatar":
+		bg := layoutColor(n.Background, GenerateColorHash(n.Name))
+		initials := n.Text
+		if initials == "" {
+			initials = GetInitials(n.Name)
+		}
+		circle := n.Shape != "square"
+		if n.Ring > 0 {
+			l.scene.Shapes = append(l.scene.Shapes, Shape{X: x, Y: y, Width: m.w, Height: m.h, Circle: circle, Color: layoutColor(n.RingColor, defaultLayoutRingColor)})
+		}
+		inner := m.w - 2*n.Ring
+		l.scene.Shapes = append(l.scene.Shapes, Shape{X: x + n.Ring, Y: y + n.Ring, Width: inner, Height: inner, Circle: circle, Color: bg})
+		if initials != "" {
+			size := n.TextSize
+			if size == 0 {
+				size = LabelFontSize(int(inner), int(inner), initials)
+			}
+			l.scene.Text = append(l.scene.Text, TextRun{
+				Text:  initials,
+				X:     x + m.w/2,
+				Y:     y + m.h/2,
+				Size:  size,
+				Bold:  n.Bold,
+				Color: layoutColor(n.Color, GetContrastColor(bg)),
+			})
+		}
+	case "shape":
+		color := layoutColor(n.Color, defaultLayoutColor)
+		if n.Dash == 0 {
+			l.scene.Shapes = append(l.scene.Shapes, Shape{X: x, Y: y, Width: m.w, Height: m.h, Circle: n.Shape == "circle", Color: color})
+			return
+		}
+		// Dashes and gaps of equal length, along the longer side
+		vertical := m.h > m.w
+		length := max(m.w, m.h)
+		for offset := 0.0; offset < length; offset += 2 * n.Dash {
+			dash := min(n.Dash, length-offset)
+			if vertical {
+				l.scene.Shapes = append(l.scene.Shapes, Shape{X: x, Y: y + offset, Width: m.w, Height: dash, Color: color})
+			} else {
+				l.scene.Shapes = append(l.scene.Shapes, Shape{X: x + offset, Y: y, Width: dash, Height: m.h, Color: color})
+			}
+		}
+	case "logo":
+		// Fitted inside the node's box, centered
+		bounds := l.doc.Logo.Bounds()
+		scale := min(m.w/float64(bounds.Dx()), m.h/float64(bounds.Dy()))
+		w, h := float64(bounds.Dx())*scale, float64(bounds.Dy())*scale
+		l.scene.Images = append(l.scene.Images, SceneImage{X: x + (m.w-w)/2, Y: y + (m.h-h)/2, Width: w, Height: h, Image: l.doc.Logo})
+	case "barcode":
+		color := layoutColor(n.Color, defaultLayoutColor)
+		module := m.w / float64(code128Modules(m.bars))
+		offset := x + code128QuietZone*module
+		for i, w := range m.bars {
+			// Widths at even indexes are bars, the rest the spaces between them
+			if i%2 == 0 {
+				l.scene.Shapes = append(l.scene.Shapes, Shape{X: offset, Y: y, Width: float64(w) * module, Height: m.h, Color: color})
+			}
+			offset += float64(w) * module
+		}
+	}
+}
+
+// border adds the shapes of the canvas border to the scene, under everything
+// else.
+func (l *layouter) border(b *LayoutBorder) error {
+	if b.Color != "" && !layoutColorRegex.MatchString(b.Color) {
+		return fmt.Errorf("%w: border color %q is not a hex color", ErrInvalidLayout, b.Color)
+	}
+	if b.Width < 0 || b.Inset < 0 || b.Width > config.MaxLayoutDimension || b.Inset > config.MaxLayoutDimension {
+		return fmt.Errorf("%w: border sizes must be between 0 and %d", ErrInvalidLayout, config.MaxLayoutDimension)
+	}
+	switch b.Style {
+	case "", "solid", "double", "ornate":
+	default:
+		return fmt.Errorf("%w: border style must be solid, double, or ornate", ErrInvalidLayout)
+	}
+
+	width := b.Width
+	if width == 0 {
+		width = defaultLayoutBorder
+	}
+	color := layoutColor(b.Color, defaultLayoutColor)
+	w, h := float64(l.scene.Width), float64(l.scene.Height)
+	// frame strokes a rectangle whose outer edge is inset pixels inside the canvas
+	frame := func(inset, stroke float64) {
+		l.scene.Shapes = append(l.scene.Shapes, Shape{
+			X: inset + stroke/2, Y: inset + stroke/2, Width: w - 2*inset - stroke, Height: h - 2*inset - stroke,
+			Color: color, Stroke: stroke,
+		})
+	}
+
+	frame(b.Inset, width)
+	if b.Style == "double" || b.Style == "ornate" {
+		// A thinner inner line, two line widths in
+		frame(b.Inset+3*width, max(width/2, 1))
+	}
+	if b.Style == "ornate" {
+		d := 5 * width
+		center := b.Inset + 2*width
+		for _, c := range [][2]float64{{center, center}, {w - center, center}, {center, h - center}, {w - center, h - center}} {
+			l.scene.Shapes = append(l.scene.Shapes, Shape{X: c[0] - d/2, Y: c[1] - d/2, Width: d, Height: d, Circle: true, Color: color})
+		}
+	}
+	return nil
+}
+
+// alignOffset returns the offset of a child of size child within space.
+func alignOffset(align string, space, child float64) float64 {
+	switch align {
+	case "start":
+		return 0
+	case "end":
+		return space - child
+	}
+	return (space - child) / 2
+}
+
+// textFont returns the font of a text node: its own, or the layout's when it
+// sets neither font nor bold.
+func (l *layouter) textFont(n *LayoutNode) string {
+	if n.Font == "" && !n.Bold {
+		return l.doc.Font
+	}
+	return n.Font
+}
+
+func layoutTextSize(n *LayoutNode) float64 {
+	if n.Size > 0 {
+		return n.Size
+	}
+	return defaultLayoutTextSize
+}
+
+func layoutAvatarSize(n *LayoutNode) float64 {
+	if n.Size > 0 {
+		return n.Size
+	}
+	return defaultLayoutAvatarSize
+}
+
+func layoutColor(hex, def string) string {
+	if hex != "" {
+		return hex
+	}
+	return def
+}
@@ -0,0 +1,316 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLayoutScene(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+
+	doc := Layout{Width: 200, Height: 100, Background: "ffffff", Root: LayoutNode{
+		Type: "row", Gap: 10, Align: "start", Children: []LayoutNode{
+			{Type: "avatar", Name: "Jane Doe", Size: 40, Background: "123456"},
+			{Type: "column", Children: []LayoutNode{
+				{Type: "shape", Width: 50, Height: 20, Color: "ff0000"},
+				{Type: "spacer", Width: 10, Height: 40},
+			}},
+		},
+	}}
+	scene, err := r.LayoutScene(doc)
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+
+	// The row is 40+10+50 wide and 60 tall, centered on the 200x100 canvas
+	expectShapes := []Shape{
+		{X: 50, Y: 20, Width: 40, Height: 40, Circle: true, Color: "123456"},
+		{X: 100, Y: 20, Width: 50, Height: 20, Color: "ff0000"},
+	}
+	if len(scene.Shapes) != len(expectShapes) {
+		t.Fatalf("expected %d shapes got %+v", len(expectShapes), scene.Shapes)
+	}
+	for i, shape := range scene.Shapes {
+		if !reflect.DeepEqual(shape, expectShapes[i]) {
+			t.Errorf("shape %d: expected %+v got %+v", i, expectShapes[i], shape)
+		}
+	}
+	if len(scene.Text) != 1 || scene.Text[0].Text != "JD" || scene.Text[0].X != 70 || scene.Text[0].Y != 40 {
+		t.Fatalf("expected the initials centered on the avatar, got %+v", scene.Text)
+	}
+}
+
+func TestLayoutSceneWrapsText(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	scene, err := r.LayoutScene(Layout{Width: 300, Height: 300, Root: LayoutNode{
+		Type: "text", Text: "one two three four five six", Size: 20, Width: 80,
+	}})
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+	if len(scene.Text) < 2 {
+		t.Fatalf("expected the text to wrap at 80px, got %+v", scene.Text)
+	}
+	if lineGap := scene.Text[1].Y - scene.Text[0].Y; lineGap != 30 {
+		t.Errorf("expected a 1.5x line height, got %v", lineGap)
+	}
+}
+
+func TestLayoutSceneBorder(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+
+	tests := []struct {
+		style   string
+		shapes  int
+		circles int
+	}{
+		{"", 1, 0},
+		{"double", 2, 0},
+		{"ornate", 6, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			scene, err := r.LayoutScene(Layout{Width: 200, Height: 100, Border: &LayoutBorder{Color: "b8860b", Width: 4, Inset: 10, Style: tt.style}, Root: LayoutNode{Type: "spacer"}})
+			if err != nil {
+				t.Fatalf("layout: %v", err)
+			}
+			if len(scene.Shapes) != tt.shapes {
+				t.Fatalf("expected %d shapes got %+v", tt.shapes, scene.Shapes)
+			}
+			// The outer frame's edge is inset 10px, with the stroke centered 2px further in
+			outer := Shape{X: 12, Y: 12, Width: 176, Height: 76, Color: "b8860b", Stroke: 4}
+			if !reflect.DeepEqual(scene.Shapes[0], outer) {
+				t.Errorf("expected outer frame %+v got %+v", outer, scene.Shapes[0])
+			}
+			circles := 0
+			for _, shape := range scene.Shapes {
+				if shape.Circle {
+					circles++
+				}
+			}
+			if circles != tt.circles {
+				t.Errorf("expected %d corner circles got %d", tt.circles, circles)
+			}
+		})
+	}
+}
+
+func TestLayoutSceneFont(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	scene, err := r.LayoutScene(Layout{Width: 300, Height: 100, Root: LayoutNode{Type: "text", Text: "Jane Doe", Font: "bold-italic", Size: 30}})
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+	if scene.Text[0].Font != "bold-italic" {
+		t.Fatalf("expected the run to keep its font, got %+v", scene.Text[0])
+	}
+	svg := string(scene.svg())
+	if !strings.Contains(svg, `font-weight="bold" font-style="italic"`) {
+		t.Errorf("expected the SVG to describe the bold italic font, got:\n%s", svg)
+	}
+}
+
+func TestLayoutSceneLogo(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	logo := image.NewRGBA(image.Rect(0, 0, 100, 50))
+
+	tests := []struct {
+		name   string
+		node   LayoutNode
+		expect SceneImage
+	}{
+		{"default height", LayoutNode{Type: "logo"}, SceneImage{X: 52, Y: 76, Width: 96, Height: 48}},
+		{"width only", LayoutNode{Type: "logo", Width: 60}, SceneImage{X: 70, Y: 85, Width: 60, Height: 30}},
+		{"fitted in a box", LayoutNode{Type: "logo", Width: 100, Height: 100}, SceneImage{X: 50, Y: 75, Width: 100, Height: 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scene, err := r.LayoutScene(Layout{Width: 200, Height: 200, Logo: logo, Root: tt.node})
+			if err != nil {
+				t.Fatalf("layout: %v", err)
+			}
+			tt.expect.Image = logo
+			if len(scene.Images) != 1 || scene.Images[0] != tt.expect {
+				t.Fatalf("expected %+v, got %+v", tt.expect, scene.Images)
+			}
+		})
+	}
+
+	scene, _ := r.LayoutScene(Layout{Width: 200, Height: 200, Logo: logo, Root: LayoutNode{Type: "logo"}})
+	if svg := string(scene.svg()); !strings.Contains(svg, `<image x="52" y="76" width="96" height="48" href="data:image/png;base64,`) {
+		t.Errorf("expected an inline PNG image in the SVG, got:\n%s", svg)
+	}
+	if _, err := r.RenderScene(context.Background(), scene, FormatPNG); err != nil {
+		t.Errorf("render PNG: %v", err)
+	}
+}
+
+func TestLayoutSceneOverlap(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	// Three 40px avatars overlapping by 10px: 100px wide, centered on 200x100
+	scene, err := r.LayoutScene(Layout{Width: 200, Height: 100, Root: LayoutNode{
+		Type: "row", Gap: -10, Children: []LayoutNode{
+			{Type: "avatar", Name: "Alice", Size: 40, Ring: 2, RingColor: "112233"},
+			{Type: "avatar", Name: "Bob", Size: 40, Ring: 2, RingColor: "112233"},
+			{Type: "avatar", Text: "+3", Size: 40, Background: "eeeeee", TextSize: 12},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+	// Each avatar is a layer over the one before, with its ring, fill, and text
+	expect := [][]Shape{
+		{{X: 50, Y: 30, Width: 40, Height: 40, Circle: true, Color: "112233"}, {X: 52, Y: 32, Width: 36, Height: 36, Circle: true, Color: GenerateColorHash("Alice")}},
+		{{X: 80, Y: 30, Width: 40, Height: 40, Circle: true, Color: "112233"}, {X: 82, Y: 32, Width: 36, Height: 36, Circle: true, Color: GenerateColorHash("Bob")}},
+		{{X: 110, Y: 30, Width: 40, Height: 40, Circle: true, Color: "eeeeee"}},
+	}
+	expectText := []string{"A", "B", "+3"}
+	if len(scene.Shapes) != 0 || len(scene.Layers) != len(expect) {
+		t.Fatalf("expected %d layers and no shapes below them, got %+v", len(expect), scene)
+	}
+	for i, layer := range scene.Layers {
+		if !reflect.DeepEqual(layer.Shapes, expect[i]) {
+			t.Errorf("layer %d: expected shapes %+v, got %+v", i, expect[i], layer.Shapes)
+		}
+		if len(layer.Text) != 1 || layer.Text[0].Text != expectText[i] {
+			t.Errorf("layer %d: expected the text %q, got %+v", i, expectText[i], layer.Text)
+		}
+	}
+	if size := scene.Layers[2].Text[0].Size; size != 12 {
+		t.Errorf("expected the +3 at its text size of 12, got %v", size)
+	}
+
+	// Layers are drawn in order, each over the last's text
+	svg := string(scene.svg())
+	if a, b := strings.Index(svg, ">A<"), strings.Index(svg, `cx="100"`); a < 0 || b < a {
+		t.Errorf("expected Bob's avatar after Alice's initials, got:\n%s", svg)
+	}
+	if _, err := r.RenderScene(context.Background(), scene, FormatPNG); err != nil {
+		t.Errorf("render PNG: %v", err)
+	}
+}
+
+func TestLayoutSceneDefaultFont(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	scene, err := r.LayoutScene(Layout{Width: 300, Height: 200, Font: "mono", Root: LayoutNode{
+		Type: "column", Children: []LayoutNode{
+			{Type: "text", Text: "plain"},
+			{Type: "text", Text: "bold", Bold: true},
+			{Type: "text", Text: "own", Font: "italic"},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+	for i, expect := range []string{"mono", "", "italic"} {
+		if scene.Text[i].Font != expect {
+			t.Errorf("run %q: expected font %q, got %q", scene.Text[i].Text, expect, scene.Text[i].Font)
+		}
+	}
+}
+
+func TestLayoutSceneBarcodeAndDash(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+
+	scene, err := r.LayoutScene(Layout{Width: 400, Height: 200, Root: LayoutNode{Type: "barcode", Text: "A1"}})
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+	// Start, 2 characters, and check make 4 symbols of 3 bars, and the stop 4 more
+	if len(scene.Shapes) != 16 {
+		t.Fatalf("expected 16 bars got %d", len(scene.Shapes))
+	}
+	// 2px modules, centered, after a 10-module quiet zone
+	modules := 11*4 + 13 + 20
+	if x := (400-float64(modules*2))/2 + 20; scene.Shapes[0].X != x || scene.Shapes[0].Height != defaultLayoutBarcode {
+		t.Errorf("expected the first bar at x=%v, got %+v", x, scene.Shapes[0])
+	}
+
+	scene, err = r.LayoutScene(Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "shape", Width: 2, Height: 50, Dash: 10}})
+	if err != nil {
+		t.Fatalf("layout: %v", err)
+	}
+	if len(scene.Shapes) != 3 || scene.Shapes[1].Y-scene.Shapes[0].Y != 20 || scene.Shapes[2].Height != 10 {
+		t.Fatalf("expected 3 vertical dashes 20px apart, got %+v", scene.Shapes)
+	}
+}
+
+func TestLayoutSceneErrors(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+
+	deep := LayoutNode{Type: "spacer"}
+	for i := 0; i < 10; i++ {
+		deep = LayoutNode{Type: "column", Children: []LayoutNode{deep}}
+	}
+	many := LayoutNode{Type: "row"}
+	for i := 0; i < 250; i++ {
+		many.Children = append(many.Children, LayoutNode{Type: "spacer"})
+	}
+
+	tests := []struct {
+		name   string
+		doc    Layout
+		expect string
+	}{
+		{"no size", Layout{Root: LayoutNode{Type: "spacer"}}, "width and height"},
+		{"too large", Layout{Width: 5000, Height: 100, Root: LayoutNode{Type: "spacer"}}, "width and height"},
+		{"unknown type", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "video"}}, "unknown node type"},
+		{"bad color", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "shape", Color: `red"/><script>`}}, "not a hex color"},
+		{"empty text", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "text"}}, "need text"},
+		{"negative size", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "shape", Width: -1}}, "sizes must be"},
+		{"gap too negative", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "row", Gap: -5000}}, "gaps must be"},
+		{"ring too wide", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "avatar", Size: 20, Ring: 10}}, "ring"},
+		{"bad ring color", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "avatar", RingColor: "white"}}, "not a hex color"},
+		{"children on a leaf", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "text", Text: "a", Children: []LayoutNode{{Type: "spacer"}}}}, "only rows and columns"},
+		{"bad align", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "row", Align: "middle"}}, "align"},
+		{"too deep", Layout{Width: 100, Height: 100, Root: deep}, "nested deeper"},
+		{"too many nodes", Layout{Width: 100, Height: 100, Root: many}, "more than"},
+		{"bad barcode", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "barcode", Text: "é"}}, "printable ASCII"},
+		{"long barcode", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "barcode", Text: strings.Repeat("1", 49)}}, "at most 48"},
+		{"dashed circle", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "shape", Shape: "circle", Width: 10, Height: 10, Dash: 2}}, "dash"},
+		{"unknown font", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "text", Text: "a", Font: "comic"}}, "unknown font"},
+		{"bad border style", Layout{Width: 100, Height: 100, Border: &LayoutBorder{Style: "dotted"}, Root: LayoutNode{Type: "spacer"}}, "border style"},
+		{"logo without a logo", Layout{Width: 100, Height: 100, Root: LayoutNode{Type: "logo"}}, "need a brand logo"},
+		{"unknown default font", Layout{Width: 100, Height: 100, Font: "comic", Root: LayoutNode{Type: "spacer"}}, "unknown font"},
+		{"bad border color", Layout{Width: 100, Height: 100, Border: &LayoutBorder{Color: "gold"}, Root: LayoutNode{Type: "spacer"}}, "not a hex color"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.LayoutScene(tt.doc)
+			if !errors.Is(err, ErrInvalidLayout) || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected an invalid layout error containing %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
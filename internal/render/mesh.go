@@ -0,0 +1,113 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// meshGridSize is the number of control points along each side of a mesh gradient.
+const meshGridSize = 3
+
+// meshControlColors assigns a seeded palette color to each point of the control grid,
+// avoiding repeating the color of the left and upper neighbors when possible.
+func meshControlColors(seed uint64, colors []string) [meshGridSize][meshGridSize]color.RGBA {
+	rng := seededRand(seed)
+	var grid [meshGridSize][meshGridSize]color.RGBA
+	var names [meshGridSize][meshGridSize]string
+	for y := 0; y < meshGridSize; y++ {
+		for x := 0; x < meshGridSize; x++ {
+			name := colors[rng.IntN(len(colors))]
+			for tries := 0; tries < 4 && len(colors) > 2; tries++ {
+				if (x == 0 || names[y][x-1] != name) && (y == 0 || names[y-1][x] != name) {
+					break
+				}
+				name = colors[rng.IntN(len(colors))]
+			}
+			names[y][x] = name
+			grid[y][x] = ParseHexColor(name).(color.RGBA)
+		}
+	}
+	return grid
+}
+
+// smoothstep eases t (0–1) so color transitions have no visible seams at grid lines.
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// meshGradientImage fills a w×h image by bilinear interpolation between control colors.
+func meshGradientImage(w, h int, grid [meshGridSize][meshGridSize]color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	lerp := func(a, b uint8, t float64) float64 { return float64(a) + (float64(b)-float64(a))*t }
+	cells := float64(meshGridSize - 1)
+
+	for y := 0; y < h; y++ {
+		fy := float64(y) / math.Max(1, float64(h-1)) * cells
+		j := min(int(fy), meshGridSize-2)
+		ty := smoothstep(fy - float64(j))
+		for x := 0; x < w; x++ {
+			fx := float64(x) / math.Max(1, float64(w-1)) * cells
+			i := min(int(fx), meshGridSize-2)
+			tx := smoothstep(fx - float64(i))
+
+			c00, c10 := grid[j][i], grid[j][i+1]
+			c01, c11 := grid[j+1][i], grid[j+1][i+1]
+			mix := func(a, b, c, d uint8) uint8 {
+				top := lerp(a, b, tx)
+				bottom := lerp(c, d, tx)
+				return uint8(math.Round(top + (bottom-top)*ty))
+			}
+			img.SetRGBA(x, y, color.RGBA{
+				R: mix(c00.R, c10.R, c01.R, c11.R),
+				G: mix(c00.G, c10.G, c01.G, c11.G),
+				B: mix(c00.B, c10.B, c01.B, c11.B),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// meshBackground draws a smooth multi-point mesh gradient. Raster output interpolates
+// exactly; SVG approximates it with heavily blurred circles at each control point.
+func meshBackground(w, h int, seed uint64, colors []string) background {
+	grid := meshControlColors(seed, colors)
+
+	return background{
+		svg: func(buf *bytes.Buffer) {
+			// Base layer in the average color so the blur never reveals gaps
+			var sum [3]int
+			for _, row := range grid {
+				for _, c := range row {
+					sum[0], sum[1], sum[2] = sum[0]+int(c.R), sum[1]+int(c.G), sum[2]+int(c.B)
+				}
+			}
+			n := meshGridSize * meshGridSize
+			buf.WriteString(fmt.Sprintf(`<defs><filter id="mesh_blur" x="-50%%" y="-50%%" width="200%%" height="200%%"><feGaussianBlur stdDeviation="%.1f" /></filter></defs>`, float64(min(w, h))*0.18))
+			buf.WriteString("\n")
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%02x%02x%02x" />`, w, h, sum[0]/n, sum[1]/n, sum[2]/n))
+			buf.WriteString("\n")
+
+			radius := float64(max(w, h)) / float64(meshGridSize-1) * 0.6
+			buf.WriteString(`<g filter="url(#mesh_blur)">`)
+			buf.WriteString("\n")
+			for y, row := range grid {
+				for x, c := range row {
+					cx := float64(w) * float64(x) / float64(meshGridSize-1)
+					cy := float64(h) * float64(y) / float64(meshGridSize-1)
+					buf.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="#%02x%02x%02x" />`, cx, cy, radius, c.R, c.G, c.B))
+					buf.WriteString("\n")
+				}
+			}
+			buf.WriteString("</g>\n")
+		},
+		raster: func(dc *gg.Context) {
+			dc.DrawImage(meshGradientImage(w, h, grid), 0, 0)
+		},
+	}
+}
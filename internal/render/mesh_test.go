@@ -0,0 +1,63 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMeshGradientImageHitsControlColors(t *testing.T) {
+	grid := meshControlColors(42, []string{"ff0000", "00ff00", "0000ff", "ffff00"})
+	img := meshGradientImage(101, 51, grid)
+
+	tests := []struct {
+		name   string
+		x, y   int
+		expect color.RGBA
+	}{
+		{"top left", 0, 0, grid[0][0]},
+		{"top right", 100, 0, grid[0][2]},
+		{"center", 50, 25, grid[1][1]},
+		{"bottom right", 100, 50, grid[2][2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := img.RGBAAt(tt.x, tt.y); got != tt.expect {
+				t.Fatalf("expected %v got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestMeshControlColorsSeeded(t *testing.T) {
+	colors := []string{"ff0000", "00ff00", "0000ff"}
+	if !reflect.DeepEqual(meshControlColors(1, colors), meshControlColors(1, colors)) {
+		t.Fatal("expected the same seed to pick the same colors")
+	}
+}
+
+func TestDrawMeshPatternSVG(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	opts := PatternOptions{Seed: 3, Colors: []string{"ff6b6b", "feca57", "48dbfb", "5f27cd"}}
+
+	data, err := r.DrawPattern(context.Background(), 600, 300, PatternMesh, opts, "", "ffffff", false, false, FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw mesh: %v", err)
+	}
+	svgStr := string(data)
+	if !strings.Contains(svgStr, "<feGaussianBlur") || strings.Count(svgStr, "<circle") != meshGridSize*meshGridSize {
+		t.Fatalf("expected blurred control circles, got: %s", svgStr)
+	}
+
+	again, _ := r.DrawPattern(context.Background(), 600, 300, PatternMesh, opts, "", "ffffff", false, false, FormatSVG)
+	if !bytes.Equal(data, again) {
+		t.Fatal("expected deterministic output")
+	}
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngHeaderEnd is the offset just past the IHDR chunk, which always comes first
+// and has a fixed 13-byte body.
+const pngHeaderEnd = 8 + 4 + 4 + 13 + 4
+
+// AddPNGText returns a copy of a PNG with a tEXt metadata chunk holding text
+// under keyword. The keyword must be 1-79 Latin-1 characters and text Latin-1.
+func AddPNGText(data []byte, keyword, text string) ([]byte, error) {
+	if len(data) < pngHeaderEnd || !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG image")
+	}
+	if len(keyword) == 0 || len(keyword) > 79 {
+		return nil, errors.New("PNG text keyword must be 1-79 characters")
+	}
+
+	body := append([]byte(keyword), 0)
+	body = append(body, text...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+	chunk = append(chunk, "tEXt"...)
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	out := make([]byte, 0, len(data)+len(chunk))
+	out = append(out, data[:pngHeaderEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[pngHeaderEnd:]...), nil
+}
+
+// AddSVGTitle returns a copy of an SVG labeled for assistive technology: the
+// root element gets role="img" and title as its aria-label, and starts with
+// <title> and <desc> elements. An empty desc leaves out <desc>. The SVG is
+// returned unchanged when it has no svg element with content.
+func AddSVGTitle(data []byte, title, desc string) []byte {
+	start := bytes.Index(data, []byte("<svg"))
+	if start < 0 {
+		return data
+	}
+	end := bytes.IndexByte(data[start:], '>')
+	if end < 0 || data[start+end-1] == '/' {
+		return data
+	}
+	end += start
+
+	var out bytes.Buffer
+	out.Grow(len(data) + 2*len(title) + len(desc) + 80)
+	out.Write(data[:end])
+	out.WriteString(` role="img" aria-label="` + escapeXML(title) + `">`)
+	out.WriteString("<title>" + escapeXML(title) + "</title>")
+	if desc != "" {
+		out.WriteString("<desc>" + escapeXML(desc) + "</desc>")
+	}
+	out.Write(data[end+1:])
+	return out.Bytes()
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestAddPNGText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	tagged, err := AddPNGText(buf.Bytes(), "Request-ID", "req-123")
+	if err != nil {
+		t.Fatalf("add text: %v", err)
+	}
+	if !bytes.Contains(tagged, []byte("tEXtRequest-ID\x00req-123")) {
+		t.Fatal("expected a tEXt chunk with the keyword and text")
+	}
+	// The decoder checks every chunk's CRC
+	if _, err := png.Decode(bytes.NewReader(tagged)); err != nil {
+		t.Fatalf("expected a valid PNG, got %v", err)
+	}
+
+	if _, err := AddPNGText([]byte("<svg/>"), "Request-ID", "req-123"); err == nil {
+		t.Error("expected an error for non-PNG data")
+	}
+	if _, err := AddPNGText(buf.Bytes(), "", "req-123"); err == nil {
+		t.Error("expected an error for an empty keyword")
+	}
+}
+
+func TestAddSVGTitle(t *testing.T) {
+	tests := []struct {
+		name   string
+		svg    string
+		title  string
+		desc   string
+		expect string
+	}{
+		{"title and desc", `<svg width="1"><rect /></svg>`, "Avatar with initials JD", "A 128 x 128 avatar",
+			`<svg width="1" role="img" aria-label="Avatar with initials JD"><title>Avatar with initials JD</title><desc>A 128 x 128 avatar</desc><rect /></svg>`},
+		{"no desc", `<svg><rect /></svg>`, "Logo", "", `<svg role="img" aria-label="Logo"><title>Logo</title><rect /></svg>`},
+		{"escaped", `<svg></svg>`, `<b>"Hi"</b>`, "", `<svg role="img" aria-label="&lt;b&gt;&quot;Hi&quot;&lt;/b&gt;"><title>&lt;b&gt;&quot;Hi&quot;&lt;/b&gt;</title></svg>`},
+		{"XML declaration", `<?xml version="1.0"?><svg></svg>`, "A", "", `<?xml version="1.0"?><svg role="img" aria-label="A"><title>A</title></svg>`},
+		{"not an SVG", `PNG`, "A", "", `PNG`},
+		{"empty root", `<svg/>`, "A", "", `<svg/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(AddSVGTitle([]byte(tt.svg), tt.title, tt.desc)); got != tt.expect {
+				t.Errorf("expected %s got %s", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	"grout/internal/render/genart"
+)
+
+// noisePaletteSize is the number of seeded colors blended into a noise photo.
+const noisePaletteSize = 4
+
+// noiseGrain is the largest brightness offset (of 255) film grain adds to a pixel.
+const noiseGrain = 12
+
+// MaxNoiseBlur is the strongest blur a noise photo accepts, matching Lorem Picsum.
+const MaxNoiseBlur = 10
+
+// NoiseOptions configures a noise photo.
+type NoiseOptions struct {
+	// Seed picks the palette, composition, and grain, so output is reproducible.
+	Seed uint64
+	// Grayscale drops the color.
+	Grayscale bool
+	// Blur softens the image, from 0 (sharp) to MaxNoiseBlur.
+	Blur int
+}
+
+// DrawNoisePhoto renders a seeded, photo-like stand-in image: a soft mesh gradient
+// in a seeded palette with film grain and a vignette. It is raster only.
+func DrawNoisePhoto(ctx context.Context, w, h int, opts NoiseOptions, format ImageFormat) ([]byte, error) {
+	if format == FormatSVG {
+		return nil, fmt.Errorf("%w: noise photos are raster only", ErrUnsupportedFormat)
+	}
+
+	img := meshGradientImage(w, h, meshControlColors(opts.Seed, genart.Palette(opts.Seed, noisePaletteSize)))
+	if opts.Blur > 0 {
+		// Scale the blur with the image so a level looks alike at any size
+		radius := max(1, min(opts.Blur, MaxNoiseBlur)*min(w, h)/100)
+		boxBlur(img, radius)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rng := seededRand(opts.Seed)
+	cx, cy := float64(w)/2, float64(h)/2
+	maxDist := math.Hypot(cx, cy)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, y)
+			// Darken towards the corners, like a lens vignette
+			shade := vignetteShade(math.Hypot(float64(x)-cx, float64(y)-cy), maxDist)
+			grain := float64(rng.IntN(2*noiseGrain+1) - noiseGrain)
+
+			r, g, b := float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2])
+			if opts.Grayscale {
+				luma := 0.299*r + 0.587*g + 0.114*b
+				r, g, b = luma, luma, luma
+			}
+			img.Pix[i] = clampByte(r*shade + grain)
+			img.Pix[i+1] = clampByte(g*shade + grain)
+			img.Pix[i+2] = clampByte(b*shade + grain)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return encodeImage(ctx, img, format)
+}
+
+// boxBlur approximates a Gaussian blur of img in place with three box blur passes
+// in each direction.
+func boxBlur(img *image.RGBA, radius int) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	line := make([]uint8, max(w, h)*4)
+	for pass := 0; pass < 3; pass++ {
+		for y := 0; y < h; y++ {
+			blurLine(img.Pix[img.PixOffset(0, y):], 4, w, radius, line)
+		}
+		for x := 0; x < w; x++ {
+			blurLine(img.Pix[img.PixOffset(x, 0):], img.Stride, h, radius, line)
+		}
+	}
+}
+
+// blurLine box-blurs n RGBA pixels that are stride bytes apart, using a running
+// sum over a window of radius pixels on each side. scratch holds a copy of the line.
+// Alpha is blurred with the colors, so premultiplied pixels stay consistent.
+func blurLine(pix []uint8, stride, n, radius int, scratch []uint8) {
+	for i := 0; i < n; i++ {
+		copy(scratch[i*4:i*4+4], pix[i*stride:i*stride+4])
+	}
+	for c := 0; c < 4; c++ {
+		var sum int
+		// Edge pixels repeat beyond the ends of the line
+		at := func(i int) int { return int(scratch[max(0, min(i, n-1))*4+c]) }
+		for i := -radius; i <= radius; i++ {
+			sum += at(i)
+		}
+		for i := 0; i < n; i++ {
+			pix[i*stride+c] = uint8(sum / (2*radius + 1))
+			sum += at(i+radius+1) - at(i-radius)
+		}
+	}
+}
+
+// clampByte rounds v to the nearest byte value.
+func clampByte(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
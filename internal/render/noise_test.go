@@ -0,0 +1,77 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func decodeNoisePhoto(t *testing.T, w, h int, opts NoiseOptions) image.Image {
+	t.Helper()
+	data, err := DrawNoisePhoto(context.Background(), w, h, opts, FormatPNG)
+	if err != nil {
+		t.Fatalf("DrawNoisePhoto: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return img
+}
+
+func TestDrawNoisePhotoSeeded(t *testing.T) {
+	first, _ := DrawNoisePhoto(context.Background(), 120, 80, NoiseOptions{Seed: 7}, FormatPNG)
+	again, _ := DrawNoisePhoto(context.Background(), 120, 80, NoiseOptions{Seed: 7}, FormatPNG)
+	other, _ := DrawNoisePhoto(context.Background(), 120, 80, NoiseOptions{Seed: 8}, FormatPNG)
+
+	if !bytes.Equal(first, again) {
+		t.Fatal("expected the same seed to render the same photo")
+	}
+	if bytes.Equal(first, other) {
+		t.Fatal("expected different seeds to render different photos")
+	}
+	if b := decodeNoisePhoto(t, 120, 80, NoiseOptions{Seed: 7}).Bounds(); b.Dx() != 120 || b.Dy() != 80 {
+		t.Fatalf("expected 120x80 got %v", b)
+	}
+}
+
+func TestDrawNoisePhotoGrayscale(t *testing.T) {
+	img := decodeNoisePhoto(t, 40, 40, NoiseOptions{Seed: 3, Grayscale: true})
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if r != g || g != b {
+				t.Fatalf("expected a gray pixel at %d,%d, got %d,%d,%d", x, y, r>>8, g>>8, b>>8)
+			}
+		}
+	}
+}
+
+func TestDrawNoisePhotoBlurSmooths(t *testing.T) {
+	// Sum the differences between neighbors; blurring should shrink it
+	roughness := func(img image.Image) uint64 {
+		var total uint64
+		for y := 0; y < 60; y++ {
+			for x := 1; x < 60; x++ {
+				a, _, _, _ := img.At(x-1, y).RGBA()
+				b, _, _, _ := img.At(x, y).RGBA()
+				total += uint64(max(a, b) - min(a, b))
+			}
+		}
+		return total
+	}
+
+	sharp := roughness(decodeNoisePhoto(t, 60, 60, NoiseOptions{Seed: 5, Grayscale: true}))
+	blurred := roughness(decodeNoisePhoto(t, 60, 60, NoiseOptions{Seed: 5, Grayscale: true, Blur: 10}))
+	if blurred >= sharp {
+		t.Fatalf("expected blur to smooth the image, got %d >= %d", blurred, sharp)
+	}
+}
+
+func TestDrawNoisePhotoRejectsSVG(t *testing.T) {
+	if _, err := DrawNoisePhoto(context.Background(), 10, 10, NoiseOptions{}, FormatSVG); err == nil {
+		t.Fatal("expected an error for SVG output")
+	}
+}
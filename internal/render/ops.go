@@ -0,0 +1,261 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+
+	"grout/internal/config"
+)
+
+// ErrInvalidOps is returned when an ops string can't be parsed.
+var ErrInvalidOps = errors.New("invalid ops")
+
+// OpKind names a stage of an ops pipeline.
+type OpKind string
+
+const (
+	OpFill   OpKind = "fill"
+	OpColor  OpKind = "color"
+	OpText   OpKind = "text"
+	OpBorder OpKind = "border"
+	OpBlur   OpKind = "blur"
+	OpEffect OpKind = "effect"
+)
+
+// Op is one stage of an ops pipeline. Kind selects which fields apply:
+//   - OpFill paints the canvas with Colors, a left-to-right gradient when there
+//     are two
+//   - OpColor sets the ink of later text and border stages to Colors[0]
+//   - OpText draws Text centered on the canvas
+//   - OpBorder frames the canvas with lines Amount pixels thick
+//   - OpBlur blurs everything drawn so far by Amount pixels
+//   - OpEffect applies Effect: scattered shapes, or a filter of everything
+//     drawn so far
+type Op struct {
+	Kind   OpKind
+	Colors []string
+	Text   string
+	Amount int
+	Effect Effect
+}
+
+// ParseOps parses an ops string for a w x h canvas: stages separated by commas,
+// each a name with arguments after colons, such as "fill:navy,text:Hi,blur:2".
+// Every stage is checked, and errors wrap ErrInvalidOps and name the stage.
+//
+//	fill:<color>[:<color>]   color:<color>   text:<text>   border:<px>   blur:<px>
+//	confetti   sparkle   vignette   halftone   dither
+//
+// Colors are hex or CSS color names. Text runs to the next comma, colons and
+// all, so it can't itself hold a comma.
+func ParseOps(s string, w, h int) ([]Op, error) {
+	stages := strings.Split(s, ",")
+	if len(stages) > config.MaxOps {
+		return nil, fmt.Errorf("%w: %d stages, at most %d are allowed", ErrInvalidOps, len(stages), config.MaxOps)
+	}
+	ops := make([]Op, 0, len(stages))
+	for i, stage := range stages {
+		op, err := parseOp(stage, w, h)
+		if err != nil {
+			return nil, fmt.Errorf("%w: stage %d (%q): %v", ErrInvalidOps, i+1, stage, err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// parseOp parses one stage of an ops string.
+func parseOp(stage string, w, h int) (Op, error) {
+	name, arg, hasArg := strings.Cut(stage, ":")
+	var args []string
+	if hasArg {
+		args = strings.Split(arg, ":")
+	}
+	wantArgs := func(lo, hi int) error {
+		if len(args) < lo || len(args) > hi {
+			if hi == 1 {
+				return fmt.Errorf("%s takes one argument, got %d", name, len(args))
+			}
+			return fmt.Errorf("%s takes %d to %d arguments, got %d", name, lo, hi, len(args))
+		}
+		return nil
+	}
+	pixels := func(limit int) (int, error) {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > limit {
+			return 0, fmt.Errorf("%s must be a number of pixels from 1 to %d", name, limit)
+		}
+		return n, nil
+	}
+
+	switch kind := OpKind(name); kind {
+	case OpFill, OpColor:
+		maxColors := 1
+		if kind == OpFill {
+			maxColors = 2
+		}
+		if err := wantArgs(1, maxColors); err != nil {
+			return Op{}, err
+		}
+		op := Op{Kind: kind}
+		for _, a := range args {
+			hex, ok := ResolveColor(a)
+			if !ok {
+				return Op{}, fmt.Errorf("%q is not a hex color or color name", a)
+			}
+			op.Colors = append(op.Colors, strings.ToLower(hex))
+		}
+		return op, nil
+	case OpText:
+		if arg == "" {
+			return Op{}, fmt.Errorf("text needs something to draw")
+		}
+		return Op{Kind: kind, Text: arg}, nil
+	case OpBorder, OpBlur:
+		if err := wantArgs(1, 1); err != nil {
+			return Op{}, err
+		}
+		limit := config.MaxOpsBlur
+		if kind == OpBorder {
+			limit = max(min(w, h)/2, 1)
+		}
+		n, err := pixels(limit)
+		return Op{Kind: kind, Amount: n}, err
+	}
+
+	if effect := Effect(name); effect.IsValid() {
+		if hasArg {
+			return Op{}, fmt.Errorf("%s takes no arguments", name)
+		}
+		return Op{Kind: OpEffect, Effect: effect}, nil
+	}
+	return Op{}, fmt.Errorf("unknown stage %q; use fill, color, text, border, blur, confetti, sparkle, vignette, halftone, or dither", name)
+}
+
+// opsState tracks what earlier stages of a pipeline set for later ones.
+type opsState struct {
+	background Background // The last fill, for contrast and effect colors
+	ink        string     // Set by a color stage; empty picks a contrasting color
+}
+
+// inkColor returns the color text and borders are drawn in.
+func (st opsState) inkColor() string {
+	switch {
+	case st.ink != "":
+		return st.ink
+	case st.background.Color == "":
+		return "000000"
+	case st.background.GradientTo != "":
+		return GetContrastColor(st.background.Color + "," + st.background.GradientTo)
+	}
+	return GetContrastColor(st.background.Color)
+}
+
+// opLayer returns the scene a drawing stage adds to the canvas, and updates st.
+// Blur and filter stages draw nothing and return false.
+func opLayer(op Op, w, h int, seed uint64, st *opsState) (Scene, bool) {
+	layer := Scene{Width: w, Height: h}
+	switch op.Kind {
+	case OpFill:
+		layer.Background = Background{Color: op.Colors[0]}
+		if len(op.Colors) == 2 {
+			layer.Background.GradientTo = op.Colors[1]
+		}
+		st.background = layer.Background
+	case OpColor:
+		st.ink = op.Colors[0]
+		return layer, false
+	case OpText:
+		layer.Text = labelScene(w, h, "", st.inkColor(), op.Text, false, true, LabelFontSize(w, h, op.Text), false, nil).Text
+	case OpBorder:
+		inset := float64(op.Amount) / 2
+		layer.Shapes = []Shape{{X: inset, Y: inset, Width: float64(w) - 2*inset, Height: float64(h) - 2*inset, Color: st.inkColor(), Stroke: float64(op.Amount)}}
+	case OpEffect:
+		if op.Effect.IsFilter() {
+			return layer, false
+		}
+		layer.Overlay = effectShapes(op.Effect, seed, Scene{Width: w, Height: h, Background: st.background})
+	default:
+		return layer, false
+	}
+	return layer, true
+}
+
+// DrawOps runs an ops pipeline on a transparent w x h canvas, stage by stage in
+// order, so a blur or filter applies to what's drawn before it and not after.
+// Effects are placed by seed. Halftone and dither have no vector form, so SVG
+// output fails with ErrUnsupportedFormat when they're used.
+func (r *Renderer) DrawOps(ctx context.Context, w, h int, ops []Op, seed uint64, format ImageFormat) ([]byte, error) {
+	if format == FormatSVG {
+		return r.opsSVG(w, h, ops, seed)
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+	var st opsState
+	for _, op := range ops {
+		if layer, ok := opLayer(op, w, h, seed, &st); ok {
+			img, err := r.rasterizeScene(ctx, layer)
+			if err != nil {
+				return nil, err
+			}
+			draw.Draw(canvas, canvas.Bounds(), img, image.Point{}, draw.Over)
+			continue
+		}
+		switch {
+		case op.Kind == OpBlur:
+			boxBlur(canvas, op.Amount)
+		case op.Kind == OpEffect:
+			applyFilter(canvas, Scene{Width: w, Height: h, Filter: op.Effect})
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return encodeImage(ctx, canvas, format)
+}
+
+// opsSVG writes an ops pipeline as an SVG document. A blur wraps everything
+// drawn before it in a group with a Gaussian blur as strong as the raster one.
+func (r *Renderer) opsSVG(w, h int, ops []Op, seed uint64) ([]byte, error) {
+	var body bytes.Buffer
+	var st opsState
+	for i, op := range ops {
+		if layer, ok := opLayer(op, w, h, seed, &st); ok {
+			layer.writeSVGBody(&body)
+			continue
+		}
+		switch {
+		case op.Kind == OpBlur:
+			// Three box blur passes of radius r blur like a Gaussian with this deviation
+			deviation := math.Sqrt(float64(op.Amount * (op.Amount + 1)))
+			var wrapped bytes.Buffer
+			wrapped.WriteString(fmt.Sprintf(`<defs><filter id="blur_%d" x="0" y="0" width="%d" height="%d" filterUnits="userSpaceOnUse"><feGaussianBlur stdDeviation="%s" edgeMode="duplicate" /></filter></defs>`,
+				i, w, h, svgNumber(deviation)))
+			wrapped.WriteString("\n")
+			wrapped.WriteString(fmt.Sprintf(`<g filter="url(#blur_%d)">`, i))
+			wrapped.WriteString("\n")
+			wrapped.Write(body.Bytes())
+			wrapped.WriteString("</g>\n")
+			body = wrapped
+		case op.Kind == OpEffect && op.Effect.IsRasterOnly():
+			return nil, fmt.Errorf("%w: the %s stage is raster only", ErrUnsupportedFormat, op.Effect)
+		case op.Kind == OpEffect && op.Effect == EffectVignette:
+			writeSVGVignette(&body, Scene{Width: w, Height: h})
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+	buf.WriteString("\n")
+	buf.Write(body.Bytes())
+	buf.WriteString("</svg>")
+	return buf.Bytes(), nil
+}
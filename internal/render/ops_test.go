@@ -0,0 +1,108 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOps(t *testing.T) {
+	tests := []struct {
+		name   string
+		ops    string
+		expect []Op
+		err    string
+	}{
+		{
+			name: "every stage",
+			ops:  "fill:navy:00ffff,color:gold,text:Hi: there,border:4,blur:2,confetti,dither",
+			expect: []Op{
+				{Kind: OpFill, Colors: []string{"000080", "00ffff"}},
+				{Kind: OpColor, Colors: []string{"ffd700"}},
+				{Kind: OpText, Text: "Hi: there"},
+				{Kind: OpBorder, Amount: 4},
+				{Kind: OpBlur, Amount: 2},
+				{Kind: OpEffect, Effect: EffectConfetti},
+				{Kind: OpEffect, Effect: EffectDither},
+			},
+		},
+		{name: "unknown stage", ops: "fill:red,wobble", err: `stage 2 ("wobble"): unknown stage`},
+		{name: "empty stage", ops: "fill:red,", err: "stage 2"},
+		{name: "bad color", ops: "fill:nope", err: `"nope" is not a hex color`},
+		{name: "too many colors", ops: "color:red:blue", err: "color takes one argument, got 2"},
+		{name: "blur too strong", ops: "blur:50", err: "from 1 to 20"},
+		{name: "border wider than half", ops: "border:60", err: "from 1 to 50"},
+		{name: "effect with argument", ops: "sparkle:3", err: "sparkle takes no arguments"},
+		{name: "empty text", ops: "text:", err: "text needs something to draw"},
+		{name: "too many stages", ops: strings.Repeat("vignette,", 16) + "vignette", err: "17 stages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := ParseOps(tt.ops, 200, 100)
+			if tt.err != "" {
+				if !errors.Is(err, ErrInvalidOps) || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an ErrInvalidOps containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(ops, tt.expect) {
+				t.Errorf("expected %+v got %+v", tt.expect, ops)
+			}
+		})
+	}
+}
+
+func TestDrawOps(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	ctx := context.Background()
+	parse := func(s string) []Op {
+		ops, err := ParseOps(s, 200, 100)
+		if err != nil {
+			t.Fatalf("parse %q: %v", s, err)
+		}
+		return ops
+	}
+
+	// A blur applies to what's drawn before it, so the text stays sharp
+	svg, err := r.DrawOps(ctx, 200, 100, parse("fill:navy,blur:2,text:Hi"), 1, FormatSVG)
+	if err != nil {
+		t.Fatalf("render svg: %v", err)
+	}
+	if group, text := strings.Index(string(svg), "</g>"), strings.Index(string(svg), ">Hi</text>"); group < 0 || text < group {
+		t.Errorf("expected the text after the blurred group in:\n%s", svg)
+	}
+	if !strings.Contains(string(svg), `fill="#ffffff"`) {
+		t.Errorf("expected text contrasting with the fill in:\n%s", svg)
+	}
+
+	data, err := r.DrawOps(ctx, 200, 100, parse("fill:red,border:10,color:blue"), 1, FormatPNG)
+	if err != nil {
+		t.Fatalf("render png: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// The border is drawn before the color stage, so it contrasts with the fill
+	if r, g, b, _ := img.At(2, 50).RGBA(); r>>8 != 255 || g>>8 != 255 || b>>8 != 255 {
+		t.Errorf("expected a white border, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+	if r, g, b, _ := img.At(100, 50).RGBA(); r>>8 != 255 || g>>8 != 0 || b>>8 != 0 {
+		t.Errorf("expected a red fill, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+
+	if _, err := r.DrawOps(ctx, 200, 100, parse("fill:red,halftone"), 1, FormatSVG); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for halftone in SVG, got %v", err)
+	}
+}
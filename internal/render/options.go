@@ -0,0 +1,98 @@
+package render
+
+import (
+	"context"
+
+	"grout/internal/config"
+)
+
+// Options describe a label image: text, such as an avatar's initials or a
+// placeholder's size, centered on a background. They're plain values, so a
+// shared set of defaults can be copied and varied per request without locking.
+type Options struct {
+	Width, Height int
+	// Background is a hex color, or two separated by a comma for a gradient
+	Background string
+	Foreground string
+	Text       string
+	Rounded    bool
+	Bold       bool
+	// FontSize is the text's size in pixels; 0 sizes it with LabelFontSize
+	FontSize float64
+	Format   ImageFormat
+}
+
+// Option sets fields of Options.
+type Option func(*Options)
+
+// NewOptions returns the default options, a config.DefaultSize square SVG
+// avatar in the default avatar colors, with opts applied in order.
+func NewOptions(opts ...Option) Options {
+	o := Options{
+		Width:      config.DefaultSize,
+		Height:     config.DefaultSize,
+		Background: config.DefaultAvatarBg,
+		Foreground: config.DefaultAvatarFg,
+		Format:     FormatSVG,
+	}
+	return o.With(opts...)
+}
+
+// With returns a copy of o with opts applied in order. o itself is unchanged.
+func (o Options) With(opts ...Option) Options {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithOptions replaces all the options with o, as a base for the options after it.
+func WithOptions(o Options) Option {
+	return func(dst *Options) { *dst = o }
+}
+
+// WithSize sets the width and height in pixels.
+func WithSize(w, h int) Option {
+	return func(o *Options) { o.Width, o.Height = w, h }
+}
+
+// WithColors sets the background and text colors.
+func WithColors(bgHex, fgHex string) Option {
+	return func(o *Options) { o.Background, o.Foreground = bgHex, fgHex }
+}
+
+// WithText sets the text.
+func WithText(text string) Option {
+	return func(o *Options) { o.Text = text }
+}
+
+// WithRounded sets whether the image is a circle rather than a square.
+func WithRounded(rounded bool) Option {
+	return func(o *Options) { o.Rounded = rounded }
+}
+
+// WithBold sets whether the text is bold.
+func WithBold(bold bool) Option {
+	return func(o *Options) { o.Bold = bold }
+}
+
+// WithFontSize sets the text's size in pixels; 0 sizes it with LabelFontSize.
+func WithFontSize(size float64) Option {
+	return func(o *Options) { o.FontSize = size }
+}
+
+// WithFormat sets the output format.
+func WithFormat(format ImageFormat) Option {
+	return func(o *Options) { o.Format = format }
+}
+
+// DrawLabel renders a single-line label image, starting from NewOptions with
+// opts applied.
+func (r *Renderer) DrawLabel(ctx context.Context, opts ...Option) ([]byte, error) {
+	o := NewOptions(opts...)
+	fontSize := o.FontSize
+	if fontSize == 0 {
+		fontSize = LabelFontSize(o.Width, o.Height, o.Text)
+	}
+	return r.RenderScene(ctx, labelScene(o.Width, o.Height, o.Background, o.Foreground, o.Text, o.Rounded, o.Bold, fontSize, false, nil), o.Format)
+}
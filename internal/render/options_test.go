@@ -0,0 +1,84 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"grout/internal/config"
+)
+
+func TestNewOptions(t *testing.T) {
+	defaults := NewOptions()
+	want := Options{Width: config.DefaultSize, Height: config.DefaultSize, Background: config.DefaultAvatarBg, Foreground: config.DefaultAvatarFg, Format: FormatSVG}
+	if defaults != want {
+		t.Fatalf("expected %+v, got %+v", want, defaults)
+	}
+
+	base := NewOptions(WithSize(64, 32), WithText("AB"), WithBold(true), WithFormat(FormatPNG))
+	varied := base.With(WithColors("000000", "ffffff"), WithRounded(true), WithFontSize(20))
+	if base.Background != config.DefaultAvatarBg || base.Rounded || base.FontSize != 0 {
+		t.Fatalf("expected With to leave the base unchanged, got %+v", base)
+	}
+	want = Options{Width: 64, Height: 32, Background: "000000", Foreground: "ffffff", Text: "AB", Rounded: true, Bold: true, FontSize: 20, Format: FormatPNG}
+	if varied != want {
+		t.Fatalf("expected %+v, got %+v", want, varied)
+	}
+	if got := NewOptions(WithText("X"), WithOptions(base), WithRounded(true)); got.Text != "AB" || !got.Rounded {
+		t.Fatalf("expected WithOptions to replace the options before it only, got %+v", got)
+	}
+}
+
+func TestDrawLabelMatchesPositional(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, format := range []ImageFormat{FormatSVG, FormatPNG} {
+		positional, err := r.DrawImageWithFormat(ctx, 96, 96, "ff5733", "ffffff", "JD", true, true, format)
+		if err != nil {
+			t.Fatalf("draw %s: %v", format, err)
+		}
+		label, err := r.DrawLabel(ctx, WithSize(96, 96), WithColors("ff5733", "ffffff"), WithText("JD"), WithRounded(true), WithBold(true), WithFormat(format))
+		if err != nil {
+			t.Fatalf("draw label %s: %v", format, err)
+		}
+		if !bytes.Equal(positional, label) {
+			t.Errorf("%s: expected DrawLabel to draw what DrawImageWithFormat does", format)
+		}
+	}
+}
+
+func TestDrawLabelSharedOptions(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	base := NewOptions(WithSize(48, 48), WithFormat(FormatPNG))
+
+	// Requests vary a shared base at once; each sees only its own options
+	texts := []string{"AB", "CD", "EF", "GH"}
+	results := make([][]byte, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = r.DrawLabel(context.Background(), WithOptions(base), WithText(text))
+		}()
+	}
+	wg.Wait()
+
+	for i, text := range texts {
+		want, err := r.DrawLabel(context.Background(), WithOptions(base), WithText(text))
+		if err != nil {
+			t.Fatalf("draw %s: %v", text, err)
+		}
+		if !bytes.Equal(results[i], want) {
+			t.Errorf("%s: expected the same image drawn alone and at once", text)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/fogleman/gg"
+)
+
+// DrawPaletteStrip renders colors as equal-width vertical swatches across a w×h image.
+func (r *Renderer) DrawPaletteStrip(ctx context.Context, colors []string, w, h int, format ImageFormat) ([]byte, error) {
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("palette strip: no colors")
+	}
+	swatchWidth := float64(w) / float64(len(colors))
+
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+		buf.WriteString("\n")
+		for i, hex := range colors {
+			buf.WriteString(fmt.Sprintf(`<rect x="%.2f" width="%.2f" height="%d" fill="#%s" />`, float64(i)*swatchWidth, swatchWidth, h, hex))
+			buf.WriteString("\n")
+		}
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(w, h)
+	for i, hex := range colors {
+		dc.SetColor(ParseHexColor(hex))
+		dc.DrawRectangle(float64(i)*swatchWidth, 0, swatchWidth, float64(h))
+		dc.Fill()
+	}
+	return encodeImage(ctx, dc.Image(), format)
+}
@@ -0,0 +1,33 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDrawPaletteStrip(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	data, err := r.DrawPaletteStrip(context.Background(), []string{"ff0000", "00ff00", "0000ff"}, 300, 50, FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw strip: %v", err)
+	}
+	svgStr := string(data)
+	if got := strings.Count(svgStr, "<rect"); got != 3 {
+		t.Fatalf("expected 3 swatches, got %d", got)
+	}
+	if !strings.Contains(svgStr, `x="200.00" width="100.00"`) {
+		t.Fatalf("expected third swatch at x=200, got: %s", svgStr)
+	}
+
+	if _, err := r.DrawPaletteStrip(context.Background(), []string{"ff0000"}, 100, 20, FormatPNG); err != nil {
+		t.Fatalf("failed to draw PNG strip: %v", err)
+	}
+	if _, err := r.DrawPaletteStrip(context.Background(), nil, 100, 20, FormatPNG); err == nil {
+		t.Fatal("expected error for empty palette")
+	}
+}
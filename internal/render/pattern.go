@@ -0,0 +1,158 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/fogleman/gg"
+
+	"grout/internal/render/genart"
+)
+
+// Pattern names a decorative background drawn behind avatar or placeholder text.
+type Pattern string
+
+const (
+	PatternLowPoly Pattern = "lowpoly"
+	PatternMesh    Pattern = "mesh"
+	PatternIsoGrid Pattern = "isogrid"
+	PatternDots    Pattern = "dots"
+)
+
+// Patterns lists the supported background patterns.
+func Patterns() []Pattern {
+	return []Pattern{PatternLowPoly, PatternMesh, PatternIsoGrid, PatternDots}
+}
+
+// IsValid reports whether p is a supported pattern.
+func (p Pattern) IsValid() bool {
+	for _, known := range Patterns() {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTiled reports whether p is a regular tiled grid rather than a seeded composition.
+func (p Pattern) IsTiled() bool {
+	return p == PatternIsoGrid || p == PatternDots
+}
+
+// PatternOptions configures pattern generation.
+type PatternOptions struct {
+	// Seed makes randomized patterns reproducible.
+	Seed uint64
+	// Colors are the pattern colors; patterns use as many as they need.
+	Colors []string
+	// Spacing is the grid spacing in pixels for tiled patterns (0 for the default).
+	Spacing int
+	// LineColor colors the lines and dots of tiled patterns (empty for a faint default).
+	LineColor string
+}
+
+// seededRand returns a deterministic random source for seed.
+func seededRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// background paints an image background in both output modes.
+type background struct {
+	svg    func(buf *bytes.Buffer)
+	raster func(dc *gg.Context)
+}
+
+// shapesBackground draws a list of generated shapes.
+func shapesBackground(shapes []genart.Shape) background {
+	return background{
+		svg:    func(buf *bytes.Buffer) { writeSVGShapes(buf, shapes) },
+		raster: func(dc *gg.Context) { fillShapes(dc, shapes) },
+	}
+}
+
+// patternBackground builds the background for pattern.
+func patternBackground(w, h int, pattern Pattern, opts PatternOptions) (background, error) {
+	if len(opts.Colors) == 0 {
+		return background{}, fmt.Errorf("pattern %s: no colors", pattern)
+	}
+	switch pattern {
+	case PatternLowPoly:
+		color1, color2 := opts.Colors[0], opts.Colors[0]
+		if len(opts.Colors) > 1 {
+			color2 = opts.Colors[1]
+		}
+		return shapesBackground(genart.LowPoly(w, h, opts.Seed, color1, color2)), nil
+	case PatternMesh:
+		return meshBackground(w, h, opts.Seed, opts.Colors), nil
+	case PatternIsoGrid:
+		return isoGridBackground(w, h, opts), nil
+	case PatternDots:
+		return dotsBackground(w, h, opts), nil
+	default:
+		return background{}, fmt.Errorf("unknown pattern: %s", pattern)
+	}
+}
+
+// DrawPattern renders a patterned background with optional centered text on top.
+func (r *Renderer) DrawPattern(ctx context.Context, w, h int, pattern Pattern, opts PatternOptions, text, fgHex string, rounded, bold bool, format ImageFormat) ([]byte, error) {
+	bg, err := patternBackground(w, h, pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.drawBackgroundWithLabel(ctx, w, h, bg, text, fgHex, rounded, bold, format)
+}
+
+// drawBackgroundWithLabel renders bg across a w×h image, clipped to a circle when
+// rounded, and draws text centered on top in the label font size. Empty text draws
+// no label.
+func (r *Renderer) drawBackgroundWithLabel(ctx context.Context, w, h int, bg background, text, fgHex string, rounded, bold bool, format ImageFormat) ([]byte, error) {
+	fontSize := LabelFontSize(w, h, text)
+	radius := min(w, h) / 2
+	fontWeight := "normal"
+	if bold {
+		fontWeight = "bold"
+	}
+
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+		buf.WriteString("\n")
+		if rounded {
+			buf.WriteString(fmt.Sprintf(`<defs><clipPath id="bg_clip"><circle cx="%d" cy="%d" r="%d" /></clipPath></defs>`, w/2, h/2, radius))
+			buf.WriteString("\n")
+			buf.WriteString(`<g clip-path="url(#bg_clip)">`)
+			buf.WriteString("\n")
+		}
+		bg.svg(&buf)
+		if rounded {
+			buf.WriteString("</g>\n")
+		}
+		if text != "" {
+			buf.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-family="sans-serif" font-size="%.0f" font-weight="%s" fill="#%s" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+				w/2, h/2, fontSize, fontWeight, fgHex, escapeXML(text)))
+			buf.WriteString("\n")
+		}
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(w, h)
+	if rounded {
+		dc.DrawCircle(float64(w)/2, float64(h)/2, float64(radius))
+		dc.Clip()
+	}
+	bg.raster(dc)
+	dc.ResetClip()
+	if text != "" {
+		font := r.regular
+		if bold {
+			font = r.bold
+		}
+		dc.SetFontFace(textOptions(ctx).face(font, fontSize))
+		dc.SetColor(ParseHexColor(fgHex))
+		dc.DrawStringAnchored(text, float64(w)/2, float64(h)/2, 0.5, 0.5)
+	}
+	return encodeImage(ctx, dc.Image(), format)
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDrawPattern(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	opts := PatternOptions{Seed: 42, Colors: []string{"1e3c72", "2a5298"}}
+
+	for _, pattern := range Patterns() {
+		for _, format := range []ImageFormat{FormatSVG, FormatPNG, FormatJPG} {
+			t.Run(string(pattern)+"/"+string(format), func(t *testing.T) {
+				data, err := r.DrawPattern(context.Background(), 300, 200, pattern, opts, "300 x 200", "ffffff", false, false, format)
+				if err != nil {
+					t.Fatalf("failed to draw pattern: %v", err)
+				}
+				if len(data) == 0 {
+					t.Fatal("expected image data, got empty")
+				}
+			})
+		}
+	}
+}
+
+func TestDrawPatternRoundedSVG(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	data, err := r.DrawPattern(context.Background(), 128, 128, PatternLowPoly, PatternOptions{Seed: 1, Colors: []string{"000000"}}, "JD", "ffffff", true, true, FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw pattern: %v", err)
+	}
+	svgStr := string(data)
+	for _, want := range []string{`<clipPath id="bg_clip">`, `clip-path="url(#bg_clip)"`, "<polygon", `font-weight="bold"`, ">JD</text>"} {
+		if !strings.Contains(svgStr, want) {
+			t.Errorf("expected SVG to contain %q", want)
+		}
+	}
+}
+
+func TestDrawPatternErrors(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	if _, err := r.DrawPattern(context.Background(), 100, 100, "plaid", PatternOptions{Colors: []string{"000000"}}, "", "ffffff", false, false, FormatSVG); err == nil {
+		t.Fatal("expected error for unknown pattern")
+	}
+	if _, err := r.DrawPattern(context.Background(), 100, 100, PatternLowPoly, PatternOptions{}, "", "ffffff", false, false, FormatSVG); err == nil {
+		t.Fatal("expected error for missing colors")
+	}
+}
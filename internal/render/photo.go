@@ -0,0 +1,96 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/draw"
+)
+
+// DrawPhotoAvatar crops img around its most salient region into a size×size avatar,
+// optionally masked to a circle. SVG output embeds the cropped photo as a PNG.
+func DrawPhotoAvatar(ctx context.Context, img image.Image, size int, rounded bool, format ImageFormat) ([]byte, error) {
+	photo, err := cropPhotoAvatar(ctx, img, size)
+	if err != nil {
+		return nil, err
+	}
+	return encodePhotoAvatar(ctx, nil, photo, rounded, nil, format)
+}
+
+// DrawPhotoAvatarWithInitials draws initials over the center of a photo avatar.
+// An empty fgHex picks black or white, whichever contrasts with the cropped photo.
+func (r *Renderer) DrawPhotoAvatarWithInitials(ctx context.Context, img image.Image, size int, rounded, bold bool, initials, fgHex string, format ImageFormat) ([]byte, error) {
+	photo, err := cropPhotoAvatar(ctx, img, size)
+	if err != nil {
+		return nil, err
+	}
+	if fgHex == "" {
+		fgHex = ImageContrastColor(photo)
+	}
+	run := TextRun{
+		Text:  initials,
+		X:     float64(size) / 2,
+		Y:     float64(size) / 2,
+		Size:  LabelFontSize(size, size, initials),
+		Bold:  bold,
+		Color: fgHex,
+	}
+	return encodePhotoAvatar(ctx, r, photo, rounded, []TextRun{run}, format)
+}
+
+// cropPhotoAvatar scales the most salient size×size region of img.
+func cropPhotoAvatar(ctx context.Context, img image.Image, size int) (*image.RGBA, error) {
+	scaled := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, SmartCrop(img, size, size), draw.Src, nil)
+	return scaled, ctx.Err()
+}
+
+// encodePhotoAvatar encodes a cropped photo as an avatar with runs drawn on top.
+// r draws the runs, and may be nil when there are none.
+func encodePhotoAvatar(ctx context.Context, r *Renderer, photo *image.RGBA, rounded bool, runs []TextRun, format ImageFormat) ([]byte, error) {
+	size := photo.Bounds().Dx()
+	if format == FormatSVG {
+		var encoded bytes.Buffer
+		if err := png.Encode(&encoded, photo); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+		href := "data:image/png;base64," + base64.StdEncoding.EncodeToString(encoded.Bytes())
+
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, size, size))
+		buf.WriteString("\n")
+		if rounded {
+			buf.WriteString(fmt.Sprintf(`<defs><clipPath id="avatar_clip"><circle cx="%d" cy="%d" r="%d" /></clipPath></defs>`, size/2, size/2, size/2))
+			buf.WriteString("\n")
+			buf.WriteString(fmt.Sprintf(`<image width="%d" height="%d" href="%s" clip-path="url(#avatar_clip)" />`, size, size, href))
+		} else {
+			buf.WriteString(fmt.Sprintf(`<image width="%d" height="%d" href="%s" />`, size, size, href))
+		}
+		buf.WriteString("\n")
+		for _, run := range runs {
+			writeSVGTextRun(&buf, run)
+		}
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	if !rounded && len(runs) == 0 {
+		return encodeImage(ctx, photo, format)
+	}
+
+	dc := gg.NewContext(size, size)
+	if rounded {
+		dc.DrawCircle(float64(size)/2, float64(size)/2, float64(size)/2)
+		dc.Clip()
+	}
+	dc.DrawImage(photo, 0, 0)
+	if len(runs) > 0 {
+		r.drawTextRuns(dc, runs, textOptions(ctx))
+	}
+	return encodeImage(ctx, dc.Image(), format)
+}
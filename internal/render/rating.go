@@ -0,0 +1,122 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// starPoints returns the ten vertices of a five-pointed star centered at (cx, cy).
+func starPoints(cx, cy, outer float64) [][2]float64 {
+	inner := outer * 0.382
+	points := make([][2]float64, 0, 10)
+	for i := 0; i < 10; i++ {
+		radius := outer
+		if i%2 == 1 {
+			radius = inner
+		}
+		// Start at the top point and walk clockwise
+		angle := -math.Pi/2 + float64(i)*math.Pi/5
+		points = append(points, [2]float64{cx + radius*math.Cos(angle), cy + radius*math.Sin(angle)})
+	}
+	return points
+}
+
+// RoundRating clamps value to [0, maxStars] and rounds it to the nearest half star.
+func RoundRating(value float64, maxStars int) float64 {
+	if value < 0 || math.IsNaN(value) {
+		return 0
+	}
+	if value > float64(maxStars) {
+		return float64(maxStars)
+	}
+	return math.Round(value*2) / 2
+}
+
+// DrawRating renders a horizontal strip of maxStars stars, each size pixels square,
+// with value stars filled (half stars allowed). An empty bgHex leaves the
+// background transparent.
+func (r *Renderer) DrawRating(ctx context.Context, value float64, maxStars, size int, fillHex, emptyHex, bgHex string, format ImageFormat) ([]byte, error) {
+	value = RoundRating(value, maxStars)
+	w, h := maxStars*size, size
+	outer := float64(size) * 0.48
+
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<defs><linearGradient id="half_%s_%s" x1="0%%" y1="0%%" x2="100%%" y2="0%%">`, fillHex, emptyHex))
+		buf.WriteString(fmt.Sprintf(`<stop offset="50%%" stop-color="#%s" /><stop offset="50%%" stop-color="#%s" />`, fillHex, emptyHex))
+		buf.WriteString(`</linearGradient></defs>`)
+		buf.WriteString("\n")
+		if bgHex != "" {
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, w, h, bgHex))
+			buf.WriteString("\n")
+		}
+		for i := 0; i < maxStars; i++ {
+			fill := "#" + emptyHex
+			if value >= float64(i+1) {
+				fill = "#" + fillHex
+			} else if value > float64(i) {
+				fill = fmt.Sprintf("url(#half_%s_%s)", fillHex, emptyHex)
+			}
+
+			coords := make([]string, 0, 10)
+			for _, p := range starPoints(float64(i*size)+float64(size)/2, float64(size)/2, outer) {
+				coords = append(coords, fmt.Sprintf("%.2f,%.2f", p[0], p[1]))
+			}
+			buf.WriteString(fmt.Sprintf(`<polygon points="%s" fill="%s" />`, strings.Join(coords, " "), fill))
+			buf.WriteString("\n")
+		}
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(w, h)
+	if bgHex != "" {
+		dc.SetColor(ParseHexColor(bgHex))
+		dc.DrawRectangle(0, 0, float64(w), float64(h))
+		dc.Fill()
+	}
+
+	fill := ParseHexColor(fillHex)
+	empty := ParseHexColor(emptyHex)
+	for i := 0; i < maxStars; i++ {
+		x := float64(i * size)
+		points := starPoints(x+float64(size)/2, float64(size)/2, outer)
+		drawPolygon := func() {
+			for _, p := range points {
+				dc.LineTo(p[0], p[1])
+			}
+			dc.ClosePath()
+		}
+
+		switch {
+		case value >= float64(i+1):
+			dc.SetColor(fill)
+			drawPolygon()
+			dc.Fill()
+		case value > float64(i):
+			// Half star: draw the empty star, then the filled star clipped to its left half
+			dc.SetColor(empty)
+			drawPolygon()
+			dc.Fill()
+			dc.DrawRectangle(x, 0, float64(size)/2, float64(size))
+			dc.Clip()
+			dc.SetColor(fill)
+			drawPolygon()
+			dc.Fill()
+			dc.ResetClip()
+		default:
+			dc.SetColor(empty)
+			drawPolygon()
+			dc.Fill()
+		}
+	}
+
+	return encodeImage(ctx, dc.Image(), format)
+}
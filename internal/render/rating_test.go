@@ -0,0 +1,78 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRoundRating(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		max   int
+		exp   float64
+	}{
+		{"whole", 3, 5, 3},
+		{"half", 3.5, 5, 3.5},
+		{"rounds down", 3.2, 5, 3},
+		{"rounds to half", 3.3, 5, 3.5},
+		{"rounds up", 3.8, 5, 4},
+		{"negative", -1, 5, 0},
+		{"above max", 7, 5, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundRating(tt.value, tt.max); got != tt.exp {
+				t.Fatalf("expected %v got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestDrawRatingSVG(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	data, err := r.DrawRating(context.Background(), 3.5, 5, 24, "f5a623", "dddddd", "", FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw rating: %v", err)
+	}
+	svgStr := string(data)
+
+	if !strings.Contains(svgStr, `width="120" height="24"`) {
+		t.Errorf("expected 120x24 strip, got: %s", svgStr)
+	}
+	if got := strings.Count(svgStr, `fill="#f5a623"`); got != 3 {
+		t.Errorf("expected 3 filled stars, got %d", got)
+	}
+	if got := strings.Count(svgStr, `fill="url(#half_f5a623_dddddd)"`); got != 1 {
+		t.Errorf("expected 1 half star, got %d", got)
+	}
+	if got := strings.Count(svgStr, `fill="#dddddd"`); got != 1 {
+		t.Errorf("expected 1 empty star, got %d", got)
+	}
+	if strings.Contains(svgStr, "<rect") {
+		t.Errorf("expected transparent background, got: %s", svgStr)
+	}
+}
+
+func TestDrawRatingRaster(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	for _, format := range []ImageFormat{FormatPNG, FormatGIF, FormatWebP} {
+		data, err := r.DrawRating(context.Background(), 2.5, 5, 32, "f5a623", "dddddd", "", format)
+		if err != nil {
+			t.Fatalf("failed to draw %s rating: %v", format, err)
+		}
+		if len(data) == 0 {
+			t.Fatalf("expected %s data, got empty", format)
+		}
+	}
+}
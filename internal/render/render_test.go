@@ -1,6 +1,11 @@
 package render
 
 import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
 	"strings"
 	"testing"
 )
@@ -17,6 +22,8 @@ func TestGetInitials(t *testing.T) {
 		{"extra words", "alice baker charlie", "AB"},
 		{"mixed spacing", "  alice   baker  ", "AB"},
 		{"non letters", "  -alice  123 baker", "-1"},
+		{"diacritics", "józef ñuñez", "JÑ"},
+		{"combining marks", "n\u0303u\u0301n\u0303ez e\u0301mile", "N\u0303E\u0301"},
 	}
 
 	for _, tc := range cases {
@@ -28,6 +35,37 @@ func TestGetInitials(t *testing.T) {
 	}
 }
 
+func TestImageContrastColor(t *testing.T) {
+	solid := func(c color.Color) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, 300, 300))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		return img
+	}
+	// Light at the top and dark below, darker on average
+	split := solid(color.RGBA{R: 20, G: 20, B: 20, A: 255}).(*image.RGBA)
+	draw.Draw(split, image.Rect(0, 0, 300, 100), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name   string
+		img    image.Image
+		expect string
+	}{
+		{"light photo", solid(color.RGBA{R: 240, G: 230, B: 200, A: 255}), "000000"},
+		{"dark photo", solid(color.RGBA{R: 30, G: 40, B: 60, A: 255}), "ffffff"},
+		{"mostly dark", split, "ffffff"},
+		{"transparent", solid(color.Transparent), "000000"},
+		// Translucent white is still white
+		{"translucent", solid(color.NRGBA{R: 255, G: 255, B: 255, A: 40}), "000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ImageContrastColor(tt.img); got != tt.expect {
+				t.Fatalf("expected %s got %s", tt.expect, got)
+			}
+		})
+	}
+}
+
 func TestGetContrastColorWithGradient(t *testing.T) {
 	cases := []struct {
 		name  string
@@ -58,19 +96,19 @@ func TestDrawImageWithGradient(t *testing.T) {
 	}
 
 	// Test that gradient image generation doesn't error
-	_, err = r.DrawImageWithFormat(400, 300, "ff0000,0000ff", "ffffff", "Test", false, false, FormatPNG)
+	_, err = r.DrawImageWithFormat(context.Background(), 400, 300, "ff0000,0000ff", "ffffff", "Test", false, false, FormatPNG)
 	if err != nil {
 		t.Fatalf("failed to draw image with gradient: %v", err)
 	}
 
 	// Test with single color (existing behavior)
-	_, err = r.DrawImageWithFormat(400, 300, "ff0000", "ffffff", "Test", false, false, FormatPNG)
+	_, err = r.DrawImageWithFormat(context.Background(), 400, 300, "ff0000", "ffffff", "Test", false, false, FormatPNG)
 	if err != nil {
 		t.Fatalf("failed to draw image with solid color: %v", err)
 	}
 
 	// Test with more than 2 colors (should use first color)
-	_, err = r.DrawImageWithFormat(400, 300, "ff0000,00ff00,0000ff", "ffffff", "Test", false, false, FormatPNG)
+	_, err = r.DrawImageWithFormat(context.Background(), 400, 300, "ff0000,00ff00,0000ff", "ffffff", "Test", false, false, FormatPNG)
 	if err != nil {
 		t.Fatalf("failed to draw image with more than 2 colors: %v", err)
 	}
@@ -99,7 +137,7 @@ func TestDrawImageWithSVGFormat(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			data, err := r.DrawImageWithFormat(tt.width, tt.height, tt.bg, tt.fg, tt.text, tt.rounded, false, FormatSVG)
+			data, err := r.DrawImageWithFormat(context.Background(), tt.width, tt.height, tt.bg, tt.fg, tt.text, tt.rounded, false, FormatSVG)
 			if err != nil {
 				t.Fatalf("failed to draw SVG: %v", err)
 			}
@@ -126,7 +164,7 @@ func TestDrawImageWithSVGBold(t *testing.T) {
 	}
 
 	// Test with bold=false
-	normalData, err := r.DrawImageWithFormat(200, 200, "cccccc", "000000", "AB", false, false, FormatSVG)
+	normalData, err := r.DrawImageWithFormat(context.Background(), 200, 200, "cccccc", "000000", "AB", false, false, FormatSVG)
 	if err != nil {
 		t.Fatalf("failed to draw normal SVG: %v", err)
 	}
@@ -136,7 +174,7 @@ func TestDrawImageWithSVGBold(t *testing.T) {
 	}
 
 	// Test with bold=true
-	boldData, err := r.DrawImageWithFormat(200, 200, "cccccc", "000000", "AB", false, true, FormatSVG)
+	boldData, err := r.DrawImageWithFormat(context.Background(), 200, 200, "cccccc", "000000", "AB", false, true, FormatSVG)
 	if err != nil {
 		t.Fatalf("failed to draw bold SVG: %v", err)
 	}
@@ -169,7 +207,7 @@ func TestDrawPlaceholderImageWithQuote(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			data, err := r.DrawPlaceholderImage(tt.width, tt.height, "2c3e50", "ecf0f1", tt.text, tt.isQuoteOrJoke, tt.format)
+			data, err := r.DrawPlaceholderImage(context.Background(), tt.width, tt.height, "2c3e50", "ecf0f1", tt.text, tt.isQuoteOrJoke, tt.format)
 			if err != nil {
 				t.Fatalf("failed to draw placeholder: %v", err)
 			}
@@ -213,3 +251,19 @@ func TestWrapTextForSVG(t *testing.T) {
 		})
 	}
 }
+
+func TestDrawStopsWhenContextDone(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := r.DrawPlaceholderImage(ctx, 400, 300, "cccccc", "000000", "Test", false, FormatPNG); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled from a raster render, got %v", err)
+	}
+	if _, err := DrawNoisePhoto(ctx, 100, 100, NoiseOptions{Seed: 1}, FormatPNG); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled from a noise photo, got %v", err)
+	}
+}
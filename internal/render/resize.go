@@ -0,0 +1,66 @@
+package render
+
+import (
+	"context"
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+// FitMode controls how an image is scaled into a target box.
+type FitMode string
+
+const (
+	// FitCover fills the box and crops any overflow around the center.
+	FitCover FitMode = "cover"
+	// FitContain fits the whole image inside the box and pads the remainder.
+	FitContain FitMode = "contain"
+	// FitSmart fills the box like FitCover but crops around the most salient region.
+	FitSmart FitMode = "smart"
+)
+
+// ResizeDimensions returns the target size for a srcW×srcH image. A zero width or
+// height is derived from the other side to preserve the aspect ratio; if both are
+// zero the source size is kept.
+func ResizeDimensions(srcW, srcH, w, h int) (int, int) {
+	switch {
+	case w <= 0 && h <= 0:
+		return srcW, srcH
+	case w <= 0:
+		return max(1, int(math.Round(float64(srcW)*float64(h)/float64(srcH)))), h
+	case h <= 0:
+		return w, max(1, int(math.Round(float64(srcH)*float64(w)/float64(srcW))))
+	}
+	return w, h
+}
+
+// ResizeImage scales img onto a w×h canvas using fit and encodes it in the given
+// raster format. An empty bgHex leaves any padding transparent.
+func ResizeImage(ctx context.Context, img image.Image, w, h int, fit FitMode, bgHex string, format ImageFormat) ([]byte, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if bgHex != "" {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(ParseHexColor(bgHex)), image.Point{}, draw.Src)
+	}
+
+	src := img.Bounds()
+	sw, sh := float64(src.Dx()), float64(src.Dy())
+
+	switch fit {
+	case FitContain:
+		scale := math.Min(float64(w)/sw, float64(h)/sh)
+		dw, dh := int(math.Round(sw*scale)), int(math.Round(sh*scale))
+		x0, y0 := (w-dw)/2, (h-dh)/2
+		draw.CatmullRom.Scale(dst, image.Rect(x0, y0, x0+dw, y0+dh), img, src, draw.Over, nil)
+	case FitSmart:
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, SmartCrop(img, w, h), draw.Over, nil)
+	default:
+		// Crop the largest centered region of the source with the target aspect ratio
+		scale := math.Max(float64(w)/sw, float64(h)/sh)
+		cw, ch := int(math.Round(float64(w)/scale)), int(math.Round(float64(h)/scale))
+		x0, y0 := src.Min.X+(src.Dx()-cw)/2, src.Min.Y+(src.Dy()-ch)/2
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x0, y0, x0+cw, y0+ch), draw.Over, nil)
+	}
+
+	return encodeImage(ctx, dst, format)
+}
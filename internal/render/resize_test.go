@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestResizeDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		srcW, srcH int
+		w, h       int
+		expW, expH int
+	}{
+		{"both given", 400, 200, 100, 100, 100, 100},
+		{"width only", 400, 200, 100, 0, 100, 50},
+		{"height only", 400, 200, 0, 100, 200, 100},
+		{"neither", 400, 200, 0, 0, 400, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := ResizeDimensions(tt.srcW, tt.srcH, tt.w, tt.h)
+			if w != tt.expW || h != tt.expH {
+				t.Fatalf("expected %dx%d got %dx%d", tt.expW, tt.expH, w, h)
+			}
+		})
+	}
+}
+
+func TestResizeImageFit(t *testing.T) {
+	// A wide red source image
+	src := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			src.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	tests := []struct {
+		name        string
+		fit         FitMode
+		cornerAlpha uint32
+	}{
+		// Cover fills the whole square so the corner is opaque
+		{"cover", FitCover, 0xffff},
+		// Contain letterboxes the wide image so the corner is transparent padding
+		{"contain", FitContain, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ResizeImage(context.Background(), src, 50, 50, tt.fit, "", FormatPNG)
+			if err != nil {
+				t.Fatalf("resize: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 50 || b.Dy() != 50 {
+				t.Fatalf("expected 50x50, got %dx%d", b.Dx(), b.Dy())
+			}
+			if _, _, _, a := img.At(0, 0).RGBA(); a != tt.cornerAlpha {
+				t.Fatalf("expected corner alpha %d, got %d", tt.cornerAlpha, a)
+			}
+		})
+	}
+}
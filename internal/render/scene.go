@@ -0,0 +1,498 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+
+	"grout/internal/config"
+)
+
+// Scene is a laid-out image: a background, shapes drawn on it, and text runs
+// drawn over both, in pixels. Layout happens once, in labelScene or LayoutScene,
+// and each backend only draws the scene, so raster and vector output can't drift
+// apart. A new vector backend such as PDF only needs to walk the same fields.
+type Scene struct {
+	Width, Height int
+	Background    Background
+	Shapes        []Shape
+	// Images are drawn over the shapes and under the text, such as brand logos
+	Images []SceneImage
+	Text   []TextRun
+	// Layers are drawn over the text, in order, each with its own shapes,
+	// images, text, and layers, for parts that overlap without showing through
+	// each other, such as a stack of avatars. Their size, background, filter,
+	// and shimmer are ignored.
+	Layers []Scene
+	// Overlay shapes are drawn over the text, for effects such as confetti
+	Overlay []Shape
+	// Filter, when set, post-processes everything drawn, such as EffectVignette
+	Filter Effect
+	// Shimmer, when set, sweeps a highlight across the scene in SVG output. Raster
+	// output can't animate and leaves it out.
+	Shimmer *Shimmer
+}
+
+// Background is the shape filling a scene: the whole canvas, or a centered circle.
+type Background struct {
+	Color string // Hex, no '#'; empty leaves the scene transparent
+	// GradientTo, when set, makes a left-to-right linear gradient from Color
+	GradientTo string
+	Circle     bool
+	Radius     float64 // Radius of the circle
+}
+
+// Shape is a filled rectangle, or the circle inscribed in it. With Stroke set
+// it's an outline of that width instead, centered on the shape's edge.
+type Shape struct {
+	X, Y, Width, Height float64
+	Circle              bool
+	Color               string // Hex, no '#'
+	Stroke              float64
+	// Points, when set, make the shape the polygon through them instead, for
+	// rotated and pointed shapes
+	Points []Point
+}
+
+// SceneImage is a bitmap scaled to fill a Width x Height box at (X, Y).
+type SceneImage struct {
+	X, Y, Width, Height float64
+	Image               image.Image
+}
+
+// Point is a position in a scene, in pixels.
+type Point struct {
+	X, Y float64
+}
+
+// TextRun is one line of text centered on (X, Y).
+type TextRun struct {
+	Text  string
+	X, Y  float64
+	Size  float64
+	Bold  bool
+	Color string // Hex, no '#'
+	// Font names an embedded font, which wins over Bold when set
+	Font string
+}
+
+// lineFits reports whether a line of text fits the width available for it.
+type lineFits func(line string) bool
+
+// labelScene lays out text centered on a background. With wrap set the text is
+// broken into lines that fit; otherwise it's a single line, as for initials and
+// dimensions.
+func labelScene(w, h int, bgHex, fgHex, text string, rounded, bold bool, fontSize float64, wrap bool, fits lineFits) Scene {
+	scene := Scene{Width: w, Height: h}
+
+	// A comma-separated pair of colors is a gradient
+	color1, color2 := parseGradientColors(bgHex)
+	switch {
+	case color1 != "" && color2 != "":
+		scene.Background = Background{Color: color1, GradientTo: color2}
+	case color1 != "":
+		scene.Background = Background{Color: color1}
+	default:
+		scene.Background = Background{Color: bgHex}
+	}
+	if rounded {
+		scene.Background.Circle = true
+		scene.Background.Radius = float64(min(w, h)) / 2
+	}
+
+	lines := []string{text}
+	if wrap {
+		lines = wrapLines(text, fits)
+	}
+	// 1.5x line spacing for readability, with the block centered vertically
+	lineHeight := fontSize * 1.5
+	startY := float64(h)/2 - float64(len(lines)-1)*lineHeight/2
+	for i, line := range lines {
+		scene.Text = append(scene.Text, TextRun{
+			Text:  line,
+			X:     float64(w) / 2,
+			Y:     startY + float64(i)*lineHeight,
+			Size:  fontSize,
+			Bold:  bold,
+			Color: fgHex,
+		})
+	}
+	return scene
+}
+
+// wrapLines breaks text into lines at spaces, each as long as fits allows. A word
+// that doesn't fit on its own gets a line anyway.
+func wrapLines(text string, fits lineFits) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{text}
+	}
+
+	var lines []string
+	var currentLine string
+	for _, word := range words {
+		testLine := word
+		if currentLine != "" {
+			testLine = currentLine + " " + word
+		}
+
+		if fits(testLine) {
+			currentLine = testLine
+		} else if currentLine != "" {
+			lines = append(lines, currentLine)
+			currentLine = word
+		} else {
+			// Single word is too long, add it anyway
+			lines = append(lines, word)
+		}
+	}
+	if currentLine != "" {
+		lines = append(lines, currentLine)
+	}
+
+	if len(lines) == 0 {
+		return []string{text}
+	}
+	return lines
+}
+
+// wrapWidth is the width available to wrapped text: the image width less 10%
+// padding on each side.
+func wrapWidth(imageWidth float64) float64 {
+	return imageWidth * 0.8
+}
+
+// measuredFits measures lines with the renderer's font, for raster output.
+func (r *Renderer) measuredFits(imageWidth, fontSize float64, bold bool) lineFits {
+	face := truetype.NewFace(r.labelFont(bold), &truetype.Options{Size: fontSize})
+	maxWidth := wrapWidth(imageWidth)
+	return func(line string) bool {
+		return float64(font.MeasureString(face, line))/64 <= maxWidth
+	}
+}
+
+// estimatedFits estimates line widths at 0.6em per character, for SVG output,
+// whose font is picked by the viewer.
+func estimatedFits(imageWidth, fontSize float64) lineFits {
+	maxChars := max(int(wrapWidth(imageWidth)/(fontSize*0.6)), config.MinCharsPerLine)
+	return func(line string) bool {
+		return len(line) <= maxChars
+	}
+}
+
+// lineFitsFor returns how lines are fitted when rendering in format.
+func (r *Renderer) lineFitsFor(format ImageFormat, imageWidth, fontSize float64, bold bool) lineFits {
+	if format == FormatSVG {
+		return estimatedFits(imageWidth, fontSize)
+	}
+	return r.measuredFits(imageWidth, fontSize, bold)
+}
+
+// labelFont returns the font of label text.
+func (r *Renderer) labelFont(bold bool) *truetype.Font {
+	if bold {
+		return r.bold
+	}
+	return r.regular
+}
+
+// runFont returns the font of text in the named embedded font, or the label
+// font when fontName is empty.
+func (r *Renderer) runFont(fontName string, bold bool) *truetype.Font {
+	if fontName != "" {
+		return r.font(fontName).ttf
+	}
+	return r.labelFont(bold)
+}
+
+// RenderScene draws a scene in format, with ctx's effect over it. It stops early
+// with ctx's error once ctx is done.
+func (r *Renderer) RenderScene(ctx context.Context, scene Scene, format ImageFormat) ([]byte, error) {
+	scene = applyEffect(ctx, scene)
+	if format == FormatSVG {
+		if scene.Filter.IsRasterOnly() {
+			return nil, fmt.Errorf("%w: the %s effect is raster only", ErrUnsupportedFormat, scene.Filter)
+		}
+		return scene.svg(), nil
+	}
+	img, err := r.rasterizeScene(ctx, scene)
+	if err != nil {
+		return nil, err
+	}
+	return encodeImage(ctx, img, format)
+}
+
+// rasterizeScene draws a scene with gg, with the text options of ctx. A
+// supersampled scene is drawn larger and scaled down before its filter runs,
+// so the filter works on the image's own pixels.
+func (r *Renderer) rasterizeScene(ctx context.Context, scene Scene) (image.Image, error) {
+	text := textOptions(ctx)
+	factor := SupersampleFactor(scene.Width, scene.Height, text.Supersample)
+	img, err := r.drawScene(ctx, scene.scaled(float64(factor)), text)
+	if err != nil {
+		return nil, err
+	}
+	if factor > 1 {
+		img = downsample(img, scene.Width, scene.Height)
+	}
+	applyFilter(img, scene)
+	return img, nil
+}
+
+// drawScene draws everything in a scene but its filter.
+func (r *Renderer) drawScene(ctx context.Context, scene Scene, text TextOptions) (*image.RGBA, error) {
+	w, h := float64(scene.Width), float64(scene.Height)
+	dc := gg.NewContext(scene.Width, scene.Height)
+
+	if bg := scene.Background; bg.Color != "" {
+		if bg.GradientTo != "" {
+			gradient := gg.NewLinearGradient(0, 0, w, 0)
+			gradient.AddColorStop(0, ParseHexColor(bg.Color))
+			gradient.AddColorStop(1, ParseHexColor(bg.GradientTo))
+			dc.SetFillStyle(gradient)
+		} else {
+			dc.SetColor(ParseHexColor(bg.Color))
+		}
+		if bg.Circle {
+			dc.DrawCircle(w/2, h/2, bg.Radius)
+		} else {
+			dc.DrawRectangle(0, 0, w, h)
+		}
+		dc.Fill()
+	}
+	drawShapes(dc, scene.Shapes)
+	drawImages(dc.Image().(*image.RGBA), scene.Images)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.drawTextRuns(dc, scene.Text, text)
+	for _, layer := range scene.Layers {
+		r.drawLayer(dc, layer, text)
+	}
+	drawShapes(dc, scene.Overlay)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return dc.Image().(*image.RGBA), nil
+}
+
+// drawLayer draws a scene layer and the layers over it with gg.
+func (r *Renderer) drawLayer(dc *gg.Context, layer Scene, text TextOptions) {
+	drawShapes(dc, layer.Shapes)
+	drawImages(dc.Image().(*image.RGBA), layer.Images)
+	r.drawTextRuns(dc, layer.Text, text)
+	for _, above := range layer.Layers {
+		r.drawLayer(dc, above, text)
+	}
+	drawShapes(dc, layer.Overlay)
+}
+
+// scaled returns the scene k times larger, for supersampling. Its filter and
+// shimmer are kept as they are.
+func (scene Scene) scaled(k float64) Scene {
+	if k == 1 {
+		return scene
+	}
+	scaled := scene
+	scaled.Width, scaled.Height = int(math.Round(float64(scene.Width)*k)), int(math.Round(float64(scene.Height)*k))
+	scaled.Background.Radius *= k
+	scaled.Shapes = scaleShapes(scene.Shapes, k)
+	scaled.Overlay = scaleShapes(scene.Overlay, k)
+	scaled.Images = make([]SceneImage, len(scene.Images))
+	for i, img := range scene.Images {
+		img.X, img.Y, img.Width, img.Height = img.X*k, img.Y*k, img.Width*k, img.Height*k
+		scaled.Images[i] = img
+	}
+	scaled.Text = make([]TextRun, len(scene.Text))
+	for i, run := range scene.Text {
+		run.X, run.Y, run.Size = run.X*k, run.Y*k, run.Size*k
+		scaled.Text[i] = run
+	}
+	scaled.Layers = make([]Scene, len(scene.Layers))
+	for i, layer := range scene.Layers {
+		scaled.Layers[i] = layer.scaled(k)
+	}
+	return scaled
+}
+
+// scaleShapes returns copies of shapes k times larger.
+func scaleShapes(shapes []Shape, k float64) []Shape {
+	scaled := make([]Shape, len(shapes))
+	for i, shape := range shapes {
+		shape.X, shape.Y, shape.Width, shape.Height, shape.Stroke = shape.X*k, shape.Y*k, shape.Width*k, shape.Height*k, shape.Stroke*k
+		if shape.Points != nil {
+			shape.Points = make([]Point, len(shape.Points))
+			for j, p := range shapes[i].Points {
+				shape.Points[j] = Point{X: p.X * k, Y: p.Y * k}
+			}
+		}
+		scaled[i] = shape
+	}
+	return scaled
+}
+
+// drawShapes draws shapes with gg.
+func drawShapes(dc *gg.Context, shapes []Shape) {
+	for _, shape := range shapes {
+		dc.SetColor(ParseHexColor(shape.Color))
+		switch {
+		case len(shape.Points) > 0:
+			for _, p := range shape.Points {
+				dc.LineTo(p.X, p.Y)
+			}
+			dc.ClosePath()
+		case shape.Circle:
+			dc.DrawEllipse(shape.X+shape.Width/2, shape.Y+shape.Height/2, shape.Width/2, shape.Height/2)
+		default:
+			dc.DrawRectangle(shape.X, shape.Y, shape.Width, shape.Height)
+		}
+		if shape.Stroke > 0 {
+			dc.SetLineWidth(shape.Stroke)
+			dc.Stroke()
+		} else {
+			dc.Fill()
+		}
+	}
+}
+
+// drawImages scales images into their boxes on dst.
+func drawImages(dst *image.RGBA, images []SceneImage) {
+	for _, img := range images {
+		rect := image.Rect(int(math.Round(img.X)), int(math.Round(img.Y)), int(math.Round(img.X+img.Width)), int(math.Round(img.Y+img.Height)))
+		draw.CatmullRom.Scale(dst, rect, img.Image, img.Image.Bounds(), draw.Over, nil)
+	}
+}
+
+// drawTextRuns draws text runs with gg, hinted and anti-aliased as text says.
+func (r *Renderer) drawTextRuns(dc *gg.Context, runs []TextRun, text TextOptions) {
+	for _, run := range runs {
+		dc.SetFontFace(text.face(r.runFont(run.Font, run.Bold), run.Size))
+		dc.SetColor(ParseHexColor(run.Color))
+		dc.DrawStringAnchored(run.Text, run.X, run.Y, 0.5, 0.5)
+	}
+}
+
+// svg writes a scene as an SVG document.
+func (scene Scene) svg() []byte {
+	var buf bytes.Buffer
+	w, h := scene.Width, scene.Height
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+	buf.WriteString("\n")
+	scene.writeSVGBody(&buf)
+	buf.WriteString("</svg>")
+	return buf.Bytes()
+}
+
+// writeSVGBody writes the elements of a scene, without the enclosing svg element.
+func (scene Scene) writeSVGBody(buf *bytes.Buffer) {
+	w, h := scene.Width, scene.Height
+	if bg := scene.Background; bg.Color != "" {
+		fill := "#" + bg.Color
+		if bg.GradientTo != "" {
+			// The ID is derived from the colors so it's stable across renders
+			gradientID := fmt.Sprintf("grad_%s_%s", bg.Color, bg.GradientTo)
+			buf.WriteString(fmt.Sprintf(`<defs><linearGradient id="%s" x1="0%%" y1="0%%" x2="100%%" y2="0%%">`, gradientID))
+			buf.WriteString(fmt.Sprintf(`<stop offset="0%%" style="stop-color:#%s;stop-opacity:1" />`, bg.Color))
+			buf.WriteString(fmt.Sprintf(`<stop offset="100%%" style="stop-color:#%s;stop-opacity:1" />`, bg.GradientTo))
+			buf.WriteString(`</linearGradient></defs>`)
+			buf.WriteString("\n")
+			fill = "url(#" + gradientID + ")"
+		}
+		if bg.Circle {
+			buf.WriteString(fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" fill="%s" />`, svgNumber(float64(w)/2), svgNumber(float64(h)/2), svgNumber(bg.Radius), fill))
+		} else {
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="%s" />`, w, h, fill))
+		}
+		buf.WriteString("\n")
+	}
+
+	for _, shape := range scene.Shapes {
+		writeSVGShape(buf, shape)
+	}
+	for _, img := range scene.Images {
+		writeSVGImage(buf, img)
+	}
+	for _, run := range scene.Text {
+		writeSVGTextRun(buf, run)
+	}
+	for _, layer := range scene.Layers {
+		layer.Background, layer.Filter, layer.Shimmer = Background{}, "", nil
+		layer.writeSVGBody(buf)
+	}
+	for _, shape := range scene.Overlay {
+		writeSVGShape(buf, shape)
+	}
+	if scene.Filter == EffectVignette {
+		writeSVGVignette(buf, scene)
+	}
+	if scene.Shimmer != nil {
+		writeSVGShimmer(buf, scene)
+	}
+}
+
+// writeSVGShape writes a shape as an SVG element.
+func writeSVGShape(buf *bytes.Buffer, shape Shape) {
+	paint := fmt.Sprintf(`fill="#%s"`, shape.Color)
+	if shape.Stroke > 0 {
+		paint = fmt.Sprintf(`fill="none" stroke="#%s" stroke-width="%s"`, shape.Color, svgNumber(shape.Stroke))
+	}
+	switch {
+	case len(shape.Points) > 0:
+		points := make([]string, len(shape.Points))
+		for i, p := range shape.Points {
+			points[i] = svgNumber(p.X) + "," + svgNumber(p.Y)
+		}
+		buf.WriteString(fmt.Sprintf(`<polygon points="%s" %s />`, strings.Join(points, " "), paint))
+	case shape.Circle:
+		buf.WriteString(fmt.Sprintf(`<ellipse cx="%s" cy="%s" rx="%s" ry="%s" %s />`,
+			svgNumber(shape.X+shape.Width/2), svgNumber(shape.Y+shape.Height/2), svgNumber(shape.Width/2), svgNumber(shape.Height/2), paint))
+	default:
+		buf.WriteString(fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" %s />`,
+			svgNumber(shape.X), svgNumber(shape.Y), svgNumber(shape.Width), svgNumber(shape.Height), paint))
+	}
+	buf.WriteString("\n")
+}
+
+// writeSVGImage writes an image as an SVG image element with the bitmap inlined
+// as a PNG data URI, so the document stands alone.
+func writeSVGImage(buf *bytes.Buffer, img SceneImage) {
+	var data bytes.Buffer
+	if err := png.Encode(&data, img.Image); err != nil {
+		return
+	}
+	buf.WriteString(fmt.Sprintf(`<image x="%s" y="%s" width="%s" height="%s" href="data:image/png;base64,%s" />`,
+		svgNumber(img.X), svgNumber(img.Y), svgNumber(img.Width), svgNumber(img.Height), base64.StdEncoding.EncodeToString(data.Bytes())))
+	buf.WriteString("\n")
+}
+
+// writeSVGTextRun writes a text run as an SVG text element.
+func writeSVGTextRun(buf *bytes.Buffer, run TextRun) {
+	fontFamily, fontWeight, fontStyle := "sans-serif", "normal", ""
+	if run.Bold {
+		fontWeight = "bold"
+	}
+	if spec, ok := embeddedFont(run.Font); ok {
+		fontFamily, fontWeight = spec.family, spec.weight
+		fontStyle = fmt.Sprintf(` font-style="%s" font-variant="%s"`, spec.style, spec.variant)
+	}
+	buf.WriteString(fmt.Sprintf(`<text x="%s" y="%s" font-family="%s" font-size="%.0f" font-weight="%s"%s fill="#%s" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+		svgNumber(run.X), svgNumber(run.Y), fontFamily, run.Size, fontWeight, fontStyle, run.Color, escapeXML(run.Text)))
+	buf.WriteString("\n")
+}
+
+// svgNumber formats a coordinate to at most one decimal place.
+func svgNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLabelScene(t *testing.T) {
+	tests := []struct {
+		name      string
+		w, h      int
+		bg        string
+		rounded   bool
+		text      string
+		wrap      bool
+		expectBg  Background
+		expectYs  []float64
+		expectRun string
+	}{
+		{"single line", 200, 100, "cccccc", false, "200 x 100", false, Background{Color: "cccccc"}, []float64{50}, "200 x 100"},
+		{"rounded uses the smaller side", 200, 100, "cccccc", true, "AB", false, Background{Color: "cccccc", Circle: true, Radius: 50}, []float64{50}, "AB"},
+		{"gradient", 100, 100, "ff0000,0000ff", false, "AB", false, Background{Color: "ff0000", GradientTo: "0000ff"}, []float64{50}, "AB"},
+		{"wrapped lines are centered", 100, 100, "cccccc", false, "one two three", true, Background{Color: "cccccc"}, []float64{35, 50, 65}, "one"},
+	}
+
+	// One word per line
+	fits := func(line string) bool { return !strings.Contains(line, " ") }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scene := labelScene(tt.w, tt.h, tt.bg, "333333", tt.text, tt.rounded, true, 10, tt.wrap, fits)
+			if scene.Background != tt.expectBg {
+				t.Fatalf("expected background %+v got %+v", tt.expectBg, scene.Background)
+			}
+			if len(scene.Text) != len(tt.expectYs) {
+				t.Fatalf("expected %d text runs got %d", len(tt.expectYs), len(scene.Text))
+			}
+			for i, run := range scene.Text {
+				if run.Y != tt.expectYs[i] || run.X != float64(tt.w)/2 {
+					t.Errorf("run %d: expected center (%v, %v) got (%v, %v)", i, float64(tt.w)/2, tt.expectYs[i], run.X, run.Y)
+				}
+			}
+			if scene.Text[0].Text != tt.expectRun {
+				t.Errorf("expected first run %q got %q", tt.expectRun, scene.Text[0].Text)
+			}
+		})
+	}
+}
+
+func TestSceneBackends(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	scene := labelScene(120, 80, "ff0000,0000ff", "ffffff", "A & B", true, false, 20, false, nil)
+
+	svg := string(scene.svg())
+	for _, expect := range []string{
+		`<circle cx="60" cy="40" r="40" fill="url(#grad_ff0000_0000ff)" />`,
+		`<text x="60" y="40"`,
+		`>A &amp; B</text>`,
+	} {
+		if !strings.Contains(svg, expect) {
+			t.Errorf("expected SVG to contain %q, got:\n%s", expect, svg)
+		}
+	}
+
+	img, err := r.rasterizeScene(context.Background(), scene)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 120 || b.Dy() != 80 {
+		t.Fatalf("expected a 120x80 image, got %v", b)
+	}
+	// Outside the circle stays transparent
+	if _, _, _, a := img.At(2, 2).RGBA(); a != 0 {
+		t.Error("expected the corner outside the circle to be transparent")
+	}
+}
@@ -0,0 +1,29 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// svgIDRefRegex matches element IDs and the url(#...) references to them.
+var svgIDRefRegex = regexp.MustCompile(`(id="|url\(#)`)
+
+// CombineSchemes merges light and dark w×h SVG renderings into one SVG that shows
+// the dark one when the viewer prefers a dark color scheme. IDs in the dark
+// rendering are prefixed so the two never reference each other's definitions.
+func CombineSchemes(light, dark []byte, w, h int) []byte {
+	dark = svgIDRefRegex.ReplaceAll(dark, []byte("${1}dark_"))
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+	buf.WriteString("\n")
+	buf.WriteString(`<style>.scheme-dark{display:none}@media (prefers-color-scheme: dark){.scheme-light{display:none}.scheme-dark{display:inline}}</style>`)
+	buf.WriteString("\n")
+	// Each rendering becomes a nested <svg> that the media query shows or hides
+	buf.Write(bytes.Replace(light, []byte("<svg "), []byte(`<svg class="scheme-light" `), 1))
+	buf.WriteString("\n")
+	buf.Write(bytes.Replace(dark, []byte("<svg "), []byte(`<svg class="scheme-dark" `), 1))
+	buf.WriteString("\n</svg>")
+	return buf.Bytes()
+}
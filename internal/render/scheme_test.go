@@ -0,0 +1,23 @@
+package render
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestCombineSchemes(t *testing.T) {
+	light := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><defs><linearGradient id="g"/></defs><rect fill="url(#g)"/></svg>`)
+	dark := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><defs><linearGradient id="g"/></defs><rect fill="url(#g)"/></svg>`)
+
+	combined := string(CombineSchemes(light, dark, 10, 10))
+
+	if err := xml.Unmarshal([]byte(combined), new(struct{})); err != nil {
+		t.Fatalf("expected well-formed XML: %v", err)
+	}
+	for _, expect := range []string{`<svg class="scheme-light"`, `<svg class="scheme-dark"`, `id="dark_g"`, `url(#dark_g)`, `id="g"`, "prefers-color-scheme: dark"} {
+		if !strings.Contains(combined, expect) {
+			t.Errorf("expected %q in: %s", expect, combined)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/fogleman/gg"
+)
+
+// Silhouette proportions, as fractions of the avatar size.
+const (
+	silhouetteHeadY      = 0.38
+	silhouetteHeadR      = 0.19
+	silhouetteShouldersY = 0.98
+	silhouetteShouldersX = 0.36
+	silhouetteShouldersH = 0.34
+)
+
+// DrawSilhouette renders a size×size "mystery person" avatar: a head and shoulders
+// in fgHex on a bgHex square.
+func DrawSilhouette(ctx context.Context, size int, bgHex, fgHex string, format ImageFormat) ([]byte, error) {
+	s := float64(size)
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, size, size))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, size, size, bgHex))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="#%s" />`, s/2, s*silhouetteHeadY, s*silhouetteHeadR, fgHex))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<ellipse cx="%.1f" cy="%.1f" rx="%.1f" ry="%.1f" fill="#%s" />`, s/2, s*silhouetteShouldersY, s*silhouetteShouldersX, s*silhouetteShouldersH, fgHex))
+		buf.WriteString("\n")
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(size, size)
+	dc.SetColor(ParseHexColor(bgHex))
+	dc.Clear()
+	dc.SetColor(ParseHexColor(fgHex))
+	dc.DrawCircle(s/2, s*silhouetteHeadY, s*silhouetteHeadR)
+	dc.Fill()
+	dc.DrawEllipse(s/2, s*silhouetteShouldersY, s*silhouetteShouldersX, s*silhouetteShouldersH)
+	dc.Fill()
+	return encodeImage(ctx, dc.Image(), format)
+}
+
+// DrawBlank renders a fully transparent w×h image (black for JPEG, which has no alpha).
+func DrawBlank(ctx context.Context, w, h int, format ImageFormat) ([]byte, error) {
+	if format == FormatSVG {
+		return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d"></svg>`, w, h, w, h)), nil
+	}
+	return encodeImage(ctx, image.NewRGBA(image.Rect(0, 0, w, h)), format)
+}
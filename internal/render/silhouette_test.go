@@ -0,0 +1,52 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestDrawSilhouette(t *testing.T) {
+	data, err := DrawSilhouette(context.Background(), 100, "ffffff", "000000", FormatPNG)
+	if err != nil {
+		t.Fatalf("DrawSilhouette: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		x, y   int
+		expect color.Color
+	}{
+		{"head", 50, 38, color.RGBA{0, 0, 0, 255}},
+		{"shoulders", 50, 95, color.RGBA{0, 0, 0, 255}},
+		{"corner", 2, 2, color.RGBA{255, 255, 255, 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)); got != tt.expect {
+				t.Fatalf("expected %v got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestDrawBlankIsTransparent(t *testing.T) {
+	data, err := DrawBlank(context.Background(), 10, 10, FormatPNG)
+	if err != nil {
+		t.Fatalf("DrawBlank: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, _, _, a := img.At(5, 5).RGBA(); a != 0 {
+		t.Fatalf("expected a transparent pixel, got alpha %d", a)
+	}
+}
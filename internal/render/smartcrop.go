@@ -0,0 +1,116 @@
+package render
+
+import (
+	"image"
+	"math"
+)
+
+// saliencyMapSize is the longest side of the downsampled map used to score crop windows.
+const saliencyMapSize = 64
+
+// isSkinTone reports whether an 8-bit RGB color falls in a common skin-tone range.
+func isSkinTone(r, g, b int) bool {
+	maxC := max(r, g, b)
+	minC := min(r, g, b)
+	return r > 95 && g > 40 && b > 20 && maxC-minC > 15 && r-g > 15 && r > b
+}
+
+// saliencyMap scores a downsampled copy of img. Each cell combines local contrast
+// (edge energy) with a bonus for skin tones, weighted toward the horizontal center
+// and the upper-middle of the frame where faces usually sit.
+func saliencyMap(img image.Image) ([][]float64, float64) {
+	bounds := img.Bounds()
+	scale := float64(saliencyMapSize) / float64(max(bounds.Dx(), bounds.Dy()))
+	if scale > 1 {
+		scale = 1
+	}
+	mw := max(1, int(float64(bounds.Dx())*scale))
+	mh := max(1, int(float64(bounds.Dy())*scale))
+
+	luma := make([][]float64, mh)
+	skin := make([][]bool, mh)
+	for y := 0; y < mh; y++ {
+		luma[y] = make([]float64, mw)
+		skin[y] = make([]bool, mw)
+		for x := 0; x < mw; x++ {
+			sx := bounds.Min.X + int((float64(x)+0.5)/scale)
+			sy := bounds.Min.Y + int((float64(y)+0.5)/scale)
+			r, g, b, _ := img.At(min(sx, bounds.Max.X-1), min(sy, bounds.Max.Y-1)).RGBA()
+			r8, g8, b8 := int(r>>8), int(g>>8), int(b>>8)
+			luma[y][x] = 0.2126*float64(r8) + 0.7152*float64(g8) + 0.0722*float64(b8)
+			skin[y][x] = isSkinTone(r8, g8, b8)
+		}
+	}
+
+	scores := make([][]float64, mh)
+	for y := 0; y < mh; y++ {
+		scores[y] = make([]float64, mw)
+		for x := 0; x < mw; x++ {
+			var edge float64
+			if x+1 < mw {
+				edge += math.Abs(luma[y][x+1] - luma[y][x])
+			}
+			if y+1 < mh {
+				edge += math.Abs(luma[y+1][x] - luma[y][x])
+			}
+			score := edge
+			if skin[y][x] {
+				score += 64
+			}
+
+			// Favor the horizontal center and a point one third from the top
+			dx := (float64(x)+0.5)/float64(mw) - 0.5
+			dy := (float64(y)+0.5)/float64(mh) - 0.35
+			weight := 1 - math.Min(0.75, math.Sqrt(dx*dx+dy*dy))
+			scores[y][x] = score * weight
+		}
+	}
+
+	return scores, scale
+}
+
+// SmartCrop returns the crop window of img with the w:h aspect ratio that holds the
+// most salient content, so faces and subjects aren't cut off when cropping.
+func SmartCrop(img image.Image, w, h int) image.Rectangle {
+	bounds := img.Bounds()
+	target := float64(w) / float64(h)
+
+	// Largest window with the target aspect ratio that fits the source
+	cw, ch := bounds.Dx(), int(math.Round(float64(bounds.Dx())/target))
+	if ch > bounds.Dy() {
+		cw, ch = int(math.Round(float64(bounds.Dy())*target)), bounds.Dy()
+	}
+	cw, ch = max(1, min(cw, bounds.Dx())), max(1, min(ch, bounds.Dy()))
+	if cw == bounds.Dx() && ch == bounds.Dy() {
+		return bounds
+	}
+
+	scores, scale := saliencyMap(img)
+	mh, mw := len(scores), len(scores[0])
+	ww := max(1, min(mw, int(math.Round(float64(cw)*scale))))
+	wh := max(1, min(mh, int(math.Round(float64(ch)*scale))))
+
+	// Summed-area table so every window sum is O(1)
+	sat := make([][]float64, mh+1)
+	sat[0] = make([]float64, mw+1)
+	for y := 0; y < mh; y++ {
+		sat[y+1] = make([]float64, mw+1)
+		for x := 0; x < mw; x++ {
+			sat[y+1][x+1] = scores[y][x] + sat[y][x+1] + sat[y+1][x] - sat[y][x]
+		}
+	}
+
+	bestX, bestY, best := (mw-ww)/2, (mh-wh)/2, -1.0
+	for y := 0; y+wh <= mh; y++ {
+		for x := 0; x+ww <= mw; x++ {
+			sum := sat[y+wh][x+ww] - sat[y][x+ww] - sat[y+wh][x] + sat[y][x]
+			if sum > best {
+				bestX, bestY, best = x, y, sum
+			}
+		}
+	}
+
+	x0 := bounds.Min.X + min(int(float64(bestX)/scale), bounds.Dx()-cw)
+	y0 := bounds.Min.Y + min(int(float64(bestY)/scale), bounds.Dy()-ch)
+	return image.Rect(x0, y0, x0+cw, y0+ch)
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// subjectImage returns a flat gray w×h image with a skin-toned, textured square of
+// side n whose top-left corner is at (sx, sy).
+func subjectImage(w, h, sx, sy, n int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 90, G: 90, B: 90, A: 255})
+		}
+	}
+	for y := sy; y < sy+n; y++ {
+		for x := sx; x < sx+n; x++ {
+			c := color.RGBA{R: 224, G: 172, B: 105, A: 255}
+			if (x/4+y/4)%2 == 0 {
+				c = color.RGBA{R: 160, G: 100, B: 60, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestSmartCropKeepsSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		img     image.Image
+		subject image.Rectangle
+	}{
+		{"portrait subject near top", subjectImage(200, 600, 60, 20, 80), image.Rect(60, 20, 140, 100)},
+		{"landscape subject on right", subjectImage(600, 200, 480, 60, 80), image.Rect(480, 60, 560, 140)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crop := SmartCrop(tt.img, 100, 100)
+			if crop.Dx() != crop.Dy() {
+				t.Fatalf("expected square crop, got %v", crop)
+			}
+			if !tt.subject.In(crop) {
+				t.Fatalf("expected crop %v to contain subject %v", crop, tt.subject)
+			}
+			if !crop.In(tt.img.Bounds()) {
+				t.Fatalf("crop %v exceeds image bounds %v", crop, tt.img.Bounds())
+			}
+		})
+	}
+}
+
+func TestSmartCropMatchingAspectReturnsBounds(t *testing.T) {
+	img := subjectImage(300, 300, 10, 10, 40)
+	if crop := SmartCrop(img, 50, 50); crop != img.Bounds() {
+		t.Fatalf("expected full bounds, got %v", crop)
+	}
+}
+
+func TestDrawPhotoAvatar(t *testing.T) {
+	img := subjectImage(200, 600, 60, 20, 80)
+
+	for _, format := range []ImageFormat{FormatPNG, FormatJPG, FormatWebP} {
+		data, err := DrawPhotoAvatar(context.Background(), img, 64, true, format)
+		if err != nil {
+			t.Fatalf("failed to draw %s photo avatar: %v", format, err)
+		}
+		if len(data) == 0 {
+			t.Fatalf("expected %s data, got empty", format)
+		}
+	}
+
+	data, err := DrawPhotoAvatar(context.Background(), img, 64, true, FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw SVG photo avatar: %v", err)
+	}
+	svgStr := string(data)
+	if !strings.Contains(svgStr, "data:image/png;base64,") || !strings.Contains(svgStr, "clip-path") {
+		t.Fatalf("expected embedded PNG clipped to a circle, got: %.200s", svgStr)
+	}
+}
+
+func TestDrawPhotoAvatarWithInitials(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	img := subjectImage(200, 600, 60, 20, 80)
+
+	data, err := r.DrawPhotoAvatarWithInitials(context.Background(), img, 64, true, true, "JD", "", FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw SVG photo avatar: %v", err)
+	}
+	svg := string(data)
+	expect := `fill="#` + ImageContrastColor(img) + `"`
+	if !strings.Contains(svg, "data:image/png;base64,") || !strings.Contains(svg, ">JD</text>") || !strings.Contains(svg, expect) {
+		t.Fatalf("expected the initials in a contrasting color over the photo, got: %.200s", svg[strings.Index(svg, "</svg>")-300:])
+	}
+
+	png, err := r.DrawPhotoAvatarWithInitials(context.Background(), img, 64, false, false, "JD", "ff0000", FormatPNG)
+	if err != nil || len(png) == 0 {
+		t.Fatalf("failed to draw PNG photo avatar: %v", err)
+	}
+	plain, _ := DrawPhotoAvatar(context.Background(), img, 64, false, FormatPNG)
+	if bytes.Equal(png, plain) {
+		t.Fatal("expected the initials to change the PNG")
+	}
+}
@@ -0,0 +1,219 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"grout/internal/config"
+)
+
+// SpinnerStyle selects the shape of a loading spinner.
+type SpinnerStyle string
+
+const (
+	SpinnerRing SpinnerStyle = "ring"
+	SpinnerDots SpinnerStyle = "dots"
+	SpinnerBars SpinnerStyle = "bars"
+)
+
+// SpinnerStyles lists the supported spinner styles.
+func SpinnerStyles() []SpinnerStyle {
+	return []SpinnerStyle{SpinnerRing, SpinnerDots, SpinnerBars}
+}
+
+// IsValid reports whether s is a supported spinner style.
+func (s SpinnerStyle) IsValid() bool {
+	for _, known := range SpinnerStyles() {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	spinnerDots = 8 // Dots around a dots spinner
+	spinnerBars = 5 // Bars in a bars spinner
+	// spinnerFade is how far a dot fades at the end of its trail
+	spinnerFade = 0.8
+	// spinnerBarMin is the height of a bar at rest, relative to its full height
+	spinnerBarMin = 0.4
+	// spinnerBarLag is how far each bar trails the one before it, in turns
+	spinnerBarLag = 0.12
+)
+
+// spinnerFrames is the number of GIF frames in one turn of each style; a dots
+// spinner takes a frame per dot.
+var spinnerFrames = map[SpinnerStyle]int{SpinnerRing: 12, SpinnerDots: spinnerDots, SpinnerBars: 12}
+
+// DrawSpinner renders a size x size loading spinner in fgHex. SVG spinners are
+// animated by CSS and GIF ones by frames, one turn every config.SpinnerPeriod;
+// other formats can't animate. An empty bgHex leaves an SVG spinner transparent
+// and a GIF one white.
+func (r *Renderer) DrawSpinner(ctx context.Context, size int, style SpinnerStyle, fgHex, bgHex string, format ImageFormat) ([]byte, error) {
+	switch format {
+	case FormatSVG:
+		return spinnerSVG(size, style, fgHex, bgHex), nil
+	case FormatGIF:
+		// Frames only store what changed, and a transparent pixel in a GIF frame
+		// leaves the previous one showing, so GIF spinners need a background
+		if bgHex == "" {
+			bgHex = "ffffff"
+		}
+		frames := spinnerFrames[style]
+		anim := Animation{Frames: make([]Frame, frames)}
+		for k := range frames {
+			anim.Frames[k] = Frame{
+				Scene: spinnerScene(size, style, fgHex, bgHex, float64(k)/float64(frames)),
+				Delay: config.SpinnerPeriod / time.Duration(frames),
+			}
+		}
+		return r.RenderAnimation(ctx, anim, format)
+	}
+	return nil, fmt.Errorf("%w: spinners are animated, so they need svg or gif, not %s", ErrUnsupportedFormat, format)
+}
+
+// spinnerGeometry holds the sizes of a spinner's parts, in pixels.
+type spinnerGeometry struct {
+	center    float64
+	stroke    float64 // Width of a ring
+	radius    float64 // Radius of a ring, or of the circle of dots
+	dot       float64 // Diameter of a dot
+	barWidth  float64
+	barGap    float64
+	barHeight float64 // Full height of a bar
+}
+
+func newSpinnerGeometry(size int) spinnerGeometry {
+	s := float64(size)
+	g := spinnerGeometry{center: s / 2, stroke: max(s/10, 2), dot: s / 8, barWidth: s / 9, barGap: s / 18, barHeight: s * 0.6}
+	g.radius = s/2 - g.stroke/2 - s*0.05
+	if g.dot > 0 {
+		g.radius = min(g.radius, s/2-g.dot/2-s*0.05)
+	}
+	return g
+}
+
+// dotCenter returns the center of dot i, clockwise from the top.
+func (g spinnerGeometry) dotCenter(i int) (float64, float64) {
+	angle := 2*math.Pi*float64(i)/spinnerDots - math.Pi/2
+	return g.center + g.radius*math.Cos(angle), g.center + g.radius*math.Sin(angle)
+}
+
+// barX returns the left edge of bar i.
+func (g spinnerGeometry) barX(i int) float64 {
+	total := spinnerBars*g.barWidth + (spinnerBars-1)*g.barGap
+	return g.center - total/2 + float64(i)*(g.barWidth+g.barGap)
+}
+
+// spinnerScene draws a spinner at phase (0–1) of its turn, for GIF frames. Faded
+// parts are blended toward the background, since GIF has no partial transparency.
+func spinnerScene(size int, style SpinnerStyle, fgHex, bgHex string, phase float64) Scene {
+	scene := Scene{Width: size, Height: size, Background: Background{Color: bgHex}}
+	g := newSpinnerGeometry(size)
+
+	switch style {
+	case SpinnerDots:
+		for i := range spinnerDots {
+			// The dot at the head of the trail is solid, and those behind it fade
+			trail := math.Mod(phase-float64(i)/spinnerDots+1, 1)
+			x, y := g.dotCenter(i)
+			scene.Shapes = append(scene.Shapes, Shape{
+				X: x - g.dot/2, Y: y - g.dot/2, Width: g.dot, Height: g.dot, Circle: true,
+				Color: blendHex(fgHex, bgHex, trail*spinnerFade),
+			})
+		}
+	case SpinnerBars:
+		for i := range spinnerBars {
+			h := g.barHeight * spinnerBarScale(phase-float64(i)*spinnerBarLag)
+			scene.Shapes = append(scene.Shapes, Shape{X: g.barX(i), Y: g.center - h/2, Width: g.barWidth, Height: h, Color: fgHex})
+		}
+	default:
+		scene.Shapes = append(scene.Shapes, Shape{
+			X: g.center - g.radius, Y: g.center - g.radius, Width: 2 * g.radius, Height: 2 * g.radius,
+			Circle: true, Stroke: g.stroke, Color: blendHex(fgHex, bgHex, spinnerFade),
+		})
+		// A quarter-turn arc with round ends is a run of overlapping discs
+		start := 2*math.Pi*phase - math.Pi/2
+		steps := max(int(math.Ceil(g.radius*math.Pi/2/(g.stroke/4))), 1)
+		for i := 0; i <= steps; i++ {
+			angle := start + math.Pi/2*float64(i)/float64(steps)
+			x, y := g.center+g.radius*math.Cos(angle), g.center+g.radius*math.Sin(angle)
+			scene.Shapes = append(scene.Shapes, Shape{X: x - g.stroke/2, Y: y - g.stroke/2, Width: g.stroke, Height: g.stroke, Circle: true, Color: fgHex})
+		}
+	}
+	return scene
+}
+
+// spinnerBarScale returns a bar's height at phase of its turn, relative to its
+// full height: at rest, easing up to full height halfway through, and back.
+func spinnerBarScale(phase float64) float64 {
+	phase = math.Mod(phase+1, 1)
+	return spinnerBarMin + (1-spinnerBarMin)*(0.5-0.5*math.Cos(2*math.Pi*phase))
+}
+
+// spinnerSVG writes a spinner as an SVG animated by CSS keyframes. Its classes
+// are named after the style and size, so spinners inlined in one page don't
+// clash. Visitors who prefer reduced motion get a slower spinner rather than a
+// still one, since the motion is what says something is loading.
+func spinnerSVG(size int, style SpinnerStyle, fgHex, bgHex string) []byte {
+	var buf bytes.Buffer
+	g := newSpinnerGeometry(size)
+	period := config.SpinnerPeriod.Seconds()
+	class := fmt.Sprintf("grout-%s-%d", style, size)
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, size, size))
+	buf.WriteString("\n")
+	if bgHex != "" {
+		buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, size, size, bgHex))
+		buf.WriteString("\n")
+	}
+
+	var keyframes, animation string
+	switch style {
+	case SpinnerDots:
+		keyframes = fmt.Sprintf(`@keyframes grout-fade{from{opacity:1}to{opacity:%s}}`, svgNumber(1-spinnerFade))
+		animation = fmt.Sprintf(`.%s{animation:grout-fade %ss linear infinite}`, class, svgNumber(period))
+	case SpinnerBars:
+		keyframes = fmt.Sprintf(`@keyframes grout-bar{0%%,100%%{transform:scaleY(%s)}50%%{transform:scaleY(1)}}`, svgNumber(spinnerBarMin))
+		animation = fmt.Sprintf(`.%s{animation:grout-bar %ss ease-in-out infinite;transform-box:fill-box;transform-origin:center}`, class, svgNumber(period))
+	default:
+		keyframes = `@keyframes grout-spin{to{transform:rotate(360deg)}}`
+		animation = fmt.Sprintf(`.%s{animation:grout-spin %ss linear infinite;transform-origin:%spx %spx}`, class, svgNumber(period), svgNumber(g.center), svgNumber(g.center))
+	}
+	buf.WriteString(fmt.Sprintf(`<style>%s%s@media (prefers-reduced-motion: reduce){.%s{animation-duration:%ss}}</style>`,
+		keyframes, animation, class, svgNumber(3*period)))
+	buf.WriteString("\n")
+
+	switch style {
+	case SpinnerDots:
+		for i := range spinnerDots {
+			// Negative delays start every dot mid-fade, so the trail shows at once
+			x, y := g.dotCenter(i)
+			delay := period * (float64(i)/spinnerDots - 1)
+			buf.WriteString(fmt.Sprintf(`<circle class="%s" style="animation-delay:%ss" cx="%s" cy="%s" r="%s" fill="#%s" />`,
+				class, svgNumber(delay), svgNumber(x), svgNumber(y), svgNumber(g.dot/2), fgHex))
+			buf.WriteString("\n")
+		}
+	case SpinnerBars:
+		for i := range spinnerBars {
+			delay := -period * (1 - float64(i)*spinnerBarLag)
+			buf.WriteString(fmt.Sprintf(`<rect class="%s" style="animation-delay:%ss" x="%s" y="%s" width="%s" height="%s" fill="#%s" />`,
+				class, svgNumber(delay), svgNumber(g.barX(i)), svgNumber(g.center-g.barHeight/2), svgNumber(g.barWidth), svgNumber(g.barHeight), fgHex))
+			buf.WriteString("\n")
+		}
+	default:
+		circumference := 2 * math.Pi * g.radius
+		buf.WriteString(fmt.Sprintf(`<circle cx="%[1]s" cy="%[1]s" r="%[2]s" fill="none" stroke="#%[3]s" stroke-opacity="%[4]s" stroke-width="%[5]s" />`,
+			svgNumber(g.center), svgNumber(g.radius), fgHex, svgNumber(1-spinnerFade), svgNumber(g.stroke)))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<circle class="%[1]s" cx="%[2]s" cy="%[2]s" r="%[3]s" fill="none" stroke="#%[4]s" stroke-width="%[5]s" stroke-linecap="round" stroke-dasharray="%[6]s %[7]s" transform="rotate(-90 %[2]s %[2]s)" />`,
+			class, svgNumber(g.center), svgNumber(g.radius), fgHex, svgNumber(g.stroke), svgNumber(circumference/4), svgNumber(circumference)))
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("</svg>")
+	return buf.Bytes()
+}
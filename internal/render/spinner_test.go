@@ -0,0 +1,87 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/gif"
+	"strings"
+	"testing"
+)
+
+func TestSpinnerSVG(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    SpinnerStyle
+		bg       string
+		expect   []string
+		unwanted []string
+	}{
+		{
+			name:     "ring",
+			style:    SpinnerRing,
+			expect:   []string{"@keyframes grout-spin", ".grout-ring-64{animation:grout-spin 1s linear infinite;transform-origin:32px 32px}", `stroke-linecap="round"`, `stroke-opacity="0.2"`},
+			unwanted: []string{"<rect"},
+		},
+		{
+			name:   "dots",
+			style:  SpinnerDots,
+			expect: []string{"@keyframes grout-fade", `class="grout-dots-64" style="animation-delay:-1s" cx="32"`, "animation-delay:-0.1s"},
+		},
+		{
+			name:   "bars on a background",
+			style:  SpinnerBars,
+			bg:     "000000",
+			expect: []string{`<rect width="64" height="64" fill="#000000" />`, "transform-box:fill-box", "prefers-reduced-motion"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg := string(spinnerSVG(64, tt.style, "e76f51", tt.bg))
+			for _, want := range tt.expect {
+				if !strings.Contains(svg, want) {
+					t.Errorf("expected %q in:\n%s", want, svg)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(svg, unwanted) {
+					t.Errorf("unexpected %q in:\n%s", unwanted, svg)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawSpinnerGIF(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	for _, style := range SpinnerStyles() {
+		t.Run(string(style), func(t *testing.T) {
+			data, err := r.DrawSpinner(context.Background(), 48, style, "e76f51", "", FormatGIF)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			anim, err := gif.DecodeAll(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(anim.Image) != spinnerFrames[style] {
+				t.Errorf("expected %d frames, got %d", spinnerFrames[style], len(anim.Image))
+			}
+			if anim.LoopCount != 0 {
+				t.Errorf("expected a spinner to loop forever, got loop count %d", anim.LoopCount)
+			}
+			// Without a background, GIF spinners are drawn on white
+			if _, _, _, a := anim.Image[0].At(0, 0).RGBA(); a != 0xffff {
+				t.Errorf("expected an opaque corner, got alpha %d", a)
+			}
+		})
+	}
+
+	if _, err := r.DrawSpinner(context.Background(), 48, SpinnerRing, "e76f51", "", FormatPNG); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for PNG, got %v", err)
+	}
+}
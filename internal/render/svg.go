@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"grout/internal/config"
+)
+
+// Shimmer is a highlight band that sweeps across a scene over and over, like a
+// loading skeleton.
+type Shimmer struct {
+	Color   string  // Hex, no '#'
+	Opacity float64 // Opacity of the middle of the band
+}
+
+// shimmerFor returns the shimmer that shows on a background: a white band on
+// most colors, and a faint dark one on near-white, where white wouldn't show.
+func shimmerFor(bg Background) *Shimmer {
+	if bg.Color == "" {
+		return &Shimmer{Color: "ffffff", Opacity: 0.5}
+	}
+	c := ParseHexColor(bg.Color).(color.RGBA)
+	if relativeLuminance(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255) > 0.9 {
+		return &Shimmer{Color: "000000", Opacity: 0.08}
+	}
+	return &Shimmer{Color: "ffffff", Opacity: 0.5}
+}
+
+// writeSVGShimmer writes the animation block of a scene's shimmer: a gradient
+// band, the CSS keyframes that sweep it from one side of the scene to the other
+// every config.ShimmerDuration, and the band itself, clipped to a circular
+// background. The keyframes are named after the width they sweep, so SVGs of
+// different sizes inlined in one page don't clash, and are skipped for visitors
+// who prefer reduced motion.
+func writeSVGShimmer(buf *bytes.Buffer, scene Scene) {
+	s := scene.Shimmer
+	w, h := scene.Width, scene.Height
+	gradientID := fmt.Sprintf("shimmer_%s_%s", s.Color, svgNumber(s.Opacity*100))
+	animation := fmt.Sprintf("grout-shimmer-%d", w)
+
+	buf.WriteString(fmt.Sprintf(`<defs><linearGradient id="%s" x1="0" y1="0" x2="1" y2="0">`, gradientID))
+	buf.WriteString(fmt.Sprintf(`<stop offset="0" stop-color="#%s" stop-opacity="0" />`, s.Color))
+	buf.WriteString(fmt.Sprintf(`<stop offset="0.5" stop-color="#%s" stop-opacity="%s" />`, s.Color, strconv.FormatFloat(s.Opacity, 'f', -1, 64)))
+	buf.WriteString(fmt.Sprintf(`<stop offset="1" stop-color="#%s" stop-opacity="0" />`, s.Color))
+	buf.WriteString(`</linearGradient>`)
+	if scene.Background.Circle {
+		buf.WriteString(fmt.Sprintf(`<clipPath id="%s_clip"><circle cx="%s" cy="%s" r="%s" /></clipPath>`,
+			gradientID, svgNumber(float64(w)/2), svgNumber(float64(h)/2), svgNumber(scene.Background.Radius)))
+	}
+	buf.WriteString(`</defs>`)
+	buf.WriteString("\n")
+
+	buf.WriteString(fmt.Sprintf(`<style>@keyframes %[1]s{from{transform:translateX(-%[2]dpx)}to{transform:translateX(%[2]dpx)}}`+
+		`.%[1]s{animation:%[1]s %[3]s linear infinite}`+
+		`@media (prefers-reduced-motion: reduce){.%[1]s{animation:none;visibility:hidden}}</style>`,
+		animation, w, svgNumber(config.ShimmerDuration.Seconds())+"s"))
+	buf.WriteString("\n")
+
+	band := fmt.Sprintf(`<rect class="%s" width="%d" height="%d" fill="url(#%s)" />`, animation, w, h, gradientID)
+	if scene.Background.Circle {
+		// The clip is on a group so it stays put while the band moves
+		band = fmt.Sprintf(`<g clip-path="url(#%s_clip)">%s</g>`, gradientID, band)
+	}
+	buf.WriteString(band)
+	buf.WriteString("\n")
+}
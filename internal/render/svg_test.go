@@ -0,0 +1,70 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSceneShimmerSVG(t *testing.T) {
+	tests := []struct {
+		name     string
+		bg       Background
+		expect   []string
+		unwanted []string
+	}{
+		{
+			name:     "white band on gray",
+			bg:       Background{Color: "cccccc"},
+			expect:   []string{`stop-color="#ffffff" stop-opacity="0.5"`, "@keyframes grout-shimmer-200{from{transform:translateX(-200px)}", "1.5s linear infinite", "prefers-reduced-motion", `class="grout-shimmer-200"`},
+			unwanted: []string{"clip-path"},
+		},
+		{
+			name:   "dark band on white",
+			bg:     Background{Color: "ffffff"},
+			expect: []string{`stop-color="#000000" stop-opacity="0.08"`},
+		},
+		{
+			name:   "clipped to a circle",
+			bg:     Background{Color: "cccccc", Circle: true, Radius: 50},
+			expect: []string{`<clipPath id="shimmer_ffffff_50_clip"><circle cx="100" cy="50" r="50" />`, `<g clip-path="url(#shimmer_ffffff_50_clip)">`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scene := Scene{Width: 200, Height: 100, Background: tt.bg}
+			scene.Shimmer = shimmerFor(tt.bg)
+			svg := string(scene.svg())
+			for _, want := range tt.expect {
+				if !strings.Contains(svg, want) {
+					t.Errorf("expected %q in:\n%s", want, svg)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(svg, unwanted) {
+					t.Errorf("unexpected %q in:\n%s", unwanted, svg)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawPlaceholderShimmer(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	svg, err := r.DrawPlaceholderShimmer(context.Background(), 300, 100, "cccccc", "333333", "Loading", false, FormatSVG)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	// The band is drawn last, over the text
+	if text, band := strings.Index(string(svg), "Loading"), strings.Index(string(svg), `class="grout-shimmer-300"`); band < text {
+		t.Errorf("expected the band after the text")
+	}
+	if _, err := r.DrawPlaceholderShimmer(context.Background(), 300, 100, "cccccc", "333333", "Loading", false, FormatPNG); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for PNG, got %v", err)
+	}
+}
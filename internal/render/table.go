@@ -0,0 +1,125 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// loremWords fill the cells of placeholder tables.
+var loremWords = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor
+	incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud exercitation ullamco laboris
+	nisi aliquip ex ea commodo consequat duis aute irure in reprehenderit voluptate velit esse cillum fugiat
+	nulla pariatur excepteur sint occaecat cupidatat non proident sunt culpa qui officia deserunt mollit anim`)
+
+// TableStyle holds the colors of a placeholder table, hex without '#'.
+type TableStyle struct {
+	Header     string // Header band
+	Background string // Odd body rows and the space around the table
+	Stripe     string // Even body rows
+	Text       string // Body text; header text contrasts with Header
+}
+
+// DrawTable renders a placeholder data table of rows body rows and cols columns
+// under a header band, with zebra-striped rows of lorem ipsum cells. The cells
+// are picked from seed, so a seed always makes the same table.
+func (r *Renderer) DrawTable(ctx context.Context, w, h, rows, cols int, seed uint64, style TableStyle, format ImageFormat) ([]byte, error) {
+	return r.RenderScene(ctx, r.tableScene(w, h, rows, cols, seed, style, format), format)
+}
+
+// tableScene lays out a placeholder table filling a w x h canvas.
+func (r *Renderer) tableScene(w, h, rows, cols int, seed uint64, style TableStyle, format ImageFormat) Scene {
+	scene := Scene{Width: w, Height: h, Background: Background{Color: style.Background}}
+	rowHeight := float64(h) / float64(rows+1)
+	colWidth := float64(w) / float64(cols)
+	fontSize := max(min(rowHeight*0.4, colWidth/7), 1)
+	headerText := GetContrastColor(style.Header)
+
+	scene.Shapes = append(scene.Shapes, Shape{Width: float64(w), Height: rowHeight, Color: style.Header})
+	for row := 1; row <= rows; row++ {
+		if row%2 == 0 {
+			scene.Shapes = append(scene.Shapes, Shape{Y: float64(row) * rowHeight, Width: float64(w), Height: rowHeight, Color: style.Stripe})
+		}
+	}
+
+	headerFits := r.lineFitsFor(format, colWidth, fontSize, true)
+	bodyFits := r.lineFitsFor(format, colWidth, fontSize, false)
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+	for row := 0; row <= rows; row++ {
+		for col := 0; col < cols; col++ {
+			bold, color, fits := false, style.Text, bodyFits
+			if row == 0 {
+				bold, color, fits = true, headerText, headerFits
+			}
+			scene.Text = append(scene.Text, TextRun{
+				Text:  fitLine(tableCell(rng, row, col, cols), fits),
+				X:     (float64(col) + 0.5) * colWidth,
+				Y:     (float64(row) + 0.5) * rowHeight,
+				Size:  fontSize,
+				Bold:  bold,
+				Color: color,
+			})
+		}
+	}
+	return scene
+}
+
+// tableCell makes up the text of a cell. Row 0 is the header. The first column
+// holds short phrases, the last amounts, and the others words and quantities in
+// turn, like an invoice.
+func tableCell(rng *rand.Rand, row, col, cols int) string {
+	word := func() string { return loremWords[rng.IntN(len(loremWords))] }
+	capitalize := func(s string) string { return strings.ToUpper(s[:1]) + s[1:] }
+	last := col == cols-1 && cols > 1
+	if row == 0 {
+		if last {
+			return "Amount"
+		}
+		return capitalize(word())
+	}
+	switch {
+	case col == 0:
+		return capitalize(word() + " " + word())
+	case last:
+		return formatAmount(rng.IntN(1000000))
+	case col%2 == 0:
+		return fmt.Sprint(rng.IntN(99) + 1)
+	}
+	return word()
+}
+
+// formatAmount formats a number of cents as an amount with thousands separators.
+func formatAmount(cents int) string {
+	whole := fmt.Sprint(cents / 100)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return fmt.Sprintf("%s.%02d", whole, cents%100)
+}
+
+// fitLine shortens text to fit, dropping whole words first and then ending the
+// last one with an ellipsis.
+func fitLine(text string, fits lineFits) string {
+	if fits(text) {
+		return text
+	}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return text
+	}
+	for len(words) > 1 {
+		words = words[:len(words)-1]
+		if line := strings.Join(words, " ") + "…"; fits(line) {
+			return line
+		}
+	}
+	runes := []rune(words[0])
+	for len(runes) > 1 {
+		runes = runes[:len(runes)-1]
+		if line := string(runes) + "…"; fits(line) {
+			return line
+		}
+	}
+	return string(runes)
+}
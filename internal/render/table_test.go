@@ -0,0 +1,74 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableScene(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	style := TableStyle{Header: "2c3e50", Background: "ffffff", Stripe: "eeeeee", Text: "333333"}
+
+	scene := r.tableScene(600, 300, 5, 4, 1, style, FormatPNG)
+	// The header band and a stripe on rows 2 and 4
+	if len(scene.Shapes) != 3 {
+		t.Fatalf("expected 3 bands got %+v", scene.Shapes)
+	}
+	if scene.Shapes[0].Color != "2c3e50" || scene.Shapes[0].Height != 50 || scene.Shapes[1].Y != 100 {
+		t.Errorf("unexpected bands %+v", scene.Shapes)
+	}
+	if len(scene.Text) != 6*4 {
+		t.Fatalf("expected 24 cells got %d", len(scene.Text))
+	}
+	if header := scene.Text[0]; !header.Bold || header.Color != "ffffff" {
+		t.Errorf("expected a bold header in a contrasting color, got %+v", header)
+	}
+	if scene.Text[3].Text != "Amount" || !strings.Contains(scene.Text[7].Text, ".") {
+		t.Errorf("expected an amount column, got %q and %q", scene.Text[3].Text, scene.Text[7].Text)
+	}
+
+	same := r.tableScene(600, 300, 5, 4, 1, style, FormatPNG)
+	other := r.tableScene(600, 300, 5, 4, 2, style, FormatPNG)
+	cells := func(s Scene) string {
+		var texts []string
+		for _, run := range s.Text {
+			texts = append(texts, run.Text)
+		}
+		return strings.Join(texts, "|")
+	}
+	if cells(scene) != cells(same) {
+		t.Error("expected the same seed to make the same table")
+	}
+	if cells(scene) == cells(other) {
+		t.Error("expected another seed to make another table")
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := map[int]string{0: "0.00", 5: "0.05", 123456: "1,234.56", 99999999: "999,999.99", 100000000: "1,000,000.00"}
+	for cents, expect := range tests {
+		if got := formatAmount(cents); got != expect {
+			t.Errorf("formatAmount(%d): expected %s got %s", cents, expect, got)
+		}
+	}
+}
+
+func TestFitLine(t *testing.T) {
+	// Up to 8 bytes fit
+	fits := func(line string) bool { return len(line) <= 8 }
+	tests := []struct {
+		text, expect string
+	}{
+		{"lorem", "lorem"},
+		{"lorem ipsum", "lorem…"},
+		{"consectetur", "conse…"},
+	}
+	for _, tt := range tests {
+		if got := fitLine(tt.text, fits); got != tt.expect {
+			t.Errorf("fitLine(%q): expected %q got %q", tt.text, tt.expect, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+)
+
+// textPadding is the transparent margin around text-only images, relative to the font size.
+const textPadding = 0.2
+
+// MeasureText returns the canvas size needed to draw text in the named font at fontSize,
+// including a small padding on every side.
+func (r *Renderer) MeasureText(text, fontName string, fontSize float64) (int, int) {
+	face := truetype.NewFace(r.font(fontName).ttf, &truetype.Options{Size: fontSize})
+	defer face.Close()
+
+	dc := gg.NewContext(1, 1)
+	dc.SetFontFace(face)
+	textWidth, _ := dc.MeasureString(text)
+
+	metrics := face.Metrics()
+	textHeight := float64(metrics.Ascent+metrics.Descent) / 64
+	padding := fontSize * textPadding
+
+	return int(math.Ceil(textWidth + 2*padding)), int(math.Ceil(textHeight + 2*padding))
+}
+
+// DrawText renders text alone on a transparent canvas sized to the text bounds.
+func (r *Renderer) DrawText(ctx context.Context, text, fontName string, fontSize float64, fgHex string, format ImageFormat) ([]byte, error) {
+	spec := r.font(fontName)
+	w, h := r.MeasureText(text, fontName, fontSize)
+
+	face := truetype.NewFace(spec.ttf, &truetype.Options{Size: fontSize})
+	defer face.Close()
+	metrics := face.Metrics()
+	ascent := float64(metrics.Ascent) / 64
+	baseline := fontSize*textPadding + ascent
+
+	if format == FormatSVG {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h))
+		buf.WriteString("\n")
+		buf.WriteString(fmt.Sprintf(`<text x="%d" y="%.0f" font-family="%s" font-size="%.0f" font-weight="%s" font-style="%s" font-variant="%s" fill="#%s" text-anchor="middle">%s</text>`,
+			w/2, baseline, spec.family, fontSize, spec.weight, spec.style, spec.variant, fgHex, escapeXML(text)))
+		buf.WriteString("\n")
+		buf.WriteString("</svg>")
+		return buf.Bytes(), nil
+	}
+
+	dc := gg.NewContext(w, h)
+	if format == FormatJPG || format == FormatJPEG {
+		// JPEG has no alpha channel, so use a white canvas instead of black
+		dc.SetColor(ParseHexColor("ffffff"))
+		dc.Clear()
+	}
+	dc.SetFontFace(textOptions(ctx).face(spec.ttf, fontSize))
+	dc.SetColor(ParseHexColor(fgHex))
+	dc.DrawStringAnchored(text, float64(w)/2, baseline, 0.5, 0)
+
+	return encodeImage(ctx, dc.Image(), format)
+}
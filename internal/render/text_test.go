@@ -0,0 +1,73 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestMeasureTextScalesWithSize(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	smallW, smallH := r.MeasureText("Hello", "regular", 16)
+	largeW, largeH := r.MeasureText("Hello", "regular", 64)
+	if largeW <= smallW || largeH <= smallH {
+		t.Fatalf("expected larger font to need a larger canvas, got %dx%d vs %dx%d", smallW, smallH, largeW, largeH)
+	}
+
+	shortW, _ := r.MeasureText("Hi", "regular", 32)
+	longW, _ := r.MeasureText("Hello, World", "regular", 32)
+	if longW <= shortW {
+		t.Fatalf("expected longer text to be wider, got %d vs %d", shortW, longW)
+	}
+}
+
+func TestDrawTextTransparentPNG(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	data, err := r.DrawText(context.Background(), "Heading", "bold", 32, "333333", FormatPNG)
+	if err != nil {
+		t.Fatalf("failed to draw text: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+
+	wantW, wantH := r.MeasureText("Heading", "bold", 32)
+	if b := img.Bounds(); b.Dx() != wantW || b.Dy() != wantH {
+		t.Fatalf("expected %dx%d canvas, got %dx%d", wantW, wantH, b.Dx(), b.Dy())
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Fatalf("expected transparent corner, got alpha %d", a)
+	}
+}
+
+func TestDrawTextSVGFontAttributes(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	data, err := r.DrawText(context.Background(), "a < b", "mono-bold", 24, "000000", FormatSVG)
+	if err != nil {
+		t.Fatalf("failed to draw text: %v", err)
+	}
+	svgStr := string(data)
+	for _, want := range []string{`font-family="monospace"`, `font-weight="bold"`, "a &lt; b"} {
+		if !strings.Contains(svgStr, want) {
+			t.Errorf("expected SVG to contain %q, got: %s", want, svgStr)
+		}
+	}
+	if strings.Contains(svgStr, "<rect") {
+		t.Errorf("expected no background, got: %s", svgStr)
+	}
+}
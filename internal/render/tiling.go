@@ -0,0 +1,122 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+const (
+	// DefaultPatternSpacing is the grid spacing in pixels for tiled patterns.
+	DefaultPatternSpacing = 20
+	// MinPatternSpacing keeps tiled patterns from becoming a solid fill (and cheap to draw).
+	MinPatternSpacing = 6
+	// MaxPatternSpacing bounds the tile size of tiled patterns.
+	MaxPatternSpacing = 200
+)
+
+// blendHex mixes a toward b by t (0–1).
+func blendHex(a, b string, t float64) string {
+	ca := ParseHexColor(a).(color.RGBA)
+	cb := ParseHexColor(b).(color.RGBA)
+	mix := func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t)) }
+	return fmt.Sprintf("%02x%02x%02x", mix(ca.R, cb.R), mix(ca.G, cb.G), mix(ca.B, cb.B))
+}
+
+// tilingColors returns the background and line colors for a tiled pattern. Without
+// an explicit line color, lines are a faint step from the background toward its
+// contrast color.
+func tilingColors(opts PatternOptions) (string, string) {
+	bgHex := opts.Colors[0]
+	lineHex := opts.LineColor
+	if lineHex == "" {
+		lineHex = blendHex(bgHex, GetContrastColor(bgHex), 0.25)
+	}
+	return bgHex, lineHex
+}
+
+// tilingSpacing clamps the requested spacing, using the default when unset.
+func tilingSpacing(spacing int) int {
+	if spacing <= 0 {
+		return DefaultPatternSpacing
+	}
+	return max(MinPatternSpacing, min(spacing, MaxPatternSpacing))
+}
+
+// isoGridBackground draws engineering-paper style isometric lines: verticals every
+// spacing pixels crossed by diagonals at ±30°.
+func isoGridBackground(w, h int, opts PatternOptions) background {
+	bgHex, lineHex := tilingColors(opts)
+	dx := float64(tilingSpacing(opts.Spacing))
+	slope := math.Tan(math.Pi / 6)
+	// Height of one tile; diagonals leave each tile where the next one picks them up
+	th := 2 * dx * slope
+
+	return background{
+		svg: func(buf *bytes.Buffer) {
+			buf.WriteString(fmt.Sprintf(`<defs><pattern id="tile_isogrid" width="%.3f" height="%.3f" patternUnits="userSpaceOnUse">`, dx, th))
+			buf.WriteString(fmt.Sprintf(`<path d="M0,0 V%.3f M0,0 L%.3f,%.3f M0,%.3f L%.3f,%.3f M0,%.3f L%.3f,0 M0,%.3f L%.3f,%.3f" stroke="#%s" stroke-width="1" fill="none" />`,
+				th, dx, th/2, th/2, dx, th, th/2, dx, th, dx, th/2, lineHex))
+			buf.WriteString("</pattern></defs>\n")
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, w, h, bgHex))
+			buf.WriteString("\n")
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="url(#tile_isogrid)" />`, w, h))
+			buf.WriteString("\n")
+		},
+		raster: func(dc *gg.Context) {
+			dc.SetColor(ParseHexColor(bgHex))
+			dc.DrawRectangle(0, 0, float64(w), float64(h))
+			dc.Fill()
+
+			fw, fh := float64(w), float64(h)
+			dc.SetColor(ParseHexColor(lineHex))
+			dc.SetLineWidth(1)
+			for x := 0.0; x <= fw; x += dx {
+				dc.DrawLine(x, 0, x, fh)
+			}
+			// Diagonals y = ±slope·x + b, with intercepts every half tile
+			rise := fw * slope
+			for b := -rise; b <= fh+rise; b += th / 2 {
+				dc.DrawLine(0, b, fw, b+rise)
+				dc.DrawLine(0, b, fw, b-rise)
+			}
+			dc.Stroke()
+		},
+	}
+}
+
+// dotsBackground draws a dot-matrix grid with one dot per spacing×spacing tile.
+func dotsBackground(w, h int, opts PatternOptions) background {
+	bgHex, lineHex := tilingColors(opts)
+	spacing := tilingSpacing(opts.Spacing)
+	radius := math.Max(1, float64(spacing)*0.08)
+	center := float64(spacing) / 2
+
+	return background{
+		svg: func(buf *bytes.Buffer) {
+			buf.WriteString(fmt.Sprintf(`<defs><pattern id="tile_dots" width="%d" height="%d" patternUnits="userSpaceOnUse">`, spacing, spacing))
+			buf.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="#%s" />`, center, center, radius, lineHex))
+			buf.WriteString("</pattern></defs>\n")
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#%s" />`, w, h, bgHex))
+			buf.WriteString("\n")
+			buf.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="url(#tile_dots)" />`, w, h))
+			buf.WriteString("\n")
+		},
+		raster: func(dc *gg.Context) {
+			// Draw a single tile and repeat it across the canvas
+			tile := gg.NewContext(spacing, spacing)
+			tile.SetColor(ParseHexColor(bgHex))
+			tile.Clear()
+			tile.SetColor(ParseHexColor(lineHex))
+			tile.DrawCircle(center, center, radius)
+			tile.Fill()
+
+			dc.SetFillStyle(gg.NewSurfacePattern(tile.Image(), gg.RepeatBoth))
+			dc.DrawRectangle(0, 0, float64(w), float64(h))
+			dc.Fill()
+		},
+	}
+}
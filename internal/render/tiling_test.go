@@ -0,0 +1,103 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestTilingSpacing(t *testing.T) {
+	tests := []struct {
+		name    string
+		spacing int
+		expect  int
+	}{
+		{"unset", 0, DefaultPatternSpacing},
+		{"negative", -5, DefaultPatternSpacing},
+		{"in range", 32, 32},
+		{"too small", 2, MinPatternSpacing},
+		{"too large", 1000, MaxPatternSpacing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tilingSpacing(tt.spacing); got != tt.expect {
+				t.Fatalf("expected %d got %d", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestBlendHex(t *testing.T) {
+	if got := blendHex("000000", "ffffff", 0.5); got != "808080" {
+		t.Fatalf("expected 808080 got %s", got)
+	}
+	if got := blendHex("ff0000", "0000ff", 0); got != "ff0000" {
+		t.Fatalf("expected ff0000 got %s", got)
+	}
+}
+
+func TestDrawTiledPatternSVG(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+
+	tests := []struct {
+		pattern Pattern
+		id      string
+	}{
+		{PatternIsoGrid, `id="tile_isogrid"`},
+		{PatternDots, `id="tile_dots"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.pattern), func(t *testing.T) {
+			opts := PatternOptions{Colors: []string{"ffffff"}, Spacing: 24, LineColor: "3498db"}
+			data, err := r.DrawPattern(context.Background(), 400, 200, tt.pattern, opts, "", "000000", false, false, FormatSVG)
+			if err != nil {
+				t.Fatalf("failed to draw pattern: %v", err)
+			}
+			svgStr := string(data)
+			if !strings.Contains(svgStr, tt.id) || !strings.Contains(svgStr, "#3498db") {
+				t.Fatalf("expected tiled pattern with line color, got: %s", svgStr)
+			}
+		})
+	}
+}
+
+func TestDrawDotsPatternPNG(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	opts := PatternOptions{Colors: []string{"ffffff"}, Spacing: 20, LineColor: "000000"}
+
+	data, err := r.DrawPattern(context.Background(), 100, 100, PatternDots, opts, "", "000000", false, false, FormatPNG)
+	if err != nil {
+		t.Fatalf("failed to draw dots: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+
+	// Count dark pixels: dots are drawn, but most of the tile stays background
+	var dark, total int
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if c.R < 128 {
+				dark++
+			}
+			total++
+		}
+	}
+	if dark == 0 || dark > total/4 {
+		t.Fatalf("expected sparse dots, got %d of %d dark pixels", dark, total)
+	}
+}
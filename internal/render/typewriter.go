@@ -0,0 +1,78 @@
+package render
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+
+	"grout/internal/config"
+)
+
+// AnimationOptions are the timing of a generated animation.
+type AnimationOptions struct {
+	FPS  int // Frames per second
+	Loop int // Times to play, or 0 to loop forever
+}
+
+// DrawPlaceholderTypewriter renders a placeholder like DrawPlaceholderImage as an
+// animation that types its text out, one character a frame.
+func (r *Renderer) DrawPlaceholderTypewriter(ctx context.Context, w, h int, bgHex, fgHex, text string, isQuoteOrJoke bool, opts AnimationOptions, format ImageFormat) ([]byte, error) {
+	scene := r.placeholderScene(w, h, bgHex, fgHex, text, isQuoteOrJoke, format)
+	return r.RenderAnimation(ctx, Animation{Frames: r.typewriterFrames(scene, opts.FPS), Loop: opts.Loop}, format)
+}
+
+// typewriterFrames returns frames typing out the text of scene at fps, with a
+// caret after the last character typed, and then holding the finished scene
+// for config.TypewriterHold. Each line keeps the left edge it has when finished,
+// so typed text doesn't move. Text longer than config.MaxAnimationFrames
+// characters is typed several characters a frame.
+func (r *Renderer) typewriterFrames(scene Scene, fps int) []Frame {
+	type typedLine struct {
+		run   TextRun
+		runes []rune
+		left  float64
+		face  font.Face
+	}
+	lines := make([]typedLine, len(scene.Text))
+	total := 0
+	for i, run := range scene.Text {
+		face := truetype.NewFace(r.runFont(run.Font, run.Bold), &truetype.Options{Size: run.Size})
+		width := float64(font.MeasureString(face, run.Text)) / 64
+		lines[i] = typedLine{run: run, runes: []rune(run.Text), left: run.X - width/2, face: face}
+		total += len(lines[i].runes)
+	}
+
+	perFrame := max((total+config.MaxAnimationFrames-2)/(config.MaxAnimationFrames-1), 1)
+	delay := time.Second / time.Duration(fps)
+	var frames []Frame
+	for typed := 0; typed < total; typed += perFrame {
+		frame := scene
+		frame.Text = nil
+		frame.Shapes = append([]Shape(nil), scene.Shapes...)
+		remaining := typed
+		for _, line := range lines {
+			n := min(remaining, len(line.runes))
+			remaining -= n
+			shown := string(line.runes[:n])
+			width := float64(font.MeasureString(line.face, shown)) / 64
+			if n > 0 {
+				run := line.run
+				run.Text, run.X = shown, line.left+width/2
+				frame.Text = append(frame.Text, run)
+			}
+			if n < len(line.runes) {
+				// The caret waits after the last character typed, a tenth of an em wide
+				size := line.run.Size
+				frame.Shapes = append(frame.Shapes, Shape{
+					X: line.left + width + size*0.05, Y: line.run.Y - size/2,
+					Width: max(size/10, 1), Height: size, Color: line.run.Color,
+				})
+				break
+			}
+		}
+		frames = append(frames, Frame{Scene: frame, Delay: delay})
+	}
+	return append(frames, Frame{Scene: scene, Delay: config.TypewriterHold})
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+
+	"grout/internal/config"
+)
+
+func TestTypewriterFrames(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+
+	scene := r.placeholderScene(400, 200, "cccccc", "333333", "Hello", false, FormatGIF)
+	frames := r.typewriterFrames(scene, 10)
+	if len(frames) != 6 {
+		t.Fatalf("expected 6 frames for 5 characters, got %d", len(frames))
+	}
+	for i, frame := range frames[:5] {
+		typed := ""
+		if len(frame.Scene.Text) > 0 {
+			typed = frame.Scene.Text[0].Text
+		}
+		if typed != "Hello"[:i] {
+			t.Errorf("frame %d: expected %q typed, got %q", i, "Hello"[:i], typed)
+		}
+		if len(frame.Scene.Shapes) != 1 {
+			t.Errorf("frame %d: expected a caret", i)
+		}
+	}
+	if last := frames[5]; last.Scene.Text[0].Text != "Hello" || len(last.Scene.Shapes) != 0 || last.Delay != config.TypewriterHold {
+		t.Errorf("expected the last frame to hold the finished text without a caret")
+	}
+
+	// Typed text keeps the left edge of the finished line
+	left := func(run TextRun) float64 {
+		face := truetype.NewFace(r.runFont(run.Font, run.Bold), &truetype.Options{Size: run.Size})
+		return run.X - float64(font.MeasureString(face, run.Text))/128
+	}
+	if a, b := left(frames[2].Scene.Text[0]), left(frames[4].Scene.Text[0]); a-b > 1 || b-a > 1 {
+		t.Errorf("expected typed text to stay put, left edges %.1f and %.1f", a, b)
+	}
+
+	long := r.placeholderScene(400, 200, "cccccc", "333333", strings.Repeat("a", 1000), false, FormatGIF)
+	if n := len(r.typewriterFrames(long, 10)); n > config.MaxAnimationFrames {
+		t.Errorf("expected at most %d frames, got %d", config.MaxAnimationFrames, n)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build cgo
+
+package render
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+func init() {
+	registerWebPBackend(&webpBackend{name: WebPCgo, lossy: true, encode: func(w io.Writer, img image.Image, quality int) error {
+		return webp.Encode(w, img, &webp.Options{Lossless: false, Quality: float32(quality)})
+	}})
+}
@@ -0,0 +1,16 @@
+package render
+
+import (
+	"image"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// The native backend only encodes losslessly, so WebP images are larger than
+// the cgo backend's and ignore the quality.
+func init() {
+	registerWebPBackend(&webpBackend{name: WebPNative, encode: func(w io.Writer, img image.Image, _ int) error {
+		return nativewebp.Encode(w, img, nil)
+	}})
+}
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Swatch is one of the dominant colors of an image.
+type Swatch struct {
+	Hex string `json:"hex"`
+	// Weight is the share of the image's pixels closest to the color
+	Weight float64 `json:"weight"`
+}
+
+// Palette returns up to count dominant colors of the image at imageURL, which
+// must be on a host the server may fetch from. A count of 0 keeps the server's
+// default.
+func (c *Client) Palette(ctx context.Context, imageURL string, count int) ([]Swatch, error) {
+	p := params{}
+	p.str("url", imageURL)
+	p.num("count", count)
+	var resp struct {
+		Colors []Swatch `json:"colors"`
+	}
+	if err := c.getJSON(ctx, "/api/v1/palette", url.Values(p), &resp); err != nil {
+		return nil, err
+	}
+	return resp.Colors, nil
+}
+
+// DiffResult is the perceptual difference of two images.
+type DiffResult struct {
+	// Score is the mean difference, from 0 for identical images to 1
+	Score     float64 `json:"score"`
+	Identical bool    `json:"identical"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	// ChangedPixels counts the pixels that differ by more than the threshold
+	ChangedPixels int `json:"changed_pixels"`
+	// Diff is a PNG data URL of the first image in gray with changes in red
+	Diff string `json:"diff"`
+}
+
+// Diff compares two raster images on the server. Pixels that differ by more
+// than threshold, from 0 to 1, count as changed; 0 keeps the server's default.
+func (c *Client) Diff(ctx context.Context, a, b Image, threshold float64) (*DiffResult, error) {
+	query := url.Values{"a": {a.Path()}, "b": {b.Path()}}
+	if threshold != 0 {
+		query.Set("threshold", strconv.FormatFloat(threshold, 'f', -1, 64))
+	}
+	var result DiffResult
+	if err := c.getJSON(ctx, "/api/v1/diff", query, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Validation is the server's verdict on an image URL.
+type Validation struct {
+	Valid bool `json:"valid"`
+	// URL is the path and query of the image in canonical form, or as given
+	// when it's signed
+	URL         string `json:"url"`
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	// Warnings are changes the server makes to serve the image, such as a
+	// size cut to its maximum
+	Warnings []string `json:"warnings"`
+	// Errors are why the image wouldn't render, with their codes
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Validate checks an image URL, such as one a user entered, without rendering
+// it: a path such as /placeholder/300x200.png, or an absolute URL on the
+// server. A URL that wouldn't render is not an error; its Validation says why.
+func (c *Client) Validate(ctx context.Context, imageURL string) (*Validation, error) {
+	var result Validation
+	if err := c.getJSON(ctx, "/api/v1/validate", url.Values{"url": {imageURL}}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ThemeOption is a choice the image endpoints offer, with a sample image of it.
+type ThemeOption struct {
+	Name string `json:"name"`
+	// Sample is the path and query of an image showing the choice, signed
+	// when the server requires signatures
+	Sample string `json:"sample"`
+}
+
+// Themes is the theme of the client's host, and the choices the image
+// endpoints offer, for filling pickers.
+type Themes struct {
+	Theme struct {
+		Name                  string `json:"name"`
+		BrandColor            string `json:"brand_color"`
+		AvatarBackground      string `json:"avatar_background"`
+		PlaceholderBackground string `json:"placeholder_background"`
+		TextColor             string `json:"text_color"`
+		AccentColor           string `json:"accent_color"`
+		Font                  string `json:"font"`
+	} `json:"theme"`
+	Schemes      []ThemeOption `json:"schemes"`
+	Fonts        []ThemeOption `json:"fonts"`
+	Patterns     []ThemeOption `json:"patterns"`
+	Art          []ThemeOption `json:"art"`
+	Effects      []ThemeOption `json:"effects"`
+	Animations   []ThemeOption `json:"animations"`
+	Dividers     []ThemeOption `json:"dividers"`
+	Spinners     []ThemeOption `json:"spinners"`
+	AvatarStyles []ThemeOption `json:"avatar_styles"`
+	Templates    []ThemeOption `json:"templates"`
+}
+
+// Themes returns the server's theme and the choices its image endpoints offer.
+func (c *Client) Themes(ctx context.Context) (*Themes, error) {
+	var themes Themes
+	if err := c.getJSON(ctx, "/api/v1/themes", nil, &themes); err != nil {
+		return nil, err
+	}
+	return &themes, nil
+}
+
+// Render renders a layout document, a value that encodes to the JSON the
+// render endpoint takes, in format. It returns the image and its content type.
+func (c *Client) Render(ctx context.Context, layout any, format Format) ([]byte, string, error) {
+	doc, err := json.Marshal(layout)
+	if err != nil {
+		return nil, "", fmt.Errorf("client: encode layout: %w", err)
+	}
+	if format != FormatDefault {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(doc, &fields); err != nil {
+			return nil, "", fmt.Errorf("client: layout is not a JSON object: %w", err)
+		}
+		fields["format"], _ = json.Marshal(format)
+		doc, _ = json.Marshal(fields)
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/api/v1/render", url.Values{}, bytes.NewReader(doc), http.StatusOK)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// Job statuses.
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Job is an image rendering in the background.
+type Job struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+	// URL is the image's path; it's cached there once the job is done
+	URL string `json:"url"`
+	// Code and Error say why a failed job failed
+	Code       string     `json:"code,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMS int64      `json:"duration_ms,omitempty"`
+}
+
+// Finished reports whether the job is done or failed.
+func (j *Job) Finished() bool {
+	return j.Status == JobDone || j.Status == JobFailed
+}
+
+// err returns the job's failure as an *Error, or nil.
+func (j *Job) err() error {
+	if j.Status != JobFailed {
+		return nil
+	}
+	return &Error{Code: j.Code, Message: j.Error}
+}
+
+// Async queues the image to render in the background, returning its job. An
+// image that's already cached comes back as a done job.
+func (img Image) Async(ctx context.Context) (*Job, error) {
+	query := url.Values{}
+	for k, v := range img.query {
+		query[k] = v
+	}
+	query.Set("async", "true")
+	resp, err := img.c.do(ctx, http.MethodGet, img.path, query, nil, http.StatusOK, http.StatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var job Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Job looks up the status of a render job.
+func (c *Client) Job(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := c.getJSON(ctx, "/api/v1/jobs/"+url.PathEscape(id), url.Values{}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Wait polls a job every interval until it finishes or ctx is done. A job that
+// failed is returned with its failure as an *Error.
+func (c *Client) Wait(ctx context.Context, job *Job, interval time.Duration) (*Job, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for !job.Finished() {
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+		next, err := c.Job(ctx, job.ID)
+		if err != nil {
+			return job, err
+		}
+		job = next
+	}
+	return job, job.err()
+}
+
+// RenderBatch queues every image to render in the background, then waits for
+// them all, polling every interval, so they're cached for the requests that
+// follow. It returns the finished jobs in the order of images, and the errors
+// of those that failed joined together.
+func (c *Client) RenderBatch(ctx context.Context, images []Image, interval time.Duration) ([]*Job, error) {
+	jobs := make([]*Job, len(images))
+	var errs []error
+	for i, img := range images {
+		job, err := img.Async(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", img.path, err))
+			continue
+		}
+		jobs[i] = job
+	}
+	for i, job := range jobs {
+		if job == nil {
+			continue
+		}
+		finished, err := c.Wait(ctx, job, interval)
+		jobs[i] = finished
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", images[i].path, err))
+		}
+	}
+	return jobs, errors.Join(errs...)
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"grout/internal/config"
+)
+
+func TestRender(t *testing.T) {
+	c := newTestClient(t, testSigningKey, signingServer)
+	layout := map[string]any{
+		"width": 200, "height": 80, "background": "ffffff",
+		"root": map[string]any{"type": "text", "text": "Admit One", "size": 24},
+	}
+	data, contentType, err := c.Render(context.Background(), layout, FormatPNG)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if contentType != "image/png" || len(data) == 0 {
+		t.Fatalf("expected a PNG, got %q with %d bytes", contentType, len(data))
+	}
+
+	layout["root"] = map[string]any{"type": "video"}
+	if _, _, err := c.Render(context.Background(), layout, FormatDefault); !IsCode(err, "invalid_parameter") || !strings.Contains(err.Error(), "video") {
+		t.Fatalf("expected an invalid_parameter error naming the node, got %v", err)
+	}
+	if _, _, err := c.Render(context.Background(), []int{1}, FormatPNG); err == nil {
+		t.Fatal("expected a layout that isn't an object to be refused")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	c := newTestClient(t, "", nil)
+	a := c.Placeholder(60, 40, PlaceholderOptions{Text: "A", Format: FormatPNG})
+	same, err := c.Diff(context.Background(), a, a, 0)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !same.Identical || same.Score != 0 || same.Width != 60 {
+		t.Fatalf("expected identical images, got %+v", same)
+	}
+	changed, err := c.Diff(context.Background(), a, a.With("bg", "000000"), 0.05)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if changed.Identical || changed.ChangedPixels == 0 || !strings.HasPrefix(changed.Diff, "data:image/png;base64,") {
+		t.Fatalf("expected a difference, got %+v", changed)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := newTestClient(t, "", nil)
+	result, err := c.Validate(context.Background(), "/placeholder/300x200.PNG?text=Hi&bg=FF0000")
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if !result.Valid || result.URL != "/placeholder/300x200.png?bg=ff0000&text=Hi" || result.Width != 300 {
+		t.Fatalf("expected a valid URL in canonical form, got %+v", result)
+	}
+	result, err = c.Validate(context.Background(), "/placeholder/300x200?scheme=sepia")
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Code != "invalid_parameter" {
+		t.Fatalf("expected an invalid_parameter error, got %+v", result)
+	}
+}
+
+func TestThemes(t *testing.T) {
+	c := newTestClient(t, "", nil)
+	themes, err := c.Themes(context.Background())
+	if err != nil {
+		t.Fatalf("themes: %v", err)
+	}
+	if themes.Theme.AvatarBackground != config.DefaultAvatarBg || len(themes.Patterns) == 0 || len(themes.Fonts) == 0 {
+		t.Fatalf("expected the default theme and its choices, got %+v", themes)
+	}
+}
+
+func TestPalette(t *testing.T) {
+	c := newTestClient(t, "", nil)
+	// Without allowed hosts the server can't fetch images at all
+	_, err := c.Palette(context.Background(), "https://assets.example.com/team.jpg", 3)
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "feature_disabled" || e.Status != 404 {
+		t.Fatalf("expected feature_disabled, got %v", err)
+	}
+}
+
+func TestRenderBatch(t *testing.T) {
+	c := newTestClient(t, testSigningKey, signingServer)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	images := []Image{
+		c.Placeholder(300, 200, PlaceholderOptions{Text: "one", Format: FormatPNG}),
+		c.Avatar("Jane Doe", AvatarOptions{Format: FormatPNG}),
+		// Can't fit in a byte, so its job fails
+		c.Placeholder(300, 200, PlaceholderOptions{Format: FormatSVG}).With("maxBytes", "1"),
+	}
+	jobs, err := c.RenderBatch(ctx, images, 10*time.Millisecond)
+	if len(jobs) != 3 || jobs[0].Status != JobDone || jobs[1].Status != JobDone || jobs[2].Status != JobFailed {
+		t.Fatalf("expected two done jobs and a failed one, got %+v", jobs)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "over_budget" {
+		t.Fatalf("expected the failed job's over_budget error, got %v", err)
+	}
+
+	// Done images are cached at their URL
+	data, _, err := images[0].Fetch(ctx)
+	if err != nil || len(data) == 0 {
+		t.Fatalf("expected the rendered image, got %d bytes, %v", len(data), err)
+	}
+	again, err := images[0].Async(ctx)
+	if err != nil || again.Status != JobDone {
+		t.Fatalf("expected a cached image to come back done, got %+v, %v", again, err)
+	}
+	if _, err := c.Job(ctx, jobs[1].ID); err != nil {
+		t.Fatalf("job: %v", err)
+	}
+}
+
+func TestRenderBatchAsyncOff(t *testing.T) {
+	c := newTestClient(t, "", func(cfg *config.ServerConfig) { cfg.AsyncWorkers = 0 })
+	images := []Image{c.Image("/placeholder/10x10.png", url.Values{})}
+	if _, err := c.RenderBatch(context.Background(), images, time.Millisecond); err == nil {
+		t.Fatal("expected an error when the server doesn't render in the background")
+	}
+}
@@ -0,0 +1,177 @@
+// Package client is a Go client for a Grout server. It builds and signs image
+// URLs from typed options, fetches images, and wraps the JSON API and async
+// render jobs, so services integrate without hand-building query strings.
+//
+// The package only depends on the standard library, so it can be vendored on
+// its own.
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxErrorBytes caps how much of an error response is read for its message.
+const maxErrorBytes = 64 << 10
+
+// Client talks to one Grout server. It's safe for concurrent use.
+type Client struct {
+	// HTTPClient sends the client's requests; nil uses http.DefaultClient.
+	HTTPClient *http.Client
+	base       *url.URL
+	signingKey string
+}
+
+// New returns a client of the server at baseURL, such as
+// https://img.example.com. signingKey is the server's SIGNING_KEY, which every
+// URL is signed with; leave it empty for servers that don't require signed
+// URLs.
+func New(baseURL, signingKey string) (*Client, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("client: base URL %q is not an absolute http or https URL", baseURL)
+	}
+	// Signatures cover the path the server sees, which a prefix would change
+	if strings.Trim(base.Path, "/") != "" || base.RawQuery != "" {
+		return nil, fmt.Errorf("client: base URL %q must not have a path or query", baseURL)
+	}
+	base.Path = ""
+	return &Client{base: base, signingKey: signingKey}, nil
+}
+
+// Error is an error response from the server, or the failure of a job.
+type Error struct {
+	// Status is the response's HTTP status, 0 for a job
+	Status int
+	// Code is the server's stable error code, such as "invalid_parameter"
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Code == "":
+		return fmt.Sprintf("grout: %d: %s", e.Status, e.Message)
+	case e.Status == 0:
+		return fmt.Sprintf("grout: %s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("grout: %s (%d): %s", e.Code, e.Status, e.Message)
+}
+
+// IsCode reports whether err is an Error with the given code.
+func IsCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// sign adds the signature of an escaped path and its query to the query, when
+// the client has a signing key: the hex HMAC-SHA256 of "{path}?{query}", with
+// the path unescaped as the server reads it, and the query sorted by key
+// without sig and async, which don't change the image.
+func (c *Client) sign(path string, query url.Values) url.Values {
+	signed, covered := url.Values{}, url.Values{}
+	for k, v := range query {
+		if k != "sig" {
+			signed[k] = v
+		}
+		if k != "sig" && k != "async" {
+			covered[k] = v
+		}
+	}
+	if c.signingKey != "" {
+		if unescaped, err := url.PathUnescape(path); err == nil {
+			path = unescaped
+		}
+		mac := hmac.New(sha256.New, []byte(c.signingKey))
+		mac.Write([]byte(path + "?" + covered.Encode()))
+		signed.Set("sig", hex.EncodeToString(mac.Sum(nil)))
+	}
+	return signed
+}
+
+// target returns an escaped path with its signed query.
+func (c *Client) target(path string, query url.Values) string {
+	if encoded := c.sign(path, query).Encode(); encoded != "" {
+		return path + "?" + encoded
+	}
+	return path
+}
+
+// resolve returns the absolute URL of an escaped path and its signed query on
+// the server.
+func (c *Client) resolve(path string, query url.Values) string {
+	return c.base.String() + c.target(path, query)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// do sends a signed request, returning the response when its status is one of
+// ok and an *Error otherwise. The caller closes the body.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, ok ...int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.resolve(path, query), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range ok {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	return nil, readError(resp)
+}
+
+// readError reads an error response: the code from the X-Error-Code header, and
+// the message from a JSON body, else the status text.
+func readError(resp *http.Response) *Error {
+	e := &Error{Status: resp.StatusCode, Code: resp.Header.Get("X-Error-Code"), Message: http.StatusText(resp.StatusCode)}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var body struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, maxErrorBytes)).Decode(&body) == nil {
+			if body.Error != "" {
+				e.Message = body.Error
+			}
+			if e.Code == "" {
+				e.Code = body.Code
+			}
+		}
+	}
+	return e
+}
+
+// getJSON sends a signed GET request and decodes its JSON response into v.
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"grout/internal/config"
+	"grout/internal/handlers"
+	"grout/internal/middleware"
+	"grout/internal/render"
+)
+
+const testSigningKey = "test-signing-key"
+
+// newTestClient starts a Grout server for the test, configured by configure,
+// and returns a client of it signing with signingKey.
+func newTestClient(t *testing.T, signingKey string, configure func(*config.ServerConfig)) *Client {
+	t.Helper()
+	renderer, err := render.New()
+	if err != nil {
+		t.Fatalf("renderer init: %v", err)
+	}
+	cache, _ := lru.New[string, []byte](100)
+	cfg := config.DefaultServerConfig()
+	cfg.RateLimitRPM, cfg.RateLimitBurst = 10000, 1000
+	if configure != nil {
+		configure(&cfg)
+	}
+	svc := handlers.NewService(renderer, cache, cfg)
+	mux := http.NewServeMux()
+	svc.RegisterRoutes(mux, middleware.NewRateLimiter(cfg.RateLimitRPM, cfg.RateLimitBurst))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	c, err := New(server.URL, signingKey)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+func signingServer(cfg *config.ServerConfig) {
+	cfg.SigningKey = testSigningKey
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		valid   bool
+	}{
+		{"https://img.example.com", true},
+		{"http://localhost:8080/", true},
+		{"img.example.com", false},
+		{"ftp://img.example.com", false},
+		{"https://example.com/grout", false},
+		{"https://img.example.com?x=1", false},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.baseURL, "")
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid %v, got %v", tt.baseURL, tt.valid, err)
+		}
+	}
+}
+
+func TestSignedRequests(t *testing.T) {
+	c := newTestClient(t, testSigningKey, signingServer)
+	ctx := context.Background()
+
+	images := []Image{
+		c.Placeholder(120, 60, PlaceholderOptions{Text: "Hi there", Background: "ff5733", Format: FormatPNG}),
+		// Names that escape: spaces, a plus, and a slash
+		c.Avatar("José Ñuñez+1/2", AvatarOptions{Size: 64, Rounded: true}),
+		c.Text("npm install", TextOptions{Font: "mono"}),
+		c.Image("/calendar/2026-03.png", nil),
+	}
+	for _, img := range images {
+		if _, contentType, err := img.Fetch(ctx); err != nil || contentType == "" {
+			t.Errorf("%s: expected a signed image, got %q, %v", img.URL(), contentType, err)
+		}
+	}
+
+	// The server rejects URLs signed with another key
+	unsigned, _ := New(c.base.String(), "wrong-key")
+	if _, _, err := unsigned.Placeholder(120, 60, PlaceholderOptions{}).Fetch(ctx); !IsCode(err, "invalid_signature") {
+		t.Fatalf("expected invalid_signature, got %v", err)
+	}
+	expired := c.Placeholder(120, 60, PlaceholderOptions{}).WithExpiry(time.Now().Add(-time.Minute))
+	if _, _, err := expired.Fetch(ctx); !IsCode(err, "link_expired") {
+		t.Fatalf("expected link_expired, got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := newTestClient(t, "", nil)
+	ctx := context.Background()
+
+	// An image endpoint's error has its code in a header
+	_, _, err := c.Placeholder(100, 100, PlaceholderOptions{Format: FormatSVG, Effect: "halftone"}).Fetch(ctx)
+	var e *Error
+	if !errors.As(err, &e) || e.Code != "unsupported_format" || e.Status != http.StatusBadRequest {
+		t.Fatalf("expected a 400 unsupported_format, got %v", err)
+	}
+	// A JSON API's error has its message in the body
+	if _, err := c.Job(ctx, "missing"); !IsCode(err, "not_found") || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected a 404 not_found, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is an image output format.
+type Format string
+
+const (
+	// FormatDefault leaves the format to the endpoint, SVG for most
+	FormatDefault Format = ""
+	FormatSVG     Format = "svg"
+	FormatPNG     Format = "png"
+	FormatJPG     Format = "jpg"
+	FormatGIF     Format = "gif"
+	FormatWebP    Format = "webp"
+)
+
+// ext returns the path extension that selects the format.
+func (f Format) ext() string {
+	if f == FormatDefault {
+		return ""
+	}
+	return "." + string(f)
+}
+
+// Image is an image on the server: an endpoint's path and its parameters.
+// Images are values; the With methods return changed copies.
+type Image struct {
+	c *Client
+	// path is escaped, as it's sent
+	path  string
+	query url.Values
+}
+
+// With returns a copy of the image with a parameter set, for the parameters
+// the typed options don't cover, such as quality or scheme. An empty value
+// removes the parameter.
+func (img Image) With(key, value string) Image {
+	query := url.Values{}
+	for k, v := range img.query {
+		query[k] = v
+	}
+	if value == "" {
+		query.Del(key)
+	} else {
+		query.Set(key, value)
+	}
+	img.query = query
+	return img
+}
+
+// WithExpiry returns a copy of the image whose signed URL stops working at t.
+func (img Image) WithExpiry(t time.Time) Image {
+	return img.With("exp", strconv.FormatInt(t.Unix(), 10))
+}
+
+// Path returns the image's path and query, signed when the client signs.
+func (img Image) Path() string {
+	return img.c.target(img.path, img.query)
+}
+
+// URL returns the image's absolute URL, signed when the client signs.
+func (img Image) URL() string {
+	return img.c.resolve(img.path, img.query)
+}
+
+// Fetch renders the image, returning its bytes and content type.
+func (img Image) Fetch(ctx context.Context) ([]byte, string, error) {
+	resp, err := img.c.do(ctx, http.MethodGet, img.path, img.query, nil, http.StatusOK)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// pathText escapes text for a path segment. A plus is escaped as well, as the
+// avatar endpoint reads it as a space.
+func pathText(text string) string {
+	return strings.ReplaceAll(url.PathEscape(text), "+", "%2B")
+}
+
+// params collects the parameters of typed options, leaving out zero values so
+// the server's defaults apply.
+type params url.Values
+
+func (p params) str(key, value string) {
+	if value != "" {
+		url.Values(p).Set(key, value)
+	}
+}
+
+func (p params) num(key string, value int) {
+	if value != 0 {
+		url.Values(p).Set(key, strconv.Itoa(value))
+	}
+}
+
+func (p params) flag(key string, value bool) {
+	if value {
+		url.Values(p).Set(key, "true")
+	}
+}
+
+// Image returns the image at any endpoint path, escaped as in a URL, such as
+// /calendar/2026-03.png, for endpoints without typed options.
+func (c *Client) Image(path string, query url.Values) Image {
+	if query == nil {
+		query = url.Values{}
+	}
+	return Image{c: c, path: path, query: query}
+}
+
+// AvatarOptions are the parameters of an initials avatar. Zero values keep the
+// server's defaults.
+type AvatarOptions struct {
+	Format Format
+	// Size is the width and height in pixels
+	Size int
+	// Background and Color are hex colors; Background can also be "random", a
+	// color derived from the name or UID
+	Background string
+	Color      string
+	Rounded    bool
+	Bold       bool
+	// UID seeds random colors in place of the name, so renames keep them
+	UID string
+	// Pattern, Style, and Effect name a background pattern, an avatar style
+	// such as "discord", and an effect such as "confetti"
+	Pattern string
+	Style   string
+	Effect  string
+}
+
+// Avatar returns the initials avatar of name.
+func (c *Client) Avatar(name string, opts AvatarOptions) Image {
+	p := params{}
+	p.num("size", opts.Size)
+	p.str("bg", opts.Background)
+	p.str("color", opts.Color)
+	p.flag("rounded", opts.Rounded)
+	p.flag("bold", opts.Bold)
+	p.str("uid", opts.UID)
+	p.str("pattern", opts.Pattern)
+	p.str("style", opts.Style)
+	p.str("effect", opts.Effect)
+	return c.Image("/avatar/"+pathText(name)+opts.Format.ext(), url.Values(p))
+}
+
+// PlaceholderOptions are the parameters of a placeholder. Zero values keep the
+// server's defaults.
+type PlaceholderOptions struct {
+	Format Format
+	// Text defaults to the dimensions
+	Text string
+	// Background is a hex color, or a comma-separated list for a gradient
+	Background string
+	Color      string
+	// Quote and Joke replace the text with a random quote or joke, from
+	// Category when it's set
+	Quote    bool
+	Joke     bool
+	Category string
+	// Pattern, Style, Effect, and Animate name a background pattern, "art" for
+	// generative art, an effect, and an animation; Seed seeds them
+	Pattern string
+	Style   string
+	Effect  string
+	Animate string
+	Seed    string
+}
+
+// Placeholder returns a placeholder of width x height pixels.
+func (c *Client) Placeholder(width, height int, opts PlaceholderOptions) Image {
+	p := params{}
+	p.str("text", opts.Text)
+	p.str("bg", opts.Background)
+	p.str("color", opts.Color)
+	p.flag("quote", opts.Quote)
+	p.flag("joke", opts.Joke)
+	p.str("category", opts.Category)
+	p.str("pattern", opts.Pattern)
+	p.str("style", opts.Style)
+	p.str("effect", opts.Effect)
+	p.str("animate", opts.Animate)
+	p.str("seed", opts.Seed)
+	return c.Image(fmt.Sprintf("/placeholder/%dx%d%s", width, height, opts.Format.ext()), url.Values(p))
+}
+
+// TextOptions are the parameters of a text image. Zero values keep the
+// server's defaults.
+type TextOptions struct {
+	Format Format
+	// Font is one of the embedded fonts, such as "bold" or "mono"
+	Font string
+	// Size is the text height in pixels
+	Size  int
+	Color string
+}
+
+// Text returns text on a transparent canvas sized to fit it.
+func (c *Client) Text(text string, opts TextOptions) Image {
+	p := params{}
+	p.str("font", opts.Font)
+	p.num("size", opts.Size)
+	p.str("color", opts.Color)
+	return c.Image("/text/"+pathText(text)+opts.Format.ext(), url.Values(p))
+}
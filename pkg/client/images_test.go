@@ -0,0 +1,66 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestImageURLs(t *testing.T) {
+	c, err := New("https://img.example.com", "")
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	tests := []struct {
+		name string
+		img  Image
+		want string
+	}{
+		{"placeholder", c.Placeholder(600, 400, PlaceholderOptions{Text: "Hello, world", Background: "ff0000,0000ff", Format: FormatPNG}),
+			"https://img.example.com/placeholder/600x400.png?bg=ff0000%2C0000ff&text=Hello%2C+world"},
+		{"placeholder defaults", c.Placeholder(128, 128, PlaceholderOptions{}), "https://img.example.com/placeholder/128x128"},
+		{"avatar", c.Avatar("Jane Doe", AvatarOptions{Size: 256, Rounded: true, Background: "random", Format: FormatWebP}),
+			"https://img.example.com/avatar/Jane%20Doe.webp?bg=random&rounded=true&size=256"},
+		{"avatar plus", c.Avatar("C++", AvatarOptions{}), "https://img.example.com/avatar/C%2B%2B"},
+		{"text", c.Text("npm install", TextOptions{Font: "mono", Size: 48}), "https://img.example.com/text/npm%20install?font=mono&size=48"},
+		{"any endpoint", c.Image("/rating/4.5.png", url.Values{"max": {"5"}}), "https://img.example.com/rating/4.5.png?max=5"},
+		{"with", c.Placeholder(10, 10, PlaceholderOptions{Text: "x"}).With("quality", "60").With("text", ""),
+			"https://img.example.com/placeholder/10x10?quality=60"},
+		{"expiry", c.Placeholder(10, 10, PlaceholderOptions{}).WithExpiry(time.Unix(1767225600, 0)),
+			"https://img.example.com/placeholder/10x10?exp=1767225600"},
+	}
+	for _, tt := range tests {
+		if got := tt.img.URL(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestImageWithCopies(t *testing.T) {
+	c, _ := New("https://img.example.com", "")
+	base := c.Placeholder(10, 10, PlaceholderOptions{Text: "x"})
+	_ = base.With("bg", "000000")
+	if got := base.Path(); got != "/placeholder/10x10?text=x" {
+		t.Fatalf("expected With to leave the image unchanged, got %s", got)
+	}
+}
+
+func TestImageSignature(t *testing.T) {
+	c, _ := New("http://localhost:8080", "secret")
+	img := c.Placeholder(1200, 630, PlaceholderOptions{Text: "Launch", Background: "ff5733"}).WithExpiry(time.Unix(1767225600, 0))
+	signed, _ := url.Parse(img.URL())
+	query := signed.Query()
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("/placeholder/1200x630?bg=ff5733&exp=1767225600&text=Launch"))
+	if want := hex.EncodeToString(mac.Sum(nil)); query.Get("sig") != want {
+		t.Fatalf("expected sig %s, got %q", want, query.Get("sig"))
+	}
+	// async isn't covered, so it can be added to a signed URL
+	withAsync := c.sign(img.path, url.Values{"async": {"true"}, "text": {"Launch"}, "bg": {"ff5733"}, "exp": {"1767225600"}})
+	if withAsync.Get("sig") != query.Get("sig") || withAsync.Get("async") != "true" {
+		t.Fatalf("expected async to keep the signature, got %v", withAsync)
+	}
+}